@@ -1,13 +1,23 @@
 package api
 
 import (
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/app"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
 )
 
 type AdminSetPasswordData struct {
@@ -47,3 +57,540 @@ func (a *API) handleAdminSetPassword(w http.ResponseWriter, r *http.Request) {
 
 	jsonStringResponse(w, http.StatusOK, "{}")
 }
+
+// handleAdminAnonymizeUser removes the identity of a departing user from its
+// sessions, the authorship of the blocks and the values of the person
+// properties
+func (a *API) handleAdminAnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	err := a.app().AnonymizeUser(username)
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminAnonymizeUser, username: %s", username)
+
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+// handleAdminDeleteUser deletes a user account, reassigning its boards to the
+// user of the reassignTo parameter, or to the configured one
+func (a *API) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	reassignTo := r.URL.Query().Get("reassignTo")
+
+	reassigned, err := a.app().DeleteUser(username, reassignTo)
+	if errors.Is(err, app.ErrNoBoardsOwner) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminDeleteUser, username: %s, boards reassigned: %d", username, reassigned)
+
+	data, err := json.Marshal(map[string]int64{"reassigned": reassigned})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminImportUsers creates the accounts of a provisioning file, sent as
+// a JSON array of records or as CSV with a header row. In strict mode any
+// invalid record or conflict aborts the whole import, in lenient mode (the
+// default) it's reported in the result of that record.
+func (a *API) handleAdminImportUsers(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "strict" && mode != "lenient" {
+		errorResponse(w, http.StatusBadRequest, "mode must be strict or lenient", nil)
+		return
+	}
+
+	var records []app.ImportUserRecord
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		records, err = parseImportUsersCSV(r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&records)
+	}
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid users file", err)
+		return
+	}
+
+	results, err := a.app().ImportUsers(records, mode == "strict")
+	var conflict *store.ErrUserConflict
+	if errors.As(err, &conflict) {
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	}
+	var invalidErr *app.ErrInvalidImportRecord
+	if errors.As(err, &invalidErr) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminImportUsers, records: %d", len(records))
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// parseImportUsersCSV reads the records of a CSV provisioning file, whose
+// header row names the columns
+func parseImportUsersCSV(body io.Reader) ([]app.ImportUserRecord, error) {
+	rows, err := csv.NewReader(body).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("missing header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("missing username column")
+	}
+
+	records := make([]app.ImportUserRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		records = append(records, app.ImportUserRecord{
+			Username:    value("username"),
+			Email:       value("email"),
+			Password:    value("password"),
+			AuthService: value("auth_service"),
+			AuthData:    value("auth_data"),
+		})
+	}
+
+	return records, nil
+}
+
+type AdminBackupResponse struct {
+	Filename string `json:"filename"`
+}
+
+// handleAdminBackup triggers a database backup outside of the backup schedule
+func (a *API) handleAdminBackup(w http.ResponseWriter, r *http.Request) {
+	filename, err := a.app().BackupDatabase()
+	if errors.Is(err, store.ErrNotSupported) {
+		errorResponse(w, http.StatusNotImplemented, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminBackup, filename: %s", filename)
+
+	data, err := json.Marshal(AdminBackupResponse{Filename: filename})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminGetSchemaVersion returns the version of the database schema and
+// the migrations this binary would apply to it
+func (a *API) handleAdminGetSchemaVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := a.app().GetSchemaVersion()
+	if errors.Is(err, store.ErrNotSupported) {
+		errorResponse(w, http.StatusNotImplemented, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(version)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+type AdminCleanUpSessionsResponse struct {
+	Removed int64 `json:"removed"`
+}
+
+// handleAdminCleanUpSessions removes the expired sessions without waiting for
+// the scheduled cleanup
+func (a *API) handleAdminCleanUpSessions(w http.ResponseWriter, r *http.Request) {
+	removed, err := a.app().CleanUpSessions()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminCleanUpSessions, removed: %d", removed)
+
+	data, err := json.Marshal(AdminCleanUpSessionsResponse{Removed: removed})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminCountBlocksByBoard returns the number of blocks of each board of
+// a workspace
+func (a *API) handleAdminCountBlocksByBoard(w http.ResponseWriter, r *http.Request) {
+	container := store.Container{
+		WorkspaceID: mux.Vars(r)["workspaceID"],
+	}
+
+	counts, err := a.app().CountBlocksByBoard(container)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(counts)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminFindDuplicateBoards returns the sets of boards of a workspace
+// with the same content, e.g. created twice by an import, so that the copies
+// can be merged or deleted. It only reads the blocks.
+func (a *API) handleAdminFindDuplicateBoards(w http.ResponseWriter, r *http.Request) {
+	container := store.Container{
+		WorkspaceID: mux.Vars(r)["workspaceID"],
+	}
+
+	duplicates, err := a.app().FindDuplicateBoards(container)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(duplicates)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminGetWorkspaceStorageUsage returns the bytes used by the files of a
+// workspace
+func (a *API) handleAdminGetWorkspaceStorageUsage(w http.ResponseWriter, r *http.Request) {
+	workspaceID := mux.Vars(r)["workspaceID"]
+
+	usage, err := a.app().GetWorkspaceStorageUsage(workspaceID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(map[string]int64{"bytes": usage})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminFindOrphanedFiles lists the files of the files storage that
+// nothing references, without removing them
+func (a *API) handleAdminFindOrphanedFiles(w http.ResponseWriter, r *http.Request) {
+	orphans, err := a.app().FindOrphanedFiles()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(orphans)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("FindOrphanedFiles, found: %d", len(orphans))
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// handleAdminGetBlocksModifiedBy returns the blocks of a workspace a user
+// modified last, optionally since a given time
+func (a *API) handleAdminGetBlocksModifiedBy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	container := store.Container{
+		WorkspaceID: vars["workspaceID"],
+	}
+	query := r.URL.Query()
+
+	var since int64
+	if sinceParam := query.Get("since"); sinceParam != "" {
+		var err error
+		since, err = strconv.ParseInt(sinceParam, 10, 64)
+		if err != nil || since < 0 {
+			errorResponse(w, http.StatusBadRequest, "invalid since", err)
+			return
+		}
+	}
+
+	limit, _ := a.ParsePaging(r)
+
+	blocks, err := a.app().GetBlocksModifiedBy(container, vars["userID"], since, limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleAdminGetActiveSharingTokens(w http.ResponseWriter, r *http.Request) {
+	container := store.Container{
+		WorkspaceID: mux.Vars(r)["workspaceID"],
+	}
+
+	sharings, err := a.app().GetActiveSharingTokens(container)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(sharings)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+// auditExportBatchSize is the number of audit logs read at a time while
+// they're exported
+const auditExportBatchSize = 1000
+
+// handleAdminExportAuditLogs streams the audit logs matching the actor,
+// action, target, since and until parameters, as a JSON array or as CSV with
+// a header row when format is csv.
+func (a *API) handleAdminExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+	}
+
+	for name, value := range map[string]*int64{"since": &filter.Since, "until": &filter.Until} {
+		if param := query.Get(name); param != "" {
+			var err error
+			*value, err = strconv.ParseInt(param, 10, 64)
+			if err != nil || *value < 0 {
+				errorResponse(w, http.StatusBadRequest, "invalid "+name, err)
+				return
+			}
+		}
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		errorResponse(w, http.StatusBadRequest, "format must be json or csv", nil)
+		return
+	}
+
+	// The first batch is read before the response is started, so a store
+	// failure can still get an error response
+	entries, err := a.app().GetAuditLogs(filter, auditExportBatchSize, 0)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	var writeEntry func(entry model.AuditLog) error
+	var finish func() error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"id", "create_at", "actor", "action", "target", "details"})
+		writeEntry = func(entry model.AuditLog) error {
+			return writer.Write([]string{entry.ID, strconv.FormatInt(entry.CreateAt, 10), entry.Actor, entry.Action, entry.Target, entry.Details})
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.json"`)
+		io.WriteString(w, "[")
+		encoder := json.NewEncoder(w)
+		first := true
+		writeEntry = func(entry model.AuditLog) error {
+			if !first {
+				io.WriteString(w, ",")
+			}
+			first = false
+			return encoder.Encode(entry)
+		}
+		finish = func() error {
+			_, err := io.WriteString(w, "]")
+			return err
+		}
+	}
+
+	exported := 0
+	for offset := 0; ; offset += auditExportBatchSize {
+		if offset > 0 {
+			entries, err = a.app().GetAuditLogs(filter, auditExportBatchSize, offset)
+			if err != nil {
+				// The status is already sent, so the export is cut short
+				log.Printf("AdminExportAuditLogs ERROR: %v", err)
+				return
+			}
+		}
+
+		for _, entry := range entries {
+			if err := writeEntry(entry); err != nil {
+				log.Printf("AdminExportAuditLogs ERROR: %v", err)
+				return
+			}
+		}
+		exported += len(entries)
+
+		if len(entries) < auditExportBatchSize {
+			break
+		}
+	}
+
+	if err := finish(); err != nil {
+		log.Printf("AdminExportAuditLogs ERROR: %v", err)
+		return
+	}
+
+	log.Printf("AdminExportAuditLogs, format: %s, exported: %d", format, exported)
+}
+
+// handleAdminExportWorkspace streams the bundle of a workspace, with its
+// settings, boards and files, as a zip attachment
+func (a *API) handleAdminExportWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspaceID := mux.Vars(r)["workspaceID"]
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="workspace-%s.zip"`, workspaceID))
+
+	if err := a.app().ExportWorkspace(workspaceID, w); err != nil {
+		// The status is already sent, so the bundle is left incomplete
+		log.Printf("AdminExportWorkspace ERROR: %v", err)
+		return
+	}
+
+	log.Printf("AdminExportWorkspace, workspaceID: %s", workspaceID)
+}
+
+// defaultMaxImportSize is used when the API has no maximum import size set
+const defaultMaxImportSize = 1 << 30
+
+// handleAdminImportWorkspace restores a bundle written by the workspace
+// export into a workspace. The bundle is spooled to a temporary file, as the
+// zip entries are read out of order, up to the maximum import size.
+func (a *API) handleAdminImportWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspaceID := mux.Vars(r)["workspaceID"]
+
+	maxSize := a.MaxImportSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxImportSize
+	}
+
+	spool, err := ioutil.TempFile("", "focalboard-workspace-import")
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil && size >= maxSize {
+		errorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the bundle is over the maximum import size of %d bytes", maxSize), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "unable to read the bundle", err)
+		return
+	}
+
+	err = a.app().ImportWorkspace(workspaceID, spool, size, "system")
+	var rejectedErr *app.ErrContentRejected
+	var invalidErr *app.ErrInvalidProperties
+	switch {
+	case errors.As(err, &rejectedErr):
+		errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+		return
+	case errors.As(err, &invalidErr):
+		invalidPropertiesResponse(w, invalidErr)
+		return
+	case errors.Is(err, app.ErrBoardLimitReached):
+		errorResponse(w, http.StatusForbidden, err.Error(), err)
+		return
+	case errors.Is(err, app.ErrBoardLocked):
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	case errors.Is(err, app.ErrInvalidWorkspaceBundle):
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	case err != nil:
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("AdminImportWorkspace, workspaceID: %s, size: %d", workspaceID, size)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/app"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestAPI(t *testing.T, cfg *config.Configuration) (*API, *mockstore.MockStore) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(cfg, nil)
+	appBuilder := func() *app.App {
+		return app.New(cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+	}
+
+	return NewAPI(appBuilder, "", "native"), store
+}
+
+func TestHandleAdminCleanUpSessions(t *testing.T) {
+	cfg := &config.Configuration{SessionExpireTime: 60 * 60 * 24 * 60}
+	api, store := setupTestAPI(t, cfg)
+
+	store.EXPECT().GetExpiredSessionIDs(cfg.SessionExpireTime).Return([]string{}, nil)
+	store.EXPECT().CleanUpSessions(cfg.SessionExpireTime).Return(int64(3), nil)
+	store.EXPECT().DeleteSessionsForDeletedUsers().Return(int64(1), nil)
+
+	recorder := httptest.NewRecorder()
+	api.handleAdminCleanUpSessions(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/admin/sessions/cleanup", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response AdminCleanUpSessionsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.EqualValues(t, 4, response.Removed)
+}
+
+func TestHandleAdminDeleteUser(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{DeletedUserBoardsOwner: "admin"})
+
+	deleteUser := func(username, reassignTo string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/"+username+"?reassignTo="+reassignTo, nil)
+		request = mux.SetURLVars(request, map[string]string{"username": username})
+
+		recorder := httptest.NewRecorder()
+		api.handleAdminDeleteUser(recorder, request)
+		return recorder
+	}
+
+	t.Run("boards reassigned to the configured owner", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("jane").Return(&model.User{ID: "user-id", Username: "jane"}, nil)
+		store.EXPECT().GetUserByUsername("admin").Return(&model.User{ID: "admin-id", Username: "admin"}, nil)
+		store.EXPECT().GetUserSessionIDs("user-id").Return([]string{}, nil)
+		store.EXPECT().DeleteUserReassigningBoards("user-id", "admin-id").Return(int64(3), nil)
+		store.EXPECT().CreateAuditLog(gomock.Any()).Return(nil)
+
+		recorder := deleteUser("jane", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, `{"reassigned":3}`, recorder.Body.String())
+	})
+
+	t.Run("boards reassigned to the requested owner", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("jane").Return(&model.User{ID: "user-id", Username: "jane"}, nil)
+		store.EXPECT().GetUserByUsername("alice").Return(&model.User{ID: "alice-id", Username: "alice"}, nil)
+		store.EXPECT().GetUserSessionIDs("user-id").Return([]string{}, nil)
+		store.EXPECT().DeleteUserReassigningBoards("user-id", "alice-id").Return(int64(0), nil)
+		store.EXPECT().CreateAuditLog(gomock.Any()).Return(nil)
+
+		require.Equal(t, http.StatusOK, deleteUser("jane", "alice").Code)
+	})
+
+	t.Run("the user can't own its own boards", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("admin").Return(&model.User{ID: "admin-id", Username: "admin"}, nil).Times(2)
+
+		require.Equal(t, http.StatusBadRequest, deleteUser("admin", "").Code)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("john").Return(nil, sql.ErrNoRows)
+
+		require.Equal(t, http.StatusNotFound, deleteUser("john", "").Code)
+	})
+}
+
+func TestHandleAdminAnonymizeUser(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+
+	anonymize := func(username string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+username+"/anonymize", nil)
+		request = mux.SetURLVars(request, map[string]string{"username": username})
+
+		recorder := httptest.NewRecorder()
+		api.handleAdminAnonymizeUser(recorder, request)
+		return recorder
+	}
+
+	t.Run("existing user", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("jane").Return(&model.User{ID: "user-id", Username: "jane"}, nil)
+		store.EXPECT().GetUserSessionIDs("user-id").Return([]string{}, nil)
+		store.EXPECT().AnonymizeUser("user-id").Return(nil)
+		store.EXPECT().CreateAuditLog(gomock.Any()).Return(nil)
+
+		require.Equal(t, http.StatusOK, anonymize("jane").Code)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("john").Return(nil, sql.ErrNoRows)
+
+		require.Equal(t, http.StatusNotFound, anonymize("john").Code)
+	})
+}
+
+func TestHandleAdminExportAuditLogs(t *testing.T) {
+	api, mockStore := setupTestAPI(t, &config.Configuration{})
+
+	entries := []model.AuditLog{
+		{ID: "audit-1", CreateAt: 100, Actor: "user-1", Action: "deleteUser", Target: "user-3"},
+		{ID: "audit-2", CreateAt: 200, Actor: "user-1", Action: "setPassword", Target: "user-3", Details: "reset, by an admin"},
+	}
+	export := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		api.handleAdminExportAuditLogs(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/export"+query, nil))
+		return recorder
+	}
+
+	t.Run("json", func(t *testing.T) {
+		filter := store.AuditFilter{Actor: "user-1", Since: 50, Until: 500}
+		mockStore.EXPECT().GetAuditLogs(filter, auditExportBatchSize, 0).Return(entries, nil)
+
+		recorder := export("?actor=user-1&since=50&until=500")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+		var exported []model.AuditLog
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &exported))
+		require.Equal(t, entries, exported)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		filter := store.AuditFilter{Action: "setPassword", Target: "user-3"}
+		mockStore.EXPECT().GetAuditLogs(filter, auditExportBatchSize, 0).Return(entries[1:], nil)
+
+		recorder := export("?format=csv&action=setPassword&target=user-3")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+		require.Equal(t, "id,create_at,actor,action,target,details\naudit-2,200,user-1,setPassword,user-3,\"reset, by an admin\"\n", recorder.Body.String())
+	})
+
+	t.Run("every batch is exported", func(t *testing.T) {
+		batch := make([]model.AuditLog, auditExportBatchSize)
+		for i := range batch {
+			batch[i] = model.AuditLog{ID: fmt.Sprintf("audit-%d", i)}
+		}
+		gomock.InOrder(
+			mockStore.EXPECT().GetAuditLogs(store.AuditFilter{}, auditExportBatchSize, 0).Return(batch, nil),
+			mockStore.EXPECT().GetAuditLogs(store.AuditFilter{}, auditExportBatchSize, auditExportBatchSize).Return(entries, nil),
+		)
+
+		var exported []model.AuditLog
+		require.NoError(t, json.Unmarshal(export("").Body.Bytes(), &exported))
+		require.Len(t, exported, auditExportBatchSize+len(entries))
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, export("?format=xml").Code)
+		require.Equal(t, http.StatusBadRequest, export("?since=yesterday").Code)
+	})
+}
+
+func TestHandleAdminImportWorkspace(t *testing.T) {
+	api, _ := setupTestAPI(t, &config.Configuration{})
+	api.MaxImportSize = 16
+
+	importBundle := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/workspaces/workspace-1/import", strings.NewReader(body))
+		request = mux.SetURLVars(request, map[string]string{"workspaceID": "workspace-1"})
+
+		recorder := httptest.NewRecorder()
+		api.handleAdminImportWorkspace(recorder, request)
+		return recorder
+	}
+
+	t.Run("a bundle over the maximum import size", func(t *testing.T) {
+		require.Equal(t, http.StatusRequestEntityTooLarge, importBundle(strings.Repeat("x", 17)).Code)
+	})
+
+	t.Run("a bundle that isn't a zip", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, importBundle("not a zip").Code)
+	})
+}
+
+func TestHandleAdminImportUsers(t *testing.T) {
+	api, mockStore := setupTestAPI(t, &config.Configuration{})
+
+	importUsers := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import?mode=strict", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		api.handleAdminImportUsers(recorder, request)
+		return recorder
+	}
+	records := `[{"username": "john", "email": "john@example.com", "password": "Password1234!"}]`
+
+	t.Run("an invalid record is a bad request", func(t *testing.T) {
+		recorder := importUsers(`[{"email": "john@example.com"}]`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("a conflict", func(t *testing.T) {
+		mockStore.EXPECT().CreateUsers(gomock.Any()).Return(&store.ErrUserConflict{Field: "username", Value: "john"})
+
+		recorder := importUsers(records)
+		require.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	t.Run("a store failure is an internal error", func(t *testing.T) {
+		mockStore.EXPECT().CreateUsers(gomock.Any()).Return(sql.ErrConnDone)
+
+		recorder := importUsers(records)
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mattermost/focalboard/server/app"
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/auth"
 	"github.com/mattermost/focalboard/server/services/store"
 	"github.com/mattermost/focalboard/server/utils"
 )
@@ -39,10 +41,17 @@ type WorkspaceAuthenticator interface {
 }
 
 type API struct {
-	appBuilder             func() *app.App
-	authService            string
-	singleUserToken        string
-	WorkspaceAuthenticator WorkspaceAuthenticator
+	appBuilder              func() *app.App
+	authService             string
+	singleUserToken         string
+	WorkspaceAuthenticator  WorkspaceAuthenticator
+	HeaderAuthenticator     *auth.HeaderAuth
+	ResponseCache           *ResponseCache
+	DefaultPageSize         int
+	MaxPageSize             int
+	MaxDecompressedBodySize int64
+	MaxBulkBatchSize        int
+	MaxImportSize           int64
 }
 
 func NewAPI(appBuilder func() *app.App, singleUserToken string, authService string) *API {
@@ -57,25 +66,58 @@ func (a *API) app() *app.App {
 	return a.appBuilder()
 }
 
+// requestApp returns an app that passes the ID of the request on to the
+// webhooks it triggers
+func (a *API) requestApp(r *http.Request) *app.App {
+	return a.appBuilder().WithRequestID(utils.RequestIDFromContext(r.Context()))
+}
+
 func (a *API) RegisterRoutes(r *mux.Router) {
 	apiv1 := r.PathPrefix("/api/v1").Subrouter()
 	apiv1.Use(a.requireCSRFToken)
+	apiv1.Use(a.decompressRequestBody)
 
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks", a.sessionRequired(a.handleGetBlocks)).Methods("GET")                         //某个工作空间的块？
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks", a.sessionRequired(a.handlePostBlocks)).Methods("POST")                       //更新或者新增某个工作空间的块
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks/{blockID}", a.sessionRequired(a.handleDeleteBlock)).Methods("DELETE")          //删除某个工作空间的块
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks/{blockID}/subtree", a.attachSession(a.handleGetSubTree, false)).Methods("GET") //获取某个块的订阅树
+	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks", a.sessionRequired(a.handlePatchBlocks)).Methods("PATCH")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks/{targetID}/merge/{sourceID}", a.sessionRequired(a.handleMergeBlocks)).Methods("POST")
 
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks/export", a.sessionRequired(a.handleExport)).Methods("GET")  //导出
 	apiv1.HandleFunc("/workspaces/{workspaceID}/blocks/import", a.sessionRequired(a.handleImport)).Methods("POST") //导入
 
+	apiv1.HandleFunc("/workspaces/{workspaceID}/my-cards", a.sessionRequired(a.handleGetMyCards)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/cards/{cardID}/comments", a.sessionRequired(a.handleGetComments)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/cards/{cardID}/copy", a.sessionRequired(a.handleCopyCard)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards", a.sessionRequired(a.handleCreateBoard)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/metadata", a.sessionRequired(a.cached(a.handleGetBoardsMetadata))).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/blocks", a.sessionRequired(a.handleGetBlocksByProperty)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/aggregates", a.sessionRequired(a.cached(a.handleGetBoardAggregates))).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/aggregates/dates", a.sessionRequired(a.cached(a.handleGroupCardsByDate))).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/archive", a.sessionRequired(a.handleArchiveBoard)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/unarchive", a.sessionRequired(a.handleUnarchiveBoard)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/snapshots", a.sessionRequired(a.handlePostBoardSnapshot)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/snapshots", a.sessionRequired(a.handleGetBoardSnapshots)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/activity", a.sessionRequired(a.handleGetBoardActivity)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/boards/{boardID}/checksum", a.sessionRequired(a.handleGetBoardChecksum)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/snapshots/{snapshotID}", a.sessionRequired(a.handleGetBoardSnapshot)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/trash", a.sessionRequired(a.handleGetDeletedBoards)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/trash/{boardID}/restore", a.sessionRequired(a.handleRestoreBoard)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/trash/{boardID}", a.sessionRequired(a.handleDeleteBoardPermanently)).Methods("DELETE")
+
 	apiv1.HandleFunc("/workspaces/{workspaceID}/sharing/{rootID}", a.sessionRequired(a.handlePostSharing)).Methods("POST")
 	apiv1.HandleFunc("/workspaces/{workspaceID}/sharing/{rootID}", a.sessionRequired(a.handleGetSharing)).Methods("GET")
 
-	apiv1.HandleFunc("/workspaces/{workspaceID}", a.sessionRequired(a.handleGetWorkspace)).Methods("GET") //某个工作空间的
+	apiv1.HandleFunc("/workspaces", a.sessionRequired(a.handleGetWorkspaces)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}", a.sessionRequired(a.cached(a.handleGetWorkspace))).Methods("GET") //某个工作空间的
 	apiv1.HandleFunc("/workspaces/{workspaceID}/regenerate_signup_token", a.sessionRequired(a.handlePostWorkspaceRegenerateSignupToken)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/webhooks", a.sessionRequired(a.handleGetWorkspaceWebhooks)).Methods("GET")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/webhooks", a.sessionRequired(a.handlePostWorkspaceWebhook)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/webhooks/{webhookID}", a.sessionRequired(a.handleDeleteWorkspaceWebhook)).Methods("DELETE")
 
 	// User APIs
+	apiv1.HandleFunc("/users", a.sessionRequired(a.handleGetUsersByIDs)).Methods("GET")
 	apiv1.HandleFunc("/users/me", a.sessionRequired(a.handleGetMe)).Methods("GET")
 	apiv1.HandleFunc("/users/{userID}", a.sessionRequired(a.handleGetUser)).Methods("GET")
 	apiv1.HandleFunc("/users/{userID}/changepassword", a.sessionRequired(a.handleChangePassword)).Methods("POST")
@@ -84,6 +126,7 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 	apiv1.HandleFunc("/register", a.handleRegister).Methods("POST")
 
 	apiv1.HandleFunc("/workspaces/{workspaceID}/{rootID}/files", a.sessionRequired(a.handleUploadFile)).Methods("POST")
+	apiv1.HandleFunc("/workspaces/{workspaceID}/{rootID}/files/{filename}", a.sessionRequired(a.handleDeleteFile)).Methods("DELETE")
 
 	// Get Files API
 
@@ -93,6 +136,23 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 
 func (a *API) RegisterAdminRoutes(r *mux.Router) {
 	r.HandleFunc("/api/v1/admin/users/{username}/password", a.adminRequired(a.handleAdminSetPassword)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/users/{username}", a.adminRequired(a.handleAdminDeleteUser)).Methods("DELETE")
+	r.HandleFunc("/api/v1/admin/users/{username}/anonymize", a.adminRequired(a.handleAdminAnonymizeUser)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/users/import", a.adminRequired(a.handleAdminImportUsers)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/audit/export", a.adminRequired(a.handleAdminExportAuditLogs)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/backup", a.adminRequired(a.handleAdminBackup)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/schema/version", a.adminRequired(a.handleAdminGetSchemaVersion)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/sessions/cleanup", a.adminRequired(a.handleAdminCleanUpSessions)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/files/orphaned", a.adminRequired(a.handleAdminFindOrphanedFiles)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/boards/counts", a.adminRequired(a.cached(a.handleAdminCountBlocksByBoard))).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/boards/duplicates", a.adminRequired(a.handleAdminFindDuplicateBoards)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/storage", a.adminRequired(a.handleAdminGetWorkspaceStorageUsage)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/export", a.adminRequired(a.handleAdminExportWorkspace)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/import", a.adminRequired(a.handleAdminImportWorkspace)).Methods("POST")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/users/{userID}/blocks", a.adminRequired(a.handleAdminGetBlocksModifiedBy)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/sharing", a.adminRequired(a.handleAdminGetActiveSharingTokens)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/webhooks", a.adminRequired(a.handleAdminGetWorkspaceWebhooks)).Methods("GET")
+	r.HandleFunc("/api/v1/admin/workspaces/{workspaceID}/webhooks/{webhookID}", a.adminRequired(a.handleAdminDeleteWorkspaceWebhook)).Methods("DELETE")
 }
 
 func (a *API) requireCSRFToken(next http.Handler) http.Handler {
@@ -206,6 +266,16 @@ func (a *API) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
 	//   description: Type of blocks to return, omit to specify all types
 	//   required: false
 	//   type: string
+	// - name: sort_by
+	//   in: query
+	//   description: ID of the property to sort the blocks by, requires parent_id and type
+	//   required: false
+	//   type: string
+	// - name: sort_dir
+	//   in: query
+	//   description: Sort direction, asc (default) or desc
+	//   required: false
+	//   type: string
 	// security:
 	// - BearerAuth: []
 	// responses:
@@ -223,13 +293,30 @@ func (a *API) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	parentID := query.Get("parent_id")
 	blockType := query.Get("type")
+	sortBy := query.Get("sort_by")
+	sortDir := query.Get("sort_dir")
 	container, err := a.getContainer(r)
 	if err != nil {
 		noContainerErrorResponse(w, err)
 		return
 	}
 
-	blocks, err := a.app().GetBlocks(*container, parentID, blockType)
+	var blocks []model.Block
+	if sortBy != "" {
+		if parentID == "" || blockType == "" {
+			errorResponse(w, http.StatusBadRequest, "sort_by requires parent_id and type", nil)
+			return
+		}
+		if sortDir != "" && sortDir != "asc" && sortDir != "desc" {
+			errorResponse(w, http.StatusBadRequest, "invalid sort_dir", nil)
+			return
+		}
+
+		sort := store.BlockSort{PropertyID: sortBy, Descending: sortDir == "desc"}
+		blocks, err = a.app().GetSortedBlocks(*container, parentID, blockType, sort)
+	} else {
+		blocks, err = a.app().GetBlocks(*container, parentID, blockType)
+	}
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
@@ -286,6 +373,18 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 	// responses:
 	//   '200':
 	//     description: success
+	//   '403':
+	//     description: the workspace has reached the maximum number of boards
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: a board of the blocks is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '422':
+	//     description: the content of a block was rejected by the moderation, or the values of its properties are invalid
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -297,15 +396,11 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	requestBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "", err)
+	blocks, err := a.decodeBlocks(r.Body)
+	if errors.Is(err, errBatchTooLarge) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
-
-	var blocks []model.Block
-
-	err = json.Unmarshal(requestBody, &blocks)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
@@ -334,7 +429,25 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 
 	stampModifiedByUser(r, blocks)
 
-	err = a.app().InsertBlocks(*container, blocks)
+	err = a.requestApp(r).InsertBlocks(*container, blocks)
+	var rejectedErr *app.ErrContentRejected
+	if errors.As(err, &rejectedErr) {
+		errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+		return
+	}
+	var invalidErr *app.ErrInvalidProperties
+	if errors.As(err, &invalidErr) {
+		invalidPropertiesResponse(w, invalidErr)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLimitReached) {
+		errorResponse(w, http.StatusForbidden, err.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLocked) {
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	}
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
@@ -344,65 +457,161 @@ func (a *API) handlePostBlocks(w http.ResponseWriter, r *http.Request) {
 	jsonStringResponse(w, http.StatusOK, "{}")
 }
 
-func (a *API) handleGetUser(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /api/v1/users/{userID} getUser
+func (a *API) handlePatchBlocks(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation PATCH /api/v1/workspaces/{workspaceID}/blocks patchBlocks
 	//
-	// Returns a user
+	// Partially updates a batch of blocks in a single transaction
 	//
 	// ---
 	// produces:
 	// - application/json
 	// parameters:
-	// - name: userID
+	// - name: workspaceID
 	//   in: path
-	//   description: User ID
+	//   description: Workspace ID
 	//   required: true
 	//   type: string
+	// - name: Body
+	//   in: body
+	//   description: array of block patches to apply
+	//   required: true
+	//   schema:
+	//     type: array
+	//     items:
+	//       "$ref": "#/definitions/BlockPatch"
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//   '404':
+	//     description: one or more blocks not found, none were modified
 	//     schema:
-	//       "$ref": "#/definitions/User"
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: a board of the blocks is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '422':
+	//     description: the content of a block was rejected by the moderation, or the values of its properties are invalid
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
 	//       "$ref": "#/definitions/ErrorResponse"
 
-	vars := mux.Vars(r)
-	userID := vars["userID"]
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
 
-	user, err := a.app().GetUser(userID)
+	container, err := a.getContainer(r)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "", err)
+		noContainerErrorResponse(w, err)
 		return
 	}
 
-	userData, err := json.Marshal(user)
+	var patches []model.BlockPatch
+	err = decodeJSONArray(r.Body, a.MaxBulkBatchSize, func(decoder *json.Decoder) error {
+		var patch model.BlockPatch
+		if err := decoder.Decode(&patch); err != nil {
+			return err
+		}
+		patches = append(patches, patch)
+		return nil
+	})
+	if errors.Is(err, errBatchTooLarge) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	for _, patch := range patches {
+		if len(patch.ID) < 1 {
+			errorResponse(w, http.StatusBadRequest, "missing id for block patch", nil)
+			return
+		}
+	}
+
+	err = a.requestApp(r).PatchBlocks(*container, patches, userID)
 	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+		var rejectedErr *app.ErrContentRejected
+		if errors.As(err, &rejectedErr) {
+			errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+			return
+		}
+		var invalidErr *app.ErrInvalidProperties
+		if errors.As(err, &invalidErr) {
+			invalidPropertiesResponse(w, invalidErr)
+			return
+		}
+		if errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
+			return
+		}
+
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	jsonBytesResponse(w, http.StatusOK, userData)
+	log.Printf("PATCH Blocks %d block(s)", len(patches))
+	jsonStringResponse(w, http.StatusOK, "{}")
 }
 
-func (a *API) handleGetMe(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /api/v1/users/me getMe
+func (a *API) handleMergeBlocks(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/blocks/{targetID}/merge/{sourceID} mergeBlocks
 	//
-	// Returns the currently logged-in user
+	// Merges a duplicated card into another one, moving its content and
+	// comments and deleting it
 	//
 	// ---
 	// produces:
 	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: targetID
+	//   in: path
+	//   description: ID of the block to keep
+	//   required: true
+	//   type: string
+	// - name: sourceID
+	//   in: path
+	//   description: ID of the block to merge and delete
+	//   required: true
+	//   type: string
+	// - name: strategy
+	//   in: query
+	//   description: Which block keeps its value when both have the same property, prefer-target (default) or prefer-source
+	//   required: false
+	//   type: string
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//   '404':
+	//     description: block not found
 	//     schema:
-	//       "$ref": "#/definitions/User"
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: a board of the blocks is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -410,39 +619,71 @@ func (a *API) handleGetMe(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	session := ctx.Value("session").(*model.Session)
-	var user *model.User
-	var err error
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
 
-	if session.UserID == "single-user" {
-		now := time.Now().Unix()
-		user = &model.User{
-			ID:       "single-user",
-			Username: "single-user",
-			Email:    "single-user",
-			CreateAt: now,
-			UpdateAt: now,
+	vars := mux.Vars(r)
+	targetID := vars["targetID"]
+	sourceID := vars["sourceID"]
+
+	strategy := store.MergePreferTarget
+	if value := r.URL.Query().Get("strategy"); value != "" {
+		strategy = store.MergeStrategy(value)
+	}
+	if strategy != store.MergePreferTarget && strategy != store.MergePreferSource {
+		errorResponse(w, http.StatusBadRequest, "invalid strategy", nil)
+		return
+	}
+	if targetID == sourceID {
+		errorResponse(w, http.StatusBadRequest, "a block can't be merged into itself", nil)
+		return
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.requestApp(r).MergeBlocks(*container, targetID, sourceID, strategy, userID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
 		}
-	} else {
-		user, err = a.app().GetUser(session.UserID)
-		if err != nil {
-			errorResponse(w, http.StatusInternalServerError, "", err)
+		if errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
 			return
 		}
-	}
 
-	userData, err := json.Marshal(user)
-	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	jsonBytesResponse(w, http.StatusOK, userData)
+	log.Printf("MERGE Block %s into %s", sourceID, targetID)
+	jsonStringResponse(w, http.StatusOK, "{}")
 }
 
-func (a *API) handleDeleteBlock(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation DELETE /api/v1/workspaces/{workspaceID}/blocks/{blockID} deleteBlock
+// CreateBoardRequest creates a board with its views
+// swagger:model
+type CreateBoardRequest struct {
+	// The board to create
+	// required: true
+	Board model.Block `json:"board"`
+
+	// The views of the board, a board view if none is given
+	// required: false
+	Views []model.Block `json:"views"`
+}
+
+func (a *API) handleCreateBoard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/boards createBoard
 	//
-	// Deletes a block
+	// Creates a board with its views in a single transaction, with a board
+	// view if none is given
 	//
 	// ---
 	// produces:
@@ -453,49 +694,109 @@ func (a *API) handleDeleteBlock(w http.ResponseWriter, r *http.Request) {
 	//   description: Workspace ID
 	//   required: true
 	//   type: string
-	// - name: blockID
-	//   in: path
-	//   description: ID of block to delete
+	// - name: Body
+	//   in: body
+	//   description: the board to create with its views
 	//   required: true
-	//   type: string
+	//   schema:
+	//     "$ref": "#/definitions/CreateBoardRequest"
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   '400':
+	//     description: the block to create isn't a board
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '403':
+	//     description: the workspace has reached the maximum number of boards
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: the boards of the workspace are locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '422':
+	//     description: the content of a block was rejected by the moderation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
 	//       "$ref": "#/definitions/ErrorResponse"
 
-	ctx := r.Context()
-	session := ctx.Value("session").(*model.Session)
-	userID := session.UserID
-
-	vars := mux.Vars(r)
-	blockID := vars["blockID"]
-
 	container, err := a.getContainer(r)
 	if err != nil {
 		noContainerErrorResponse(w, err)
 		return
 	}
 
-	err = a.app().DeleteBlock(*container, blockID, userID)
+	requestBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
 
+	var request CreateBoardRequest
+	if err = json.Unmarshal(requestBody, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "", err)
 		return
 	}
 
-	log.Printf("DELETE Block %s", blockID)
-	jsonStringResponse(w, http.StatusOK, "{}")
+	blocks := append([]model.Block{request.Board}, request.Views...)
+	stampModifiedByUser(r, blocks)
+
+	blocks, err = a.requestApp(r).CreateBoard(*container, blocks[0], blocks[1:])
+	if errors.Is(err, app.ErrNotABoard) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	var rejectedErr *app.ErrContentRejected
+	if errors.As(err, &rejectedErr) {
+		errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLimitReached) {
+		errorResponse(w, http.StatusForbidden, err.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLocked) {
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("CREATE Board %s with %d view(s)", blocks[0].ID, len(blocks)-1)
+	jsonBytesResponse(w, http.StatusOK, data)
 }
 
-func (a *API) handleGetSubTree(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /api/v1/workspaces/{workspaceID}/blocks/{blockID}/subtree getSubTree
+// CopyCardRequest copies a card into a board
+// swagger:model
+type CopyCardRequest struct {
+	// ID of the board the card is copied into
+	// required: true
+	TargetBoardID string `json:"targetBoardId"`
+}
+
+func (a *API) handleCopyCard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/cards/{cardID}/copy copyCard
 	//
-	// Returns the blocks of a subtree
+	// Copies a card with its content into a board, mapping its properties
+	// onto the schema of the board
 	//
 	// ---
 	// produces:
@@ -506,73 +807,1558 @@ func (a *API) handleGetSubTree(w http.ResponseWriter, r *http.Request) {
 	//   description: Workspace ID
 	//   required: true
 	//   type: string
-	// - name: blockID
+	// - name: cardID
 	//   in: path
-	//   description: The ID of the root block of the subtree
+	//   description: ID of the card to copy
+	//   required: true
+	//   type: string
+	// - name: Body
+	//   in: body
+	//   description: board to copy the card into
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CopyCardRequest"
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/Block"
+	//   '400':
+	//     description: the target board is missing
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '404':
+	//     description: card or board not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: the board is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '422':
+	//     description: the content of the card was rejected by the moderation, or the values of its properties are invalid
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
+
+	cardID := mux.Vars(r)["cardID"]
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	var request CopyCardRequest
+	if err = json.Unmarshal(requestBody, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "", err)
+		return
+	}
+	if request.TargetBoardID == "" {
+		errorResponse(w, http.StatusBadRequest, "targetBoardId is required", nil)
+		return
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	card, err := a.requestApp(r).CopyCardToBoard(*container, cardID, request.TargetBoardID, userID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+		if errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
+			return
+		}
+		var rejectedErr *app.ErrContentRejected
+		if errors.As(err, &rejectedErr) {
+			errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+			return
+		}
+		var invalidErr *app.ErrInvalidProperties
+		if errors.As(err, &invalidErr) {
+			invalidPropertiesResponse(w, invalidErr)
+			return
+		}
+
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(card)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("COPY Card %s into %s as %s", cardID, request.TargetBoardID, card.ID)
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/users/{userID} getUser
+	//
+	// Returns a user
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: userID
+	//   in: path
+	//   description: User ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/User"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+
+	user, err := a.app().GetUser(userID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	userData, err := json.Marshal(user)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, userData)
+}
+
+// maxUsersByIDs is the maximum number of users that can be requested at once
+const maxUsersByIDs = 200
+
+func (a *API) handleGetUsersByIDs(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/users getUsersByIDs
+	//
+	// Returns the name and avatar of several users, leaving out the IDs without a user
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: ids
+	//   in: query
+	//   description: Comma separated IDs of the users, at most 200
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/UserDisplay"
+	//   '400':
+	//     description: missing or too many IDs
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	userIDs := []string{}
+	for _, userID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) == 0 {
+		errorResponse(w, http.StatusBadRequest, "ids is required", nil)
+		return
+	}
+	if len(userIDs) > maxUsersByIDs {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("at most %d ids can be requested", maxUsersByIDs), nil)
+		return
+	}
+
+	users, err := a.app().GetUsersByIDs(userIDs)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(users)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/users/me getMe
+	//
+	// Returns the currently logged-in user
+	//
+	// ---
+	// produces:
+	// - application/json
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/User"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	var user *model.User
+	var err error
+
+	if session.UserID == "single-user" {
+		now := time.Now().Unix()
+		user = &model.User{
+			ID:       "single-user",
+			Username: "single-user",
+			Email:    "single-user",
+			CreateAt: now,
+			UpdateAt: now,
+		}
+	} else {
+		user, err = a.app().GetUser(session.UserID)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	userData, err := json.Marshal(user)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, userData)
+}
+
+func (a *API) handleDeleteBlock(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /api/v1/workspaces/{workspaceID}/blocks/{blockID} deleteBlock
+	//
+	// Deletes a block
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: blockID
+	//   in: path
+	//   description: ID of block to delete
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '409':
+	//     description: the board of the block is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	userID := session.UserID
+
+	vars := mux.Vars(r)
+	blockID := vars["blockID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().DeleteBlock(*container, blockID, userID)
+	if errors.Is(err, app.ErrBoardLocked) {
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+
+		return
+	}
+
+	log.Printf("DELETE Block %s", blockID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+func (a *API) handleGetSubTree(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/blocks/{blockID}/subtree getSubTree
+	//
+	// Returns the blocks of a subtree
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: blockID
+	//   in: path
+	//   description: The ID of the root block of the subtree
+	//   required: true
+	//   type: string
+	// - name: l
+	//   in: query
+	//   description: The number of levels to return. 2 or 3. Defaults to 2.
+	//   required: false
+	//   type: integer
+	//   minimum: 2
+	//   maximum: 3
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	blockID := vars["blockID"]
+
+	container, err := a.getContainerAllowingReadTokenForBlock(r, blockID)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	levels, err := strconv.ParseInt(query.Get("l"), 10, 32)
+	if err != nil {
+		levels = 2
+	}
+
+	if levels != 2 && levels != 3 {
+		log.Printf(`ERROR Invalid levels: %d`, levels)
+		errorResponse(w, http.StatusBadRequest, "invalid levels", nil)
+		return
+	}
+
+	blocks, err := a.app().GetSubTree(*container, blockID, int(levels))
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("GetSubTree (%v) blockID: %s, %d result(s)", levels, blockID, len(blocks))
+	json, err := json.Marshal(blocks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/blocks/export exportBlocks
+	//
+	// Returns all blocks
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	blocks, err := a.app().GetAllBlocks(*container)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("%d raw block(s)", len(blocks))
+	blocks = filterOrphanBlocks(blocks)
+	log.Printf("EXPORT %d filtered block(s)", len(blocks))
+
+	json, err := json.Marshal(blocks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func filterOrphanBlocks(blocks []model.Block) (ret []model.Block) {
+	queue := make([]model.Block, 0)
+	childrenOfBlockWithID := make(map[string]*[]model.Block)
+
+	// Build the trees from nodes
+	for _, block := range blocks {
+		if len(block.ParentID) == 0 {
+			// Queue root blocks to process first
+			queue = append(queue, block)
+		} else {
+			siblings := childrenOfBlockWithID[block.ParentID]
+			if siblings != nil {
+				*siblings = append(*siblings, block)
+			} else {
+				siblings := []model.Block{block}
+				childrenOfBlockWithID[block.ParentID] = &siblings
+			}
+		}
+	}
+
+	// Map the trees to an array, which skips orphaned nodes
+	blocks = make([]model.Block, 0)
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:] // dequeue
+		blocks = append(blocks, block)
+		children := childrenOfBlockWithID[block.ID]
+		if children != nil {
+			queue = append(queue, (*children)...)
+		}
+	}
+
+	return blocks
+}
+
+func arrayContainsBlockWithID(array []model.Block, blockID string) bool {
+	for _, item := range array {
+		if item.ID == blockID {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/blocks/import importBlocks
+	//
+	// Import blocks
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: Body
+	//   in: body
+	//   description: array of blocks to import
+	//   required: true
+	//   schema:
+	//     type: array
+	//     items:
+	//       "$ref": "#/definitions/Block"
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '409':
+	//     description: a board of the blocks is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '422':
+	//     description: the content of a block was rejected by the moderation, or the values of its properties are invalid
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	blocks, err := a.decodeBlocks(r.Body)
+	if errors.Is(err, errBatchTooLarge) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	stampModifiedByUser(r, blocks)
+
+	err = a.requestApp(r).ImportBlocks(*container, blocks)
+	var rejectedErr *app.ErrContentRejected
+	if errors.As(err, &rejectedErr) {
+		errorResponse(w, http.StatusUnprocessableEntity, rejectedErr.Error(), err)
+		return
+	}
+	var invalidErr *app.ErrInvalidProperties
+	if errors.As(err, &invalidErr) {
+		invalidPropertiesResponse(w, invalidErr)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLimitReached) {
+		errorResponse(w, http.StatusForbidden, err.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrBoardLocked) {
+		errorResponse(w, http.StatusConflict, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("IMPORT Blocks %d block(s)", len(blocks))
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+// Sharing
+
+func (a *API) handleGetBlocksByProperty(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/blocks getBlocksByProperty
+	//
+	// Returns the blocks of a board with the given property value
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: property_id
+	//   in: query
+	//   description: ID of the property to filter by
+	//   required: true
+	//   type: string
+	// - name: value
+	//   in: query
+	//   description: Value of the property
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   '400':
+	//     description: the property isn't in the schema of the board
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '404':
+	//     description: board not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	query := r.URL.Query()
+	propertyID := query.Get("property_id")
+	value := query.Get("value")
+	if len(propertyID) < 1 {
+		errorResponse(w, http.StatusBadRequest, "missing property_id", nil)
+		return
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	blocks, err := a.app().GetBlocksByProperty(*container, boardID, propertyID, value)
+	var unknownErr *app.ErrUnknownProperty
+	if errors.As(err, &unknownErr) {
+		errorResponse(w, http.StatusBadRequest, unknownErr.Error(), err)
+		return
+	}
+	var notFoundErr *store.ErrBlocksNotFound
+	if errors.As(err, &notFoundErr) {
+		errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(blocks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleGetMyCards(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/my-cards getMyCards
+	//
+	// Returns the cards of the workspace assigned to the user in a person
+	// property, most recently updated first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	session := r.Context().Value("session").(*model.Session)
+
+	cards, err := a.app().GetCardsAssignedTo(*container, session.UserID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(cards)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleGetComments(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/cards/{cardID}/comments getComments
+	//
+	// Returns a page of the comments of a card, newest first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: cardID
+	//   in: path
+	//   description: Card ID
+	//   required: true
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: Maximum number of comments to return, 50 by default and 200 at most, or the page sizes of the configuration if they're lower
+	//   required: false
+	//   type: integer
+	// - name: before
+	//   in: query
+	//   description: Only return the comments created before this time, the creation time of the last comment of the previous page
+	//   required: false
+	//   type: integer
+	// - name: before_id
+	//   in: query
+	//   description: With before, the ID of the last comment of the previous page, so the comments created at the same time aren't skipped
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	cardID := mux.Vars(r)["cardID"]
+	query := r.URL.Query()
+
+	limit, _ := a.parseListingPaging(r, 50, 200)
+
+	var before int64
+	if beforeParam := query.Get("before"); beforeParam != "" {
+		var err error
+		before, err = strconv.ParseInt(beforeParam, 10, 64)
+		if err != nil || before < 0 {
+			errorResponse(w, http.StatusBadRequest, "invalid before", err)
+			return
+		}
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	comments, err := a.app().GetComments(*container, cardID, limit, before, query.Get("before_id"))
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(comments)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleGetBoardActivity(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/activity getBoardActivity
+	//
+	// Returns a page of the changes to the blocks of a board, newest first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: Maximum number of changes to return, the default page size by default
+	//   required: false
+	//   type: integer
+	// - name: before
+	//   in: query
+	//   description: Only return the changes made before this time, the time of the last change of the previous page
+	//   required: false
+	//   type: integer
+	// - name: before_id
+	//   in: query
+	//   description: With before, the block ID of the last change of the previous page, so the changes made at the same time aren't skipped
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/BlockActivity"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	query := r.URL.Query()
+
+	limit, _ := a.ParsePaging(r)
+
+	var before int64
+	if beforeParam := query.Get("before"); beforeParam != "" {
+		var err error
+		before, err = strconv.ParseInt(beforeParam, 10, 64)
+		if err != nil || before < 0 {
+			errorResponse(w, http.StatusBadRequest, "invalid before", err)
+			return
+		}
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	activity, err := a.app().GetBoardActivity(*container, boardID, limit, before, query.Get("before_id"))
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(activity)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleGetBoardChecksum(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/checksum getBoardChecksum
+	//
+	// Returns a checksum of the blocks of a board, which changes when any of them changes
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: object
+	//       properties:
+	//         checksum:
+	//           type: string
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	checksum, err := a.app().GetBoardChecksum(*container, boardID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(map[string]string{"checksum": checksum})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleGetBoardAggregates(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/aggregates getBoardAggregates
+	//
+	// Returns the number of cards of a board, optionally grouped by a property
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: group_by
+	//   in: query
+	//   description: ID of the property to group the cards by
+	//   required: false
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/BoardAggregates"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	groupBy := r.URL.Query().Get("group_by")
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	aggregates, err := a.app().GetBoardAggregates(*container, boardID, groupBy)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(aggregates)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleGroupCardsByDate(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/aggregates/dates groupCardsByDate
+	//
+	// Returns the number of cards of a board per day, week or month of a date property
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
+	// - name: property
+	//   in: query
+	//   description: ID of the date property to group the cards by
+	//   required: true
+	//   type: string
+	// - name: bucket
+	//   in: query
+	//   description: Size of the buckets, day, week or month
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/DateBucket"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+	query := r.URL.Query()
+	propertyID := query.Get("property")
+	bucket := query.Get("bucket")
+
+	if propertyID == "" {
+		errorResponse(w, http.StatusBadRequest, "missing property", nil)
+		return
+	}
+	if !model.IsValidDateBucket(bucket) {
+		errorResponse(w, http.StatusBadRequest, "invalid bucket", nil)
+		return
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	buckets, err := a.app().GroupCardsByDate(*container, boardID, propertyID, bucket)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(buckets)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+// maxBoardsMetadataIDs is the maximum number of boards whose metadata can be
+// requested at once
+const maxBoardsMetadataIDs = 200
+
+func (a *API) handleGetBoardsMetadata(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/metadata getBoardsMetadata
+	//
+	// Returns the title, icon and card count of several boards
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: ids
+	//   in: query
+	//   description: Comma separated IDs of the boards, at most 200
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/BoardMetadata"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardIDs := []string{}
+	for _, boardID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if boardID = strings.TrimSpace(boardID); boardID != "" {
+			boardIDs = append(boardIDs, boardID)
+		}
+	}
+	if len(boardIDs) == 0 {
+		errorResponse(w, http.StatusBadRequest, "ids is required", nil)
+		return
+	}
+	if len(boardIDs) > maxBoardsMetadataIDs {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("at most %d ids can be requested", maxBoardsMetadataIDs), nil)
+		return
+	}
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	metadata, err := a.app().GetBoardsMetadata(*container, boardIDs)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	json, err := json.Marshal(metadata)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, json)
+}
+
+func (a *API) handleArchiveBoard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/boards/{boardID}/archive archiveBoard
+	//
+	// Archives a board, moving its blocks to cold storage
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: ID of the board to archive
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '404':
+	//     description: board not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: board already archived, or locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().ArchiveBoard(*container, boardID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+		if errors.Is(err, store.ErrBoardArchived) || errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
+			return
+		}
+
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("ARCHIVE Board %s", boardID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+func (a *API) handleUnarchiveBoard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/boards/{boardID}/unarchive unarchiveBoard
+	//
+	// Restores an archived board from cold storage
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: ID of the board to restore
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '404':
+	//     description: archived board not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: the board is locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().UnarchiveBoard(*container, boardID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+		if errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
+			return
+		}
+
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("UNARCHIVE Board %s", boardID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+func (a *API) handleGetDeletedBoards(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/trash getDeletedBoards
+	//
+	// Returns the boards in the trash of a workspace, most recently deleted first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Block"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	boards, err := a.app().GetDeletedBoards(container.WorkspaceID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(boards)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("GET %d deleted Boards", len(boards))
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleRestoreBoard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/trash/{boardID}/restore restoreBoard
+	//
+	// Moves a board out of the trash, with the blocks of the board deleted since it was
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: ID of the board to restore
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '403':
+	//     description: the workspace has reached the maximum number of boards
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '404':
+	//     description: board not in the trash
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '409':
+	//     description: the boards of the workspace are locked by another operation
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	session := r.Context().Value("session").(*model.Session)
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
+	boardID := mux.Vars(r)["boardID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().RestoreBoard(*container, boardID, userID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+		if errors.Is(err, app.ErrBoardLimitReached) {
+			errorResponse(w, http.StatusForbidden, err.Error(), err)
+			return
+		}
+		if errors.Is(err, app.ErrBoardLocked) {
+			errorResponse(w, http.StatusConflict, err.Error(), err)
+			return
+		}
+
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("RESTORE Board %s", boardID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+func (a *API) handleDeleteBoardPermanently(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /api/v1/workspaces/{workspaceID}/trash/{boardID} deleteBoardPermanently
+	//
+	// Deletes a board of the trash for good, with its blocks, their history and its files
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: ID of the board to delete
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '404':
+	//     description: board not in the trash
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	boardID := mux.Vars(r)["boardID"]
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().DeleteBoardPermanently(*container, boardID)
+	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("PURGE Board %s", boardID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
+func (a *API) handlePostBoardSnapshot(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/boards/{boardID}/snapshots createBoardSnapshot
+	//
+	// Creates a read-only snapshot of a board
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: ID of the board to snapshot
 	//   required: true
 	//   type: string
-	// - name: l
-	//   in: query
-	//   description: The number of levels to return. 2 or 3. Defaults to 2.
-	//   required: false
-	//   type: integer
-	//   minimum: 2
-	//   maximum: 3
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
-	//     description: success
+	//     description: success, with the ID of the snapshot
+	//   '404':
+	//     description: board not found
 	//     schema:
-	//       type: array
-	//       items:
-	//         "$ref": "#/definitions/Block"
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
 	//       "$ref": "#/definitions/ErrorResponse"
 
-	vars := mux.Vars(r)
-	blockID := vars["blockID"]
+	boardID := mux.Vars(r)["boardID"]
 
-	container, err := a.getContainerAllowingReadTokenForBlock(r, blockID)
+	container, err := a.getContainer(r)
 	if err != nil {
 		noContainerErrorResponse(w, err)
 		return
 	}
 
-	query := r.URL.Query()
-	levels, err := strconv.ParseInt(query.Get("l"), 10, 32)
-	if err != nil {
-		levels = 2
-	}
-
-	if levels != 2 && levels != 3 {
-		log.Printf(`ERROR Invalid levels: %d`, levels)
-		errorResponse(w, http.StatusBadRequest, "invalid levels", nil)
-		return
-	}
+	session := r.Context().Value("session").(*model.Session)
 
-	blocks, err := a.app().GetSubTree(*container, blockID, int(levels))
+	snapshotID, err := a.app().CreateBoardSnapshot(*container, boardID, session.UserID)
 	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	log.Printf("GetSubTree (%v) blockID: %s, %d result(s)", levels, blockID, len(blocks))
-	json, err := json.Marshal(blocks)
+	data, err := json.Marshal(map[string]string{"id": snapshotID})
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	jsonBytesResponse(w, http.StatusOK, json)
+	log.Printf("SNAPSHOT Board %s: %s", boardID, snapshotID)
+	jsonBytesResponse(w, http.StatusOK, data)
 }
 
-func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /api/v1/workspaces/{workspaceID}/blocks/export exportBlocks
+func (a *API) handleGetBoardSnapshots(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/boards/{boardID}/snapshots getBoardSnapshots
 	//
-	// Returns all blocks
+	// Returns the snapshots of a board, newest first, without their blocks
 	//
 	// ---
 	// produces:
@@ -583,6 +2369,11 @@ func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
 	//   description: Workspace ID
 	//   required: true
 	//   type: string
+	// - name: boardID
+	//   in: path
+	//   description: Board ID
+	//   required: true
+	//   type: string
 	// security:
 	// - BearerAuth: []
 	// responses:
@@ -591,85 +2382,50 @@ func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
 	//     schema:
 	//       type: array
 	//       items:
-	//         "$ref": "#/definitions/Block"
+	//         "$ref": "#/definitions/BoardSnapshot"
+	//   '404':
+	//     description: board not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
 	//       "$ref": "#/definitions/ErrorResponse"
 
+	boardID := mux.Vars(r)["boardID"]
+
 	container, err := a.getContainer(r)
 	if err != nil {
 		noContainerErrorResponse(w, err)
 		return
 	}
 
-	blocks, err := a.app().GetAllBlocks(*container)
+	snapshots, err := a.app().GetBoardSnapshots(*container, boardID)
 	if err != nil {
+		var notFoundErr *store.ErrBlocksNotFound
+		if errors.As(err, &notFoundErr) {
+			errorResponse(w, http.StatusNotFound, notFoundErr.Error(), err)
+			return
+		}
+
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	log.Printf("%d raw block(s)", len(blocks))
-	blocks = filterOrphanBlocks(blocks)
-	log.Printf("EXPORT %d filtered block(s)", len(blocks))
-
-	json, err := json.Marshal(blocks)
+	data, err := json.Marshal(snapshots)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	jsonBytesResponse(w, http.StatusOK, json)
-}
-
-func filterOrphanBlocks(blocks []model.Block) (ret []model.Block) {
-	queue := make([]model.Block, 0)
-	childrenOfBlockWithID := make(map[string]*[]model.Block)
-
-	// Build the trees from nodes
-	for _, block := range blocks {
-		if len(block.ParentID) == 0 {
-			// Queue root blocks to process first
-			queue = append(queue, block)
-		} else {
-			siblings := childrenOfBlockWithID[block.ParentID]
-			if siblings != nil {
-				*siblings = append(*siblings, block)
-			} else {
-				siblings := []model.Block{block}
-				childrenOfBlockWithID[block.ParentID] = &siblings
-			}
-		}
-	}
-
-	// Map the trees to an array, which skips orphaned nodes
-	blocks = make([]model.Block, 0)
-	for len(queue) > 0 {
-		block := queue[0]
-		queue = queue[1:] // dequeue
-		blocks = append(blocks, block)
-		children := childrenOfBlockWithID[block.ID]
-		if children != nil {
-			queue = append(queue, (*children)...)
-		}
-	}
-
-	return blocks
-}
-
-func arrayContainsBlockWithID(array []model.Block, blockID string) bool {
-	for _, item := range array {
-		if item.ID == blockID {
-			return true
-		}
-	}
-	return false
+	log.Printf("GET %d snapshots of Board %s", len(snapshots), boardID)
+	jsonBytesResponse(w, http.StatusOK, data)
 }
 
-func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation POST /api/v1/workspaces/{workspaceID}/blocks/import importBlocks
+func (a *API) handleGetBoardSnapshot(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/snapshots/{snapshotID} getBoardSnapshot
 	//
-	// Import blocks
+	// Returns a snapshot of a board with its blocks
 	//
 	// ---
 	// produces:
@@ -680,58 +2436,55 @@ func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
 	//   description: Workspace ID
 	//   required: true
 	//   type: string
-	// - name: Body
-	//   in: body
-	//   description: array of blocks to import
+	// - name: snapshotID
+	//   in: path
+	//   description: Snapshot ID
 	//   required: true
-	//   schema:
-	//     type: array
-	//     items:
-	//       "$ref": "#/definitions/Block"
+	//   type: string
 	// security:
 	// - BearerAuth: []
 	// responses:
 	//   '200':
 	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/BoardSnapshot"
+	//   '404':
+	//     description: snapshot not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
 	//       "$ref": "#/definitions/ErrorResponse"
 
+	snapshotID := mux.Vars(r)["snapshotID"]
+
 	container, err := a.getContainer(r)
 	if err != nil {
 		noContainerErrorResponse(w, err)
 		return
 	}
 
-	requestBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "", err)
+	snapshot, err := a.app().GetBoardSnapshot(*container, snapshotID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(w, http.StatusNotFound, "snapshot not found", err)
 		return
 	}
-
-	var blocks []model.Block
-
-	err = json.Unmarshal(requestBody, &blocks)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	stampModifiedByUser(r, blocks)
-
-	err = a.app().InsertBlocks(*container, blocks)
+	data, err := json.Marshal(snapshot)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
 	}
 
-	log.Printf("IMPORT Blocks %d block(s)", len(blocks))
-	jsonStringResponse(w, http.StatusOK, "{}")
+	log.Printf("GET snapshot %s", snapshotID)
+	jsonBytesResponse(w, http.StatusOK, data)
 }
 
-// Sharing
-
 func (a *API) handleGetSharing(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation GET /api/v1/workspaces/{workspaceID}/sharing/{rootID} getSharing
 	//
@@ -926,6 +2679,78 @@ func (a *API) handleGetWorkspace(w http.ResponseWriter, r *http.Request) {
 	jsonBytesResponse(w, http.StatusOK, workspaceData)
 }
 
+func (a *API) handleGetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces getWorkspaces
+	//
+	// Returns the workspaces the user has changed blocks in, most recently
+	// active first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: sort
+	//   in: query
+	//   description: Order of the workspaces, only activity is supported
+	//   required: true
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: Maximum number of workspaces to return, 10 by default and 100 at most, or the page sizes of the configuration if they're lower
+	//   required: false
+	//   type: integer
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/WorkspaceActivity"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	query := r.URL.Query()
+	if query.Get("sort") != "activity" {
+		errorResponse(w, http.StatusBadRequest, "sort must be activity", nil)
+		return
+	}
+
+	limit, _ := a.parseListingPaging(r, 10, 100)
+
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
+
+	// The user may have lost access to some of the workspaces since
+	var hasAccess func(workspaceID string) bool
+	if a.WorkspaceAuthenticator != nil {
+		hasAccess = func(workspaceID string) bool {
+			return a.WorkspaceAuthenticator.DoesUserHaveWorkspaceAccess(session, workspaceID)
+		}
+	}
+
+	activities, err := a.app().GetWorkspacesByActivity(userID, limit, hasAccess)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(activities)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
 func (a *API) handlePostWorkspaceRegenerateSignupToken(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation POST /api/v1/workspaces/{workspaceID}/regenerate_signup_token regenerateSignupToken
 	//
@@ -1070,6 +2895,14 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 	//     description: success
 	//     schema:
 	//       "$ref": "#/definitions/FileUploadResponse"
+	//   '413':
+	//     description: the workspace has reached its storage quota
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   '415':
+	//     description: the content type of the file isn't allowed
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
 	//   default:
 	//     description: internal error
 	//     schema:
@@ -1086,6 +2919,20 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The upload is registered before its body is read, so the shutdown
+	// waits for the whole of it
+	body, endUpload, uploadApp, err := a.app().BeginUpload(r.Body)
+	if errors.Is(err, app.ErrShuttingDown) {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+	defer endUpload()
+	r.Body = ioutil.NopCloser(body)
+
 	file, handle, err := r.FormFile("file")
 	if err != nil {
 		fmt.Fprintf(w, "%v", err)
@@ -1094,7 +2941,19 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	fileId, err := a.app().SaveFile(file, workspaceID, rootID, handle.Filename)
+	fileId, err := uploadApp.SaveFile(file, workspaceID, rootID, handle.Filename, handle.Size)
+	if errors.Is(err, app.ErrContentTypeNotAllowed) {
+		errorResponse(w, http.StatusUnsupportedMediaType, err.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrStorageQuotaExceeded) {
+		errorResponse(w, http.StatusRequestEntityTooLarge, err.Error(), err)
+		return
+	}
+	if errors.Is(err, app.ErrShuttingDown) {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error(), err)
+		return
+	}
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "", err)
 		return
@@ -1110,6 +2969,69 @@ func (a *API) handleUploadFile(w http.ResponseWriter, r *http.Request) {
 	jsonBytesResponse(w, http.StatusOK, data)
 }
 
+func (a *API) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /api/v1/workspaces/{workspaceID}/{rootID}/files/{fileID} deleteFile
+	//
+	// Deletes an uploaded file
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: rootID
+	//   in: path
+	//   description: ID of the root block
+	//   required: true
+	//   type: string
+	// - name: fileID
+	//   in: path
+	//   description: ID of the file
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '404':
+	//     description: file not found
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	vars := mux.Vars(r)
+	workspaceID := vars["workspaceID"]
+	rootID := vars["rootID"]
+	filename := vars["filename"]
+
+	// Caller must have access to the root block's container
+	_, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	err = a.app().DeleteFile(workspaceID, rootID, filename)
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(w, http.StatusNotFound, "file not found", err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("deleteFile, fileId: %s", filename)
+
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
+
 // Response helpers
 
 func jsonStringResponse(w http.ResponseWriter, code int, message string) {
@@ -1125,7 +3047,7 @@ func jsonBytesResponse(w http.ResponseWriter, code int, json []byte) {
 }
 
 func errorResponse(w http.ResponseWriter, code int, message string, sourceError error) {
-	log.Printf("API ERROR %d, err: %v\n", code, sourceError)
+	log.Printf("API ERROR %d, requestID: %s, err: %v\n", code, w.Header().Get(utils.RequestIDHeader), sourceError)
 	w.Header().Set("Content-Type", "application/json")
 	data, err := json.Marshal(model.ErrorResponse{Error: message, ErrorCode: code})
 	if err != nil {
@@ -1136,7 +3058,7 @@ func errorResponse(w http.ResponseWriter, code int, message string, sourceError
 }
 
 func errorResponseWithCode(w http.ResponseWriter, statusCode int, errorCode int, message string, sourceError error) {
-	log.Printf("API ERROR status %d, errorCode: %d, err: %v\n", statusCode, errorCode, sourceError)
+	log.Printf("API ERROR status %d, errorCode: %d, requestID: %s, err: %v\n", statusCode, errorCode, w.Header().Get(utils.RequestIDHeader), sourceError)
 	w.Header().Set("Content-Type", "application/json")
 	data, err := json.Marshal(model.ErrorResponse{Error: message, ErrorCode: errorCode})
 	if err != nil {
@@ -1146,6 +3068,23 @@ func errorResponseWithCode(w http.ResponseWriter, statusCode int, errorCode int,
 	w.Write(data)
 }
 
+// invalidPropertiesResponse responds with the problem with each invalid
+// value of the properties of a card
+func invalidPropertiesResponse(w http.ResponseWriter, sourceError *app.ErrInvalidProperties) {
+	log.Printf("API ERROR %d, requestID: %s, err: %v\n", http.StatusUnprocessableEntity, w.Header().Get(utils.RequestIDHeader), sourceError)
+	w.Header().Set("Content-Type", "application/json")
+	data, err := json.Marshal(model.ErrorResponse{
+		Error:     sourceError.Error(),
+		ErrorCode: http.StatusUnprocessableEntity,
+		Fields:    sourceError.Fields,
+	})
+	if err != nil {
+		data = []byte("{}")
+	}
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(data)
+}
+
 func noContainerErrorResponse(w http.ResponseWriter, sourceError error) {
 	errorResponseWithCode(w, http.StatusBadRequest, ERROR_NO_WORKSPACE_CODE, ERROR_NO_WORKSPACE_MESSAGE, sourceError)
 }
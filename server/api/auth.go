@@ -11,12 +11,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	serverContext "github.com/mattermost/focalboard/server/context"
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/auth"
 )
 
+// deviceCookieMaxAge is how long, in seconds, a browser keeps its device ID
+const deviceCookieMaxAge = 60 * 60 * 24 * 365
+
 // LoginRequest is a login request
 // swagger:model
 type LoginRequest struct {
@@ -40,6 +44,15 @@ type LoginRequest struct {
 	// required: false
 	// swagger:ignore
 	MfaToken string `json:"mfa_token"`
+
+	// URL or path to go to after logging in, also accepted as the redirect
+	// query parameter
+	// required: false
+	Redirect string `json:"redirect"`
+
+	// Alias of redirect, also accepted as the returnTo query parameter
+	// required: false
+	ReturnTo string `json:"returnTo"`
 }
 
 // LoginResponse is a login response
@@ -48,6 +61,11 @@ type LoginResponse struct {
 	// Session token
 	// required: true
 	Token string `json:"token"`
+
+	// Where to go after logging in, if a redirect was requested. It's the
+	// home page if the requested one isn't allowed
+	// required: false
+	Redirect string `json:"redirect,omitempty"`
 }
 
 // RegisterRequest is a user registration request
@@ -123,6 +141,19 @@ func isValidPassword(password string) error {
 	return nil
 }
 
+// loginRedirectTarget returns the redirect requested by the login, from its
+// body or its query parameters
+func loginRedirectTarget(r *http.Request, loginData LoginRequest) string {
+	query := r.URL.Query()
+	for _, target := range []string{loginData.Redirect, loginData.ReturnTo, query.Get("redirect"), query.Get("returnTo")} {
+		if target != "" {
+			return target
+		}
+	}
+
+	return ""
+}
+
 func (a *API) handleLogin(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation POST /api/v1/login login
 	//
@@ -172,12 +203,32 @@ func (a *API) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if loginData.Type == "normal" {
-		token, err := a.app().Login(loginData.Username, loginData.Email, loginData.Password, loginData.MfaToken)
+		deviceID := auth.ParseDeviceIDFromRequest(r)
+		if deviceID == "" {
+			deviceID = uuid.New().String()
+			http.SetCookie(w, &http.Cookie{
+				Name:     auth.DEVICE_COOKIE_ID,
+				Value:    deviceID,
+				Path:     "/",
+				MaxAge:   deviceCookieMaxAge,
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		deviceFingerprint := auth.DeviceFingerprint(r.UserAgent(), deviceID)
+		token, err := a.app().Login(loginData.Username, loginData.Email, loginData.Password, loginData.MfaToken, deviceFingerprint)
 		if err != nil {
 			errorResponse(w, http.StatusUnauthorized, "incorrect login", err)
 			return
 		}
-		json, err := json.Marshal(LoginResponse{Token: token})
+		response := LoginResponse{Token: token}
+		if target := loginRedirectTarget(r, loginData); target != "" {
+			response.Redirect = a.app().GetLoginRedirect(target)
+		}
+
+		json, err := json.Marshal(response)
 		if err != nil {
 			errorResponse(w, http.StatusInternalServerError, "", err)
 			return
@@ -371,7 +422,12 @@ func (a *API) attachSession(handler func(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		session, err := a.app().GetSession(token)
+		if a.HeaderAuthenticator != nil {
+			a.attachHeaderSession(w, r, handler, required)
+			return
+		}
+
+		session, err := a.app().GetSession(token, auth.ParseDeviceFingerprintFromRequest(r))
 		if err != nil {
 			if required {
 				errorResponse(w, http.StatusUnauthorized, "", err)
@@ -394,6 +450,65 @@ func (a *API) attachSession(handler func(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// attachHeaderSession authenticates the request with the user set by the
+// trusted reverse proxy instead of a session token
+func (a *API) attachHeaderSession(w http.ResponseWriter, r *http.Request, handler func(w http.ResponseWriter, r *http.Request), required bool) {
+	username, err := a.HeaderAuthenticator.ParseUsernameFromRequest(r)
+	if err != nil {
+		log.Printf(`Header authentication rejected for %s: %v`, r.RemoteAddr, err)
+		errorResponse(w, http.StatusUnauthorized, "", err)
+		return
+	}
+
+	if username == "" {
+		if required {
+			errorResponse(w, http.StatusUnauthorized, "", nil)
+			return
+		}
+
+		handler(w, r)
+		return
+	}
+
+	session, err := a.headerUserSession(username)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "session", session)
+	handler(w, r.WithContext(ctx))
+}
+
+// HeaderSession returns the session of the user set by the trusted reverse
+// proxy, or nil if the request has no authentication header. It
+// authenticates the websocket connections in the header auth mode.
+func (a *API) HeaderSession(r *http.Request) (*model.Session, error) {
+	username, err := a.HeaderAuthenticator.ParseUsernameFromRequest(r)
+	if err != nil || username == "" {
+		return nil, err
+	}
+
+	return a.headerUserSession(username)
+}
+
+func (a *API) headerUserSession(username string) (*model.Session, error) {
+	user, err := a.app().GetOrCreateHeaderUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	return &model.Session{
+		ID:          "header-" + user.ID,
+		UserID:      user.ID,
+		AuthService: a.authService,
+		Props:       map[string]interface{}{},
+		CreateAt:    now,
+		UpdateAt:    now,
+	}, nil
+}
+
 func (a *API) adminRequired(handler func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Currently, admin APIs require local unix connections
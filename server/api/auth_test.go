@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/auth"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderAuth(t *testing.T) {
+	cfg := &config.Configuration{AuthMode: "header"}
+	api, store := setupTestAPI(t, cfg)
+	api.authService = "header"
+
+	headerAuth, err := auth.NewHeaderAuth("X-Auth-User", []string{"10.0.0.0/8", "192.168.1.10"})
+	require.NoError(t, err)
+	api.HeaderAuthenticator = headerAuth
+
+	var session *model.Session
+	handler := api.sessionRequired(func(w http.ResponseWriter, r *http.Request) {
+		session, _ = r.Context().Value("session").(*model.Session)
+	})
+
+	serve := func(remoteAddr, username string) *httptest.ResponseRecorder {
+		session = nil
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+		request.RemoteAddr = remoteAddr
+		if username != "" {
+			request.Header.Set("X-Auth-User", username)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	t.Run("trusted source", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("jane").Return(&model.User{ID: "user-id", Username: "jane"}, nil)
+
+		recorder := serve("10.1.2.3:4567", "jane")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NotNil(t, session)
+		require.Equal(t, "user-id", session.UserID)
+	})
+
+	t.Run("trusted source with a new user", func(t *testing.T) {
+		store.EXPECT().GetUserByUsername("john").Return(nil, sql.ErrNoRows)
+		store.EXPECT().CreateUser(gomock.Any()).DoAndReturn(func(user *model.User) error {
+			require.Equal(t, "john", user.Username)
+			require.Equal(t, "header", user.AuthService)
+			return nil
+		})
+
+		recorder := serve("192.168.1.10:4567", "john")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NotNil(t, session)
+	})
+
+	t.Run("untrusted source", func(t *testing.T) {
+		recorder := serve("203.0.113.5:4567", "jane")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Nil(t, session)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		recorder := serve("10.1.2.3:4567", "")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Nil(t, session)
+	})
+
+	t.Run("websocket session", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/ws/onchange", nil)
+		request.RemoteAddr = "10.1.2.3:4567"
+
+		wsSession, err := api.HeaderSession(request)
+		require.NoError(t, err)
+		require.Nil(t, wsSession)
+
+		request.Header.Set("X-Auth-User", "jane")
+		store.EXPECT().GetUserByUsername("jane").Return(&model.User{ID: "user-id", Username: "jane"}, nil)
+		wsSession, err = api.HeaderSession(request)
+		require.NoError(t, err)
+		require.Equal(t, "user-id", wsSession.UserID)
+
+		request.RemoteAddr = "203.0.113.5:4567"
+		_, err = api.HeaderSession(request)
+		require.Equal(t, auth.ErrUntrustedSource, err)
+	})
+}
+
+func TestLoginRedirect(t *testing.T) {
+	cfg := &config.Configuration{
+		ServerRoot:          "https://boards.example.com",
+		AllowedRedirectURLs: []string{"https://example.com/app"},
+		PasswordHashCost:    4,
+	}
+	api, store := setupTestAPI(t, cfg)
+	user := &model.User{ID: "user-id", Username: "jane", Password: auth.HashPasswordWithCost("password", 4)}
+
+	login := func(t *testing.T, target string) LoginResponse {
+		store.EXPECT().GetUserByUsername("jane").Return(user, nil)
+		store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+
+		body, err := json.Marshal(LoginRequest{Type: "normal", Username: "jane", Password: "password", Redirect: target})
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		api.handleLogin(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response LoginResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.NotEmpty(t, response.Token)
+		return response
+	}
+
+	t.Run("allowed redirect", func(t *testing.T) {
+		response := login(t, "https://example.com/app/boards/board-id")
+		require.Equal(t, "https://example.com/app/boards/board-id", response.Redirect)
+	})
+
+	t.Run("disallowed redirect goes home", func(t *testing.T) {
+		response := login(t, "https://evil.example.net/login")
+		require.Equal(t, "https://boards.example.com", response.Redirect)
+	})
+
+	t.Run("no redirect", func(t *testing.T) {
+		response := login(t, "")
+		require.Empty(t, response.Redirect)
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/app"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+// secretModerator rejects the blocks with a title containing "secret"
+type secretModerator struct{}
+
+func (secretModerator) Check(block model.Block) (bool, string) {
+	if strings.Contains(block.Title, "secret") {
+		return false, "the title contains a secret"
+	}
+
+	return true, ""
+}
+
+func TestBlocksModeration(t *testing.T) {
+	api, _ := setupTestAPI(t, &config.Configuration{})
+	appBuilder := api.appBuilder
+	api.appBuilder = func() *app.App {
+		return appBuilder().WithModerator(secretModerator{})
+	}
+
+	serve := func(handler http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/api/v1/workspaces/0/blocks", bytes.NewBufferString(body))
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	t.Run("post", func(t *testing.T) {
+		recorder := serve(api.handlePostBlocks, http.MethodPost, `[{"id":"card-1","type":"card","title":"The secret","createAt":1,"updateAt":1}]`)
+		require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "the title contains a secret")
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		recorder := serve(api.handlePatchBlocks, http.MethodPatch, `[{"id":"card-1","title":"The secret"}]`)
+		require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "the title contains a secret")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		recorder := serve(api.handleImport, http.MethodPost, `[{"id":"card-1","type":"card","title":"The secret"}]`)
+		require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	})
+}
+
+func TestBlocksPropertyValidation(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{ValidateCardProperties: true})
+	store.EXPECT().GetLockedBoards(gomock.Any(), gomock.Any()).Return([]string{}, nil).AnyTimes()
+
+	board := model.Block{ID: "board-1", RootID: "board-1", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{map[string]interface{}{"id": "estimate", "type": "number"}},
+	}}
+	store.EXPECT().GetBlocksByIDs(st.Container{WorkspaceID: "0"}, []string{"board-1", "card-1"}).Return([]model.Block{board}, nil)
+
+	body := `[{"id":"card-1","rootId":"board-1","parentId":"board-1","type":"card","createAt":1,"updateAt":1,"fields":{"properties":{"estimate":"three"}}}]`
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/blocks", bytes.NewBufferString(body))
+	request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+	recorder := httptest.NewRecorder()
+	api.handlePostBlocks(recorder, request)
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	var response model.ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, http.StatusUnprocessableEntity, response.ErrorCode)
+	require.Equal(t, map[string]string{"estimate": "must be a number"}, response.Fields)
+}
+
+func TestBlocksBoardLocked(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+	container := st.Container{WorkspaceID: "0"}
+	store.EXPECT().GetLockedBoards(container, []string{"board-1"}).Return([]string{"board-1"}, nil).Times(2)
+
+	t.Run("insert", func(t *testing.T) {
+		body := `[{"id":"card-1","rootId":"board-1","parentId":"board-1","type":"card","createAt":1,"updateAt":1}]`
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/blocks", bytes.NewBufferString(body))
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+		recorder := httptest.NewRecorder()
+		api.handlePostBlocks(recorder, request)
+		require.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		store.EXPECT().GetParentID(container, "card-1").Return("board-1", nil)
+		store.EXPECT().GetRootID(container, "card-1").Return("board-1", nil)
+
+		request := httptest.NewRequest(http.MethodDelete, "/api/v1/workspaces/0/blocks/card-1", nil)
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+		request = mux.SetURLVars(request, map[string]string{"workspaceID": "0", "blockID": "card-1"})
+		recorder := httptest.NewRecorder()
+		api.handleDeleteBlock(recorder, request)
+		require.Equal(t, http.StatusConflict, recorder.Code)
+	})
+}
+
+func TestGetBlocksByProperty(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+	container := st.Container{WorkspaceID: "0"}
+	board := model.Block{ID: "board-1", RootID: "board-1", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{map[string]interface{}{"id": "priority", "type": "select"}},
+	}}
+
+	serve := func(boardID, propertyID string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/0/boards/"+boardID+"/blocks?property_id="+propertyID+"&value=high", nil)
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+		request = mux.SetURLVars(request, map[string]string{"workspaceID": "0", "boardID": boardID})
+		recorder := httptest.NewRecorder()
+		api.handleGetBlocksByProperty(recorder, request)
+		return recorder
+	}
+
+	t.Run("a property of the schema", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{board}, nil)
+		store.EXPECT().GetBlocksByProperty(container, "board-1", "priority", "high").Return([]model.Block{{ID: "card-1"}}, nil)
+
+		recorder := serve("board-1", "priority")
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("an unknown property", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{board}, nil)
+
+		recorder := serve("board-1", "status")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `unknown property \"status\"`)
+	})
+
+	t.Run("an unknown board", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-2"}).Return([]model.Block{}, nil)
+
+		recorder := serve("board-2", "priority")
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
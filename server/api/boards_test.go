@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBoardsMetadata(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+
+	serve := func(ids string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/0/boards/metadata?ids="+ids, nil)
+		request = mux.SetURLVars(request, map[string]string{"workspaceID": "0"})
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+
+		recorder := httptest.NewRecorder()
+		api.handleGetBoardsMetadata(recorder, request)
+		return recorder
+	}
+
+	t.Run("returns the metadata of the boards", func(t *testing.T) {
+		store.EXPECT().GetBoardsMetadata(st.Container{WorkspaceID: "0"}, []string{"board-1", "board-2"}).Return([]model.BoardMetadata{
+			{ID: "board-1", Title: "Roadmap", Icon: "🚀", CardCount: 2},
+			{ID: "board-2", Title: "Bugs"},
+		}, nil)
+
+		recorder := serve("board-1,board-2")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response []map[string]interface{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Len(t, response, 2)
+		require.Equal(t, "Roadmap", response[0]["title"])
+		require.EqualValues(t, 2, response[0]["cardCount"])
+		require.NotContains(t, response[0], "fields")
+	})
+
+	t.Run("ids are required", func(t *testing.T) {
+		recorder := serve("")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestCreateBoard(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/boards", strings.NewReader(body))
+		request = mux.SetURLVars(request, map[string]string{"workspaceID": "0"})
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+
+		recorder := httptest.NewRecorder()
+		api.handleCreateBoard(recorder, request)
+		return recorder
+	}
+
+	t.Run("creates the board with a default view", func(t *testing.T) {
+		store.EXPECT().CreateBoardWithDefaults(st.Container{WorkspaceID: "0"}, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(c st.Container, board model.Block, views []model.Block) error {
+				require.Equal(t, "Roadmap", board.Title)
+				require.Equal(t, "user-id", board.ModifiedBy)
+				require.Len(t, views, 1)
+				return nil
+			})
+
+		recorder := serve(`{"board": {"type": "board", "title": "Roadmap"}}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var response []model.Block
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Len(t, response, 2)
+		require.NotEmpty(t, response[0].ID)
+		require.Equal(t, response[0].ID, response[1].ParentID)
+		require.Equal(t, "view", response[1].Type)
+	})
+
+	t.Run("the block must be a board", func(t *testing.T) {
+		recorder := serve(`{"board": {"type": "card"}}`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		recorder := serve(`{"board":`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
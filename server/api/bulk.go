@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+var errBatchTooLarge = errors.New("too many elements in the batch")
+
+// decodeJSONArray decodes the elements of a JSON array one at a time with
+// decodeItem, so a batch with more than maxItems elements is rejected with
+// errBatchTooLarge as soon as the element over the cap is reached, without
+// reading the rest of it. A null array has no elements, and maxItems 0 means
+// no cap.
+func decodeJSONArray(body io.Reader, maxItems int, decodeItem func(decoder *json.Decoder) error) error {
+	decoder := json.NewDecoder(body)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("expected a JSON array, got %v", token)
+		}
+
+		count := 0
+		for decoder.More() {
+			count++
+			if maxItems > 0 && count > maxItems {
+				return fmt.Errorf("%w, the maximum is %d", errBatchTooLarge, maxItems)
+			}
+
+			if err := decodeItem(decoder); err != nil {
+				return err
+			}
+		}
+
+		// The closing bracket
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON array")
+	}
+
+	return nil
+}
+
+// decodeBlocks decodes a batch of blocks of a request body
+func (a *API) decodeBlocks(body io.Reader) ([]model.Block, error) {
+	var blocks []model.Block
+	err := decodeJSONArray(body, a.MaxBulkBatchSize, func(decoder *json.Decoder) error {
+		var block model.Block
+		if err := decoder.Decode(&block); err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+
+	return blocks, err
+}
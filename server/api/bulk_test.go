@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBulkBatchSize(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+	api.MaxBulkBatchSize = 2
+	store.EXPECT().AcquireBoardLock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	store.EXPECT().ReleaseBoardLock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/blocks/import", bytes.NewBufferString(body))
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+
+		recorder := httptest.NewRecorder()
+		api.handleImport(recorder, request)
+		return recorder
+	}
+
+	t.Run("batch at the cap", func(t *testing.T) {
+		store.EXPECT().InsertBlock(st.Container{WorkspaceID: "0"}, gomock.Any()).Return(nil).Times(2)
+
+		recorder := serve(`[{"id":"block-1","type":"card"},{"id":"block-2","type":"card"}]`)
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	})
+
+	t.Run("batch over the cap", func(t *testing.T) {
+		// The element over the cap is malformed, so the request would fail
+		// with a different status if it was decoded
+		recorder := serve(`[{"id":"block-1","type":"card"},{"id":"block-2","type":"card"},{"id":`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "too many elements")
+	})
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	decode := func(body string, maxItems int) ([]string, error) {
+		var items []string
+		err := decodeJSONArray(strings.NewReader(body), maxItems, func(decoder *json.Decoder) error {
+			var item string
+			if err := decoder.Decode(&item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+		return items, err
+	}
+
+	t.Run("array", func(t *testing.T) {
+		items, err := decode(`["a", "b"]`, 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, items)
+	})
+
+	t.Run("null and empty arrays", func(t *testing.T) {
+		items, err := decode(`null`, 1)
+		require.NoError(t, err)
+		require.Empty(t, items)
+
+		items, err = decode(`[]`, 1)
+		require.NoError(t, err)
+		require.Empty(t, items)
+	})
+
+	t.Run("over the cap", func(t *testing.T) {
+		items, err := decode(`["a", "b", "c"]`, 2)
+		require.ErrorIs(t, err, errBatchTooLarge)
+		require.Len(t, items, 2)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		_, err := decode(`{"a": "b"}`, 0)
+		require.Error(t, err)
+
+		_, err = decode(`["a"] ["b"]`, 0)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// responseCacheMaxEntries is the number of responses the cache keeps, past
+// which the expired ones are dropped, or all of them if none has expired
+const responseCacheMaxEntries = 1000
+
+// ResponseCache keeps the responses of the cacheable routes in memory for
+// the time configured for each of them. The responses are kept per user, as
+// the access to the workspace is checked before serving them. The app drops
+// the ones of the workspaces it writes to, and the TTL bounds how long the
+// writes of the other servers take to show.
+type ResponseCache struct {
+	ttls map[string]time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	// The generation counts the invalidations, so a response that raced with
+	// a write isn't cached with what it read before the write
+	gen int
+}
+
+type cachedResponse struct {
+	workspaceID string
+	header      http.Header
+	body        []byte
+	expireAt    time.Time
+}
+
+// NewResponseCache creates a cache with the TTLs in seconds of the route
+// path templates, e.g. /api/v1/workspaces/{workspaceID}. The routes without
+// a TTL aren't cached.
+func NewResponseCache(ttls map[string]int) *ResponseCache {
+	cache := &ResponseCache{
+		ttls:    map[string]time.Duration{},
+		now:     time.Now,
+		entries: map[string]cachedResponse{},
+	}
+
+	for template, seconds := range ttls {
+		if seconds > 0 {
+			// The config keys are case insensitive
+			cache.ttls[strings.ToLower(template)] = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return cache
+}
+
+func (c *ResponseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+
+	if !c.now().Before(entry.expireAt) {
+		delete(c.entries, key)
+		return cachedResponse{}, false
+	}
+
+	return entry, true
+}
+
+func (c *ResponseCache) generation() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+// set caches the response unless the cache was invalidated since the
+// generation the response was read at
+func (c *ResponseCache) set(gen int, key string, entry cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if len(c.entries) >= responseCacheMaxEntries {
+		now := c.now()
+		for existingKey, existing := range c.entries {
+			if !now.Before(existing.expireAt) {
+				delete(c.entries, existingKey)
+			}
+		}
+		if len(c.entries) >= responseCacheMaxEntries {
+			c.entries = map[string]cachedResponse{}
+		}
+	}
+
+	c.entries[key] = entry
+}
+
+// InvalidateWorkspace drops the responses cached for a workspace
+func (c *ResponseCache) InvalidateWorkspace(workspaceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if entry.workspaceID == workspaceID {
+			delete(c.entries, key)
+		}
+	}
+	c.gen++
+}
+
+// InvalidateAll drops every cached response
+func (c *ResponseCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]cachedResponse{}
+	c.gen++
+}
+
+// cached serves the GET requests from the response cache if their route has
+// a TTL, and caches the successful responses. It must run after the session
+// is checked.
+func (a *API) cached(handler func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cache := a.ResponseCache
+		if cache == nil || r.Method != http.MethodGet {
+			handler(w, r)
+			return
+		}
+
+		ttl := cache.ttls[strings.ToLower(routeTemplate(r))]
+		if ttl == 0 {
+			handler(w, r)
+			return
+		}
+
+		userID := ""
+		if session, ok := r.Context().Value("session").(*model.Session); ok {
+			userID = session.UserID
+		}
+		key := userID + " " + r.URL.Path + "?" + r.URL.Query().Encode()
+
+		if entry, ok := cache.get(key); ok {
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(entry.body)
+			return
+		}
+
+		gen := cache.generation()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		if recorder.status == http.StatusOK {
+			cache.set(gen, key, cachedResponse{
+				workspaceID: mux.Vars(r)["workspaceID"],
+				header:      w.Header().Clone(),
+				body:        recorder.body.Bytes(),
+				expireAt:    cache.now().Add(ttl),
+			})
+		}
+	}
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return ""
+}
+
+// responseRecorder keeps a copy of the response while it's written
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	rr.status = code
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
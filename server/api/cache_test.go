@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+	api.ResponseCache = NewResponseCache(map[string]int{
+		"/api/v1/workspaces/{workspaceID}/Counts": 10,
+	})
+	now := time.Now()
+	api.ResponseCache.now = func() time.Time { return now }
+
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		jsonStringResponse(w, http.StatusOK, `{"count":1}`)
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/counts", api.cached(handler)).Methods("GET")
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/other", api.cached(handler)).Methods("GET")
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/settings", func(w http.ResponseWriter, r *http.Request) {
+		workspace := model.Workspace{ID: mux.Vars(r)["workspaceID"]}
+		require.NoError(t, api.app().WithCacheInvalidator(api.ResponseCache).UpsertWorkspaceSettings(workspace))
+	}).Methods("POST")
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	t.Run("second request served from the cache", func(t *testing.T) {
+		calls = 0
+		first := serve(http.MethodGet, "/api/v1/workspaces/workspace-1/counts")
+		second := serve(http.MethodGet, "/api/v1/workspaces/workspace-1/counts")
+		require.Equal(t, 1, calls)
+		require.Equal(t, http.StatusOK, second.Code)
+		require.Equal(t, first.Body.String(), second.Body.String())
+		require.Equal(t, "application/json", second.Header().Get("Content-Type"))
+
+		// The query is part of the key
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-1/counts?ids=a")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("routes without a TTL aren't cached", func(t *testing.T) {
+		calls = 0
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-1/other")
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-1/other")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("the cache expires", func(t *testing.T) {
+		calls = 0
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-2/counts")
+		now = now.Add(5 * time.Second)
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-2/counts")
+		require.Equal(t, 1, calls)
+
+		now = now.Add(5 * time.Second)
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-2/counts")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("the writes of the app to the workspace invalidate the cache", func(t *testing.T) {
+		store.EXPECT().UpsertWorkspaceSettings(model.Workspace{ID: "workspace-3"}).Return(nil)
+
+		calls = 0
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-3/counts")
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-4/counts")
+		serve(http.MethodPost, "/api/v1/workspaces/workspace-3/settings")
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-3/counts")
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-4/counts")
+		require.Equal(t, 3, calls)
+
+		api.ResponseCache.InvalidateAll()
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-4/counts")
+		require.Equal(t, 4, calls)
+	})
+
+	t.Run("a response read before a write isn't cached", func(t *testing.T) {
+		calls = 0
+		racing := func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			api.ResponseCache.InvalidateWorkspace("workspace-5")
+			jsonStringResponse(w, http.StatusOK, `{"count":1}`)
+		}
+		r.HandleFunc("/api/v1/workspaces/{workspaceID}/racing", api.cached(racing)).Methods("GET")
+		api.ResponseCache.ttls["/api/v1/workspaces/{workspaceid}/racing"] = 10 * time.Second
+
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-5/racing")
+		serve(http.MethodGet, "/api/v1/workspaces/workspace-5/racing")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("the number of cached responses is bounded", func(t *testing.T) {
+		for i := 0; i <= responseCacheMaxEntries; i++ {
+			serve(http.MethodGet, fmt.Sprintf("/api/v1/workspaces/workspace-6/counts?page=%d", i))
+		}
+
+		api.ResponseCache.mu.Lock()
+		defer api.ResponseCache.mu.Unlock()
+		require.LessOrEqual(t, len(api.ResponseCache.entries), responseCacheMaxEntries)
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxDecompressedBodySize is used when the API has no limit set
+const defaultMaxDecompressedBodySize = 100 << 20
+
+// decompressRequestBody decodes the gzip encoded request bodies as the
+// handlers read them, failing the read once the body inflates over the
+// maximum size. Whatever the handler responds to the failed read, a body
+// inflating over the limit is answered with a 413 and a malformed one with a
+// 400.
+func (a *API) decompressRequestBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		maxSize := a.MaxDecompressedBodySize
+		if maxSize <= 0 {
+			maxSize = defaultMaxDecompressedBodySize
+		}
+
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			r.Body.Close()
+			errorResponse(w, http.StatusBadRequest, "invalid gzip request body", err)
+			return
+		}
+
+		body := &decompressedBody{reader: reader, body: r.Body, remaining: maxSize, maxSize: maxSize}
+		r.Body = body
+		r.ContentLength = -1
+		r.Header.Del("Content-Length")
+		r.Header.Del("Content-Encoding")
+
+		next.ServeHTTP(&decompressResponseWriter{ResponseWriter: w, body: body}, r)
+	})
+}
+
+var errDecompressedBodyTooLarge = errors.New("request body too large once decompressed")
+
+// decompressedBody is a request body decompressed as it's read, up to a maximum size.
+// It keeps the error that failed the decompression for the response.
+type decompressedBody struct {
+	reader    *gzip.Reader
+	body      io.ReadCloser
+	remaining int64
+	maxSize   int64
+	err       error
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Read one byte over the limit to tell a body of the maximum size from a
+	// larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.reader.Read(p)
+	if int64(n) > b.remaining {
+		b.err = fmt.Errorf("%w, over %d bytes", errDecompressedBodyTooLarge, b.maxSize)
+		return int(b.remaining), b.err
+	}
+	b.remaining -= int64(n)
+
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	b.reader.Close()
+	return b.body.Close()
+}
+
+// decompressResponseWriter replaces the response of a handler that failed
+// to read its gzip body with the error of the decompression. It forwards the
+// flushes and the hijacks of the handlers that stream their response or take
+// the connection over.
+type decompressResponseWriter struct {
+	http.ResponseWriter
+	body        *decompressedBody
+	wroteHeader bool
+	replaced    bool
+}
+
+func (w *decompressResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if err := w.body.err; err != nil {
+		w.replaced = true
+		if errors.Is(err, errDecompressedBodyTooLarge) {
+			errorResponse(w.ResponseWriter, http.StatusRequestEntityTooLarge, err.Error(), err)
+		} else {
+			errorResponse(w.ResponseWriter, http.StatusBadRequest, "invalid gzip request body", err)
+		}
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *decompressResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *decompressResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *decompressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+	return hijacker.Hijack()
+}
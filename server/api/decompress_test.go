@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBody(t *testing.T, data []byte) *bytes.Buffer {
+	var body bytes.Buffer
+	writer := gzip.NewWriter(&body)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return &body
+}
+
+func TestDecompressRequestBody(t *testing.T) {
+	api, store := setupTestAPI(t, &config.Configuration{})
+	api.MaxDecompressedBodySize = 1024
+	store.EXPECT().AcquireBoardLock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	store.EXPECT().ReleaseBoardLock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	handler := api.decompressRequestBody(http.HandlerFunc(api.handleImport))
+
+	serve := func(body *bytes.Buffer, encoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/blocks/import", body)
+		if encoding != "" {
+			request.Header.Set("Content-Encoding", encoding)
+		}
+		request = request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	blocks := []byte(`[{"id":"board-1","rootId":"board-1","type":"board","title":"Imported"}]`)
+
+	t.Run("gzip encoded import", func(t *testing.T) {
+		store.EXPECT().InsertBlock(st.Container{WorkspaceID: "0"}, gomock.Any()).DoAndReturn(func(c st.Container, block model.Block) error {
+			require.Equal(t, "board-1", block.ID)
+			require.Equal(t, "Imported", block.Title)
+			return nil
+		})
+
+		recorder := serve(gzipBody(t, blocks), "gzip")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	})
+
+	t.Run("uncompressed import", func(t *testing.T) {
+		store.EXPECT().InsertBlock(st.Container{WorkspaceID: "0"}, gomock.Any()).Return(nil)
+
+		recorder := serve(bytes.NewBuffer(blocks), "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("malformed gzip body", func(t *testing.T) {
+		recorder := serve(bytes.NewBuffer(blocks), "gzip")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		// Cut short
+		compressed := gzipBody(t, blocks).Bytes()
+		recorder = serve(bytes.NewBuffer(compressed[:len(compressed)-10]), "gzip")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("decompression bomb", func(t *testing.T) {
+		// A block with a megabyte long title compresses to about two
+		// kilobytes, and the handler decodes it until the read fails
+		title := bytes.Repeat([]byte("a"), 1<<20)
+		bomb := gzipBody(t, append(append([]byte(`[{"id":"board-1","type":"board","title":"`), title...), []byte(`"}]`)...))
+		require.Less(t, bomb.Len(), 4096)
+
+		recorder := serve(bomb, "gzip")
+		require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "request body too large once decompressed")
+	})
+
+	t.Run("exactly the maximum size", func(t *testing.T) {
+		limit := api.MaxDecompressedBodySize
+		defer func() { api.MaxDecompressedBodySize = limit }()
+		api.MaxDecompressedBodySize = int64(len(blocks))
+
+		store.EXPECT().InsertBlock(st.Container{WorkspaceID: "0"}, gomock.Any()).Return(nil)
+
+		recorder := serve(gzipBody(t, blocks), "gzip")
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("the flushes and hijacks reach the connection", func(t *testing.T) {
+		streaming := api.decompressRequestBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/flush" {
+				w.Write([]byte("partial"))
+				w.(http.Flusher).Flush()
+				return
+			}
+
+			conn, buffer, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			buffer.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+			buffer.Flush()
+		}))
+
+		request := httptest.NewRequest(http.MethodPost, "/flush", gzipBody(t, blocks))
+		request.Header.Set("Content-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		streaming.ServeHTTP(recorder, request)
+		require.True(t, recorder.Flushed)
+		require.Equal(t, "partial", recorder.Body.String())
+
+		server := httptest.NewServer(streaming)
+		defer server.Close()
+		response, err := http.Post(server.URL+"/hijack", "application/json", gzipBody(t, blocks))
+		require.NoError(t, err)
+		defer response.Body.Close()
+		data, err := ioutil.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "hijacked", string(data))
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newUploadFileRequest(t *testing.T, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="photo.png"`)
+	header.Set("Content-Type", "image/png")
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	part.Write([]byte(content))
+	require.NoError(t, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/board-id/files", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request = mux.SetURLVars(request, map[string]string{"workspaceID": "0", "rootID": "board-id"})
+	return request.WithContext(context.WithValue(request.Context(), "session", &model.Session{UserID: "user-id"}))
+}
+
+func TestUploadFileContentType(t *testing.T) {
+	cfg := &config.Configuration{AllowedUploadContentTypes: []string{"image/png"}}
+	api, _ := setupTestAPI(t, cfg)
+
+	recorder := httptest.NewRecorder()
+	api.handleUploadFile(recorder, newUploadFileRequest(t, "<html><body>not a picture</body></html>"))
+	require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+func TestUploadFileStorageQuota(t *testing.T) {
+	cfg := &config.Configuration{WorkspaceStorageQuota: 1024}
+	api, store := setupTestAPI(t, cfg)
+
+	store.EXPECT().GetWorkspaceStorageUsage("0").Return(int64(1024), nil)
+
+	recorder := httptest.NewRecorder()
+	api.handleUploadFile(recorder, newUploadFileRequest(t, "hello"))
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func TestUploadFileOverStorageQuota(t *testing.T) {
+	cfg := &config.Configuration{WorkspaceStorageQuota: 1024}
+	api, store := setupTestAPI(t, cfg)
+
+	// The workspace is under its quota, but not with the upload
+	store.EXPECT().GetWorkspaceStorageUsage("0").Return(int64(1020), nil)
+
+	recorder := httptest.NewRecorder()
+	api.handleUploadFile(recorder, newUploadFileRequest(t, "hello"))
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// ParsePaging reads the limit and offset parameters of a listing request,
+// with the page sizes of the configuration. A missing or invalid limit gets
+// the default page size, and a limit over the maximum page size is clamped to
+// it, so that a client can't ask for an unbounded number of rows. The
+// listings paged by a cursor only use the limit.
+func (a *API) ParsePaging(r *http.Request) (limit, offset int) {
+	pageSize, maxSize := a.pageSizes()
+	return parsePagingWithSizes(r, pageSize, maxSize)
+}
+
+// parseListingPaging is ParsePaging for a listing with page sizes of its
+// own, smaller than the usual ones, which the page sizes of the
+// configuration still bound
+func (a *API) parseListingPaging(r *http.Request, pageSize, maxSize int) (limit, offset int) {
+	configuredPageSize, configuredMaxSize := a.pageSizes()
+	if pageSize > configuredPageSize {
+		pageSize = configuredPageSize
+	}
+	if maxSize > configuredMaxSize {
+		maxSize = configuredMaxSize
+	}
+
+	return parsePagingWithSizes(r, pageSize, maxSize)
+}
+
+func (a *API) pageSizes() (pageSize, maxSize int) {
+	pageSize = a.DefaultPageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	maxSize = a.MaxPageSize
+	if maxSize <= 0 {
+		maxSize = maxPageSize
+	}
+
+	return pageSize, maxSize
+}
+
+// parsePagingWithSizes reads the limit and offset parameters like
+// ParsePaging, with the page sizes of a listing that has its own
+func parsePagingWithSizes(r *http.Request, pageSize, maxSize int) (limit, offset int) {
+	if pageSize > maxSize {
+		pageSize = maxSize
+	}
+
+	query := r.URL.Query()
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = pageSize
+	}
+	if limit > maxSize {
+		limit = maxSize
+	}
+
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
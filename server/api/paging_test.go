@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaging(t *testing.T) {
+	api := &API{DefaultPageSize: 20, MaxPageSize: 200}
+
+	testCases := []struct {
+		name   string
+		query  string
+		limit  int
+		offset int
+	}{
+		{"missing limit uses the default", "", 20, 0},
+		{"requested limit", "?limit=50&offset=100", 50, 100},
+		{"over-max limit is clamped", "?limit=1000000", 200, 0},
+		{"invalid limit uses the default", "?limit=abc", 20, 0},
+		{"zero limit uses the default", "?limit=0", 20, 0},
+		{"negative offset is ignored", "?offset=-5", 20, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, offset := api.ParsePaging(httptest.NewRequest(http.MethodGet, "/api/v1/items"+tc.query, nil))
+			require.Equal(t, tc.limit, limit)
+			require.Equal(t, tc.offset, offset)
+		})
+	}
+
+	t.Run("the page sizes of a listing", func(t *testing.T) {
+		limit, _ := parsePagingWithSizes(httptest.NewRequest(http.MethodGet, "/api/v1/items", nil), 50, 200)
+		require.Equal(t, 50, limit)
+
+		limit, _ = parsePagingWithSizes(httptest.NewRequest(http.MethodGet, "/api/v1/items?limit=500", nil), 50, 200)
+		require.Equal(t, 200, limit)
+	})
+
+	t.Run("the page sizes of a listing bounded by the configuration", func(t *testing.T) {
+		limit, _ := api.parseListingPaging(httptest.NewRequest(http.MethodGet, "/api/v1/items", nil), 10, 100)
+		require.Equal(t, 10, limit)
+
+		limit, _ = api.parseListingPaging(httptest.NewRequest(http.MethodGet, "/api/v1/items?limit=500", nil), 10, 100)
+		require.Equal(t, 100, limit)
+
+		small := &API{DefaultPageSize: 5, MaxPageSize: 20}
+		limit, _ = small.parseListingPaging(httptest.NewRequest(http.MethodGet, "/api/v1/items", nil), 50, 200)
+		require.Equal(t, 5, limit)
+
+		limit, _ = small.parseListingPaging(httptest.NewRequest(http.MethodGet, "/api/v1/items?limit=500", nil), 50, 200)
+		require.Equal(t, 20, limit)
+	})
+
+	t.Run("unconfigured page sizes", func(t *testing.T) {
+		limit, _ := (&API{}).ParsePaging(httptest.NewRequest(http.MethodGet, "/api/v1/items?limit=5000", nil))
+		require.Equal(t, maxPageSize, limit)
+
+		limit, _ = (&API{}).ParsePaging(httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+		require.Equal(t, defaultPageSize, limit)
+	})
+}
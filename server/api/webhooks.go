@@ -0,0 +1,231 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/app"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// WorkspaceWebhookRequest registers a webhook for a workspace
+// swagger:model
+type WorkspaceWebhookRequest struct {
+	// URL the changes are posted to
+	// required: true
+	URL string `json:"url"`
+}
+
+func (a *API) handleGetWorkspaceWebhooks(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /api/v1/workspaces/{workspaceID}/webhooks getWorkspaceWebhooks
+	//
+	// Returns the webhooks registered by the workspace
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/WorkspaceWebhook"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	a.writeWorkspaceWebhooks(w, *container)
+}
+
+func (a *API) handlePostWorkspaceWebhook(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /api/v1/workspaces/{workspaceID}/webhooks postWorkspaceWebhook
+	//
+	// Registers a webhook notified of the block changes in the workspace
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: Body
+	//   in: body
+	//   description: webhook to register
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/WorkspaceWebhookRequest"
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//     schema:
+	//       "$ref": "#/definitions/WorkspaceWebhook"
+	//   '400':
+	//     description: invalid, unresolvable or private webhook URL
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	var request WorkspaceWebhookRequest
+	if err = json.Unmarshal(requestBody, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	ctx := r.Context()
+	session := ctx.Value("session").(*model.Session)
+	userID := session.UserID
+	if userID == "single-user" {
+		userID = ""
+	}
+
+	webhook, err := a.app().CreateWorkspaceWebhook(*container, request.URL, userID)
+	if errors.Is(err, app.ErrInvalidWebhookURL) || errors.Is(err, app.ErrUnresolvableWebhookHost) || errors.Is(err, app.ErrPrivateWebhookTarget) {
+		errorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("POST webhook %s for workspace %s", webhook.ID, container.WorkspaceID)
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) handleDeleteWorkspaceWebhook(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /api/v1/workspaces/{workspaceID}/webhooks/{webhookID} deleteWorkspaceWebhook
+	//
+	// Removes a webhook of the workspace
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: workspaceID
+	//   in: path
+	//   description: Workspace ID
+	//   required: true
+	//   type: string
+	// - name: webhookID
+	//   in: path
+	//   description: ID of the webhook to remove
+	//   required: true
+	//   type: string
+	// security:
+	// - BearerAuth: []
+	// responses:
+	//   '200':
+	//     description: success
+	//   '404':
+	//     description: webhook not found
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+	//   default:
+	//     description: internal error
+	//     schema:
+	//       "$ref": "#/definitions/ErrorResponse"
+
+	container, err := a.getContainer(r)
+	if err != nil {
+		noContainerErrorResponse(w, err)
+		return
+	}
+
+	a.deleteWorkspaceWebhook(w, *container, mux.Vars(r)["webhookID"])
+}
+
+func (a *API) handleAdminGetWorkspaceWebhooks(w http.ResponseWriter, r *http.Request) {
+	container := store.Container{
+		WorkspaceID: mux.Vars(r)["workspaceID"],
+	}
+
+	a.writeWorkspaceWebhooks(w, container)
+}
+
+func (a *API) handleAdminDeleteWorkspaceWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	container := store.Container{
+		WorkspaceID: vars["workspaceID"],
+	}
+
+	a.deleteWorkspaceWebhook(w, container, vars["webhookID"])
+}
+
+func (a *API) writeWorkspaceWebhooks(w http.ResponseWriter, container store.Container) {
+	webhooks, err := a.app().GetWorkspaceWebhooks(container)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	data, err := json.Marshal(webhooks)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	jsonBytesResponse(w, http.StatusOK, data)
+}
+
+func (a *API) deleteWorkspaceWebhook(w http.ResponseWriter, container store.Container, webhookID string) {
+	err := a.app().DeleteWorkspaceWebhook(container, webhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(w, http.StatusNotFound, "webhook not found", err)
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	log.Printf("DELETE webhook %s of workspace %s", webhookID, container.WorkspaceID)
+	jsonStringResponse(w, http.StatusOK, "{}")
+}
@@ -16,6 +16,21 @@ type App struct {
 	wsServer     *ws.Server
 	filesBackend filesstore.FileBackend
 	webhook      *webhook.Client
+	requestID    string
+	uploads      *Uploads
+	moderator    ContentModerator
+	invalidator  CacheInvalidator
+}
+
+// CacheInvalidator drops what's cached of the data the app writes, like the
+// responses of the API
+type CacheInvalidator interface {
+	// InvalidateWorkspace drops what's cached of a workspace
+	InvalidateWorkspace(workspaceID string)
+
+	// InvalidateAll drops everything, for the writes that span the
+	// workspaces
+	InvalidateAll()
 }
 
 func New(
@@ -33,5 +48,42 @@ func New(
 		wsServer:     wsServer,
 		filesBackend: filesBackend,
 		webhook:      webhook,
+		moderator:    newContentModerator(config.ModerationWords),
+	}
+}
+
+// WithRequestID returns a copy of the app that sends the ID of the request
+// it's serving with the webhooks it triggers
+func (a *App) WithRequestID(requestID string) *App {
+	copy := *a
+	copy.requestID = requestID
+	return &copy
+}
+
+// WithUploads returns a copy of the app that registers the files it saves
+// with the uploads, so shutting them down waits for the files
+func (a *App) WithUploads(uploads *Uploads) *App {
+	copy := *a
+	copy.uploads = uploads
+	return &copy
+}
+
+// WithCacheInvalidator returns a copy of the app that tells the invalidator
+// of the workspaces it writes to
+func (a *App) WithCacheInvalidator(invalidator CacheInvalidator) *App {
+	copy := *a
+	copy.invalidator = invalidator
+	return &copy
+}
+
+func (a *App) invalidateWorkspace(workspaceID string) {
+	if a.invalidator != nil {
+		a.invalidator.InvalidateWorkspace(workspaceID)
+	}
+}
+
+func (a *App) invalidateAll() {
+	if a.invalidator != nil {
+		a.invalidator.InvalidateAll()
 	}
 }
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	archivePrefix  = "archive"
+	archiveVersion = model.ArchiveVersion
+)
+
+func boardArchivePath(workspaceID, boardID string) string {
+	return filepath.Join(archivePrefix, workspaceID, boardID+".json")
+}
+
+// ArchiveBoard writes the board's blocks to the files backend, using the
+// export archive format, and removes them from the database. The board block
+// itself is kept, flagged as archived, so the board can be restored later. A
+// board that's already archived fails with store.ErrBoardArchived. The board
+// is locked until it's done, and the store reads and removes the blocks in
+// the transaction that writes the archive.
+func (a *App) ArchiveBoard(c store.Container, boardID string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	var board model.Block
+	err := a.withBoardLocks(c, []string{boardID}, func() error {
+		archivePath := boardArchivePath(c.WorkspaceID, boardID)
+		written := false
+
+		err := a.store.ArchiveBoard(c, boardID, func(blocks []model.Block) error {
+			for _, block := range blocks {
+				if block.ID == boardID {
+					board = block
+				}
+			}
+
+			// Archiving again would replace the archive with only the board
+			// block
+			exists, err := a.filesBackend.FileExists(archivePath)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return store.ErrBoardArchived
+			}
+
+			archive := model.Archive{
+				Version: archiveVersion,
+				Date:    utils.GetMillis(),
+				Blocks:  blocks,
+			}
+			data, err := json.Marshal(archive)
+			if err != nil {
+				return err
+			}
+
+			if _, err = a.filesBackend.WriteFile(bytes.NewReader(data), archivePath); err != nil {
+				return errors.Wrap(err, "unable to store the board archive")
+			}
+			written = true
+
+			return nil
+		})
+		if err != nil && written {
+			if removeErr := a.filesBackend.RemoveFile(archivePath); removeErr != nil {
+				return errors.Wrapf(err, "unable to remove archive %s after failure: %v", archivePath, removeErr)
+			}
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.wsServer.BroadcastBlockDelete(c.WorkspaceID, board.ID, board.ParentID, board.ID)
+
+	return nil
+}
+
+// UnarchiveBoard restores the blocks of an archived board from the files
+// backend and removes the archive, with the board locked until it's done.
+func (a *App) UnarchiveBoard(c store.Container, boardID string) error {
+	var archive model.Archive
+	err := a.withBoardLocks(c, []string{boardID}, func() error {
+		return a.unarchiveBoard(c, boardID, &archive)
+	})
+	if err != nil {
+		return err
+	}
+
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, archive.Blocks)
+
+	return nil
+}
+
+func (a *App) unarchiveBoard(c store.Container, boardID string, archive *model.Archive) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	archivePath := boardArchivePath(c.WorkspaceID, boardID)
+
+	exists, err := a.filesBackend.FileExists(archivePath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	data, err := a.filesBackend.ReadFile(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "unable to read the board archive")
+	}
+
+	if err = json.Unmarshal(data, archive); err != nil {
+		return errors.Wrap(err, "invalid board archive")
+	}
+
+	if err = a.store.UnarchiveBoard(c, boardID, archive.Blocks); err != nil {
+		return err
+	}
+
+	if err = a.filesBackend.RemoveFile(archivePath); err != nil {
+		return errors.Wrap(err, "unable to remove the board archive")
+	}
+
+	return nil
+}
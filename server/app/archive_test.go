@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesBackend, err := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	container := st.Container{WorkspaceID: "workspace-1"}
+	blocks := []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card"},
+	}
+	archivePath := boardArchivePath("workspace-1", "board-1")
+
+	t.Run("archive the blocks the store removes", func(t *testing.T) {
+		store.EXPECT().AcquireBoardLock(container, "board-1", gomock.Any(), boardLockTTL).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(container, "board-1", gomock.Any()).Return(nil)
+		store.EXPECT().ArchiveBoard(container, "board-1", gomock.Any()).DoAndReturn(
+			func(c st.Container, boardID string, archive func([]model.Block) error) error {
+				return archive(blocks)
+			})
+
+		require.NoError(t, app.ArchiveBoard(container, "board-1"))
+		exists, err := filesBackend.FileExists(archivePath)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("the archive of a failed store change is removed", func(t *testing.T) {
+		store.EXPECT().AcquireBoardLock(container, "board-2", gomock.Any(), boardLockTTL).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(container, "board-2", gomock.Any()).Return(nil)
+		store.EXPECT().ArchiveBoard(container, "board-2", gomock.Any()).DoAndReturn(
+			func(c st.Container, boardID string, archive func([]model.Block) error) error {
+				require.NoError(t, archive([]model.Block{{ID: "board-2", RootID: "board-2", Type: "board"}}))
+				return errors.New("database is locked")
+			})
+
+		require.EqualError(t, app.ArchiveBoard(container, "board-2"), "database is locked")
+		exists, err := filesBackend.FileExists(boardArchivePath("workspace-1", "board-2"))
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("an archive in progress isn't touched", func(t *testing.T) {
+		// Another archive of the board holds the lock, so its archive stays
+		_, err := filesBackend.WriteFile(bytes.NewBufferString("{}"), boardArchivePath("workspace-1", "board-3"))
+		require.NoError(t, err)
+		store.EXPECT().AcquireBoardLock(container, "board-3", gomock.Any(), boardLockTTL).Return(false, nil)
+
+		require.Equal(t, ErrBoardLocked, app.ArchiveBoard(container, "board-3"))
+		exists, err := filesBackend.FileExists(boardArchivePath("workspace-1", "board-3"))
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		store.EXPECT().AcquireBoardLock(container, "board-3", gomock.Any(), boardLockTTL).Return(false, nil)
+		require.Equal(t, ErrBoardLocked, app.UnarchiveBoard(container, "board-3"))
+	})
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"log"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// auditActorSystem is the actor of the actions of the admin API and of the
+// scheduled tasks, which aren't done by a user
+const auditActorSystem = "system"
+
+// RecordAudit stores an action of a user in the audit logs
+func (a *App) RecordAudit(actor, action, target, details string) error {
+	return a.store.CreateAuditLog(model.AuditLog{
+		ID:       utils.CreateGUID(),
+		CreateAt: utils.GetMillis(),
+		Actor:    actor,
+		Action:   action,
+		Target:   target,
+		Details:  details,
+	})
+}
+
+// audit records an action once it's done. A failure to record it is logged
+// instead of failing the action, which can't be undone.
+func (a *App) audit(actor, action, target, details string) {
+	if err := a.RecordAudit(actor, action, target, details); err != nil {
+		log.Printf("ERROR recording the audit log of %s %s: %v", action, target, err)
+	}
+}
+
+func (a *App) GetAuditLogs(filter store.AuditFilter, limit, offset int) ([]model.AuditLog, error) {
+	return a.store.GetAuditLogs(filter, limit, offset)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAudit expects an action to be recorded in the audit logs
+func expectAudit(t *testing.T, store *mockstore.MockStore, actor, action, target string) {
+	store.EXPECT().CreateAuditLog(gomock.Any()).DoAndReturn(func(entry model.AuditLog) error {
+		require.NotEmpty(t, entry.ID)
+		require.NotZero(t, entry.CreateAt)
+		require.Equal(t, actor, entry.Actor)
+		require.Equal(t, action, entry.Action)
+		require.Equal(t, target, entry.Target)
+		return nil
+	})
+}
+
+func TestAuditFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, nil, webhook)
+
+	// The password is set even if the action can't be recorded
+	store.EXPECT().UpdateUserPassword("jane", gomock.Any()).Return(nil)
+	store.EXPECT().CreateAuditLog(gomock.Any()).Return(errors.New("database is locked"))
+
+	require.NoError(t, app.UpdateUserPassword("jane", "new-password"))
+}
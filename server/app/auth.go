@@ -1,6 +1,7 @@
 package app
 
 import (
+	"database/sql"
 	"log"
 
 	"github.com/google/uuid"
@@ -11,9 +12,55 @@ import (
 	"github.com/pkg/errors"
 )
 
+// GetLoginRedirect returns where to send the user after logging in, which is
+// the target if the configuration allows it and the home page otherwise
+func (a *App) GetLoginRedirect(target string) string {
+	if auth.IsRedirectAllowed(target, a.config.AllowedRedirectURLs) {
+		return target
+	}
+
+	log.Printf("Login redirect not allowed: %q", target)
+	if a.config.ServerRoot == "" {
+		return "/"
+	}
+
+	return a.config.ServerRoot
+}
+
+// CleanUpSessions removes the sessions unused for longer than the session
+// lifetime, keeping them for at least 31 days, and the ones of the deleted
+// users, and returns the number removed. The websocket connections of the
+// sessions past their lifetime, removed or not, are told to log out.
+func (a *App) CleanUpSessions() (int64, error) {
+	if a.config.SessionExpireTime > 0 {
+		expired, err := a.store.GetExpiredSessionIDs(a.auth.SessionExpireTime())
+		if err != nil {
+			return 0, err
+		}
+		a.wsServer.ExpireSessions(expired)
+	}
+
+	secondsAgo := int64(60 * 60 * 24 * 31)
+	if secondsAgo < a.config.SessionExpireTime {
+		secondsAgo = a.config.SessionExpireTime
+	}
+
+	removed, err := a.store.CleanUpSessions(secondsAgo)
+	if err != nil {
+		return 0, err
+	}
+
+	orphaned, err := a.store.DeleteSessionsForDeletedUsers()
+	if err != nil {
+		return removed, err
+	}
+
+	return removed + orphaned, nil
+}
+
 // GetSession Get a user active session and refresh the session if is needed
-func (a *App) GetSession(token string) (*model.Session, error) {
-	return a.auth.GetSession(token)
+func (a *App) GetSession(token, deviceFingerprint string) (*model.Session, error) {
+	return a.auth.GetSession(token, deviceFingerprint)
 }
 
 // IsValidReadToken validates the read token for a block
@@ -57,8 +104,14 @@ func (a *App) GetUser(ID string) (*model.User, error) {
 	return user, nil
 }
 
+// GetUsersByIDs returns the display information of the existing users among
+// the given IDs
+func (a *App) GetUsersByIDs(ids []string) ([]model.UserDisplay, error) {
+	return a.store.GetUsersByIDs(ids)
+}
+
 // Login create a new user session if the authentication data is valid
-func (a *App) Login(username, email, password, mfaToken string) (string, error) {
+func (a *App) Login(username, email, password, mfaToken, deviceFingerprint string) (string, error) {
 	var user *model.User
 	if username != "" {
 		var err error
@@ -84,6 +137,14 @@ func (a *App) Login(username, email, password, mfaToken string) (string, error)
 		return "", errors.New("invalid username or password")
 	}
 
+	// Upgrade the hashes made with a lower cost than the configured one,
+	// the login goes on even if it fails
+	if auth.NeedsRehash(user.Password, a.config.PasswordHashCost) {
+		if err := a.store.UpdateUserPasswordByID(user.ID, a.hashPassword(password)); err != nil {
+			log.Printf("Unable to rehash the password for userID: %s, err: %v\n", user.ID, err)
+		}
+	}
+
 	authService := user.AuthService
 	if authService == "" {
 		authService = "native"
@@ -95,6 +156,8 @@ func (a *App) Login(username, email, password, mfaToken string) (string, error)
 		UserID:      user.ID,
 		AuthService: authService,
 		Props:       map[string]interface{}{},
+
+		DeviceFingerprint: deviceFingerprint,
 	}
 	err := a.store.CreateSession(&session)
 	if err != nil {
@@ -138,7 +201,7 @@ func (a *App) RegisterUser(username, email, password string) error {
 		ID:          uuid.New().String(),
 		Username:    username,
 		Email:       email,
-		Password:    auth.HashPassword(password),
+		Password:    a.hashPassword(password),
 		MfaSecret:   "",
 		AuthService: a.config.AuthMode,
 		AuthData:    "",
@@ -151,11 +214,36 @@ func (a *App) RegisterUser(username, email, password string) error {
 	return nil
 }
 
+// GetOrCreateHeaderUser returns the user authenticated by the reverse proxy,
+// creating it the first time it's seen
+func (a *App) GetOrCreateHeaderUser(username string) (*model.User, error) {
+	user, err := a.store.GetUserByUsername(username)
+	if err == nil {
+		return user, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	user = &model.User{
+		ID:          uuid.New().String(),
+		Username:    username,
+		AuthService: "header",
+		Props:       map[string]interface{}{},
+	}
+	if err := a.store.CreateUser(user); err != nil {
+		return nil, errors.Wrap(err, "unable to create the header authenticated user")
+	}
+
+	return user, nil
+}
+
 func (a *App) UpdateUserPassword(username, password string) error {
-	err := a.store.UpdateUserPassword(username, auth.HashPassword(password))
+	err := a.store.UpdateUserPassword(username, a.hashPassword(password))
 	if err != nil {
 		return err
 	}
+	a.audit(auditActorSystem, "setPassword", username, "by an admin")
 
 	return nil
 }
@@ -179,10 +267,16 @@ func (a *App) ChangePassword(userID, oldPassword, newPassword string) error {
 		return errors.New("invalid username or password")
 	}
 
-	err := a.store.UpdateUserPasswordByID(userID, auth.HashPassword(newPassword))
+	err := a.store.UpdateUserPasswordByID(userID, a.hashPassword(newPassword))
 	if err != nil {
 		return errors.Wrap(err, "unable to update password")
 	}
+	a.audit(userID, "setPassword", userID, "changed by the user")
 
 	return nil
 }
+
+// hashPassword hashes a new password with the configured cost
+func (a *App) hashPassword(password string) string {
+	return auth.HashPasswordWithCost(password, a.config.PasswordHashCost)
+}
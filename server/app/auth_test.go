@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	serviceAuth "github.com/mattermost/focalboard/server/services/auth"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginRehashesPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{PasswordHashCost: bcrypt.MinCost + 1}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	t.Run("outdated hash", func(t *testing.T) {
+		user := &model.User{ID: "user-id", Username: "jane", Password: serviceAuth.HashPasswordWithCost("password", bcrypt.MinCost)}
+		store.EXPECT().GetUserByUsername("jane").Return(user, nil)
+		store.EXPECT().UpdateUserPasswordByID("user-id", gomock.Any()).DoAndReturn(func(userID, hash string) error {
+			cost, err := bcrypt.Cost([]byte(hash))
+			require.NoError(t, err)
+			require.Equal(t, bcrypt.MinCost+1, cost)
+			require.True(t, serviceAuth.ComparePassword(hash, "password"))
+			return nil
+		})
+		store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+
+		token, err := app.Login("jane", "", "password", "", "")
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+	})
+
+	t.Run("current hash", func(t *testing.T) {
+		user := &model.User{ID: "user-id", Username: "jane", Password: serviceAuth.HashPasswordWithCost("password", bcrypt.MinCost+1)}
+		store.EXPECT().GetUserByUsername("jane").Return(user, nil)
+		store.EXPECT().CreateSession(gomock.Any()).Return(nil)
+
+		_, err := app.Login("jane", "", "password", "", "")
+		require.NoError(t, err)
+	})
+
+	t.Run("new passwords use the configured cost", func(t *testing.T) {
+		store.EXPECT().UpdateUserPassword("jane", gomock.Any()).DoAndReturn(func(username, hash string) error {
+			cost, err := bcrypt.Cost([]byte(hash))
+			require.NoError(t, err)
+			require.Equal(t, bcrypt.MinCost+1, cost)
+			return nil
+		})
+		expectAudit(t, store, "system", "setPassword", "jane")
+
+		require.NoError(t, app.UpdateUserPassword("jane", "new-password"))
+	})
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupFilePrefix = "focalboard-"
+	backupFileSuffix = ".db"
+)
+
+// BackupDatabase creates a new database backup in the configured backup
+// directory and removes the oldest ones above the configured limit
+func (a *App) BackupDatabase() (string, error) {
+	err := os.MkdirAll(a.config.BackupDir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	name := backupFilePrefix + time.Now().UTC().Format("20060102-150405.000") + backupFileSuffix
+	filename := filepath.Join(a.config.BackupDir, name)
+
+	err = a.store.BackupDatabase(filename)
+	if err != nil {
+		return "", err
+	}
+	a.audit(auditActorSystem, "backup", "", filename)
+
+	err = a.rotateBackups()
+	if err != nil {
+		log.Printf("Unable to rotate the database backups: %v", err)
+	}
+
+	return filename, nil
+}
+
+func (a *App) rotateBackups() error {
+	if a.config.BackupKeep < 1 {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(a.config.BackupDir)
+	if err != nil {
+		return err
+	}
+
+	backups := []string{}
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+
+	if len(backups) <= a.config.BackupKeep {
+		return nil
+	}
+
+	// Backup names embed their creation time, so they sort chronologically
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-a.config.BackupKeep] {
+		if err := os.Remove(filepath.Join(a.config.BackupDir, name)); err != nil {
+			return fmt.Errorf("removing backup %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{
+		BackupDir:  t.TempDir(),
+		BackupKeep: 2,
+	}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "TESTTOKEN")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	store.EXPECT().BackupDatabase(gomock.Any()).DoAndReturn(func(filename string) error {
+		return ioutil.WriteFile(filename, []byte("backup"), 0600)
+	}).Times(3)
+	store.EXPECT().CreateAuditLog(gomock.Any()).DoAndReturn(func(entry model.AuditLog) error {
+		require.Equal(t, "backup", entry.Action)
+		require.Contains(t, entry.Details, cfg.BackupDir)
+		return nil
+	}).Times(3)
+
+	filenames := []string{}
+	for i := 0; i < 3; i++ {
+		filename, err := app.BackupDatabase()
+		require.NoError(t, err)
+		filenames = append(filenames, filename)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	files, err := ioutil.ReadDir(cfg.BackupDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.NoFileExists(t, filenames[0])
+	require.FileExists(t, filenames[1])
+	require.FileExists(t, filenames[2])
+}
@@ -1,10 +1,17 @@
 package app
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/store"
 )
 
+// ErrBoardLimitReached is returned when creating a board would exceed the
+// configured maximum of boards per workspace
+var ErrBoardLimitReached = errors.New("the workspace has reached the maximum number of boards")
+
 func (a *App) GetBlocks(c store.Container, parentID string, blockType string) ([]model.Block, error) {
 	if len(blockType) > 0 && len(parentID) > 0 {
 		return a.store.GetBlocksWithParentAndType(c, parentID, blockType)
@@ -17,6 +24,62 @@ func (a *App) GetBlocks(c store.Container, parentID string, blockType string) ([
 	return a.store.GetBlocksWithParent(c, parentID)
 }
 
+func (a *App) GetSortedBlocks(c store.Container, parentID string, blockType string, sort store.BlockSort) ([]model.Block, error) {
+	return a.store.GetBlocksWithParentAndTypeSorted(c, parentID, blockType, sort)
+}
+
+// GetBlocksByProperty returns the blocks of a board with the value of one of
+// the properties of its schema
+func (a *App) GetBlocksByProperty(c store.Container, boardID, propertyID, value string) ([]model.Block, error) {
+	boards, err := a.store.GetBlocksByIDs(c, []string{boardID})
+	if err != nil {
+		return nil, err
+	}
+	if len(boards) == 0 || boards[0].Type != "board" {
+		return nil, &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	for _, property := range cardPropertySchema(boards[0]) {
+		if property["id"] == propertyID {
+			return a.store.GetBlocksByProperty(c, boardID, propertyID, value)
+		}
+	}
+
+	return nil, &ErrUnknownProperty{PropertyID: propertyID}
+}
+
+func (a *App) GetBoardAggregates(c store.Container, boardID, groupByPropertyID string) (*model.BoardAggregates, error) {
+	return a.store.GetBoardAggregates(c, boardID, groupByPropertyID)
+}
+
+func (a *App) GroupCardsByDate(c store.Container, boardID, datePropertyID, bucket string) ([]model.DateBucket, error) {
+	return a.store.GroupCardsByDate(c, boardID, datePropertyID, bucket)
+}
+
+func (a *App) CountBlocksByBoard(c store.Container) (map[string]int, error) {
+	return a.store.CountBlocksByBoard(c)
+}
+
+func (a *App) GetBlocksModifiedBy(c store.Container, userID string, since int64, limit int) ([]model.Block, error) {
+	return a.store.GetBlocksModifiedBy(c, userID, since, limit)
+}
+
+func (a *App) GetComments(c store.Container, cardID string, limit int, before int64, beforeID string) ([]model.Block, error) {
+	return a.store.GetComments(c, cardID, limit, before, beforeID)
+}
+
+func (a *App) GetCardsAssignedTo(c store.Container, userID string) ([]model.Block, error) {
+	return a.store.GetCardsAssignedTo(c, userID)
+}
+
+func (a *App) GetBoardsMetadata(c store.Container, boardIDs []string) ([]model.BoardMetadata, error) {
+	return a.store.GetBoardsMetadata(c, boardIDs)
+}
+
+func (a *App) FindDuplicateBoards(c store.Container) ([]model.DuplicateBoards, error) {
+	return a.store.FindDuplicateBoards(c)
+}
+
 func (a *App) GetRootID(c store.Container, blockID string) (string, error) {
 	return a.store.GetRootID(c, blockID)
 }
@@ -26,10 +89,51 @@ func (a *App) GetParentID(c store.Container, blockID string) (string, error) {
 }
 
 func (a *App) InsertBlock(c store.Container, block model.Block) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	if err := a.moderate([]model.Block{block}); err != nil {
+		return err
+	}
+
+	if err := a.validateCardProperties(c, []model.Block{block}); err != nil {
+		return err
+	}
+
 	return a.store.InsertBlock(c, block)
 }
 
+// InsertBlocks inserts or updates the blocks, unless a bulk operation has
+// any of the boards they belong to locked
 func (a *App) InsertBlocks(c store.Container, blocks []model.Block) error {
+	if err := a.checkBoardLocks(c, rootIDs(blocks)); err != nil {
+		return err
+	}
+
+	return a.insertBlocks(c, blocks)
+}
+
+func rootIDs(blocks []model.Block) []string {
+	boardIDs := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		boardIDs = append(boardIDs, block.RootID)
+	}
+
+	return boardIDs
+}
+
+func (a *App) insertBlocks(c store.Container, blocks []model.Block) error {
+	if err := a.checkBlocks(c, blocks); err != nil {
+		return err
+	}
+
+	return a.withinBoardLimit(c, blocks, func() error {
+		return a.writeBlocks(c, blocks)
+	})
+}
+
+func (a *App) writeBlocks(c store.Container, blocks []model.Block) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
 	blockIDsToNotify := []string{}
 
 	uniqueBlockIDs := make(map[string]bool)
@@ -50,7 +154,176 @@ func (a *App) InsertBlocks(c store.Container, blocks []model.Block) error {
 		}
 
 		a.wsServer.BroadcastBlockChange(c.WorkspaceID, block)
-		go a.webhook.NotifyUpdate(block)
+		a.webhook.Dispatch(c.WorkspaceID, block, a.requestID)
+	}
+
+	return nil
+}
+
+// checkBlocks screens and validates the blocks about to be written, for
+// every path that creates blocks
+func (a *App) checkBlocks(c store.Container, blocks []model.Block) error {
+	if err := a.moderate(blocks); err != nil {
+		return err
+	}
+
+	return a.validateCardProperties(c, blocks)
+}
+
+// ImportBlocks inserts the blocks of an import, with the boards they belong
+// to locked until it's done
+func (a *App) ImportBlocks(c store.Container, blocks []model.Block) error {
+	return a.withBoardLocks(c, rootIDs(blocks), func() error {
+		return a.insertBlocks(c, blocks)
+	})
+}
+
+// withinBoardLimit runs the write of the blocks unless the boards they
+// create take the workspace beyond the configured maximum of boards. The
+// boards are counted and written under the lock of the workspace, so two
+// creations can't both pass the limit with the same count. The templates
+// don't count.
+func (a *App) withinBoardLimit(c store.Container, blocks []model.Block, write func() error) error {
+	if a.config.MaxBoardsPerWorkspace <= 0 {
+		return write()
+	}
+
+	boardIDs := []string{}
+	for _, block := range blocks {
+		if block.Type != "board" {
+			continue
+		}
+		if isTemplate, _ := block.Fields["isTemplate"].(bool); isTemplate {
+			continue
+		}
+		boardIDs = append(boardIDs, block.ID)
+	}
+	if len(boardIDs) == 0 {
+		return write()
+	}
+
+	return a.withWorkspaceLock(c, func() error {
+		existing, err := a.store.GetExistingBoards(c, boardIDs)
+		if err != nil {
+			return err
+		}
+
+		newBoards := map[string]bool{}
+		for _, id := range boardIDs {
+			newBoards[id] = true
+		}
+		for _, id := range existing {
+			delete(newBoards, id)
+		}
+		if len(newBoards) == 0 {
+			return write()
+		}
+
+		count, err := a.store.CountBoards(c)
+		if err != nil {
+			return err
+		}
+
+		if count+len(newBoards) > a.config.MaxBoardsPerWorkspace {
+			return ErrBoardLimitReached
+		}
+
+		return write()
+	})
+}
+
+// PatchBlocks applies the patches, once their content is moderated and the
+// properties they set are validated, unless a bulk operation has the boards
+// of the blocks locked
+func (a *App) PatchBlocks(c store.Container, patches []model.BlockPatch, modifiedBy string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	if err := a.moderatePatches(patches); err != nil {
+		return err
+	}
+
+	if err := a.validatePatchedCardProperties(c, patches); err != nil {
+		return err
+	}
+
+	blockIDs := make([]string, 0, len(patches))
+	for _, patch := range patches {
+		blockIDs = append(blockIDs, patch.ID)
+	}
+
+	boardIDs, err := a.boardsOf(c, blockIDs)
+	if err != nil {
+		return err
+	}
+	// And the boards the blocks are moved to
+	for _, patch := range patches {
+		if patch.RootID != nil {
+			boardIDs = append(boardIDs, *patch.RootID)
+		}
+	}
+
+	if err := a.checkBoardLocks(c, boardIDs); err != nil {
+		return err
+	}
+	if err := a.store.PatchBlocks(c, patches, modifiedBy); err != nil {
+		return err
+	}
+
+	blocks, err := a.store.GetBlocksByIDs(c, blockIDs)
+	if err != nil {
+		return err
+	}
+
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, blocks)
+	for _, block := range blocks {
+		a.webhook.Dispatch(c.WorkspaceID, block, a.requestID)
+	}
+
+	return nil
+}
+
+// MergeBlocks merges the blocks with the boards of both of them locked
+func (a *App) MergeBlocks(c store.Container, targetID, sourceID string, strategy store.MergeStrategy, modifiedBy string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	sourceParentID, err := a.store.GetParentID(c, sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &store.ErrBlocksNotFound{BlockIDs: []string{sourceID}}
+	}
+	if err != nil {
+		return err
+	}
+	sourceRootID, err := a.store.GetRootID(c, sourceID)
+	if err != nil {
+		return err
+	}
+
+	boardIDs, err := a.boardsOf(c, []string{targetID, sourceID})
+	if err != nil {
+		return err
+	}
+
+	err = a.withBoardLocks(c, boardIDs, func() error {
+		return a.store.MergeBlocks(c, targetID, sourceID, strategy, modifiedBy)
+	})
+	if err != nil {
+		return err
+	}
+
+	blocks, err := a.store.GetBlocksByIDs(c, []string{targetID})
+	if err != nil {
+		return err
+	}
+	children, err := a.store.GetBlocksWithParent(c, targetID)
+	if err != nil {
+		return err
+	}
+	blocks = append(blocks, children...)
+
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, blocks)
+	a.wsServer.BroadcastBlockDelete(c.WorkspaceID, sourceID, sourceParentID, sourceRootID)
+	for _, block := range blocks {
+		a.webhook.Dispatch(c.WorkspaceID, block, a.requestID)
 	}
 
 	return nil
@@ -69,6 +342,8 @@ func (a *App) GetAllBlocks(c store.Container) ([]model.Block, error) {
 }
 
 func (a *App) DeleteBlock(c store.Container, blockID string, modifiedBy string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
 	blockIDsToNotify := []string{blockID}
 	parentID, err := a.GetParentID(c, blockID)
 	if err != nil {
@@ -79,12 +354,54 @@ func (a *App) DeleteBlock(c store.Container, blockID string, modifiedBy string)
 		blockIDsToNotify = append(blockIDsToNotify, parentID)
 	}
 
-	err = a.store.DeleteBlock(c, blockID, modifiedBy)
+	rootID, err := a.store.GetRootID(c, blockID)
 	if err != nil {
 		return err
 	}
 
-	a.wsServer.BroadcastBlockDelete(c.WorkspaceID, blockID, parentID)
+	if err := a.checkBoardLocks(c, []string{rootID}); err != nil {
+		return err
+	}
+	if err := a.store.DeleteBlock(c, blockID, modifiedBy); err != nil {
+		return err
+	}
+
+	a.wsServer.BroadcastBlockDelete(c.WorkspaceID, blockID, parentID, rootID)
 
 	return nil
 }
+
+func (a *App) CreateBoardSnapshot(c store.Container, boardID, createdBy string) (string, error) {
+	return a.store.CreateBoardSnapshot(c, boardID, createdBy)
+}
+
+func (a *App) GetBoardSnapshot(c store.Container, snapshotID string) (*model.BoardSnapshot, error) {
+	return a.store.GetBoardSnapshot(c, snapshotID)
+}
+
+// GetBoardSnapshots lists the snapshots of a board. The snapshots outlive
+// their board, so only a board without any must be in the workspace.
+func (a *App) GetBoardSnapshots(c store.Container, boardID string) ([]model.BoardSnapshot, error) {
+	snapshots, err := a.store.GetBoardSnapshots(c, boardID)
+	if err != nil || len(snapshots) > 0 {
+		return snapshots, err
+	}
+
+	exists, err := a.store.BoardExists(c, boardID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	return snapshots, nil
+}
+
+func (a *App) GetBoardActivity(c store.Container, boardID string, limit int, before int64, beforeID string) ([]model.BlockActivity, error) {
+	return a.store.GetBoardActivity(c, boardID, limit, before, beforeID)
+}
+
+func (a *App) GetBoardChecksum(c store.Container, boardID string) (string, error) {
+	return a.store.GetBoardChecksum(c, boardID)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/config"
 	st "github.com/mattermost/focalboard/server/services/store"
 	"github.com/mattermost/focalboard/server/services/store/mockstore"
@@ -23,7 +24,7 @@ func TestGetParentID(t *testing.T) {
 	auth := auth.New(&cfg, store)
 	sessionToken := "TESTTOKEN"
 	wsserver := ws.NewServer(auth, sessionToken)
-	webhook := webhook.NewClient(&cfg)
+	webhook := webhook.NewClient(&cfg, nil)
 	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
 
 	container := st.Container{
@@ -43,3 +44,161 @@ func TestGetParentID(t *testing.T) {
 		require.Equal(t, "block-not-found", err.Error())
 	})
 }
+
+func TestInsertBlocksBoardLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{MaxBoardsPerWorkspace: 2}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{
+		WorkspaceID: "0",
+	}
+	board := model.Block{ID: "board-id", RootID: "board-id", Type: "board"}
+	store.EXPECT().GetLockedBoards(gomock.Any(), gomock.Any()).Return([]string{}, nil).AnyTimes()
+	lockWorkspace := func() {
+		store.EXPECT().AcquireBoardLock(container, "0", gomock.Any(), boardLockTTL).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(container, "0", gomock.Any()).Return(nil)
+	}
+
+	t.Run("creation up to the limit", func(t *testing.T) {
+		lockWorkspace()
+		store.EXPECT().GetExistingBoards(container, []string{"board-id"}).Return([]string{}, nil)
+		store.EXPECT().CountBoards(container).Return(1, nil)
+		store.EXPECT().InsertBlock(container, board).Return(nil)
+
+		err := app.InsertBlocks(container, []model.Block{board})
+		require.NoError(t, err)
+	})
+
+	t.Run("creation beyond the limit", func(t *testing.T) {
+		lockWorkspace()
+		store.EXPECT().GetExistingBoards(container, []string{"board-id"}).Return([]string{}, nil)
+		store.EXPECT().CountBoards(container).Return(2, nil)
+
+		err := app.InsertBlocks(container, []model.Block{board})
+		require.Equal(t, ErrBoardLimitReached, err)
+	})
+
+	t.Run("updating an existing board at the limit", func(t *testing.T) {
+		lockWorkspace()
+		store.EXPECT().GetExistingBoards(container, []string{"board-id"}).Return([]string{"board-id"}, nil)
+		store.EXPECT().InsertBlock(container, board).Return(nil)
+
+		err := app.InsertBlocks(container, []model.Block{board})
+		require.NoError(t, err)
+	})
+
+	t.Run("the boards are counted and written under the lock of the workspace", func(t *testing.T) {
+		gomock.InOrder(
+			store.EXPECT().AcquireBoardLock(container, "0", gomock.Any(), boardLockTTL).Return(false, nil),
+			store.EXPECT().AcquireBoardLock(container, "0", gomock.Any(), boardLockTTL).Return(true, nil),
+			store.EXPECT().GetExistingBoards(container, []string{"board-id"}).Return([]string{}, nil),
+			store.EXPECT().CountBoards(container).Return(1, nil),
+			store.EXPECT().InsertBlock(container, board).Return(nil),
+			store.EXPECT().ReleaseBoardLock(container, "0", gomock.Any()).Return(nil),
+		)
+
+		err := app.InsertBlocks(container, []model.Block{board})
+		require.NoError(t, err)
+	})
+
+	t.Run("blocks other than boards", func(t *testing.T) {
+		card := model.Block{ID: "card-id", RootID: "board-id", ParentID: "board-id", Type: "card"}
+		store.EXPECT().InsertBlock(container, card).Return(nil)
+
+		err := app.InsertBlocks(container, []model.Block{card})
+		require.NoError(t, err)
+	})
+
+	t.Run("templates at the limit", func(t *testing.T) {
+		template := model.Block{ID: "template-id", RootID: "template-id", Type: "board", Fields: map[string]interface{}{"isTemplate": true}}
+		store.EXPECT().InsertBlock(container, template).Return(nil)
+
+		err := app.InsertBlocks(container, []model.Block{template})
+		require.NoError(t, err)
+	})
+}
+
+func TestCreateBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{
+		WorkspaceID: "0",
+	}
+
+	t.Run("with a default view", func(t *testing.T) {
+		store.EXPECT().CreateBoardWithDefaults(gomock.Eq(container), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(c st.Container, board model.Block, views []model.Block) error {
+				require.Equal(t, board.ID, board.RootID)
+				require.Len(t, views, 1)
+				require.Equal(t, "view", views[0].Type)
+				require.Equal(t, board.ID, views[0].ParentID)
+				require.Equal(t, "user-id", views[0].ModifiedBy)
+				return nil
+			})
+
+		blocks, err := app.CreateBoard(container, model.Block{Type: "board", ModifiedBy: "user-id"}, nil)
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		store.EXPECT().CreateBoardWithDefaults(gomock.Eq(container), gomock.Any(), gomock.Any()).Return(errors.New("view insert failed"))
+
+		blocks, err := app.CreateBoard(container, model.Block{ID: "board-id", Type: "board"}, []model.Block{{Type: "view"}})
+		require.EqualError(t, err, "view insert failed")
+		require.Nil(t, blocks)
+	})
+}
+
+func TestGetBoardSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{WorkspaceID: "0"}
+
+	t.Run("the snapshots of a deleted board", func(t *testing.T) {
+		snapshots := []model.BoardSnapshot{{ID: "snapshot-1", BoardID: "board-1"}}
+		store.EXPECT().GetBoardSnapshots(container, "board-1").Return(snapshots, nil)
+
+		result, err := app.GetBoardSnapshots(container, "board-1")
+		require.NoError(t, err)
+		require.Equal(t, snapshots, result)
+	})
+
+	t.Run("a board without snapshots", func(t *testing.T) {
+		store.EXPECT().GetBoardSnapshots(container, "board-2").Return([]model.BoardSnapshot{}, nil)
+		store.EXPECT().BoardExists(container, "board-2").Return(true, nil)
+
+		result, err := app.GetBoardSnapshots(container, "board-2")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		store.EXPECT().GetBoardSnapshots(container, "unknown").Return([]model.BoardSnapshot{}, nil)
+		store.EXPECT().BoardExists(container, "unknown").Return(false, nil)
+
+		_, err := app.GetBoardSnapshots(container, "unknown")
+		var notFoundErr *st.ErrBlocksNotFound
+		require.ErrorAs(t, err, &notFoundErr)
+	})
+}
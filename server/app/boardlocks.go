@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// ErrBoardLocked is returned when a board is locked by another operation
+var ErrBoardLocked = errors.New("the board is locked by another operation")
+
+// boardLockTTL is how long a board stays locked if the operation that
+// locked it never releases it, e.g. because the server stopped
+const boardLockTTL = 5 * time.Minute
+
+// withBoardLocks runs the operation with exclusive access to the boards, and
+// fails with ErrBoardLocked without running it if another operation has any
+// of them. It's for the bulk operations, like the imports and the merges,
+// the ordinary writes only check the locks.
+func (a *App) withBoardLocks(c store.Container, boardIDs []string, operation func() error) error {
+	sorted := uniqueBoardIDs(boardIDs)
+
+	holder := utils.CreateGUID()
+	locked := []string{}
+	defer func() {
+		for _, boardID := range locked {
+			if err := a.store.ReleaseBoardLock(c, boardID, holder); err != nil {
+				log.Printf("Unable to release the lock of board %s, it expires in %s: %v", boardID, boardLockTTL, err)
+			}
+		}
+	}()
+
+	for _, boardID := range sorted {
+		acquired, err := a.store.AcquireBoardLock(c, boardID, holder, boardLockTTL)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			return ErrBoardLocked
+		}
+		locked = append(locked, boardID)
+	}
+
+	return operation()
+}
+
+// workspaceLockWait is how long an operation waits for the lock of the
+// workspace, which the others only hold for a few writes
+const workspaceLockWait = 10 * time.Second
+
+// workspaceLockRetryInterval is how often the lock of the workspace is
+// retried while another operation has it
+const workspaceLockRetryInterval = 50 * time.Millisecond
+
+// withWorkspaceLock runs the operation with exclusive access to the
+// workspace, for the checks that span its boards. The lock is kept with the
+// locks of the boards, under the ID of the workspace, and it fails with
+// ErrBoardLocked if another operation holds it for longer than
+// workspaceLockWait.
+func (a *App) withWorkspaceLock(c store.Container, operation func() error) error {
+	holder := utils.CreateGUID()
+	deadline := time.Now().Add(workspaceLockWait)
+	for {
+		acquired, err := a.store.AcquireBoardLock(c, c.WorkspaceID, holder, boardLockTTL)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrBoardLocked
+		}
+		time.Sleep(workspaceLockRetryInterval)
+	}
+	defer func() {
+		if err := a.store.ReleaseBoardLock(c, c.WorkspaceID, holder); err != nil {
+			log.Printf("Unable to release the lock of workspace %s, it expires in %s: %v", c.WorkspaceID, boardLockTTL, err)
+		}
+	}()
+
+	return operation()
+}
+
+// checkBoardLocks fails with ErrBoardLocked if a bulk operation holds the
+// lock of any of the boards. The ordinary writes don't take the locks, so
+// the people editing the same board don't block each other.
+func (a *App) checkBoardLocks(c store.Container, boardIDs []string) error {
+	unique := uniqueBoardIDs(boardIDs)
+	if len(unique) == 0 {
+		return nil
+	}
+
+	locked, err := a.store.GetLockedBoards(c, unique)
+	if err != nil {
+		return err
+	}
+	if len(locked) > 0 {
+		return ErrBoardLocked
+	}
+
+	return nil
+}
+
+// uniqueBoardIDs returns the board IDs once each, sorted so the locks are
+// always acquired in the same order
+func uniqueBoardIDs(boardIDs []string) []string {
+	unique := map[string]bool{}
+	for _, boardID := range boardIDs {
+		if boardID != "" {
+			unique[boardID] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for boardID := range unique {
+		sorted = append(sorted, boardID)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// boardsOf returns the boards of the existing blocks
+func (a *App) boardsOf(c store.Container, blockIDs []string) ([]string, error) {
+	blocks, err := a.store.GetBlocksByIDs(c, blockIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	boardIDs := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		boardIDs = append(boardIDs, block.RootID)
+	}
+
+	return boardIDs, nil
+}
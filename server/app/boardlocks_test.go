@@ -0,0 +1,101 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardLocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{
+		WorkspaceID: "0",
+	}
+	blocks := []model.Block{
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card"},
+		{ID: "card-2", RootID: "board-2", ParentID: "board-2", Type: "card"},
+	}
+
+	t.Run("import with the boards locked", func(t *testing.T) {
+		var holder string
+		gomock.InOrder(
+			store.EXPECT().AcquireBoardLock(container, "board-1", gomock.Any(), boardLockTTL).DoAndReturn(
+				func(c st.Container, boardID, lockHolder string, ttl interface{}) (bool, error) {
+					holder = lockHolder
+					return true, nil
+				}),
+			store.EXPECT().AcquireBoardLock(container, "board-2", gomock.Any(), boardLockTTL).Return(true, nil),
+			store.EXPECT().InsertBlock(container, blocks[0]).Return(nil),
+			store.EXPECT().InsertBlock(container, blocks[1]).Return(nil),
+		)
+		store.EXPECT().ReleaseBoardLock(container, "board-1", gomock.Any()).DoAndReturn(
+			func(c st.Container, boardID, lockHolder string) error {
+				require.Equal(t, holder, lockHolder)
+				return nil
+			})
+		store.EXPECT().ReleaseBoardLock(container, "board-2", gomock.Any()).Return(nil)
+
+		require.NoError(t, app.ImportBlocks(container, blocks))
+	})
+
+	t.Run("a board locked by another operation", func(t *testing.T) {
+		store.EXPECT().AcquireBoardLock(container, "board-1", gomock.Any(), boardLockTTL).Return(true, nil)
+		store.EXPECT().AcquireBoardLock(container, "board-2", gomock.Any(), boardLockTTL).Return(false, nil)
+		// The locks that were acquired are released, and nothing is inserted
+		store.EXPECT().ReleaseBoardLock(container, "board-1", gomock.Any()).Return(nil)
+
+		err := app.ImportBlocks(container, blocks)
+		require.Equal(t, ErrBoardLocked, err)
+	})
+
+	t.Run("concurrent inserts into the same board", func(t *testing.T) {
+		// The ordinary writes only check for the locks of the bulk
+		// operations, so they don't lock each other out
+		store.EXPECT().GetLockedBoards(container, []string{"board-1"}).Return([]string{}, nil).Times(2)
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil).Times(2)
+
+		errs := make(chan error, 2)
+		for _, id := range []string{"card-3", "card-4"} {
+			go func(id string) {
+				errs <- app.InsertBlocks(container, []model.Block{{ID: id, RootID: "board-1", ParentID: "board-1", Type: "card"}})
+			}(id)
+		}
+		require.NoError(t, <-errs)
+		require.NoError(t, <-errs)
+	})
+
+	t.Run("patches moving a block to a locked board", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1"}).Return(blocks[:1], nil)
+		store.EXPECT().GetLockedBoards(container, []string{"board-1", "board-2"}).Return([]string{"board-2"}, nil)
+
+		boardID := "board-2"
+		err := app.PatchBlocks(container, []model.BlockPatch{{ID: "card-1", RootID: &boardID, ParentID: &boardID}}, "user-id")
+		require.Equal(t, ErrBoardLocked, err)
+	})
+
+	t.Run("deletes from a locked board", func(t *testing.T) {
+		store.EXPECT().GetParentID(container, "card-1").Return("board-1", nil)
+		store.EXPECT().GetRootID(container, "card-1").Return("board-1", nil)
+		store.EXPECT().GetLockedBoards(container, []string{"board-1"}).Return([]string{"board-1"}, nil)
+
+		err := app.DeleteBlock(container, "card-1", "user-id")
+		require.Equal(t, ErrBoardLocked, err)
+	})
+}
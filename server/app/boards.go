@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// ErrNotABoard is returned when the block to create as a board isn't one
+var ErrNotABoard = errors.New("the block to create isn't a board")
+
+// CreateBoard creates a board with its views in a single transaction, with
+// a board view if none is given, and returns the created blocks
+func (a *App) CreateBoard(c store.Container, board model.Block, views []model.Block) ([]model.Block, error) {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	if board.Type != "board" {
+		return nil, ErrNotABoard
+	}
+
+	now := utils.GetMillis()
+	stamp := func(block *model.Block) {
+		if block.ID == "" {
+			block.ID = utils.CreateGUID()
+		}
+		if block.CreateAt == 0 {
+			block.CreateAt = now
+		}
+		if block.UpdateAt == 0 {
+			block.UpdateAt = now
+		}
+		if block.ModifiedBy == "" {
+			block.ModifiedBy = board.ModifiedBy
+		}
+	}
+
+	stamp(&board)
+	board.RootID = board.ID
+
+	if len(views) == 0 {
+		views = []model.Block{{
+			Type:   "view",
+			Title:  "Board view",
+			Fields: map[string]interface{}{"viewType": "board"},
+		}}
+	}
+
+	views = append([]model.Block{}, views...)
+	for i := range views {
+		stamp(&views[i])
+		views[i].ParentID = board.ID
+		views[i].RootID = board.ID
+	}
+
+	if err := a.checkBlocks(c, append([]model.Block{board}, views...)); err != nil {
+		return nil, err
+	}
+
+	err := a.withinBoardLimit(c, []model.Block{board}, func() error {
+		return a.store.CreateBoardWithDefaults(c, board, views)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := append([]model.Block{board}, views...)
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, blocks)
+	for _, block := range blocks {
+		a.webhook.Dispatch(c.WorkspaceID, block, a.requestID)
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,400 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// CopyCardToBoard copies a card with its content into a board, which can be
+// the board of the card, and returns the copy. The copies get new IDs, so
+// they're independent of the originals, and the comments aren't copied. The
+// properties are mapped onto the schema of the target board, the copies are
+// moderated and validated like the blocks of an insert, and the files of the
+// content are referenced by new files of the target board.
+func (a *App) CopyCardToBoard(c store.Container, cardID, targetBoardID, modifiedBy string) (*model.Block, error) {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	blocks, err := a.store.GetBlocksByIDs(c, []string{cardID, targetBoardID})
+	if err != nil {
+		return nil, err
+	}
+
+	var card, target *model.Block
+	for i := range blocks {
+		switch {
+		case blocks[i].ID == cardID && blocks[i].Type == "card":
+			card = &blocks[i]
+		case blocks[i].ID == targetBoardID && blocks[i].Type == "board":
+			target = &blocks[i]
+		}
+	}
+
+	missing := []string{}
+	if card == nil {
+		missing = append(missing, cardID)
+	}
+	if target == nil {
+		missing = append(missing, targetBoardID)
+	}
+	if len(missing) > 0 {
+		return nil, &store.ErrBlocksNotFound{BlockIDs: missing}
+	}
+
+	source := *target
+	if card.RootID != targetBoardID {
+		boards, err := a.store.GetBlocksByIDs(c, []string{card.RootID})
+		if err != nil {
+			return nil, err
+		}
+		source = model.Block{}
+		if len(boards) > 0 {
+			source = boards[0]
+		}
+	}
+
+	content, err := a.cardContent(c, *card)
+	if err != nil {
+		return nil, err
+	}
+
+	newIDs := map[string]string{card.ID: utils.CreateGUID()}
+	for _, block := range content {
+		newIDs[block.ID] = utils.CreateGUID()
+	}
+
+	now := utils.GetMillis()
+	copyBlock := func(block model.Block, parentID string) (model.Block, error) {
+		fields, err := copyFields(block.Fields)
+		if err != nil {
+			return model.Block{}, err
+		}
+
+		block.ID = newIDs[block.ID]
+		block.ParentID = parentID
+		block.RootID = targetBoardID
+		block.ModifiedBy = modifiedBy
+		block.Fields = fields
+		block.CreateAt = now
+		block.UpdateAt = now
+		block.DeleteAt = 0
+		return block, nil
+	}
+
+	cardCopy, err := copyBlock(*card, targetBoardID)
+	if err != nil {
+		return nil, err
+	}
+	properties, _ := cardCopy.Fields["properties"].(map[string]interface{})
+	if properties != nil {
+		cardCopy.Fields["properties"] = mapCardProperties(source, *target, properties)
+	}
+	if order, ok := cardCopy.Fields["contentOrder"].([]interface{}); ok {
+		cardCopy.Fields["contentOrder"] = remapContentOrder(order, newIDs)
+	}
+
+	contentCopy := make([]model.Block, 0, len(content))
+	for _, block := range content {
+		blockCopy, err := copyBlock(block, newIDs[block.ParentID])
+		if err != nil {
+			return nil, err
+		}
+		contentCopy = append(contentCopy, blockCopy)
+	}
+
+	// The copies are new blocks of the target board, so they're checked
+	// like the blocks of an insert
+	if err := a.checkBlocks(c, append([]model.Block{cardCopy}, contentCopy...)); err != nil {
+		return nil, err
+	}
+
+	// The files are copied next, and removed again if the copy fails
+	var copiedFiles []func()
+	cleanUp := func() {
+		for _, remove := range copiedFiles {
+			remove()
+		}
+	}
+
+	for i := range contentCopy {
+		fileID, _ := contentCopy[i].Fields["fileId"].(string)
+		if fileID == "" {
+			continue
+		}
+
+		newFileID, remove, err := a.copyCardFile(c.WorkspaceID, card.RootID, targetBoardID, fileID, now)
+		if err != nil {
+			cleanUp()
+			return nil, err
+		}
+		if remove != nil {
+			copiedFiles = append(copiedFiles, remove)
+		}
+		contentCopy[i].Fields["fileId"] = newFileID
+	}
+
+	err = a.withBoardLocks(c, []string{targetBoardID}, func() error {
+		return a.store.CopyCard(c, cardCopy, contentCopy)
+	})
+	if err != nil {
+		cleanUp()
+		return nil, err
+	}
+
+	copies := append([]model.Block{cardCopy}, contentCopy...)
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, copies)
+	for _, block := range copies {
+		a.webhook.Dispatch(c.WorkspaceID, block, a.requestID)
+	}
+
+	return &cardCopy, nil
+}
+
+// cardContent returns the blocks under a card, parents first, leaving out
+// the comments and anything under them
+func (a *App) cardContent(c store.Container, card model.Block) ([]model.Block, error) {
+	blocks, err := a.store.GetBlocksWithRootID(c, card.RootID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := map[string][]model.Block{}
+	for _, block := range blocks {
+		children[block.ParentID] = append(children[block.ParentID], block)
+	}
+
+	content := []model.Block{}
+	parents := []string{card.ID}
+	for len(parents) > 0 {
+		parentID := parents[0]
+		parents = parents[1:]
+		for _, block := range children[parentID] {
+			if block.Type == "comment" || block.ID == card.ID {
+				continue
+			}
+			content = append(content, block)
+			parents = append(parents, block.ID)
+		}
+	}
+
+	return content, nil
+}
+
+// copyCardFile copies a file of the content of a card for a board, and
+// returns the ID of the copy with a function that removes it. The files
+// stored by their hash get a new reference to the same blob, and the legacy
+// files get a copy in the directory of the board. A legacy file that's gone
+// is left referenced as it is.
+func (a *App) copyCardFile(workspaceID, sourceBoardID, targetBoardID, fileID string, now int64) (string, func(), error) {
+	newFileID := utils.CreateGUID() + filepath.Ext(fileID)
+
+	ref, err := a.store.GetFileRef(fileID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", nil, err
+	}
+
+	if err == nil && ref.WorkspaceID == workspaceID && ref.RootID == sourceBoardID {
+		blob, err := a.store.GetFileBlob(ref.Hash)
+		if err != nil {
+			return "", nil, err
+		}
+
+		copyRef := model.FileRef{
+			ID:          newFileID,
+			WorkspaceID: workspaceID,
+			RootID:      targetBoardID,
+			Hash:        ref.Hash,
+			CreateAt:    now,
+		}
+		if _, err := a.store.CreateFileRef(copyRef, blob.Size); err != nil {
+			return "", nil, err
+		}
+
+		return newFileID, func() {
+			if err := a.store.DeleteFileRef(newFileID, a.removeBlob); err != nil {
+				log.Printf("ERROR deleting file '%s': %v", newFileID, err)
+			}
+		}, nil
+	}
+
+	sourcePath := filepath.Join(workspaceID, sourceBoardID, fileID)
+	if exists, err := a.filesBackend.FileExists(sourcePath); err != nil {
+		return "", nil, err
+	} else if !exists {
+		log.Printf("Copying a card that references the missing file '%s'", sourcePath)
+		return fileID, nil, nil
+	}
+
+	copyPath := filepath.Join(workspaceID, targetBoardID, newFileID)
+	if err := a.filesBackend.CopyFile(sourcePath, copyPath); err != nil {
+		return "", nil, err
+	}
+
+	return newFileID, func() { a.removeFile(copyPath) }, nil
+}
+
+// copyFields returns a deep copy of the fields of a block, so the copy
+// doesn't share the nested values with the original
+func copyFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := map[string]interface{}{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// remapContentOrder replaces the IDs of the content order of a card, a list
+// of IDs and of rows of IDs, with the IDs of the copies, dropping the ones
+// that weren't copied
+func remapContentOrder(order []interface{}, newIDs map[string]string) []interface{} {
+	remapped := []interface{}{}
+	for _, entry := range order {
+		switch value := entry.(type) {
+		case string:
+			if newID, ok := newIDs[value]; ok {
+				remapped = append(remapped, newID)
+			}
+		case []interface{}:
+			if row := remapContentOrder(value, newIDs); len(row) > 0 {
+				remapped = append(remapped, row)
+			}
+		}
+	}
+
+	return remapped
+}
+
+// mapCardProperties maps the values of the properties of a card of the
+// source board onto the schema of the target board. A property maps to the
+// target property with the same ID and type, or else to the one with the
+// same name and type, and the options map by their ID or else their value.
+// The values that don't map, or aren't valid for the target property, are
+// dropped.
+func mapCardProperties(source, target model.Block, properties map[string]interface{}) map[string]interface{} {
+	sourceSchema := map[string]map[string]interface{}{}
+	for _, property := range cardPropertySchema(source) {
+		sourceSchema[property["id"].(string)] = property
+	}
+	targetSchema := cardPropertySchema(target)
+
+	mapped := map[string]interface{}{}
+	for propertyID, value := range properties {
+		sourceProperty, ok := sourceSchema[propertyID]
+		if !ok {
+			sourceProperty = map[string]interface{}{"id": propertyID}
+		}
+
+		targetProperty := matchProperty(sourceProperty, targetSchema)
+		if targetProperty == nil {
+			continue
+		}
+
+		value = mapPropertyValue(sourceProperty, targetProperty, value)
+		if value == nil || invalidPropertyValue(targetProperty, value) != "" {
+			continue
+		}
+		mapped[targetProperty["id"].(string)] = value
+	}
+
+	return mapped
+}
+
+// matchProperty returns the property of the schema a property maps to, or
+// nil if there's none
+func matchProperty(property map[string]interface{}, schema []map[string]interface{}) map[string]interface{} {
+	propertyType, _ := property["type"].(string)
+	name, _ := property["name"].(string)
+
+	var byName map[string]interface{}
+	for _, candidate := range schema {
+		candidateType, _ := candidate["type"].(string)
+		if candidate["id"] == property["id"] && (propertyType == "" || candidateType == propertyType) {
+			return candidate
+		}
+
+		candidateName, _ := candidate["name"].(string)
+		if byName == nil && name != "" && candidateType == propertyType && samePropertyLabel(candidateName, name) {
+			byName = candidate
+		}
+	}
+
+	return byName
+}
+
+// mapPropertyValue maps the options of a value onto the options of the
+// target property, or returns nil if none of them map
+func mapPropertyValue(source, target map[string]interface{}, value interface{}) interface{} {
+	switch target["type"] {
+	case "select":
+		optionID, _ := value.(string)
+		if mapped := mapPropertyOption(source, target, optionID); mapped != "" {
+			return mapped
+		}
+		return nil
+
+	case "multiSelect":
+		optionIDs, _ := value.([]interface{})
+		mapped := []interface{}{}
+		for _, o := range optionIDs {
+			optionID, _ := o.(string)
+			if mappedID := mapPropertyOption(source, target, optionID); mappedID != "" {
+				mapped = append(mapped, mappedID)
+			}
+		}
+		if len(mapped) == 0 {
+			return nil
+		}
+		return mapped
+	}
+
+	return value
+}
+
+func mapPropertyOption(source, target map[string]interface{}, optionID string) string {
+	if optionID == "" {
+		return ""
+	}
+	if isPropertyOption(target, optionID) {
+		return optionID
+	}
+
+	var label string
+	sourceOptions, _ := source["options"].([]interface{})
+	for _, o := range sourceOptions {
+		if option, ok := o.(map[string]interface{}); ok && option["id"] == optionID {
+			label, _ = option["value"].(string)
+		}
+	}
+	if label == "" {
+		return ""
+	}
+
+	targetOptions, _ := target["options"].([]interface{})
+	for _, o := range targetOptions {
+		if option, ok := o.(map[string]interface{}); ok {
+			if value, _ := option["value"].(string); samePropertyLabel(value, label) {
+				id, _ := option["id"].(string)
+				return id
+			}
+		}
+	}
+
+	return ""
+}
+
+func samePropertyLabel(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
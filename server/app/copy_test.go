@@ -0,0 +1,250 @@
+package app
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyCardToBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, err := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.NoError(t, err)
+
+	cfg := config.Configuration{FilesPath: filesPath}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	container := st.Container{WorkspaceID: "workspace-1"}
+	source := model.Block{ID: "board-1", RootID: "board-1", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{
+			map[string]interface{}{"id": "status", "name": "Status", "type": "select", "options": []interface{}{
+				map[string]interface{}{"id": "status-done", "value": "Done"},
+				map[string]interface{}{"id": "status-todo", "value": "To do"},
+			}},
+			map[string]interface{}{"id": "estimate", "name": "Estimate", "type": "number"},
+			map[string]interface{}{"id": "owner", "name": "Owner", "type": "text"},
+		},
+	}}
+	target := model.Block{ID: "board-2", RootID: "board-2", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{
+			map[string]interface{}{"id": "state", "name": "status", "type": "select", "options": []interface{}{
+				map[string]interface{}{"id": "state-done", "value": "done"},
+			}},
+			map[string]interface{}{"id": "estimate", "name": "Estimate", "type": "number"},
+			map[string]interface{}{"id": "owner", "name": "Owner", "type": "person"},
+		},
+	}}
+	card := model.Block{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Card", Fields: map[string]interface{}{
+		"properties":   map[string]interface{}{"status": "status-done", "estimate": "3", "owner": "someone"},
+		"contentOrder": []interface{}{"text-1", []interface{}{"image-1", "missing"}},
+	}}
+	content := []model.Block{
+		card,
+		{ID: "text-1", ParentID: "card-1", RootID: "board-1", Type: "text", Title: "Some text"},
+		{ID: "image-1", ParentID: "card-1", RootID: "board-1", Type: "image", Fields: map[string]interface{}{"fileId": "file-1.png"}},
+		{ID: "comment-1", ParentID: "card-1", RootID: "board-1", Type: "comment", Title: "A comment"},
+		{ID: "card-2", ParentID: "board-1", RootID: "board-1", Type: "card"},
+	}
+
+	expectLock := func() {
+		store.EXPECT().AcquireBoardLock(container, "board-2", gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(container, "board-2", gomock.Any()).Return(nil)
+	}
+
+	t.Run("copy the card and its content", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1", "board-2"}).Return([]model.Block{card, target}, nil)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{source}, nil)
+		store.EXPECT().GetBlocksWithRootID(container, "board-1").Return(content, nil)
+		store.EXPECT().GetFileRef("file-1.png").Return(&model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "ab0123"}, nil)
+		store.EXPECT().GetFileBlob("ab0123").Return(&model.FileBlob{Hash: "ab0123", Size: 5}, nil)
+
+		var ref model.FileRef
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(r model.FileRef, size int64) (bool, error) {
+			ref = r
+			return false, nil
+		})
+		expectLock()
+
+		var copiedCard model.Block
+		var copiedContent []model.Block
+		store.EXPECT().CopyCard(container, gomock.Any(), gomock.Any()).DoAndReturn(func(c st.Container, card model.Block, content []model.Block) error {
+			copiedCard = card
+			copiedContent = content
+			return nil
+		})
+
+		copied, err := app.CopyCardToBoard(container, "card-1", "board-2", "user-1")
+		require.NoError(t, err)
+		require.Equal(t, copiedCard, *copied)
+
+		require.NotEqual(t, "card-1", copied.ID)
+		require.Equal(t, "board-2", copied.ParentID)
+		require.Equal(t, "board-2", copied.RootID)
+		require.Equal(t, "user-1", copied.ModifiedBy)
+		require.Equal(t, "Card", copied.Title)
+
+		// The status maps by name and option value, the estimate by ID, and
+		// the owner doesn't have the same type on the target board
+		require.Equal(t, map[string]interface{}{"state": "state-done", "estimate": "3"}, copied.Fields["properties"])
+
+		// The comment isn't copied
+		require.Len(t, copiedContent, 2)
+		text, image := copiedContent[0], copiedContent[1]
+		require.Equal(t, "Some text", text.Title)
+		for _, block := range copiedContent {
+			require.NotContains(t, []string{"text-1", "image-1"}, block.ID)
+			require.Equal(t, copied.ID, block.ParentID)
+			require.Equal(t, "board-2", block.RootID)
+		}
+		require.Equal(t, []interface{}{text.ID, []interface{}{image.ID}}, copied.Fields["contentOrder"])
+
+		// The image references a new file with the same content
+		require.Equal(t, ref.ID, image.Fields["fileId"])
+		require.NotEqual(t, "file-1.png", ref.ID)
+		require.Equal(t, ".png", filepath.Ext(ref.ID))
+		require.Equal(t, "board-2", ref.RootID)
+		require.Equal(t, "ab0123", ref.Hash)
+
+		// The copy doesn't share anything with the original
+		copied.Fields["properties"].(map[string]interface{})["estimate"] = "5"
+		require.Equal(t, "3", card.Fields["properties"].(map[string]interface{})["estimate"])
+		require.Equal(t, []interface{}{"text-1", []interface{}{"image-1", "missing"}}, card.Fields["contentOrder"])
+		require.Equal(t, "file-1.png", content[2].Fields["fileId"])
+	})
+
+	t.Run("copy a legacy file", func(t *testing.T) {
+		_, err := filesBackend.WriteFile(bytes.NewBufferString("legacy image"), filepath.Join("workspace-1", "board-1", "legacy.png"))
+		require.NoError(t, err)
+		legacy := []model.Block{
+			card,
+			{ID: "image-1", ParentID: "card-1", RootID: "board-1", Type: "image", Fields: map[string]interface{}{"fileId": "legacy.png"}},
+		}
+
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1", "board-2"}).Return([]model.Block{card, target}, nil)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{source}, nil)
+		store.EXPECT().GetBlocksWithRootID(container, "board-1").Return(legacy, nil)
+		store.EXPECT().GetFileRef("legacy.png").Return(nil, sql.ErrNoRows)
+		expectLock()
+
+		var fileID string
+		store.EXPECT().CopyCard(container, gomock.Any(), gomock.Any()).DoAndReturn(func(c st.Container, card model.Block, content []model.Block) error {
+			fileID = content[0].Fields["fileId"].(string)
+			return nil
+		})
+
+		_, err = app.CopyCardToBoard(container, "card-1", "board-2", "user-1")
+		require.NoError(t, err)
+
+		data, err := filesBackend.ReadFile(filepath.Join("workspace-1", "board-2", fileID))
+		require.NoError(t, err)
+		require.Equal(t, "legacy image", string(data))
+	})
+
+	t.Run("the files are removed when the copy fails", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1", "board-2"}).Return([]model.Block{card, target}, nil)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{source}, nil)
+		store.EXPECT().GetBlocksWithRootID(container, "board-1").Return(content, nil)
+		store.EXPECT().GetFileRef("file-1.png").Return(&model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "ab0123"}, nil)
+		store.EXPECT().GetFileBlob("ab0123").Return(&model.FileBlob{Hash: "ab0123", Size: 5}, nil)
+
+		var ref model.FileRef
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(r model.FileRef, size int64) (bool, error) {
+			ref = r
+			return false, nil
+		})
+		expectLock()
+		store.EXPECT().CopyCard(container, gomock.Any(), gomock.Any()).Return(errors.New("database is locked"))
+		store.EXPECT().DeleteFileRef(gomock.Any(), gomock.Any()).DoAndReturn(func(id string, removeBlob func(string) error) error {
+			require.Equal(t, ref.ID, id)
+			return nil
+		})
+
+		_, err := app.CopyCardToBoard(container, "card-1", "board-2", "user-1")
+		require.EqualError(t, err, "database is locked")
+	})
+
+	t.Run("the copies are moderated before anything is copied", func(t *testing.T) {
+		moderated := app.WithModerator(NewWordListModerator([]string{"text"}))
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1", "board-2"}).Return([]model.Block{card, target}, nil)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{source}, nil)
+		store.EXPECT().GetBlocksWithRootID(container, "board-1").Return(content, nil)
+
+		_, err := moderated.CopyCardToBoard(container, "card-1", "board-2", "user-1")
+		var rejectedErr *ErrContentRejected
+		require.True(t, errors.As(err, &rejectedErr))
+	})
+
+	t.Run("not a card", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1", "board-2"}).Return([]model.Block{source, target}, nil)
+
+		_, err := app.CopyCardToBoard(container, "board-1", "board-2", "user-1")
+		var notFoundErr *st.ErrBlocksNotFound
+		require.True(t, errors.As(err, &notFoundErr))
+		require.Equal(t, []string{"board-1"}, notFoundErr.BlockIDs)
+	})
+}
+
+func TestMapCardProperties(t *testing.T) {
+	board := model.Block{ID: "board-1", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{
+			map[string]interface{}{"id": "tags", "name": "Tags", "type": "multiSelect", "options": []interface{}{
+				map[string]interface{}{"id": "tag-a", "value": "A"},
+				map[string]interface{}{"id": "tag-b", "value": "B"},
+			}},
+			map[string]interface{}{"id": "done", "name": "Done", "type": "checkbox"},
+		},
+	}}
+
+	t.Run("the same board keeps the values", func(t *testing.T) {
+		properties := map[string]interface{}{"tags": []interface{}{"tag-a"}, "done": "true"}
+		require.Equal(t, properties, mapCardProperties(board, board, properties))
+	})
+
+	t.Run("the unmapped values are dropped", func(t *testing.T) {
+		target := model.Block{ID: "board-2", Type: "board", Fields: map[string]interface{}{
+			"cardProperties": []interface{}{
+				map[string]interface{}{"id": "labels", "name": "tags", "type": "multiSelect", "options": []interface{}{
+					map[string]interface{}{"id": "label-b", "value": "b"},
+				}},
+				map[string]interface{}{"id": "done", "name": "Done", "type": "checkbox"},
+			},
+		}}
+
+		mapped := mapCardProperties(board, target, map[string]interface{}{
+			"tags":    []interface{}{"tag-a", "tag-b"},
+			"done":    "maybe",
+			"unknown": "value",
+		})
+		require.Equal(t, map[string]interface{}{"labels": []interface{}{"label-b"}}, mapped)
+	})
+
+	t.Run("a board without properties", func(t *testing.T) {
+		target := model.Block{ID: "board-2", Type: "board"}
+		require.Empty(t, mapCardProperties(board, target, map[string]interface{}{"tags": []interface{}{"tag-a"}}))
+	})
+}
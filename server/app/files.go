@@ -1,37 +1,296 @@
 package app
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
 )
 
-func (a *App) SaveFile(reader io.Reader, workspaceID, rootID, filename string) (string, error) {
+const (
+	blobsDirectory   = "blobs"
+	uploadsDirectory = "uploads"
+)
+
+// ErrContentTypeNotAllowed is returned when uploading a file whose content
+// isn't of any of the types allowed by the configuration
+var ErrContentTypeNotAllowed = errors.New("the content type of the file isn't allowed")
+
+// ErrStorageQuotaExceeded is returned when uploading a file to a workspace
+// whose files already use the storage quota of the configuration
+var ErrStorageQuotaExceeded = errors.New("the workspace has reached its storage quota")
+
+// checkStorageQuota fails if the files of the workspace with the upload of a
+// size would be over the configured quota. An upload of an unknown size, 0,
+// is only rejected once the quota is reached.
+func (a *App) checkStorageQuota(workspaceID string, size int64) error {
+	if a.config.WorkspaceStorageQuota <= 0 {
+		return nil
+	}
+
+	usage, err := a.store.GetWorkspaceStorageUsage(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if usage >= a.config.WorkspaceStorageQuota || usage+size > a.config.WorkspaceStorageQuota {
+		log.Printf("Upload rejected, workspace: %s, storage usage: %d, upload size: %d", workspaceID, usage, size)
+		return ErrStorageQuotaExceeded
+	}
+
+	return nil
+}
+
+func (a *App) GetWorkspaceStorageUsage(workspaceID string) (int64, error) {
+	return a.store.GetWorkspaceStorageUsage(workspaceID)
+}
+
+// checkContentType detects the type of the content, regardless of the name
+// of the file, and fails if the configuration doesn't allow it. It returns a
+// reader with the whole content, including the part read to detect it.
+func (a *App) checkContentType(reader io.Reader) (io.Reader, error) {
+	if len(a.config.AllowedUploadContentTypes) == 0 {
+		return reader, nil
+	}
+
+	// DetectContentType considers at most the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, allowed := range a.config.AllowedUploadContentTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == contentType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*"))) {
+			return io.MultiReader(bytes.NewReader(head), reader), nil
+		}
+	}
+
+	log.Printf("Upload rejected, content type: %s", contentType)
+	return nil, ErrContentTypeNotAllowed
+}
+
+// SaveFile stores an uploaded file under the SHA-256 hash of its content, so
+// identical uploads share a single blob in the files storage. Content of a
+// type not allowed by the configuration is rejected, and so are the uploads
+// that would take a workspace over its storage quota. The size is the one of
+// the upload given by the client, 0 if it's unknown.
+func (a *App) SaveFile(reader io.Reader, workspaceID, rootID, filename string, size int64) (string, error) {
 	// NOTE: File extension includes the dot
 	fileExtension := strings.ToLower(filepath.Ext(filename))
 	if fileExtension == ".jpeg" {
 		fileExtension = ".jpg"
 	}
 
+	if a.uploads != nil {
+		if err := a.uploads.begin(); err != nil {
+			return "", err
+		}
+		defer a.uploads.end()
+		reader = a.uploads.abortable(reader)
+	}
+
+	if err := a.checkStorageQuota(workspaceID, size); err != nil {
+		return "", err
+	}
+
+	reader, err := a.checkContentType(reader)
+	if err != nil {
+		return "", err
+	}
+
 	createdFilename := fmt.Sprintf(`%s%s`, utils.CreateGUID(), fileExtension)
-	filePath := filepath.Join(workspaceID, rootID, createdFilename)
 
-	_, appErr := a.filesBackend.WriteFile(reader, filePath)
+	// The hash is only known once the upload is written, so it's written to
+	// a temporary path and moved to the blob path if it's new content
+	uploadPath := filepath.Join(uploadsDirectory, createdFilename)
+
+	// The upload session lets the partial content be cleaned up if the
+	// server stops before the upload completes
+	now := utils.GetMillis()
+	session := model.UploadSession{
+		ID:          createdFilename,
+		WorkspaceID: workspaceID,
+		RootID:      rootID,
+		Path:        uploadPath,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	if err := a.store.CreateUploadSession(session); err != nil {
+		return "", err
+	}
+	defer a.deleteUploadSession(session.ID)
+
+	hash := sha256.New()
+	size, appErr := a.filesBackend.WriteFile(io.TeeReader(reader, hash), uploadPath)
 	if appErr != nil {
+		a.removeFile(uploadPath)
 		return "", errors.New("unable to store the file in the files storage")
 	}
 
+	ref := model.FileRef{
+		ID:          createdFilename,
+		WorkspaceID: workspaceID,
+		RootID:      rootID,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		CreateAt:    utils.GetMillis(),
+	}
+
+	if err := a.storeBlob(uploadPath, ref, size); err != nil {
+		return "", err
+	}
+
 	return createdFilename, nil
 }
 
+// storeBlob references the blob of the content written to an upload path by
+// a file, moving the content to the blob path first if there's no blob with
+// it yet, so a file is never referenced before its content is stored. A blob
+// left unreferenced by a failure is found by FindOrphanedFiles.
+func (a *App) storeBlob(uploadPath string, ref model.FileRef, size int64) error {
+	path := blobPath(ref.Hash)
+	exists, err := a.filesBackend.FileExists(path)
+	if err != nil {
+		a.removeFile(uploadPath)
+		return err
+	}
+
+	if !exists {
+		if err := a.filesBackend.MoveFile(uploadPath, path); err != nil {
+			a.removeFile(uploadPath)
+			return errors.New("unable to store the file in the files storage")
+		}
+	}
+
+	created, err := a.store.CreateFileRef(ref, size)
+	if err != nil {
+		if exists {
+			a.removeFile(uploadPath)
+		}
+		return err
+	}
+
+	if !exists {
+		return nil
+	}
+
+	// The blob was deleted with its last file in between, so the upload
+	// becomes its content again
+	if created {
+		if err := a.filesBackend.MoveFile(uploadPath, path); err != nil {
+			return errors.New("unable to store the file in the files storage")
+		}
+		return nil
+	}
+
+	a.removeFile(uploadPath)
+	return nil
+}
+
+// DeleteFile removes an uploaded file, and the blob with its content if no
+// other file has the same content
+func (a *App) DeleteFile(workspaceID, rootID, filename string) error {
+	ref, err := a.store.GetFileRef(filename)
+	if err != nil {
+		return err
+	}
+	if ref.WorkspaceID != workspaceID || ref.RootID != rootID {
+		return sql.ErrNoRows
+	}
+
+	return a.store.DeleteFileRef(filename, a.removeBlob)
+}
+
+// removeBlob removes the content of a blob deleted with its last file. It's
+// called while the blob is deleted, so an upload of the same content at the
+// same time stores the content again after the removal.
+func (a *App) removeBlob(hash string) error {
+	path := blobPath(hash)
+	exists, err := a.filesBackend.FileExists(path)
+	if err != nil || !exists {
+		return err
+	}
+
+	return a.filesBackend.RemoveFile(path)
+}
+
+// CleanUpAbandonedUploads removes the partial content of the uploads that
+// haven't been written for longer than the upload session TTL, and returns
+// how many were removed. Nothing is removed if the TTL is 0 or less.
+func (a *App) CleanUpAbandonedUploads() (int, error) {
+	if a.config.UploadSessionTTL <= 0 {
+		return 0, nil
+	}
+
+	olderThan := utils.GetMillis() - a.config.UploadSessionTTL*1000
+
+	sessions, err := a.store.GetAbandonedUploadSessions(olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, session := range sessions {
+		a.removeFile(session.Path)
+		if err := a.store.DeleteUploadSession(session.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(sessions), nil
+}
+
+func (a *App) deleteUploadSession(id string) {
+	if err := a.store.DeleteUploadSession(id); err != nil {
+		log.Printf("ERROR deleting upload session '%s': %v", id, err)
+	}
+}
+
+func (a *App) removeFile(path string) {
+	if err := a.filesBackend.RemoveFile(path); err != nil {
+		log.Printf("ERROR removing file '%s': %v", path, err)
+	}
+}
+
+// blobPath returns the path of the blob with a hash, spread over
+// subdirectories to keep them small
+func blobPath(hash string) string {
+	return filepath.Join(blobsDirectory, hash[:2], hash)
+}
+
+// FindOrphanedFiles lists the files of the files storage that nothing
+// references, without removing them, so they can be reviewed before a cleanup
+func (a *App) FindOrphanedFiles() ([]model.FileInfo, error) {
+	return a.store.FindOrphanedFiles()
+}
+
 func (a *App) GetFilePath(workspaceID, rootID, filename string) string {
 	folderPath := a.config.FilesPath
+
+	ref, err := a.store.GetFileRef(filename)
+	if err == nil && ref.WorkspaceID == workspaceID && ref.RootID == rootID {
+		return filepath.Join(folderPath, blobPath(ref.Hash))
+	}
+
+	// Files uploaded before the blobs are stored by their own name
 	rootPath := filepath.Join(folderPath, workspaceID, rootID)
 
 	filePath := filepath.Join(rootPath, filename)
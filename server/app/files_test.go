@@ -0,0 +1,409 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveFileDeduplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+
+	cfg := config.Configuration{FilesPath: filesPath}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	sessions := []model.UploadSession{}
+	store.EXPECT().CreateUploadSession(gomock.Any()).DoAndReturn(func(session model.UploadSession) error {
+		sessions = append(sessions, session)
+		return nil
+	}).Times(2)
+	store.EXPECT().DeleteUploadSession(gomock.Any()).Times(2)
+
+	// The content is stored before the file references it
+	refs := []model.FileRef{}
+	store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(ref model.FileRef, size int64) (bool, error) {
+		require.FileExists(t, filepath.Join(filesPath, blobPath(ref.Hash)))
+		refs = append(refs, ref)
+		return len(refs) == 1, nil
+	}).Times(2)
+
+	first, err := app.SaveFile(strings.NewReader("hello"), "workspace-id", "board-id", "logo.png", 5)
+	require.NoError(t, err)
+	second, err := app.SaveFile(strings.NewReader("hello"), "workspace-id", "board-id", "logo.png", 5)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+	require.Equal(t, filepath.Join(uploadsDirectory, first), sessions[0].Path)
+
+	require.Len(t, refs, 2)
+	require.Equal(t, refs[0].Hash, refs[1].Hash)
+
+	blob := filepath.Join(filesPath, blobPath(refs[0].Hash))
+	content, err := ioutil.ReadFile(blob)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	uploads, _ := ioutil.ReadDir(filepath.Join(filesPath, uploadsDirectory))
+	require.Empty(t, uploads)
+
+	store.EXPECT().GetFileRef(first).Return(&refs[0], nil).Times(2)
+	require.Equal(t, blob, app.GetFilePath("workspace-id", "board-id", first))
+
+	store.EXPECT().DeleteFileRef(first, gomock.Any()).Return(nil)
+	require.NoError(t, app.DeleteFile("workspace-id", "board-id", first))
+	require.FileExists(t, blob)
+
+	store.EXPECT().GetFileRef(second).Return(&refs[1], nil)
+	store.EXPECT().DeleteFileRef(second, gomock.Any()).DoAndReturn(func(id string, removeBlob func(string) error) error {
+		return removeBlob(refs[1].Hash)
+	})
+	require.NoError(t, app.DeleteFile("workspace-id", "board-id", second))
+	require.NoFileExists(t, blob)
+}
+
+func TestSaveFileConcurrentBlobDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+
+	cfg := config.Configuration{FilesPath: filesPath}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	hash := sha256.Sum256([]byte("hello"))
+	blob := blobPath(hex.EncodeToString(hash[:]))
+	_, appErr = filesBackend.WriteFile(strings.NewReader("hello"), blob)
+	require.Nil(t, appErr)
+
+	// The last file with the content is deleted after the blob was found,
+	// so the blob is created again
+	store.EXPECT().CreateUploadSession(gomock.Any())
+	store.EXPECT().DeleteUploadSession(gomock.Any())
+	store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(ref model.FileRef, size int64) (bool, error) {
+		require.Nil(t, filesBackend.RemoveFile(blob))
+		return true, nil
+	})
+
+	_, err = app.SaveFile(strings.NewReader("hello"), "workspace-id", "board-id", "notes.txt", 5)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(filesPath, blob))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	uploads, _ := ioutil.ReadDir(filepath.Join(filesPath, uploadsDirectory))
+	require.Empty(t, uploads)
+}
+
+func TestDeleteFileConcurrentUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+
+	cfg := config.Configuration{FilesPath: filesPath}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	sum := sha256.Sum256([]byte("hello"))
+	hash := hex.EncodeToString(sum[:])
+	blob := blobPath(hash)
+	_, appErr = filesBackend.WriteFile(strings.NewReader("hello"), blob)
+	require.Nil(t, appErr)
+	ref := model.FileRef{ID: "first.png", WorkspaceID: "workspace-id", RootID: "board-id", Hash: hash}
+
+	// The store holds the blob while deleting it, so the reference of the
+	// upload waits for the deletion to commit, and then creates the blob
+	// again
+	uploading := make(chan struct{})
+	committed := make(chan struct{})
+	store.EXPECT().CreateUploadSession(gomock.Any())
+	store.EXPECT().DeleteUploadSession(gomock.Any())
+	store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(ref model.FileRef, size int64) (bool, error) {
+		close(uploading)
+		<-committed
+		return true, nil
+	})
+
+	uploaded := make(chan error)
+	store.EXPECT().GetFileRef("first.png").Return(&ref, nil)
+	store.EXPECT().DeleteFileRef("first.png", gomock.Any()).DoAndReturn(func(id string, removeBlob func(string) error) error {
+		go func() {
+			_, err := app.SaveFile(strings.NewReader("hello"), "workspace-id", "board-id", "second.png", 5)
+			uploaded <- err
+		}()
+		<-uploading
+
+		defer close(committed)
+		return removeBlob(hash)
+	})
+
+	require.NoError(t, app.DeleteFile("workspace-id", "board-id", "first.png"))
+	require.NoError(t, <-uploaded)
+
+	content, err := ioutil.ReadFile(filepath.Join(filesPath, blob))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+}
+
+func TestSaveFileContentTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+
+	cfg := config.Configuration{FilesPath: filesPath, AllowedUploadContentTypes: []string{"image/*", "application/pdf"}}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	store.EXPECT().CreateUploadSession(gomock.Any()).AnyTimes()
+	store.EXPECT().DeleteUploadSession(gomock.Any()).AnyTimes()
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 600)
+
+	t.Run("allowed type", func(t *testing.T) {
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(len(png))).Return(true, nil)
+
+		filename, err := app.SaveFile(strings.NewReader(png), "workspace-id", "board-id", "logo.png", int64(len(png)))
+		require.NoError(t, err)
+		require.NotEmpty(t, filename)
+	})
+
+	t.Run("disallowed type", func(t *testing.T) {
+		_, err := app.SaveFile(strings.NewReader("MZ\x90\x00\x03\x00\x00\x00"), "workspace-id", "board-id", "setup.exe", 8)
+		require.Equal(t, ErrContentTypeNotAllowed, err)
+	})
+
+	t.Run("the file name lies about the content", func(t *testing.T) {
+		_, err := app.SaveFile(strings.NewReader("<html><script>alert(1)</script></html>"), "workspace-id", "board-id", "photo.png", 38)
+		require.Equal(t, ErrContentTypeNotAllowed, err)
+	})
+
+	t.Run("the file name hides an allowed type", func(t *testing.T) {
+		store.EXPECT().CreateFileRef(gomock.Any(), gomock.Any()).Return(true, nil)
+
+		_, err := app.SaveFile(strings.NewReader("%PDF-1.4\n"), "workspace-id", "board-id", "report.txt", 9)
+		require.NoError(t, err)
+	})
+}
+
+func TestCleanUpAbandonedUploads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+
+	cfg := config.Configuration{FilesPath: filesPath, UploadSessionTTL: 60 * 60}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	stalePath := filepath.Join(uploadsDirectory, "stale.png")
+	activePath := filepath.Join(uploadsDirectory, "active.png")
+	for _, path := range []string{stalePath, activePath} {
+		_, appErr := filesBackend.WriteFile(strings.NewReader("partial"), path)
+		require.Nil(t, appErr)
+	}
+
+	stale := model.UploadSession{ID: "stale.png", Path: stalePath}
+	store.EXPECT().GetAbandonedUploadSessions(gomock.Any()).DoAndReturn(func(olderThan int64) ([]model.UploadSession, error) {
+		require.InDelta(t, utils.GetMillis()-cfg.UploadSessionTTL*1000, olderThan, 1000)
+		return []model.UploadSession{stale}, nil
+	})
+	store.EXPECT().DeleteUploadSession("stale.png").Return(nil)
+
+	removed, err := app.CleanUpAbandonedUploads()
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	require.NoFileExists(t, filepath.Join(filesPath, stalePath))
+	require.FileExists(t, filepath.Join(filesPath, activePath))
+
+	t.Run("disabled without a TTL", func(t *testing.T) {
+		cfg.UploadSessionTTL = 0
+		removed, err := app.CleanUpAbandonedUploads()
+		require.NoError(t, err)
+		require.Zero(t, removed)
+		require.FileExists(t, filepath.Join(filesPath, activePath))
+	})
+}
+
+// slowReader returns one byte of the content at a time, waiting before each
+type slowReader struct {
+	content string
+	delay   time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.content == "" {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	p[0] = r.content[0]
+	r.content = r.content[1:]
+	return 1, nil
+}
+
+func TestSaveFileDuringShutdown(t *testing.T) {
+	setup := func(t *testing.T) (*App, *mockstore.MockStore, string) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		filesPath, err := ioutil.TempDir("", "focalboard-files")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(filesPath) })
+
+		filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+		require.Nil(t, appErr)
+
+		cfg := config.Configuration{FilesPath: filesPath}
+		store := mockstore.NewMockStore(ctrl)
+		auth := auth.New(&cfg, store)
+		wsserver := ws.NewServer(auth, "")
+		webhook := webhook.NewClient(&cfg, nil)
+		app := New(&cfg, store, auth, wsserver, filesBackend, webhook).WithUploads(NewUploads())
+
+		store.EXPECT().CreateUploadSession(gomock.Any()).AnyTimes()
+		store.EXPECT().DeleteUploadSession(gomock.Any()).AnyTimes()
+
+		return app, store, filesPath
+	}
+
+	// saveFile starts saving the file, and waits until its upload is in progress
+	saveFile := func(app *App, reader io.Reader) chan error {
+		result := make(chan error, 1)
+		go func() {
+			_, err := app.SaveFile(reader, "workspace-id", "board-id", "notes.txt", 0)
+			result <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			app.uploads.mu.Lock()
+			defer app.uploads.mu.Unlock()
+			return app.uploads.count == 1
+		}, time.Second, time.Millisecond)
+
+		return result
+	}
+
+	t.Run("the upload finishes within the timeout", func(t *testing.T) {
+		app, store, filesPath := setup(t)
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).Return(true, nil)
+
+		result := saveFile(app, &slowReader{content: "hello", delay: 20 * time.Millisecond})
+		require.NoError(t, app.uploads.Shutdown(5*time.Second))
+		require.NoError(t, <-result)
+
+		blobs, _ := ioutil.ReadDir(filepath.Join(filesPath, blobsDirectory))
+		require.Len(t, blobs, 1)
+	})
+
+	t.Run("the upload is aborted once the timeout passes", func(t *testing.T) {
+		app, _, filesPath := setup(t)
+
+		result := saveFile(app, &slowReader{content: strings.Repeat("x", 1000), delay: 20 * time.Millisecond})
+		require.NoError(t, app.uploads.Shutdown(100*time.Millisecond))
+		require.Error(t, <-result)
+
+		uploads, _ := ioutil.ReadDir(filepath.Join(filesPath, uploadsDirectory))
+		require.Empty(t, uploads)
+		blobs, _ := ioutil.ReadDir(filepath.Join(filesPath, blobsDirectory))
+		require.Empty(t, blobs)
+	})
+
+	t.Run("the shutdown waits for an upload whose body is being read", func(t *testing.T) {
+		app, store, _ := setup(t)
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).Return(true, nil)
+
+		body, endUpload, uploadApp, err := app.BeginUpload(&slowReader{content: "hello", delay: 20 * time.Millisecond})
+		require.NoError(t, err)
+
+		shutDown := make(chan error, 1)
+		go func() { shutDown <- app.uploads.Shutdown(5 * time.Second) }()
+
+		// The body is read in full, as the upload began before the shutdown
+		content, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+		_, err = uploadApp.SaveFile(strings.NewReader(string(content)), "workspace-id", "board-id", "notes.txt", int64(len(content)))
+		require.NoError(t, err)
+
+		select {
+		case <-shutDown:
+			require.Fail(t, "the shutdown didn't wait for the upload")
+		default:
+		}
+
+		endUpload()
+		require.NoError(t, <-shutDown)
+	})
+
+	t.Run("no uploads are accepted after the shutdown", func(t *testing.T) {
+		app, _, _ := setup(t)
+		require.NoError(t, app.uploads.Shutdown(time.Second))
+
+		_, err := app.SaveFile(strings.NewReader("hello"), "workspace-id", "board-id", "notes.txt", 5)
+		require.Equal(t, ErrShuttingDown, err)
+		_, _, _, err = app.BeginUpload(strings.NewReader("hello"))
+		require.Equal(t, ErrShuttingDown, err)
+	})
+}
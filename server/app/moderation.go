@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// ContentModerator screens the content of the blocks before they're stored
+type ContentModerator interface {
+	// Check returns whether the block can be stored, and the reason why not
+	Check(block model.Block) (allowed bool, reason string)
+}
+
+// ErrContentRejected is returned when the moderator rejects a block
+type ErrContentRejected struct {
+	BlockID string
+	Reason  string
+}
+
+func (e *ErrContentRejected) Error() string {
+	return fmt.Sprintf("the content of block %s was rejected: %s", e.BlockID, e.Reason)
+}
+
+// noopModerator allows all the blocks
+type noopModerator struct{}
+
+func (noopModerator) Check(block model.Block) (bool, string) {
+	return true, ""
+}
+
+// WordListModerator rejects the blocks with any of a list of words in their
+// title or their text fields, ignoring the case. Only whole words match, so
+// the words don't need to account for the longer ones containing them.
+type WordListModerator struct {
+	words map[string]bool
+}
+
+// NewWordListModerator creates a moderator rejecting the given words
+func NewWordListModerator(words []string) *WordListModerator {
+	moderator := &WordListModerator{words: map[string]bool{}}
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			moderator.words[strings.ToLower(word)] = true
+		}
+	}
+
+	return moderator
+}
+
+func (m *WordListModerator) Check(block model.Block) (bool, string) {
+	if word := m.findWord(block.Title); word != "" {
+		return false, fmt.Sprintf("the title contains the blocked word %q", word)
+	}
+
+	for name, value := range block.Fields {
+		if word := m.findWordIn(value); word != "" {
+			return false, fmt.Sprintf("the field %s contains the blocked word %q", name, word)
+		}
+	}
+
+	return true, ""
+}
+
+// findWordIn looks for the words in the strings of a field value, which can
+// be nested in lists and objects
+func (m *WordListModerator) findWordIn(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return m.findWord(value)
+	case []interface{}:
+		for _, item := range value {
+			if word := m.findWordIn(item); word != "" {
+				return word
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			if word := m.findWordIn(item); word != "" {
+				return word
+			}
+		}
+	}
+
+	return ""
+}
+
+func (m *WordListModerator) findWord(text string) string {
+	if len(m.words) == 0 {
+		return ""
+	}
+
+	isSeparator := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		if m.words[word] {
+			return word
+		}
+	}
+
+	return ""
+}
+
+// newContentModerator returns the moderator configured, which allows all the
+// blocks without a list of words
+func newContentModerator(words []string) ContentModerator {
+	if len(words) == 0 {
+		return noopModerator{}
+	}
+
+	return NewWordListModerator(words)
+}
+
+// WithModerator returns a copy of the app that screens the blocks written
+// with the moderator instead of the configured one
+func (a *App) WithModerator(moderator ContentModerator) *App {
+	copy := *a
+	copy.moderator = moderator
+	return &copy
+}
+
+// moderate fails with ErrContentRejected if the moderator rejects any of the
+// blocks
+func (a *App) moderate(blocks []model.Block) error {
+	for _, block := range blocks {
+		if allowed, reason := a.moderator.Check(block); !allowed {
+			return &ErrContentRejected{BlockID: block.ID, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// moderatePatches screens the content set by the patches, as the rest of the
+// blocks was screened when it was written
+func (a *App) moderatePatches(patches []model.BlockPatch) error {
+	blocks := make([]model.Block, 0, len(patches))
+	for _, patch := range patches {
+		block := model.Block{ID: patch.ID, Fields: patch.UpdatedFields}
+		if patch.Type != nil {
+			block.Type = *patch.Type
+		}
+		if patch.Title != nil {
+			block.Title = *patch.Title
+		}
+		blocks = append(blocks, block)
+	}
+
+	return a.moderate(blocks)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// stubModerator rejects the blocks with a title containing "secret"
+type stubModerator struct{}
+
+func (stubModerator) Check(block model.Block) (bool, string) {
+	if strings.Contains(block.Title, "secret") {
+		return false, "the title contains a secret"
+	}
+
+	return true, ""
+}
+
+func TestModeration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook).WithModerator(stubModerator{})
+
+	container := st.Container{
+		WorkspaceID: "0",
+	}
+
+	t.Run("rejected insert", func(t *testing.T) {
+		err := app.InsertBlocks(container, []model.Block{
+			{ID: "card-1", Type: "card", Title: "Allowed"},
+			{ID: "card-2", Type: "card", Title: "The secret"},
+		})
+
+		var rejectedErr *ErrContentRejected
+		require.True(t, errors.As(err, &rejectedErr))
+		require.Equal(t, "card-2", rejectedErr.BlockID)
+		require.Equal(t, "the title contains a secret", rejectedErr.Reason)
+	})
+
+	t.Run("allowed insert", func(t *testing.T) {
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil)
+
+		require.NoError(t, app.InsertBlocks(container, []model.Block{{ID: "card-1", Type: "card", Title: "Allowed"}}))
+	})
+
+	t.Run("rejected patch", func(t *testing.T) {
+		title := "The secret"
+		err := app.PatchBlocks(container, []model.BlockPatch{{ID: "card-1", Title: &title}}, "user-1")
+
+		var rejectedErr *ErrContentRejected
+		require.True(t, errors.As(err, &rejectedErr))
+		require.Equal(t, "card-1", rejectedErr.BlockID)
+	})
+
+	t.Run("no moderation by default", func(t *testing.T) {
+		app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil)
+
+		require.NoError(t, app.InsertBlocks(container, []model.Block{{ID: "card-2", Type: "card", Title: "The secret"}}))
+	})
+}
+
+func TestWordListModerator(t *testing.T) {
+	moderator := NewWordListModerator([]string{"Darn", " password ", ""})
+
+	testCases := []struct {
+		name    string
+		block   model.Block
+		allowed bool
+		reason  string
+	}{
+		{"clean", model.Block{Title: "A passwordless login", Fields: map[string]interface{}{"icon": "🔑"}}, true, ""},
+		{"title", model.Block{Title: "Darn it"}, false, `the title contains the blocked word "darn"`},
+		{"case and punctuation", model.Block{Title: "the PASSWORD: hunter2"}, false, `the title contains the blocked word "password"`},
+		{"nested field", model.Block{Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"notes": []interface{}{"fine", "darn"}},
+		}}, false, `the field properties contains the blocked word "darn"`},
+		{"non-text field", model.Block{Fields: map[string]interface{}{"count": 3.0, "done": true}}, true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, reason := moderator.Check(tc.block)
+			require.Equal(t, tc.allowed, allowed)
+			require.Equal(t, tc.reason, reason)
+		})
+	}
+
+	t.Run("configured words", func(t *testing.T) {
+		allowed, _ := newContentModerator(nil).Check(model.Block{Title: "darn"})
+		require.True(t, allowed)
+
+		allowed, _ = newContentModerator([]string{"darn"}).Check(model.Block{Title: "darn"})
+		require.False(t, allowed)
+	})
+}
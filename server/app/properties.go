@@ -0,0 +1,290 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// ErrInvalidProperties is returned when the values of the properties of a
+// card don't match the schema of its board
+type ErrInvalidProperties struct {
+	BlockID string
+	// Fields has the problem with each invalid value, keyed by property ID
+	Fields map[string]string
+}
+
+func (e *ErrInvalidProperties) Error() string {
+	propertyIDs := make([]string, 0, len(e.Fields))
+	for propertyID := range e.Fields {
+		propertyIDs = append(propertyIDs, propertyID)
+	}
+	sort.Strings(propertyIDs)
+
+	problems := make([]string, 0, len(propertyIDs))
+	for _, propertyID := range propertyIDs {
+		problems = append(problems, propertyID+": "+e.Fields[propertyID])
+	}
+
+	return fmt.Sprintf("invalid properties for block %s: %s", e.BlockID, strings.Join(problems, ", "))
+}
+
+// ErrUnknownProperty is returned when filtering the cards of a board by a
+// property that the schema of the board doesn't have
+type ErrUnknownProperty struct {
+	PropertyID string
+}
+
+func (e *ErrUnknownProperty) Error() string {
+	return fmt.Sprintf("unknown property %q", e.PropertyID)
+}
+
+// validateCardProperties checks the values of the properties of the cards
+// against the schemas of their boards, which are either among the blocks or
+// already stored. Only the values that differ from the stored cards are
+// checked, so the cards with values that an edit of the schema made invalid
+// can still be updated. The values of the properties the schema doesn't have,
+// and the cards whose board isn't found, aren't checked.
+func (a *App) validateCardProperties(c store.Container, blocks []model.Block) error {
+	if !a.config.ValidateCardProperties {
+		return nil
+	}
+
+	return a.validateChangedCardProperties(c, blocks, nil)
+}
+
+// validatePatchedCardProperties checks the properties of the cards the
+// patches set them for, once patched
+func (a *App) validatePatchedCardProperties(c store.Container, patches []model.BlockPatch) error {
+	if !a.config.ValidateCardProperties {
+		return nil
+	}
+
+	patchesByID := map[string]model.BlockPatch{}
+	blockIDs := []string{}
+	for _, patch := range patches {
+		if _, ok := patch.UpdatedFields["properties"]; ok {
+			patchesByID[patch.ID] = patch
+			blockIDs = append(blockIDs, patch.ID)
+		}
+	}
+	if len(blockIDs) == 0 {
+		return nil
+	}
+
+	blocks, err := a.store.GetBlocksByIDs(c, blockIDs)
+	if err != nil {
+		return err
+	}
+
+	stored := map[string]model.Block{}
+	patched := make([]model.Block, 0, len(blocks))
+	for _, block := range blocks {
+		// The patch replaces the fields of the block in place
+		fields, err := copyFields(block.Fields)
+		if err != nil {
+			return err
+		}
+		storedBlock := block
+		storedBlock.Fields = fields
+		stored[block.ID] = storedBlock
+
+		patch := patchesByID[block.ID]
+		patched = append(patched, *patch.Patch(&block))
+	}
+
+	return a.validateChangedCardProperties(c, patched, stored)
+}
+
+// validateChangedCardProperties checks the values of the properties of the
+// cards that differ from the stored cards. The stored cards are read along
+// with the boards that aren't among the blocks, unless they're given.
+func (a *App) validateChangedCardProperties(c store.Container, blocks []model.Block, stored map[string]model.Block) error {
+	boards := map[string]model.Block{}
+	for _, block := range blocks {
+		if block.Type == "board" {
+			boards[block.ID] = block
+		}
+	}
+
+	readCards := stored == nil
+	if readCards {
+		stored = map[string]model.Block{}
+	}
+
+	lookup := []string{}
+	for _, block := range blocks {
+		properties, _ := block.Fields["properties"].(map[string]interface{})
+		if block.Type != "card" || len(properties) == 0 || block.RootID == "" {
+			continue
+		}
+		if _, ok := boards[block.RootID]; !ok {
+			boards[block.RootID] = model.Block{}
+			lookup = append(lookup, block.RootID)
+		}
+		if readCards {
+			lookup = append(lookup, block.ID)
+		}
+	}
+	if len(lookup) > 0 {
+		found, err := a.store.GetBlocksByIDs(c, lookup)
+		if err != nil {
+			return err
+		}
+		for _, block := range found {
+			switch block.Type {
+			case "board":
+				boards[block.ID] = block
+			case "card":
+				if readCards {
+					stored[block.ID] = block
+				}
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		board, ok := boards[block.RootID]
+		if block.Type != "card" || !ok || board.Type != "board" {
+			continue
+		}
+
+		if problems := invalidPropertyValues(board, block, stored[block.ID]); len(problems) > 0 {
+			return &ErrInvalidProperties{BlockID: block.ID, Fields: problems}
+		}
+	}
+
+	return nil
+}
+
+// invalidPropertyValues returns the problems with the values of the
+// properties of a card that differ from the stored card, keyed by property
+// ID. A card that isn't stored yet has all of its values checked.
+func invalidPropertyValues(board, card, storedCard model.Block) map[string]string {
+	schema := map[string]map[string]interface{}{}
+	for _, property := range cardPropertySchema(board) {
+		schema[property["id"].(string)] = property
+	}
+
+	problems := map[string]string{}
+	properties, _ := card.Fields["properties"].(map[string]interface{})
+	storedProperties, _ := storedCard.Fields["properties"].(map[string]interface{})
+	for propertyID, value := range properties {
+		property, ok := schema[propertyID]
+		if !ok {
+			continue
+		}
+		if storedValue, ok := storedProperties[propertyID]; ok && reflect.DeepEqual(storedValue, value) {
+			continue
+		}
+
+		if problem := invalidPropertyValue(property, value); problem != "" {
+			problems[propertyID] = problem
+		}
+	}
+
+	return problems
+}
+
+// cardPropertySchema returns the card properties of the schema of a board,
+// in their order, skipping the ones without an ID
+func cardPropertySchema(board model.Block) []map[string]interface{} {
+	schema := []map[string]interface{}{}
+	cardProperties, _ := board.Fields["cardProperties"].([]interface{})
+	for _, p := range cardProperties {
+		if property, ok := p.(map[string]interface{}); ok {
+			if propertyID, _ := property["id"].(string); propertyID != "" {
+				schema = append(schema, property)
+			}
+		}
+	}
+
+	return schema
+}
+
+// invalidPropertyValue returns the problem with the value of a property, or
+// an empty string if it's valid for the type of the property. Empty values
+// clear the property, so they're valid for any type.
+func invalidPropertyValue(property map[string]interface{}, value interface{}) string {
+	if value == nil || value == "" {
+		return ""
+	}
+
+	propertyType, _ := property["type"].(string)
+	switch propertyType {
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return ""
+		case string:
+			if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return ""
+			}
+		}
+		return "must be a number"
+
+	case "select":
+		if optionID, ok := value.(string); ok && isPropertyOption(property, optionID) {
+			return ""
+		}
+		return "must be an option of the property"
+
+	case "multiSelect":
+		optionIDs, ok := value.([]interface{})
+		if !ok {
+			return "must be a list of options of the property"
+		}
+		for _, o := range optionIDs {
+			if optionID, ok := o.(string); !ok || !isPropertyOption(property, optionID) {
+				return "must be a list of options of the property"
+			}
+		}
+		return ""
+
+	case "date":
+		if date, ok := value.(string); ok && isDateValue(date) {
+			return ""
+		}
+		return `must be a date, as {"from":milliseconds,"to":milliseconds}`
+
+	case "checkbox":
+		switch value {
+		case true, false, "true", "false":
+			return ""
+		}
+		return "must be true or false"
+	}
+
+	return ""
+}
+
+func isPropertyOption(property map[string]interface{}, optionID string) bool {
+	options, _ := property["options"].([]interface{})
+	for _, o := range options {
+		if option, ok := o.(map[string]interface{}); ok && option["id"] == optionID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDateValue checks a date value, the JSON of the start and optional end
+// time in milliseconds
+func isDateValue(value string) bool {
+	var date struct {
+		From *float64 `json:"from"`
+		To   *float64 `json:"to"`
+	}
+	if err := json.Unmarshal([]byte(value), &date); err != nil {
+		return false
+	}
+
+	return date.From != nil
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func propertiesTestBoard() model.Block {
+	options := []interface{}{
+		map[string]interface{}{"id": "option-todo", "value": "To do"},
+		map[string]interface{}{"id": "option-done", "value": "Done"},
+	}
+
+	return model.Block{ID: "board-1", RootID: "board-1", Type: "board", Fields: map[string]interface{}{
+		"cardProperties": []interface{}{
+			map[string]interface{}{"id": "estimate", "type": "number"},
+			map[string]interface{}{"id": "status", "type": "select", "options": options},
+			map[string]interface{}{"id": "tags", "type": "multiSelect", "options": options},
+			map[string]interface{}{"id": "due", "type": "date"},
+			map[string]interface{}{"id": "reviewed", "type": "checkbox"},
+			map[string]interface{}{"id": "notes", "type": "text"},
+		},
+	}}
+}
+
+func propertiesTestCard(properties map[string]interface{}) model.Block {
+	return model.Block{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Fields: map[string]interface{}{
+		"properties": properties,
+	}}
+}
+
+func TestInvalidPropertyValues(t *testing.T) {
+	board := propertiesTestBoard()
+
+	t.Run("valid values", func(t *testing.T) {
+		card := propertiesTestCard(map[string]interface{}{
+			"estimate": "3.5",
+			"status":   "option-todo",
+			"tags":     []interface{}{"option-todo", "option-done"},
+			"due":      `{"from":1623715200000,"to":1623801600000}`,
+			"reviewed": "true",
+			"notes":    "anything",
+			// Not in the schema anymore
+			"removed": 42.0,
+		})
+		require.Empty(t, invalidPropertyValues(board, card, model.Block{}))
+
+		// Empty values clear the properties
+		card = propertiesTestCard(map[string]interface{}{"estimate": "", "status": "", "due": nil})
+		require.Empty(t, invalidPropertyValues(board, card, model.Block{}))
+	})
+
+	t.Run("type mismatches", func(t *testing.T) {
+		card := propertiesTestCard(map[string]interface{}{
+			"estimate": "three",
+			"status":   "option-unknown",
+			"tags":     "option-todo",
+			"due":      "tomorrow",
+			"reviewed": "yes",
+			"notes":    "anything",
+		})
+		problems := invalidPropertyValues(board, card, model.Block{})
+		require.Equal(t, map[string]string{
+			"estimate": "must be a number",
+			"status":   "must be an option of the property",
+			"tags":     "must be a list of options of the property",
+			"due":      `must be a date, as {"from":milliseconds,"to":milliseconds}`,
+			"reviewed": "must be true or false",
+		}, problems)
+	})
+
+	t.Run("only the changed values", func(t *testing.T) {
+		stored := propertiesTestCard(map[string]interface{}{"estimate": "three", "status": "option-gone"})
+		card := propertiesTestCard(map[string]interface{}{"estimate": "three", "status": "option-unknown"})
+		require.Equal(t, map[string]string{"status": "must be an option of the property"}, invalidPropertyValues(board, card, stored))
+	})
+}
+
+func TestValidateCardProperties(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{ValidateCardProperties: true}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{
+		WorkspaceID: "0",
+	}
+	board := propertiesTestBoard()
+	store.EXPECT().GetLockedBoards(gomock.Any(), gomock.Any()).Return([]string{}, nil).AnyTimes()
+
+	t.Run("board in the same batch", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1"}).Return([]model.Block{}, nil)
+
+		err := app.InsertBlocks(container, []model.Block{board, propertiesTestCard(map[string]interface{}{"estimate": "three"})})
+
+		var invalidErr *ErrInvalidProperties
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, "card-1", invalidErr.BlockID)
+		require.Equal(t, map[string]string{"estimate": "must be a number"}, invalidErr.Fields)
+	})
+
+	t.Run("stored board", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1", "card-1"}).Return([]model.Block{board}, nil).Times(2)
+
+		err := app.InsertBlocks(container, []model.Block{propertiesTestCard(map[string]interface{}{"status": "option-unknown"})})
+		var invalidErr *ErrInvalidProperties
+		require.True(t, errors.As(err, &invalidErr))
+
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil)
+		require.NoError(t, app.InsertBlocks(container, []model.Block{propertiesTestCard(map[string]interface{}{"status": "option-done"})}))
+	})
+
+	t.Run("patched properties", func(t *testing.T) {
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1"}).Return([]model.Block{propertiesTestCard(map[string]interface{}{})}, nil)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{board}, nil)
+
+		err := app.PatchBlocks(container, []model.BlockPatch{{
+			ID:            "card-1",
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"reviewed": "maybe"}},
+		}}, "user-1")
+		var invalidErr *ErrInvalidProperties
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, map[string]string{"reviewed": "must be true or false"}, invalidErr.Fields)
+	})
+
+	t.Run("values made invalid by an edit of the schema", func(t *testing.T) {
+		stored := propertiesTestCard(map[string]interface{}{"estimate": "three", "status": "option-todo"})
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1", "card-1"}).Return([]model.Block{board, stored}, nil).Times(2)
+
+		// The card can still be updated while the value is kept
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil)
+		require.NoError(t, app.InsertBlocks(container, []model.Block{propertiesTestCard(map[string]interface{}{"estimate": "three", "status": "option-done"})}))
+
+		err := app.InsertBlocks(container, []model.Block{propertiesTestCard(map[string]interface{}{"estimate": "four", "status": "option-todo"})})
+		var invalidErr *ErrInvalidProperties
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, map[string]string{"estimate": "must be a number"}, invalidErr.Fields)
+
+		store.EXPECT().GetBlocksByIDs(container, []string{"card-1"}).Return([]model.Block{stored}, nil).Times(3)
+		store.EXPECT().GetBlocksByIDs(container, []string{"board-1"}).Return([]model.Block{board}, nil)
+		store.EXPECT().PatchBlocks(container, gomock.Any(), "user-1").Return(nil)
+		require.NoError(t, app.PatchBlocks(container, []model.BlockPatch{{
+			ID:            "card-1",
+			UpdatedFields: map[string]interface{}{"properties": map[string]interface{}{"estimate": "three", "status": "option-done"}},
+		}}, "user-1"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := config.Configuration{}
+		app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+		store.EXPECT().InsertBlock(container, gomock.Any()).Return(nil)
+
+		require.NoError(t, app.InsertBlocks(container, []model.Block{propertiesTestCard(map[string]interface{}{"estimate": "three"})}))
+	})
+}
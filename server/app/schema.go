@@ -0,0 +1,7 @@
+package app
+
+import "github.com/mattermost/focalboard/server/services/store"
+
+func (a *App) GetSchemaVersion() (store.SchemaVersion, error) {
+	return a.store.GetSchemaVersion()
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/store"
@@ -21,3 +22,28 @@ func (a *App) GetSharing(c store.Container, rootID string) (*model.Sharing, erro
 func (a *App) UpsertSharing(c store.Container, sharing model.Sharing) error {
 	return a.store.UpsertSharing(c, sharing)
 }
+
+// GetActiveSharingTokens lists the tokens that currently give public access
+// to the boards of the workspace
+func (a *App) GetActiveSharingTokens(c store.Container) ([]model.Sharing, error) {
+	return a.store.GetActiveSharingTokens(c, time.Now().Unix())
+}
+
+// PurgeExpiredSharingTokens removes the expired sharing tokens of all the
+// workspaces and returns the removed ones
+func (a *App) PurgeExpiredSharingTokens() ([]model.Sharing, error) {
+	now := time.Now().Unix()
+	expired, err := a.store.GetExpiredSharingTokens(now)
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+
+	if _, err := a.store.DeleteExpiredSharingTokens(now); err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"path/filepath"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// GetDeletedBoards returns the boards in the trash of a workspace
+func (a *App) GetDeletedBoards(workspaceID string) ([]model.Block, error) {
+	return a.store.GetDeletedBoards(workspaceID)
+}
+
+// RestoreBoard moves a board out of the trash, with the blocks of the board
+// deleted since it was. The restored board counts towards the limit of
+// boards of the workspace like a new one.
+func (a *App) RestoreBoard(c store.Container, boardID, modifiedBy string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	deleted, err := a.store.GetDeletedBoards(c.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	restored := []model.Block{}
+	for _, board := range deleted {
+		if board.ID == boardID {
+			restored = append(restored, board)
+		}
+	}
+
+	var blocks []model.Block
+	err = a.withinBoardLimit(c, restored, func() error {
+		blocks, err = a.store.RestoreBoard(c, boardID, modifiedBy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.wsServer.BroadcastBlockChanges(c.WorkspaceID, blocks)
+
+	return nil
+}
+
+// DeleteBoardPermanently removes a board from the trash for good, with its
+// blocks and their history, and then cleans up the files of the board
+func (a *App) DeleteBoardPermanently(c store.Container, boardID string) error {
+	defer a.invalidateWorkspace(c.WorkspaceID)
+
+	if err := a.store.PurgeBoard(c, boardID); err != nil {
+		return err
+	}
+
+	a.cleanUpBoardFiles(c.WorkspaceID, boardID)
+
+	return nil
+}
+
+// cleanUpBoardFiles removes the files uploaded to a board that's gone. The
+// blocks are already removed, so the failures are only logged, and the blobs
+// left behind are reported as orphaned files.
+func (a *App) cleanUpBoardFiles(workspaceID, boardID string) {
+	refs, err := a.store.GetWorkspaceFileRefs(workspaceID)
+	if err != nil {
+		log.Printf("ERROR listing the files of board '%s': %v", boardID, err)
+		return
+	}
+
+	for _, ref := range refs {
+		if ref.RootID != boardID {
+			continue
+		}
+
+		if err := a.store.DeleteFileRef(ref.ID, a.removeBlob); err != nil {
+			log.Printf("ERROR deleting file '%s' of board '%s': %v", ref.ID, boardID, err)
+		}
+	}
+
+	// The files uploaded before the blobs are in a directory of the board
+	legacyPath := filepath.Join(workspaceID, boardID)
+	if exists, err := a.filesBackend.FileExists(legacyPath); err == nil && exists {
+		if err := a.filesBackend.RemoveDirectory(legacyPath); err != nil {
+			log.Printf("ERROR removing directory '%s': %v", legacyPath, err)
+		}
+	}
+}
+
+// PurgeExpiredTrash deletes for good the boards that have been in the trash
+// for longer than the retention, and returns how many were deleted
+func (a *App) PurgeExpiredTrash() (int, error) {
+	if a.config.TrashRetentionDays <= 0 {
+		return 0, nil
+	}
+
+	deletedBefore := utils.GetMillis() - int64(a.config.TrashRetentionDays)*24*60*60*1000
+	boards, err := a.store.GetDeletedBoardsBefore(deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, board := range boards {
+		err := a.DeleteBoardPermanently(store.Container{WorkspaceID: board.WorkspaceID}, board.BoardID)
+		if err != nil {
+			// Restored since it was listed
+			var notFoundErr *store.ErrBlocksNotFound
+			if errors.As(err, &notFoundErr) {
+				continue
+			}
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
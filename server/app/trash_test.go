@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteBoardPermanently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, err := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.NoError(t, err)
+
+	cfg := config.Configuration{FilesPath: filesPath, TrashRetentionDays: 30}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, filesBackend, webhook)
+
+	container := st.Container{WorkspaceID: "workspace-1"}
+	hash := "ab0123"
+	_, err = filesBackend.WriteFile(bytes.NewBufferString("image"), blobPath(hash))
+	require.NoError(t, err)
+	_, err = filesBackend.WriteFile(bytes.NewBufferString("legacy image"), filepath.Join("workspace-1", "board-1", "legacy.png"))
+	require.NoError(t, err)
+
+	t.Run("the files of the board are cleaned up", func(t *testing.T) {
+		store.EXPECT().PurgeBoard(container, "board-1").Return(nil)
+		store.EXPECT().GetWorkspaceFileRefs("workspace-1").Return([]model.FileRef{
+			{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: hash},
+			{ID: "file-2.png", WorkspaceID: "workspace-1", RootID: "board-2", Hash: "cd4567"},
+		}, nil)
+		store.EXPECT().DeleteFileRef("file-1.png", gomock.Any()).DoAndReturn(func(id string, removeBlob func(string) error) error {
+			return removeBlob(hash)
+		})
+
+		require.NoError(t, app.DeleteBoardPermanently(container, "board-1"))
+
+		exists, err := filesBackend.FileExists(blobPath(hash))
+		require.NoError(t, err)
+		require.False(t, exists)
+		exists, err = filesBackend.FileExists(filepath.Join("workspace-1", "board-1"))
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("purge the expired boards", func(t *testing.T) {
+		store.EXPECT().GetDeletedBoardsBefore(gomock.Any()).Return([]model.DeletedBoard{
+			{WorkspaceID: "workspace-1", BoardID: "board-2"},
+			{WorkspaceID: "workspace-1", BoardID: "board-3"},
+		}, nil)
+		store.EXPECT().PurgeBoard(container, "board-2").Return(nil)
+		store.EXPECT().GetWorkspaceFileRefs("workspace-1").Return([]model.FileRef{}, nil)
+		// Restored since it was listed
+		store.EXPECT().PurgeBoard(container, "board-3").Return(&st.ErrBlocksNotFound{BlockIDs: []string{"board-3"}})
+
+		purged, err := app.PurgeExpiredTrash()
+		require.NoError(t, err)
+		require.Equal(t, 1, purged)
+	})
+
+	t.Run("no retention", func(t *testing.T) {
+		cfg.TrashRetentionDays = 0
+		defer func() { cfg.TrashRetentionDays = 30 }()
+
+		purged, err := app.PurgeExpiredTrash()
+		require.NoError(t, err)
+		require.Zero(t, purged)
+	})
+}
+
+func TestRestoreBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Configuration{MaxBoardsPerWorkspace: 2}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, nil, webhook)
+
+	container := st.Container{WorkspaceID: "workspace-1"}
+	board := model.Block{ID: "board-1", RootID: "board-1", Type: "board", DeleteAt: 100}
+	store.EXPECT().GetDeletedBoards("workspace-1").Return([]model.Block{board}, nil).Times(2)
+	store.EXPECT().GetExistingBoards(container, []string{"board-1"}).Return([]string{}, nil).Times(2)
+	store.EXPECT().AcquireBoardLock(container, "workspace-1", gomock.Any(), boardLockTTL).Return(true, nil).Times(2)
+	store.EXPECT().ReleaseBoardLock(container, "workspace-1", gomock.Any()).Return(nil).Times(2)
+
+	t.Run("restore up to the limit", func(t *testing.T) {
+		store.EXPECT().CountBoards(container).Return(1, nil)
+		store.EXPECT().RestoreBoard(container, "board-1", "user-1").Return([]model.Block{board}, nil)
+
+		require.NoError(t, app.RestoreBoard(container, "board-1", "user-1"))
+	})
+
+	t.Run("restore beyond the limit", func(t *testing.T) {
+		store.EXPECT().CountBoards(container).Return(2, nil)
+
+		err := app.RestoreBoard(container, "board-1", "user-1")
+		require.Equal(t, ErrBoardLimitReached, err)
+	})
+}
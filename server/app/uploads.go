@@ -0,0 +1,123 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrShuttingDown is returned when saving a file while the server shuts
+	// down
+	ErrShuttingDown = errors.New("the server is shutting down")
+
+	// ErrUploadAborted is returned when an upload is still in progress once
+	// the shutdown timeout passes
+	ErrUploadAborted = errors.New("the upload was aborted by the server shutdown")
+)
+
+// Uploads tracks the files being saved by every app of the server
+type Uploads struct {
+	mu       sync.Mutex
+	inFlight sync.WaitGroup
+	count    int
+	closed   bool
+	aborted  chan struct{}
+}
+
+func NewUploads() *Uploads {
+	return &Uploads{aborted: make(chan struct{})}
+}
+
+func (u *Uploads) begin() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closed {
+		return ErrShuttingDown
+	}
+
+	u.count++
+	u.inFlight.Add(1)
+
+	return nil
+}
+
+func (u *Uploads) end() {
+	u.mu.Lock()
+	u.count--
+	u.mu.Unlock()
+
+	u.inFlight.Done()
+}
+
+// Shutdown stops accepting uploads and waits for the ones in progress to
+// finish. Once the timeout passes the rest of them are aborted, and it waits
+// for them to remove their partial content.
+func (u *Uploads) Shutdown(timeout time.Duration) error {
+	u.mu.Lock()
+	u.closed = true
+	u.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		u.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	u.mu.Lock()
+	pending := u.count
+	u.mu.Unlock()
+
+	log.Printf("Aborting %d uploads in progress on shutdown", pending)
+	close(u.aborted)
+	<-done
+
+	return nil
+}
+
+// abortable returns a reader that fails once the uploads are aborted
+func (u *Uploads) abortable(reader io.Reader) io.Reader {
+	return &abortableReader{reader: reader, aborted: u.aborted}
+}
+
+type abortableReader struct {
+	reader  io.Reader
+	aborted <-chan struct{}
+}
+
+func (r *abortableReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.aborted:
+		return 0, ErrUploadAborted
+	default:
+	}
+
+	return r.reader.Read(p)
+}
+
+// BeginUpload registers an upload before its request body is read, so the
+// shutdown waits for it from the start. It returns the body, aborted once the
+// shutdown timeout passes, the function ending the upload, and a copy of the
+// app saving its file as part of the upload.
+func (a *App) BeginUpload(body io.Reader) (io.Reader, func(), *App, error) {
+	if a.uploads == nil {
+		return body, func() {}, a, nil
+	}
+
+	if err := a.uploads.begin(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	copy := *a
+	copy.uploads = nil
+	return a.uploads.abortable(body), a.uploads.end, &copy, nil
+}
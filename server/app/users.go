@@ -0,0 +1,209 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/auth"
+	"github.com/pkg/errors"
+)
+
+// ErrNoBoardsOwner is returned when deleting a user without another user to
+// reassign its boards to
+var ErrNoBoardsOwner = errors.New("another user is required as the new owner of the boards")
+
+// ErrInvalidImportRecord is returned when a strict import has an invalid
+// record
+type ErrInvalidImportRecord struct {
+	Username string
+	Reason   error
+}
+
+func (e *ErrInvalidImportRecord) Error() string {
+	return fmt.Sprintf("invalid user %q: %v", e.Username, e.Reason)
+}
+
+// ImportUserRecord is one of the accounts of a provisioning file. Accounts
+// with an auth service are linked to it, the rest of them get the password
+// of the record or a temporary one when it's empty.
+type ImportUserRecord struct {
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Password    string `json:"password,omitempty"`
+	AuthService string `json:"auth_service,omitempty"`
+	AuthData    string `json:"auth_data,omitempty"`
+}
+
+// ImportUserResult reports what happened with each record of an import
+type ImportUserResult struct {
+	Username          string `json:"username"`
+	Success           bool   `json:"success"`
+	UserID            string `json:"user_id,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// ImportUsers creates the accounts of a provisioning file. In strict mode
+// they're created in a single transaction and a single invalid record or
+// conflict aborts the whole batch, otherwise every record is created on its
+// own and the failures are only reported in its result.
+func (a *App) ImportUsers(records []ImportUserRecord, strict bool) ([]ImportUserResult, error) {
+	results := make([]ImportUserResult, len(records))
+	users := make([]model.User, 0, len(records))
+	for i, record := range records {
+		results[i].Username = record.Username
+
+		user, temporaryPassword, err := a.userFromImportRecord(record)
+		if err != nil {
+			if strict {
+				return nil, &ErrInvalidImportRecord{Username: record.Username, Reason: err}
+			}
+
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i].UserID = user.ID
+		results[i].TemporaryPassword = temporaryPassword
+
+		if strict {
+			users = append(users, *user)
+			continue
+		}
+
+		if err := a.store.CreateUsers([]model.User{*user}); err != nil {
+			results[i] = ImportUserResult{Username: record.Username, Error: err.Error()}
+			continue
+		}
+		results[i].Success = true
+	}
+
+	if !strict {
+		return results, nil
+	}
+
+	if err := a.store.CreateUsers(users); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Success = true
+	}
+
+	return results, nil
+}
+
+func (a *App) userFromImportRecord(record ImportUserRecord) (*model.User, string, error) {
+	if record.Username == "" {
+		return nil, "", errors.New("the username is required")
+	}
+
+	if record.Email != "" && !auth.IsEmailValid(record.Email) {
+		return nil, "", errors.New("invalid email")
+	}
+
+	user := &model.User{
+		ID:          uuid.New().String(),
+		Username:    record.Username,
+		Email:       record.Email,
+		AuthService: record.AuthService,
+		AuthData:    record.AuthData,
+		Props:       map[string]interface{}{},
+	}
+
+	if record.AuthService != "" {
+		if record.AuthData == "" {
+			return nil, "", errors.New("the auth data is required to link the user to its auth service")
+		}
+
+		return user, "", nil
+	}
+
+	passwordSettings := auth.PasswordSettings{
+		MinimumLength: 6,
+	}
+
+	password := record.Password
+	temporaryPassword := ""
+	if password == "" {
+		password = auth.GeneratePassword(16)
+		temporaryPassword = password
+	} else if err := auth.IsPasswordValid(password, passwordSettings); err != nil {
+		return nil, "", errors.Wrap(err, "invalid password")
+	}
+
+	user.AuthService = a.config.AuthMode
+	user.Password = a.hashPassword(password)
+
+	return user, temporaryPassword, nil
+}
+
+// AnonymizeUser removes the identity of a user from its sessions and from
+// the content of the boards, which is kept. The websocket connections of its
+// sessions are logged out.
+func (a *App) AnonymizeUser(username string) error {
+	// The blocks the user modified may be in any workspace
+	defer a.invalidateAll()
+
+	user, err := a.store.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	sessionIDs, err := a.store.GetUserSessionIDs(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.store.AnonymizeUser(user.ID); err != nil {
+		return err
+	}
+	a.wsServer.ExpireSessions(sessionIDs)
+	a.audit(auditActorSystem, "anonymizeUser", user.ID, "")
+
+	return nil
+}
+
+// DeleteUser deletes the account of a user, after reassigning its boards to
+// another user, by default the one configured as the owner of the boards of
+// the deleted users. The websocket connections of its sessions are logged
+// out. It returns the number of boards reassigned.
+func (a *App) DeleteUser(username, boardsOwner string) (int64, error) {
+	// The blocks the user modified may be in any workspace
+	defer a.invalidateAll()
+
+	if boardsOwner == "" {
+		boardsOwner = a.config.DeletedUserBoardsOwner
+	}
+	if boardsOwner == "" {
+		return 0, ErrNoBoardsOwner
+	}
+
+	user, err := a.store.GetUserByUsername(username)
+	if err != nil {
+		return 0, err
+	}
+
+	owner, err := a.store.GetUserByUsername(boardsOwner)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get the new owner of the boards")
+	}
+	if owner.ID == user.ID {
+		return 0, ErrNoBoardsOwner
+	}
+
+	sessionIDs, err := a.store.GetUserSessionIDs(user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned, err := a.store.DeleteUserReassigningBoards(user.ID, owner.ID)
+	if err != nil {
+		return reassigned, err
+	}
+	a.wsServer.ExpireSessions(sessionIDs)
+	a.audit(auditActorSystem, "deleteUser", user.ID, fmt.Sprintf("%d boards reassigned to %s", reassigned, owner.ID))
+
+	return reassigned, nil
+}
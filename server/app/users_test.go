@@ -0,0 +1,179 @@
+package app
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{AuthMode: "native"}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	records := []ImportUserRecord{
+		{Username: "jane", Email: "jane@example.com", Password: "secret-password"},
+		{Username: "john", Email: "john@example.com"},
+		{Username: "alice", AuthService: "saml", AuthData: "alice-sso-id"},
+	}
+
+	t.Run("strict mode creates every user in a single batch", func(t *testing.T) {
+		store.EXPECT().CreateUsers(gomock.Any()).DoAndReturn(func(users []model.User) error {
+			require.Len(t, users, 3)
+			require.Equal(t, "native", users[0].AuthService)
+			require.NotEqual(t, "secret-password", users[0].Password)
+			require.NotEmpty(t, users[1].Password)
+			require.Equal(t, "saml", users[2].AuthService)
+			require.Empty(t, users[2].Password)
+			return nil
+		})
+
+		results, err := app.ImportUsers(records, true)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for _, result := range results {
+			require.True(t, result.Success)
+			require.NotEmpty(t, result.UserID)
+		}
+		require.Empty(t, results[0].TemporaryPassword)
+		require.NotEmpty(t, results[1].TemporaryPassword)
+		require.Empty(t, results[2].TemporaryPassword)
+	})
+
+	t.Run("strict mode aborts on a conflict", func(t *testing.T) {
+		conflict := &st.ErrUserConflict{Field: "username", Value: "john"}
+		store.EXPECT().CreateUsers(gomock.Any()).Return(conflict)
+
+		results, err := app.ImportUsers(records, true)
+		require.Equal(t, conflict, err)
+		require.Nil(t, results)
+	})
+
+	t.Run("strict mode aborts on an invalid record", func(t *testing.T) {
+		invalid := append([]ImportUserRecord{{Username: "bob", Password: "123"}}, records...)
+
+		results, err := app.ImportUsers(invalid, true)
+		var invalidErr *ErrInvalidImportRecord
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, "bob", invalidErr.Username)
+		require.Nil(t, results)
+	})
+
+	t.Run("lenient mode reports the failures of each record", func(t *testing.T) {
+		store.EXPECT().CreateUsers(gomock.Any()).Return(nil)
+		store.EXPECT().CreateUsers(gomock.Any()).Return(&st.ErrUserConflict{Field: "email", Value: "john@example.com"})
+		store.EXPECT().CreateUsers(gomock.Any()).Return(nil)
+
+		invalid := append(append([]ImportUserRecord{}, records...), ImportUserRecord{Username: "bob", AuthService: "saml"})
+
+		results, err := app.ImportUsers(invalid, false)
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		require.True(t, results[0].Success)
+		require.False(t, results[1].Success)
+		require.Equal(t, `the email "john@example.com" is already taken`, results[1].Error)
+		require.Empty(t, results[1].UserID)
+		require.Empty(t, results[1].TemporaryPassword)
+		require.True(t, results[2].Success)
+		require.False(t, results[3].Success)
+		require.NotEmpty(t, results[3].Error)
+	})
+}
+
+func TestRevokedSessionsLogOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{SessionExpireTime: 60, SessionRefreshTime: 60, DeletedUserBoardsOwner: "owner"}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	r := mux.NewRouter()
+	wsserver.RegisterRoutes(r)
+	httpServer := httptest.NewServer(r)
+	defer httpServer.Close()
+
+	// connect opens a websocket connection authenticated with the session
+	connect := func(t *testing.T) *websocket.Conn {
+		// The connection of the previous test is gone
+		require.Eventually(t, func() bool {
+			return wsserver.SubscriptionCounts()["block-id"] == 0
+		}, time.Second, 10*time.Millisecond)
+
+		store.EXPECT().GetSession("session-token", int64(60)).Return(&model.Session{ID: "session-id", UpdateAt: time.Now().Unix()}, nil)
+
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws/onchange", nil)
+		require.NoError(t, err)
+		require.NoError(t, client.WriteJSON(ws.WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "session-token"}))
+
+		// A broadcast only reaches the connection once it's authenticated
+		require.NoError(t, client.WriteJSON(ws.WebsocketCommand{Action: "ADD", BlockIDs: []string{"block-id"}}))
+		require.Eventually(t, func() bool {
+			return wsserver.SubscriptionCounts()["block-id"] == 1
+		}, time.Second, 10*time.Millisecond)
+
+		return client
+	}
+
+	requireLoggedOut := func(t *testing.T, client *websocket.Conn) {
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		var message ws.SessionExpiredMsg
+		require.NoError(t, client.ReadJSON(&message))
+		require.Equal(t, "SESSION_EXPIRED", message.Action)
+
+		_, _, err := client.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, ws.CloseSessionExpired), err)
+	}
+
+	user := &model.User{ID: "user-id", Username: "jane"}
+
+	t.Run("deleted user", func(t *testing.T) {
+		client := connect(t)
+		defer client.Close()
+
+		store.EXPECT().GetUserByUsername("jane").Return(user, nil)
+		store.EXPECT().GetUserByUsername("owner").Return(&model.User{ID: "owner-id", Username: "owner"}, nil)
+		store.EXPECT().GetUserSessionIDs("user-id").Return([]string{"session-id"}, nil)
+		store.EXPECT().DeleteUserReassigningBoards("user-id", "owner-id").Return(int64(0), nil)
+		expectAudit(t, store, "system", "deleteUser", "user-id")
+
+		_, err := app.DeleteUser("jane", "")
+		require.NoError(t, err)
+		requireLoggedOut(t, client)
+	})
+
+	t.Run("anonymized user", func(t *testing.T) {
+		client := connect(t)
+		defer client.Close()
+
+		store.EXPECT().GetUserByUsername("jane").Return(user, nil)
+		store.EXPECT().GetUserSessionIDs("user-id").Return([]string{"session-id"}, nil)
+		store.EXPECT().AnonymizeUser("user-id").Return(nil)
+		expectAudit(t, store, "system", "anonymizeUser", "user-id")
+
+		require.NoError(t, app.AnonymizeUser("jane"))
+		requireLoggedOut(t, client)
+	})
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// ErrInvalidWebhookURL is returned when registering a webhook that isn't an
+// absolute HTTP or HTTPS URL
+var ErrInvalidWebhookURL = errors.New("the webhook URL must be an absolute http or https URL")
+
+// ErrUnresolvableWebhookHost is returned when registering a webhook whose
+// host can't be resolved
+var ErrUnresolvableWebhookHost = errors.New("the webhook host can't be resolved")
+
+// ErrPrivateWebhookTarget is returned when registering a webhook at a
+// private, loopback or link-local address, which the webhooks aren't
+// delivered to unless WebhookAllowPrivateTargets is set
+var ErrPrivateWebhookTarget = errors.New("the webhook URL resolves to a private address")
+
+func (a *App) GetWorkspaceWebhooks(c store.Container) ([]model.WorkspaceWebhook, error) {
+	return a.store.GetWorkspaceWebhooks(c.WorkspaceID)
+}
+
+func (a *App) CreateWorkspaceWebhook(c store.Container, webhookURL, createdBy string) (*model.WorkspaceWebhook, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	// The deliveries to private addresses are refused, so the webhook
+	// isn't registered at all
+	if err := a.webhook.CheckTarget(context.Background(), webhookURL); err != nil {
+		var dnsErr *net.DNSError
+		switch {
+		case errors.Is(err, webhook.ErrPrivateTarget):
+			return nil, ErrPrivateWebhookTarget
+		case errors.As(err, &dnsErr):
+			return nil, ErrUnresolvableWebhookHost
+		}
+		return nil, err
+	}
+
+	webhook := model.WorkspaceWebhook{
+		ID:          utils.CreateGUID(),
+		WorkspaceID: c.WorkspaceID,
+		URL:         webhookURL,
+		CreatedBy:   createdBy,
+		CreateAt:    utils.GetMillis(),
+	}
+	if err := a.store.CreateWorkspaceWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (a *App) DeleteWorkspaceWebhook(c store.Container, webhookID string) error {
+	return a.store.DeleteWorkspaceWebhook(c.WorkspaceID, webhookID)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWorkspaceWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, &mocks.FileBackend{}, webhook)
+
+	container := st.Container{
+		WorkspaceID: "workspace-id",
+	}
+
+	t.Run("public target", func(t *testing.T) {
+		store.EXPECT().CreateWorkspaceWebhook(gomock.Any()).DoAndReturn(func(webhook model.WorkspaceWebhook) error {
+			require.Equal(t, "workspace-id", webhook.WorkspaceID)
+			require.Equal(t, "https://93.184.216.34/hook", webhook.URL)
+			return nil
+		})
+
+		_, err := app.CreateWorkspaceWebhook(container, "https://93.184.216.34/hook", "user-id")
+		require.NoError(t, err)
+	})
+
+	t.Run("private targets", func(t *testing.T) {
+		for _, url := range []string{"http://10.0.0.5/hook", "http://127.0.0.1:8000/hook", "http://[::1]/hook", "http://169.254.169.254/latest"} {
+			_, err := app.CreateWorkspaceWebhook(container, url, "user-id")
+			require.Equal(t, ErrPrivateWebhookTarget, err, url)
+		}
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		_, err := app.CreateWorkspaceWebhook(container, "ftp://93.184.216.34/hook", "user-id")
+		require.Equal(t, ErrInvalidWebhookURL, err)
+	})
+}
@@ -0,0 +1,278 @@
+package app
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidWorkspaceBundle is returned when importing a bundle that isn't
+// one written by the workspace export
+var ErrInvalidWorkspaceBundle = errors.New("invalid workspace bundle")
+
+// ExportWorkspace writes the zip bundle of a workspace, with its settings,
+// its boards and the files uploaded to them, streamed from the store
+func (a *App) ExportWorkspace(workspaceID string, w io.Writer) error {
+	if err := a.store.ExportWorkspace(workspaceID, w); err != nil {
+		return err
+	}
+	a.audit(auditActorSystem, "exportWorkspace", workspaceID, "")
+
+	return nil
+}
+
+// ImportWorkspace restores a bundle written by ExportWorkspace into a
+// workspace. The boards are imported like the blocks of an import. Into the
+// workspace of the bundle, the blocks and files keep their IDs, so they're
+// restored, and the files that still exist are skipped. Into another
+// workspace, they get new IDs like the copies of the cards, so they don't
+// clash with the ones of the workspace of the bundle.
+func (a *App) ImportWorkspace(workspaceID string, r io.ReaderAt, size int64, modifiedBy string) error {
+	defer a.invalidateWorkspace(workspaceID)
+
+	bundle, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(ErrInvalidWorkspaceBundle, err.Error())
+	}
+
+	var workspaceFile, refsFile *zip.File
+	var boardFiles []*zip.File
+	var legacyFiles []bundleLegacyFile
+	files := map[string]*zip.File{}
+	for _, file := range bundle.File {
+		switch {
+		case file.Name == store.BundleWorkspaceEntry:
+			workspaceFile = file
+		case strings.HasPrefix(file.Name, store.BundleBoardsPrefix):
+			boardFiles = append(boardFiles, file)
+		case file.Name == store.BundleFilesEntry:
+			refsFile = file
+		case strings.HasPrefix(file.Name, store.BundleFilesPrefix):
+			files[strings.TrimPrefix(file.Name, store.BundleFilesPrefix)] = file
+		case strings.HasPrefix(file.Name, store.BundleLegacyFilesPrefix):
+			parts := strings.Split(strings.TrimPrefix(file.Name, store.BundleLegacyFilesPrefix), "/")
+			if len(parts) != 2 || !validBundleFileName(parts[0]) || !validBundleFileName(parts[1]) {
+				return errors.Wrapf(ErrInvalidWorkspaceBundle, "invalid legacy file %q", file.Name)
+			}
+			legacyFiles = append(legacyFiles, bundleLegacyFile{boardID: parts[0], fileID: parts[1], file: file})
+		}
+	}
+
+	var workspace model.Workspace
+	if workspaceFile != nil {
+		if err := readBundleJSON(workspaceFile, &workspace); err != nil {
+			return err
+		}
+	}
+	remap := workspace.ID != workspaceID
+	if workspace.Settings != nil {
+		workspace.ID = workspaceID
+		workspace.ModifiedBy = modifiedBy
+		if err := a.store.UpsertWorkspaceSettings(workspace); err != nil {
+			return err
+		}
+	}
+
+	var refs []model.FileRef
+	if refsFile != nil {
+		if err := readBundleJSON(refsFile, &refs); err != nil {
+			return err
+		}
+	}
+	fileIDs := map[string]string{}
+	if remap {
+		for _, ref := range refs {
+			fileIDs[ref.ID] = utils.CreateGUID() + filepath.Ext(ref.ID)
+		}
+		for _, legacy := range legacyFiles {
+			if _, ok := fileIDs[legacy.fileID]; !ok {
+				fileIDs[legacy.fileID] = utils.CreateGUID() + filepath.Ext(legacy.fileID)
+			}
+		}
+	}
+
+	c := store.Container{WorkspaceID: workspaceID}
+	blockIDs := map[string]string{}
+	for _, file := range boardFiles {
+		var archive model.Archive
+		if err := readBundleJSON(file, &archive); err != nil {
+			return err
+		}
+		blocks := archive.Blocks
+		if remap {
+			blocks = remapBundleBlocks(blocks, blockIDs, fileIDs)
+		}
+		if err := a.ImportBlocks(c, blocks); err != nil {
+			return err
+		}
+	}
+
+	for _, ref := range refs {
+		file, ok := files[ref.ID]
+		if !ok {
+			return errors.Wrapf(ErrInvalidWorkspaceBundle, "file %s is missing", ref.ID)
+		}
+		if remap {
+			ref.ID = fileIDs[ref.ID]
+			if rootID, ok := blockIDs[ref.RootID]; ok {
+				ref.RootID = rootID
+			}
+		}
+		if err := a.importBundleFile(workspaceID, ref, file); err != nil {
+			return err
+		}
+	}
+
+	for _, legacy := range legacyFiles {
+		if remap {
+			boardID, ok := blockIDs[legacy.boardID]
+			if !ok {
+				return errors.Wrapf(ErrInvalidWorkspaceBundle, "legacy file %s of a board that isn't in the bundle", legacy.file.Name)
+			}
+			legacy.boardID, legacy.fileID = boardID, fileIDs[legacy.fileID]
+		}
+		if err := a.importBundleLegacyFile(workspaceID, legacy); err != nil {
+			return err
+		}
+	}
+	a.audit(modifiedBy, "importWorkspace", workspaceID, fmt.Sprintf("%d boards, %d files", len(boardFiles), len(refs)+len(legacyFiles)))
+
+	return nil
+}
+
+// remapBundleBlocks gives the blocks of a board of a bundle new IDs, the
+// same for a block across the boards of the bundle, and updates the
+// references to the blocks and to the files of the bundle
+func remapBundleBlocks(blocks []model.Block, blockIDs, fileIDs map[string]string) []model.Block {
+	newID := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		if _, ok := blockIDs[id]; !ok {
+			blockIDs[id] = utils.CreateGUID()
+		}
+		return blockIDs[id]
+	}
+	for _, block := range blocks {
+		newID(block.ID)
+	}
+
+	remapped := make([]model.Block, 0, len(blocks))
+	for _, block := range blocks {
+		block.ID = newID(block.ID)
+		block.ParentID = newID(block.ParentID)
+		block.RootID = newID(block.RootID)
+		if order, ok := block.Fields["contentOrder"].([]interface{}); ok {
+			block.Fields["contentOrder"] = remapContentOrder(order, blockIDs)
+		}
+		if fileID, _ := block.Fields["fileId"].(string); fileID != "" {
+			if newFileID, ok := fileIDs[fileID]; ok {
+				block.Fields["fileId"] = newFileID
+			}
+		}
+		remapped = append(remapped, block)
+	}
+
+	return remapped
+}
+
+func readBundleJSON(file *zip.File, value interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := json.NewDecoder(reader).Decode(value); err != nil {
+		return errors.Wrapf(ErrInvalidWorkspaceBundle, "entry %s: %v", file.Name, err)
+	}
+
+	return nil
+}
+
+// importBundleFile stores the content of a file of a bundle like an upload,
+// sharing the blob of the same content if there's one
+func (a *App) importBundleFile(workspaceID string, ref model.FileRef, file *zip.File) error {
+	// The IDs are file names, as the legacy files are looked up by them
+	if !validBundleFileName(ref.ID) {
+		return errors.Wrapf(ErrInvalidWorkspaceBundle, "invalid file id %q", ref.ID)
+	}
+
+	if existing, err := a.store.GetFileRef(ref.ID); err == nil {
+		if existing.WorkspaceID != workspaceID {
+			return errors.Wrapf(ErrInvalidWorkspaceBundle, "file %s belongs to another workspace", ref.ID)
+		}
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	uploadPath := filepath.Join(uploadsDirectory, utils.CreateGUID())
+	hash := sha256.New()
+	size, err := a.filesBackend.WriteFile(io.TeeReader(reader, hash), uploadPath)
+	if err != nil {
+		a.removeFile(uploadPath)
+		return errors.Wrapf(err, "unable to store file %s", ref.ID)
+	}
+
+	ref.WorkspaceID = workspaceID
+	ref.Hash = hex.EncodeToString(hash.Sum(nil))
+	return a.storeBlob(uploadPath, ref, size)
+}
+
+// bundleLegacyFile is a file of a bundle uploaded before the content of the
+// files was stored by hash, which has no ref and is stored by its own name
+type bundleLegacyFile struct {
+	boardID string
+	fileID  string
+	file    *zip.File
+}
+
+// importBundleLegacyFile restores a legacy file where the legacy files of
+// the board are looked up, keeping the file if it's still there
+func (a *App) importBundleLegacyFile(workspaceID string, legacy bundleLegacyFile) error {
+	path := filepath.Join(workspaceID, legacy.boardID, legacy.fileID)
+	exists, err := a.filesBackend.FileExists(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to store file %s", legacy.fileID)
+	}
+	if exists {
+		return nil
+	}
+
+	reader, err := legacy.file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if _, err := a.filesBackend.WriteFile(reader, path); err != nil {
+		a.removeFile(path)
+		return errors.Wrapf(err, "unable to store file %s", legacy.fileID)
+	}
+
+	return nil
+}
+
+// validBundleFileName tells if an ID of a bundle can be the name of a file,
+// as the files are stored by the IDs of their boards and their own
+func validBundleFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,286 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportWorkspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newApp := func() (*App, *mockstore.MockStore, filesstore.FileBackend) {
+		filesPath, err := ioutil.TempDir("", "focalboard-files")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(filesPath) })
+
+		filesBackend, err := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+		require.NoError(t, err)
+
+		cfg := config.Configuration{FilesPath: filesPath}
+		store := mockstore.NewMockStore(ctrl)
+		auth := auth.New(&cfg, store)
+		wsserver := ws.NewServer(auth, "")
+		webhook := webhook.NewClient(&cfg, nil)
+		return New(&cfg, store, auth, wsserver, filesBackend, webhook), store, filesBackend
+	}
+
+	source := st.Container{WorkspaceID: "workspace-1"}
+	boards := map[string][]model.Block{
+		"board-1": {
+			{ID: "board-1", RootID: "board-1", Type: "board", Title: "Roadmap"},
+			{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Launch"},
+			{ID: "image-1", RootID: "board-1", ParentID: "card-1", Type: "image", Fields: map[string]interface{}{"fileId": "file-1.png"}},
+		},
+		"board-2": {
+			{ID: "board-2", RootID: "board-2", Type: "board", Title: "Bugs"},
+		},
+	}
+	refs := []model.FileRef{
+		{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", CreateAt: 1},
+	}
+
+	app, store, filesBackend := newApp()
+	_, err := filesBackend.WriteFile(strings.NewReader("hello"), blobPath(refs[0].Hash))
+	require.NoError(t, err)
+
+	store.EXPECT().GetWorkspace("workspace-1").Return(&model.Workspace{ID: "workspace-1", Title: "Acme", Settings: map[string]interface{}{"theme": "dark"}}, nil)
+	store.EXPECT().GetBlocksWithType(source, "board").Return([]model.Block{boards["board-1"][0], boards["board-2"][0]}, nil)
+	store.EXPECT().GetBlocksWithRootID(source, "board-1").Return(boards["board-1"], nil)
+	store.EXPECT().GetBlocksWithRootID(source, "board-2").Return(boards["board-2"], nil)
+	store.EXPECT().GetWorkspaceFileRefs("workspace-1").Return(refs, nil)
+	store.EXPECT().ExportWorkspace("workspace-1", gomock.Any()).DoAndReturn(func(workspaceID string, w io.Writer) error {
+		return st.WriteWorkspaceBundle(store, filesBackend, workspaceID, w)
+	})
+	expectAudit(t, store, "system", "exportWorkspace", "workspace-1")
+
+	var bundle bytes.Buffer
+	require.NoError(t, app.ExportWorkspace("workspace-1", &bundle))
+
+	t.Run("the bundle has all the boards and files", func(t *testing.T) {
+		reader, err := zip.NewReader(bytes.NewReader(bundle.Bytes()), int64(bundle.Len()))
+		require.NoError(t, err)
+
+		entries := map[string][]byte{}
+		for _, file := range reader.File {
+			f, err := file.Open()
+			require.NoError(t, err)
+			entries[file.Name], err = ioutil.ReadAll(f)
+			require.NoError(t, err)
+			f.Close()
+		}
+		require.Len(t, entries, 5)
+
+		var workspace model.Workspace
+		require.NoError(t, json.Unmarshal(entries["workspace.json"], &workspace))
+		require.Equal(t, "Acme", workspace.Title)
+
+		for boardID, blocks := range boards {
+			var archive model.Archive
+			require.NoError(t, json.Unmarshal(entries["boards/"+boardID+".json"], &archive))
+			require.EqualValues(t, archiveVersion, archive.Version)
+			require.Len(t, archive.Blocks, len(blocks))
+		}
+
+		var exportedRefs []model.FileRef
+		require.NoError(t, json.Unmarshal(entries["files.json"], &exportedRefs))
+		require.Equal(t, refs, exportedRefs)
+		require.Equal(t, "hello", string(entries["files/file-1.png"]))
+	})
+
+	t.Run("import into another workspace", func(t *testing.T) {
+		app, store, filesBackend := newApp()
+		target := st.Container{WorkspaceID: "workspace-2"}
+
+		store.EXPECT().UpsertWorkspaceSettings(gomock.Any()).DoAndReturn(func(workspace model.Workspace) error {
+			require.Equal(t, "workspace-2", workspace.ID)
+			require.Equal(t, map[string]interface{}{"theme": "dark"}, workspace.Settings)
+			return nil
+		})
+		store.EXPECT().AcquireBoardLock(target, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+		store.EXPECT().ReleaseBoardLock(target, gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		imported := map[string]model.Block{}
+		store.EXPECT().InsertBlock(target, gomock.Any()).DoAndReturn(func(c st.Container, block model.Block) error {
+			imported[block.Title] = block
+			return nil
+		}).Times(4)
+		store.EXPECT().GetFileRef(gomock.Any()).Return(nil, sql.ErrNoRows)
+		var ref model.FileRef
+		store.EXPECT().CreateFileRef(gomock.Any(), int64(5)).DoAndReturn(func(r model.FileRef, size int64) (bool, error) {
+			ref = r
+			return true, nil
+		})
+		expectAudit(t, store, "admin", "importWorkspace", "workspace-2")
+
+		err := app.ImportWorkspace("workspace-2", bytes.NewReader(bundle.Bytes()), int64(bundle.Len()), "admin")
+		require.NoError(t, err)
+		require.Len(t, imported, 4)
+
+		// The blocks and the file get new IDs, with the references between
+		// them kept
+		board, card, image := imported["Roadmap"], imported["Launch"], imported[""]
+		for _, block := range imported {
+			require.NotContains(t, []string{"board-1", "card-1", "image-1", "board-2"}, block.ID)
+		}
+		require.Equal(t, board.ID, board.RootID)
+		require.Equal(t, board.ID, card.ParentID)
+		require.Equal(t, card.ID, image.ParentID)
+		require.Equal(t, board.ID, image.RootID)
+
+		require.NotEqual(t, "file-1.png", ref.ID)
+		require.Equal(t, ".png", filepath.Ext(ref.ID))
+		require.Equal(t, ref.ID, image.Fields["fileId"])
+		require.Equal(t, "workspace-2", ref.WorkspaceID)
+		require.Equal(t, board.ID, ref.RootID)
+		require.Equal(t, refs[0].Hash, ref.Hash)
+
+		content, err := filesBackend.ReadFile(blobPath(refs[0].Hash))
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(content))
+	})
+
+	t.Run("import into the same workspace", func(t *testing.T) {
+		app, store, _ := newApp()
+
+		store.EXPECT().UpsertWorkspaceSettings(gomock.Any()).Return(nil)
+		store.EXPECT().AcquireBoardLock(source, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+		store.EXPECT().ReleaseBoardLock(source, gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		imported := []string{}
+		store.EXPECT().InsertBlock(source, gomock.Any()).DoAndReturn(func(c st.Container, block model.Block) error {
+			imported = append(imported, block.ID)
+			return nil
+		}).Times(4)
+		// The file still exists, so it's kept as it is
+		store.EXPECT().GetFileRef("file-1.png").Return(&refs[0], nil)
+		expectAudit(t, store, "admin", "importWorkspace", "workspace-1")
+
+		err := app.ImportWorkspace("workspace-1", bytes.NewReader(bundle.Bytes()), int64(bundle.Len()), "admin")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"board-1", "card-1", "image-1", "board-2"}, imported)
+	})
+
+	t.Run("a file of another workspace", func(t *testing.T) {
+		app, store, _ := newApp()
+
+		store.EXPECT().UpsertWorkspaceSettings(gomock.Any()).Return(nil)
+		store.EXPECT().AcquireBoardLock(source, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+		store.EXPECT().ReleaseBoardLock(source, gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		store.EXPECT().InsertBlock(source, gomock.Any()).Return(nil).Times(4)
+		store.EXPECT().GetFileRef("file-1.png").Return(&model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-3"}, nil)
+
+		err := app.ImportWorkspace("workspace-1", bytes.NewReader(bundle.Bytes()), int64(bundle.Len()), "admin")
+		require.ErrorIs(t, err, ErrInvalidWorkspaceBundle)
+	})
+
+	t.Run("legacy files round-trip", func(t *testing.T) {
+		legacyBlocks := []model.Block{
+			{ID: "board-3", RootID: "board-3", Type: "board", Title: "Legacy"},
+			{ID: "image-3", RootID: "board-3", ParentID: "board-3", Type: "image", Title: "Old image", Fields: map[string]interface{}{"fileId": "legacy.png"}},
+			// A file that's gone from the files storage is left out
+			{ID: "image-4", RootID: "board-3", ParentID: "board-3", Type: "image", Title: "Lost image", Fields: map[string]interface{}{"fileId": "lost.png"}},
+		}
+		source := st.Container{WorkspaceID: "workspace-3"}
+
+		app, store, filesBackend := newApp()
+		_, err := filesBackend.WriteFile(strings.NewReader("old content"), filepath.Join("workspace-3", "board-3", "legacy.png"))
+		require.NoError(t, err)
+
+		store.EXPECT().GetWorkspace("workspace-3").Return(nil, sql.ErrNoRows)
+		store.EXPECT().GetWorkspaceFileRefs("workspace-3").Return([]model.FileRef{}, nil)
+		store.EXPECT().GetBlocksWithType(source, "board").Return(legacyBlocks[:1], nil)
+		store.EXPECT().GetBlocksWithRootID(source, "board-3").Return(legacyBlocks, nil)
+		store.EXPECT().ExportWorkspace("workspace-3", gomock.Any()).DoAndReturn(func(workspaceID string, w io.Writer) error {
+			return st.WriteWorkspaceBundle(store, filesBackend, workspaceID, w)
+		})
+		expectAudit(t, store, "system", "exportWorkspace", "workspace-3")
+
+		var legacyBundle bytes.Buffer
+		require.NoError(t, app.ExportWorkspace("workspace-3", &legacyBundle))
+
+		reader, err := zip.NewReader(bytes.NewReader(legacyBundle.Bytes()), int64(legacyBundle.Len()))
+		require.NoError(t, err)
+		names := []string{}
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		require.ElementsMatch(t, []string{
+			st.BundleWorkspaceEntry,
+			st.BundleBoardsPrefix + "board-3.json",
+			st.BundleLegacyFilesPrefix + "board-3/legacy.png",
+			st.BundleFilesEntry,
+		}, names)
+
+		// Into another workspace, the file follows the new IDs of the board
+		// and of the file
+		app, store, filesBackend = newApp()
+		target := st.Container{WorkspaceID: "workspace-4"}
+		store.EXPECT().AcquireBoardLock(target, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(target, gomock.Any(), gomock.Any()).Return(nil)
+		imported := map[string]model.Block{}
+		store.EXPECT().InsertBlock(target, gomock.Any()).DoAndReturn(func(c st.Container, block model.Block) error {
+			imported[block.Title] = block
+			return nil
+		}).Times(3)
+		expectAudit(t, store, "admin", "importWorkspace", "workspace-4")
+
+		err = app.ImportWorkspace("workspace-4", bytes.NewReader(legacyBundle.Bytes()), int64(legacyBundle.Len()), "admin")
+		require.NoError(t, err)
+
+		board, image := imported["Legacy"], imported["Old image"]
+		fileID, _ := image.Fields["fileId"].(string)
+		require.NotEqual(t, "legacy.png", fileID)
+		require.Equal(t, ".png", filepath.Ext(fileID))
+		content, err := filesBackend.ReadFile(filepath.Join("workspace-4", board.ID, fileID))
+		require.NoError(t, err)
+		require.Equal(t, "old content", string(content))
+		require.Equal(t, "lost.png", imported["Lost image"].Fields["fileId"])
+
+		// Into the same workspace, the file is restored where it was
+		app, store, filesBackend = newApp()
+		store.EXPECT().AcquireBoardLock(source, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().ReleaseBoardLock(source, gomock.Any(), gomock.Any()).Return(nil)
+		store.EXPECT().InsertBlock(source, gomock.Any()).Return(nil).Times(3)
+		expectAudit(t, store, "admin", "importWorkspace", "workspace-3")
+
+		err = app.ImportWorkspace("workspace-3", bytes.NewReader(legacyBundle.Bytes()), int64(legacyBundle.Len()), "admin")
+		require.NoError(t, err)
+		content, err = filesBackend.ReadFile(filepath.Join("workspace-3", "board-3", "legacy.png"))
+		require.NoError(t, err)
+		require.Equal(t, "old content", string(content))
+	})
+
+	t.Run("a legacy file with an invalid name", func(t *testing.T) {
+		var invalid bytes.Buffer
+		writer := zip.NewWriter(&invalid)
+		_, err := writer.Create(st.BundleLegacyFilesPrefix + "board-3/../../secret")
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		err = app.ImportWorkspace("workspace-2", bytes.NewReader(invalid.Bytes()), int64(invalid.Len()), "admin")
+		require.ErrorIs(t, err, ErrInvalidWorkspaceBundle)
+	})
+
+	t.Run("invalid bundle", func(t *testing.T) {
+		err := app.ImportWorkspace("workspace-2", strings.NewReader("not a zip"), 9, "admin")
+		require.ErrorIs(t, err, ErrInvalidWorkspaceBundle)
+	})
+}
@@ -6,30 +6,38 @@ import (
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/utils"
+	"github.com/pkg/errors"
 )
 
+// GetRootWorkspace returns the root workspace, creating it if it doesn't
+// exist. It's only created when it's missing, so a failure to read it
+// doesn't replace the signup token of the existing one, and a failed
+// initialization can be retried.
 func (a *App) GetRootWorkspace() (*model.Workspace, error) {
 	workspaceID := "0"
-	workspace, _ := a.store.GetWorkspace(workspaceID)
-	if workspace == nil {
-		workspace = &model.Workspace{
-			ID:          workspaceID,
-			SignupToken: utils.CreateGUID(),
-		}
-		err := a.store.UpsertWorkspaceSignupToken(*workspace)
-		if err != nil {
-			log.Fatal("Unable to initialize workspace", err)
-			return nil, err
-		}
-		workspace, err = a.store.GetWorkspace(workspaceID)
-		if err != nil {
-			log.Fatal("Unable to get initialized workspace", err)
-			return nil, err
-		}
+	workspace, err := a.store.GetWorkspace(workspaceID)
+	if err == nil {
+		return workspace, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "unable to read the root workspace")
+	}
 
-		log.Println("initialized workspace")
+	err = a.store.UpsertWorkspaceSignupToken(model.Workspace{
+		ID:          workspaceID,
+		SignupToken: utils.CreateGUID(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the root workspace")
 	}
 
+	workspace, err = a.store.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the created root workspace")
+	}
+
+	log.Println("initialized workspace")
+
 	return workspace, nil
 }
 
@@ -45,9 +53,37 @@ func (a *App) getWorkspace(ID string) (*model.Workspace, error) {
 }
 
 func (a *App) UpsertWorkspaceSettings(workspace model.Workspace) error {
+	defer a.invalidateWorkspace(workspace.ID)
 	return a.store.UpsertWorkspaceSettings(workspace)
 }
 
 func (a *App) UpsertWorkspaceSignupToken(workspace model.Workspace) error {
+	defer a.invalidateWorkspace(workspace.ID)
 	return a.store.UpsertWorkspaceSignupToken(workspace)
 }
+
+// GetWorkspacesByActivity returns the most recently active workspaces of the
+// user. If hasAccess is set, the workspaces it denies are left out before
+// the limit is applied, so they don't take the place of accessible ones.
+func (a *App) GetWorkspacesByActivity(userID string, limit int, hasAccess func(workspaceID string) bool) ([]model.WorkspaceActivity, error) {
+	if hasAccess == nil {
+		return a.store.GetWorkspacesByActivity(userID, limit)
+	}
+
+	activities, err := a.store.GetWorkspacesByActivity(userID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	accessible := []model.WorkspaceActivity{}
+	for _, activity := range activities {
+		if len(accessible) == limit {
+			break
+		}
+		if hasAccess(activity.WorkspaceID) {
+			accessible = append(accessible, activity)
+		}
+	}
+
+	return accessible, nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWorkspacesByActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Configuration{}
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&cfg, store)
+	wsserver := ws.NewServer(auth, "")
+	webhook := webhook.NewClient(&cfg, nil)
+	app := New(&cfg, store, auth, wsserver, nil, webhook)
+
+	activities := []model.WorkspaceActivity{
+		{WorkspaceID: "workspace-1", LastActivityAt: 400},
+		{WorkspaceID: "workspace-2", LastActivityAt: 300},
+		{WorkspaceID: "workspace-3", LastActivityAt: 200},
+		{WorkspaceID: "workspace-4", LastActivityAt: 100},
+	}
+
+	t.Run("without an access check", func(t *testing.T) {
+		store.EXPECT().GetWorkspacesByActivity("user-1", 2).Return(activities[:2], nil)
+
+		result, err := app.GetWorkspacesByActivity("user-1", 2, nil)
+		require.NoError(t, err)
+		require.Equal(t, activities[:2], result)
+	})
+
+	t.Run("the denied workspaces don't count toward the limit", func(t *testing.T) {
+		store.EXPECT().GetWorkspacesByActivity("user-1", 0).Return(activities, nil)
+
+		result, err := app.GetWorkspacesByActivity("user-1", 2, func(workspaceID string) bool {
+			return workspaceID != "workspace-1"
+		})
+		require.NoError(t, err)
+		require.Equal(t, activities[1:3], result)
+	})
+}
@@ -2,36 +2,85 @@ package auth
 
 import (
 	"database/sql"
+	"log"
 	"time"
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/config"
 	"github.com/mattermost/focalboard/server/services/store"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 // Auth authenticates sessions
 type Auth struct {
 	config *config.Configuration
 	store  store.Store
+	clock  *clock
+
+	// SessionDenied, if set, is called with the sessions denied a refresh,
+	// so their websocket connections are logged out
+	SessionDenied func(sessionIDs []string)
+
+	// Logger, if set, gets the warnings, which otherwise go to the standard
+	// log
+	Logger *zap.Logger
 }
 
 // New returns a new Auth
 func New(config *config.Configuration, store store.Store) *Auth {
-	return &Auth{config: config, store: store}
+	return &Auth{config: config, store: store, clock: newClock()}
+}
+
+// SessionExpireTime returns the lifetime of the sessions, in seconds, with
+// the clock skew tolerance, so the sessions aren't expired early if the
+// clock moved forward a little
+func (a *Auth) SessionExpireTime() int64 {
+	return a.config.SessionExpireTime + a.config.ClockSkewTolerance
+}
+
+// now returns the time in seconds, logging a warning if the clock jumped
+// back further than the clock skew tolerance since the last session lookup
+func (a *Auth) now() int64 {
+	now, jump := a.clock.now()
+	if -jump > time.Duration(a.config.ClockSkewTolerance)*time.Second {
+		if a.Logger != nil {
+			a.Logger.Warn("The clock jumped back, the sessions refreshed before may outlive their lifetime until their next refresh", zap.Duration("jump", -jump))
+		} else {
+			log.Printf("WARNING the clock jumped back %v, the sessions refreshed before may outlive their lifetime until their next refresh", -jump)
+		}
+	}
+
+	return now.Unix()
 }
 
-// GetSession Get a user active session and refresh the session if is needed
-func (a *Auth) GetSession(token string) (*model.Session, error) {
+// ErrSessionDeviceMismatch is returned when a session is due for a refresh
+// but is presented from a device other than the one that created it.
+var ErrSessionDeviceMismatch = errors.New("session used from a different device, authentication required")
+
+// GetSession Get a user active session and refresh the session if is needed.
+// With SessionDeviceBinding enabled, the session is only refreshed if the
+// device fingerprint matches the one it was created with, and otherwise its
+// connections are logged out through SessionDenied. The sessions last
+// updated later than now, beyond the clock skew tolerance, were refreshed
+// before the clock went back, so they're refreshed again.
+func (a *Auth) GetSession(token, deviceFingerprint string) (*model.Session, error) {
 	if len(token) < 1 {
 		return nil, errors.New("no session token")
 	}
 
-	session, err := a.store.GetSession(token, a.config.SessionExpireTime)
+	now := a.now()
+	session, err := a.store.GetSession(token, a.SessionExpireTime())
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to get the session for the token")
 	}
-	if session.UpdateAt < (time.Now().Unix() - a.config.SessionRefreshTime) {
+	if session.UpdateAt < (now-a.config.SessionRefreshTime) || session.UpdateAt > (now+a.config.ClockSkewTolerance) {
+		if a.config.SessionDeviceBinding && session.DeviceFingerprint != deviceFingerprint {
+			if a.SessionDenied != nil {
+				a.SessionDenied([]string{session.ID})
+			}
+			return nil, ErrSessionDeviceMismatch
+		}
 		a.store.RefreshSession(session)
 	}
 	return session, nil
@@ -39,6 +88,12 @@ func (a *Auth) GetSession(token string) (*model.Session, error) {
 
 // IsValidReadToken validates the read token for a block
 func (a *Auth) IsValidReadToken(c store.Container, blockID string, readToken string) (bool, error) {
+	// A token can't be valid for a block that isn't in the workspace
+	exists, err := a.store.BlockExists(c, blockID)
+	if err != nil || !exists {
+		return false, err
+	}
+
 	rootID, err := a.store.GetRootID(c, blockID)
 	if err != nil {
 		return false, err
@@ -52,7 +107,7 @@ func (a *Auth) IsValidReadToken(c store.Container, blockID string, readToken str
 		return false, err
 	}
 
-	if sharing != nil && (sharing.ID == rootID && sharing.Enabled && sharing.Token == readToken) {
+	if sharing != nil && (sharing.ID == rootID && sharing.Enabled && sharing.Token == readToken && !sharing.IsExpired(time.Now().Unix())) {
 		return true, nil
 	}
 
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGetSessionDeviceBinding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{
+		SessionExpireTime:    60 * 60 * 24,
+		SessionRefreshTime:   60 * 60,
+		SessionDeviceBinding: true,
+	}
+	store := mockstore.NewMockStore(ctrl)
+	auth := New(&cfg, store)
+
+	staleSession := func() *model.Session {
+		return &model.Session{
+			ID:                "session-id",
+			Token:             "session-token",
+			UserID:            "user-id",
+			UpdateAt:          time.Now().Unix() - 2*60*60,
+			DeviceFingerprint: "device-fingerprint",
+		}
+	}
+
+	t.Run("matching device refresh", func(t *testing.T) {
+		session := staleSession()
+		store.EXPECT().GetSession("session-token", cfg.SessionExpireTime).Return(session, nil)
+		store.EXPECT().RefreshSession(session).Return(nil)
+
+		result, err := auth.GetSession("session-token", "device-fingerprint")
+		require.NoError(t, err)
+		require.Equal(t, session, result)
+	})
+
+	t.Run("mismatched device refresh", func(t *testing.T) {
+		var denied []string
+		auth.SessionDenied = func(sessionIDs []string) { denied = append(denied, sessionIDs...) }
+		defer func() { auth.SessionDenied = nil }()
+
+		store.EXPECT().GetSession("session-token", cfg.SessionExpireTime).Return(staleSession(), nil)
+
+		result, err := auth.GetSession("session-token", "other-device-fingerprint")
+		require.Equal(t, ErrSessionDeviceMismatch, err)
+		require.Nil(t, result)
+		require.Equal(t, []string{"session-id"}, denied)
+	})
+
+	t.Run("mismatched device before the refresh", func(t *testing.T) {
+		session := staleSession()
+		session.UpdateAt = time.Now().Unix()
+		store.EXPECT().GetSession("session-token", cfg.SessionExpireTime).Return(session, nil)
+
+		result, err := auth.GetSession("session-token", "other-device-fingerprint")
+		require.NoError(t, err)
+		require.Equal(t, session, result)
+	})
+
+	t.Run("device binding disabled", func(t *testing.T) {
+		cfg.SessionDeviceBinding = false
+		defer func() { cfg.SessionDeviceBinding = true }()
+
+		session := staleSession()
+		store.EXPECT().GetSession("session-token", cfg.SessionExpireTime).Return(session, nil)
+		store.EXPECT().RefreshSession(session).Return(nil)
+
+		result, err := auth.GetSession("session-token", "other-device-fingerprint")
+		require.NoError(t, err)
+		require.Equal(t, session, result)
+	})
+}
+
+func TestGetSessionClockSkew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfg := config.Configuration{
+		SessionExpireTime:  60 * 60 * 24,
+		SessionRefreshTime: 60 * 60,
+		ClockSkewTolerance: 5 * 60,
+	}
+	store := mockstore.NewMockStore(ctrl)
+	auth := New(&cfg, store)
+
+	wall := time.Now().Round(0)
+	var monotonic time.Duration
+	auth.clock = &clock{
+		wall:      func() time.Time { return wall },
+		monotonic: func() time.Duration { return monotonic },
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	// Refreshed just now, before the clock goes back
+	session := &model.Session{ID: "session-id", Token: "session-token", UserID: "user-id", UpdateAt: wall.Unix()}
+	store.EXPECT().GetSession("session-token", cfg.SessionExpireTime+cfg.ClockSkewTolerance).Return(session, nil).AnyTimes()
+	_, err := auth.GetSession("session-token", "")
+	require.NoError(t, err)
+
+	t.Run("skew within the tolerance", func(t *testing.T) {
+		logs.Reset()
+		monotonic += time.Minute
+		wall = wall.Add(-time.Minute)
+
+		result, err := auth.GetSession("session-token", "")
+		require.NoError(t, err)
+		require.Equal(t, session, result)
+		require.Empty(t, logs.String())
+	})
+
+	t.Run("skew beyond the tolerance", func(t *testing.T) {
+		logs.Reset()
+		monotonic += time.Minute
+		wall = wall.Add(-10 * time.Minute)
+		// The session updated in the future of the clock is refreshed
+		store.EXPECT().RefreshSession(session).Return(nil)
+
+		result, err := auth.GetSession("session-token", "")
+		require.NoError(t, err)
+		require.Equal(t, session, result)
+		require.Contains(t, logs.String(), "the clock jumped back 11m0s")
+	})
+
+	t.Run("forward jumps aren't reported", func(t *testing.T) {
+		logs.Reset()
+		session.UpdateAt = wall.Unix()
+		wall = wall.Add(30 * time.Minute)
+
+		_, err := auth.GetSession("session-token", "")
+		require.NoError(t, err)
+		require.Empty(t, logs.String())
+	})
+
+	t.Run("the warning goes to the logger of the server", func(t *testing.T) {
+		logs.Reset()
+		core, logged := observer.New(zapcore.WarnLevel)
+		auth.Logger = zap.New(core)
+		defer func() { auth.Logger = nil }()
+		monotonic += time.Minute
+		wall = wall.Add(-10 * time.Minute)
+
+		_, err := auth.GetSession("session-token", "")
+		require.NoError(t, err)
+		require.Empty(t, logs.String())
+		require.Equal(t, 1, logged.Len())
+		require.Equal(t, 11*time.Minute, logged.All()[0].ContextMap()["jump"])
+	})
+}
+
+func TestIsValidReadTokenExpiration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockstore.NewMockStore(ctrl)
+	auth := New(&config.Configuration{}, store)
+
+	container := st.Container{WorkspaceID: "0"}
+	sharing := &model.Sharing{ID: "board-id", Enabled: true, Token: "read-token"}
+
+	t.Run("active token", func(t *testing.T) {
+		sharing.ExpireAt = time.Now().Unix() + 60
+		store.EXPECT().BlockExists(container, "card-id").Return(true, nil)
+		store.EXPECT().GetRootID(container, "card-id").Return("board-id", nil)
+		store.EXPECT().GetSharing(container, "board-id").Return(sharing, nil)
+
+		isValid, err := auth.IsValidReadToken(container, "card-id", "read-token")
+		require.NoError(t, err)
+		require.True(t, isValid)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		sharing.ExpireAt = time.Now().Unix() - 60
+		store.EXPECT().BlockExists(container, "card-id").Return(true, nil)
+		store.EXPECT().GetRootID(container, "card-id").Return("board-id", nil)
+		store.EXPECT().GetSharing(container, "board-id").Return(sharing, nil)
+
+		isValid, err := auth.IsValidReadToken(container, "card-id", "read-token")
+		require.NoError(t, err)
+		require.False(t, isValid)
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		store.EXPECT().BlockExists(container, "unknown-id").Return(false, nil)
+
+		isValid, err := auth.IsValidReadToken(container, "unknown-id", "read-token")
+		require.NoError(t, err)
+		require.False(t, isValid)
+	})
+}
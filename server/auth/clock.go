@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// clock reads the wall clock and detects its jumps by comparing the wall
+// time elapsed between two reads with the monotonic time elapsed, which
+// isn't affected by the changes to the system clock
+type clock struct {
+	wall      func() time.Time
+	monotonic func() time.Duration
+
+	mu       sync.Mutex
+	read     bool
+	lastWall time.Time
+	lastMono time.Duration
+}
+
+func newClock() *clock {
+	start := time.Now()
+
+	return &clock{
+		wall:      func() time.Time { return time.Now().Round(0) },
+		monotonic: func() time.Duration { return time.Since(start) },
+	}
+}
+
+// now returns the wall time and how far the wall clock jumped since the
+// last read, negative if it went back
+func (c *clock) now() (time.Time, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := c.wall()
+	mono := c.monotonic()
+	var jump time.Duration
+	if c.read {
+		jump = wall.Sub(c.lastWall) - (mono - c.lastMono)
+	}
+	c.read = true
+	c.lastWall = wall
+	c.lastMono = mono
+
+	return wall, jump
+}
@@ -92,6 +92,10 @@ func (c *Client) doApiPutBytes(url string, data []byte) (*http.Response, error)
 	return c.doApiRequestBytes(http.MethodPut, c.ApiUrl+url, data, "")
 }
 
+func (c *Client) DoApiPatch(url, data string) (*http.Response, error) {
+	return c.DoApiRequest(http.MethodPatch, c.ApiUrl+url, data, "")
+}
+
 func (c *Client) DoApiDelete(url string) (*http.Response, error) {
 	return c.DoApiRequest(http.MethodDelete, c.ApiUrl+url, "", "")
 }
@@ -169,6 +173,16 @@ func (c *Client) InsertBlocks(blocks []model.Block) (bool, *Response) {
 	return true, BuildResponse(r)
 }
 
+func (c *Client) PatchBlocks(patches []model.BlockPatch) (bool, *Response) {
+	r, err := c.DoApiPatch(c.GetBlocksRoute(), toJSON(patches))
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return true, BuildResponse(r)
+}
+
 func (c *Client) DeleteBlock(blockID string) (bool, *Response) {
 	r, err := c.DoApiDelete(c.GetBlockRoute(blockID))
 	if err != nil {
@@ -191,6 +205,30 @@ func (c *Client) GetSubtree(blockID string) ([]model.Block, *Response) {
 
 // Sharing
 
+func (c *Client) GetBoardRoute(id string) string {
+	return fmt.Sprintf("/workspaces/0/boards/%s", id)
+}
+
+func (c *Client) ArchiveBoard(boardID string) (bool, *Response) {
+	r, err := c.DoApiPost(c.GetBoardRoute(boardID)+"/archive", "")
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return true, BuildResponse(r)
+}
+
+func (c *Client) UnarchiveBoard(boardID string) (bool, *Response) {
+	r, err := c.DoApiPost(c.GetBoardRoute(boardID)+"/unarchive", "")
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return true, BuildResponse(r)
+}
+
 func (c *Client) GetSharingRoute(rootID string) string {
 	return fmt.Sprintf("/workspaces/0/sharing/%s", rootID)
 }
@@ -1,6 +1,7 @@
 package integrationtests
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/mattermost/focalboard/server/model"
@@ -257,3 +258,156 @@ func TestGetSubtree(t *testing.T) {
 		require.Contains(t, blockIDs, childBlockID2)
 	})
 }
+
+func TestPatchBlocks(t *testing.T) {
+	th := SetupTestHelper().InitBasic()
+	defer th.TearDown()
+
+	boardID := utils.CreateGUID()
+	cardID1 := utils.CreateGUID()
+	cardID2 := utils.CreateGUID()
+	newBlocks := []model.Block{
+		{
+			ID:       boardID,
+			RootID:   boardID,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Type:     "board",
+		},
+		{
+			ID:       cardID1,
+			RootID:   boardID,
+			ParentID: boardID,
+			CreateAt: 2,
+			UpdateAt: 2,
+			Type:     "card",
+			Fields:   map[string]interface{}{"status": "todo"},
+		},
+		{
+			ID:       cardID2,
+			RootID:   boardID,
+			ParentID: boardID,
+			CreateAt: 2,
+			UpdateAt: 2,
+			Type:     "card",
+			Fields:   map[string]interface{}{"status": "todo"},
+		},
+	}
+
+	_, resp := th.Client.InsertBlocks(newBlocks)
+	require.NoError(t, resp.Error)
+
+	t.Run("Patch a couple of blocks in the same call", func(t *testing.T) {
+		patches := []model.BlockPatch{
+			{ID: cardID1, UpdatedFields: map[string]interface{}{"status": "done"}},
+			{ID: cardID2, UpdatedFields: map[string]interface{}{"status": "done"}},
+		}
+
+		_, resp := th.Client.PatchBlocks(patches)
+		require.NoError(t, resp.Error)
+
+		blocks, resp := th.Client.GetSubtree(boardID)
+		require.NoError(t, resp.Error)
+		require.Len(t, blocks, 3)
+
+		for _, b := range blocks {
+			if b.ID == cardID1 || b.ID == cardID2 {
+				require.Equal(t, "done", b.Fields["status"])
+			}
+		}
+	})
+
+	t.Run("Patch with an unknown block", func(t *testing.T) {
+		patches := []model.BlockPatch{
+			{ID: cardID1, UpdatedFields: map[string]interface{}{"status": "archived"}},
+			{ID: "not-exists", UpdatedFields: map[string]interface{}{"status": "archived"}},
+		}
+
+		_, resp := th.Client.PatchBlocks(patches)
+		require.Error(t, resp.Error)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Contains(t, resp.Error.Error(), "not-exists")
+
+		blocks, resp := th.Client.GetSubtree(boardID)
+		require.NoError(t, resp.Error)
+		for _, b := range blocks {
+			if b.ID == cardID1 {
+				require.Equal(t, "done", b.Fields["status"])
+			}
+		}
+	})
+}
+
+func TestArchiveBoard(t *testing.T) {
+	th := SetupTestHelper().InitBasic()
+	defer th.TearDown()
+
+	boardID := utils.CreateGUID()
+	cardID := utils.CreateGUID()
+	newBlocks := []model.Block{
+		{
+			ID:       boardID,
+			RootID:   boardID,
+			CreateAt: 1,
+			UpdateAt: 1,
+			Type:     "board",
+			Title:    "Board to archive",
+		},
+		{
+			ID:       cardID,
+			RootID:   boardID,
+			ParentID: boardID,
+			CreateAt: 2,
+			UpdateAt: 2,
+			Type:     "card",
+			Title:    "Card to archive",
+			Fields:   map[string]interface{}{"status": "done"},
+		},
+	}
+
+	_, resp := th.Client.InsertBlocks(newBlocks)
+	require.NoError(t, resp.Error)
+
+	t.Run("Archive the board", func(t *testing.T) {
+		_, resp := th.Client.ArchiveBoard(boardID)
+		require.NoError(t, resp.Error)
+
+		blocks, resp := th.Client.GetBlocks()
+		require.NoError(t, resp.Error)
+		for _, b := range blocks {
+			require.NotEqual(t, boardID, b.ID)
+		}
+
+		blocks, resp = th.Client.GetSubtree(boardID)
+		require.NoError(t, resp.Error)
+		require.Len(t, blocks, 1)
+	})
+
+	t.Run("Archive the board again", func(t *testing.T) {
+		_, resp := th.Client.ArchiveBoard(boardID)
+		require.Error(t, resp.Error)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Unarchive the board", func(t *testing.T) {
+		_, resp := th.Client.UnarchiveBoard(boardID)
+		require.NoError(t, resp.Error)
+
+		blocks, resp := th.Client.GetSubtree(boardID)
+		require.NoError(t, resp.Error)
+		require.Len(t, blocks, 2)
+
+		for _, b := range blocks {
+			if b.ID == cardID {
+				require.Equal(t, "Card to archive", b.Title)
+				require.Equal(t, "done", b.Fields["status"])
+			}
+		}
+	})
+
+	t.Run("Unarchive a board that isn't archived", func(t *testing.T) {
+		_, resp := th.Client.UnarchiveBoard(boardID)
+		require.Error(t, resp.Error)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/server"
 	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/sqlstore"
 )
 
 // Active server used with shared code (dll)
@@ -100,6 +101,7 @@ func main() {
 	pSingleUser := flag.Bool("single-user", false, "single user mode") //是否是单人模式
 	pDBType := flag.String("dbtype", "", "Database type")              //数据库类型
 	pDBConfig := flag.String("dbconfig", "", "Database config")        //数据库配置
+	pMigrateDryRun := flag.Bool("migrate-dry-run", false, "log the pending database migrations and exit")
 	flag.Parse()
 
 	singleUser := false
@@ -140,6 +142,18 @@ func main() {
 		config.Port = *pPort
 	}
 
+	if pMigrateDryRun != nil && *pMigrateDryRun {
+		config.MigrateDryRun = true
+	}
+
+	// Only report the migrations, without touching the database or serving
+	if config.MigrateDryRun {
+		if _, err := sqlstore.DryRunMigrations(config.DBType, config.DBConfigString, config.DBTablePrefix); err != nil {
+			log.Fatal("Migrate dry run ERROR: ", err)
+		}
+		return
+	}
+
 	server, err := server.New(config, singleUserToken)
 	if err != nil {
 		log.Fatal("server.New ERROR: ", err)
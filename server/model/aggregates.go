@@ -0,0 +1,40 @@
+package model
+
+// BoardAggregates are the card counts of a board
+// swagger:model
+type BoardAggregates struct {
+	// Number of cards in the board
+	// required: true
+	Total int64 `json:"total"`
+
+	// Number of cards per value of the property the cards are grouped by.
+	// Cards without a value are counted under the empty string
+	// required: false
+	Groups map[string]int64 `json:"groups,omitempty"`
+}
+
+// Sizes of the buckets the cards can be grouped by date into
+const (
+	DateBucketDay   = "day"
+	DateBucketWeek  = "week"
+	DateBucketMonth = "month"
+)
+
+// IsValidDateBucket checks if the bucket is one of the supported sizes
+func IsValidDateBucket(bucket string) bool {
+	return bucket == DateBucketDay || bucket == DateBucketWeek || bucket == DateBucketMonth
+}
+
+// DateBucket is the number of cards whose date falls in a day, week or
+// month
+// swagger:model
+type DateBucket struct {
+	// First day of the bucket, as YYYY-MM-DD in UTC. The weeks start on
+	// Monday
+	// required: true
+	Start string `json:"start"`
+
+	// Number of cards in the bucket
+	// required: true
+	Count int64 `json:"count"`
+}
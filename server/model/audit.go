@@ -0,0 +1,29 @@
+package model
+
+// AuditLog is an action recorded for compliance
+// swagger:model
+type AuditLog struct {
+	// ID of the entry
+	// required: true
+	ID string `json:"id"`
+
+	// Time of the action
+	// required: true
+	CreateAt int64 `json:"createAt"`
+
+	// ID of the user who performed the action
+	// required: true
+	Actor string `json:"actor"`
+
+	// Name of the action
+	// required: true
+	Action string `json:"action"`
+
+	// ID of the object of the action, empty if there's none
+	// required: false
+	Target string `json:"target"`
+
+	// Details of the action
+	// required: false
+	Details string `json:"details"`
+}
@@ -53,6 +53,82 @@ type Block struct {
 	DeleteAt int64 `json:"deleteAt"`
 }
 
+// BlockPatch is a patch for modifying a block
+// swagger:model
+type BlockPatch struct {
+	// The id of the block to patch
+	// required: true
+	ID string `json:"id"`
+
+	// The updated parent id of the block
+	// required: false
+	ParentID *string `json:"parentId"`
+
+	// The updated root id of the block
+	// required: false
+	RootID *string `json:"rootId"`
+
+	// The updated schema version of the block
+	// required: false
+	Schema *int64 `json:"schema"`
+
+	// The updated block type
+	// required: false
+	Type *string `json:"type"`
+
+	// The updated display title
+	// required: false
+	Title *string `json:"title"`
+
+	// The block fields to set or replace
+	// required: false
+	UpdatedFields map[string]interface{} `json:"updatedFields"`
+
+	// The block fields to remove
+	// required: false
+	DeletedFields []string `json:"deletedFields"`
+}
+
+// Patch applies the patch to the given block and returns it
+func (p *BlockPatch) Patch(block *Block) *Block {
+	if p.ParentID != nil {
+		block.ParentID = *p.ParentID
+	}
+
+	if p.RootID != nil {
+		block.RootID = *p.RootID
+	}
+
+	if p.Schema != nil {
+		block.Schema = *p.Schema
+	}
+
+	if p.Type != nil {
+		block.Type = *p.Type
+	}
+
+	if p.Title != nil {
+		block.Title = *p.Title
+	}
+
+	if len(p.UpdatedFields) > 0 && block.Fields == nil {
+		block.Fields = map[string]interface{}{}
+	}
+
+	for key, field := range p.UpdatedFields {
+		block.Fields[key] = field
+	}
+
+	for _, key := range p.DeletedFields {
+		delete(block.Fields, key)
+	}
+
+	return block
+}
+
+// ArchiveVersion is the version of the import / export archive format
+const ArchiveVersion = 1
+
 // Archive is an import / export archive
 type Archive struct {
 	Version int64   `json:"version"`
@@ -65,3 +141,107 @@ func BlocksFromJSON(data io.Reader) []Block {
 	json.NewDecoder(data).Decode(&blocks)
 	return blocks
 }
+
+// BoardSnapshot is a read-only copy of the blocks of a board at a point in
+// time, which isn't affected by the later changes to the board
+// swagger:model
+type BoardSnapshot struct {
+	// ID of the snapshot
+	// required: true
+	ID string `json:"id"`
+
+	// ID of the board
+	// required: true
+	BoardID string `json:"boardId"`
+
+	// ID of the user who created the snapshot
+	// required: true
+	CreatedBy string `json:"createdBy"`
+
+	// Created time
+	// required: true
+	CreateAt int64 `json:"createAt"`
+
+	// Blocks of the board, in the export archive format. Left out of the
+	// lists of snapshots
+	// required: false
+	Archive *Archive `json:"archive,omitempty"`
+}
+
+// BlockMigrationFlag records that a data migration touched a block
+// swagger:model
+type BlockMigrationFlag struct {
+	// ID of the block
+	// required: true
+	BlockID string `json:"blockId"`
+
+	// Name of the migration
+	// required: true
+	Flag string `json:"flag"`
+
+	// Time the block was first tagged with the flag
+	// required: true
+	CreateAt int64 `json:"createAt"`
+}
+
+// Actions of the entries of a board activity timeline
+const (
+	// BlockActivityCreated is the first version of a block
+	BlockActivityCreated = "created"
+
+	// BlockActivityUpdated is any later version of a block
+	BlockActivityUpdated = "updated"
+
+	// BlockActivityDeleted is the deletion of a block
+	BlockActivityDeleted = "deleted"
+)
+
+// BlockActivity is an entry of the activity timeline of a board: a block of
+// the board created, updated or deleted, by whom and when, as recorded by the
+// history of the blocks
+// swagger:model
+type BlockActivity struct {
+	// ID of the changed block
+	// required: true
+	BlockID string `json:"blockId"`
+
+	// Type of the changed block
+	// required: true
+	BlockType string `json:"blockType"`
+
+	// Title of the changed block
+	// required: true
+	Title string `json:"title"`
+
+	// What was done to the block: created, updated or deleted
+	// required: true
+	Action string `json:"action"`
+
+	// ID of the user who made the change
+	// required: true
+	ModifiedBy string `json:"modifiedBy"`
+
+	// Time of the change
+	// required: true
+	UpdateAt int64 `json:"updateAt"`
+}
+
+// DeletedBoard is a board in the trash of its workspace
+// swagger:model
+type DeletedBoard struct {
+	// ID of the workspace of the board
+	// required: true
+	WorkspaceID string `json:"workspaceId"`
+
+	// ID of the board
+	// required: true
+	BoardID string `json:"boardId"`
+
+	// ID of the user who deleted the board
+	// required: true
+	ModifiedBy string `json:"modifiedBy"`
+
+	// Deleted time
+	// required: true
+	DeleteAt int64 `json:"deleteAt"`
+}
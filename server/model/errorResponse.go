@@ -10,4 +10,8 @@ type ErrorResponse struct {
 	// The error code
 	// required: false
 	ErrorCode int `json:"errorCode"`
+
+	// The problem with each invalid field, keyed by field
+	// required: false
+	Fields map[string]string `json:"fields,omitempty"`
 }
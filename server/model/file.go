@@ -0,0 +1,74 @@
+package model
+
+// FileBlob is the content of one or more uploaded files, stored once under
+// its SHA-256 hash
+type FileBlob struct {
+	// SHA-256 hash of the content of a blob, hex encoded, or empty for a
+	// file uploaded before the blobs
+	Hash string `json:"hash"`
+
+	// Size of the content in bytes
+	Size int64 `json:"size"`
+
+	// Number of files with this content
+	RefCount int64 `json:"refCount"`
+
+	// Created time
+	CreateAt int64 `json:"createAt"`
+}
+
+// FileInfo is a file found in the files storage
+type FileInfo struct {
+	// Path of the blob in the files storage
+	Path string `json:"path"`
+
+	// SHA-256 hash of the content of a blob, hex encoded, or empty for a
+	// file uploaded before the blobs
+	Hash string `json:"hash"`
+
+	// Size of the content in bytes
+	Size int64 `json:"size"`
+
+	// Last modification time in the files storage
+	UpdateAt int64 `json:"updateAt"`
+}
+
+// FileRef maps the ID of an uploaded file to the blob with its content
+type FileRef struct {
+	// ID of the file, as returned by the upload
+	ID string `json:"id"`
+
+	// ID of the workspace
+	WorkspaceID string `json:"workspaceId"`
+
+	// ID of the root block the file is attached to
+	RootID string `json:"rootId"`
+
+	// Hash of the blob with the content
+	Hash string `json:"hash"`
+
+	// Created time
+	CreateAt int64 `json:"createAt"`
+}
+
+// UploadSession is an upload in progress, whose partial content is at a
+// temporary path of the files storage until it completes
+type UploadSession struct {
+	// ID of the upload, the ID of the file once it completes
+	ID string `json:"id"`
+
+	// ID of the workspace
+	WorkspaceID string `json:"workspaceId"`
+
+	// ID of the root block the file is attached to
+	RootID string `json:"rootId"`
+
+	// Path of the partial content in the files storage
+	Path string `json:"path"`
+
+	// Created time
+	CreateAt int64 `json:"createAt"`
+
+	// Time of the last write
+	UpdateAt int64 `json:"updateAt"`
+}
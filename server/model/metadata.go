@@ -0,0 +1,47 @@
+package model
+
+// BoardMetadata is the summary of a board shown in the sidebar, without the
+// fields of the board
+// swagger:model
+type BoardMetadata struct {
+	// ID of the board
+	// required: true
+	ID string `json:"id"`
+
+	// Title of the board
+	// required: true
+	Title string `json:"title"`
+
+	// Icon of the board, empty if it has none
+	// required: false
+	Icon string `json:"icon"`
+
+	// Number of cards in the board
+	// required: true
+	CardCount int64 `json:"cardCount"`
+
+	// Updated time
+	// required: true
+	UpdateAt int64 `json:"updateAt"`
+}
+
+// DuplicateBoards is a set of boards of a workspace with the same content,
+// which are likely copies of each other
+// swagger:model
+type DuplicateBoards struct {
+	// Hash of the content of the boards
+	// required: true
+	Hash string `json:"hash"`
+
+	// Title of the boards
+	// required: true
+	Title string `json:"title"`
+
+	// Number of blocks of each board, the board included
+	// required: true
+	BlockCount int `json:"blockCount"`
+
+	// IDs of the boards, sorted
+	// required: true
+	BoardIDs []string `json:"boardIds"`
+}
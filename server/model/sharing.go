@@ -27,6 +27,15 @@ type Sharing struct {
 	// Updated time
 	// required: true
 	UpdateAt int64 `json:"update_at,omitempty"`
+
+	// Expiration time in seconds, zero if the token never expires
+	// required: false
+	ExpireAt int64 `json:"expireAt,omitempty"`
+}
+
+// IsExpired checks if the token has expired at the time now, in seconds
+func (s Sharing) IsExpired(now int64) bool {
+	return s.ExpireAt > 0 && s.ExpireAt <= now
 }
 
 func SharingFromJSON(data io.Reader) Sharing {
@@ -44,6 +44,23 @@ type User struct {
 	DeleteAt int64 `json:"delete_at"`
 }
 
+// UserDisplay is what's needed to show a user, like the users of the person
+// properties of the cards
+// swagger:model
+type UserDisplay struct {
+	// The user ID
+	// required: true
+	ID string `json:"id"`
+
+	// The user name
+	// required: true
+	Username string `json:"username"`
+
+	// URL of the avatar of the user, from the avatarUrl user setting
+	// required: false
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
 type Session struct {
 	ID          string                 `json:"id"`
 	Token       string                 `json:"token"`
@@ -52,4 +69,7 @@ type Session struct {
 	Props       map[string]interface{} `json:"props"`
 	CreateAt    int64                  `json:"create_at,omitempty"`
 	UpdateAt    int64                  `json:"update_at,omitempty"`
+
+	// Hash of the user agent and device ID the session was created from
+	DeviceFingerprint string `json:"-"`
 }
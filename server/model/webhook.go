@@ -0,0 +1,26 @@
+package model
+
+// WorkspaceWebhook is an endpoint notified of the changes to the blocks of a
+// workspace, besides the webhooks of the server configuration
+// swagger:model
+type WorkspaceWebhook struct {
+	// ID of the webhook
+	// required: true
+	ID string `json:"id"`
+
+	// ID of the workspace
+	// required: true
+	WorkspaceID string `json:"workspaceId"`
+
+	// URL the changes are posted to
+	// required: true
+	URL string `json:"url"`
+
+	// ID of the user who registered the webhook
+	// required: true
+	CreatedBy string `json:"createdBy"`
+
+	// Created time
+	// required: true
+	CreateAt int64 `json:"createAt"`
+}
@@ -27,3 +27,16 @@ type Workspace struct {
 	// required: true
 	UpdateAt int64 `json:"updateAt"`
 }
+
+// WorkspaceActivity is the time of the latest change to the blocks of a
+// workspace
+// swagger:model
+type WorkspaceActivity struct {
+	// ID of the workspace
+	// required: true
+	WorkspaceID string `json:"workspaceId"`
+
+	// Update time of the most recently changed block
+	// required: true
+	LastActivityAt int64 `json:"lastActivityAt"`
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// slowRequestExcludedRoutes are long lived or streaming by design, so their
+// duration says nothing about the server being slow
+var slowRequestExcludedRoutes = map[string]bool{
+	"/ws/onchange": true,
+	"/api/v1/workspaces/{workspaceID}/blocks/export":      true,
+	"/files/workspaces/{workspaceID}/{rootID}/{filename}": true,
+}
+
+// SlowRequestFunc is called when a request takes longer than the slow
+// request threshold.
+type SlowRequestFunc func(method, route string, duration time.Duration)
+
+// OnSlowRequest registers a function to be called, in its own goroutine,
+// for every request exceeding the configured slow request threshold.
+func (s *Server) OnSlowRequest(fn SlowRequestFunc) {
+	s.slowRequestMu.Lock()
+	defer s.slowRequestMu.Unlock()
+
+	s.slowRequestFuncs = append(s.slowRequestFuncs, fn)
+}
+
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		// The ID is also set in the response headers before calling the
+		// handler, so the API error logs can include it
+		requestID := utils.CreateGUID()
+		w.Header().Set(utils.RequestIDHeader, requestID)
+		r = r.WithContext(utils.WithRequestID(r.Context(), requestID))
+
+		next.ServeHTTP(recorder, r)
+
+		duration := time.Since(start)
+		route := routeTemplate(r)
+		s.logger.Debug("Request",
+			zap.String("request_id", requestID),
+			zap.String("method", r.Method),
+			zap.String("route", route),
+			zap.Int("status", recorder.status),
+			zap.Duration("duration", duration),
+		)
+
+		threshold := time.Duration(s.config.SlowRequestThreshold) * time.Millisecond
+		if threshold <= 0 || duration <= threshold || slowRequestExcludedRoutes[route] {
+			return
+		}
+
+		s.logger.Warn("Slow request",
+			zap.String("request_id", requestID),
+			zap.String("method", r.Method),
+			zap.String("route", route),
+			zap.Int("status", recorder.status),
+			zap.Duration("duration", duration),
+			zap.Duration("threshold", threshold),
+		)
+		s.metrics.IncrementSlowRequests(route)
+
+		s.slowRequestMu.RLock()
+		defer s.slowRequestMu.RUnlock()
+		for _, fn := range s.slowRequestFuncs {
+			go fn(r.Method, route, duration)
+		}
+	})
+}
+
+// routeTemplate returns the path template of the matched route, so requests
+// to the same endpoint are grouped regardless of their IDs
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return "unmatched"
+}
+
+// statusRecorder keeps the response status code for logging. It still
+// supports hijacking, as the websocket upgrade needs it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
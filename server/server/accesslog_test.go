@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/metrics"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowRequestLogging(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	s := &Server{
+		config:  &config.Configuration{SlowRequestThreshold: 20},
+		logger:  zap.New(core),
+		metrics: metrics.NewMetrics(),
+	}
+
+	slowRequests := make(chan string, 1)
+	s.OnSlowRequest(func(method, route string, duration time.Duration) {
+		slowRequests <- method + " " + route
+	})
+
+	slowHandler := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	r := mux.NewRouter()
+	r.Use(s.accessLogMiddleware)
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/blocks", slowHandler)
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/blocks/export", slowHandler)
+	r.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	t.Run("slow request", func(t *testing.T) {
+		serve("/api/v1/workspaces/0/blocks")
+
+		entries := logs.FilterMessage("Slow request").All()
+		logs.TakeAll()
+		require.Len(t, entries, 1)
+		require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		require.Equal(t, "/api/v1/workspaces/{workspaceID}/blocks", entries[0].ContextMap()["route"])
+
+		expected := `
+# HELP focalboard_api_slow_requests_total Total number of requests that took longer than the slow request threshold.
+# TYPE focalboard_api_slow_requests_total counter
+focalboard_api_slow_requests_total{route="/api/v1/workspaces/{workspaceID}/blocks"} 1
+`
+		err := testutil.GatherAndCompare(s.metrics.Registry(), strings.NewReader(expected), "focalboard_api_slow_requests_total")
+		require.NoError(t, err)
+
+		select {
+		case slowRequest := <-slowRequests:
+			require.Equal(t, "GET /api/v1/workspaces/{workspaceID}/blocks", slowRequest)
+		case <-time.After(time.Second):
+			require.Fail(t, "slow request callback not called")
+		}
+	})
+
+	t.Run("fast request", func(t *testing.T) {
+		serve("/api/v1/users/me")
+		require.Zero(t, logs.FilterMessage("Slow request").Len())
+	})
+
+	t.Run("excluded route", func(t *testing.T) {
+		serve("/api/v1/workspaces/0/blocks/export")
+		require.Zero(t, logs.FilterMessage("Slow request").Len())
+	})
+
+	t.Run("disabled threshold", func(t *testing.T) {
+		s.config.SlowRequestThreshold = 0
+		serve("/api/v1/workspaces/0/blocks")
+		require.Zero(t, logs.FilterMessage("Slow request").Len())
+	})
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	deliveries := make(chan string, 1)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries <- r.Header.Get(utils.RequestIDHeader)
+	}))
+	defer sink.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg := &config.Configuration{WebhookUpdate: []string{sink.URL}, WebhookRequestID: true, WebhookAllowPrivateTargets: true}
+	s := &Server{
+		config:  cfg,
+		logger:  zap.New(core),
+		metrics: metrics.NewMetrics(),
+	}
+	webhookClient := webhook.NewClient(cfg, nil)
+
+	r := mux.NewRouter()
+	r.Use(s.accessLogMiddleware)
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/blocks", func(w http.ResponseWriter, r *http.Request) {
+		webhookClient.NotifyUpdate("0", model.Block{ID: "card-id"}, utils.RequestIDFromContext(r.Context()))
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/0/blocks", nil))
+
+	requestID := recorder.Header().Get(utils.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+	require.Equal(t, requestID, <-deliveries)
+
+	entries := logs.FilterMessage("Request").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, requestID, entries[0].ContextMap()["request_id"])
+}
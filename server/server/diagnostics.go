@@ -0,0 +1,36 @@
+package server
+
+import (
+	"runtime"
+)
+
+// Diagnostics are the counts of the goroutines of the server, to spot the
+// background work piling up
+type Diagnostics struct {
+	Goroutines       int
+	WebhookWorkers   int
+	WebhookQueued    int
+	WebhookDropped   int64
+	TelemetryPending int
+}
+
+// Diagnostics returns the number of goroutines and of the workers delivering
+// the webhooks and the telemetry events
+func (s *Server) Diagnostics() Diagnostics {
+	diagnostics := Diagnostics{
+		Goroutines: runtime.NumGoroutine(),
+	}
+
+	if s.webhook != nil {
+		stats := s.webhook.Stats()
+		diagnostics.WebhookWorkers = stats.ActiveWorkers
+		diagnostics.WebhookQueued = stats.QueuedEvents
+		diagnostics.WebhookDropped = stats.DroppedEvents
+	}
+
+	if s.telemetry != nil {
+		diagnostics.TelemetryPending = s.telemetry.PendingEvents()
+	}
+
+	return diagnostics
+}
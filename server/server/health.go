@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+)
+
+// Ready returns a channel that is closed once the server can serve
+// requests: the web server is accepting connections and the store is
+// reachable.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// markReady reports the server ready, once even if it's started again
+func (s *Server) markReady() {
+	s.readyOnce.Do(func() {
+		close(s.ready)
+		s.logger.Info("Server ready")
+	})
+}
+
+func (s *Server) isReady() bool {
+	select {
+	case <-s.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleHealthz lets orchestrators know when to route traffic to the
+// server, answering 503 until it's ready.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !s.isReady() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("starting"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/telemetry"
+	"github.com/mattermost/focalboard/server/web"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	s := &Server{
+		config:    &config.Configuration{},
+		wsServer:  ws.NewServer(nil, ""),
+		webServer: web.NewServer("", "", 0, false, true, 0),
+		store:     store,
+		telemetry: telemetry.New("telemetry-id", log.New(ioutil.Discard, "", 0)),
+		logger:    zap.NewNop(),
+		ready:     make(chan struct{}),
+	}
+
+	healthz := func() int {
+		recorder := httptest.NewRecorder()
+		s.handleHealthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, healthz())
+
+	store.EXPECT().GetSystemSettings().Return(map[string]string{}, nil)
+	require.NoError(t, s.Start())
+
+	select {
+	case <-s.Ready():
+	default:
+		require.Fail(t, "the server isn't ready after starting")
+	}
+	require.Equal(t, http.StatusOK, healthz())
+
+	// Reporting it ready again, as a second start does, doesn't panic
+	require.NotPanics(t, s.markReady)
+	require.Equal(t, http.StatusOK, healthz())
+
+	store.EXPECT().Shutdown().Return(nil)
+	require.NoError(t, s.Shutdown())
+}
@@ -12,10 +12,11 @@ import (
 	"log"
 
 	"github.com/mattermost/focalboard/server/einterfaces"
+	"github.com/mattermost/focalboard/server/services/auth"
 )
 
 //启动服务
-func (s *Server) initHandlers() {
+func (s *Server) initHandlers() error {
 	cfg := s.config
 	if cfg.AuthMode == "mattermost" && mattermostAuth != nil { //如果是mattermost 认证模式
 		log.Println("Using Mattermost Auth")
@@ -33,4 +34,16 @@ func (s *Server) initHandlers() {
 		s.api.WorkspaceAuthenticator = mmauthHandler
 		log.Println("SETTING THE AUTHENTICATOR")
 	}
+
+	if cfg.AuthMode == "header" {
+		log.Println("Using Header Auth")
+		headerAuth, err := auth.NewHeaderAuth(cfg.AuthHeader, cfg.TrustedProxies)
+		if err != nil {
+			return err
+		}
+		s.api.HeaderAuthenticator = headerAuth
+		s.wsServer.HeaderAuthenticator = s.api
+	}
+
+	return nil
 }
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// loadSheddingRetryAfter is the number of seconds clients are asked to wait
+// before retrying a shed request
+const loadSheddingRetryAfter = 1
+
+// loadSheddingExemptRoutes never count against the concurrency limit, either
+// because they must answer under load or because they're long lived
+var loadSheddingExemptRoutes = map[string]bool{
+	"/ws/onchange": true,
+	"/healthz":     true,
+}
+
+// newRequestSlots returns the semaphore used to limit the concurrent
+// requests, or nil if there is no limit
+func newRequestSlots(maxConcurrentRequests int) chan struct{} {
+	if maxConcurrentRequests <= 0 {
+		return nil
+	}
+
+	return make(chan struct{}, maxConcurrentRequests)
+}
+
+// loadSheddingMiddleware limits the requests served at the same time to
+// MaxConcurrentRequests, rejecting the excess with a 503 instead of queuing
+// them, and tracks the number of in-flight requests.
+func (s *Server) loadSheddingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loadSheddingExemptRoutes[routeTemplate(r)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.requestSlots != nil {
+			select {
+			case s.requestSlots <- struct{}{}:
+				defer func() { <-s.requestSlots }()
+			default:
+				s.metrics.IncrementShedRequests()
+				w.Header().Set("Retry-After", strconv.Itoa(loadSheddingRetryAfter))
+				http.Error(w, "server is overloaded, retry later", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		s.metrics.IncrementInFlightRequests()
+		defer s.metrics.DecrementInFlightRequests()
+
+		next.ServeHTTP(w, r)
+	})
+}
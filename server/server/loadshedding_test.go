@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/metrics"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadShedding(t *testing.T) {
+	s := &Server{
+		config:       &config.Configuration{MaxConcurrentRequests: 2},
+		logger:       zap.NewNop(),
+		metrics:      metrics.NewMetrics(),
+		requestSlots: newRequestSlots(2),
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blockingHandler := func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}
+
+	r := mux.NewRouter()
+	r.Use(s.loadSheddingMiddleware)
+	r.HandleFunc("/api/v1/workspaces/{workspaceID}/blocks", blockingHandler)
+	r.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {})
+	r.HandleFunc("/ws/onchange", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 2)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = serve("/api/v1/workspaces/0/blocks")
+		}(i)
+	}
+	<-started
+	<-started
+
+	require.Equal(t, float64(2), metricValue(t, s, "focalboard_api_in_flight_requests"))
+
+	t.Run("requests over the limit are shed", func(t *testing.T) {
+		response := serve("/api/v1/users/me")
+		require.Equal(t, http.StatusServiceUnavailable, response.Code)
+		require.Equal(t, "1", response.Header().Get("Retry-After"))
+		require.Equal(t, float64(1), metricValue(t, s, "focalboard_api_shed_requests_total"))
+	})
+
+	t.Run("exempt routes are served", func(t *testing.T) {
+		response := serve("/ws/onchange")
+		require.Equal(t, http.StatusOK, response.Code)
+	})
+
+	close(release)
+	wg.Wait()
+
+	for _, response := range responses {
+		require.Equal(t, http.StatusOK, response.Code)
+	}
+	require.Zero(t, metricValue(t, s, "focalboard_api_in_flight_requests"))
+
+	t.Run("slots are freed once requests complete", func(t *testing.T) {
+		response := serve("/api/v1/users/me")
+		require.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func metricValue(t *testing.T, s *Server, name string) float64 {
+	families, err := s.metrics.Registry().Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		metric := family.GetMetric()[0]
+		if metric.GetGauge() != nil {
+			return metric.GetGauge().GetValue()
+		}
+		return metric.GetCounter().GetValue()
+	}
+
+	require.Failf(t, "metric not found", name)
+	return 0
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// prewarmSessionsWindow is how recently the sessions read by the warm-up
+	// were used, in seconds
+	prewarmSessionsWindow = 60 * 60
+
+	// prewarmSessionsLimit is the number of the most recently used sessions
+	// read by the warm-up
+	prewarmSessionsLimit = 1000
+)
+
+// prewarm loads what the first requests after a restart look up, the system
+// settings with the feature flags and the recently used sessions, into the
+// cache of the store before the server reports ready. There's nothing to
+// warm up unless the store is cached. The warm-up gives up waiting after the
+// timeout, and its failures don't stop the server from starting.
+func (s *Server) prewarm(timeout time.Duration) {
+	if s.cache == nil {
+		s.logger.Warn("Not pre-warming, the store isn't cached")
+		return
+	}
+
+	steps := []struct {
+		name string
+		load func() (int, error)
+	}{
+		{"system settings", func() (int, error) {
+			settings, err := s.cache.GetSystemSettings()
+			return len(settings), err
+		}},
+		{"feature flags", func() (int, error) {
+			flags, err := s.cache.GetFeatureFlags()
+			return len(flags), err
+		}},
+		{"sessions", func() (int, error) {
+			sessions, err := s.cache.GetRecentSessions(prewarmSessionsWindow, prewarmSessionsLimit)
+			return len(sessions), err
+		}},
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, step := range steps {
+			loaded, err := step.load()
+			if err != nil {
+				s.logger.Warn("Unable to pre-warm", zap.String("step", step.name), zap.Error(err))
+				continue
+			}
+			s.logger.Debug("Pre-warmed", zap.String("step", step.name), zap.Int("loaded", loaded))
+		}
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("Pre-warm done", zap.Duration("duration", time.Since(start)))
+	case <-time.After(timeout):
+		s.logger.Warn("Pre-warm timed out, starting without it", zap.Duration("timeout", timeout))
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/telemetry"
+	"github.com/mattermost/focalboard/server/web"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPrewarmedStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	store := mockstore.NewMockStore(ctrl)
+	cache := st.NewCache(store, time.Hour)
+	s := &Server{
+		config:    &config.Configuration{StoreCacheTTL: 3600, PrewarmCaches: true, PrewarmTimeout: 10},
+		wsServer:  ws.NewServer(nil, ""),
+		webServer: web.NewServer("", "", 0, false, true, 0),
+		store:     cache,
+		cache:     cache,
+		telemetry: telemetry.New("telemetry-id", log.New(ioutil.Discard, "", 0)),
+		logger:    zap.New(core),
+		ready:     make(chan struct{}),
+	}
+
+	// Once to check the store is reachable, and once to pre-warm the cache
+	store.EXPECT().GetSystemSettings().Return(map[string]string{
+		"TelemetryID":         "telemetry-id",
+		"FeatureFlag.Archive": "true",
+	}, nil).Times(2)
+	store.EXPECT().GetRecentSessions(int64(prewarmSessionsWindow), prewarmSessionsLimit).Return([]*model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", UpdateAt: time.Now().Unix()},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", UpdateAt: time.Now().Unix()},
+	}, nil)
+
+	require.NoError(t, s.Start())
+	defer func() {
+		store.EXPECT().Shutdown().Return(nil)
+		require.NoError(t, s.Shutdown())
+	}()
+
+	select {
+	case <-s.Ready():
+	default:
+		require.Fail(t, "the server isn't ready after starting")
+	}
+
+	loaded := map[string]int64{}
+	for _, entry := range logs.FilterMessage("Pre-warmed").All() {
+		fields := entry.ContextMap()
+		loaded[fields["step"].(string)] = fields["loaded"].(int64)
+	}
+	require.Equal(t, map[string]int64{"system settings": 2, "feature flags": 1, "sessions": 2}, loaded)
+	require.Equal(t, 1, logs.FilterMessage("Pre-warm done").Len())
+
+	settingsCount, sessionsCount := cache.CachedCounts()
+	require.Equal(t, 2, settingsCount)
+	require.Equal(t, 2, sessionsCount)
+
+	// Served by the cache, the store doesn't expect any more reads
+	flags, err := s.store.GetFeatureFlags()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Archive": "true"}, flags)
+	session, err := s.store.GetSession("token-2", 60)
+	require.NoError(t, err)
+	require.Equal(t, "user-2", session.UserID)
+}
+
+func TestPrewarm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newServer := func() (*Server, *mockstore.MockStore, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		store := mockstore.NewMockStore(ctrl)
+		cache := st.NewCache(store, time.Hour)
+		return &Server{
+			config: &config.Configuration{},
+			store:  cache,
+			cache:  cache,
+			logger: zap.New(core),
+		}, store, logs
+	}
+
+	t.Run("the store isn't cached", func(t *testing.T) {
+		s, _, logs := newServer()
+		s.store = s.cache.Store
+		s.cache = nil
+
+		s.prewarm(time.Second)
+		require.Equal(t, 1, logs.FilterMessage("Not pre-warming, the store isn't cached").Len())
+	})
+
+	t.Run("a failed step doesn't stop the others", func(t *testing.T) {
+		s, store, logs := newServer()
+		// The feature flags are read with the settings, so they fail too
+		store.EXPECT().GetSystemSettings().Return(nil, errors.New("database is locked")).Times(2)
+		store.EXPECT().GetRecentSessions(gomock.Any(), gomock.Any()).Return([]*model.Session{}, nil)
+
+		s.prewarm(time.Second)
+
+		failures := logs.FilterMessage("Unable to pre-warm").All()
+		require.Len(t, failures, 2)
+		require.Equal(t, "system settings", failures[0].ContextMap()["step"])
+		require.Equal(t, "feature flags", failures[1].ContextMap()["step"])
+		require.Equal(t, 1, logs.FilterMessage("Pre-warm done").Len())
+	})
+
+	t.Run("the start doesn't wait past the timeout", func(t *testing.T) {
+		s, store, logs := newServer()
+		release := make(chan struct{})
+		loaded := make(chan struct{})
+		store.EXPECT().GetSystemSettings().Return(map[string]string{}, nil)
+		store.EXPECT().GetRecentSessions(gomock.Any(), gomock.Any()).DoAndReturn(func(updatedSecondsAgo int64, limit int) ([]*model.Session, error) {
+			defer close(loaded)
+			<-release
+			return []*model.Session{}, nil
+		})
+
+		start := time.Now()
+		s.prewarm(20 * time.Millisecond)
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+		require.Equal(t, 1, logs.FilterMessage("Pre-warm timed out, starting without it").Len())
+
+		close(release)
+		<-loaded
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,9 +21,9 @@ import (
 	"github.com/mattermost/focalboard/server/context"
 	appModel "github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/metrics"
 	"github.com/mattermost/focalboard/server/services/scheduler"
 	"github.com/mattermost/focalboard/server/services/store"
-	"github.com/mattermost/focalboard/server/services/store/sqlstore"
 	"github.com/mattermost/focalboard/server/services/telemetry"
 	"github.com/mattermost/focalboard/server/services/webhook"
 	"github.com/mattermost/focalboard/server/web"
@@ -36,10 +37,26 @@ type Server struct {
 	wsServer            *ws.Server
 	webServer           *web.Server
 	store               store.Store
+	cache               *store.Cache
 	filesBackend        filesstore.FileBackend
+	uploads             *app.Uploads
 	telemetry           *telemetry.Service
+	webhook             *webhook.Client
 	logger              *zap.Logger
 	cleanUpSessionsTask *scheduler.ScheduledTask
+	purgeSharingTask    *scheduler.ScheduledTask
+	cleanUpUploadsTask  *scheduler.ScheduledTask
+	purgeTrashTask      *scheduler.ScheduledTask
+	backupTask          *scheduler.ScheduledTask
+	backupSchedule      *scheduler.CronSchedule
+	metrics             *metrics.Metrics
+	metricsService      *metrics.Service
+	slowRequestFuncs    []SlowRequestFunc
+	slowRequestMu       sync.RWMutex
+	requestSlots        chan struct{}
+	ready               chan struct{}
+	readyOnce           sync.Once
+	startupGate         *startupGate
 
 	localRouter     *mux.Router
 	localModeServer *http.Server
@@ -54,15 +71,91 @@ func New(cfg *config.Configuration, singleUserToken string) (*Server, error) {
 		return nil, err
 	}
 
-	store, err := sqlstore.New(cfg.DBType, cfg.DBConfigString, cfg.DBTablePrefix) //初始化的数据库
+	// The backup schedule is interpreted in the server timezone, so "3am" is
+	// 3am local. It's the only cron schedule, the other scheduled tasks run at
+	// fixed intervals from the start, so the timezone doesn't affect them.
+	location, err := time.LoadLocation(cfg.ServerTimezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid serverTimezone %q", cfg.ServerTimezone)
+	}
+
+	var backupSchedule *scheduler.CronSchedule
+	if cfg.BackupSchedule != "" {
+		backupSchedule, err = scheduler.ParseCronSchedule(cfg.BackupSchedule, location)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid backupSchedule")
+		}
+	}
+
+	var tcpKeepAlive time.Duration
+	if cfg.TCPKeepAlive {
+		tcpKeepAlive = time.Duration(cfg.TCPKeepAlivePeriod) * time.Second
+	}
+	webServer := web.NewServer(cfg.WebPath, cfg.ServerRoot, cfg.Port, cfg.UseSSL, cfg.LocalOnly, tcpKeepAlive)
+	webServer.ShutdownTimeout = time.Duration(cfg.ShutdownTimeout) * time.Second
+	if cfg.UseSSL {
+		tlsConfig, err := web.NewTLSConfig(cfg.TLSMinVersion, cfg.TLSCipherSuites)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid TLS settings")
+		}
+		webServer.TLSConfig = tlsConfig
+	}
+
+	// The requests are rejected until the migrations are done and the
+	// routes registered, instead of refusing the connections
+	var gate *startupGate
+	created := false
+	if cfg.ListenDuringMigration {
+		gate = newStartupGate(webServer.Router())
+		webServer.Handler = gate
+		if err := webServer.Listen(); err != nil {
+			return nil, errors.Wrap(err, "unable to listen")
+		}
+		defer func() {
+			if !created {
+				webServer.Close()
+			}
+		}()
+	}
+
+	store, err := openStore(cfg) //初始化的数据库
 	if err != nil {
 		log.Print("Unable to start the database", err)
 		return nil, err
 	}
+	cache := newStoreCache(cfg, store)
+	if cache != nil {
+		store = cache
+	}
+
+	upgraded, err := store.UpgradeBlockData()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to upgrade the block data")
+	}
+	if upgraded > 0 {
+		log.Printf("Upgraded the data of %d blocks", upgraded)
+	}
+	if gate != nil {
+		gate.migrated()
+	}
 
 	auth := auth.New(cfg, store) //验证服务？
 
 	wsServer := ws.NewServer(auth, singleUserToken) //websocket
+	auth.SessionDenied = wsServer.ExpireSessions
+	auth.Logger = logger
+	wsServer.AuthTimeout = time.Duration(cfg.WebSocketAuthTimeout) * time.Second
+	wsServer.MaxClients = cfg.WebSocketMaxClients
+	wsServer.CoalesceWindow = time.Duration(cfg.BroadcastCoalesceWindow) * time.Millisecond
+	wsServer.WriteTimeout = time.Duration(cfg.BroadcastWriteTimeout) * time.Millisecond
+	if len(cfg.WebSocketProtocolVersions) > 0 {
+		if err := wsServer.SetProtocolVersions(cfg.WebSocketProtocolVersions); err != nil {
+			return nil, errors.Wrap(err, "invalid webSocketProtocolVersions")
+		}
+	}
+	if err := wsServer.SetReconnectDelays(cfg.WebSocketReconnectDelays); err != nil {
+		return nil, errors.Wrap(err, "invalid webSocketReconnectDelays")
+	}
 
 	filesBackendSettings := filesstore.FileBackendSettings{} //本地的文件存储
 	filesBackendSettings.DriverName = "local"
@@ -73,20 +166,39 @@ func New(cfg *config.Configuration, singleUserToken string) (*Server, error) {
 
 		return nil, errors.New("unable to initialize the files storage")
 	}
+	store.SetFilesBackend(filesBackend)
 
-	webhookClient := webhook.NewClient(cfg)
+	webhookClient := webhook.NewClient(cfg, store)
 
-	appBuilder := func() *app.App { return app.New(cfg, store, auth, wsServer, filesBackend, webhookClient) }
+	var responseCache *api.ResponseCache
+	if len(cfg.ResponseCacheTTLs) > 0 {
+		responseCache = api.NewResponseCache(cfg.ResponseCacheTTLs)
+	}
+	uploads := app.NewUploads()
+	appBuilder := func() *app.App {
+		a := app.New(cfg, store, auth, wsServer, filesBackend, webhookClient).WithUploads(uploads)
+		if responseCache != nil {
+			a = a.WithCacheInvalidator(responseCache)
+		}
+		return a
+	}
 	api := api.NewAPI(appBuilder, singleUserToken, cfg.AuthMode)
+	api.ResponseCache = responseCache
+	api.DefaultPageSize = cfg.DefaultPageSize
+	api.MaxPageSize = cfg.MaxPageSize
+	api.MaxDecompressedBodySize = cfg.MaxDecompressedBodySize
+	api.MaxBulkBatchSize = cfg.MaxBulkBatchSize
+	api.MaxImportSize = cfg.MaxImportSize
 
 	// Local router for admin APIs
 	localRouter := mux.NewRouter()
 	api.RegisterAdminRoutes(localRouter)
 
 	// Init workspace
-	appBuilder().GetRootWorkspace()
+	if _, err := appBuilder().GetRootWorkspace(); err != nil {
+		return nil, errors.Wrap(err, "unable to initialize the root workspace")
+	}
 
-	webServer := web.NewServer(cfg.WebPath, cfg.ServerRoot, cfg.Port, cfg.UseSSL, cfg.LocalOnly)
 	webServer.AddRoutes(wsServer) //添加websocket路径
 	webServer.AddRoutes(api)      //添加http路径
 
@@ -98,8 +210,7 @@ func New(cfg *config.Configuration, singleUserToken string) (*Server, error) {
 
 	telemetryID := settings["TelemetryID"] //
 	if len(telemetryID) == 0 {
-		telemetryID = uuid.New().String()
-		err := store.SetSystemSetting("TelemetryID", uuid.New().String())
+		telemetryID, err = initTelemetryID(store)
 		if err != nil {
 			return nil, err
 		}
@@ -126,6 +237,7 @@ func New(cfg *config.Configuration, singleUserToken string) (*Server, error) {
 	}
 
 	telemetryService := telemetry.New(telemetryID, zap.NewStdLog(logger))
+	telemetryService.Timeout = time.Duration(cfg.TelemetryTimeout) * time.Second
 	telemetryService.RegisterTracker("server", func() map[string]interface{} { //注册服务信息的函数
 		return map[string]interface{}{
 			"version":          appModel.CurrentVersion,
@@ -154,27 +266,77 @@ func New(cfg *config.Configuration, singleUserToken string) (*Server, error) {
 	})
 
 	server := Server{ //服务集成
-		config:       cfg,              //配置
-		wsServer:     wsServer,         //websocket
-		webServer:    webServer,        //http服务
-		store:        store,            //数据库
-		filesBackend: filesBackend,     //资源文件
-		telemetry:    telemetryService, //回调,插件？
-		logger:       logger,           //日志
-		localRouter:  localRouter,      //本地管理的API
-		api:          api,              //对外API
-		appBuilder:   appBuilder,       //
+		config:         cfg,              //配置
+		wsServer:       wsServer,         //websocket
+		webServer:      webServer,        //http服务
+		store:          store,            //数据库
+		cache:          cache,            //设置和会话的缓存
+		filesBackend:   filesBackend,     //资源文件
+		telemetry:      telemetryService, //回调,插件？
+		logger:         logger,           //日志
+		localRouter:    localRouter,      //本地管理的API
+		api:            api,              //对外API
+		appBuilder:     appBuilder,       //
+		backupSchedule: backupSchedule,
+		uploads:        uploads,
+		webhook:        webhookClient,
+		metrics:        metrics.NewMetrics(),
+		requestSlots:   newRequestSlots(cfg.MaxConcurrentRequests),
+		ready:          make(chan struct{}),
+		startupGate:    gate,
 	}
 
-	server.initHandlers()
+	server.metrics.RegisterSources(metrics.Sources{
+		WebsocketClients: wsServer.ClientCount,
+		DBStats:          store.DBStats,
+		ActiveUsers:      appBuilder().GetDailyActiveUsers,
+		StartTime:        time.Now(),
+		DBSize: func() (int64, error) {
+			size, err := store.GetDatabaseSize()
+			return size.Total, err
+		},
+		WebsocketBroadcastFailures: wsServer.BroadcastFailures,
+	})
+	localRouter.HandleFunc("/api/v1/admin/stats", server.handleAdminStats).Methods("GET")
+
+	webServer.Router().HandleFunc("/healthz", server.handleHealthz).Methods("GET")
+	webServer.Router().Use(server.accessLogMiddleware, server.loadSheddingMiddleware)
+	if err := server.initHandlers(); err != nil {
+		return nil, err
+	}
 
+	created = true
 	return &server, nil
 }
 
+// initTelemetryID persists a new telemetry ID and returns the one stored,
+// which is the ID of another server if it initialized it at the same time
+func initTelemetryID(db store.Store) (string, error) {
+	err := db.CreateSystemSettingIfNotExists("TelemetryID", uuid.New().String())
+	if err != nil {
+		return "", err
+	}
+
+	settings, err := db.GetSystemSettings()
+	if err != nil {
+		return "", err
+	}
+
+	telemetryID := settings["TelemetryID"]
+	if telemetryID == "" {
+		return "", errors.New("unable to initialize the telemetry ID")
+	}
+
+	return telemetryID, nil
+}
+
 func (s *Server) Start() error {
 	s.logger.Info("Server.Start")
 
 	s.webServer.Start() //启动http服务
+	if s.startupGate != nil {
+		s.startupGate.open()
+	}
 
 	if s.config.EnableLocalMode { //本地服务
 		if err := s.startLocalModeServer(); err != nil {
@@ -183,39 +345,157 @@ func (s *Server) Start() error {
 	}
 
 	s.cleanUpSessionsTask = scheduler.CreateRecurringTask("cleanUpSessions", func() { //清楚session缓存任务
-		secondsAgo := int64(60 * 60 * 24 * 31)
-		if secondsAgo < s.config.SessionExpireTime {
-			secondsAgo = s.config.SessionExpireTime
-		}
-		if err := s.store.CleanUpSessions(secondsAgo); err != nil {
+		removed, err := s.appBuilder().CleanUpSessions()
+		if err != nil {
 			s.logger.Error("Unable to clean up the sessions", zap.Error(err))
+			return
 		}
+		s.logger.Debug("Cleaned up the sessions", zap.Int64("removed", removed))
 	}, 10*time.Minute)
 
+	s.purgeSharingTask = scheduler.CreateRecurringTask("purgeExpiredSharingTokens", func() {
+		expired, err := s.appBuilder().PurgeExpiredSharingTokens()
+		if err != nil {
+			s.logger.Error("Unable to purge the expired sharing tokens", zap.Error(err))
+			return
+		}
+		for _, sharing := range expired {
+			s.logger.Info("Purged expired sharing token", zap.String("rootID", sharing.ID))
+		}
+	}, time.Hour)
+
+	// A TTL of 0 or less disables the cleanup, as every upload in progress
+	// would be older than it
+	if s.config.UploadSessionTTL > 0 {
+		s.cleanUpUploadsTask = scheduler.CreateRecurringTask("cleanUpAbandonedUploads", func() {
+			removed, err := s.appBuilder().CleanUpAbandonedUploads()
+			if err != nil {
+				s.logger.Error("Unable to clean up the abandoned uploads", zap.Error(err))
+				return
+			}
+			s.logger.Debug("Cleaned up the abandoned uploads", zap.Int("removed", removed))
+		}, time.Hour)
+	}
+
+	if s.config.TrashRetentionDays > 0 {
+		s.purgeTrashTask = scheduler.CreateRecurringTask("purgeExpiredTrash", func() {
+			purged, err := s.appBuilder().PurgeExpiredTrash()
+			if err != nil {
+				s.logger.Error("Unable to purge the expired boards of the trash", zap.Error(err))
+				return
+			}
+			s.logger.Debug("Purged the expired boards of the trash", zap.Int("purged", purged))
+		}, time.Hour)
+	}
+
+	if s.config.AutoBackupInterval > 0 || s.backupSchedule != nil {
+		if s.config.DBType == "sqlite3" {
+			backup := func() {
+				filename, err := s.appBuilder().BackupDatabase()
+				if err != nil {
+					s.logger.Error("Unable to back up the database", zap.Error(err))
+					return
+				}
+				s.logger.Info("Database backup created", zap.String("filename", filename))
+			}
+
+			if s.backupSchedule != nil {
+				s.backupTask = scheduler.CreateCronTask("backupDatabase", backup, s.backupSchedule)
+			} else {
+				s.backupTask = scheduler.CreateRecurringTask("backupDatabase", backup, time.Duration(s.config.AutoBackupInterval)*time.Second)
+			}
+		} else {
+			s.logger.Info("Automatic backups are only available for SQLite, use the native database tooling instead", zap.String("dbType", s.config.DBType))
+		}
+	}
+
+	if s.config.PrometheusAddress != "" {
+		s.metricsService = metrics.NewMetricsServer(s.config.PrometheusAddress, s.metrics)
+		go func() {
+			if err := s.metricsService.Run(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("Metrics server stopped", zap.Error(err))
+			}
+		}()
+	}
+
 	if s.config.Telemetry { //
 		firstRun := utils.MillisFromTime(time.Now())
 		s.telemetry.RunTelemetryJob(firstRun)
 	}
 
+	// The web server is already listening, so the server is ready as soon
+	// as a query gets through to the database, past the cache
+	db := s.store
+	if s.cache != nil {
+		db = s.cache.Store
+	}
+	if _, err := db.GetSystemSettings(); err != nil {
+		return errors.Wrap(err, "unable to reach the store")
+	}
+	if s.config.PrewarmCaches {
+		s.prewarm(time.Duration(s.config.PrewarmTimeout) * time.Second)
+	}
+	s.markReady()
+
 	return nil
 }
 
 func (s *Server) Shutdown() error { //关闭服务
-	if err := s.webServer.Shutdown(); err != nil {
-		return err
-	}
+	err := s.shutdownSequence().run(s.logger)
+
+	s.logger.Info("Server.Shutdown")
 
-	s.stopLocalModeServer() //禁止本地服务
+	return err
+}
 
-	if s.cleanUpSessionsTask != nil {
-		s.cleanUpSessionsTask.Cancel()
+// shutdownSequence registers the components of the server in the phase of
+// their shutdown, skipping the ones that weren't started. The files backend
+// has nothing to close, the uploads writing to it are drained with the web
+// server.
+func (s *Server) shutdownSequence() *shutdownSequence {
+	seq := &shutdownSequence{}
+	timeout := time.Duration(s.config.ShutdownTimeout) * time.Second
+
+	// The uploads in progress are given a chance to finish before their
+	// connections are closed
+	if s.uploads != nil {
+		seq.register(shutdownPhaseIngress, "uploads", func() error {
+			return s.uploads.Shutdown(timeout)
+		})
+	}
+	seq.register(shutdownPhaseIngress, "web server", s.webServer.Shutdown)
+	seq.register(shutdownPhaseIngress, "local mode server", s.stopLocalModeServer) //禁止本地服务
+
+	for _, task := range []*scheduler.ScheduledTask{
+		s.cleanUpSessionsTask,
+		s.purgeSharingTask,
+		s.cleanUpUploadsTask,
+		s.purgeTrashTask,
+		s.backupTask,
+	} {
+		if task != nil {
+			task := task
+			seq.register(shutdownPhaseBackground, task.Name+" task", func() error {
+				task.Cancel()
+				return nil
+			})
+		}
+	}
+	if s.metricsService != nil {
+		seq.register(shutdownPhaseBackground, "metrics server", s.metricsService.Shutdown)
 	}
 
-	s.telemetry.Shutdown()
+	seq.register(shutdownPhaseServices, "websocket server", s.wsServer.Shutdown)
+	if s.webhook != nil {
+		seq.register(shutdownPhaseServices, "webhook workers", func() error {
+			return s.webhook.Shutdown(timeout)
+		})
+	}
+	seq.register(shutdownPhaseServices, "telemetry", s.telemetry.Shutdown)
 
-	defer s.logger.Info("Server.Shutdown")
+	seq.register(shutdownPhaseStorage, "store", s.store.Shutdown)
 
-	return s.store.Shutdown()
+	return seq
 }
 
 func (s *Server) Config() *config.Configuration {
@@ -253,11 +533,14 @@ func (s *Server) startLocalModeServer() error {
 	return nil
 }
 
-func (s *Server) stopLocalModeServer() {
-	if s.localModeServer != nil {
-		s.localModeServer.Close()
-		s.localModeServer = nil
+func (s *Server) stopLocalModeServer() error {
+	if s.localModeServer == nil {
+		return nil
 	}
+
+	err := s.localModeServer.Close()
+	s.localModeServer = nil
+	return err
 }
 
 func (s *Server) GetRootRouter() *mux.Router {
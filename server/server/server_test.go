@@ -0,0 +1,129 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/services/config"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/telemetry"
+	"github.com/mattermost/focalboard/server/web"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	store := mockstore.NewMockStore(ctrl)
+	webServer := web.NewServer("", "", 0, false, true, 0)
+	s := &Server{
+		config:    &config.Configuration{},
+		wsServer:  ws.NewServer(nil, ""),
+		webServer: webServer,
+		store:     store,
+		telemetry: telemetry.New("telemetry-id", log.New(ioutil.Discard, "", 0)),
+		logger:    zap.New(core),
+	}
+
+	store.EXPECT().Shutdown().Return(errors.New("database is locked"))
+
+	err := s.Shutdown()
+	require.EqualError(t, err, "1 error occurred:\n\t* unable to shut down the store: database is locked\n\n")
+
+	shutDown := []string{}
+	for _, entry := range logs.FilterMessage("Component shut down").All() {
+		shutDown = append(shutDown, entry.ContextMap()["component"].(string))
+	}
+	require.Equal(t, []string{"web server", "local mode server", "websocket server", "telemetry"}, shutDown)
+
+	failures := logs.FilterMessage("Unable to shut down component").All()
+	require.Len(t, failures, 1)
+	require.Equal(t, "store", failures[0].ContextMap()["component"])
+
+	// The web server was closed despite the store failure
+	require.Equal(t, http.ErrServerClosed, webServer.ListenAndServe())
+}
+
+func TestInitTelemetryID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("first run", func(t *testing.T) {
+		store := mockstore.NewMockStore(ctrl)
+
+		var persisted string
+		store.EXPECT().CreateSystemSettingIfNotExists("TelemetryID", gomock.Any()).DoAndReturn(func(key, value string) error {
+			persisted = value
+			return nil
+		})
+		store.EXPECT().GetSystemSettings().DoAndReturn(func() (map[string]string, error) {
+			return map[string]string{"TelemetryID": persisted}, nil
+		})
+
+		telemetryID, err := initTelemetryID(store)
+		require.NoError(t, err)
+		require.NotEmpty(t, telemetryID)
+		require.Equal(t, persisted, telemetryID)
+	})
+
+	t.Run("another server initialized it first", func(t *testing.T) {
+		store := mockstore.NewMockStore(ctrl)
+
+		store.EXPECT().CreateSystemSettingIfNotExists("TelemetryID", gomock.Any()).Return(nil)
+		store.EXPECT().GetSystemSettings().Return(map[string]string{"TelemetryID": "other-server-id"}, nil)
+
+		telemetryID, err := initTelemetryID(store)
+		require.NoError(t, err)
+		require.Equal(t, "other-server-id", telemetryID)
+	})
+}
+
+func TestNewRootWorkspaceFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	defer func(open func(cfg *config.Configuration) (st.Store, error)) {
+		openStore = open
+	}(openStore)
+	openStore = func(cfg *config.Configuration) (st.Store, error) {
+		return store, nil
+	}
+
+	cfg := &config.Configuration{
+		ServerTimezone: "UTC",
+		FilesPath:      t.TempDir(),
+		LocalOnly:      true,
+	}
+	store.EXPECT().UpgradeBlockData().Return(0, nil).AnyTimes()
+	store.EXPECT().SetFilesBackend(gomock.Any()).AnyTimes()
+
+	t.Run("the workspace can't be read", func(t *testing.T) {
+		store.EXPECT().GetWorkspace("0").Return(nil, errors.New("connection reset"))
+
+		server, err := New(cfg, "")
+		require.Nil(t, server)
+		require.EqualError(t, err, "unable to initialize the root workspace: unable to read the root workspace: connection reset")
+	})
+
+	t.Run("the workspace can't be created", func(t *testing.T) {
+		store.EXPECT().GetWorkspace("0").Return(nil, sql.ErrNoRows)
+		store.EXPECT().UpsertWorkspaceSignupToken(gomock.Any()).Return(errors.New("database is read-only"))
+
+		server, err := New(cfg, "")
+		require.Nil(t, server)
+		require.EqualError(t, err, "unable to initialize the root workspace: unable to create the root workspace: database is read-only")
+	})
+}
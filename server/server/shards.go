@@ -0,0 +1,66 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/sqlstore"
+	"github.com/pkg/errors"
+)
+
+// openStore creates the store of the server, replaced by the tests
+var openStore = newStore
+
+// newStore connects to the database and, when shards are configured, to
+// the database of each of them, which are migrated like the primary one
+func newStore(cfg *config.Configuration) (store.Store, error) {
+	connect := func(connectionString string) (*sqlstore.SQLStore, error) {
+		return sqlstore.New(cfg.DBType, connectionString, cfg.DBTablePrefix, cfg.DBConnectRetries, time.Duration(cfg.DBConnectRetryInterval)*time.Second)
+	}
+
+	primary, err := connect(cfg.DBConfigString)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.DBShards) == 0 {
+		return primary, nil
+	}
+
+	shards := map[string]store.Store{}
+	closeAll := func() {
+		for _, shard := range shards {
+			shard.Shutdown()
+		}
+		primary.Shutdown()
+	}
+
+	for name, connectionString := range cfg.DBShards {
+		shard, err := connect(connectionString)
+		if err != nil {
+			closeAll()
+			return nil, errors.Wrapf(err, "unable to connect to the database of shard %s", name)
+		}
+		shards[name] = shard
+	}
+
+	router, err := store.NewRouter(primary, shards, cfg.ShardMap)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	log.Printf("Routing the workspaces to %d database shards", len(shards))
+	return router, nil
+}
+
+// newStoreCache returns the cache of the system settings and sessions of the
+// store, or nil if they aren't cached
+func newStoreCache(cfg *config.Configuration, s store.Store) *store.Cache {
+	if cfg.StoreCacheTTL <= 0 {
+		return nil
+	}
+
+	return store.NewCache(s, time.Duration(cfg.StoreCacheTTL)*time.Second)
+}
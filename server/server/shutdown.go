@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// shutdownPhase orders the shutdown of the components. The components of a
+// phase can still use the ones of the later phases while they drain, so each
+// phase only starts once the previous one is done.
+type shutdownPhase int
+
+const (
+	// shutdownPhaseIngress stops accepting requests and waits for the ones in
+	// progress
+	shutdownPhaseIngress shutdownPhase = iota
+	// shutdownPhaseBackground stops the scheduled tasks, waiting for the runs
+	// in progress
+	shutdownPhaseBackground
+	// shutdownPhaseServices drains the services the requests and the tasks
+	// hand work to, like the websocket broadcasts and the webhook deliveries
+	shutdownPhaseServices
+	// shutdownPhaseStorage closes the store, once nothing uses it anymore
+	shutdownPhaseStorage
+)
+
+type shutdownComponent struct {
+	name     string
+	phase    shutdownPhase
+	shutdown func() error
+}
+
+// shutdownSequence shuts down the registered components phase by phase, and
+// in the order of their registration within a phase
+type shutdownSequence struct {
+	components []shutdownComponent
+}
+
+func (seq *shutdownSequence) register(phase shutdownPhase, name string, shutdown func() error) {
+	seq.components = append(seq.components, shutdownComponent{name: name, phase: phase, shutdown: shutdown})
+}
+
+// run shuts down all the components, even when some of them fail, and
+// returns the failures
+func (seq *shutdownSequence) run(logger *zap.Logger) error {
+	components := append([]shutdownComponent{}, seq.components...)
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].phase < components[j].phase
+	})
+
+	var result *multierror.Error
+	for _, component := range components {
+		if err := component.shutdown(); err != nil {
+			logger.Error("Unable to shut down component", zap.String("component", component.name), zap.Error(err))
+			result = multierror.Append(result, errors.Wrapf(err, "unable to shut down the %s", component.name))
+			continue
+		}
+		logger.Info("Component shut down", zap.String("component", component.name))
+	}
+
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/scheduler"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/services/telemetry"
+	"github.com/mattermost/focalboard/server/services/webhook"
+	"github.com/mattermost/focalboard/server/web"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestShutdownSequence(t *testing.T) {
+	seq := &shutdownSequence{}
+	shutDown := []string{}
+	register := func(phase shutdownPhase, name string, err error) {
+		seq.register(phase, name, func() error {
+			shutDown = append(shutDown, name)
+			return err
+		})
+	}
+
+	register(shutdownPhaseStorage, "store", nil)
+	register(shutdownPhaseServices, "websocket server", errors.New("already closed"))
+	register(shutdownPhaseIngress, "web server", nil)
+	register(shutdownPhaseBackground, "task", nil)
+	register(shutdownPhaseServices, "webhook workers", nil)
+	register(shutdownPhaseIngress, "local mode server", nil)
+
+	err := seq.run(zap.NewNop())
+	require.EqualError(t, err, "1 error occurred:\n\t* unable to shut down the websocket server: already closed\n\n")
+
+	// By phase, then in the order of registration, and the failure doesn't
+	// stop the later phases
+	require.Equal(t, []string{
+		"web server",
+		"local mode server",
+		"task",
+		"websocket server",
+		"webhook workers",
+		"store",
+	}, shutDown)
+}
+
+func TestServerShutdownPhases(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := &config.Configuration{}
+	s := &Server{
+		config:              cfg,
+		wsServer:            ws.NewServer(nil, ""),
+		webServer:           web.NewServer("", "", 0, false, true, 0),
+		store:               mockstore.NewMockStore(ctrl),
+		telemetry:           telemetry.New("telemetry-id", log.New(ioutil.Discard, "", 0)),
+		webhook:             webhook.NewClient(cfg, nil),
+		cleanUpSessionsTask: scheduler.CreateRecurringTask("cleanUpSessions", func() {}, time.Hour),
+		logger:              zap.NewNop(),
+	}
+
+	phases := map[string]shutdownPhase{}
+	for _, component := range s.shutdownSequence().components {
+		phases[component.name] = component.phase
+	}
+
+	require.Equal(t, map[string]shutdownPhase{
+		"web server":           shutdownPhaseIngress,
+		"local mode server":    shutdownPhaseIngress,
+		"cleanUpSessions task": shutdownPhaseBackground,
+		"websocket server":     shutdownPhaseServices,
+		"webhook workers":      shutdownPhaseServices,
+		"telemetry":            shutdownPhaseServices,
+		"store":                shutdownPhaseStorage,
+	}, phases)
+
+	s.cleanUpSessionsTask.Cancel()
+}
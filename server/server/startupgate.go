@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// startupGateRetryAfter is the number of seconds clients are asked to wait
+// before retrying a request received while the server starts
+const startupGateRetryAfter = 5
+
+const (
+	gateMigrating int32 = iota
+	gateStarting
+	gateOpen
+)
+
+// startupGate serves the requests received while the store is migrated and
+// the routes registered, so the web server can listen from the start. They
+// get a 503 with a Retry-After, except for the health checks, which report
+// the startup phase. Once opened, the requests go through to the handler.
+type startupGate struct {
+	handler http.Handler
+	state   int32
+}
+
+func newStartupGate(handler http.Handler) *startupGate {
+	return &startupGate{
+		handler: handler,
+		state:   gateMigrating,
+	}
+}
+
+// migrated moves on from the migrations to the rest of the startup
+func (g *startupGate) migrated() {
+	atomic.StoreInt32(&g.state, gateStarting)
+}
+
+// open lets the requests through, it must be called once all the routes
+// are registered
+func (g *startupGate) open() {
+	atomic.StoreInt32(&g.state, gateOpen)
+}
+
+func (g *startupGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state := atomic.LoadInt32(&g.state)
+	if state == gateOpen {
+		g.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if r.URL.Path == "/healthz" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if state == gateMigrating {
+			w.Write([]byte("migrating"))
+		} else {
+			w.Write([]byte("starting"))
+		}
+		return
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(startupGateRetryAfter))
+	http.Error(w, "the server is starting, retry later", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupGate(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	gate := newStartupGate(handler)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		gate.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	t.Run("requests are rejected during the migrations", func(t *testing.T) {
+		recorder := serve("/api/v1/workspaces/0/blocks")
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Equal(t, "5", recorder.Header().Get("Retry-After"))
+
+		recorder = serve("/healthz")
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Equal(t, "migrating", recorder.Body.String())
+	})
+
+	t.Run("requests are rejected until the server starts", func(t *testing.T) {
+		gate.migrated()
+
+		require.Equal(t, http.StatusServiceUnavailable, serve("/api/v1/workspaces/0/blocks").Code)
+		require.Equal(t, "starting", serve("/healthz").Body.String())
+	})
+
+	t.Run("requests go through once open", func(t *testing.T) {
+		gate.open()
+
+		recorder := serve("/api/v1/workspaces/0/blocks")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "ok", recorder.Body.String())
+		require.Empty(t, recorder.Header().Get("Retry-After"))
+	})
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/focalboard/server/services/store"
+	"go.uber.org/zap"
+)
+
+// handleAdminStats returns a JSON snapshot of the metrics, for a quick look
+// at the server status without a Prometheus server, with the size of each
+// table of the database, the websocket clients listening to each board and
+// the diagnostics of the background workers.
+// It's only served by the local router, on the admin socket.
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.metrics.Snapshot()
+	if err != nil {
+		s.logger.Error("Unable to gather the metrics", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	size, err := s.store.GetDatabaseSize()
+	switch {
+	case errors.Is(err, store.ErrNotSupported):
+		snapshot["db_size_supported"] = 0
+	case err != nil:
+		s.logger.Error("Unable to get the database size", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	default:
+		snapshot["db_size_supported"] = 1
+		for table, tableSize := range size.Tables {
+			snapshot[fmt.Sprintf(`db_table_size_bytes{table="%s"}`, table)] = float64(tableSize)
+		}
+	}
+
+	if s.wsServer != nil {
+		for boardID, count := range s.wsServer.SubscriptionCounts() {
+			snapshot[fmt.Sprintf(`websocket_subscriptions{board="%s"}`, boardID)] = float64(count)
+		}
+	}
+
+	diagnostics := s.Diagnostics()
+	snapshot["goroutines"] = float64(diagnostics.Goroutines)
+	snapshot["webhook_workers"] = float64(diagnostics.WebhookWorkers)
+	snapshot["webhook_queued_events"] = float64(diagnostics.WebhookQueued)
+	snapshot["webhook_dropped_events_total"] = float64(diagnostics.WebhookDropped)
+	snapshot["telemetry_pending_events"] = float64(diagnostics.TelemetryPending)
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
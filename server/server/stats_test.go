@@ -0,0 +1,96 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mattermost/focalboard/server/services/metrics"
+	st "github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/mattermost/focalboard/server/ws"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleAdminStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	s := &Server{
+		logger:   zap.NewNop(),
+		metrics:  metrics.NewMetrics(),
+		store:    store,
+		wsServer: ws.NewServer(nil, ""),
+	}
+	s.metrics.RegisterSources(metrics.Sources{
+		WebsocketClients: func() int { return 3 },
+		DBStats: func() sql.DBStats {
+			return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 1, Idle: 3}
+		},
+		ActiveUsers: func() (int, error) { return 7, nil },
+		StartTime:   time.Now().Add(-time.Minute),
+		DBSize:      func() (int64, error) { return 4096, nil },
+	})
+	store.EXPECT().GetDatabaseSize().Return(st.DBSize{Total: 4096, Tables: map[string]int64{"blocks": 3072}}, nil)
+
+	s.metrics.IncrementInFlightRequests()
+	s.metrics.IncrementShedRequests()
+	s.metrics.IncrementShedRequests()
+	s.metrics.IncrementSlowRequests("/api/v1/workspaces/{workspaceID}/blocks")
+	s.metrics.IncrementSlowRequests("/api/v1/login")
+
+	recorder := httptest.NewRecorder()
+	s.handleAdminStats(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var stats map[string]float64
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &stats))
+
+	require.Equal(t, 3.0, stats["websocket_clients"])
+	require.Equal(t, 1.0, stats["api_in_flight_requests"])
+	require.Equal(t, 2.0, stats["api_shed_requests_total"])
+	require.Equal(t, 10.0, stats["db_max_open_connections"])
+	require.Equal(t, 4.0, stats["db_open_connections"])
+	require.Equal(t, 1.0, stats["db_in_use_connections"])
+	require.Equal(t, 3.0, stats["db_idle_connections"])
+	require.Equal(t, 7.0, stats["system_daily_active_users"])
+	require.GreaterOrEqual(t, stats["system_uptime_seconds"], 60.0)
+	require.Equal(t, 2.0, stats["api_slow_requests_total"])
+	require.NotContains(t, stats, "process_open_fds")
+	require.Equal(t, 4096.0, stats["db_size_bytes"])
+	require.Equal(t, 1.0, stats["db_size_supported"])
+	require.Equal(t, 3072.0, stats[`db_table_size_bytes{table="blocks"}`])
+	require.NotContains(t, stats, `websocket_subscriptions{board="board-id"}`)
+	require.Greater(t, stats["goroutines"], 0.0)
+	require.Equal(t, 0.0, stats["webhook_workers"])
+	require.Equal(t, 0.0, stats["webhook_dropped_events_total"])
+	require.Equal(t, 0.0, stats["telemetry_pending_events"])
+}
+
+func TestHandleAdminStatsUnsupportedDatabaseSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	s := &Server{
+		logger:  zap.NewNop(),
+		metrics: metrics.NewMetrics(),
+		store:   store,
+	}
+	store.EXPECT().GetDatabaseSize().Return(st.DBSize{}, st.ErrNotSupported)
+
+	recorder := httptest.NewRecorder()
+	s.handleAdminStats(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var stats map[string]float64
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &stats))
+	require.Equal(t, 0.0, stats["db_size_supported"])
+}
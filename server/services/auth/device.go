@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+const DEVICE_COOKIE_ID = "FOCALBOARDDEVICEID"
+
+// DeviceFingerprint identifies the device a session was created from, by
+// hashing its user agent and device ID cookie.
+func DeviceFingerprint(userAgent, deviceID string) string {
+	hash := sha256.Sum256([]byte(userAgent + "\x00" + deviceID))
+	return hex.EncodeToString(hash[:])
+}
+
+// ParseDeviceIDFromRequest returns the device ID cookie of the request, or an
+// empty string if there is none.
+func ParseDeviceIDFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie(DEVICE_COOKIE_ID); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+// ParseDeviceFingerprintFromRequest returns the fingerprint of the device
+// presenting the request.
+func ParseDeviceFingerprintFromRequest(r *http.Request) string {
+	return DeviceFingerprint(r.UserAgent(), ParseDeviceIDFromRequest(r))
+}
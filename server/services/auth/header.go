@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrUntrustedSource is returned when a request carries the authentication
+// header without coming from one of the trusted proxies.
+var ErrUntrustedSource = errors.New("authentication header received from an untrusted source")
+
+// HeaderAuth authenticates the requests through a header set by a reverse
+// proxy, which is trusted only when the request comes from that proxy.
+type HeaderAuth struct {
+	header         string
+	trustedProxies []*net.IPNet
+}
+
+// NewHeaderAuth returns a HeaderAuth reading the user from header. The
+// trusted proxies can be IP addresses or CIDR ranges.
+func NewHeaderAuth(header string, trustedProxies []string) (*HeaderAuth, error) {
+	if header == "" {
+		return nil, errors.New("the authentication header is required")
+	}
+
+	h := &HeaderAuth{header: header}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		h.trustedProxies = append(h.trustedProxies, network)
+	}
+
+	return h, nil
+}
+
+// ParseUsernameFromRequest returns the username set by the proxy, or an
+// empty string if the request has no authentication header.
+func (h *HeaderAuth) ParseUsernameFromRequest(r *http.Request) (string, error) {
+	username := strings.TrimSpace(r.Header.Get(h.header))
+	if username == "" {
+		return "", nil
+	}
+
+	if !h.isTrustedProxy(r.RemoteAddr) {
+		return "", ErrUntrustedSource
+	}
+
+	return username, nil
+}
+
+func (h *HeaderAuth) isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range h.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHeaderAuth(t *testing.T) {
+	_, err := NewHeaderAuth("X-Auth-User", []string{"not-an-ip"})
+	require.Error(t, err)
+
+	_, err = NewHeaderAuth("", nil)
+	require.Error(t, err)
+}
+
+func TestParseUsernameFromRequest(t *testing.T) {
+	headerAuth, err := NewHeaderAuth("X-Auth-User", []string{"::1", "10.0.0.0/8"})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("X-Auth-User", "jane")
+
+	request.RemoteAddr = "[::1]:4567"
+	username, err := headerAuth.ParseUsernameFromRequest(request)
+	require.NoError(t, err)
+	require.Equal(t, "jane", username)
+
+	request.RemoteAddr = "11.0.0.1:4567"
+	_, err = headerAuth.ParseUsernameFromRequest(request)
+	require.Equal(t, ErrUntrustedSource, err)
+}
@@ -27,9 +27,19 @@ const (
 	InvalidSymbolPassword    = "symbol"
 )
 
+// DefaultPasswordHashCost is the bcrypt cost of the hashes when the
+// configuration doesn't set a valid one
+const DefaultPasswordHashCost = 10
+
 // HashPassword generates a hash using the bcrypt.GenerateFromPassword
 func HashPassword(password string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	return HashPasswordWithCost(password, DefaultPasswordHashCost)
+}
+
+// HashPasswordWithCost generates a hash with the given bcrypt cost, or with
+// the default one if it's out of the range bcrypt supports
+func HashPasswordWithCost(password string, cost int) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost(cost))
 	if err != nil {
 		panic(err)
 	}
@@ -37,6 +47,25 @@ func HashPassword(password string) string {
 	return string(hash)
 }
 
+// NeedsRehash checks if the hash was generated with a lower cost than the
+// given one, so it should be replaced once the password is known
+func NeedsRehash(hash string, cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+
+	return hashCost < passwordHashCost(cost)
+}
+
+func passwordHashCost(cost int) int {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return DefaultPasswordHashCost
+	}
+
+	return cost
+}
+
 // ComparePassword compares the hash
 func ComparePassword(hash, password string) bool {
 	if len(password) == 0 || len(hash) == 0 {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestPasswordHash(t *testing.T) {
@@ -163,3 +164,27 @@ func TestIsPasswordValidWithSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordHashCost(t *testing.T) {
+	t.Run("configured cost", func(t *testing.T) {
+		hash := HashPasswordWithCost("Test", bcrypt.MinCost+1)
+		cost, err := bcrypt.Cost([]byte(hash))
+		require.NoError(t, err)
+		require.Equal(t, bcrypt.MinCost+1, cost)
+		require.True(t, ComparePassword(hash, "Test"))
+	})
+
+	t.Run("invalid cost", func(t *testing.T) {
+		hash := HashPasswordWithCost("Test", 0)
+		cost, err := bcrypt.Cost([]byte(hash))
+		require.NoError(t, err)
+		require.Equal(t, DefaultPasswordHashCost, cost)
+	})
+
+	t.Run("needs rehash", func(t *testing.T) {
+		hash := HashPasswordWithCost("Test", bcrypt.MinCost)
+		require.True(t, NeedsRehash(hash, bcrypt.MinCost+1))
+		require.False(t, NeedsRehash(hash, bcrypt.MinCost))
+		require.False(t, NeedsRehash("not a hash", bcrypt.MinCost+1))
+	})
+}
@@ -0,0 +1,32 @@
+package auth
+
+import "strings"
+
+// IsRedirectAllowed checks if the target of a post-login redirect is a path
+// of the server, or a URL of the allow-list. An entry matches the URLs equal
+// to it and the ones under it, so "https://example.com/app" allows
+// "https://example.com/app/boards" but not "https://example.com/apps" or
+// "https://example.com/app.evil.com".
+func IsRedirectAllowed(target string, allowed []string) bool {
+	if target == "" || strings.ContainsAny(target, "\\\r\n\t") {
+		return false
+	}
+
+	// Relative paths stay on the server, unless they're protocol-relative
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		return true
+	}
+
+	for _, entry := range allowed {
+		if entry == "" || !strings.HasPrefix(target, entry) {
+			continue
+		}
+
+		rest := target[len(entry):]
+		if rest == "" || strings.HasSuffix(entry, "/") || strings.ContainsAny(rest[:1], "/?#") {
+			return true
+		}
+	}
+
+	return false
+}
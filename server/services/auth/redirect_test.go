@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRedirectAllowed(t *testing.T) {
+	allowed := []string{"https://example.com/app", "https://boards.example.com/"}
+
+	for target, expected := range map[string]bool{
+		"/boards/board-id":                   true,
+		"https://example.com/app":            true,
+		"https://example.com/app/boards":     true,
+		"https://example.com/app?tab=1":      true,
+		"https://boards.example.com/b/1":     true,
+		"https://example.com/apps":           false,
+		"https://example.com/app.evil.com":   false,
+		"https://example.com/app@evil.com":   false,
+		"https://evil.com/app":               false,
+		"//evil.com":                         false,
+		"/\\evil.com":                        false,
+		"javascript:alert(1)":                false,
+		"":                                   false,
+		"https://boards.example.com.evil.io": false,
+	} {
+		require.Equal(t, expected, IsRedirectAllowed(target, allowed), target)
+	}
+}
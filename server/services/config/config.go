@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -17,7 +20,10 @@ type Configuration struct {
 	Port                    int      `json:"port" mapstructure:"port"`
 	DBType                  string   `json:"dbtype" mapstructure:"dbtype"`
 	DBConfigString          string   `json:"dbconfig" mapstructure:"dbconfig"`
+	DBConfigFile            string   `json:"dbconfigfile" mapstructure:"dbconfigfile"`
 	DBTablePrefix           string   `json:"dbtableprefix" mapstructure:"dbtableprefix"`
+	DBConnectRetries        int      `json:"dbConnectRetries" mapstructure:"dbConnectRetries"`
+	DBConnectRetryInterval  int      `json:"dbConnectRetryInterval" mapstructure:"dbConnectRetryInterval"`
 	UseSSL                  bool     `json:"useSSL" mapstructure:"useSSL"`
 	SecureCookie            bool     `json:"secureCookie" mapstructure:"secureCookie"`
 	WebPath                 string   `json:"webpath" mapstructure:"webpath"`
@@ -27,14 +33,75 @@ type Configuration struct {
 	Secret                  string   `json:"secret" mapstructure:"secret"`
 	SessionExpireTime       int64    `json:"session_expire_time" mapstructure:"session_expire_time"`
 	SessionRefreshTime      int64    `json:"session_refresh_time" mapstructure:"session_refresh_time"`
+	SessionDeviceBinding    bool     `json:"session_device_binding" mapstructure:"session_device_binding"`
+	ClockSkewTolerance      int64    `json:"clockSkewTolerance" mapstructure:"clockSkewTolerance"`
 	LocalOnly               bool     `json:"localonly" mapstructure:"localonly"`
 	EnableLocalMode         bool     `json:"enableLocalMode" mapstructure:"enableLocalMode"`
 	LocalModeSocketLocation string   `json:"localModeSocketLocation" mapstructure:"localModeSocketLocation"`
+	AutoBackupInterval      int64    `json:"autoBackupInterval" mapstructure:"autoBackupInterval"`
+	BackupDir               string   `json:"backupDir" mapstructure:"backupDir"`
+	BackupKeep              int      `json:"backupKeep" mapstructure:"backupKeep"`
+	BackupSchedule          string   `json:"backupSchedule" mapstructure:"backupSchedule"`
+	ServerTimezone          string   `json:"serverTimezone" mapstructure:"serverTimezone"`
+	SlowRequestThreshold    int64    `json:"slowRequestThreshold" mapstructure:"slowRequestThreshold"`
+	PrometheusAddress       string   `json:"prometheusAddress" mapstructure:"prometheusAddress"`
+	MaxConcurrentRequests   int      `json:"maxConcurrentRequests" mapstructure:"maxConcurrentRequests"`
+	TCPKeepAlive            bool     `json:"tcpKeepAlive" mapstructure:"tcpKeepAlive"`
+	TCPKeepAlivePeriod      int      `json:"tcpKeepAlivePeriod" mapstructure:"tcpKeepAlivePeriod"`
+	WebSocketAuthTimeout    int      `json:"webSocketAuthTimeout" mapstructure:"webSocketAuthTimeout"`
+	WebSocketMaxClients     int      `json:"webSocketMaxClients" mapstructure:"webSocketMaxClients"`
+	MaxBoardsPerWorkspace   int      `json:"maxBoardsPerWorkspace" mapstructure:"maxBoardsPerWorkspace"`
+	WebhookRequestID        bool     `json:"webhookRequestID" mapstructure:"webhookRequestID"`
+	WebhookWorkers          int      `json:"webhookWorkers" mapstructure:"webhookWorkers"`
+	WebhookQueueSize        int      `json:"webhookQueueSize" mapstructure:"webhookQueueSize"`
+	WebhookTimeout          int      `json:"webhookTimeout" mapstructure:"webhookTimeout"`
+	BroadcastCoalesceWindow int      `json:"broadcastCoalesceWindow" mapstructure:"broadcastCoalesceWindow"`
+	BroadcastWriteTimeout   int      `json:"broadcastWriteTimeout" mapstructure:"broadcastWriteTimeout"`
+	PasswordHashCost        int      `json:"passwordHashCost" mapstructure:"passwordHashCost"`
+	UploadSessionTTL        int64    `json:"uploadSessionTTL" mapstructure:"uploadSessionTTL"`
+	ShutdownTimeout         int      `json:"shutdownTimeout" mapstructure:"shutdownTimeout"`
+	MigrateDryRun           bool     `json:"migrateDryRun" mapstructure:"migrateDryRun"`
+	TLSMinVersion           string   `json:"tlsMinVersion" mapstructure:"tlsMinVersion"`
+	TelemetryTimeout        int      `json:"telemetryTimeout" mapstructure:"telemetryTimeout"`
+	ListenDuringMigration   bool     `json:"listenDuringMigration" mapstructure:"listenDuringMigration"`
+	DeletedUserBoardsOwner  string   `json:"deletedUserBoardsOwner" mapstructure:"deletedUserBoardsOwner"`
+	DefaultPageSize         int      `json:"defaultPageSize" mapstructure:"defaultPageSize"`
+	MaxPageSize             int      `json:"maxPageSize" mapstructure:"maxPageSize"`
+	WorkspaceStorageQuota   int64    `json:"workspaceStorageQuota" mapstructure:"workspaceStorageQuota"`
+	MaxDecompressedBodySize int64    `json:"maxDecompressedBodySize" mapstructure:"maxDecompressedBodySize"`
+	MaxBulkBatchSize        int      `json:"maxBulkBatchSize" mapstructure:"maxBulkBatchSize"`
+	MaxImportSize           int64    `json:"maxImportSize" mapstructure:"maxImportSize"`
+	ModerationWords         []string `json:"moderationWords" mapstructure:"moderationWords"`
+	ValidateCardProperties  bool     `json:"validateCardProperties" mapstructure:"validateCardProperties"`
+	TrashRetentionDays      int      `json:"trashRetentionDays" mapstructure:"trashRetentionDays"`
+	StoreCacheTTL           int      `json:"storeCacheTTL" mapstructure:"storeCacheTTL"`
+	PrewarmCaches           bool     `json:"prewarmCaches" mapstructure:"prewarmCaches"`
+	PrewarmTimeout          int      `json:"prewarmTimeout" mapstructure:"prewarmTimeout"`
 
 	AuthMode               string `json:"authMode" mapstructure:"authMode"`
 	MattermostURL          string `json:"mattermostURL" mapstructure:"mattermostURL"`
 	MattermostClientID     string `json:"mattermostClientID" mapstructure:"mattermostClientID"`
 	MattermostClientSecret string `json:"mattermostClientSecret" mapstructure:"mattermostClientSecret"`
+
+	MattermostClientSecretFile string `json:"mattermostClientSecretFile" mapstructure:"mattermostClientSecretFile"`
+
+	AuthHeader     string   `json:"authHeader" mapstructure:"authHeader"`
+	TrustedProxies []string `json:"trustedProxies" mapstructure:"trustedProxies"`
+
+	WebhookTemplates []WebhookTemplate `json:"webhook_templates" mapstructure:"webhook_templates"`
+
+	WebhookAllowPrivateTargets bool `json:"webhookAllowPrivateTargets" mapstructure:"webhookAllowPrivateTargets"`
+
+	AllowedUploadContentTypes []string `json:"allowedUploadContentTypes" mapstructure:"allowedUploadContentTypes"`
+	AllowedRedirectURLs       []string `json:"allowedRedirectURLs" mapstructure:"allowedRedirectURLs"`
+	TLSCipherSuites           []string `json:"tlsCipherSuites" mapstructure:"tlsCipherSuites"`
+	WebSocketProtocolVersions []int    `json:"webSocketProtocolVersions" mapstructure:"webSocketProtocolVersions"`
+
+	ResponseCacheTTLs        map[string]int `json:"responseCacheTTLs" mapstructure:"responseCacheTTLs"`
+	WebSocketReconnectDelays map[string]int `json:"webSocketReconnectDelays" mapstructure:"webSocketReconnectDelays"`
+
+	DBShards map[string]string `json:"dbShards" mapstructure:"dbShards"`
+	ShardMap map[string]string `json:"shardMap" mapstructure:"shardMap"`
 }
 
 // ReadConfigFile read the configuration from the filesystem.
@@ -48,19 +115,76 @@ func ReadConfigFile() (*Configuration, error) {
 	viper.SetDefault("Port", DefaultPort)
 	viper.SetDefault("DBType", "sqlite3")
 	viper.SetDefault("DBConfigString", "./focalboard.db")
+	viper.SetDefault("DBConfigFile", "")
 	viper.SetDefault("DBTablePrefix", "")
+	viper.SetDefault("DBConnectRetries", 0)       // fail at once if the database can't be reached
+	viper.SetDefault("DBConnectRetryInterval", 1) // seconds before the first retry, doubled after each one
 	viper.SetDefault("SecureCookie", false)
 	viper.SetDefault("WebPath", "./pack")
 	viper.SetDefault("FilesPath", "./files")
+	viper.SetDefault("MaxDecompressedBodySize", 100<<20) // bytes of a gzip request body once decompressed
+	viper.SetDefault("MaxBulkBatchSize", 10000)          // elements of the arrays of the bulk block endpoints
+	viper.SetDefault("MaxImportSize", 1<<30)             // bytes of a workspace bundle uploaded to the import
 	viper.SetDefault("Telemetry", true)
 	viper.SetDefault("WebhookUpdate", nil)
 	viper.SetDefault("SessionExpireTime", 60*60*24*30) // 30 days session lifetime
 	viper.SetDefault("SessionRefreshTime", 60*60*5)    // 5 minutes session refresh
+	viper.SetDefault("SessionDeviceBinding", false)
+	viper.SetDefault("ClockSkewTolerance", 60*5) // seconds the clock may be off before the sessions expire
 	viper.SetDefault("LocalOnly", false)
 	viper.SetDefault("EnableLocalMode", false)
 	viper.SetDefault("LocalModeSocketLocation", "/var/tmp/focalboard_local.socket")
+	viper.SetDefault("AutoBackupInterval", 0) // automatic backups disabled
+	viper.SetDefault("BackupDir", "./backups")
+	viper.SetDefault("BackupKeep", 7)
+	viper.SetDefault("BackupSchedule", "") // cron expression, takes precedence over AutoBackupInterval
+	viper.SetDefault("ServerTimezone", "UTC")
+	viper.SetDefault("SlowRequestThreshold", 0)  // milliseconds, slow request logging disabled
+	viper.SetDefault("PrometheusAddress", "")    // metrics server disabled
+	viper.SetDefault("MaxConcurrentRequests", 0) // no limit
+	viper.SetDefault("TCPKeepAlive", false)
+	viper.SetDefault("TCPKeepAlivePeriod", 180)  // seconds
+	viper.SetDefault("WebSocketAuthTimeout", 10) // seconds
+	viper.SetDefault("WebSocketMaxClients", 0)   // no limit
+	viper.SetDefault("MaxBoardsPerWorkspace", 0) // no limit
+	viper.SetDefault("WebhookRequestID", true)
+	viper.SetDefault("WebhookWorkers", 8)
+	viper.SetDefault("WebhookQueueSize", 1000)
+	viper.SetDefault("WebhookTimeout", 10) // seconds a webhook delivery may take
+	viper.SetDefault("WebhookAllowPrivateTargets", false)
+	viper.SetDefault("ValidateCardProperties", true)
+	viper.SetDefault("BroadcastCoalesceWindow", 0) // milliseconds, every change broadcast at once
+	viper.SetDefault("PasswordHashCost", 10)       // bcrypt cost
+	viper.SetDefault("UploadSessionTTL", 60*60*24) // seconds, unfinished uploads cleaned up after a day, 0 disables it
+	viper.SetDefault("ShutdownTimeout", 30)        // seconds to wait for the uploads in progress
+	viper.SetDefault("MigrateDryRun", false)
+	viper.SetDefault("ListenDuringMigration", true) // answer 503 until the migrations are done
+	viper.SetDefault("TLSMinVersion", "1.2")
+	viper.SetDefault("TelemetryTimeout", 10)       // seconds per request to the telemetry endpoint
+	viper.SetDefault("DeletedUserBoardsOwner", "") // username, must be given when deleting a user
+	viper.SetDefault("DefaultPageSize", 100)       // rows of the listings without a limit
+	viper.SetDefault("MaxPageSize", 1000)          // rows at most of any listing
+	viper.SetDefault("WorkspaceStorageQuota", 0)   // bytes of files per workspace, no limit
 
 	viper.SetDefault("AuthMode", "native")
+	viper.SetDefault("AuthHeader", "X-Auth-User") // only used by the header auth mode
+	viper.SetDefault("TrustedProxies", []string{})
+	viper.SetDefault("MattermostClientSecretFile", "")
+	viper.SetDefault("AllowedUploadContentTypes", []string{})      // all content types allowed
+	viper.SetDefault("AllowedRedirectURLs", []string{})            // only paths of the server allowed
+	viper.SetDefault("TLSCipherSuites", []string{})                // Go's default cipher suites
+	viper.SetDefault("ModerationWords", []string{})                // nothing rejected
+	viper.SetDefault("WebSocketProtocolVersions", []int{})         // every supported version
+	viper.SetDefault("ResponseCacheTTLs", map[string]int{})        // seconds per route template, nothing cached
+	viper.SetDefault("WebSocketReconnectDelays", map[string]int{}) // milliseconds per close cause, the defaults of the websocket server
+	viper.SetDefault("BroadcastWriteTimeout", 10000)               // milliseconds before dropping a slow client
+	viper.SetDefault("TrashRetentionDays", 30)                     // days in the trash before the boards are deleted for good, 0 keeps them
+	viper.SetDefault("StoreCacheTTL", 0)                           // seconds the settings and sessions are cached, 0 doesn't cache them
+	viper.SetDefault("PrewarmCaches", false)                       // load the store cache before reporting ready, with storeCacheTTL set
+	viper.SetDefault("PrewarmTimeout", 10)                         // seconds the warm-up may delay the readiness
+
+	viper.SetDefault("DBShards", map[string]string{}) // connection strings by shard name, no sharding
+	viper.SetDefault("ShardMap", map[string]string{}) // shard names by workspace ID, the others spread by hash
 
 	err := viper.ReadInConfig() // Find and read the config file
 	if err != nil {             // Handle errors reading the config file
@@ -74,18 +198,69 @@ func ReadConfigFile() (*Configuration, error) {
 		return nil, err
 	}
 
+	err = loadSecretFiles(&configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateWebhookTemplates(&configuration)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Println("readConfigFile")
 	log.Printf("%+v", removeSecurityData(configuration))
 
 	return &configuration, nil
 }
 
+// loadSecretFiles replaces the inline secrets with the contents of their
+// files when set, so secrets mounted as files by Docker or Kubernetes don't
+// need to be in the config file, the command line or the environment.
+func loadSecretFiles(config *Configuration) error {
+	if config.DBConfigFile != "" {
+		value, err := readSecretFile(config.DBConfigFile)
+		if err != nil {
+			return fmt.Errorf("unable to read dbconfigfile: %w", err)
+		}
+		config.DBConfigString = value
+	}
+
+	if config.MattermostClientSecretFile != "" {
+		value, err := readSecretFile(config.MattermostClientSecretFile)
+		if err != nil {
+			return fmt.Errorf("unable to read mattermostClientSecretFile: %w", err)
+		}
+		config.MattermostClientSecret = value
+	}
+
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Secret files usually end with a newline that isn't part of the secret
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return value, nil
+}
+
 //清楚安全数据
 func removeSecurityData(config Configuration) Configuration {
 	clean := config
 	clean.Secret = "hidden"
 	clean.MattermostClientID = "hidden"
 	clean.MattermostClientSecret = "hidden"
+	if clean.DBConfigFile != "" {
+		clean.DBConfigString = "hidden"
+	}
 
 	return clean
 }
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("file takes precedence over the inline value", func(t *testing.T) {
+		dsnFile := filepath.Join(dir, "dsn")
+		err := ioutil.WriteFile(dsnFile, []byte("postgres://user:secret@db/focalboard\n"), 0600)
+		require.NoError(t, err)
+
+		secretFile := filepath.Join(dir, "client-secret")
+		err = ioutil.WriteFile(secretFile, []byte("client-secret"), 0600)
+		require.NoError(t, err)
+
+		config := Configuration{
+			DBConfigString:             "./focalboard.db",
+			DBConfigFile:               dsnFile,
+			MattermostClientSecret:     "inline-secret",
+			MattermostClientSecretFile: secretFile,
+		}
+		err = loadSecretFiles(&config)
+		require.NoError(t, err)
+		require.Equal(t, "postgres://user:secret@db/focalboard", config.DBConfigString)
+		require.Equal(t, "client-secret", config.MattermostClientSecret)
+		require.Equal(t, "hidden", removeSecurityData(config).DBConfigString)
+	})
+
+	t.Run("inline values are kept without files", func(t *testing.T) {
+		config := Configuration{
+			DBConfigString:         "./focalboard.db",
+			MattermostClientSecret: "inline-secret",
+		}
+		err := loadSecretFiles(&config)
+		require.NoError(t, err)
+		require.Equal(t, "./focalboard.db", config.DBConfigString)
+		require.Equal(t, "inline-secret", config.MattermostClientSecret)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		config := Configuration{
+			DBConfigFile: filepath.Join(dir, "missing"),
+		}
+		err := loadSecretFiles(&config)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "dbconfigfile")
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		emptyFile := filepath.Join(dir, "empty")
+		err := ioutil.WriteFile(emptyFile, []byte("\n"), 0600)
+		require.NoError(t, err)
+
+		config := Configuration{
+			MattermostClientSecretFile: emptyFile,
+		}
+		err = loadSecretFiles(&config)
+		require.Error(t, err)
+	})
+}
+
+func TestValidateWebhookTemplates(t *testing.T) {
+	t.Run("valid templates", func(t *testing.T) {
+		config := Configuration{
+			WebhookTemplates: []WebhookTemplate{
+				{URL: "https://hooks.example.com/slack", Template: `{"text": {{json .Block.Title}}}`},
+			},
+		}
+		require.NoError(t, validateWebhookTemplates(&config))
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		config := Configuration{
+			WebhookTemplates: []WebhookTemplate{
+				{URL: "https://hooks.example.com/slack", Template: `{"text": {{upper .Block.Title}}}`},
+			},
+		}
+		err := validateWebhookTemplates(&config)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "https://hooks.example.com/slack")
+	})
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// DefaultWebhookTemplate sends the block as is, for the webhooks without a
+// payload template
+const DefaultWebhookTemplate = "{{json .Block}}"
+
+// webhookTemplateFuncs are available to the payload templates. json encodes
+// any value, so strings can be embedded in a payload safely.
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// WebhookTemplate sets the format of the payloads sent to a webhook URL. The
+// template is rendered with the event, see webhook.Event.
+type WebhookTemplate struct {
+	URL      string `json:"url" mapstructure:"url"`
+	Template string `json:"template" mapstructure:"template"`
+}
+
+// Parse compiles the payload template
+func (wt WebhookTemplate) Parse() (*template.Template, error) {
+	return template.New(wt.URL).Funcs(webhookTemplateFuncs).Parse(wt.Template)
+}
+
+// validateWebhookTemplates fails on the templates that don't compile, so a
+// bad template is found at startup instead of on the first delivery
+func validateWebhookTemplates(config *Configuration) error {
+	for _, wt := range config.WebhookTemplates {
+		if _, err := wt.Parse(); err != nil {
+			return fmt.Errorf("invalid webhook template for %s: %w", wt.URL, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	MetricsNamespace          = "focalboard"
+	MetricsSubsystemSystem    = "system"
+	MetricsSubsystemAPI       = "api"
+	MetricsSubsystemWebsocket = "websocket"
+	MetricsSubsystemDB        = "db"
+)
+
+// Metrics holds the server's Prometheus collectors in their own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	slowRequests     *prometheus.CounterVec
+	inFlightRequests prometheus.Gauge
+	shedRequests     prometheus.Counter
+}
+
+// NewMetrics creates the metrics collectors and registers them, along with
+// the standard process and Go runtime collectors.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+	}
+
+	m.registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{
+		Namespace: MetricsNamespace,
+	}))
+	m.registry.MustRegister(prometheus.NewGoCollector())
+
+	m.slowRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemAPI,
+		Name:      "slow_requests_total",
+		Help:      "Total number of requests that took longer than the slow request threshold.",
+	}, []string{"route"})
+	m.registry.MustRegister(m.slowRequests)
+
+	m.inFlightRequests = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemAPI,
+		Name:      "in_flight_requests",
+		Help:      "Number of requests currently being served.",
+	})
+	m.registry.MustRegister(m.inFlightRequests)
+
+	m.shedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemAPI,
+		Name:      "shed_requests_total",
+		Help:      "Total number of requests rejected because the server was at its concurrency limit.",
+	})
+	m.registry.MustRegister(m.shedRequests)
+
+	return m
+}
+
+// Registry returns the registry the collectors are registered in.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+func (m *Metrics) IncrementSlowRequests(route string) {
+	if m != nil {
+		m.slowRequests.WithLabelValues(route).Inc()
+	}
+}
+
+func (m *Metrics) IncrementInFlightRequests() {
+	if m != nil {
+		m.inFlightRequests.Inc()
+	}
+}
+
+func (m *Metrics) DecrementInFlightRequests() {
+	if m != nil {
+		m.inFlightRequests.Dec()
+	}
+}
+
+func (m *Metrics) IncrementShedRequests() {
+	if m != nil {
+		m.shedRequests.Inc()
+	}
+}
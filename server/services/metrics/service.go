@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Service serves the metrics in the Prometheus scrape format.
+type Service struct {
+	*http.Server
+}
+
+// NewMetricsServer creates the metrics server listening on the given address.
+func NewMetricsServer(address string, metrics *Metrics) *Service {
+	r := mux.NewRouter()
+	r.Handle("/metrics", promhttp.HandlerFor(metrics.Registry(), promhttp.HandlerOpts{}))
+
+	return &Service{
+		&http.Server{
+			Addr:    address,
+			Handler: r,
+		},
+	}
+}
+
+// Run starts the metrics server, blocking until it's closed.
+func (h *Service) Run() error {
+	return h.ListenAndServe()
+}
+
+// Shutdown closes the metrics server.
+func (h *Service) Shutdown() error {
+	return h.Close()
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sources are the server components the live gauges are read from, at
+// scrape time.
+type Sources struct {
+	WebsocketClients func() int
+	DBStats          func() sql.DBStats
+	ActiveUsers      func() (int, error)
+	StartTime        time.Time
+
+	// DBSize is optional, for the backends that can report their size
+	DBSize func() (int64, error)
+
+	// WebsocketBroadcastFailures is optional, the number of broadcast
+	// messages not written to a client
+	WebsocketBroadcastFailures func() int64
+}
+
+// RegisterSources registers the gauges read from the server components.
+func (m *Metrics) RegisterSources(sources Sources) {
+	gauge := func(subsystem, name, help string, value func() float64) {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, value))
+	}
+
+	gauge(MetricsSubsystemWebsocket, "clients", "Number of connected websocket clients.", func() float64 {
+		return float64(sources.WebsocketClients())
+	})
+
+	if sources.WebsocketBroadcastFailures != nil {
+		m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystemWebsocket,
+			Name:      "broadcast_failures_total",
+			Help:      "Total number of broadcast messages that couldn't be written to a client.",
+		}, func() float64 {
+			return float64(sources.WebsocketBroadcastFailures())
+		}))
+	}
+
+	gauge(MetricsSubsystemDB, "max_open_connections", "Maximum number of open connections to the database.", func() float64 {
+		return float64(sources.DBStats().MaxOpenConnections)
+	})
+	gauge(MetricsSubsystemDB, "open_connections", "Number of established connections to the database.", func() float64 {
+		return float64(sources.DBStats().OpenConnections)
+	})
+	gauge(MetricsSubsystemDB, "in_use_connections", "Number of database connections currently in use.", func() float64 {
+		return float64(sources.DBStats().InUse)
+	})
+	gauge(MetricsSubsystemDB, "idle_connections", "Number of idle database connections.", func() float64 {
+		return float64(sources.DBStats().Idle)
+	})
+	gauge(MetricsSubsystemDB, "wait_count", "Total number of connections waited for.", func() float64 {
+		return float64(sources.DBStats().WaitCount)
+	})
+
+	if sources.DBSize != nil {
+		gauge(MetricsSubsystemDB, "size_bytes", "Space used by the database, in bytes.", func() float64 {
+			size, err := sources.DBSize()
+			if err != nil {
+				log.Printf("Unable to get the database size for the metrics: %v", err)
+				return 0
+			}
+			return float64(size)
+		})
+	}
+
+	gauge(MetricsSubsystemSystem, "daily_active_users", "Number of users active in the last 24 hours.", func() float64 {
+		count, err := sources.ActiveUsers()
+		if err != nil {
+			log.Printf("Unable to get the active users for the metrics: %v", err)
+			return 0
+		}
+		return float64(count)
+	})
+
+	gauge(MetricsSubsystemSystem, "uptime_seconds", "Number of seconds since the server started.", func() float64 {
+		return time.Since(sources.StartTime).Seconds()
+	})
+}
+
+// Snapshot gathers the current value of the server metrics, without the
+// process and Go runtime ones, keyed by their name without the namespace.
+// Metrics with labels are added up.
+func (m *Metrics) Snapshot() (map[string]float64, error) {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := MetricsNamespace + "_"
+	snapshot := map[string]float64{}
+	for _, family := range families {
+		name := family.GetName()
+		if !strings.HasPrefix(name, prefix) || strings.HasPrefix(name, prefix+"process_") {
+			continue
+		}
+
+		var value float64
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.GetGauge() != nil:
+				value += metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				value += metric.GetCounter().GetValue()
+			}
+		}
+		snapshot[strings.TrimPrefix(name, prefix)] = value
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds how far ahead Next looks for a matching instant,
+// so impossible schedules like "0 0 30 2 *" don't loop forever
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// CronSchedule is a standard five field cron expression (minute, hour,
+// day of month, month and day of week), interpreted in a given location.
+type CronSchedule struct {
+	Spec     string
+	Location *time.Location
+
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// When both day fields are restricted a day matches if either does
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+// ParseCronSchedule parses a cron expression such as "0 3 * * *" (every day
+// at 3am). Fields accept "*", single values, ranges, lists and steps.
+func ParseCronSchedule(spec string, location *time.Location) (*CronSchedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron schedule %q: expected %d fields, got %d", spec, len(cronFields), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		bits[i], err = parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+		}
+	}
+
+	// Sunday can be written as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &CronSchedule{
+		Spec:          spec,
+		Location:      location,
+		minute:        bits[0],
+		hour:          bits[1],
+		dayOfMonth:    bits[2],
+		month:         bits[3],
+		dayOfWeek:     bits[4],
+		dayOfMonthAny: fields[2] == "*",
+		dayOfWeekAny:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s field %q", field.name, part)
+			}
+		}
+
+		start, end := field.min, field.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %s field %q", field.name, part)
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("invalid value in %s field %q", field.name, part)
+				}
+			} else if step > 1 {
+				end = field.max
+			}
+		}
+
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("%s field %q out of range %d-%d", field.name, part, field.min, field.max)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first instant strictly after t matching the schedule, or
+// the zero time if there's none within the next few years.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.Location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.Location))
+			continue
+		}
+
+		if !s.matchesDay(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.Location))
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.Location))
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// forward guards against time.Date normalizing a wall clock time that falls
+// in a DST gap to an instant before the current one
+func forward(current, next time.Time) time.Time {
+	if !next.After(current) {
+		return current.Add(time.Hour)
+	}
+
+	return next
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.dayOfMonthAny || s.dayOfWeekAny {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// CreateCronTask creates a task that runs every time the schedule fires.
+func CreateCronTask(name string, function TaskFunc, schedule *CronSchedule) *ScheduledTask {
+	task := &ScheduledTask{
+		Name:      name,
+		Recurring: true,
+		function:  function,
+		schedule:  schedule,
+		cancel:    make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(task.cancelled)
+
+		for {
+			next := schedule.Next(time.Now())
+			if next.IsZero() {
+				return
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				task.run()
+			case <-task.cancel:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return task
+}
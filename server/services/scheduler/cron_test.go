@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	valid := []string{"* * * * *", "0 3 * * *", "*/15 * * * *", "0 9-17/2 * * 1-5", "30 4 1,15 * 7"}
+	for _, spec := range valid {
+		_, err := ParseCronSchedule(spec, time.UTC)
+		assert.NoError(t, err, spec)
+	}
+
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "a * * * *", "5-1 * * * *"}
+	for _, spec := range invalid {
+		_, err := ParseCronSchedule(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	nightly, err := ParseCronSchedule("0 3 * * *", newYork)
+	require.NoError(t, err)
+
+	t.Run("fires at the local hour", func(t *testing.T) {
+		from := time.Date(2021, 1, 10, 12, 0, 0, 0, time.UTC)
+		next := nightly.Next(from)
+		// 3am EST is 08:00 UTC
+		assert.True(t, next.Equal(time.Date(2021, 1, 11, 8, 0, 0, 0, time.UTC)), next.String())
+	})
+
+	t.Run("follows daylight saving time", func(t *testing.T) {
+		from := time.Date(2021, 7, 10, 12, 0, 0, 0, time.UTC)
+		next := nightly.Next(from)
+		// 3am EDT is 07:00 UTC
+		assert.True(t, next.Equal(time.Date(2021, 7, 11, 7, 0, 0, 0, time.UTC)), next.String())
+	})
+
+	t.Run("across the spring forward gap", func(t *testing.T) {
+		schedule, err := ParseCronSchedule("30 2 * * *", newYork)
+		require.NoError(t, err)
+
+		// 2:30am doesn't exist on 2021-03-14 in New York
+		from := time.Date(2021, 3, 14, 5, 0, 0, 0, time.UTC)
+		next := schedule.Next(from)
+		assert.True(t, next.Equal(time.Date(2021, 3, 15, 6, 30, 0, 0, time.UTC)), next.String())
+	})
+
+	t.Run("strictly after the given time", func(t *testing.T) {
+		from := time.Date(2021, 1, 11, 3, 0, 0, 0, newYork)
+		next := nightly.Next(from)
+		assert.True(t, next.Equal(time.Date(2021, 1, 12, 3, 0, 0, 0, newYork)), next.String())
+	})
+
+	t.Run("day of month or day of week", func(t *testing.T) {
+		schedule, err := ParseCronSchedule("0 0 13 * 5", time.UTC)
+		require.NoError(t, err)
+
+		// Friday 2021-01-01 comes before the 13th
+		next := schedule.Next(time.Date(2020, 12, 31, 12, 0, 0, 0, time.UTC))
+		assert.True(t, next.Equal(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)), next.String())
+	})
+
+	t.Run("impossible schedule", func(t *testing.T) {
+		schedule, err := ParseCronSchedule("0 0 30 2 *", time.UTC)
+		require.NoError(t, err)
+		assert.True(t, schedule.Next(time.Now()).IsZero())
+	})
+}
+
+func TestCreateCronTask(t *testing.T) {
+	schedule, err := ParseCronSchedule("* * * * *", time.UTC)
+	require.NoError(t, err)
+
+	executionCount := new(int32)
+	testFunc := func() {
+		atomic.AddInt32(executionCount, 1)
+	}
+
+	task := CreateCronTask("Test Cron Task", testFunc, schedule)
+	assert.True(t, task.Recurring)
+
+	task.Cancel()
+	assert.EqualValues(t, 0, atomic.LoadInt32(executionCount))
+}
@@ -5,6 +5,9 @@ package scheduler
 
 import (
 	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,8 +18,12 @@ type ScheduledTask struct {
 	Interval  time.Duration `json:"interval"`
 	Recurring bool          `json:"recurring"`
 	function  func()
+	schedule  *CronSchedule
 	cancel    chan struct{}
 	cancelled chan struct{}
+	// running is 1 while an invocation of the function is in progress
+	running int32
+	runs    sync.WaitGroup
 }
 
 func CreateTask(name string, function TaskFunc, timeToExecution time.Duration) *ScheduledTask {
@@ -46,7 +53,7 @@ func createTask(name string, function TaskFunc, interval time.Duration, recurrin
 		for {
 			select {
 			case <-ticker.C:
-				function()
+				task.run()
 			case <-task.cancel:
 				return
 			}
@@ -60,12 +67,42 @@ func createTask(name string, function TaskFunc, interval time.Duration, recurrin
 	return task
 }
 
+// run invokes the function in the background, unless the previous
+// invocation is still running, in which case the tick is skipped so the runs
+// of a task never overlap
+func (task *ScheduledTask) run() {
+	if !atomic.CompareAndSwapInt32(&task.running, 0, 1) {
+		log.Printf("Skipping scheduled task %s, the previous run is still in progress", task.Name)
+		return
+	}
+
+	task.runs.Add(1)
+	go func() {
+		defer task.runs.Done()
+		defer atomic.StoreInt32(&task.running, 0)
+
+		task.function()
+	}()
+}
+
+// Cancel stops the task and waits for the invocation in progress, if any
 func (task *ScheduledTask) Cancel() {
 	close(task.cancel)
 	<-task.cancelled
+	task.runs.Wait()
 }
 
 func (task *ScheduledTask) String() string {
+	if task.schedule != nil {
+		return fmt.Sprintf(
+			"%s\nSchedule: %s (%s)\nRecurring: %t\n",
+			task.Name,
+			task.schedule.Spec,
+			task.schedule.Location,
+			task.Recurring,
+		)
+	}
+
 	return fmt.Sprintf(
 		"%s\nInterval: %s\nRecurring: %t\n",
 		task.Name,
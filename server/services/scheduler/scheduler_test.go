@@ -79,3 +79,32 @@ func TestCancelTask(t *testing.T) {
 	time.Sleep(taskTime + taskWait)
 	assert.EqualValues(t, 0, atomic.LoadInt32(executionCount))
 }
+
+func TestRecurringTaskRunsDontOverlap(t *testing.T) {
+	taskTime := time.Millisecond * 50
+
+	running := new(int32)
+	overlapped := new(int32)
+	executionCount := new(int32)
+	testFunc := func() {
+		if atomic.AddInt32(running, 1) > 1 {
+			atomic.StoreInt32(overlapped, 1)
+		}
+		atomic.AddInt32(executionCount, 1)
+		// Block for several intervals
+		time.Sleep(taskTime * 3)
+		atomic.AddInt32(running, -1)
+	}
+
+	task := CreateRecurringTask("Slow Task", testFunc, taskTime)
+
+	time.Sleep(taskTime * 10)
+	task.Cancel()
+
+	assert.EqualValues(t, 0, atomic.LoadInt32(overlapped))
+	assert.EqualValues(t, 0, atomic.LoadInt32(running))
+	// Some runs happened, but fewer than the ticks
+	count := atomic.LoadInt32(executionCount)
+	assert.GreaterOrEqual(t, count, int32(2))
+	assert.Less(t, count, int32(9))
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+)
+
+// The entries of the workspace bundles. Each board is in the export archive
+// format, and the files are listed with the boards they're attached to. The
+// legacy files, uploaded before the content of the files was stored by hash,
+// have no refs, so they're at "legacy-files/<boardID>/<fileID>".
+const (
+	BundleWorkspaceEntry    = "workspace.json"
+	BundleBoardsPrefix      = "boards/"
+	BundleFilesEntry        = "files.json"
+	BundleFilesPrefix       = "files/"
+	BundleLegacyFilesPrefix = "legacy-files/"
+)
+
+// ErrNoFilesBackend is returned when exporting a workspace from a store
+// without a files storage to read the files from
+var ErrNoFilesBackend = errors.New("no files storage to read the files from")
+
+// WriteWorkspaceBundle writes the zip bundle of a workspace from the store,
+// with its settings, its boards and the files uploaded to them, for the
+// stores to implement ExportWorkspace with. The workspace entry is written
+// even if the workspace has no settings, so the import can tell the
+// workspace the bundle comes from. The boards and the files are read and
+// written one at a time, so the bundle is streamed without holding the whole
+// workspace in memory. The legacy files are found through the fileId fields
+// of the blocks that have no file ref, at <workspaceID>/<boardID>/<fileID>
+// in the files storage.
+func WriteWorkspaceBundle(s Store, files filesstore.FileBackend, workspaceID string, w io.Writer) error {
+	c := Container{WorkspaceID: workspaceID}
+	bundle := zip.NewWriter(w)
+
+	workspace, err := s.GetWorkspace(workspaceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		workspace, err = &model.Workspace{ID: workspaceID}, nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeBundleJSON(bundle, BundleWorkspaceEntry, workspace); err != nil {
+		return err
+	}
+
+	refs, err := s.GetWorkspaceFileRefs(workspaceID)
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 && files == nil {
+		return ErrNoFilesBackend
+	}
+	refIDs := map[string]bool{}
+	for _, ref := range refs {
+		refIDs[ref.ID] = true
+	}
+
+	boards, err := s.GetBlocksWithType(c, "board")
+	if err != nil {
+		return err
+	}
+	for _, board := range boards {
+		blocks, err := s.GetBlocksWithRootID(c, board.ID)
+		if err != nil {
+			return err
+		}
+
+		archive := model.Archive{
+			Version: model.ArchiveVersion,
+			Date:    utils.GetMillis(),
+			Blocks:  blocks,
+		}
+		if err := writeBundleJSON(bundle, BundleBoardsPrefix+board.ID+".json", archive); err != nil {
+			return err
+		}
+		if err := writeBundleLegacyFiles(bundle, files, workspaceID, board.ID, blocks, refIDs); err != nil {
+			return err
+		}
+	}
+
+	if err := writeBundleJSON(bundle, BundleFilesEntry, refs); err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := writeBundleFile(bundle, files, ref); err != nil {
+			return err
+		}
+	}
+
+	return bundle.Close()
+}
+
+func writeBundleJSON(bundle *zip.Writer, name string, value interface{}) error {
+	entry, err := bundle.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(entry).Encode(value)
+}
+
+func writeBundleFile(bundle *zip.Writer, files filesstore.FileBackend, ref model.FileRef) error {
+	if err := writeBundleEntry(bundle, files, filepath.Join("blobs", ref.Hash[:2], ref.Hash), BundleFilesPrefix+ref.ID); err != nil {
+		return fmt.Errorf("unable to read file %s: %w", ref.ID, err)
+	}
+
+	return nil
+}
+
+// writeBundleEntry copies a file of the files storage into an entry
+func writeBundleEntry(bundle *zip.Writer, files filesstore.FileBackend, path, name string) error {
+	reader, err := files.Reader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	entry, err := bundle.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, reader)
+	return err
+}
+
+// writeBundleLegacyFiles writes the legacy files of the blocks of a board,
+// the ones their fileId fields name without a file ref, that are in the
+// files storage
+func writeBundleLegacyFiles(bundle *zip.Writer, files filesstore.FileBackend, workspaceID, boardID string, blocks []model.Block, refIDs map[string]bool) error {
+	written := map[string]bool{}
+	for _, block := range blocks {
+		fileID, _ := block.Fields["fileId"].(string)
+		if fileID == "" || refIDs[fileID] || written[fileID] {
+			continue
+		}
+		// The legacy files are looked up by name, so a name that isn't one
+		// can't be a file of the board
+		if fileID == "." || fileID == ".." || strings.ContainsAny(fileID, `/\`) {
+			continue
+		}
+		if files == nil {
+			return ErrNoFilesBackend
+		}
+		written[fileID] = true
+
+		path := filepath.Join(workspaceID, boardID, fileID)
+		exists, err := files.FileExists(path)
+		if err != nil {
+			return fmt.Errorf("unable to read file %s: %w", fileID, err)
+		}
+		if !exists {
+			continue
+		}
+		if err := writeBundleEntry(bundle, files, path, BundleLegacyFilesPrefix+boardID+"/"+fileID); err != nil {
+			return fmt.Errorf("unable to read file %s: %w", fileID, err)
+		}
+	}
+
+	return nil
+}
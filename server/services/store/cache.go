@@ -0,0 +1,290 @@
+package store
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// FeatureFlagPrefix starts the IDs of the system settings that are feature
+// flags, so the setting "FeatureFlag.Archive" is the flag "Archive". There
+// was no other place for the flags, so they're kept with the settings, and
+// read and pre-warmed with them.
+const FeatureFlagPrefix = "FeatureFlag."
+
+// cacheMaxSessions is the number of sessions the cache keeps, past which the
+// stale ones are dropped, or all of them if none is stale
+const cacheMaxSessions = 10000
+
+// FeatureFlags returns the feature flags among the system settings, by name
+func FeatureFlags(settings map[string]string) map[string]string {
+	flags := map[string]string{}
+	for id, value := range settings {
+		if strings.HasPrefix(id, FeatureFlagPrefix) {
+			flags[strings.TrimPrefix(id, FeatureFlagPrefix)] = value
+		}
+	}
+
+	return flags
+}
+
+// Cache is a store that keeps the system settings, with the feature flags,
+// and the sessions of the store it wraps in memory for up to the TTL, so the
+// lookups of every request don't all go to the database. The writes made
+// through the cache drop what it has of them, and the TTL bounds how long
+// the writes of the other servers take to show.
+type Cache struct {
+	// Store is the cached store, which serves the methods not cached below
+	Store
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu sync.Mutex
+	// The generations count the invalidations, so a read that raced with a
+	// write doesn't cache what it read before the write
+	settings    map[string]string
+	settingsAt  time.Time
+	settingsGen int
+	sessions    map[string]cachedSession
+	sessionsGen int
+}
+
+type cachedSession struct {
+	session  model.Session
+	cachedAt time.Time
+}
+
+// NewCache creates a cache over the store, keeping what it reads for the TTL
+func NewCache(s Store, ttl time.Duration) *Cache {
+	return &Cache{
+		Store:    s,
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: map[string]cachedSession{},
+	}
+}
+
+// CachedCounts returns the number of system settings and of sessions the
+// cache has, fresh or not
+func (c *Cache) CachedCounts() (settings, sessions int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.settings), len(c.sessions)
+}
+
+func (c *Cache) fresh(cachedAt time.Time) bool {
+	return c.now().Sub(cachedAt) < c.ttl
+}
+
+func (c *Cache) GetSystemSettings() (map[string]string, error) {
+	c.mu.Lock()
+	if c.settings != nil && c.fresh(c.settingsAt) {
+		settings := copySettings(c.settings)
+		c.mu.Unlock()
+		return settings, nil
+	}
+	gen := c.settingsGen
+	c.mu.Unlock()
+
+	settings, err := c.Store.GetSystemSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if gen == c.settingsGen {
+		c.settings = copySettings(settings)
+		c.settingsAt = c.now()
+	}
+	c.mu.Unlock()
+
+	return settings, nil
+}
+
+// GetFeatureFlags returns the feature flags of the cached system settings
+func (c *Cache) GetFeatureFlags() (map[string]string, error) {
+	settings, err := c.GetSystemSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return FeatureFlags(settings), nil
+}
+
+func (c *Cache) SetSystemSetting(key, value string) error {
+	defer c.invalidateSettings()
+	return c.Store.SetSystemSetting(key, value)
+}
+
+func (c *Cache) CreateSystemSettingIfNotExists(key, value string) error {
+	defer c.invalidateSettings()
+	return c.Store.CreateSystemSettingIfNotExists(key, value)
+}
+
+// UpgradeBlockData stores the schema version of the blocks it upgraded in
+// the system settings
+func (c *Cache) UpgradeBlockData() (int, error) {
+	defer c.invalidateSettings()
+	return c.Store.UpgradeBlockData()
+}
+
+func (c *Cache) invalidateSettings() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settings = nil
+	c.settingsGen++
+}
+
+// GetSession returns the cached session of the token if it's fresh and
+// hasn't expired, or else reads and caches it
+func (c *Cache) GetSession(token string, expireTime int64) (*model.Session, error) {
+	c.mu.Lock()
+	if cached, ok := c.sessions[token]; ok && c.fresh(cached.cachedAt) && cached.session.UpdateAt > c.now().Unix()-expireTime {
+		session := copySession(cached.session)
+		c.mu.Unlock()
+		return session, nil
+	}
+	gen := c.sessionsGen
+	c.mu.Unlock()
+
+	session, err := c.Store.GetSession(token, expireTime)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheSessions(gen, session)
+
+	return session, nil
+}
+
+// GetRecentSessions caches the sessions it returns, which is how the cache
+// is warmed up with the sessions of the first requests
+func (c *Cache) GetRecentSessions(updatedSecondsAgo int64, limit int) ([]*model.Session, error) {
+	c.mu.Lock()
+	gen := c.sessionsGen
+	c.mu.Unlock()
+
+	sessions, err := c.Store.GetRecentSessions(updatedSecondsAgo, limit)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheSessions(gen, sessions...)
+
+	return sessions, nil
+}
+
+func (c *Cache) cacheSessions(gen int, sessions ...*model.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.sessionsGen {
+		return
+	}
+
+	now := c.now()
+	for _, session := range sessions {
+		if len(c.sessions) >= cacheMaxSessions {
+			for token, cached := range c.sessions {
+				if !c.fresh(cached.cachedAt) {
+					delete(c.sessions, token)
+				}
+			}
+			if len(c.sessions) >= cacheMaxSessions {
+				c.sessions = map[string]cachedSession{}
+			}
+		}
+		c.sessions[session.Token] = cachedSession{session: *copySession(*session), cachedAt: now}
+	}
+}
+
+func (c *Cache) RefreshSession(session *model.Session) error {
+	defer c.invalidateSession(session.Token)
+	return c.Store.RefreshSession(session)
+}
+
+func (c *Cache) UpdateSession(session *model.Session) error {
+	defer c.invalidateSession(session.Token)
+	return c.Store.UpdateSession(session)
+}
+
+func (c *Cache) DeleteSession(sessionID string) error {
+	defer c.invalidateSessions(func(session model.Session) bool { return session.ID == sessionID })
+	return c.Store.DeleteSession(sessionID)
+}
+
+func (c *Cache) CleanUpSessions(expireTime int64) (int64, error) {
+	defer c.invalidateSessions(nil)
+	return c.Store.CleanUpSessions(expireTime)
+}
+
+func (c *Cache) DeleteSessionsForDeletedUsers() (int64, error) {
+	defer c.invalidateSessions(nil)
+	return c.Store.DeleteSessionsForDeletedUsers()
+}
+
+// DeleteUser deletes the sessions of the user too
+func (c *Cache) DeleteUser(userID string) error {
+	defer c.invalidateSessions(func(session model.Session) bool { return session.UserID == userID })
+	return c.Store.DeleteUser(userID)
+}
+
+// DeleteUserReassigningBoards deletes the sessions of the user too
+func (c *Cache) DeleteUserReassigningBoards(userID, boardsOwnerID string) (int64, error) {
+	defer c.invalidateSessions(func(session model.Session) bool { return session.UserID == userID })
+	return c.Store.DeleteUserReassigningBoards(userID, boardsOwnerID)
+}
+
+// AnonymizeUser deletes the sessions of the user too
+func (c *Cache) AnonymizeUser(userID string) error {
+	defer c.invalidateSessions(func(session model.Session) bool { return session.UserID == userID })
+	return c.Store.AnonymizeUser(userID)
+}
+
+func (c *Cache) invalidateSession(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sessions, token)
+	c.sessionsGen++
+}
+
+// invalidateSessions drops the cached sessions that match, or all of them if
+// there's no match function
+func (c *Cache) invalidateSessions(match func(session model.Session) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for token, cached := range c.sessions {
+		if match == nil || match(cached.session) {
+			delete(c.sessions, token)
+		}
+	}
+	c.sessionsGen++
+}
+
+func copySettings(settings map[string]string) map[string]string {
+	copied := make(map[string]string, len(settings))
+	for id, value := range settings {
+		copied[id] = value
+	}
+
+	return copied
+}
+
+// copySession returns a copy of the session that doesn't share its props,
+// so the callers can't change the cached session
+func copySession(session model.Session) *model.Session {
+	if session.Props != nil {
+		props := make(map[string]interface{}, len(session.Props))
+		for key, value := range session.Props {
+			props[key] = value
+		}
+		session.Props = props
+	}
+
+	return &session
+}
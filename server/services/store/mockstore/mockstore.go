@@ -5,11 +5,15 @@
 package mockstore
 
 import (
+	sql "database/sql"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/mattermost/focalboard/server/model"
 	store "github.com/mattermost/focalboard/server/services/store"
+	filesstore "github.com/mattermost/mattermost-server/v5/services/filesstore"
 )
 
 // MockStore is a mock of Store interface.
@@ -35,149 +39,959 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
-// CleanUpSessions mocks base method.
-func (m *MockStore) CleanUpSessions(arg0 int64) error {
+// AcquireBoardLock mocks base method.
+func (m *MockStore) AcquireBoardLock(arg0 store.Container, arg1, arg2 string, arg3 time.Duration) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CleanUpSessions", arg0)
+	ret := m.ctrl.Call(m, "AcquireBoardLock", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireBoardLock indicates an expected call of AcquireBoardLock.
+func (mr *MockStoreMockRecorder) AcquireBoardLock(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireBoardLock", reflect.TypeOf((*MockStore)(nil).AcquireBoardLock), arg0, arg1, arg2, arg3)
+}
+
+// AnonymizeUser mocks base method.
+func (m *MockStore) AnonymizeUser(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockStoreMockRecorder) AnonymizeUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockStore)(nil).AnonymizeUser), arg0)
+}
+
+// ArchiveBoard mocks base method.
+func (m *MockStore) ArchiveBoard(arg0 store.Container, arg1 string, arg2 func([]model.Block) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveBoard", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveBoard indicates an expected call of ArchiveBoard.
+func (mr *MockStoreMockRecorder) ArchiveBoard(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveBoard", reflect.TypeOf((*MockStore)(nil).ArchiveBoard), arg0, arg1, arg2)
+}
+
+// BackupDatabase mocks base method.
+func (m *MockStore) BackupDatabase(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackupDatabase", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// BackupDatabase indicates an expected call of BackupDatabase.
+func (mr *MockStoreMockRecorder) BackupDatabase(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackupDatabase", reflect.TypeOf((*MockStore)(nil).BackupDatabase), arg0)
+}
+
+// BlockExists mocks base method.
+func (m *MockStore) BlockExists(arg0 store.Container, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockExists", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockExists indicates an expected call of BlockExists.
+func (mr *MockStoreMockRecorder) BlockExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockExists", reflect.TypeOf((*MockStore)(nil).BlockExists), arg0, arg1)
+}
+
+// BoardExists mocks base method.
+func (m *MockStore) BoardExists(arg0 store.Container, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BoardExists", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BoardExists indicates an expected call of BoardExists.
+func (mr *MockStoreMockRecorder) BoardExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BoardExists", reflect.TypeOf((*MockStore)(nil).BoardExists), arg0, arg1)
+}
+
+// CleanUpSessions mocks base method.
+func (m *MockStore) CleanUpSessions(arg0 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanUpSessions", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // CleanUpSessions indicates an expected call of CleanUpSessions.
 func (mr *MockStoreMockRecorder) CleanUpSessions(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUpSessions", reflect.TypeOf((*MockStore)(nil).CleanUpSessions), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUpSessions", reflect.TypeOf((*MockStore)(nil).CleanUpSessions), arg0)
+}
+
+// CopyCard mocks base method.
+func (m *MockStore) CopyCard(arg0 store.Container, arg1 model.Block, arg2 []model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyCard", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyCard indicates an expected call of CopyCard.
+func (mr *MockStoreMockRecorder) CopyCard(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyCard", reflect.TypeOf((*MockStore)(nil).CopyCard), arg0, arg1, arg2)
+}
+
+// CountBlocksByBoard mocks base method.
+func (m *MockStore) CountBlocksByBoard(arg0 store.Container) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBlocksByBoard", arg0)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBlocksByBoard indicates an expected call of CountBlocksByBoard.
+func (mr *MockStoreMockRecorder) CountBlocksByBoard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBlocksByBoard", reflect.TypeOf((*MockStore)(nil).CountBlocksByBoard), arg0)
+}
+
+// CountBoards mocks base method.
+func (m *MockStore) CountBoards(arg0 store.Container) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBoards", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBoards indicates an expected call of CountBoards.
+func (mr *MockStoreMockRecorder) CountBoards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBoards", reflect.TypeOf((*MockStore)(nil).CountBoards), arg0)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStore) CreateAuditLog(arg0 model.AuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStoreMockRecorder) CreateAuditLog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStore)(nil).CreateAuditLog), arg0)
+}
+
+// CreateBoardSnapshot mocks base method.
+func (m *MockStore) CreateBoardSnapshot(arg0 store.Container, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBoardSnapshot", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBoardSnapshot indicates an expected call of CreateBoardSnapshot.
+func (mr *MockStoreMockRecorder) CreateBoardSnapshot(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBoardSnapshot", reflect.TypeOf((*MockStore)(nil).CreateBoardSnapshot), arg0, arg1, arg2)
+}
+
+// CreateBoardWithDefaults mocks base method.
+func (m *MockStore) CreateBoardWithDefaults(arg0 store.Container, arg1 model.Block, arg2 []model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBoardWithDefaults", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBoardWithDefaults indicates an expected call of CreateBoardWithDefaults.
+func (mr *MockStoreMockRecorder) CreateBoardWithDefaults(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBoardWithDefaults", reflect.TypeOf((*MockStore)(nil).CreateBoardWithDefaults), arg0, arg1, arg2)
+}
+
+// CreateFileRef mocks base method.
+func (m *MockStore) CreateFileRef(arg0 model.FileRef, arg1 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileRef", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileRef indicates an expected call of CreateFileRef.
+func (mr *MockStoreMockRecorder) CreateFileRef(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileRef", reflect.TypeOf((*MockStore)(nil).CreateFileRef), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 *model.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0)
+}
+
+// CreateSystemSettingIfNotExists mocks base method.
+func (m *MockStore) CreateSystemSettingIfNotExists(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSystemSettingIfNotExists", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSystemSettingIfNotExists indicates an expected call of CreateSystemSettingIfNotExists.
+func (mr *MockStoreMockRecorder) CreateSystemSettingIfNotExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSystemSettingIfNotExists", reflect.TypeOf((*MockStore)(nil).CreateSystemSettingIfNotExists), arg0, arg1)
+}
+
+// CreateUploadSession mocks base method.
+func (m *MockStore) CreateUploadSession(arg0 model.UploadSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUploadSession", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUploadSession indicates an expected call of CreateUploadSession.
+func (mr *MockStoreMockRecorder) CreateUploadSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUploadSession", reflect.TypeOf((*MockStore)(nil).CreateUploadSession), arg0)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0)
+}
+
+// CreateUsers mocks base method.
+func (m *MockStore) CreateUsers(arg0 []model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockStoreMockRecorder) CreateUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockStore)(nil).CreateUsers), arg0)
+}
+
+// CreateWorkspaceWebhook mocks base method.
+func (m *MockStore) CreateWorkspaceWebhook(arg0 model.WorkspaceWebhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWorkspaceWebhook", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWorkspaceWebhook indicates an expected call of CreateWorkspaceWebhook.
+func (mr *MockStoreMockRecorder) CreateWorkspaceWebhook(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorkspaceWebhook", reflect.TypeOf((*MockStore)(nil).CreateWorkspaceWebhook), arg0)
+}
+
+// DBStats mocks base method.
+func (m *MockStore) DBStats() sql.DBStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBStats")
+	ret0, _ := ret[0].(sql.DBStats)
+	return ret0
+}
+
+// DBStats indicates an expected call of DBStats.
+func (mr *MockStoreMockRecorder) DBStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBStats", reflect.TypeOf((*MockStore)(nil).DBStats))
+}
+
+// DeleteBlock mocks base method.
+func (m *MockStore) DeleteBlock(arg0 store.Container, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlock indicates an expected call of DeleteBlock.
+func (mr *MockStoreMockRecorder) DeleteBlock(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlock", reflect.TypeOf((*MockStore)(nil).DeleteBlock), arg0, arg1, arg2)
+}
+
+// DeleteExpiredSharingTokens mocks base method.
+func (m *MockStore) DeleteExpiredSharingTokens(arg0 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSharingTokens", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSharingTokens indicates an expected call of DeleteExpiredSharingTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredSharingTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSharingTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSharingTokens), arg0)
+}
+
+// DeleteFileRef mocks base method.
+func (m *MockStore) DeleteFileRef(arg0 string, arg1 func(string) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFileRef", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFileRef indicates an expected call of DeleteFileRef.
+func (mr *MockStoreMockRecorder) DeleteFileRef(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileRef", reflect.TypeOf((*MockStore)(nil).DeleteFileRef), arg0, arg1)
+}
+
+// DeleteSession mocks base method.
+func (m *MockStore) DeleteSession(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStoreMockRecorder) DeleteSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStore)(nil).DeleteSession), arg0)
+}
+
+// DeleteSessionsForDeletedUsers mocks base method.
+func (m *MockStore) DeleteSessionsForDeletedUsers() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionsForDeletedUsers")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSessionsForDeletedUsers indicates an expected call of DeleteSessionsForDeletedUsers.
+func (mr *MockStoreMockRecorder) DeleteSessionsForDeletedUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionsForDeletedUsers", reflect.TypeOf((*MockStore)(nil).DeleteSessionsForDeletedUsers))
+}
+
+// DeleteUploadSession mocks base method.
+func (m *MockStore) DeleteUploadSession(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUploadSession", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUploadSession indicates an expected call of DeleteUploadSession.
+func (mr *MockStoreMockRecorder) DeleteUploadSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUploadSession", reflect.TypeOf((*MockStore)(nil).DeleteUploadSession), arg0)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStore) DeleteUser(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStoreMockRecorder) DeleteUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), arg0)
+}
+
+// DeleteUserReassigningBoards mocks base method.
+func (m *MockStore) DeleteUserReassigningBoards(arg0, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserReassigningBoards", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserReassigningBoards indicates an expected call of DeleteUserReassigningBoards.
+func (mr *MockStoreMockRecorder) DeleteUserReassigningBoards(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserReassigningBoards", reflect.TypeOf((*MockStore)(nil).DeleteUserReassigningBoards), arg0, arg1)
+}
+
+// DeleteWorkspaceWebhook mocks base method.
+func (m *MockStore) DeleteWorkspaceWebhook(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWorkspaceWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWorkspaceWebhook indicates an expected call of DeleteWorkspaceWebhook.
+func (mr *MockStoreMockRecorder) DeleteWorkspaceWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorkspaceWebhook", reflect.TypeOf((*MockStore)(nil).DeleteWorkspaceWebhook), arg0, arg1)
+}
+
+// ExportWorkspace mocks base method.
+func (m *MockStore) ExportWorkspace(arg0 string, arg1 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportWorkspace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportWorkspace indicates an expected call of ExportWorkspace.
+func (mr *MockStoreMockRecorder) ExportWorkspace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportWorkspace", reflect.TypeOf((*MockStore)(nil).ExportWorkspace), arg0, arg1)
+}
+
+// FindDuplicateBoards mocks base method.
+func (m *MockStore) FindDuplicateBoards(arg0 store.Container) ([]model.DuplicateBoards, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicateBoards", arg0)
+	ret0, _ := ret[0].([]model.DuplicateBoards)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicateBoards indicates an expected call of FindDuplicateBoards.
+func (mr *MockStoreMockRecorder) FindDuplicateBoards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicateBoards", reflect.TypeOf((*MockStore)(nil).FindDuplicateBoards), arg0)
+}
+
+// FindOrphanedFiles mocks base method.
+func (m *MockStore) FindOrphanedFiles() ([]model.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrphanedFiles")
+	ret0, _ := ret[0].([]model.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOrphanedFiles indicates an expected call of FindOrphanedFiles.
+func (mr *MockStoreMockRecorder) FindOrphanedFiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrphanedFiles", reflect.TypeOf((*MockStore)(nil).FindOrphanedFiles))
+}
+
+// GetAbandonedUploadSessions mocks base method.
+func (m *MockStore) GetAbandonedUploadSessions(arg0 int64) ([]model.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAbandonedUploadSessions", arg0)
+	ret0, _ := ret[0].([]model.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAbandonedUploadSessions indicates an expected call of GetAbandonedUploadSessions.
+func (mr *MockStoreMockRecorder) GetAbandonedUploadSessions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAbandonedUploadSessions", reflect.TypeOf((*MockStore)(nil).GetAbandonedUploadSessions), arg0)
+}
+
+// GetActiveSharingTokens mocks base method.
+func (m *MockStore) GetActiveSharingTokens(arg0 store.Container, arg1 int64) ([]model.Sharing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveSharingTokens", arg0, arg1)
+	ret0, _ := ret[0].([]model.Sharing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveSharingTokens indicates an expected call of GetActiveSharingTokens.
+func (mr *MockStoreMockRecorder) GetActiveSharingTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveSharingTokens", reflect.TypeOf((*MockStore)(nil).GetActiveSharingTokens), arg0, arg1)
+}
+
+// GetActiveUserCount mocks base method.
+func (m *MockStore) GetActiveUserCount(arg0 int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveUserCount", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveUserCount indicates an expected call of GetActiveUserCount.
+func (mr *MockStoreMockRecorder) GetActiveUserCount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveUserCount", reflect.TypeOf((*MockStore)(nil).GetActiveUserCount), arg0)
+}
+
+// GetAllBlocks mocks base method.
+func (m *MockStore) GetAllBlocks(arg0 store.Container) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllBlocks", arg0)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllBlocks indicates an expected call of GetAllBlocks.
+func (mr *MockStoreMockRecorder) GetAllBlocks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllBlocks", reflect.TypeOf((*MockStore)(nil).GetAllBlocks), arg0)
+}
+
+// GetAuditLogs mocks base method.
+func (m *MockStore) GetAuditLogs(arg0 store.AuditFilter, arg1, arg2 int) ([]model.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogs", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogs indicates an expected call of GetAuditLogs.
+func (mr *MockStoreMockRecorder) GetAuditLogs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogs", reflect.TypeOf((*MockStore)(nil).GetAuditLogs), arg0, arg1, arg2)
+}
+
+// GetBlocksByIDs mocks base method.
+func (m *MockStore) GetBlocksByIDs(arg0 store.Container, arg1 []string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksByIDs indicates an expected call of GetBlocksByIDs.
+func (mr *MockStoreMockRecorder) GetBlocksByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksByIDs", reflect.TypeOf((*MockStore)(nil).GetBlocksByIDs), arg0, arg1)
+}
+
+// GetBlocksByMigrationFlag mocks base method.
+func (m *MockStore) GetBlocksByMigrationFlag(arg0 string) ([]model.BlockMigrationFlag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksByMigrationFlag", arg0)
+	ret0, _ := ret[0].([]model.BlockMigrationFlag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksByMigrationFlag indicates an expected call of GetBlocksByMigrationFlag.
+func (mr *MockStoreMockRecorder) GetBlocksByMigrationFlag(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksByMigrationFlag", reflect.TypeOf((*MockStore)(nil).GetBlocksByMigrationFlag), arg0)
+}
+
+// GetBlocksByProperty mocks base method.
+func (m *MockStore) GetBlocksByProperty(arg0 store.Container, arg1, arg2, arg3 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksByProperty", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksByProperty indicates an expected call of GetBlocksByProperty.
+func (mr *MockStoreMockRecorder) GetBlocksByProperty(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksByProperty", reflect.TypeOf((*MockStore)(nil).GetBlocksByProperty), arg0, arg1, arg2, arg3)
+}
+
+// GetBlocksModifiedBy mocks base method.
+func (m *MockStore) GetBlocksModifiedBy(arg0 store.Container, arg1 string, arg2 int64, arg3 int) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksModifiedBy", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksModifiedBy indicates an expected call of GetBlocksModifiedBy.
+func (mr *MockStoreMockRecorder) GetBlocksModifiedBy(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksModifiedBy", reflect.TypeOf((*MockStore)(nil).GetBlocksModifiedBy), arg0, arg1, arg2, arg3)
+}
+
+// GetBlocksWithParent mocks base method.
+func (m *MockStore) GetBlocksWithParent(arg0 store.Container, arg1 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksWithParent", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksWithParent indicates an expected call of GetBlocksWithParent.
+func (mr *MockStoreMockRecorder) GetBlocksWithParent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithParent", reflect.TypeOf((*MockStore)(nil).GetBlocksWithParent), arg0, arg1)
+}
+
+// GetBlocksWithParentAndType mocks base method.
+func (m *MockStore) GetBlocksWithParentAndType(arg0 store.Container, arg1, arg2 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksWithParentAndType", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksWithParentAndType indicates an expected call of GetBlocksWithParentAndType.
+func (mr *MockStoreMockRecorder) GetBlocksWithParentAndType(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithParentAndType", reflect.TypeOf((*MockStore)(nil).GetBlocksWithParentAndType), arg0, arg1, arg2)
+}
+
+// GetBlocksWithParentAndTypeSorted mocks base method.
+func (m *MockStore) GetBlocksWithParentAndTypeSorted(arg0 store.Container, arg1, arg2 string, arg3 store.BlockSort) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksWithParentAndTypeSorted", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksWithParentAndTypeSorted indicates an expected call of GetBlocksWithParentAndTypeSorted.
+func (mr *MockStoreMockRecorder) GetBlocksWithParentAndTypeSorted(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithParentAndTypeSorted", reflect.TypeOf((*MockStore)(nil).GetBlocksWithParentAndTypeSorted), arg0, arg1, arg2, arg3)
+}
+
+// GetBlocksWithRootID mocks base method.
+func (m *MockStore) GetBlocksWithRootID(arg0 store.Container, arg1 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksWithRootID", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksWithRootID indicates an expected call of GetBlocksWithRootID.
+func (mr *MockStoreMockRecorder) GetBlocksWithRootID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithRootID", reflect.TypeOf((*MockStore)(nil).GetBlocksWithRootID), arg0, arg1)
+}
+
+// GetBlocksWithType mocks base method.
+func (m *MockStore) GetBlocksWithType(arg0 store.Container, arg1 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksWithType", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksWithType indicates an expected call of GetBlocksWithType.
+func (mr *MockStoreMockRecorder) GetBlocksWithType(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithType", reflect.TypeOf((*MockStore)(nil).GetBlocksWithType), arg0, arg1)
+}
+
+// GetBoardActivity mocks base method.
+func (m *MockStore) GetBoardActivity(arg0 store.Container, arg1 string, arg2 int, arg3 int64, arg4 string) ([]model.BlockActivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardActivity", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.BlockActivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardActivity indicates an expected call of GetBoardActivity.
+func (mr *MockStoreMockRecorder) GetBoardActivity(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardActivity", reflect.TypeOf((*MockStore)(nil).GetBoardActivity), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetBoardAggregates mocks base method.
+func (m *MockStore) GetBoardAggregates(arg0 store.Container, arg1, arg2 string) (*model.BoardAggregates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardAggregates", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.BoardAggregates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardAggregates indicates an expected call of GetBoardAggregates.
+func (mr *MockStoreMockRecorder) GetBoardAggregates(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardAggregates", reflect.TypeOf((*MockStore)(nil).GetBoardAggregates), arg0, arg1, arg2)
+}
+
+// GetBoardChecksum mocks base method.
+func (m *MockStore) GetBoardChecksum(arg0 store.Container, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardChecksum", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardChecksum indicates an expected call of GetBoardChecksum.
+func (mr *MockStoreMockRecorder) GetBoardChecksum(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardChecksum", reflect.TypeOf((*MockStore)(nil).GetBoardChecksum), arg0, arg1)
+}
+
+// GetBoardSnapshot mocks base method.
+func (m *MockStore) GetBoardSnapshot(arg0 store.Container, arg1 string) (*model.BoardSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardSnapshot", arg0, arg1)
+	ret0, _ := ret[0].(*model.BoardSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardSnapshot indicates an expected call of GetBoardSnapshot.
+func (mr *MockStoreMockRecorder) GetBoardSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardSnapshot", reflect.TypeOf((*MockStore)(nil).GetBoardSnapshot), arg0, arg1)
+}
+
+// GetBoardSnapshots mocks base method.
+func (m *MockStore) GetBoardSnapshots(arg0 store.Container, arg1 string) ([]model.BoardSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardSnapshots", arg0, arg1)
+	ret0, _ := ret[0].([]model.BoardSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardSnapshots indicates an expected call of GetBoardSnapshots.
+func (mr *MockStoreMockRecorder) GetBoardSnapshots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardSnapshots", reflect.TypeOf((*MockStore)(nil).GetBoardSnapshots), arg0, arg1)
+}
+
+// GetBoardsMetadata mocks base method.
+func (m *MockStore) GetBoardsMetadata(arg0 store.Container, arg1 []string) ([]model.BoardMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoardsMetadata", arg0, arg1)
+	ret0, _ := ret[0].([]model.BoardMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoardsMetadata indicates an expected call of GetBoardsMetadata.
+func (mr *MockStoreMockRecorder) GetBoardsMetadata(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoardsMetadata", reflect.TypeOf((*MockStore)(nil).GetBoardsMetadata), arg0, arg1)
+}
+
+// GetCardsAssignedTo mocks base method.
+func (m *MockStore) GetCardsAssignedTo(arg0 store.Container, arg1 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardsAssignedTo", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCardsAssignedTo indicates an expected call of GetCardsAssignedTo.
+func (mr *MockStoreMockRecorder) GetCardsAssignedTo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardsAssignedTo", reflect.TypeOf((*MockStore)(nil).GetCardsAssignedTo), arg0, arg1)
+}
+
+// GetComments mocks base method.
+func (m *MockStore) GetComments(arg0 store.Container, arg1 string, arg2 int, arg3 int64, arg4 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockStoreMockRecorder) GetComments(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockStore)(nil).GetComments), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetDatabaseSize mocks base method.
+func (m *MockStore) GetDatabaseSize() (store.DBSize, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDatabaseSize")
+	ret0, _ := ret[0].(store.DBSize)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDatabaseSize indicates an expected call of GetDatabaseSize.
+func (mr *MockStoreMockRecorder) GetDatabaseSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDatabaseSize", reflect.TypeOf((*MockStore)(nil).GetDatabaseSize))
 }
 
-// CreateSession mocks base method.
-func (m *MockStore) CreateSession(arg0 *model.Session) error {
+// GetDeletedBoards mocks base method.
+func (m *MockStore) GetDeletedBoards(arg0 string) ([]model.Block, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetDeletedBoards", arg0)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// CreateSession indicates an expected call of CreateSession.
-func (mr *MockStoreMockRecorder) CreateSession(arg0 interface{}) *gomock.Call {
+// GetDeletedBoards indicates an expected call of GetDeletedBoards.
+func (mr *MockStoreMockRecorder) GetDeletedBoards(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedBoards", reflect.TypeOf((*MockStore)(nil).GetDeletedBoards), arg0)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 *model.User) error {
+// GetDeletedBoardsBefore mocks base method.
+func (m *MockStore) GetDeletedBoardsBefore(arg0 int64) ([]model.DeletedBoard, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetDeletedBoardsBefore", arg0)
+	ret0, _ := ret[0].([]model.DeletedBoard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+// GetDeletedBoardsBefore indicates an expected call of GetDeletedBoardsBefore.
+func (mr *MockStoreMockRecorder) GetDeletedBoardsBefore(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedBoardsBefore", reflect.TypeOf((*MockStore)(nil).GetDeletedBoardsBefore), arg0)
 }
 
-// DeleteBlock mocks base method.
-func (m *MockStore) DeleteBlock(arg0 store.Container, arg1, arg2 string) error {
+// GetExistingBoards mocks base method.
+func (m *MockStore) GetExistingBoards(arg0 store.Container, arg1 []string) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteBlock", arg0, arg1, arg2)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetExistingBoards", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteBlock indicates an expected call of DeleteBlock.
-func (mr *MockStoreMockRecorder) DeleteBlock(arg0, arg1, arg2 interface{}) *gomock.Call {
+// GetExistingBoards indicates an expected call of GetExistingBoards.
+func (mr *MockStoreMockRecorder) GetExistingBoards(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlock", reflect.TypeOf((*MockStore)(nil).DeleteBlock), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExistingBoards", reflect.TypeOf((*MockStore)(nil).GetExistingBoards), arg0, arg1)
 }
 
-// DeleteSession mocks base method.
-func (m *MockStore) DeleteSession(arg0 string) error {
+// GetExpiredSessionIDs mocks base method.
+func (m *MockStore) GetExpiredSessionIDs(arg0 int64) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSession", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetExpiredSessionIDs", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteSession indicates an expected call of DeleteSession.
-func (mr *MockStoreMockRecorder) DeleteSession(arg0 interface{}) *gomock.Call {
+// GetExpiredSessionIDs indicates an expected call of GetExpiredSessionIDs.
+func (mr *MockStoreMockRecorder) GetExpiredSessionIDs(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStore)(nil).DeleteSession), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredSessionIDs", reflect.TypeOf((*MockStore)(nil).GetExpiredSessionIDs), arg0)
 }
 
-// GetActiveUserCount mocks base method.
-func (m *MockStore) GetActiveUserCount(arg0 int64) (int, error) {
+// GetExpiredSharingTokens mocks base method.
+func (m *MockStore) GetExpiredSharingTokens(arg0 int64) ([]model.Sharing, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActiveUserCount", arg0)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "GetExpiredSharingTokens", arg0)
+	ret0, _ := ret[0].([]model.Sharing)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetActiveUserCount indicates an expected call of GetActiveUserCount.
-func (mr *MockStoreMockRecorder) GetActiveUserCount(arg0 interface{}) *gomock.Call {
+// GetExpiredSharingTokens indicates an expected call of GetExpiredSharingTokens.
+func (mr *MockStoreMockRecorder) GetExpiredSharingTokens(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveUserCount", reflect.TypeOf((*MockStore)(nil).GetActiveUserCount), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredSharingTokens", reflect.TypeOf((*MockStore)(nil).GetExpiredSharingTokens), arg0)
 }
 
-// GetAllBlocks mocks base method.
-func (m *MockStore) GetAllBlocks(arg0 store.Container) ([]model.Block, error) {
+// GetFeatureFlags mocks base method.
+func (m *MockStore) GetFeatureFlags() (map[string]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllBlocks", arg0)
-	ret0, _ := ret[0].([]model.Block)
+	ret := m.ctrl.Call(m, "GetFeatureFlags")
+	ret0, _ := ret[0].(map[string]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllBlocks indicates an expected call of GetAllBlocks.
-func (mr *MockStoreMockRecorder) GetAllBlocks(arg0 interface{}) *gomock.Call {
+// GetFeatureFlags indicates an expected call of GetFeatureFlags.
+func (mr *MockStoreMockRecorder) GetFeatureFlags() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllBlocks", reflect.TypeOf((*MockStore)(nil).GetAllBlocks), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeatureFlags", reflect.TypeOf((*MockStore)(nil).GetFeatureFlags))
 }
 
-// GetBlocksWithParent mocks base method.
-func (m *MockStore) GetBlocksWithParent(arg0 store.Container, arg1 string) ([]model.Block, error) {
+// GetFileBlob mocks base method.
+func (m *MockStore) GetFileBlob(arg0 string) (*model.FileBlob, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBlocksWithParent", arg0, arg1)
-	ret0, _ := ret[0].([]model.Block)
+	ret := m.ctrl.Call(m, "GetFileBlob", arg0)
+	ret0, _ := ret[0].(*model.FileBlob)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBlocksWithParent indicates an expected call of GetBlocksWithParent.
-func (mr *MockStoreMockRecorder) GetBlocksWithParent(arg0, arg1 interface{}) *gomock.Call {
+// GetFileBlob indicates an expected call of GetFileBlob.
+func (mr *MockStoreMockRecorder) GetFileBlob(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithParent", reflect.TypeOf((*MockStore)(nil).GetBlocksWithParent), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileBlob", reflect.TypeOf((*MockStore)(nil).GetFileBlob), arg0)
 }
 
-// GetBlocksWithParentAndType mocks base method.
-func (m *MockStore) GetBlocksWithParentAndType(arg0 store.Container, arg1, arg2 string) ([]model.Block, error) {
+// GetFileRef mocks base method.
+func (m *MockStore) GetFileRef(arg0 string) (*model.FileRef, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBlocksWithParentAndType", arg0, arg1, arg2)
-	ret0, _ := ret[0].([]model.Block)
+	ret := m.ctrl.Call(m, "GetFileRef", arg0)
+	ret0, _ := ret[0].(*model.FileRef)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBlocksWithParentAndType indicates an expected call of GetBlocksWithParentAndType.
-func (mr *MockStoreMockRecorder) GetBlocksWithParentAndType(arg0, arg1, arg2 interface{}) *gomock.Call {
+// GetFileRef indicates an expected call of GetFileRef.
+func (mr *MockStoreMockRecorder) GetFileRef(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithParentAndType", reflect.TypeOf((*MockStore)(nil).GetBlocksWithParentAndType), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileRef", reflect.TypeOf((*MockStore)(nil).GetFileRef), arg0)
 }
 
-// GetBlocksWithType mocks base method.
-func (m *MockStore) GetBlocksWithType(arg0 store.Container, arg1 string) ([]model.Block, error) {
+// GetLockedBoards mocks base method.
+func (m *MockStore) GetLockedBoards(arg0 store.Container, arg1 []string) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBlocksWithType", arg0, arg1)
-	ret0, _ := ret[0].([]model.Block)
+	ret := m.ctrl.Call(m, "GetLockedBoards", arg0, arg1)
+	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBlocksWithType indicates an expected call of GetBlocksWithType.
-func (mr *MockStoreMockRecorder) GetBlocksWithType(arg0, arg1 interface{}) *gomock.Call {
+// GetLockedBoards indicates an expected call of GetLockedBoards.
+func (mr *MockStoreMockRecorder) GetLockedBoards(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksWithType", reflect.TypeOf((*MockStore)(nil).GetBlocksWithType), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockedBoards", reflect.TypeOf((*MockStore)(nil).GetLockedBoards), arg0, arg1)
 }
 
 // GetParentID mocks base method.
@@ -195,6 +1009,21 @@ func (mr *MockStoreMockRecorder) GetParentID(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetParentID", reflect.TypeOf((*MockStore)(nil).GetParentID), arg0, arg1)
 }
 
+// GetRecentSessions mocks base method.
+func (m *MockStore) GetRecentSessions(arg0 int64, arg1 int) ([]*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentSessions", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentSessions indicates an expected call of GetRecentSessions.
+func (mr *MockStoreMockRecorder) GetRecentSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentSessions", reflect.TypeOf((*MockStore)(nil).GetRecentSessions), arg0, arg1)
+}
+
 // GetRegisteredUserCount mocks base method.
 func (m *MockStore) GetRegisteredUserCount() (int, error) {
 	m.ctrl.T.Helper()
@@ -225,6 +1054,21 @@ func (mr *MockStoreMockRecorder) GetRootID(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRootID", reflect.TypeOf((*MockStore)(nil).GetRootID), arg0, arg1)
 }
 
+// GetSchemaVersion mocks base method.
+func (m *MockStore) GetSchemaVersion() (store.SchemaVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchemaVersion")
+	ret0, _ := ret[0].(store.SchemaVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchemaVersion indicates an expected call of GetSchemaVersion.
+func (mr *MockStoreMockRecorder) GetSchemaVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaVersion", reflect.TypeOf((*MockStore)(nil).GetSchemaVersion))
+}
+
 // GetSession mocks base method.
 func (m *MockStore) GetSession(arg0 string, arg1 int64) (*model.Session, error) {
 	m.ctrl.T.Helper()
@@ -345,6 +1189,36 @@ func (mr *MockStoreMockRecorder) GetUserByUsername(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0)
 }
 
+// GetUserSessionIDs mocks base method.
+func (m *MockStore) GetUserSessionIDs(arg0 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessionIDs", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessionIDs indicates an expected call of GetUserSessionIDs.
+func (mr *MockStoreMockRecorder) GetUserSessionIDs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessionIDs", reflect.TypeOf((*MockStore)(nil).GetUserSessionIDs), arg0)
+}
+
+// GetUsersByIDs mocks base method.
+func (m *MockStore) GetUsersByIDs(arg0 []string) ([]model.UserDisplay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByIDs", arg0)
+	ret0, _ := ret[0].([]model.UserDisplay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByIDs indicates an expected call of GetUsersByIDs.
+func (mr *MockStoreMockRecorder) GetUsersByIDs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByIDs", reflect.TypeOf((*MockStore)(nil).GetUsersByIDs), arg0)
+}
+
 // GetWorkspace mocks base method.
 func (m *MockStore) GetWorkspace(arg0 string) (*model.Workspace, error) {
 	m.ctrl.T.Helper()
@@ -360,6 +1234,81 @@ func (mr *MockStoreMockRecorder) GetWorkspace(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspace", reflect.TypeOf((*MockStore)(nil).GetWorkspace), arg0)
 }
 
+// GetWorkspaceFileRefs mocks base method.
+func (m *MockStore) GetWorkspaceFileRefs(arg0 string) ([]model.FileRef, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspaceFileRefs", arg0)
+	ret0, _ := ret[0].([]model.FileRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspaceFileRefs indicates an expected call of GetWorkspaceFileRefs.
+func (mr *MockStoreMockRecorder) GetWorkspaceFileRefs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspaceFileRefs", reflect.TypeOf((*MockStore)(nil).GetWorkspaceFileRefs), arg0)
+}
+
+// GetWorkspaceStorageUsage mocks base method.
+func (m *MockStore) GetWorkspaceStorageUsage(arg0 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspaceStorageUsage", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspaceStorageUsage indicates an expected call of GetWorkspaceStorageUsage.
+func (mr *MockStoreMockRecorder) GetWorkspaceStorageUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspaceStorageUsage", reflect.TypeOf((*MockStore)(nil).GetWorkspaceStorageUsage), arg0)
+}
+
+// GetWorkspaceWebhooks mocks base method.
+func (m *MockStore) GetWorkspaceWebhooks(arg0 string) ([]model.WorkspaceWebhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspaceWebhooks", arg0)
+	ret0, _ := ret[0].([]model.WorkspaceWebhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspaceWebhooks indicates an expected call of GetWorkspaceWebhooks.
+func (mr *MockStoreMockRecorder) GetWorkspaceWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspaceWebhooks", reflect.TypeOf((*MockStore)(nil).GetWorkspaceWebhooks), arg0)
+}
+
+// GetWorkspacesByActivity mocks base method.
+func (m *MockStore) GetWorkspacesByActivity(arg0 string, arg1 int) ([]model.WorkspaceActivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspacesByActivity", arg0, arg1)
+	ret0, _ := ret[0].([]model.WorkspaceActivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspacesByActivity indicates an expected call of GetWorkspacesByActivity.
+func (mr *MockStoreMockRecorder) GetWorkspacesByActivity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspacesByActivity", reflect.TypeOf((*MockStore)(nil).GetWorkspacesByActivity), arg0, arg1)
+}
+
+// GroupCardsByDate mocks base method.
+func (m *MockStore) GroupCardsByDate(arg0 store.Container, arg1, arg2, arg3 string) ([]model.DateBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupCardsByDate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.DateBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupCardsByDate indicates an expected call of GroupCardsByDate.
+func (mr *MockStoreMockRecorder) GroupCardsByDate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupCardsByDate", reflect.TypeOf((*MockStore)(nil).GroupCardsByDate), arg0, arg1, arg2, arg3)
+}
+
 // InsertBlock mocks base method.
 func (m *MockStore) InsertBlock(arg0 store.Container, arg1 model.Block) error {
 	m.ctrl.T.Helper()
@@ -374,6 +1323,63 @@ func (mr *MockStoreMockRecorder) InsertBlock(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBlock", reflect.TypeOf((*MockStore)(nil).InsertBlock), arg0, arg1)
 }
 
+// MergeBlocks mocks base method.
+func (m *MockStore) MergeBlocks(arg0 store.Container, arg1, arg2 string, arg3 store.MergeStrategy, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeBlocks", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeBlocks indicates an expected call of MergeBlocks.
+func (mr *MockStoreMockRecorder) MergeBlocks(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeBlocks", reflect.TypeOf((*MockStore)(nil).MergeBlocks), arg0, arg1, arg2, arg3, arg4)
+}
+
+// PatchBlocks mocks base method.
+func (m *MockStore) PatchBlocks(arg0 store.Container, arg1 []model.BlockPatch, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchBlocks", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchBlocks indicates an expected call of PatchBlocks.
+func (mr *MockStoreMockRecorder) PatchBlocks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchBlocks", reflect.TypeOf((*MockStore)(nil).PatchBlocks), arg0, arg1, arg2)
+}
+
+// PurgeBoard mocks base method.
+func (m *MockStore) PurgeBoard(arg0 store.Container, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeBoard", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeBoard indicates an expected call of PurgeBoard.
+func (mr *MockStoreMockRecorder) PurgeBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeBoard", reflect.TypeOf((*MockStore)(nil).PurgeBoard), arg0, arg1)
+}
+
+// ReassignBoards mocks base method.
+func (m *MockStore) ReassignBoards(arg0, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignBoards", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReassignBoards indicates an expected call of ReassignBoards.
+func (mr *MockStoreMockRecorder) ReassignBoards(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignBoards", reflect.TypeOf((*MockStore)(nil).ReassignBoards), arg0, arg1)
+}
+
 // RefreshSession mocks base method.
 func (m *MockStore) RefreshSession(arg0 *model.Session) error {
 	m.ctrl.T.Helper()
@@ -388,6 +1394,104 @@ func (mr *MockStoreMockRecorder) RefreshSession(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSession", reflect.TypeOf((*MockStore)(nil).RefreshSession), arg0)
 }
 
+// ReleaseBoardLock mocks base method.
+func (m *MockStore) ReleaseBoardLock(arg0 store.Container, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseBoardLock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseBoardLock indicates an expected call of ReleaseBoardLock.
+func (mr *MockStoreMockRecorder) ReleaseBoardLock(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseBoardLock", reflect.TypeOf((*MockStore)(nil).ReleaseBoardLock), arg0, arg1, arg2)
+}
+
+// RenameBoardProperty mocks base method.
+func (m *MockStore) RenameBoardProperty(arg0 store.Container, arg1, arg2, arg3, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameBoardProperty", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameBoardProperty indicates an expected call of RenameBoardProperty.
+func (mr *MockStoreMockRecorder) RenameBoardProperty(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameBoardProperty", reflect.TypeOf((*MockStore)(nil).RenameBoardProperty), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RenamePropertyOption mocks base method.
+func (m *MockStore) RenamePropertyOption(arg0 store.Container, arg1, arg2, arg3, arg4, arg5 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenamePropertyOption", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenamePropertyOption indicates an expected call of RenamePropertyOption.
+func (mr *MockStoreMockRecorder) RenamePropertyOption(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenamePropertyOption", reflect.TypeOf((*MockStore)(nil).RenamePropertyOption), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// RestoreBoard mocks base method.
+func (m *MockStore) RestoreBoard(arg0 store.Container, arg1, arg2 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBoard", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBoard indicates an expected call of RestoreBoard.
+func (mr *MockStoreMockRecorder) RestoreBoard(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBoard", reflect.TypeOf((*MockStore)(nil).RestoreBoard), arg0, arg1, arg2)
+}
+
+// SearchBlocks mocks base method.
+func (m *MockStore) SearchBlocks(arg0 store.Container, arg1 string) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchBlocks", arg0, arg1)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchBlocks indicates an expected call of SearchBlocks.
+func (mr *MockStoreMockRecorder) SearchBlocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchBlocks", reflect.TypeOf((*MockStore)(nil).SearchBlocks), arg0, arg1)
+}
+
+// SetBlockMigrationFlag mocks base method.
+func (m *MockStore) SetBlockMigrationFlag(arg0 []string, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlockMigrationFlag", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBlockMigrationFlag indicates an expected call of SetBlockMigrationFlag.
+func (mr *MockStoreMockRecorder) SetBlockMigrationFlag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlockMigrationFlag", reflect.TypeOf((*MockStore)(nil).SetBlockMigrationFlag), arg0, arg1)
+}
+
+// SetFilesBackend mocks base method.
+func (m *MockStore) SetFilesBackend(arg0 filesstore.FileBackend) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFilesBackend", arg0)
+}
+
+// SetFilesBackend indicates an expected call of SetFilesBackend.
+func (mr *MockStoreMockRecorder) SetFilesBackend(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFilesBackend", reflect.TypeOf((*MockStore)(nil).SetFilesBackend), arg0)
+}
+
 // SetSystemSetting mocks base method.
 func (m *MockStore) SetSystemSetting(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -416,6 +1520,20 @@ func (mr *MockStoreMockRecorder) Shutdown() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockStore)(nil).Shutdown))
 }
 
+// UnarchiveBoard mocks base method.
+func (m *MockStore) UnarchiveBoard(arg0 store.Container, arg1 string, arg2 []model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnarchiveBoard", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnarchiveBoard indicates an expected call of UnarchiveBoard.
+func (mr *MockStoreMockRecorder) UnarchiveBoard(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveBoard", reflect.TypeOf((*MockStore)(nil).UnarchiveBoard), arg0, arg1, arg2)
+}
+
 // UpdateSession mocks base method.
 func (m *MockStore) UpdateSession(arg0 *model.Session) error {
 	m.ctrl.T.Helper()
@@ -472,6 +1590,21 @@ func (mr *MockStoreMockRecorder) UpdateUserPasswordByID(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPasswordByID", reflect.TypeOf((*MockStore)(nil).UpdateUserPasswordByID), arg0, arg1)
 }
 
+// UpgradeBlockData mocks base method.
+func (m *MockStore) UpgradeBlockData() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpgradeBlockData")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpgradeBlockData indicates an expected call of UpgradeBlockData.
+func (mr *MockStoreMockRecorder) UpgradeBlockData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpgradeBlockData", reflect.TypeOf((*MockStore)(nil).UpgradeBlockData))
+}
+
 // UpsertSharing mocks base method.
 func (m *MockStore) UpsertSharing(arg0 store.Container, arg1 model.Sharing) error {
 	m.ctrl.T.Helper()
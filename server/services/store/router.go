@@ -0,0 +1,508 @@
+package store
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+)
+
+// rootWorkspaceID is the workspace of the single-user and native auth modes,
+// which also has the templates
+const rootWorkspaceID = "0"
+
+// Router is a store that keeps the blocks and sharing of each workspace in
+// the database of its shard. A workspace goes to the shard the shard map
+// gives it, or else to one picked by hashing its ID with each shard name,
+// so changing the shards only moves the unmapped workspaces of the shards
+// added or removed, and the root
+// workspace stays in the primary unless it's mapped. Everything else, like
+// the users, sessions, files and system settings, lives in the primary, and
+// the maintenance that spans the workspaces runs on every database.
+type Router struct {
+	// Store is the primary, which serves the methods not routed below
+	Store
+
+	shards     map[string]Store
+	shardNames []string
+	shardMap   map[string]string
+
+	filesBackend filesstore.FileBackend
+}
+
+// NewRouter creates a router over the primary and the shards by name. The
+// shard map gives the name of the shard of some of the workspaces, which
+// must be one of the shards.
+func NewRouter(primary Store, shards map[string]Store, shardMap map[string]string) (*Router, error) {
+	router := &Router{
+		Store:    primary,
+		shards:   map[string]Store{},
+		shardMap: map[string]string{},
+	}
+
+	for name, shard := range shards {
+		router.shards[name] = shard
+		router.shardNames = append(router.shardNames, name)
+	}
+	sort.Strings(router.shardNames)
+
+	for workspaceID, name := range shardMap {
+		if _, ok := router.shards[name]; !ok {
+			return nil, fmt.Errorf("workspace %s is mapped to the unknown shard %q", workspaceID, name)
+		}
+		router.shardMap[workspaceID] = name
+	}
+
+	return router, nil
+}
+
+// Primary returns the store of the data that isn't sharded
+func (r *Router) Primary() Store {
+	return r.Store
+}
+
+// ShardFor returns the store of a workspace
+func (r *Router) ShardFor(workspaceID string) Store {
+	if name, ok := r.shardMap[workspaceID]; ok {
+		return r.shards[name]
+	}
+
+	if workspaceID == rootWorkspaceID || len(r.shardNames) == 0 {
+		return r.Store
+	}
+
+	// Rendezvous hashing: the workspace goes to the shard of the highest
+	// weight, so adding a shard only moves the workspaces that it wins, and
+	// removing one only moves the workspaces it had
+	var best string
+	var bestWeight uint64
+	for _, name := range r.shardNames {
+		weight := shardWeight(name, workspaceID)
+		if best == "" || weight > bestWeight {
+			best, bestWeight = name, weight
+		}
+	}
+
+	return r.shards[best]
+}
+
+func shardWeight(shardName, workspaceID string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(shardName))
+	hash.Write([]byte{0})
+	hash.Write([]byte(workspaceID))
+
+	return hash.Sum64()
+}
+
+func (r *Router) storeFor(c Container) Store {
+	return r.ShardFor(c.WorkspaceID)
+}
+
+// all returns the primary and the shards, each once even if a shard is the
+// primary itself
+func (r *Router) all() []Store {
+	stores := []Store{r.Store}
+	for _, name := range r.shardNames {
+		shard := r.shards[name]
+
+		found := false
+		for _, s := range stores {
+			if s == shard {
+				found = true
+			}
+		}
+		if !found {
+			stores = append(stores, shard)
+		}
+	}
+
+	return stores
+}
+
+// Shutdown closes every database, returning the first error
+func (r *Router) Shutdown() error {
+	var firstErr error
+	for _, s := range r.all() {
+		if err := s.Shutdown(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (r *Router) UpgradeBlockData() (int, error) {
+	total := 0
+	for _, s := range r.all() {
+		upgraded, err := s.UpgradeBlockData()
+		total += upgraded
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (r *Router) ReassignBoards(fromUserID, toUserID string) (int64, error) {
+	var total int64
+	for _, s := range r.all() {
+		reassigned, err := s.ReassignBoards(fromUserID, toUserID)
+		if err != nil {
+			return total, err
+		}
+		total += reassigned
+	}
+
+	return total, nil
+}
+
+// DeleteUserReassigningBoards checks the user on the primary, where their
+// account is, then reassigns the boards of the shards, and then the ones of
+// the primary with the deletion of the user. A failure on a shard leaves the
+// user to delete again.
+func (r *Router) DeleteUserReassigningBoards(userID, boardsOwnerID string) (int64, error) {
+	if _, err := r.Store.GetUserById(userID); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, s := range r.all()[1:] {
+		reassigned, err := s.ReassignBoards(userID, boardsOwnerID)
+		if err != nil {
+			return total, err
+		}
+		total += reassigned
+	}
+
+	reassigned, err := r.Store.DeleteUserReassigningBoards(userID, boardsOwnerID)
+	if err != nil {
+		return total, err
+	}
+
+	return total + reassigned, nil
+}
+
+// AnonymizeUser removes the user from the blocks of every database, and
+// from the primary first, where their account is
+func (r *Router) AnonymizeUser(userID string) error {
+	for _, s := range r.all() {
+		if err := s.AnonymizeUser(userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Router) GetWorkspacesByActivity(userID string, limit int) ([]model.WorkspaceActivity, error) {
+	activities := []model.WorkspaceActivity{}
+	for _, s := range r.all() {
+		found, err := s.GetWorkspacesByActivity(userID, limit)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, found...)
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		if activities[i].LastActivityAt != activities[j].LastActivityAt {
+			return activities[i].LastActivityAt > activities[j].LastActivityAt
+		}
+		return activities[i].WorkspaceID < activities[j].WorkspaceID
+	})
+	if limit > 0 && len(activities) > limit {
+		activities = activities[:limit]
+	}
+
+	return activities, nil
+}
+
+func (r *Router) GetExpiredSharingTokens(now int64) ([]model.Sharing, error) {
+	sharings := []model.Sharing{}
+	for _, s := range r.all() {
+		found, err := s.GetExpiredSharingTokens(now)
+		if err != nil {
+			return nil, err
+		}
+		sharings = append(sharings, found...)
+	}
+
+	return sharings, nil
+}
+
+func (r *Router) DeleteExpiredSharingTokens(now int64) (int64, error) {
+	var total int64
+	for _, s := range r.all() {
+		deleted, err := s.DeleteExpiredSharingTokens(now)
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+	}
+
+	return total, nil
+}
+
+func (r *Router) GetDeletedBoardsBefore(deletedBefore int64) ([]model.DeletedBoard, error) {
+	boards := []model.DeletedBoard{}
+	for _, s := range r.all() {
+		found, err := s.GetDeletedBoardsBefore(deletedBefore)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, found...)
+	}
+
+	return boards, nil
+}
+
+// SetFilesBackend sets the files storage of every database, to be scanned
+// against the blocks of each of them, and of the router for the workspace
+// exports
+func (r *Router) SetFilesBackend(backend filesstore.FileBackend) {
+	r.filesBackend = backend
+	for _, s := range r.all() {
+		s.SetFilesBackend(backend)
+	}
+}
+
+// ExportWorkspace writes the bundle of a workspace through the router, as
+// its boards are in its shard, but its settings and files in the primary
+func (r *Router) ExportWorkspace(workspaceID string, w io.Writer) error {
+	return WriteWorkspaceBundle(r, r.filesBackend, workspaceID, w)
+}
+
+// FindOrphanedFiles lists the files that every database finds orphaned, as
+// the blobs are referenced by the files of the primary, and the files
+// uploaded before the blobs by the blocks of the shard of their workspace.
+func (r *Router) FindOrphanedFiles() ([]model.FileInfo, error) {
+	var orphans []model.FileInfo
+	for i, s := range r.all() {
+		found, err := s.FindOrphanedFiles()
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			orphans = found
+			continue
+		}
+
+		orphaned := map[string]bool{}
+		for _, info := range found {
+			orphaned[info.Path] = true
+		}
+		kept := []model.FileInfo{}
+		for _, info := range orphans {
+			if orphaned[info.Path] {
+				kept = append(kept, info)
+			}
+		}
+		orphans = kept
+	}
+
+	return orphans, nil
+}
+
+// The methods scoped to a workspace go to its shard
+
+func (r *Router) GetBlocksWithParentAndType(c Container, parentID string, blockType string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksWithParentAndType(c, parentID, blockType)
+}
+
+func (r *Router) GetComments(c Container, cardID string, limit int, before int64, beforeID string) ([]model.Block, error) {
+	return r.storeFor(c).GetComments(c, cardID, limit, before, beforeID)
+}
+
+func (r *Router) GetBlocksWithParentAndTypeSorted(c Container, parentID string, blockType string, sort BlockSort) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksWithParentAndTypeSorted(c, parentID, blockType, sort)
+}
+
+func (r *Router) GetBlocksWithParent(c Container, parentID string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksWithParent(c, parentID)
+}
+
+func (r *Router) GetBlocksWithType(c Container, blockType string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksWithType(c, blockType)
+}
+
+func (r *Router) GetBlocksWithRootID(c Container, rootID string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksWithRootID(c, rootID)
+}
+
+func (r *Router) GetBlocksByProperty(c Container, boardID, propertyID, value string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksByProperty(c, boardID, propertyID, value)
+}
+
+func (r *Router) SearchBlocks(c Container, term string) ([]model.Block, error) {
+	return r.storeFor(c).SearchBlocks(c, term)
+}
+
+func (r *Router) GetBoardAggregates(c Container, boardID, groupByPropertyID string) (*model.BoardAggregates, error) {
+	return r.storeFor(c).GetBoardAggregates(c, boardID, groupByPropertyID)
+}
+
+func (r *Router) GroupCardsByDate(c Container, boardID, datePropertyID, bucket string) ([]model.DateBucket, error) {
+	return r.storeFor(c).GroupCardsByDate(c, boardID, datePropertyID, bucket)
+}
+
+func (r *Router) CountBlocksByBoard(c Container) (map[string]int, error) {
+	return r.storeFor(c).CountBlocksByBoard(c)
+}
+
+func (r *Router) FindDuplicateBoards(c Container) ([]model.DuplicateBoards, error) {
+	return r.storeFor(c).FindDuplicateBoards(c)
+}
+
+func (r *Router) CreateBoardSnapshot(c Container, boardID, createdBy string) (string, error) {
+	return r.storeFor(c).CreateBoardSnapshot(c, boardID, createdBy)
+}
+
+func (r *Router) GetBoardSnapshot(c Container, snapshotID string) (*model.BoardSnapshot, error) {
+	return r.storeFor(c).GetBoardSnapshot(c, snapshotID)
+}
+
+func (r *Router) GetBoardSnapshots(c Container, boardID string) ([]model.BoardSnapshot, error) {
+	return r.storeFor(c).GetBoardSnapshots(c, boardID)
+}
+
+func (r *Router) GetBoardActivity(c Container, boardID string, limit int, before int64, beforeID string) ([]model.BlockActivity, error) {
+	return r.storeFor(c).GetBoardActivity(c, boardID, limit, before, beforeID)
+}
+
+func (r *Router) GetBoardChecksum(c Container, boardID string) (string, error) {
+	return r.storeFor(c).GetBoardChecksum(c, boardID)
+}
+
+func (r *Router) GetBoardsMetadata(c Container, boardIDs []string) ([]model.BoardMetadata, error) {
+	return r.storeFor(c).GetBoardsMetadata(c, boardIDs)
+}
+
+func (r *Router) GetCardsAssignedTo(c Container, userID string) ([]model.Block, error) {
+	return r.storeFor(c).GetCardsAssignedTo(c, userID)
+}
+
+func (r *Router) GetBlocksModifiedBy(c Container, userID string, since int64, limit int) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksModifiedBy(c, userID, since, limit)
+}
+
+func (r *Router) CountBoards(c Container) (int, error) {
+	return r.storeFor(c).CountBoards(c)
+}
+
+func (r *Router) RenameBoardProperty(c Container, boardID, propertyID, newName, modifiedBy string) error {
+	return r.storeFor(c).RenameBoardProperty(c, boardID, propertyID, newName, modifiedBy)
+}
+
+func (r *Router) RenamePropertyOption(c Container, boardID, propertyID, oldOption, newOption, modifiedBy string) error {
+	return r.storeFor(c).RenamePropertyOption(c, boardID, propertyID, oldOption, newOption, modifiedBy)
+}
+
+func (r *Router) GetSubTree2(c Container, blockID string) ([]model.Block, error) {
+	return r.storeFor(c).GetSubTree2(c, blockID)
+}
+
+func (r *Router) GetSubTree3(c Container, blockID string) ([]model.Block, error) {
+	return r.storeFor(c).GetSubTree3(c, blockID)
+}
+
+func (r *Router) GetAllBlocks(c Container) ([]model.Block, error) {
+	return r.storeFor(c).GetAllBlocks(c)
+}
+
+func (r *Router) GetRootID(c Container, blockID string) (string, error) {
+	return r.storeFor(c).GetRootID(c, blockID)
+}
+
+func (r *Router) GetParentID(c Container, blockID string) (string, error) {
+	return r.storeFor(c).GetParentID(c, blockID)
+}
+
+func (r *Router) GetBlocksByIDs(c Container, blockIDs []string) ([]model.Block, error) {
+	return r.storeFor(c).GetBlocksByIDs(c, blockIDs)
+}
+
+func (r *Router) BlockExists(c Container, blockID string) (bool, error) {
+	return r.storeFor(c).BlockExists(c, blockID)
+}
+
+func (r *Router) BoardExists(c Container, boardID string) (bool, error) {
+	return r.storeFor(c).BoardExists(c, boardID)
+}
+
+func (r *Router) GetExistingBoards(c Container, boardIDs []string) ([]string, error) {
+	return r.storeFor(c).GetExistingBoards(c, boardIDs)
+}
+
+func (r *Router) InsertBlock(c Container, block model.Block) error {
+	return r.storeFor(c).InsertBlock(c, block)
+}
+
+func (r *Router) PatchBlocks(c Container, patches []model.BlockPatch, modifiedBy string) error {
+	return r.storeFor(c).PatchBlocks(c, patches, modifiedBy)
+}
+
+func (r *Router) DeleteBlock(c Container, blockID string, modifiedBy string) error {
+	return r.storeFor(c).DeleteBlock(c, blockID, modifiedBy)
+}
+
+func (r *Router) MergeBlocks(c Container, targetID, sourceID string, strategy MergeStrategy, modifiedBy string) error {
+	return r.storeFor(c).MergeBlocks(c, targetID, sourceID, strategy, modifiedBy)
+}
+
+func (r *Router) CopyCard(c Container, card model.Block, content []model.Block) error {
+	return r.storeFor(c).CopyCard(c, card, content)
+}
+
+func (r *Router) CreateBoardWithDefaults(c Container, board model.Block, views []model.Block) error {
+	return r.storeFor(c).CreateBoardWithDefaults(c, board, views)
+}
+
+func (r *Router) ArchiveBoard(c Container, boardID string, archive func(blocks []model.Block) error) error {
+	return r.storeFor(c).ArchiveBoard(c, boardID, archive)
+}
+
+func (r *Router) UnarchiveBoard(c Container, boardID string, blocks []model.Block) error {
+	return r.storeFor(c).UnarchiveBoard(c, boardID, blocks)
+}
+
+func (r *Router) GetDeletedBoards(workspaceID string) ([]model.Block, error) {
+	return r.ShardFor(workspaceID).GetDeletedBoards(workspaceID)
+}
+
+func (r *Router) RestoreBoard(c Container, boardID, modifiedBy string) ([]model.Block, error) {
+	return r.storeFor(c).RestoreBoard(c, boardID, modifiedBy)
+}
+
+func (r *Router) PurgeBoard(c Container, boardID string) error {
+	return r.storeFor(c).PurgeBoard(c, boardID)
+}
+
+func (r *Router) AcquireBoardLock(c Container, boardID, holder string, ttl time.Duration) (bool, error) {
+	return r.storeFor(c).AcquireBoardLock(c, boardID, holder, ttl)
+}
+
+func (r *Router) ReleaseBoardLock(c Container, boardID, holder string) error {
+	return r.storeFor(c).ReleaseBoardLock(c, boardID, holder)
+}
+
+func (r *Router) GetLockedBoards(c Container, boardIDs []string) ([]string, error) {
+	return r.storeFor(c).GetLockedBoards(c, boardIDs)
+}
+
+func (r *Router) UpsertSharing(c Container, sharing model.Sharing) error {
+	return r.storeFor(c).UpsertSharing(c, sharing)
+}
+
+func (r *Router) GetSharing(c Container, rootID string) (*model.Sharing, error) {
+	return r.storeFor(c).GetSharing(c, rootID)
+}
+
+func (r *Router) GetActiveSharingTokens(c Container, now int64) ([]model.Sharing, error) {
+	return r.storeFor(c).GetActiveSharingTokens(c, now)
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// GetBoardActivity returns the changes to the blocks of a board, newest
+// first, from the history of the blocks. If before isn't zero only the
+// changes before the one made at that time to the block beforeID are
+// returned, so the next page starts after the last change of the previous
+// one, even with several changes made at the same time. The history entries
+// of the deletions only have the ID of the block, so the blocks are matched
+// by any of their versions, by the index on the root IDs of the history, and
+// their type and title are the ones of their latest version that has them.
+func (s *SQLStore) GetBoardActivity(c store.Container, boardID string, limit int, before int64, beforeID string) ([]model.BlockActivity, error) {
+	history := s.tablePrefix + "blocks_history"
+	latestVersion := func(column string) string {
+		return "COALESCE(h." + column + ", (SELECT p." + column + " FROM " + history + " p" +
+			" WHERE p.id = h.id AND p." + column + " IS NOT NULL ORDER BY p.insert_at DESC LIMIT 1), '')"
+	}
+
+	query := s.getQueryBuilder().
+		Select(
+			"h.id",
+			latestVersion("type"),
+			latestVersion("title"),
+			"COALESCE(h.modified_by, '')",
+			"COALESCE(h.update_at, 0)",
+			"COALESCE(h.delete_at, 0)",
+			"CASE WHEN EXISTS (SELECT 1 FROM "+history+" p WHERE p.id = h.id AND p.insert_at < h.insert_at) THEN 1 ELSE 0 END",
+		).
+		From(history+" h").
+		Where(sq.Eq{"coalesce(h.workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Expr("h.id IN (SELECT r.id FROM "+history+" r WHERE r.root_id = ?)", boardID)).
+		OrderBy("h.update_at DESC", "h.id DESC", "h.insert_at DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		if beforeID == "" {
+			query = query.Where(sq.Lt{"h.update_at": before})
+		} else {
+			query = query.Where(sq.Or{
+				sq.Lt{"h.update_at": before},
+				sq.And{sq.Eq{"h.update_at": before}, sq.Lt{"h.id": beforeID}},
+			})
+		}
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBoardActivity ERROR: %v`, err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := []model.BlockActivity{}
+	for rows.Next() {
+		var entry model.BlockActivity
+		var deleteAt int64
+		var hasPreviousVersion int
+
+		err := rows.Scan(
+			&entry.BlockID,
+			&entry.BlockType,
+			&entry.Title,
+			&entry.ModifiedBy,
+			&entry.UpdateAt,
+			&deleteAt,
+			&hasPreviousVersion,
+		)
+		if err != nil {
+			log.Printf(`getBoardActivity ERROR: %v`, err)
+
+			return nil, err
+		}
+
+		switch {
+		case deleteAt > 0:
+			entry.Action = model.BlockActivityDeleted
+		case hasPreviousVersion > 0:
+			entry.Action = model.BlockActivityUpdated
+		default:
+			entry.Action = model.BlockActivityCreated
+		}
+
+		activity = append(activity, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf(`getBoardActivity ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,207 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// AnonymizeUser removes the identity of a user from the store, in a single
+// transaction: its sessions are deleted, it's removed from the values of the
+// person properties of the cards, and it's replaced by store.DeletedUserID
+// as the author of the blocks, including their history, and of the rest of
+// the records it modified. The history of the cards that had it as a value
+// of a person property is removed, the rest of the content is kept.
+func (s *SQLStore) AnonymizeUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.anonymizeUser(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) anonymizeUser(ctx context.Context, tx *sql.Tx, userID string) error {
+	deleteSessions := s.getQueryBuilder().
+		Delete(s.tablePrefix + "sessions").
+		Where(sq.Eq{"user_id": userID})
+	if _, err := sq.ExecContextWith(ctx, tx, deleteSessions); err != nil {
+		return err
+	}
+
+	if err := s.removeUserFromPersonProperties(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	authorColumns := map[string]string{
+		"blocks":             "modified_by",
+		"blocks_history":     "modified_by",
+		"sharing":            "modified_by",
+		"workspaces":         "modified_by",
+		"workspace_webhooks": "created_by",
+	}
+	for table, column := range authorColumns {
+		query := s.getQueryBuilder().
+			Update(s.tablePrefix+table).
+			Set(column, store.DeletedUserID).
+			Where(sq.Eq{column: userID})
+		if _, err := sq.ExecContextWith(ctx, tx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeUserFromPersonProperties saves a new version of the cards that have
+// the user as a value of a person property without it, and removes their
+// history where it appears
+func (s *SQLStore) removeUserFromPersonProperties(ctx context.Context, tx *sql.Tx, userID string) error {
+	pattern, err := userLikePattern(userID)
+	if err != nil {
+		return err
+	}
+
+	fields := "fields"
+	if s.dbType == postgresDBType {
+		fields = "CAST(fields AS TEXT)"
+	}
+	mentionsUser := sq.Expr(fields+" LIKE ? ESCAPE '!'", pattern)
+
+	candidatesQuery := s.getQueryBuilder().
+		Select("id", "COALESCE(workspace_id, '0')").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"type": "card"}).
+		Where(mentionsUser)
+
+	rows, err := sq.QueryContextWith(ctx, tx, candidatesQuery)
+	if err != nil {
+		return err
+	}
+
+	candidates := map[string][]string{}
+	for rows.Next() {
+		var blockID, workspaceID string
+		if err := rows.Scan(&blockID, &workspaceID); err != nil {
+			rows.Close()
+			return err
+		}
+		candidates[workspaceID] = append(candidates[workspaceID], blockID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := utils.GetMillis()
+	for workspaceID, blockIDs := range candidates {
+		c := store.Container{WorkspaceID: workspaceID}
+
+		cards, err := s.queryBlocks(ctx, tx, s.getBlocksByIDsQuery(c, blockIDs))
+		if err != nil {
+			return err
+		}
+
+		boardIDs := []string{}
+		for _, card := range cards {
+			boardIDs = append(boardIDs, card.RootID)
+		}
+		boards, err := s.queryBlocks(ctx, tx, s.getBlocksByIDsQuery(c, boardIDs))
+		if err != nil {
+			return err
+		}
+		personProperties := personPropertiesOf(boards)
+
+		for _, card := range cards {
+			if !removePersonValue(card, personProperties[card.RootID], userID) {
+				continue
+			}
+
+			deleteHistory := s.getQueryBuilder().
+				Delete(s.tablePrefix + "blocks_history").
+				Where(sq.Eq{"id": card.ID}).
+				Where(mentionsUser)
+			if _, err := sq.ExecContextWith(ctx, tx, deleteHistory); err != nil {
+				return err
+			}
+
+			card.UpdateAt = now
+			if card.ModifiedBy == userID {
+				card.ModifiedBy = store.DeletedUserID
+			}
+			if err := s.insertBlock(ctx, tx, c, card); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLStore) queryBlocks(ctx context.Context, tx *sql.Tx, query sq.SelectBuilder) ([]model.Block, error) {
+	rows, err := sq.QueryContextWith(ctx, tx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
+
+// removePersonValue removes the user from the values of the person
+// properties of the card, and returns true if it was in any of them
+func removePersonValue(card model.Block, personProperties map[string]bool, userID string) bool {
+	properties, _ := card.Fields["properties"].(map[string]interface{})
+
+	changed := false
+	for propertyID, value := range properties {
+		if !personProperties[propertyID] || !isPersonValue(value, userID) {
+			continue
+		}
+		changed = true
+
+		values, ok := value.([]interface{})
+		if !ok {
+			delete(properties, propertyID)
+			continue
+		}
+
+		remaining := []interface{}{}
+		for _, item := range values {
+			if item != userID {
+				remaining = append(remaining, item)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(properties, propertyID)
+		} else {
+			properties[propertyID] = remaining
+		}
+	}
+
+	return changed
+}
+
+// userLikePattern matches the serialized fields with the user ID as a JSON
+// string. It escapes with "!", as the backslash isn't escaped the same way
+// by every database.
+func userLikePattern(userID string) (string, error) {
+	jsonValue, err := json.Marshal(userID)
+	if err != nil {
+		return "", err
+	}
+
+	escaper := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + escaper.Replace(string(jsonValue)) + "%", nil
+}
@@ -0,0 +1,47 @@
+package sqlstore
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/storetests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizeUserHistory(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+	sqlStore := s.(*SQLStore)
+
+	container := store.Container{
+		WorkspaceID: "workspace-1",
+	}
+	blocks := storetests.AssignedCardsBlocks()
+	for i := range blocks {
+		blocks[i].ModifiedBy = "user-1"
+	}
+	storetests.InsertBlocks(t, s, container, blocks)
+
+	require.NoError(t, s.AnonymizeUser("user-1"))
+
+	countHistory := func(where sq.Sqlizer) int {
+		var count int
+		err := sqlStore.getQueryBuilder().
+			Select("COUNT(*)").
+			From(sqlStore.tablePrefix + "blocks_history").
+			Where(where).
+			QueryRow().
+			Scan(&count)
+		require.NoError(t, err)
+		return count
+	}
+
+	require.Zero(t, countHistory(sq.Eq{"modified_by": "user-1"}))
+	require.Positive(t, countHistory(sq.Eq{"modified_by": store.DeletedUserID}))
+
+	// The old versions that had the user as a value are gone, the latest
+	// one remains
+	require.Equal(t, 1, countHistory(sq.Eq{"id": "card-assigned-owner"}))
+	require.Equal(t, 1, countHistory(sq.Eq{"id": "card-text"}))
+}
@@ -0,0 +1,154 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// ArchiveBoard flags the board block as archived and removes the rest of the
+// board's blocks from the blocks table, in a transaction. The board's blocks
+// are read in the same transaction and passed to the archive function, which
+// keeps a copy of them to restore them later, and only those are removed, so
+// a block inserted meanwhile is neither lost nor archived. If the archive
+// function fails, nothing is changed. Archiving a board that's already
+// archived fails with store.ErrBoardArchived.
+func (s *SQLStore) ArchiveBoard(c store.Container, boardID string, archive func(blocks []model.Block) error) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// The board is looked up rather than relying on the rows affected by the
+	// update, which MySQL doesn't count when the values don't change
+	selectQuery := s.getQueryBuilder().
+		Select("archived").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"id": boardID}).
+		Where(sq.Eq{"type": "board"}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+	rows, err := sq.QueryContextWith(ctx, tx, selectQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	found, archived := false, false
+	for rows.Next() {
+		found = true
+		if err = rows.Scan(&archived); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !found {
+		tx.Rollback()
+		return &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+	if archived {
+		tx.Rollback()
+		return store.ErrBoardArchived
+	}
+
+	blockRows, err := sq.QueryContextWith(ctx, tx, s.getBlocksWithRootIDQuery(c, boardID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	blocks, err := blocksFromRows(blockRows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = archive(blocks); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	updateQuery := s.getQueryBuilder().Update(s.tablePrefix+"blocks").
+		Set("archived", true).
+		Where(sq.Eq{"id": boardID}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+	if _, err = sq.ExecContextWith(ctx, tx, updateQuery); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	archivedIDs := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ID != boardID {
+			archivedIDs = append(archivedIDs, block.ID)
+		}
+	}
+
+	if len(archivedIDs) > 0 {
+		deleteQuery := s.getQueryBuilder().Delete(s.tablePrefix + "blocks").
+			Where(sq.Eq{"id": archivedIDs}).
+			Where(sq.Eq{"root_id": boardID}).
+			Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+		if _, err = sq.ExecContextWith(ctx, tx, deleteQuery); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnarchiveBoard restores the blocks of an archived board in a single
+// transaction, which also clears the board's archived flag.
+func (s *SQLStore) UnarchiveBoard(c store.Container, boardID string, blocks []model.Block) error {
+	hasBoard := false
+	for _, block := range blocks {
+		if block.ID == boardID {
+			hasBoard = true
+		}
+		if block.RootID != boardID {
+			return errors.New("block doesn't belong to the board being restored")
+		}
+	}
+	if !hasBoard {
+		return errors.New("board block missing from the blocks to restore")
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		err = s.insertBlock(ctx, tx, c, block)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
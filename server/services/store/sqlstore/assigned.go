@@ -0,0 +1,169 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// personPropertyTypes are the types of the properties whose values are users
+var personPropertyTypes = map[string]bool{
+	"person":      true,
+	"multiPerson": true,
+}
+
+// GetCardsAssignedTo returns the cards of the workspace that have the user as
+// the value of a person property of their board. The query matches the cards
+// with the user as the value of any property, and the candidates are checked
+// against the schemas of their boards.
+func (s *SQLStore) GetCardsAssignedTo(c store.Container, userID string) ([]model.Block, error) {
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix+"blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"type": "card"}).
+		Where(sq.Eq{"archived": false}).
+		OrderBy("update_at DESC", "id")
+
+	switch {
+	case s.dbType == postgresDBType:
+		// Both the single person values and the arrays of several of them
+		query = query.Where(sq.Expr(
+			"EXISTS (SELECT 1 FROM jsonb_each(fields::jsonb->'properties') p WHERE p.value = to_jsonb(?::text) OR p.value @> jsonb_build_array(?::text))",
+			userID, userID,
+		))
+	case s.dbType == mysqlDBType:
+		query = query.Where(sq.Expr("JSON_CONTAINS(JSON_EXTRACT(fields, '$.properties.*'), JSON_QUOTE(?))", userID))
+	case s.jsonSupported:
+		query = query.Where(sq.Expr("EXISTS (SELECT 1 FROM json_tree(fields, '$.properties') WHERE type = 'text' AND atom = ?)", userID))
+	default:
+		jsonValue, err := json.Marshal(userID)
+		if err != nil {
+			return nil, err
+		}
+		escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+		query = query.Where(sq.Expr(`fields LIKE ? ESCAPE '\'`, "%"+escaper.Replace(string(jsonValue))+"%"))
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getCardsAssignedTo ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	candidates, err := blocksFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	personProperties, err := s.getPersonProperties(c, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := []model.Block{}
+	for _, card := range candidates {
+		properties, _ := card.Fields["properties"].(map[string]interface{})
+		for propertyID, value := range properties {
+			if personProperties[card.RootID][propertyID] && isPersonValue(value, userID) {
+				cards = append(cards, card)
+				break
+			}
+		}
+	}
+
+	return cards, nil
+}
+
+// getPersonProperties returns the IDs of the person properties of the boards
+// of the cards, keyed by board ID
+func (s *SQLStore) getPersonProperties(c store.Container, cards []model.Block) (map[string]map[string]bool, error) {
+	boardIDs := []string{}
+	seen := map[string]bool{}
+	for _, card := range cards {
+		if !seen[card.RootID] {
+			seen[card.RootID] = true
+			boardIDs = append(boardIDs, card.RootID)
+		}
+	}
+
+	rows, err := s.getBlocksByIDsQuery(c, boardIDs).Query()
+	if err != nil {
+		log.Printf(`getPersonProperties ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	boards, err := blocksFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return personPropertiesOf(boards), nil
+}
+
+// personPropertiesOf returns the IDs of the person properties defined by the
+// schemas of the boards, keyed by board ID
+func personPropertiesOf(boards []model.Block) map[string]map[string]bool {
+	result := map[string]map[string]bool{}
+	for _, board := range boards {
+		if board.Type != "board" {
+			continue
+		}
+
+		cardProperties, _ := board.Fields["cardProperties"].([]interface{})
+		for _, p := range cardProperties {
+			property, _ := p.(map[string]interface{})
+			propertyID, _ := property["id"].(string)
+			propertyType, _ := property["type"].(string)
+			if propertyID == "" || !personPropertyTypes[propertyType] {
+				continue
+			}
+
+			if result[board.ID] == nil {
+				result[board.ID] = map[string]bool{}
+			}
+			result[board.ID][propertyID] = true
+		}
+	}
+
+	return result
+}
+
+// isPersonValue checks if the value of a person property is the user, or
+// includes the user if it has several of them
+func isPersonValue(value interface{}, userID string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == userID
+	case []interface{}:
+		for _, item := range v {
+			if item == userID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
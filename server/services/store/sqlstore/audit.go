@@ -0,0 +1,93 @@
+package sqlstore
+
+import (
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+func (s *SQLStore) CreateAuditLog(entry model.AuditLog) error {
+	query := s.getQueryBuilder().
+		Insert(s.tablePrefix+"audit_logs").
+		Columns(
+			"id",
+			"create_at",
+			"actor",
+			"action",
+			"target",
+			"details",
+		).
+		Values(
+			entry.ID,
+			entry.CreateAt,
+			entry.Actor,
+			entry.Action,
+			entry.Target,
+			entry.Details,
+		)
+
+	_, err := query.Exec()
+	return err
+}
+
+// GetAuditLogs returns a page of the audit logs matching the filter, oldest
+// first. The time range includes both ends.
+func (s *SQLStore) GetAuditLogs(filter store.AuditFilter, limit, offset int) ([]model.AuditLog, error) {
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"create_at",
+			"actor",
+			"action",
+			"COALESCE(target, '')",
+			"COALESCE(details, '')",
+		).
+		From(s.tablePrefix+"audit_logs").
+		OrderBy("create_at", "id").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+
+	if filter.Actor != "" {
+		query = query.Where(sq.Eq{"actor": filter.Actor})
+	}
+	if filter.Action != "" {
+		query = query.Where(sq.Eq{"action": filter.Action})
+	}
+	if filter.Target != "" {
+		query = query.Where(sq.Eq{"target": filter.Target})
+	}
+	if filter.Since > 0 {
+		query = query.Where(sq.GtOrEq{"create_at": filter.Since})
+	}
+	if filter.Until > 0 {
+		query = query.Where(sq.LtOrEq{"create_at": filter.Until})
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getAuditLogs ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.AuditLog{}
+	for rows.Next() {
+		var entry model.AuditLog
+		err := rows.Scan(
+			&entry.ID,
+			&entry.CreateAt,
+			&entry.Actor,
+			&entry.Action,
+			&entry.Target,
+			&entry.Details,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
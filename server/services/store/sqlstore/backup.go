@@ -0,0 +1,19 @@
+package sqlstore
+
+import (
+	"fmt"
+
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// BackupDatabase writes a consistent copy of the database to filename
+// without blocking the server. Only SQLite databases are supported, other
+// backends should be backed up with their native tooling.
+func (s *SQLStore) BackupDatabase(filename string) error {
+	if s.dbType != sqliteDBType {
+		return fmt.Errorf("backup of %s databases: %w", s.dbType, store.ErrNotSupported)
+	}
+
+	_, err := s.db.Exec("VACUUM INTO ?", filename)
+	return err
+}
@@ -0,0 +1,47 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupDatabase(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	sqlStore := s.(*SQLStore)
+	if sqlStore.dbType != sqliteDBType {
+		err := s.BackupDatabase(filepath.Join(t.TempDir(), "backup.db"))
+		require.ErrorIs(t, err, store.ErrNotSupported)
+		return
+	}
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	block := model.Block{
+		ID:     "backup-block",
+		RootID: "backup-block",
+		Type:   "board",
+	}
+	err := s.InsertBlock(container, block)
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "backup.db")
+	err = s.BackupDatabase(filename)
+	require.NoError(t, err)
+
+	db, err := sql.Open(sqliteDBType, filename)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	err = db.QueryRow("SELECT count(*) FROM "+sqlStore.tablePrefix+"blocks WHERE id = $1", block.ID).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
@@ -5,18 +5,32 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	_ "github.com/lib/pq"
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func (s *SQLStore) GetBlocksWithParentAndType(c store.Container, parentID string, blockType string) ([]model.Block, error) {
-	query := s.getQueryBuilder().
+	rows, err := s.getBlocksWithParentAndTypeQuery(c, parentID, blockType).Query()
+	if err != nil {
+		log.Printf(`getBlocksWithParentAndType ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
+
+func (s *SQLStore) getBlocksWithParentAndTypeQuery(c store.Container, parentID string, blockType string) sq.SelectBuilder {
+	return s.getQueryBuilder().
 		Select(
 			"id",
 			"parent_id",
@@ -33,11 +47,44 @@ func (s *SQLStore) GetBlocksWithParentAndType(c store.Container, parentID string
 		From(s.tablePrefix + "blocks").
 		Where(sq.Eq{"COALESCE(workspace_id, '0')": c.WorkspaceID}).
 		Where(sq.Eq{"parent_id": parentID}).
-		Where(sq.Eq{"type": blockType})
+		Where(sq.Eq{"type": blockType}).
+		Where(sq.Eq{"archived": false})
+}
+
+func (s *SQLStore) GetBlocksWithParent(c store.Container, parentID string) ([]model.Block, error) {
+	rows, err := s.getBlocksWithParentQuery(c, parentID).Query()
+	if err != nil {
+		log.Printf(`getBlocksWithParent ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
+
+// GetComments returns the comments of a card, newest first. If before isn't
+// zero only the comments before the one with that creation time and beforeID
+// are returned, so the next page starts after the last comment of the
+// previous one, even with several comments created at the same time.
+func (s *SQLStore) GetComments(c store.Container, cardID string, limit int, before int64, beforeID string) ([]model.Block, error) {
+	query := s.getBlocksWithParentAndTypeQuery(c, cardID, "comment").
+		OrderBy("create_at DESC", "id DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		if beforeID == "" {
+			query = query.Where(sq.Lt{"create_at": before})
+		} else {
+			query = query.Where(sq.Or{
+				sq.Lt{"create_at": before},
+				sq.And{sq.Eq{"create_at": before}, sq.Lt{"id": beforeID}},
+			})
+		}
+	}
 
 	rows, err := query.Query()
 	if err != nil {
-		log.Printf(`getBlocksWithParentAndType ERROR: %v`, err)
+		log.Printf(`getComments ERROR: %v`, err)
 
 		return nil, err
 	}
@@ -45,7 +92,9 @@ func (s *SQLStore) GetBlocksWithParentAndType(c store.Container, parentID string
 	return blocksFromRows(rows)
 }
 
-func (s *SQLStore) GetBlocksWithParent(c store.Container, parentID string) ([]model.Block, error) {
+// GetBlocksModifiedBy returns the blocks of the workspace last modified by
+// the user since the given time, the most recent first
+func (s *SQLStore) GetBlocksModifiedBy(c store.Container, userID string, since int64, limit int) ([]model.Block, error) {
 	query := s.getQueryBuilder().
 		Select(
 			"id",
@@ -60,13 +109,16 @@ func (s *SQLStore) GetBlocksWithParent(c store.Container, parentID string) ([]mo
 			"update_at",
 			"delete_at",
 		).
-		From(s.tablePrefix + "blocks").
-		Where(sq.Eq{"parent_id": parentID}).
-		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+		From(s.tablePrefix+"blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"modified_by": userID}).
+		Where(sq.GtOrEq{"update_at": since}).
+		OrderBy("update_at DESC", "id").
+		Limit(uint64(limit))
 
 	rows, err := query.Query()
 	if err != nil {
-		log.Printf(`getBlocksWithParent ERROR: %v`, err)
+		log.Printf(`getBlocksModifiedBy ERROR: %v`, err)
 
 		return nil, err
 	}
@@ -74,6 +126,27 @@ func (s *SQLStore) GetBlocksWithParent(c store.Container, parentID string) ([]mo
 	return blocksFromRows(rows)
 }
 
+func (s *SQLStore) getBlocksWithParentQuery(c store.Container, parentID string) sq.SelectBuilder {
+	return s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"parent_id": parentID}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"archived": false})
+}
+
 func (s *SQLStore) GetBlocksWithType(c store.Container, blockType string) ([]model.Block, error) {
 	query := s.getQueryBuilder().
 		Select(
@@ -91,7 +164,8 @@ func (s *SQLStore) GetBlocksWithType(c store.Container, blockType string) ([]mod
 		).
 		From(s.tablePrefix + "blocks").
 		Where(sq.Eq{"type": blockType}).
-		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"archived": false})
 
 	rows, err := query.Query()
 	if err != nil {
@@ -103,6 +177,324 @@ func (s *SQLStore) GetBlocksWithType(c store.Container, blockType string) ([]mod
 	return blocksFromRows(rows)
 }
 
+func (s *SQLStore) GetBlocksWithRootID(c store.Container, rootID string) ([]model.Block, error) {
+	rows, err := s.getBlocksWithRootIDQuery(c, rootID).Query()
+	if err != nil {
+		log.Printf(`getBlocksWithRootID ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
+
+func (s *SQLStore) getBlocksWithRootIDQuery(c store.Container, rootID string) sq.SelectBuilder {
+	return s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"root_id": rootID}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+}
+
+// GetBlocksByProperty returns the blocks of a board whose property, as set
+// by the cards' fields.properties map, has the given value
+func (s *SQLStore) GetBlocksByProperty(c store.Container, boardID, propertyID, value string) ([]model.Block, error) {
+	if err := checkPropertyID(propertyID); err != nil {
+		return nil, err
+	}
+
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"root_id": boardID}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+	jsonPath := fmt.Sprintf(`$.properties."%s"`, propertyID)
+	switch {
+	case s.dbType == postgresDBType:
+		query = query.Where(sq.Expr("fields->'properties'->>? = ?", propertyID, value))
+	case s.dbType == mysqlDBType:
+		query = query.Where(sq.Expr("JSON_UNQUOTE(JSON_EXTRACT(fields, ?)) = ?", jsonPath, value))
+	case s.jsonSupported:
+		query = query.Where(sq.Expr("json_extract(fields, ?) = ?", jsonPath, value))
+	default:
+		// Without JSON functions, match the serialized property and then
+		// check the candidates, as the pattern could match nested fields
+		pattern, err := propertyLikePattern(propertyID, value)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(sq.Expr(`fields LIKE ? ESCAPE '\'`, pattern))
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBlocksByProperty ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	blocks, err := blocksFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.jsonSupported {
+		return blocks, nil
+	}
+
+	results := []model.Block{}
+	for _, block := range blocks {
+		properties, _ := block.Fields["properties"].(map[string]interface{})
+		if propertyValue, ok := properties[propertyID].(string); ok && propertyValue == value {
+			results = append(results, block)
+		}
+	}
+
+	return results, nil
+}
+
+// GetBoardAggregates counts the cards of a board and, if groupByPropertyID
+// isn't empty, how many of them have each value of that property
+func (s *SQLStore) GetBoardAggregates(c store.Container, boardID, groupByPropertyID string) (*model.BoardAggregates, error) {
+	if groupByPropertyID != "" {
+		if err := checkPropertyID(groupByPropertyID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := s.getQueryBuilder().
+		Select().
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"root_id": boardID}).
+		Where(sq.Eq{"type": "card"}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+	if groupByPropertyID == "" {
+		var total int64
+		err := query.Columns("COUNT(*)").QueryRow().Scan(&total)
+		if err != nil {
+			log.Printf(`getBoardAggregates ERROR: %v`, err)
+			return nil, err
+		}
+
+		return &model.BoardAggregates{Total: total}, nil
+	}
+
+	if !s.jsonSupported {
+		return s.getBoardAggregatesWithoutJSON(query, groupByPropertyID)
+	}
+
+	jsonPath := fmt.Sprintf(`$.properties."%s"`, groupByPropertyID)
+	var groupBy sq.Sqlizer
+	switch s.dbType {
+	case postgresDBType:
+		groupBy = sq.Expr("COALESCE(fields->'properties'->>?, '')", groupByPropertyID)
+	case mysqlDBType:
+		groupBy = sq.Expr("COALESCE(JSON_UNQUOTE(JSON_EXTRACT(fields, ?)), '')", jsonPath)
+	default:
+		groupBy = sq.Expr("COALESCE(json_extract(fields, ?), '')", jsonPath)
+	}
+
+	rows, err := query.Column(groupBy).Column("COUNT(*)").GroupBy("1").Query()
+	if err != nil {
+		log.Printf(`getBoardAggregates ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := &model.BoardAggregates{Groups: map[string]int64{}}
+	for rows.Next() {
+		var value string
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		aggregates.Groups[value] += count
+		aggregates.Total += count
+	}
+
+	return aggregates, rows.Err()
+}
+
+// getBoardAggregatesWithoutJSON groups the cards in memory, for the databases
+// that can't extract the property in the query
+func (s *SQLStore) getBoardAggregatesWithoutJSON(query sq.SelectBuilder, groupByPropertyID string) (*model.BoardAggregates, error) {
+	rows, err := query.Columns("COALESCE(fields, '{}')").Query()
+	if err != nil {
+		log.Printf(`getBoardAggregates ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := &model.BoardAggregates{Groups: map[string]int64{}}
+	for rows.Next() {
+		var fieldsJSON string
+		if err := rows.Scan(&fieldsJSON); err != nil {
+			return nil, err
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(fieldsJSON), &fields); err != nil {
+			log.Printf("getBoardAggregates ERROR unmarshalling fields: %v", err)
+			return nil, err
+		}
+
+		properties, _ := fields["properties"].(map[string]interface{})
+		value, _ := properties[groupByPropertyID].(string)
+		aggregates.Groups[value]++
+		aggregates.Total++
+	}
+
+	return aggregates, rows.Err()
+}
+
+// CountBlocksByBoard returns the number of blocks of each board of the
+// workspace, keyed by board ID
+func (s *SQLStore) CountBlocksByBoard(c store.Container) (map[string]int, error) {
+	query := s.getQueryBuilder().
+		Select("root_id", "COUNT(*)").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		GroupBy("root_id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`countBlocksByBoard ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var boardID string
+		var count int
+		if err := rows.Scan(&boardID, &count); err != nil {
+			return nil, err
+		}
+		counts[boardID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// CountBoards returns the number of boards in the workspace, leaving out the
+// templates
+func (s *SQLStore) CountBoards(c store.Container) (int, error) {
+	boards := func(column string) sq.SelectBuilder {
+		return s.getQueryBuilder().
+			Select(column).
+			From(s.tablePrefix + "blocks").
+			Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+			Where(sq.Eq{"type": "board"})
+	}
+
+	var isTemplate string
+	switch {
+	case s.dbType == postgresDBType:
+		isTemplate = "COALESCE(fields->>'isTemplate', '') = 'true'"
+	case s.dbType == mysqlDBType:
+		isTemplate = "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(fields, '$.isTemplate')), '') = 'true'"
+	case s.jsonSupported:
+		isTemplate = "COALESCE(json_extract(fields, '$.isTemplate'), 0) = 1"
+	}
+
+	if isTemplate != "" {
+		var count int
+		err := boards("COUNT(*)").Where("NOT (" + isTemplate + ")").QueryRow().Scan(&count)
+		if err != nil {
+			log.Printf(`countBoards ERROR: %v`, err)
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	// Without JSON functions, the boards whose fields mention the flag are
+	// checked here, as the pattern could match nested fields
+	var total int
+	err := boards("COUNT(*)").QueryRow().Scan(&total)
+	if err != nil {
+		log.Printf(`countBoards ERROR: %v`, err)
+		return 0, err
+	}
+
+	rows, err := boards("COALESCE(fields, '{}')").
+		Where(sq.Like{"fields": `%"isTemplate":true%`}).
+		Query()
+	if err != nil {
+		log.Printf(`countBoards ERROR: %v`, err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fieldsJSON string
+		if err := rows.Scan(&fieldsJSON); err != nil {
+			return 0, err
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(fieldsJSON), &fields); err != nil {
+			log.Printf("countBoards ERROR unmarshalling fields: %v", err)
+			return 0, err
+		}
+		if isTemplate, _ := fields["isTemplate"].(bool); isTemplate {
+			total--
+		}
+	}
+
+	return total, rows.Err()
+}
+
+// checkPropertyID rejects the property IDs that can't be safely used in a
+// JSON path
+func checkPropertyID(propertyID string) error {
+	if propertyID == "" || strings.ContainsAny(propertyID, `"\`) {
+		return fmt.Errorf("invalid property id %q", propertyID)
+	}
+
+	return nil
+}
+
+func propertyLikePattern(propertyID, value string) (string, error) {
+	key, err := json.Marshal(propertyID)
+	if err != nil {
+		return "", err
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + escaper.Replace(string(key)+":"+string(jsonValue)) + "%", nil
+}
+
 // GetSubTree2 returns blocks within 2 levels of the given blockID
 func (s *SQLStore) GetSubTree2(c store.Container, blockID string) ([]model.Block, error) {
 	query := s.getQueryBuilder().
@@ -239,6 +631,12 @@ func blocksFromRows(rows *sql.Rows) ([]model.Block, error) {
 			return nil, err
 		}
 
+		if err := upgradeBlock(&block); err != nil {
+			log.Printf(`ERROR blocksFromRows upgrade: %v`, err)
+
+			return nil, err
+		}
+
 		results = append(results, block)
 	}
 
@@ -281,25 +679,90 @@ func (s *SQLStore) GetParentID(c store.Container, blockID string) (string, error
 	return parentID, nil
 }
 
-func (s *SQLStore) InsertBlock(c store.Container, block model.Block) error {
-	if block.RootID == "" {
-		return errors.New("rootId is nil")
+func (s *SQLStore) GetBlocksByIDs(c store.Container, blockIDs []string) ([]model.Block, error) {
+	rows, err := s.getBlocksByIDsQuery(c, blockIDs).Query()
+	if err != nil {
+		log.Printf(`getBlocksByIDs ERROR: %v`, err)
+
+		return nil, err
 	}
 
-	fieldsJSON, err := json.Marshal(block.Fields)
+	return blocksFromRows(rows)
+}
+
+// BlockExists checks whether a block is in the workspace, without reading
+// its data
+func (s *SQLStore) BlockExists(c store.Container, blockID string) (bool, error) {
+	return s.blockExists(sq.Eq{
+		"id":                          blockID,
+		"coalesce(workspace_id, '0')": c.WorkspaceID,
+	})
+}
+
+// BoardExists checks whether a board is in the workspace, archived or not,
+// without reading its data
+func (s *SQLStore) BoardExists(c store.Container, boardID string) (bool, error) {
+	return s.blockExists(sq.Eq{
+		"id":                          boardID,
+		"type":                        "board",
+		"coalesce(workspace_id, '0')": c.WorkspaceID,
+	})
+}
+
+// GetExistingBoards returns the boards among the given ones that are in the
+// workspace, archived or not, reading only their IDs
+func (s *SQLStore) GetExistingBoards(c store.Container, boardIDs []string) ([]string, error) {
+	query := s.getQueryBuilder().
+		Select("id").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{
+			"id":                          boardIDs,
+			"type":                        "board",
+			"coalesce(workspace_id, '0')": c.WorkspaceID,
+		})
+
+	rows, err := query.Query()
 	if err != nil {
-		return err
+		log.Printf(`getExistingBoards ERROR: %v`, err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	ctx := context.Background()
-	tx, err := s.db.BeginTx(ctx, nil)
+	existing := []string{}
+	for rows.Next() {
+		var boardID string
+		if err := rows.Scan(&boardID); err != nil {
+			return nil, err
+		}
+		existing = append(existing, boardID)
+	}
+
+	return existing, rows.Err()
+}
+
+func (s *SQLStore) blockExists(condition sq.Eq) (bool, error) {
+	query := s.getQueryBuilder().
+		Select("1").
+		From(s.tablePrefix + "blocks").
+		Where(condition).
+		Limit(1)
+
+	var found int
+	err := query.QueryRow().Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
 	if err != nil {
-		return err
+		log.Printf(`blockExists ERROR: %v`, err)
+		return false, err
 	}
 
-	query := s.getQueryBuilder().Insert("").
-		Columns(
-			"workspace_id",
+	return true, nil
+}
+
+func (s *SQLStore) getBlocksByIDsQuery(c store.Container, blockIDs []string) sq.SelectBuilder {
+	return s.getQueryBuilder().
+		Select(
 			"id",
 			"parent_id",
 			"root_id",
@@ -307,11 +770,62 @@ func (s *SQLStore) InsertBlock(c store.Container, block model.Block) error {
 			s.escapeField("schema"),
 			"type",
 			"title",
-			"fields",
+			"COALESCE(fields, '{}')",
 			"create_at",
 			"update_at",
 			"delete_at",
-		).Values(
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"id": blockIDs}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+}
+
+func (s *SQLStore) InsertBlock(c store.Container, block model.Block) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = s.insertBlock(ctx, tx, c, block)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLStore) insertBlock(ctx context.Context, tx *sql.Tx, c store.Container, block model.Block) error {
+	if block.RootID == "" {
+		return errors.New("rootId is nil")
+	}
+
+	fieldsJSON, err := json.Marshal(block.Fields)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{
+		"workspace_id",
+		"id",
+		"parent_id",
+		"root_id",
+		"modified_by",
+		s.escapeField("schema"),
+		"type",
+		"title",
+		"fields",
+		"create_at",
+		"update_at",
+		"delete_at",
+	}
+	values := []interface{}{
 		c.WorkspaceID,
 		block.ID,
 		block.ParentID,
@@ -324,28 +838,95 @@ func (s *SQLStore) InsertBlock(c store.Container, block model.Block) error {
 		block.CreateAt,
 		block.UpdateAt,
 		block.DeleteAt,
-	)
+	}
+	query := s.getQueryBuilder().Insert("").Columns(columns...).Values(values...)
 
 	// TODO: migrate this delete/insert to an upsert
 	deleteQuery := s.getQueryBuilder().Delete(s.tablePrefix + "blocks").Where(sq.Eq{"id": block.ID})
 	_, err = sq.ExecContextWith(ctx, tx, deleteQuery)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	_, err = sq.ExecContextWith(ctx, tx, query.Into(s.tablePrefix+"blocks"))
+	if err != nil {
+		return err
+	}
+
+	historyQuery := s.historyInsert(columns, values)
+	_, err = sq.ExecContextWith(ctx, tx, historyQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PatchBlocks applies the patches to their blocks in a single transaction.
+// If any of the patched blocks doesn't exist, nothing is modified and an
+// *store.ErrBlocksNotFound listing the missing IDs is returned.
+func (s *SQLStore) PatchBlocks(c store.Container, patches []model.BlockPatch, modifiedBy string) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	blockIDs := make([]string, 0, len(patches))
+	for _, patch := range patches {
+		blockIDs = append(blockIDs, patch.ID)
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := sq.QueryContextWith(ctx, tx, s.getBlocksByIDsQuery(c, blockIDs))
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = sq.ExecContextWith(ctx, tx, query.Into(s.tablePrefix+"blocks_history"))
+	blocks, err := blocksFromRows(rows)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	blocksByID := make(map[string]*model.Block, len(blocks))
+	for i := range blocks {
+		blocksByID[blocks[i].ID] = &blocks[i]
+	}
+
+	missing := []string{}
+	for _, blockID := range blockIDs {
+		if _, ok := blocksByID[blockID]; !ok {
+			missing = append(missing, blockID)
+		}
+	}
+	if len(missing) > 0 {
+		tx.Rollback()
+		return &store.ErrBlocksNotFound{BlockIDs: missing}
+	}
+
+	// Several patches may target the same block, so apply them all
+	// before writing each block (and its history entry) once
+	for _, patch := range patches {
+		patch.Patch(blocksByID[patch.ID])
+	}
+
+	now := utils.GetMillis()
+	for i := range blocks {
+		blocks[i].ModifiedBy = modifiedBy
+		blocks[i].UpdateAt = now
+
+		err = s.insertBlock(ctx, tx, c, blocks[i])
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return err
@@ -361,38 +942,67 @@ func (s *SQLStore) DeleteBlock(c store.Container, blockID string, modifiedBy str
 		return err
 	}
 
-	now := time.Now().Unix()
-	insertQuery := s.getQueryBuilder().Insert(s.tablePrefix+"blocks_history").
-		Columns(
-			"workspace_id",
-			"id",
-			"modified_by",
-			"update_at",
-			"delete_at",
-		).
-		Values(
-			c.WorkspaceID,
-			blockID,
-			modifiedBy,
-			now,
-			now,
-		)
-
-	_, err = sq.ExecContextWith(ctx, tx, insertQuery)
+	err = s.deleteBlock(ctx, tx, c, blockID, modifiedBy)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	deleteQuery := s.getQueryBuilder().Delete(s.tablePrefix + "blocks").Where(sq.Eq{"id": blockID})
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
 
-	_, err = sq.ExecContextWith(ctx, tx, deleteQuery)
+	return nil
+}
+
+// historyInsert inserts an entry in the history of the blocks. The
+// timestamps of SQLite only have millisecond precision, so two entries of a
+// block written within the same millisecond would collide on the primary key
+// of the history. There, each entry is given an insert_at at least a
+// millisecond after the previous one.
+func (s *SQLStore) historyInsert(columns []string, values []interface{}) sq.InsertBuilder {
+	if s.dbType == sqliteDBType {
+		columns = append(append([]string{}, columns...), "insert_at")
+		values = append(append([]interface{}{}, values...), s.nextHistoryTime().Format(sqliteHistoryTimeLayout))
+	}
+
+	return s.getQueryBuilder().Insert(s.tablePrefix + "blocks_history").Columns(columns...).Values(values...)
+}
+
+// sqliteHistoryTimeLayout is the format of the insert_at of SQLite, the one
+// of its STRFTIME('%Y-%m-%d %H:%M:%f') default
+const sqliteHistoryTimeLayout = "2006-01-02 15:04:05.000"
+
+func (s *SQLStore) nextHistoryTime() time.Time {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if !now.After(s.lastHistoryTime) {
+		now = s.lastHistoryTime.Add(time.Millisecond)
+	}
+	s.lastHistoryTime = now
+
+	return now
+}
+
+// deleteBlock removes the block, keeping its history so it can be restored
+func (s *SQLStore) deleteBlock(ctx context.Context, tx *sql.Tx, c store.Container, blockID string, modifiedBy string) error {
+	now := utils.GetMillis()
+	insertQuery := s.historyInsert(
+		[]string{"workspace_id", "id", "modified_by", "update_at", "delete_at"},
+		[]interface{}{c.WorkspaceID, blockID, modifiedBy, now, now},
+	)
+
+	_, err := sq.ExecContextWith(ctx, tx, insertQuery)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	deleteQuery := s.getQueryBuilder().Delete(s.tablePrefix + "blocks").Where(sq.Eq{"id": blockID})
+
+	_, err = sq.ExecContextWith(ctx, tx, deleteQuery)
 	if err != nil {
 		return err
 	}
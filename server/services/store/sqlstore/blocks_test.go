@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/storetests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBlocksByPropertyWithoutJSONFunctions(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	sqlStore := s.(*SQLStore)
+	if sqlStore.dbType != sqliteDBType {
+		t.Skip("the pattern matching fallback is only used by SQLite")
+	}
+	jsonSupported := sqlStore.jsonSupported
+	defer func() { sqlStore.jsonSupported = jsonSupported }()
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	blocks := []model.Block{
+		{
+			ID:     "card-match",
+			RootID: "board",
+			Type:   "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"priority": "high"},
+			},
+		},
+		{
+			// Matches the pattern, but it isn't a card property
+			ID:     "card-nested",
+			RootID: "board",
+			Type:   "card",
+			Fields: map[string]interface{}{
+				"other": map[string]interface{}{"priority": "high"},
+			},
+		},
+	}
+	for _, block := range blocks {
+		require.NoError(t, s.InsertBlock(container, block))
+	}
+
+	sqlStore.jsonSupported = false
+	result, err := s.GetBlocksByProperty(container, "board", "priority", "high")
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Equal(t, "card-match", result[0].ID)
+}
+
+func TestGetBoardsMetadataWithoutJSONFunctions(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	sqlStore := s.(*SQLStore)
+	jsonSupported := sqlStore.jsonSupported
+	defer func() { sqlStore.jsonSupported = jsonSupported }()
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	require.NoError(t, s.InsertBlock(container, model.Block{ID: "board", RootID: "board", Type: "board", Title: "Board", Fields: map[string]interface{}{"icon": "📋"}}))
+	require.NoError(t, s.InsertBlock(container, model.Block{ID: "card", ParentID: "board", RootID: "board", Type: "card"}))
+
+	sqlStore.jsonSupported = false
+	metadata, err := s.GetBoardsMetadata(container, []string{"board"})
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	require.Equal(t, "📋", metadata[0].Icon)
+	require.EqualValues(t, 1, metadata[0].CardCount)
+}
+
+func TestGetCardsAssignedToWithoutJSONFunctions(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	sqlStore := s.(*SQLStore)
+	jsonSupported := sqlStore.jsonSupported
+	defer func() { sqlStore.jsonSupported = jsonSupported }()
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	storetests.InsertBlocks(t, s, container, storetests.AssignedCardsBlocks())
+
+	sqlStore.jsonSupported = false
+	cards, err := s.GetCardsAssignedTo(container, "user-1")
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	require.Equal(t, "card-assigned-owner", cards[0].ID)
+	require.Equal(t, "card-assigned-reviewer", cards[1].ID)
+}
+
+func TestGroupCardsByDateWithoutJSONFunctions(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	sqlStore := s.(*SQLStore)
+	jsonSupported := sqlStore.jsonSupported
+	defer func() { sqlStore.jsonSupported = jsonSupported }()
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	storetests.InsertBlocks(t, s, container, storetests.DatedCardsBlocks())
+
+	for _, bucket := range []string{model.DateBucketDay, model.DateBucketWeek, model.DateBucketMonth} {
+		sqlStore.jsonSupported = jsonSupported
+		expected, err := s.GroupCardsByDate(container, "board", "due", bucket)
+		require.NoError(t, err)
+
+		sqlStore.jsonSupported = false
+		buckets, err := s.GroupCardsByDate(container, "board", "due", bucket)
+		require.NoError(t, err)
+		require.Equal(t, expected, buckets, bucket)
+	}
+}
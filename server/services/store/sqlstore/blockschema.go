@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+)
+
+// blockSchemaVersionSetting is the system setting with the schema version
+// all the blocks have been upgraded to
+const blockSchemaVersionSetting = "BlockSchemaVersion"
+
+// upgradeBlockDataBatchSize is the number of blocks upgraded by each
+// transaction, to keep them short
+const upgradeBlockDataBatchSize = 100
+
+// blockSchemaTransforms upgrade the fields of a block from the schema version
+// of their key to the next one, starting at 1. They must only depend on the
+// block, as the blocks are upgraded one at a time, and they're applied when
+// the blocks are read too, until UpgradeBlockData stores the result. Blocks
+// without a schema version are left as they are.
+var blockSchemaTransforms = map[int64]func(block *model.Block) error{}
+
+// latestBlockSchemaVersion is the version the blocks are upgraded to
+func latestBlockSchemaVersion() int64 {
+	version := int64(1)
+	for blockSchemaTransforms[version] != nil {
+		version++
+	}
+
+	return version
+}
+
+// upgradeBlock applies the transforms from the version of the block to the
+// latest one
+func upgradeBlock(block *model.Block) error {
+	for {
+		transform := blockSchemaTransforms[block.Schema]
+		if transform == nil {
+			return nil
+		}
+
+		if block.Fields == nil {
+			block.Fields = map[string]interface{}{}
+		}
+		if err := transform(block); err != nil {
+			return err
+		}
+		block.Schema++
+	}
+}
+
+// UpgradeBlockData upgrades the fields of the blocks stored with an older
+// schema version, in batches, and returns the number of blocks upgraded. The
+// blocks are updated in place, without a new version in their history. It
+// can be run again safely, as only the blocks with an older version are
+// selected and the latest version is recorded once all of them are upgraded.
+func (s *SQLStore) UpgradeBlockData() (int, error) {
+	latest := latestBlockSchemaVersion()
+
+	settings, err := s.GetSystemSettings()
+	if err != nil {
+		return 0, err
+	}
+	if settings[blockSchemaVersionSetting] == strconv.FormatInt(latest, 10) {
+		return 0, nil
+	}
+
+	upgraded := 0
+	for {
+		count, err := s.upgradeBlockDataBatch(latest)
+		if err != nil {
+			log.Printf(`upgradeBlockData ERROR: %v`, err)
+
+			return upgraded, err
+		}
+		upgraded += count
+
+		if count < upgradeBlockDataBatchSize {
+			break
+		}
+	}
+
+	if err := s.SetSystemSetting(blockSchemaVersionSetting, strconv.FormatInt(latest, 10)); err != nil {
+		return upgraded, err
+	}
+
+	return upgraded, nil
+}
+
+func (s *SQLStore) upgradeBlockDataBatch(latest int64) (int, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.GtOrEq{s.escapeField("schema"): 1}).
+		Where(sq.Lt{s.escapeField("schema"): latest}).
+		OrderBy("id").
+		Limit(upgradeBlockDataBatchSize)
+
+	rows, err := sq.QueryContextWith(ctx, tx, query)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	blocks, err := blocksFromRows(rows)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// The blocks are upgraded by blocksFromRows, so only their new version
+	// needs to be stored
+	for _, block := range blocks {
+		fieldsJSON, err := json.Marshal(block.Fields)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		update := s.getQueryBuilder().
+			Update(s.tablePrefix+"blocks").
+			Set(s.escapeField("schema"), block.Schema).
+			Set("fields", fieldsJSON).
+			Where(sq.Eq{"id": block.ID})
+		if _, err := sq.ExecContextWith(ctx, tx, update); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(blocks), nil
+}
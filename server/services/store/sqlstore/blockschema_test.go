@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeBlockData(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+	sqlStore := s.(*SQLStore)
+
+	container := store.Container{
+		WorkspaceID: "workspace-1",
+	}
+	blocks := []model.Block{
+		{ID: "card-v1", RootID: "board", Type: "card", Schema: 1, Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"tags": "urgent"},
+		}},
+		{ID: "card-no-version", RootID: "board", Type: "card", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"tags": "urgent"},
+		}},
+	}
+	for _, block := range blocks {
+		require.NoError(t, s.InsertBlock(container, block))
+	}
+
+	// Version 2 stores the tags as a list
+	defer func(transforms map[int64]func(block *model.Block) error) {
+		blockSchemaTransforms = transforms
+	}(blockSchemaTransforms)
+	blockSchemaTransforms = map[int64]func(block *model.Block) error{
+		1: func(block *model.Block) error {
+			properties, _ := block.Fields["properties"].(map[string]interface{})
+			if tags, ok := properties["tags"].(string); ok {
+				properties["tags"] = []interface{}{tags}
+			}
+			return nil
+		},
+	}
+
+	getBlock := func(id string) model.Block {
+		rows, err := sqlStore.getBlocksByIDsQuery(container, []string{id}).Query()
+		require.NoError(t, err)
+		found, err := blocksFromRows(rows)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		return found[0]
+	}
+
+	t.Run("the blocks are upgraded when read", func(t *testing.T) {
+		block := getBlock("card-v1")
+		require.EqualValues(t, 2, block.Schema)
+		require.Equal(t, []interface{}{"urgent"}, block.Fields["properties"].(map[string]interface{})["tags"])
+	})
+
+	t.Run("upgrade the stored blocks", func(t *testing.T) {
+		upgraded, err := s.UpgradeBlockData()
+		require.NoError(t, err)
+		// The blocks of the initial templates are version 1 too
+		require.Greater(t, upgraded, 1)
+
+		// Read the stored version without the transforms
+		transforms := blockSchemaTransforms
+		blockSchemaTransforms = map[int64]func(block *model.Block) error{}
+		block := getBlock("card-v1")
+		noVersion := getBlock("card-no-version")
+		blockSchemaTransforms = transforms
+
+		require.EqualValues(t, 2, block.Schema)
+		require.Equal(t, []interface{}{"urgent"}, block.Fields["properties"].(map[string]interface{})["tags"])
+		require.EqualValues(t, 0, noVersion.Schema)
+		require.Equal(t, "urgent", noVersion.Fields["properties"].(map[string]interface{})["tags"])
+
+		settings, err := s.GetSystemSettings()
+		require.NoError(t, err)
+		require.Equal(t, "2", settings[blockSchemaVersionSetting])
+	})
+
+	t.Run("running it again is a no-op", func(t *testing.T) {
+		upgraded, err := s.UpgradeBlockData()
+		require.NoError(t, err)
+		require.Zero(t, upgraded)
+
+		// Even without the marker of the upgraded version
+		require.NoError(t, s.SetSystemSetting(blockSchemaVersionSetting, "1"))
+		upgraded, err = s.UpgradeBlockData()
+		require.NoError(t, err)
+		require.Zero(t, upgraded)
+
+		block := getBlock("card-v1")
+		require.EqualValues(t, 2, block.Schema)
+		require.Equal(t, []interface{}{"urgent"}, block.Fields["properties"].(map[string]interface{})["tags"])
+	})
+}
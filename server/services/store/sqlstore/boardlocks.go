@@ -0,0 +1,160 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// AcquireBoardLock gives the holder exclusive access to the board for the
+// TTL, and returns false if someone else holds it. The holder that already
+// has the lock extends it. The lock expires after the TTL, so it's released
+// even if its holder never does.
+func (s *SQLStore) AcquireBoardLock(c store.Container, boardID, holder string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	now := utils.GetMillis()
+	expireAt := now + ttl.Milliseconds()
+
+	deleteExpired := s.getQueryBuilder().
+		Delete(s.tablePrefix + "board_locks").
+		Where(sq.Eq{"board_id": boardID}).
+		Where(sq.LtOrEq{"expire_at": now})
+	if _, err := sq.ExecContextWith(ctx, tx, deleteExpired); err != nil {
+		tx.Rollback()
+		log.Printf(`acquireBoardLock ERROR: %v`, err)
+		return false, err
+	}
+
+	insert := s.getQueryBuilder().
+		Insert(s.tablePrefix+"board_locks").
+		Columns("board_id", "workspace_id", "holder", "expire_at").
+		Values(boardID, c.WorkspaceID, holder, expireAt)
+	if s.dbType == mysqlDBType {
+		insert = insert.Options("IGNORE")
+	} else {
+		insert = insert.Suffix("ON CONFLICT (board_id) DO NOTHING")
+	}
+
+	result, err := sq.ExecContextWith(ctx, tx, insert)
+	if err != nil {
+		tx.Rollback()
+		log.Printf(`acquireBoardLock ERROR: %v`, err)
+		return false, err
+	}
+	acquired, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if acquired == 0 {
+		// The holder is checked rather than the rows the update affects, as
+		// MySQL doesn't count the rows an update leaves unchanged, like an
+		// extension within the same millisecond
+		current, err := s.boardLockHolder(ctx, tx, boardID)
+		if err != nil {
+			tx.Rollback()
+			log.Printf(`acquireBoardLock ERROR: %v`, err)
+			return false, err
+		}
+		if current != holder {
+			tx.Rollback()
+			return false, nil
+		}
+
+		extend := s.getQueryBuilder().
+			Update(s.tablePrefix+"board_locks").
+			Set("expire_at", expireAt).
+			Where(sq.Eq{"board_id": boardID}).
+			Where(sq.Eq{"holder": holder})
+		if _, err := sq.ExecContextWith(ctx, tx, extend); err != nil {
+			tx.Rollback()
+			log.Printf(`acquireBoardLock ERROR: %v`, err)
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// boardLockHolder returns the holder of the lock of the board, or an empty
+// string if it isn't locked
+func (s *SQLStore) boardLockHolder(ctx context.Context, tx *sql.Tx, boardID string) (string, error) {
+	query := s.getQueryBuilder().
+		Select("holder").
+		From(s.tablePrefix + "board_locks").
+		Where(sq.Eq{"board_id": boardID})
+
+	rows, err := sq.QueryContextWith(ctx, tx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var holder string
+	for rows.Next() {
+		if err := rows.Scan(&holder); err != nil {
+			return "", err
+		}
+	}
+
+	return holder, rows.Err()
+}
+
+// GetLockedBoards returns the boards among the given ones that someone holds
+// the lock of
+func (s *SQLStore) GetLockedBoards(c store.Container, boardIDs []string) ([]string, error) {
+	query := s.getQueryBuilder().
+		Select("board_id").
+		From(s.tablePrefix + "board_locks").
+		Where(sq.Eq{"board_id": boardIDs}).
+		Where(sq.Gt{"expire_at": utils.GetMillis()})
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getLockedBoards ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	locked := []string{}
+	for rows.Next() {
+		var boardID string
+		if err := rows.Scan(&boardID); err != nil {
+			return nil, err
+		}
+		locked = append(locked, boardID)
+	}
+
+	return locked, rows.Err()
+}
+
+// ReleaseBoardLock releases the lock of the board if the holder has it
+func (s *SQLStore) ReleaseBoardLock(c store.Container, boardID, holder string) error {
+	query := s.getQueryBuilder().
+		Delete(s.tablePrefix + "board_locks").
+		Where(sq.Eq{"board_id": boardID}).
+		Where(sq.Eq{"workspace_id": c.WorkspaceID}).
+		Where(sq.Eq{"holder": holder})
+
+	if _, err := query.Exec(); err != nil {
+		log.Printf(`releaseBoardLock ERROR: %v`, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package sqlstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// CreateBoardWithDefaults inserts a board, whose fields carry its schema,
+// and its views in a single transaction, so a failure doesn't leave a board
+// without them.
+func (s *SQLStore) CreateBoardWithDefaults(c store.Container, board model.Block, views []model.Block) error {
+	if board.Type != "board" {
+		return errors.New("the block to create isn't a board")
+	}
+	for _, view := range views {
+		if view.Type != "view" || view.ParentID != board.ID || view.RootID != board.ID {
+			return errors.New("the default views must be views of the board")
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range append([]model.Block{board}, views...) {
+		err = s.insertBlock(ctx, tx, c, block)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBoardsMetadata returns the title, icon and card count of the boards of
+// the workspace with the given IDs, in a single query. IDs of blocks that
+// aren't boards of the workspace are ignored.
+func (s *SQLStore) GetBoardsMetadata(c store.Container, boardIDs []string) ([]model.BoardMetadata, error) {
+	if len(boardIDs) == 0 {
+		return []model.BoardMetadata{}, nil
+	}
+
+	// Only the icon is read from the fields, which may carry a large schema
+	var icon string
+	switch {
+	case !s.jsonSupported:
+		icon = "COALESCE(b.fields, '{}')"
+	case s.dbType == postgresDBType:
+		icon = "COALESCE(b.fields->>'icon', '')"
+	case s.dbType == mysqlDBType:
+		icon = "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(b.fields, '$.icon')), '')"
+	default:
+		icon = "COALESCE(json_extract(b.fields, '$.icon'), '')"
+	}
+
+	cardCount := sq.Expr(
+		"(SELECT COUNT(*) FROM "+s.tablePrefix+"blocks cards WHERE cards.root_id = b.id AND cards.type = 'card' AND coalesce(cards.workspace_id, '0') = ?)",
+		c.WorkspaceID,
+	)
+
+	query := s.getQueryBuilder().
+		Select("b.id", "b.title", icon).
+		Column(cardCount).
+		Column("b.update_at").
+		From(s.tablePrefix+"blocks b").
+		Where(sq.Eq{"b.id": boardIDs}).
+		Where(sq.Eq{"b.type": "board"}).
+		Where(sq.Eq{"coalesce(b.workspace_id, '0')": c.WorkspaceID}).
+		OrderBy("b.title", "b.id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBoardsMetadata ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.BoardMetadata{}
+	for rows.Next() {
+		var metadata model.BoardMetadata
+		if err := rows.Scan(&metadata.ID, &metadata.Title, &metadata.Icon, &metadata.CardCount, &metadata.UpdateAt); err != nil {
+			return nil, err
+		}
+
+		if !s.jsonSupported {
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(metadata.Icon), &fields); err != nil {
+				log.Printf("getBoardsMetadata ERROR unmarshalling fields: %v", err)
+				return nil, err
+			}
+			metadata.Icon, _ = fields["icon"].(string)
+		}
+
+		results = append(results, metadata)
+	}
+
+	return results, rows.Err()
+}
+
+// FindDuplicateBoards groups the boards of the workspace by a hash of their
+// structure, the title and card properties of the board and the type and
+// title of each of its blocks, and returns the groups of more than one
+// board. The IDs of the blocks and properties aren't part of the hash, as
+// the copies of a board get new ones.
+func (s *SQLStore) FindDuplicateBoards(c store.Container) ([]model.DuplicateBoards, error) {
+	// Only the fields of the boards are needed
+	query := s.getQueryBuilder().
+		Select("id", "root_id", "type", "title", "CASE WHEN type = 'board' THEN COALESCE(fields, '{}') ELSE '{}' END").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"archived": false})
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`findDuplicateBoards ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	boards := map[string]model.Block{}
+	contents := map[string][]string{}
+	for rows.Next() {
+		var block model.Block
+		var fieldsJSON []byte
+		if err := rows.Scan(&block.ID, &block.RootID, &block.Type, &block.Title, &fieldsJSON); err != nil {
+			return nil, err
+		}
+
+		if block.Type == "board" && block.ID == block.RootID {
+			if err := json.Unmarshal(fieldsJSON, &block.Fields); err != nil {
+				log.Printf(`findDuplicateBoards ERROR: invalid fields of board %s: %v`, block.ID, err)
+			}
+			boards[block.ID] = block
+		}
+		contents[block.RootID] = append(contents[block.RootID], block.Type+"\t"+block.Title)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*model.DuplicateBoards{}
+	for boardID, board := range boards {
+		content := contents[boardID]
+		sort.Strings(content)
+
+		hash := sha256.New()
+		fmt.Fprintln(hash, board.Title)
+		for _, line := range normalizedCardProperties(board) {
+			fmt.Fprintln(hash, line)
+		}
+		for _, line := range content {
+			fmt.Fprintln(hash, line)
+		}
+		key := hex.EncodeToString(hash.Sum(nil))
+
+		group, ok := groups[key]
+		if !ok {
+			group = &model.DuplicateBoards{Hash: key, Title: board.Title, BlockCount: len(content)}
+			groups[key] = group
+		}
+		group.BoardIDs = append(group.BoardIDs, boardID)
+	}
+
+	duplicates := []model.DuplicateBoards{}
+	for _, group := range groups {
+		if len(group.BoardIDs) > 1 {
+			sort.Strings(group.BoardIDs)
+			duplicates = append(duplicates, *group)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].BoardIDs[0] < duplicates[j].BoardIDs[0]
+	})
+
+	return duplicates, nil
+}
+
+// normalizedCardProperties returns a line per card property of a board, with
+// its name, type and sorted option values, in sorted order, so the boards
+// with the same properties compare equal whatever their order and IDs
+func normalizedCardProperties(board model.Block) []string {
+	cardProperties, _ := board.Fields["cardProperties"].([]interface{})
+
+	lines := make([]string, 0, len(cardProperties))
+	for _, p := range cardProperties {
+		property, _ := p.(map[string]interface{})
+		name, _ := property["name"].(string)
+		propertyType, _ := property["type"].(string)
+
+		values := []string{}
+		options, _ := property["options"].([]interface{})
+		for _, o := range options {
+			option, _ := o.(map[string]interface{})
+			if value, ok := option["value"].(string); ok {
+				values = append(values, value)
+			}
+		}
+		sort.Strings(values)
+
+		lines = append(lines, fmt.Sprintf("%q\t%q\t%q", name, propertyType, values))
+	}
+	sort.Strings(lines)
+
+	return lines
+}
+
+// ReassignBoards transfers the boards last modified by a user, which is who
+// they belong to in the workspace, to another user in a single transaction,
+// and returns the number of boards reassigned
+func (s *SQLStore) ReassignBoards(fromUserID, toUserID string) (int64, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned, err := s.reassignBoards(ctx, tx, fromUserID, toUserID)
+	if err != nil {
+		tx.Rollback()
+		log.Printf(`reassignBoards ERROR: %v`, err)
+
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return reassigned, nil
+}
+
+// reassignBoards saves a new version of each board last modified by a user,
+// modified by the other user, so the reassignment is in their history
+func (s *SQLStore) reassignBoards(ctx context.Context, tx *sql.Tx, fromUserID, toUserID string) (int64, error) {
+	query := s.getQueryBuilder().
+		Select("id", "COALESCE(workspace_id, '0')").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"type": "board"}).
+		Where(sq.Eq{"modified_by": fromUserID})
+
+	rows, err := sq.QueryContextWith(ctx, tx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	boardIDs := map[string][]string{}
+	for rows.Next() {
+		var boardID, workspaceID string
+		if err := rows.Scan(&boardID, &workspaceID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		boardIDs[workspaceID] = append(boardIDs[workspaceID], boardID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var reassigned int64
+	now := utils.GetMillis()
+	for workspaceID, ids := range boardIDs {
+		c := store.Container{WorkspaceID: workspaceID}
+
+		boards, err := s.queryBlocks(ctx, tx, s.getBlocksByIDsQuery(c, ids))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, board := range boards {
+			board.ModifiedBy = toUserID
+			board.UpdateAt = now
+			if err := s.insertBlock(ctx, tx, c, board); err != nil {
+				return 0, err
+			}
+			reassigned++
+		}
+	}
+
+	return reassigned, nil
+}
@@ -0,0 +1,126 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/storetests"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCachedTests(t *testing.T) (store.Store, func()) {
+	s, tearDown := SetupTests(t)
+	return store.NewCache(s, time.Hour), tearDown
+}
+
+func TestCache(t *testing.T) {
+	t.Run("the settings are cached until they're written", func(t *testing.T) {
+		s, tearDown := SetupTests(t)
+		defer tearDown()
+		cache := store.NewCache(s, time.Hour)
+
+		require.NoError(t, s.SetSystemSetting("FeatureFlag.Archive", "true"))
+		flags, err := cache.GetFeatureFlags()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"Archive": "true"}, flags)
+
+		// Written past the cache, so not seen until the cache is
+		require.NoError(t, s.SetSystemSetting("FeatureFlag.Archive", "false"))
+		flags, err = cache.GetFeatureFlags()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"Archive": "true"}, flags)
+
+		require.NoError(t, cache.SetSystemSetting("Setting", "value"))
+		settings, err := cache.GetSystemSettings()
+		require.NoError(t, err)
+		require.Equal(t, "false", settings["FeatureFlag.Archive"])
+		require.Equal(t, "value", settings["Setting"])
+
+		// The callers can't change the cached settings
+		settings["Setting"] = "changed"
+		settings, err = cache.GetSystemSettings()
+		require.NoError(t, err)
+		require.Equal(t, "value", settings["Setting"])
+	})
+
+	t.Run("the sessions are cached until they're deleted", func(t *testing.T) {
+		s, tearDown := SetupTests(t)
+		defer tearDown()
+		cache := store.NewCache(s, time.Hour)
+
+		for _, session := range []model.Session{
+			{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+			{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{}},
+		} {
+			session := session
+			require.NoError(t, s.CreateSession(&session))
+		}
+
+		sessions, err := cache.GetRecentSessions(60, 10)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		settingsCount, sessionsCount := cache.CachedCounts()
+		require.Equal(t, 0, settingsCount)
+		require.Equal(t, 2, sessionsCount)
+
+		// Deleted past the cache, so still served
+		require.NoError(t, s.DeleteSession("session-1"))
+		session, err := cache.GetSession("token-1", 60)
+		require.NoError(t, err)
+		require.Equal(t, "user-1", session.UserID)
+
+		require.NoError(t, cache.DeleteSession("session-1"))
+		_, err = cache.GetSession("token-1", 60)
+		require.Error(t, err)
+
+		require.NoError(t, s.CreateUser(&model.User{ID: "user-2", Username: "user-2", Email: "user-2@example.com"}))
+		require.NoError(t, cache.DeleteUser("user-2"))
+		_, err = cache.GetSession("token-2", 60)
+		require.Error(t, err)
+	})
+
+	t.Run("the expired sessions aren't served", func(t *testing.T) {
+		s, tearDown := SetupTests(t)
+		defer tearDown()
+		cache := store.NewCache(s, time.Hour)
+
+		session := model.Session{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}}
+		require.NoError(t, s.CreateSession(&session))
+
+		// Last used half a minute ago
+		sqlStore := s.(*SQLStore)
+		_, err := sqlStore.getQueryBuilder().
+			Update(sqlStore.tablePrefix+"sessions").
+			Set("update_at", time.Now().Unix()-30).
+			Where(sq.Eq{"id": "session-1"}).
+			Exec()
+		require.NoError(t, err)
+
+		_, err = cache.GetSession("token-1", 60)
+		require.NoError(t, err)
+		_, err = cache.GetSession("token-1", 10)
+		require.Error(t, err)
+	})
+
+	t.Run("nothing is cached without a TTL", func(t *testing.T) {
+		s, tearDown := SetupTests(t)
+		defer tearDown()
+		cache := store.NewCache(s, 0)
+
+		require.NoError(t, s.SetSystemSetting("Setting", "first-value"))
+		_, err := cache.GetSystemSettings()
+		require.NoError(t, err)
+
+		require.NoError(t, s.SetSystemSetting("Setting", "second-value"))
+		settings, err := cache.GetSystemSettings()
+		require.NoError(t, err)
+		require.Equal(t, "second-value", settings["Setting"])
+	})
+}
+
+func TestCachedStore(t *testing.T) {
+	t.Run("UsersStore", func(t *testing.T) { storetests.StoreTestUsersStore(t, setupCachedTests) })
+}
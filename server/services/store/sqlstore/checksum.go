@@ -0,0 +1,52 @@
+package sqlstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// GetBoardChecksum returns a hash of the IDs and update times of the blocks
+// of a board, which changes whenever a block is added, edited or deleted, so
+// the clients can tell if a board changed without comparing its blocks. The
+// rows are read in the order of their IDs and hashed as they're scanned, so
+// the board is never loaded in memory as a whole.
+func (s *SQLStore) GetBoardChecksum(c store.Container, boardID string) (string, error) {
+	query := s.getQueryBuilder().
+		Select("id", "update_at").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Or{sq.Eq{"root_id": boardID}, sq.Eq{"id": boardID}}).
+		OrderBy("id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBoardChecksum ERROR: %v`, err)
+
+		return "", err
+	}
+	defer rows.Close()
+
+	hash := sha256.New()
+	for rows.Next() {
+		var id string
+		var updateAt int64
+		if err := rows.Scan(&id, &updateAt); err != nil {
+			log.Printf(`getBoardChecksum ERROR: %v`, err)
+
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s:%d\n", id, updateAt)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf(`getBoardChecksum ERROR: %v`, err)
+
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
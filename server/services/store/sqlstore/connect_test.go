@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailableDriver refuses the first connections, like a database that is
+// still starting
+type unavailableDriver struct {
+	sqlite3.SQLiteDriver
+
+	mu       sync.Mutex
+	refusals int
+	attempts int
+}
+
+func (d *unavailableDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	d.attempts++
+	refuse := d.attempts <= d.refusals
+	d.mu.Unlock()
+
+	if refuse {
+		return nil, errors.New("connection refused")
+	}
+
+	return d.SQLiteDriver.Open(name)
+}
+
+var testUnavailableDriver = &unavailableDriver{}
+
+func init() {
+	sql.Register("sqlite3-unavailable", testUnavailableDriver)
+}
+
+func TestNewRetriesConnection(t *testing.T) {
+	defer func() { sqlOpen = sql.Open }()
+	sqlOpen = func(dbType, connectionString string) (*sql.DB, error) {
+		return sql.Open("sqlite3-unavailable", connectionString)
+	}
+
+	refuse := func(refusals int) {
+		testUnavailableDriver.mu.Lock()
+		defer testUnavailableDriver.mu.Unlock()
+		testUnavailableDriver.refusals = refusals
+		testUnavailableDriver.attempts = 0
+	}
+
+	t.Run("the store comes up once the database is ready", func(t *testing.T) {
+		refuse(3)
+
+		store, err := New(sqliteDBType, ":memory:", "test_", 5, time.Millisecond)
+		require.NoError(t, err)
+		defer store.Shutdown()
+
+		_, err = store.GetSystemSettings()
+		require.NoError(t, err)
+	})
+
+	t.Run("give up after the retries", func(t *testing.T) {
+		refuse(3)
+
+		_, err := New(sqliteDBType, ":memory:", "test_", 2, time.Millisecond)
+		require.EqualError(t, err, "connection refused")
+		require.Equal(t, 3, testUnavailableDriver.attempts)
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		refuse(1)
+
+		_, err := New(sqliteDBType, ":memory:", "test_", 0, time.Millisecond)
+		require.Error(t, err)
+		require.Equal(t, 1, testUnavailableDriver.attempts)
+	})
+}
@@ -0,0 +1,38 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// CopyCard inserts the copy of a card with the copies of its content in a
+// single transaction, so a failed copy leaves nothing behind. The content
+// must belong to the same board as the card.
+func (s *SQLStore) CopyCard(c store.Container, card model.Block, content []model.Block) error {
+	if card.Type != "card" {
+		return errors.New("the block to copy isn't a card")
+	}
+	for _, block := range content {
+		if block.RootID != card.RootID {
+			return errors.New("the content must belong to the board of the card")
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range append([]model.Block{card}, content...) {
+		if err := s.insertBlock(ctx, tx, c, block); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
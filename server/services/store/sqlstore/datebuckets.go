@@ -0,0 +1,178 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// GroupCardsByDate counts the cards of a board per day, week or month of the
+// start of their value of a date property, the earliest bucket first. The
+// date properties store a JSON object with the start time in milliseconds
+// under "from", and the cards without one aren't counted.
+func (s *SQLStore) GroupCardsByDate(c store.Container, boardID, datePropertyID, bucket string) ([]model.DateBucket, error) {
+	if err := checkPropertyID(datePropertyID); err != nil {
+		return nil, err
+	}
+	if !model.IsValidDateBucket(bucket) {
+		return nil, fmt.Errorf("invalid date bucket %q", bucket)
+	}
+
+	query := s.getQueryBuilder().
+		Select().
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"root_id": boardID}).
+		Where(sq.Eq{"type": "card"}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+
+	if !s.jsonSupported {
+		return s.groupCardsByDateWithoutJSON(query, datePropertyID, bucket)
+	}
+
+	// The start time is extracted by a subquery so the bucket expression can
+	// refer to it by name
+	rows, err := s.getQueryBuilder().
+		Select(s.dateBucketStart(bucket), "COUNT(*)").
+		FromSelect(query.Column(s.dateFromValue(datePropertyID)), "d").
+		Where("d.date_from IS NOT NULL").
+		GroupBy("1").
+		OrderBy("1").
+		Query()
+	if err != nil {
+		log.Printf(`groupCardsByDate ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []model.DateBucket{}
+	for rows.Next() {
+		var dateBucket model.DateBucket
+		if err := rows.Scan(&dateBucket.Start, &dateBucket.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, dateBucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+// dateFromValue returns the expression of the start time of the value of a
+// date property, as date_from, which is NULL for the cards without a valid
+// one
+func (s *SQLStore) dateFromValue(propertyID string) sq.Sqlizer {
+	jsonPath := fmt.Sprintf(`$.properties."%s"`, propertyID)
+
+	switch s.dbType {
+	case postgresDBType:
+		// There's no function to check if a text is valid JSON, so the start
+		// time is matched instead of parsed. The pattern can't have a ? as
+		// it's the placeholder of the arguments
+		return sq.Expr(`CAST(substring(fields->'properties'->>? from '"from"\s*:\s*(-{0,1}[0-9]+)') AS BIGINT) AS date_from`, propertyID)
+	case mysqlDBType:
+		value := "JSON_UNQUOTE(JSON_EXTRACT(fields, ?))"
+		return sq.Expr(
+			"CASE WHEN JSON_VALID("+value+") THEN CASE WHEN JSON_TYPE(JSON_EXTRACT("+value+", '$.from')) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL')"+
+				" THEN CAST(JSON_EXTRACT("+value+", '$.from') AS SIGNED) END END AS date_from",
+			jsonPath, jsonPath, jsonPath,
+		)
+	default:
+		value := "json_extract(fields, ?)"
+		return sq.Expr(
+			"CASE WHEN json_valid("+value+") THEN CASE WHEN json_type("+value+", '$.from') IN ('integer', 'real')"+
+				" THEN CAST(json_extract("+value+", '$.from') AS INTEGER) END END AS date_from",
+			jsonPath, jsonPath, jsonPath,
+		)
+	}
+}
+
+// dateBucketStart returns the expression of the first day of the bucket of
+// date_from, in UTC
+func (s *SQLStore) dateBucketStart(bucket string) string {
+	switch s.dbType {
+	case postgresDBType:
+		return fmt.Sprintf("to_char(date_trunc('%s', to_timestamp(date_from / 1000.0) AT TIME ZONE 'UTC'), 'YYYY-MM-DD')", bucket)
+	case mysqlDBType:
+		date := "DATE_ADD('1970-01-01', INTERVAL FLOOR(date_from / 1000) SECOND)"
+		switch bucket {
+		case model.DateBucketWeek:
+			return fmt.Sprintf("DATE_FORMAT(DATE_SUB(%s, INTERVAL WEEKDAY(%s) DAY), '%%Y-%%m-%%d')", date, date)
+		case model.DateBucketMonth:
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-01')", date)
+		default:
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", date)
+		}
+	default:
+		switch bucket {
+		case model.DateBucketWeek:
+			// The next Sunday, or the same day, minus six days
+			return "strftime('%Y-%m-%d', date_from / 1000, 'unixepoch', 'weekday 0', '-6 days')"
+		case model.DateBucketMonth:
+			return "strftime('%Y-%m-01', date_from / 1000, 'unixepoch')"
+		default:
+			return "strftime('%Y-%m-%d', date_from / 1000, 'unixepoch')"
+		}
+	}
+}
+
+// groupCardsByDateWithoutJSON groups the cards in memory, for the databases
+// that can't extract the property in the query
+func (s *SQLStore) groupCardsByDateWithoutJSON(query sq.SelectBuilder, datePropertyID, bucket string) ([]model.DateBucket, error) {
+	rows, err := query.Columns("COALESCE(fields, '{}')").Query()
+	if err != nil {
+		log.Printf(`groupCardsByDate ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var fieldsJSON string
+		if err := rows.Scan(&fieldsJSON); err != nil {
+			return nil, err
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(fieldsJSON), &fields); err != nil {
+			log.Printf("groupCardsByDate ERROR unmarshalling fields: %v", err)
+			return nil, err
+		}
+
+		properties, _ := fields["properties"].(map[string]interface{})
+		value, _ := properties[datePropertyID].(string)
+
+		var date struct {
+			From *int64 `json:"from"`
+		}
+		if err := json.Unmarshal([]byte(value), &date); err != nil || date.From == nil {
+			continue
+		}
+
+		start := time.Unix(*date.From/1000, 0).UTC()
+		switch bucket {
+		case model.DateBucketWeek:
+			start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		case model.DateBucketMonth:
+			start = start.AddDate(0, 0, 1-start.Day())
+		}
+		counts[start.Format("2006-01-02")]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	buckets := make([]model.DateBucket, 0, len(counts))
+	for start, count := range counts {
+		buckets = append(buckets, model.DateBucket{Start: start, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start < buckets[j].Start
+	})
+
+	return buckets, nil
+}
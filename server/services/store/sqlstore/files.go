@@ -0,0 +1,485 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"path/filepath"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+)
+
+// CreateFileRef maps a file to the blob of its content, adding a reference to
+// the blob or creating it if it's the first file with that content. It
+// returns whether the blob was created. A blob created by a concurrent
+// upload in between counts as already existing.
+func (s *SQLStore) CreateFileRef(ref model.FileRef, size int64) (bool, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	updateQuery := s.getQueryBuilder().
+		Update(s.tablePrefix+"file_blobs").
+		Set("ref_count", sq.Expr("ref_count + 1")).
+		Where(sq.Eq{"hash": ref.Hash})
+
+	updated, err := s.execRowsAffected(ctx, tx, updateQuery)
+	if err != nil {
+		log.Printf(`createFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return false, err
+	}
+
+	created := false
+	if updated == 0 {
+		insertBlobQuery := s.getQueryBuilder().
+			Insert(s.tablePrefix+"file_blobs").
+			Columns("hash", "size", "ref_count", "create_at").
+			Values(ref.Hash, size, 1, ref.CreateAt)
+		if s.dbType == mysqlDBType {
+			insertBlobQuery = insertBlobQuery.Options("IGNORE")
+		} else {
+			insertBlobQuery = insertBlobQuery.Suffix("ON CONFLICT (hash) DO NOTHING")
+		}
+
+		inserted, err := s.execRowsAffected(ctx, tx, insertBlobQuery)
+		if err != nil {
+			log.Printf(`createFileRef ERROR: %v`, err)
+			tx.Rollback()
+			return false, err
+		}
+
+		created = inserted > 0
+		if !created {
+			if _, err := s.execRowsAffected(ctx, tx, updateQuery); err != nil {
+				log.Printf(`createFileRef ERROR: %v`, err)
+				tx.Rollback()
+				return false, err
+			}
+		}
+	}
+
+	insertRefQuery := s.getQueryBuilder().
+		Insert(s.tablePrefix+"file_refs").
+		Columns("id", "workspace_id", "root_id", "hash", "create_at").
+		Values(ref.ID, ref.WorkspaceID, ref.RootID, ref.Hash, ref.CreateAt)
+
+	if _, err := sq.ExecContextWith(ctx, tx, insertRefQuery); err != nil {
+		log.Printf(`createFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return false, err
+	}
+
+	return created, tx.Commit()
+}
+
+func (s *SQLStore) execRowsAffected(ctx context.Context, tx *sql.Tx, query sq.Sqlizer) (int64, error) {
+	result, err := sq.ExecContextWith(ctx, tx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *SQLStore) GetFileRef(id string) (*model.FileRef, error) {
+	query := s.getQueryBuilder().
+		Select("id", "workspace_id", "root_id", "hash", "create_at").
+		From(s.tablePrefix + "file_refs").
+		Where(sq.Eq{"id": id})
+
+	var ref model.FileRef
+	err := query.QueryRow().Scan(&ref.ID, &ref.WorkspaceID, &ref.RootID, &ref.Hash, &ref.CreateAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ref, nil
+}
+
+// GetWorkspaceFileRefs returns the files of a workspace, oldest first
+func (s *SQLStore) GetWorkspaceFileRefs(workspaceID string) ([]model.FileRef, error) {
+	query := s.getQueryBuilder().
+		Select("id", "workspace_id", "root_id", "hash", "create_at").
+		From(s.tablePrefix+"file_refs").
+		Where(sq.Eq{"workspace_id": workspaceID}).
+		OrderBy("create_at", "id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getWorkspaceFileRefs ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []model.FileRef{}
+	for rows.Next() {
+		var ref model.FileRef
+		if err := rows.Scan(&ref.ID, &ref.WorkspaceID, &ref.RootID, &ref.Hash, &ref.CreateAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+func (s *SQLStore) GetFileBlob(hash string) (*model.FileBlob, error) {
+	query := s.getQueryBuilder().
+		Select("hash", "size", "ref_count", "create_at").
+		From(s.tablePrefix + "file_blobs").
+		Where(sq.Eq{"hash": hash})
+
+	var blob model.FileBlob
+	err := query.QueryRow().Scan(&blob.Hash, &blob.Size, &blob.RefCount, &blob.CreateAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blob, nil
+}
+
+// DeleteFileRef removes a file and its reference to the blob of its content,
+// returning sql.ErrNoRows if the file doesn't exist. The blob is removed with
+// its last reference, and then its content is removed with removeBlob before
+// the transaction commits, so an upload of the same content waits for the
+// removal instead of having its content removed. Nothing is deleted if
+// removeBlob fails.
+func (s *SQLStore) DeleteFileRef(id string, removeBlob func(hash string) error) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	hashQuery := s.getQueryBuilder().
+		Select("hash").
+		From(s.tablePrefix + "file_refs").
+		Where(sq.Eq{"id": id})
+
+	rows, err := sq.QueryContextWith(ctx, tx, hashQuery)
+	if err != nil {
+		log.Printf(`deleteFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return err
+	}
+
+	var hash string
+	if rows.Next() {
+		err = rows.Scan(&hash)
+	} else if err = rows.Err(); err == nil {
+		err = sql.ErrNoRows
+	}
+	rows.Close()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	deleteRefQuery := s.getQueryBuilder().
+		Delete(s.tablePrefix + "file_refs").
+		Where(sq.Eq{"id": id})
+
+	if _, err := sq.ExecContextWith(ctx, tx, deleteRefQuery); err != nil {
+		log.Printf(`deleteFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return err
+	}
+
+	updateQuery := s.getQueryBuilder().
+		Update(s.tablePrefix+"file_blobs").
+		Set("ref_count", sq.Expr("ref_count - 1")).
+		Where(sq.Eq{"hash": hash})
+
+	if _, err := sq.ExecContextWith(ctx, tx, updateQuery); err != nil {
+		log.Printf(`deleteFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return err
+	}
+
+	deleteBlobQuery := s.getQueryBuilder().
+		Delete(s.tablePrefix + "file_blobs").
+		Where(sq.Eq{"hash": hash}).
+		Where(sq.LtOrEq{"ref_count": 0})
+
+	result, err := sq.ExecContextWith(ctx, tx, deleteBlobQuery)
+	if err != nil {
+		log.Printf(`deleteFileRef ERROR: %v`, err)
+		tx.Rollback()
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if deleted > 0 {
+		if err := removeBlob(hash); err != nil {
+			log.Printf(`deleteFileRef ERROR: %v`, err)
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetWorkspaceStorageUsage returns the bytes used by the files of the
+// workspace. The blobs shared by several files are counted for each of them.
+func (s *SQLStore) GetWorkspaceStorageUsage(workspaceID string) (int64, error) {
+	query := s.getQueryBuilder().
+		Select("COALESCE(SUM(b.size), 0)").
+		From(s.tablePrefix + "file_refs r").
+		Join(s.tablePrefix + "file_blobs b ON b.hash = r.hash").
+		Where(sq.Eq{"r.workspace_id": workspaceID})
+
+	var usage int64
+	if err := query.QueryRow().Scan(&usage); err != nil {
+		log.Printf(`getWorkspaceStorageUsage ERROR: %v`, err)
+		return 0, err
+	}
+
+	return usage, nil
+}
+
+// The directories of the files storage the app writes besides the ones of
+// the workspaces
+const (
+	blobsDirectory    = "blobs"
+	uploadsDirectory  = "uploads"
+	archivesDirectory = "archive"
+)
+
+// errNoFilesBackend is returned when scanning the files storage of a store
+// that wasn't given one
+var errNoFilesBackend = errors.New("no files storage to scan")
+
+// orphanedFilesBatchSize is the number of hashes looked up by each query, to
+// stay under the limit of query parameters of the databases
+const orphanedFilesBatchSize = 500
+
+// SetFilesBackend sets the files storage scanned by FindOrphanedFiles and
+// read by ExportWorkspace
+func (s *SQLStore) SetFilesBackend(backend filesstore.FileBackend) {
+	s.filesBackend = backend
+}
+
+// FindOrphanedFiles lists the files of the files storage that nothing
+// references, without removing them, so they can be reviewed before a
+// cleanup. The blobs are checked against the file references one prefix
+// directory at a time, and the files uploaded before the blobs, in the
+// directory of their board, against the blocks of the board one board at a
+// time, instead of listing the whole storage at once.
+func (s *SQLStore) FindOrphanedFiles() ([]model.FileInfo, error) {
+	if s.filesBackend == nil {
+		return nil, errNoFilesBackend
+	}
+
+	entries, err := s.filesBackend.ListDirectory("")
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []model.FileInfo{}
+	for _, entry := range entries {
+		var found []model.FileInfo
+		switch name := filepath.Base(entry); {
+		case name == blobsDirectory:
+			found, err = s.findOrphanedBlobs(entry)
+		case name == uploadsDirectory || name == archivesDirectory:
+			continue
+		case filepath.Ext(name) != "":
+			// The files of the root workspace stored outside of its
+			// directory are moved into it when they're read
+			continue
+		default:
+			found, err = s.findOrphanedLegacyFiles(entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, found...)
+	}
+
+	return orphans, nil
+}
+
+func (s *SQLStore) findOrphanedBlobs(directory string) ([]model.FileInfo, error) {
+	prefixes, err := s.filesBackend.ListDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []model.FileInfo{}
+	for _, prefix := range prefixes {
+		paths, err := s.filesBackend.ListDirectory(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		hashes := make([]string, 0, len(paths))
+		for _, path := range paths {
+			hashes = append(hashes, filepath.Base(path))
+		}
+
+		referenced, err := s.referencedHashes(hashes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			if hash := filepath.Base(path); !referenced[hash] {
+				orphans = append(orphans, s.orphanedFileInfo(path, hash))
+			}
+		}
+	}
+
+	return orphans, nil
+}
+
+// findOrphanedLegacyFiles lists the files of the boards of a workspace
+// directory that no block of their board references
+func (s *SQLStore) findOrphanedLegacyFiles(directory string) ([]model.FileInfo, error) {
+	boardDirectories, err := s.filesBackend.ListDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	c := store.Container{WorkspaceID: filepath.Base(directory)}
+	orphans := []model.FileInfo{}
+	for _, boardDirectory := range boardDirectories {
+		paths, err := s.filesBackend.ListDirectory(boardDirectory)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		blocks, err := s.GetBlocksWithRootID(c, filepath.Base(boardDirectory))
+		if err != nil {
+			return nil, err
+		}
+
+		referenced := map[string]bool{}
+		for _, block := range blocks {
+			if fileID, _ := block.Fields["fileId"].(string); fileID != "" {
+				referenced[fileID] = true
+			}
+		}
+
+		for _, path := range paths {
+			if !referenced[filepath.Base(path)] {
+				orphans = append(orphans, s.orphanedFileInfo(path, ""))
+			}
+		}
+	}
+
+	return orphans, nil
+}
+
+func (s *SQLStore) orphanedFileInfo(path, hash string) model.FileInfo {
+	info := model.FileInfo{Path: path, Hash: hash}
+	if size, err := s.filesBackend.FileSize(path); err == nil {
+		info.Size = size
+	}
+	if modTime, err := s.filesBackend.FileModTime(path); err == nil {
+		info.UpdateAt = modTime.UnixNano() / int64(time.Millisecond)
+	}
+
+	return info
+}
+
+// referencedHashes returns which of the hashes of blobs a file references
+func (s *SQLStore) referencedHashes(hashes []string) (map[string]bool, error) {
+	referenced := map[string]bool{}
+	for start := 0; start < len(hashes); start += orphanedFilesBatchSize {
+		end := start + orphanedFilesBatchSize
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		query := s.getQueryBuilder().
+			Select("DISTINCT hash").
+			From(s.tablePrefix + "file_refs").
+			Where(sq.Eq{"hash": hashes[start:end]})
+
+		rows, err := query.Query()
+		if err != nil {
+			log.Printf(`findOrphanedFiles ERROR: %v`, err)
+			return nil, err
+		}
+
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			referenced[hash] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return referenced, nil
+}
+
+func (s *SQLStore) CreateUploadSession(session model.UploadSession) error {
+	query := s.getQueryBuilder().
+		Insert(s.tablePrefix+"upload_sessions").
+		Columns("id", "workspace_id", "root_id", "path", "create_at", "update_at").
+		Values(session.ID, session.WorkspaceID, session.RootID, session.Path, session.CreateAt, session.UpdateAt)
+
+	_, err := query.Exec()
+	return err
+}
+
+// GetAbandonedUploadSessions returns the uploads without writes since the
+// olderThan time, in milliseconds
+func (s *SQLStore) GetAbandonedUploadSessions(olderThan int64) ([]model.UploadSession, error) {
+	query := s.getQueryBuilder().
+		Select("id", "workspace_id", "root_id", "path", "create_at", "update_at").
+		From(s.tablePrefix+"upload_sessions").
+		Where(sq.Lt{"update_at": olderThan}).
+		OrderBy("update_at", "id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getAbandonedUploadSessions ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.UploadSession{}
+	for rows.Next() {
+		var session model.UploadSession
+		err := rows.Scan(&session.ID, &session.WorkspaceID, &session.RootID, &session.Path, &session.CreateAt, &session.UpdateAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *SQLStore) DeleteUploadSession(id string) error {
+	query := s.getQueryBuilder().
+		Delete(s.tablePrefix + "upload_sessions").
+		Where(sq.Eq{"id": id})
+
+	_, err := query.Exec()
+	return err
+}
@@ -0,0 +1,150 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// MergeBlocks merges the source block into the target one in a single
+// transaction. The children of the source, like its comments and content,
+// are moved to the target, the properties are combined following the
+// strategy, and the source is deleted keeping its history.
+func (s *SQLStore) MergeBlocks(c store.Container, targetID, sourceID string, strategy store.MergeStrategy, modifiedBy string) error {
+	if targetID == sourceID {
+		return errors.New("a block can't be merged into itself")
+	}
+	if strategy != store.MergePreferTarget && strategy != store.MergePreferSource {
+		return fmt.Errorf("invalid merge strategy %q", strategy)
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := sq.QueryContextWith(ctx, tx, s.getBlocksByIDsQuery(c, []string{targetID, sourceID}))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	blocks, err := blocksFromRows(rows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var target, source *model.Block
+	for i := range blocks {
+		switch blocks[i].ID {
+		case targetID:
+			target = &blocks[i]
+		case sourceID:
+			source = &blocks[i]
+		}
+	}
+
+	missing := []string{}
+	if target == nil {
+		missing = append(missing, targetID)
+	}
+	if source == nil {
+		missing = append(missing, sourceID)
+	}
+	if len(missing) > 0 {
+		tx.Rollback()
+		return &store.ErrBlocksNotFound{BlockIDs: missing}
+	}
+
+	if target.Type != source.Type {
+		tx.Rollback()
+		return fmt.Errorf("can't merge a %s into a %s", source.Type, target.Type)
+	}
+
+	rows, err = sq.QueryContextWith(ctx, tx, s.getBlocksWithParentQuery(c, sourceID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	children, err := blocksFromRows(rows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	mergeFields(target, source, strategy)
+
+	now := utils.GetMillis()
+	target.ModifiedBy = modifiedBy
+	target.UpdateAt = now
+	err = s.insertBlock(ctx, tx, c, *target)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, child := range children {
+		child.ParentID = target.ID
+		child.RootID = target.RootID
+		child.ModifiedBy = modifiedBy
+		child.UpdateAt = now
+
+		err = s.insertBlock(ctx, tx, c, child)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = s.deleteBlock(ctx, tx, c, sourceID, modifiedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// mergeFields copies the non empty properties of the source into the target,
+// keeping the value of the card the strategy prefers when both have one, and
+// appends the content order of the source to the target's
+func mergeFields(target, source *model.Block, strategy store.MergeStrategy) {
+	if target.Fields == nil {
+		target.Fields = map[string]interface{}{}
+	}
+
+	targetProperties, _ := target.Fields["properties"].(map[string]interface{})
+	if targetProperties == nil {
+		targetProperties = map[string]interface{}{}
+	}
+	sourceProperties, _ := source.Fields["properties"].(map[string]interface{})
+	for propertyID, value := range sourceProperties {
+		if value == "" {
+			continue
+		}
+		current, ok := targetProperties[propertyID]
+		if !ok || current == "" || strategy == store.MergePreferSource {
+			targetProperties[propertyID] = value
+		}
+	}
+	target.Fields["properties"] = targetProperties
+
+	sourceOrder, _ := source.Fields["contentOrder"].([]interface{})
+	if len(sourceOrder) > 0 {
+		targetOrder, _ := target.Fields["contentOrder"].([]interface{})
+		target.Fields["contentOrder"] = append(targetOrder, sourceOrder...)
+	}
+}
@@ -2,11 +2,16 @@ package sqlstore
 
 import (
 	"bytes"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -18,6 +23,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
 	_ "github.com/lib/pq"
+	"github.com/mattermost/focalboard/server/services/store"
 	"github.com/mattermost/focalboard/server/services/store/sqlstore/migrations"
 )
 
@@ -117,3 +123,57 @@ func (s *SQLStore) Migrate() error {
 
 	return nil
 }
+
+// GetSchemaVersion returns the last migration applied and the ones pending.
+// It only reads the migrations table, which may not exist yet.
+func (s *SQLStore) GetSchemaVersion() (store.SchemaVersion, error) {
+	result := store.SchemaVersion{Pending: []string{}}
+	migrationsTable := fmt.Sprintf("%sschema_migrations", s.tablePrefix)
+
+	var tableQuery string
+	switch s.dbType {
+	case sqliteDBType:
+		tableQuery = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case postgresDBType:
+		tableQuery = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	case mysqlDBType:
+		tableQuery = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	default:
+		return result, store.ErrNotSupported
+	}
+
+	var tables int
+	if err := s.db.QueryRow(tableQuery, migrationsTable).Scan(&tables); err != nil {
+		log.Printf(`getSchemaVersion ERROR: %v`, err)
+		return result, err
+	}
+
+	if tables > 0 {
+		var version int64
+		err := s.db.QueryRow(fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", migrationsTable)).Scan(&version, &result.Dirty)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf(`getSchemaVersion ERROR: %v`, err)
+			return result, err
+		}
+		if version > 0 {
+			result.Version = uint(version)
+		}
+	}
+
+	for _, name := range migrations.AssetNames() {
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		version, err := strconv.ParseUint(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return result, fmt.Errorf("invalid migration name %q: %w", name, err)
+		}
+		if uint(version) > result.Version {
+			result.Pending = append(result.Pending, strings.TrimSuffix(name, ".up.sql"))
+		}
+	}
+	sort.Strings(result.Pending)
+
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package sqlstore
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/services/store/sqlstore/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunMigrations(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "focalboard.db")
+
+	migrationCount := 0
+	for _, name := range migrations.AssetNames() {
+		if strings.HasSuffix(name, ".up.sql") {
+			migrationCount++
+		}
+	}
+
+	pending, err := DryRunMigrations(sqliteDBType, filename, "test_")
+	require.NoError(t, err)
+	require.Len(t, pending, migrationCount)
+	require.Equal(t, "000001_init", pending[0])
+
+	// Nothing was applied, not even the migrations table was created
+	s, err := connect(sqliteDBType, filename, "test_")
+	require.NoError(t, err)
+	var tables int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables))
+	require.Zero(t, tables)
+	require.NoError(t, s.Shutdown())
+
+	migrated, err := New(sqliteDBType, filename, "test_", 0, 0)
+	require.NoError(t, err)
+	version, err := migrated.GetSchemaVersion()
+	require.NoError(t, err)
+	require.EqualValues(t, migrationCount, version.Version)
+	require.False(t, version.Dirty)
+	require.Empty(t, version.Pending)
+	require.NoError(t, migrated.Shutdown())
+
+	pending, err = DryRunMigrations(sqliteDBType, filename, "test_")
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestMigrateHistoryDeletionsToMillis(t *testing.T) {
+	store, tearDown := SetupTests(t)
+	defer tearDown()
+	s := store.(*SQLStore)
+	if s.dbType != sqliteDBType {
+		t.Skip("rolls the migrations table back the SQLite way")
+	}
+
+	insert := func(id string, updateAt, deleteAt int64) {
+		_, err := s.historyInsert(
+			[]string{"workspace_id", "id", "modified_by", "update_at", "delete_at"},
+			[]interface{}{"0", id, "user-1", updateAt, deleteAt},
+		).RunWith(s.db).Exec()
+		require.NoError(t, err)
+	}
+	// Deleted in seconds before the deletions were in milliseconds, and
+	// after, and a version that wasn't deleted
+	insert("block-1", 1600000000, 1600000000)
+	insert("block-2", 1600000000000, 1600000000000)
+	insert("block-3", 1600000000, 0)
+
+	// The migration runs again over the entries, from the version before it,
+	// with the index of the later migration undone
+	_, err := s.db.Exec("UPDATE test_schema_migrations SET version = 21")
+	require.NoError(t, err)
+	_, err = s.db.Exec("DROP INDEX idx_test_blocks_history_root_id")
+	require.NoError(t, err)
+	require.NoError(t, s.Migrate())
+
+	times := map[string][2]int64{}
+	rows, err := s.db.Query("SELECT id, update_at, delete_at FROM test_blocks_history WHERE id LIKE 'block-%'")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var updateAt, deleteAt int64
+		require.NoError(t, rows.Scan(&id, &updateAt, &deleteAt))
+		times[id] = [2]int64{updateAt, deleteAt}
+	}
+	require.NoError(t, rows.Err())
+
+	require.Equal(t, map[string][2]int64{
+		"block-1": {1600000000000, 1600000000000},
+		"block-2": {1600000000000, 1600000000000},
+		"block-3": {1600000000, 0},
+	}, times)
+}
@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// SetBlockMigrationFlag tags the blocks with the flag of a data migration,
+// in a side table so the blocks themselves aren't modified. The blocks that
+// already have the flag keep the time they were first tagged.
+func (s *SQLStore) SetBlockMigrationFlag(blockIDs []string, flag string) error {
+	if flag == "" {
+		return errors.New("a migration flag is required")
+	}
+	if len(blockIDs) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	now := utils.GetMillis()
+	for _, blockID := range blockIDs {
+		query := s.getQueryBuilder().
+			Insert(s.tablePrefix+"block_migration_flags").
+			Columns("block_id", "flag", "create_at").
+			Values(blockID, flag, now)
+		if s.dbType == mysqlDBType {
+			query = query.Options("IGNORE")
+		} else {
+			query = query.Suffix("ON CONFLICT (flag, block_id) DO NOTHING")
+		}
+
+		if _, err := sq.ExecContextWith(ctx, tx, query); err != nil {
+			tx.Rollback()
+			log.Printf(`setBlockMigrationFlag ERROR: %v`, err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBlocksByMigrationFlag returns the blocks tagged with a migration flag,
+// in the order they were tagged, whether or not they still exist
+func (s *SQLStore) GetBlocksByMigrationFlag(flag string) ([]model.BlockMigrationFlag, error) {
+	query := s.getQueryBuilder().
+		Select("block_id", "flag", "create_at").
+		From(s.tablePrefix+"block_migration_flags").
+		Where(sq.Eq{"flag": flag}).
+		OrderBy("create_at", "block_id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBlocksByMigrationFlag ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := []model.BlockMigrationFlag{}
+	for rows.Next() {
+		var f model.BlockMigrationFlag
+		if err := rows.Scan(&f.BlockID, &f.Flag, &f.CreateAt); err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+
+	return flags, rows.Err()
+}
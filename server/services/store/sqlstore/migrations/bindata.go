@@ -18,6 +18,34 @@
 // migrations_files/000008_teams.up.sql (304B)
 // migrations_files/000009_blocks_history.down.sql (97B)
 // migrations_files/000009_blocks_history.up.sql (1.188kB)
+// migrations_files/000010_blocks_archived.down.sql (52B)
+// migrations_files/000010_blocks_archived.up.sql (82B)
+// migrations_files/000011_sessions_device_fingerprint.down.sql (64B)
+// migrations_files/000011_sessions_device_fingerprint.up.sql (75B)
+// migrations_files/000012_blocks_workspace_type_index.down.sql (93B)
+// migrations_files/000012_blocks_workspace_type_index.up.sql (132B)
+// migrations_files/000013_sharing_expire_at.down.sql (54B)
+// migrations_files/000013_sharing_expire_at.up.sql (79B)
+// migrations_files/000014_workspace_webhooks.down.sql (42B)
+// migrations_files/000014_workspace_webhooks.up.sql (375B)
+// migrations_files/000015_file_blobs.down.sql (67B)
+// migrations_files/000015_file_blobs.up.sql (579B)
+// migrations_files/000016_upload_sessions.down.sql (39B)
+// migrations_files/000016_upload_sessions.up.sql (387B)
+// migrations_files/000017_blocks_modified_by_index.down.sql (97B)
+// migrations_files/000017_blocks_modified_by_index.up.sql (104B)
+// migrations_files/000018_audit_logs.down.sql (44B)
+// migrations_files/000018_audit_logs.up.sql (449B)
+// migrations_files/000019_board_snapshots.down.sql (49B)
+// migrations_files/000019_board_snapshots.up.sql (432B)
+// migrations_files/000020_block_migration_flags.down.sql (55B)
+// migrations_files/000020_block_migration_flags.up.sql (252B)
+// migrations_files/000021_board_locks.down.sql (45B)
+// migrations_files/000021_board_locks.up.sql (274B)
+// migrations_files/000022_history_deletions_millis.down.sql (290B)
+// migrations_files/000022_history_deletions_millis.up.sql (250B)
+// migrations_files/000023_blocks_history_root_id_index.down.sql (99B)
+// migrations_files/000023_blocks_history_root_id_index.up.sql (91B)
 
 package migrations
 
@@ -446,6 +474,566 @@ func _000009_blocks_historyUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __000010_blocks_archivedDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x34\x00\xcb\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x0a\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x61\x72\x63\x68\x69\x76\x65\x64\x3b\x0a\x03\x00\x95\x14\xd4\x61\x34\x00\x00\x00")
+
+func _000010_blocks_archivedDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000010_blocks_archivedDownSql,
+		"000010_blocks_archived.down.sql",
+	)
+}
+
+func _000010_blocks_archivedDownSql() (*asset, error) {
+	bytes, err := _000010_blocks_archivedDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000010_blocks_archived.down.sql", size: 52, mode: os.FileMode(0644), modTime: time.Unix(1791966222, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xfc, 0xd3, 0xba, 0xfa, 0x2a, 0xce, 0x2f, 0x64, 0x8b, 0x53, 0xf3, 0x4c, 0x7f, 0x1e, 0x55, 0xc9, 0x18, 0x33, 0xed, 0x64, 0xc3, 0x68, 0x26, 0xfc, 0x46, 0x40, 0x1c, 0x6b, 0xf1, 0xc6, 0xa0, 0xaf}}
+	return a, nil
+}
+
+var __000010_blocks_archivedUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x52\x00\xad\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x0a\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x61\x72\x63\x68\x69\x76\x65\x64\x20\x42\x4f\x4f\x4c\x45\x41\x4e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x46\x41\x4c\x53\x45\x3b\x0a\x03\x00\xd1\xed\x41\xdc\x52\x00\x00\x00")
+
+func _000010_blocks_archivedUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000010_blocks_archivedUpSql,
+		"000010_blocks_archived.up.sql",
+	)
+}
+
+func _000010_blocks_archivedUpSql() (*asset, error) {
+	bytes, err := _000010_blocks_archivedUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000010_blocks_archived.up.sql", size: 82, mode: os.FileMode(0644), modTime: time.Unix(1791966222, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8b, 0x49, 0xbb, 0x86, 0xb0, 0x3e, 0x3, 0x5a, 0xe3, 0x4, 0xd, 0xf4, 0x2d, 0x40, 0xfc, 0x73, 0x3f, 0xab, 0x9, 0x7f, 0xe3, 0x54, 0xaf, 0x47, 0xab, 0xea, 0x1c, 0x89, 0xce, 0x5d, 0xcb, 0x9f}}
+	return a, nil
+}
+
+var __000011_sessions_device_fingerprintDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x40\x00\xbf\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x73\x65\x73\x73\x69\x6f\x6e\x73\x0a\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x76\x69\x63\x65\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x3b\x0a\x03\x00\x48\x3b\x8c\xc9\x40\x00\x00\x00")
+
+func _000011_sessions_device_fingerprintDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000011_sessions_device_fingerprintDownSql,
+		"000011_sessions_device_fingerprint.down.sql",
+	)
+}
+
+func _000011_sessions_device_fingerprintDownSql() (*asset, error) {
+	bytes, err := _000011_sessions_device_fingerprintDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000011_sessions_device_fingerprint.down.sql", size: 64, mode: os.FileMode(0644), modTime: time.Unix(1791967042, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xff, 0x89, 0x9f, 0x60, 0x92, 0x68, 0xf4, 0x89, 0xa2, 0x73, 0x6, 0x4, 0xd9, 0x99, 0x74, 0x73, 0xb, 0x42, 0x8d, 0x85, 0x4b, 0xb1, 0x43, 0x36, 0x53, 0x25, 0xab, 0xff, 0x65, 0xa, 0x67, 0xed}}
+	return a, nil
+}
+
+var __000011_sessions_device_fingerprintUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4b\x00\xb4\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x73\x65\x73\x73\x69\x6f\x6e\x73\x0a\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x76\x69\x63\x65\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x20\x56\x41\x52\x43\x48\x41\x52\x28\x36\x34\x29\x3b\x0a\x03\x00\xfa\x38\xaf\x38\x4b\x00\x00\x00")
+
+func _000011_sessions_device_fingerprintUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000011_sessions_device_fingerprintUpSql,
+		"000011_sessions_device_fingerprint.up.sql",
+	)
+}
+
+func _000011_sessions_device_fingerprintUpSql() (*asset, error) {
+	bytes, err := _000011_sessions_device_fingerprintUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000011_sessions_device_fingerprint.up.sql", size: 75, mode: os.FileMode(0644), modTime: time.Unix(1791967042, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb2, 0xae, 0x65, 0x1b, 0xc7, 0x50, 0x7c, 0x68, 0xd9, 0x88, 0x8f, 0xf0, 0x28, 0xe3, 0xca, 0x8b, 0xd2, 0x9b, 0xf4, 0x5, 0x97, 0x80, 0xd2, 0xea, 0x21, 0x65, 0xe8, 0x6, 0x64, 0xf5, 0x59, 0x36}}
+	return a, nil
+}
+
+var __000012_blocks_workspace_type_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5d\x00\xa2\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x77\x6f\x72\x6b\x73\x70\x61\x63\x65\x5f\x69\x64\x5f\x74\x79\x70\x65\x7b\x7b\x69\x66\x20\x2e\x6d\x79\x73\x71\x6c\x7d\x7d\x20\x4f\x4e\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x7b\x7b\x65\x6e\x64\x7d\x7d\x3b\x0a\x03\x00\xa6\x24\x73\xb4\x5d\x00\x00\x00")
+
+func _000012_blocks_workspace_type_indexDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000012_blocks_workspace_type_indexDownSql,
+		"000012_blocks_workspace_type_index.down.sql",
+	)
+}
+
+func _000012_blocks_workspace_type_indexDownSql() (*asset, error) {
+	bytes, err := _000012_blocks_workspace_type_indexDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000012_blocks_workspace_type_index.down.sql", size: 93, mode: os.FileMode(0644), modTime: time.Unix(1791967720, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe0, 0xf, 0x84, 0x43, 0xd2, 0x82, 0x38, 0xe3, 0xb8, 0xc0, 0xff, 0xf2, 0x6f, 0xe, 0x5c, 0x15, 0x3a, 0x5b, 0xfd, 0xd4, 0x85, 0x3c, 0x9b, 0x1b, 0x9b, 0xe2, 0xf4, 0x52, 0x93, 0x11, 0x1b, 0x1d}}
+	return a, nil
+}
+
+var __000012_blocks_workspace_type_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xc8\x4c\xa9\x88\xaf\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\x4d\xca\xc9\x4f\xce\x2e\x8e\x2f\xcf\x2f\xca\x2e\x2e\x48\x4c\x4e\x8d\xcf\x4c\x89\x2f\xa9\x2c\x48\x55\xf0\xf7\x53\xc0\x50\xa6\xa0\x81\xac\x4e\x47\xa1\xba\x3a\x33\x4d\x41\x2f\xb7\xb2\xb8\x30\xa7\xb6\x16\xa4\x4b\xc3\xd8\x48\xb3\xba\x3a\x35\xa7\x38\x15\xc2\xaf\xae\x4e\xcd\x4b\xa9\xad\xd5\xb4\xe6\x02\x0c\x00\x4c\xa5\x0d\xdf\x84\x00\x00\x00")
+
+func _000012_blocks_workspace_type_indexUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000012_blocks_workspace_type_indexUpSql,
+		"000012_blocks_workspace_type_index.up.sql",
+	)
+}
+
+func _000012_blocks_workspace_type_indexUpSql() (*asset, error) {
+	bytes, err := _000012_blocks_workspace_type_indexUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000012_blocks_workspace_type_index.up.sql", size: 132, mode: os.FileMode(0644), modTime: time.Unix(1791967720, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x51, 0xb5, 0x10, 0x43, 0xe3, 0x98, 0xcd, 0xf6, 0x54, 0xb1, 0x57, 0x21, 0x50, 0x95, 0x87, 0x4d, 0x86, 0xe2, 0x9d, 0x96, 0x30, 0x85, 0x1f, 0x18, 0xd1, 0x95, 0x73, 0x4b, 0x2e, 0x6b, 0x86, 0x31}}
+	return a, nil
+}
+
+var __000013_sharing_expire_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x36\x00\xc9\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x73\x68\x61\x72\x69\x6e\x67\x0a\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x65\x78\x70\x69\x72\x65\x5f\x61\x74\x3b\x0a\x03\x00\x59\xc7\xd7\x91\x36\x00\x00\x00")
+
+func _000013_sharing_expire_atDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000013_sharing_expire_atDownSql,
+		"000013_sharing_expire_at.down.sql",
+	)
+}
+
+func _000013_sharing_expire_atDownSql() (*asset, error) {
+	bytes, err := _000013_sharing_expire_atDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000013_sharing_expire_at.down.sql", size: 54, mode: os.FileMode(0644), modTime: time.Unix(1791968070, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4f, 0x1f, 0x7f, 0x6f, 0xb1, 0x35, 0xde, 0xfa, 0x18, 0xc1, 0xcc, 0xc1, 0x12, 0xe6, 0xe0, 0xcc, 0x25, 0x57, 0xf3, 0x51, 0x75, 0x34, 0xeb, 0x44, 0x82, 0x15, 0x6, 0x2d, 0x20, 0x73, 0x77, 0x6f}}
+	return a, nil
+}
+
+var __000013_sharing_expire_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4f\x00\xb0\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x73\x68\x61\x72\x69\x6e\x67\x0a\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x65\x78\x70\x69\x72\x65\x5f\x61\x74\x20\x42\x49\x47\x49\x4e\x54\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x30\x3b\x0a\x03\x00\xb0\x7b\x94\x37\x4f\x00\x00\x00")
+
+func _000013_sharing_expire_atUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000013_sharing_expire_atUpSql,
+		"000013_sharing_expire_at.up.sql",
+	)
+}
+
+func _000013_sharing_expire_atUpSql() (*asset, error) {
+	bytes, err := _000013_sharing_expire_atUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000013_sharing_expire_at.up.sql", size: 79, mode: os.FileMode(0644), modTime: time.Unix(1791968070, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2c, 0xc4, 0x96, 0xf0, 0x58, 0x1a, 0xe, 0x59, 0xc5, 0xfb, 0x27, 0x5b, 0xaf, 0x33, 0x60, 0xca, 0x8a, 0x29, 0xab, 0x62, 0x56, 0xfb, 0x85, 0x7d, 0x40, 0x39, 0x8e, 0xb9, 0x7c, 0xe, 0x12, 0xed}}
+	return a, nil
+}
+
+var __000014_workspace_webhooksDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2a\x00\xd5\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x77\x6f\x72\x6b\x73\x70\x61\x63\x65\x5f\x77\x65\x62\x68\x6f\x6f\x6b\x73\x3b\x0a\x03\x00\x06\x29\xb0\xb4\x2a\x00\x00\x00")
+
+func _000014_workspace_webhooksDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000014_workspace_webhooksDownSql,
+		"000014_workspace_webhooks.down.sql",
+	)
+}
+
+func _000014_workspace_webhooksDownSql() (*asset, error) {
+	bytes, err := _000014_workspace_webhooksDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000014_workspace_webhooks.down.sql", size: 42, mode: os.FileMode(0644), modTime: time.Unix(1791968266, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x84, 0xa6, 0x57, 0xa0, 0xe0, 0xfd, 0x76, 0x8a, 0x1b, 0xe7, 0x82, 0xab, 0xf3, 0x2, 0x9e, 0x3d, 0x35, 0xb, 0xfb, 0xf6, 0x3, 0xb4, 0x33, 0x2c, 0x42, 0x6d, 0x62, 0x9f, 0x9, 0x2f, 0x21, 0xcb}}
+	return a, nil
+}
+
+var __000014_workspace_webhooksUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\x4d\x4b\xc3\x30\x18\xc7\xcf\xcd\xa7\x78\x8e\x2b\x94\x5d\x14\x11\x76\xca\x6a\xd4\x60\x4c\x25\x7d\x94\xee\x14\xda\x26\xc5\xb0\x97\xce\xbe\xb0\x8d\x90\xef\x2e\xd3\x81\x9b\x07\x3d\x3e\xcf\xff\x05\x7e\xff\x54\x31\x8a\x0c\x90\xce\x05\x03\x7e\x0f\x32\x43\x60\x05\xcf\x31\x07\xef\xa7\xdb\xce\x36\x6e\x1f\xc2\xae\xed\x96\xfd\xb6\xac\xad\xde\xd9\xea\xbd\x6d\x97\x3d\x4c\x48\xe4\x0c\xbc\x51\x95\x3e\x52\x35\xb9\xba\x89\x13\x12\xfd\xd8\x2e\xa5\xaf\x56\xf9\x2a\x44\x42\xa2\xb1\x5b\x01\xb2\x02\xcf\x7f\x75\x67\xcb\xc1\x1a\x5d\x1d\x7e\x15\x7e\x0b\xba\x1c\x60\xce\x1f\xb8\xc4\x84\x44\x2f\x8a\x3f\x53\xb5\x80\x27\xb6\x80\x89\x33\x31\x89\xbd\x77\x0d\x4c\xd7\x87\xfe\x63\x15\xc2\x31\x4c\x53\x64\x0a\x72\x86\x30\x0e\xcd\xed\xba\xba\x86\x34\x13\xe2\x88\x79\xba\xf5\xb8\x71\x75\x6b\xac\xae\x9d\xf7\x76\x63\x42\x98\x11\x72\x5a\x82\xcb\x3b\x56\x80\x33\x7b\xfd\x27\xbf\xbe\x60\xcd\xe4\x7f\x6b\x9d\xdb\xe3\x19\xf9\x1c\x00\x39\x3a\xb9\xf8\x77\x01\x00\x00")
+
+func _000014_workspace_webhooksUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000014_workspace_webhooksUpSql,
+		"000014_workspace_webhooks.up.sql",
+	)
+}
+
+func _000014_workspace_webhooksUpSql() (*asset, error) {
+	bytes, err := _000014_workspace_webhooksUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000014_workspace_webhooks.up.sql", size: 375, mode: os.FileMode(0644), modTime: time.Unix(1791968266, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x25, 0x6, 0x7, 0x66, 0xaf, 0xf4, 0x5d, 0x46, 0x18, 0x5b, 0x54, 0x60, 0x1a, 0x2c, 0xf1, 0x43, 0xff, 0x84, 0x3a, 0xa3, 0x54, 0x44, 0xf9, 0xb3, 0xc0, 0xd5, 0x1d, 0x2, 0x83, 0x1d, 0x3e, 0x87}}
+	return a, nil
+}
+
+var __000015_file_blobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x43\x00\xbc\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x66\x69\x6c\x65\x5f\x72\x65\x66\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x66\x69\x6c\x65\x5f\x62\x6c\x6f\x62\x73\x3b\x0a\x03\x00\xab\x89\x0f\xb4\x43\x00\x00\x00")
+
+func _000015_file_blobsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000015_file_blobsDownSql,
+		"000015_file_blobs.down.sql",
+	)
+}
+
+func _000015_file_blobsDownSql() (*asset, error) {
+	bytes, err := _000015_file_blobsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000015_file_blobs.down.sql", size: 67, mode: os.FileMode(0644), modTime: time.Unix(1791968602, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x32, 0x6, 0xf1, 0x81, 0x21, 0x99, 0x66, 0xde, 0xdd, 0x17, 0xaa, 0xd1, 0x74, 0x41, 0x10, 0x1f, 0xa6, 0x61, 0x42, 0x29, 0x99, 0x91, 0x35, 0xea, 0x69, 0xbe, 0x75, 0xca, 0xe4, 0x9a, 0x61, 0x47}}
+	return a, nil
+}
+
+var __000015_file_blobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\x5f\x4b\xf3\x30\x18\x47\xaf\x9b\x4f\xf1\x5c\xae\x30\xc6\x5e\xde\x31\x84\x5d\x65\x35\x6a\xb0\x76\x92\x45\xd9\xae\x42\xff\x24\x34\xd8\x35\xb3\x69\x71\x1a\xf2\xdd\xa5\x45\x45\x65\x0a\x03\x2f\xc3\x81\xfc\x9e\x73\x22\x46\x30\x27\xc0\xf1\x32\x26\x40\x2f\x20\x59\x71\x20\x1b\xba\xe6\x6b\x70\x6e\xb2\x6f\xa4\xd2\x07\xef\x95\xae\xa4\xc8\x2a\x93\x59\x18\xa1\xa0\x4c\x6d\x09\xf7\x98\x45\x57\x98\x8d\xe6\xb3\x70\x8c\x02\xab\x5f\x24\x2c\xe9\x25\x4d\xf8\xf0\x45\x72\x17\xc7\x63\x14\x34\x52\x89\xdc\x74\x75\x7b\x84\xe5\x8d\x4c\x5b\x29\xd2\x77\x36\x46\xc1\x2d\xa3\x37\x98\x6d\xe1\x9a\x6c\x61\xd4\xaf\x84\x28\x74\x4e\x2b\x98\xec\x9e\xed\x63\xe5\x7d\xbf\x88\x23\x4e\x18\xac\x09\x87\xae\x55\x67\xbb\x6c\x06\xd1\x2a\x8e\x7b\x89\xb7\xb7\xe8\x6a\x9d\x9b\x42\x8a\x5c\x3b\x27\xeb\xc2\xfb\x05\x42\x27\x78\x36\x52\x0d\x9a\xba\xf8\x90\xfc\x37\x9d\xf6\x96\x4f\xa6\x79\xb0\xfb\x34\x97\xe2\x13\xfb\x3f\x0f\xbf\x28\x1b\xd3\xfe\x82\xbf\xb7\x3b\xa5\x88\x2e\xfe\xbc\x07\x4d\xce\xc9\x06\x74\x71\x10\xc7\x2a\x88\xe1\xda\x55\xf2\x43\xa2\x32\xb5\x65\xb8\x40\xaf\x03\x00\x59\xf7\x8b\xb5\x43\x02\x00\x00")
+
+func _000015_file_blobsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000015_file_blobsUpSql,
+		"000015_file_blobs.up.sql",
+	)
+}
+
+func _000015_file_blobsUpSql() (*asset, error) {
+	bytes, err := _000015_file_blobsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000015_file_blobs.up.sql", size: 579, mode: os.FileMode(0644), modTime: time.Unix(1791968602, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7, 0xfc, 0xe7, 0x9b, 0xf1, 0x1b, 0xef, 0x7, 0x3, 0x1, 0x17, 0x57, 0xfb, 0x26, 0x97, 0x52, 0xaa, 0x46, 0x62, 0xea, 0x84, 0x20, 0xe8, 0x19, 0x7d, 0xcc, 0xfe, 0x7b, 0xee, 0xe6, 0x3f, 0xd}}
+	return a, nil
+}
+
+var __000016_upload_sessionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x70\x6c\x6f\x61\x64\x5f\x73\x65\x73\x73\x69\x6f\x6e\x73\x3b\x0a\x03\x00\xfd\x80\x68\xa8\x27\x00\x00\x00")
+
+func _000016_upload_sessionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000016_upload_sessionsDownSql,
+		"000016_upload_sessions.down.sql",
+	)
+}
+
+func _000016_upload_sessionsDownSql() (*asset, error) {
+	bytes, err := _000016_upload_sessionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000016_upload_sessions.down.sql", size: 39, mode: os.FileMode(0644), modTime: time.Unix(1791969379, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xfd, 0x7f, 0x65, 0x73, 0x95, 0xe5, 0x11, 0x84, 0x12, 0xa9, 0xb4, 0xc6, 0x6d, 0x83, 0x36, 0x24, 0x43, 0x95, 0x46, 0xcd, 0xb1, 0x76, 0xba, 0xe3, 0xd8, 0x24, 0xa0, 0x1a, 0xf7, 0x8a, 0x89, 0x6b}}
+	return a, nil
+}
+
+var __000016_upload_sessionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\xd1\x4a\xc3\x30\x14\x86\xaf\x9b\xa7\x38\x97\x2b\x94\x31\x51\x44\xd8\x55\x56\xa3\x06\x6b\x2b\x69\x94\xee\x2a\xd4\x26\xc5\xe0\xd6\xc4\x26\xc5\x49\xc8\xbb\xcb\x64\xe8\x10\xdc\xe5\xf9\xff\xc3\xe1\x3b\x5f\xce\x08\xe6\x04\x38\x5e\x15\x04\xe8\x0d\x94\x15\x07\xd2\xd0\x9a\xd7\x10\xc2\xdc\x8e\xaa\xd7\xbb\x18\x27\xbb\x31\xad\x14\x4e\x39\xa7\xcd\xe0\x60\x86\x12\x2d\xe1\x19\xb3\xfc\x0e\xb3\xd9\xd9\x62\x91\x66\x28\xf9\x30\xe3\x9b\xb3\x6d\xa7\xc4\x51\x77\x7e\x99\x7e\xdf\x2c\x9f\x8a\x22\x43\xc9\x68\x8c\x3f\x51\xdb\xd6\xbf\x02\x27\x0d\x3f\x0e\xbb\x51\xb5\x5e\x89\xd6\xc3\x8a\xde\xd2\x92\x67\x28\x99\xac\xfc\x1b\x3d\x32\xfa\x80\xd9\x1a\xee\xc9\x1a\x66\x5a\xa6\x28\x0d\x41\xf7\x30\xdf\x7e\xba\xf7\x4d\x8c\x7b\x18\x9c\x73\xc2\xa0\x26\x1c\x26\xdf\x5f\x6d\x5f\x2e\x20\xaf\x8a\x62\xff\xfe\x61\x16\xd3\xa0\x3b\x23\x95\xe8\x74\x08\x6a\x90\x31\x2e\x11\x3a\x18\xa2\xe5\x35\x69\x40\xcb\x9d\xf8\xdf\x8b\xf8\xe5\xaa\xca\x93\xfe\x7e\x16\xd3\x25\xfa\x1a\x00\x50\x97\x05\xa8\x83\x01\x00\x00")
+
+func _000016_upload_sessionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000016_upload_sessionsUpSql,
+		"000016_upload_sessions.up.sql",
+	)
+}
+
+func _000016_upload_sessionsUpSql() (*asset, error) {
+	bytes, err := _000016_upload_sessionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000016_upload_sessions.up.sql", size: 387, mode: os.FileMode(0644), modTime: time.Unix(1791969379, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x57, 0x28, 0xb0, 0xb2, 0x9f, 0xc, 0xe4, 0xd1, 0xe3, 0xed, 0xf5, 0x2b, 0xd5, 0xbf, 0xe, 0xf3, 0xbd, 0x38, 0x26, 0x7a, 0x35, 0x9c, 0x48, 0xbf, 0xd0, 0x17, 0x9, 0x5c, 0x1b, 0xb8, 0xe7, 0x1d}}
+	return a, nil
+}
+
+var __000017_blocks_modified_by_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x61\x00\x9e\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x6d\x6f\x64\x69\x66\x69\x65\x64\x5f\x62\x79\x5f\x75\x70\x64\x61\x74\x65\x5f\x61\x74\x7b\x7b\x69\x66\x20\x2e\x6d\x79\x73\x71\x6c\x7d\x7d\x20\x4f\x4e\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x7b\x7b\x65\x6e\x64\x7d\x7d\x3b\x0a\x03\x00\xb7\x12\xf7\xbd\x61\x00\x00\x00")
+
+func _000017_blocks_modified_by_indexDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000017_blocks_modified_by_indexDownSql,
+		"000017_blocks_modified_by_index.down.sql",
+	)
+}
+
+func _000017_blocks_modified_by_indexDownSql() (*asset, error) {
+	bytes, err := _000017_blocks_modified_by_indexDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000017_blocks_modified_by_index.down.sql", size: 97, mode: os.FileMode(0644), modTime: time.Unix(1791971345, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x14, 0xe8, 0x8e, 0x5b, 0xe4, 0x89, 0xd2, 0x59, 0xa0, 0x7d, 0x2d, 0x3a, 0xc, 0xfa, 0xec, 0xef, 0x7a, 0x24, 0x33, 0xa2, 0x55, 0xac, 0xf, 0xec, 0xd0, 0x15, 0xc7, 0x9e, 0xe2, 0xc3, 0xd6, 0x6d}}
+	return a, nil
+}
+
+var __000017_blocks_modified_by_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x68\x00\x97\xff\x43\x52\x45\x41\x54\x45\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x6d\x6f\x64\x69\x66\x69\x65\x64\x5f\x62\x79\x5f\x75\x70\x64\x61\x74\x65\x5f\x61\x74\x20\x4f\x4e\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x20\x28\x6d\x6f\x64\x69\x66\x69\x65\x64\x5f\x62\x79\x2c\x20\x75\x70\x64\x61\x74\x65\x5f\x61\x74\x29\x3b\x0a\x03\x00\x46\xb4\x6f\x58\x68\x00\x00\x00")
+
+func _000017_blocks_modified_by_indexUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000017_blocks_modified_by_indexUpSql,
+		"000017_blocks_modified_by_index.up.sql",
+	)
+}
+
+func _000017_blocks_modified_by_indexUpSql() (*asset, error) {
+	bytes, err := _000017_blocks_modified_by_indexUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000017_blocks_modified_by_index.up.sql", size: 104, mode: os.FileMode(0644), modTime: time.Unix(1791971345, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdd, 0x32, 0x18, 0x60, 0x5b, 0x6c, 0x10, 0x76, 0xc8, 0x68, 0x93, 0x55, 0xb2, 0xde, 0x37, 0x63, 0x61, 0x11, 0x49, 0x60, 0xe6, 0x6, 0x62, 0x65, 0xf4, 0x1a, 0x12, 0xb6, 0x1, 0xba, 0xb1, 0xb0}}
+	return a, nil
+}
+
+var __000018_audit_logsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2c\x00\xd3\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x73\x3b\x0a\x03\x00\xcf\x19\x9a\x22\x2c\x00\x00\x00")
+
+func _000018_audit_logsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000018_audit_logsDownSql,
+		"000018_audit_logs.down.sql",
+	)
+}
+
+func _000018_audit_logsDownSql() (*asset, error) {
+	bytes, err := _000018_audit_logsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000018_audit_logs.down.sql", size: 44, mode: os.FileMode(0644), modTime: time.Unix(1791972058, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7a, 0x37, 0xa3, 0x74, 0x5f, 0xf3, 0x42, 0x81, 0x3f, 0x36, 0x73, 0xb8, 0xbd, 0xad, 0x98, 0xed, 0xec, 0x6, 0xde, 0x21, 0x85, 0x57, 0xe1, 0xaa, 0x7a, 0x7f, 0x74, 0x40, 0xfa, 0x26, 0x39, 0x91}}
+	return a, nil
+}
+
+var __000018_audit_logsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x51\x4b\xc3\x30\x14\x85\x9f\x9b\x5f\x71\x1f\x57\x28\x63\xa2\x88\xd0\xa7\xac\x46\x0d\xd6\x56\xd2\x28\xdd\x53\x88\x4d\x3a\x02\x5d\xab\x69\x0a\x93\x90\xff\x2e\xd3\x31\x9d\x38\xf0\xf1\xf2\x9d\x7b\xe0\x7c\x19\x23\x98\x13\xe0\x78\x99\x13\xa0\x37\x50\x94\x1c\x48\x4d\x2b\x5e\x81\xf7\xf3\x57\xab\x5b\xb3\x0d\x41\x4e\xca\x38\xd1\x0d\xeb\x11\x66\x28\x32\x0a\x9e\x31\xcb\xee\x30\x9b\x9d\x5f\xc6\x09\x8a\x1a\xab\xa5\xd3\x42\x3a\x58\xd2\x5b\x5a\xf0\xcf\x96\xe2\x29\xcf\x13\x14\xc9\xc6\x0d\xf6\x67\xfe\x17\x34\x43\x7f\xa0\x67\x8b\xc5\x11\x76\xd2\xae\xb5\x3b\xc2\x09\x8a\x94\x76\xd2\x74\x23\x70\x52\xf3\x04\x45\x8f\x8c\x3e\x60\xb6\x82\x7b\xb2\x82\x99\x51\x31\x8a\xbd\x37\x2d\xcc\x37\xef\xe3\x5b\x17\xc2\xee\x13\x67\x9c\x30\xa8\x08\x87\xc9\xb5\x57\x9b\x97\x0b\xc8\xca\x3c\xdf\xed\xde\xdf\x62\xea\x4d\x33\x28\x2d\x1a\xe3\xbd\xee\x55\x08\x29\x42\x7b\x35\xb4\xb8\x26\x35\x18\xb5\x15\x7f\x0a\x11\xdf\xe3\xcb\xe2\x94\xb3\x43\x26\x4e\xff\x5b\xfb\xe5\xed\x74\xa5\x6c\xdc\x60\xe3\x14\x7d\x0c\x00\x7f\x84\x66\xb5\xc1\x01\x00\x00")
+
+func _000018_audit_logsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000018_audit_logsUpSql,
+		"000018_audit_logs.up.sql",
+	)
+}
+
+func _000018_audit_logsUpSql() (*asset, error) {
+	bytes, err := _000018_audit_logsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000018_audit_logs.up.sql", size: 449, mode: os.FileMode(0644), modTime: time.Unix(1791972069, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xae, 0xd3, 0xe, 0xd4, 0x7f, 0xcb, 0xa6, 0x60, 0xd1, 0x67, 0x1, 0x2e, 0x72, 0xc6, 0x43, 0x5b, 0xba, 0x7f, 0xf9, 0x8f, 0xaa, 0xa0, 0xa6, 0x5c, 0x9f, 0x54, 0xc8, 0x2c, 0xb9, 0x81, 0x83, 0xf7}}
+	return a, nil
+}
+
+var __000019_board_snapshotsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x31\x00\xce\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6f\x61\x72\x64\x5f\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x3b\x0a\x03\x00\x8f\x9c\x2a\x50\x31\x00\x00\x00")
+
+func _000019_board_snapshotsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000019_board_snapshotsDownSql,
+		"000019_board_snapshots.down.sql",
+	)
+}
+
+func _000019_board_snapshotsDownSql() (*asset, error) {
+	bytes, err := _000019_board_snapshotsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000019_board_snapshots.down.sql", size: 49, mode: os.FileMode(0644), modTime: time.Unix(1791972306, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x68, 0x42, 0x20, 0x12, 0xf0, 0xfb, 0x27, 0xe3, 0xbb, 0xe8, 0xff, 0xb3, 0x40, 0xad, 0x4f, 0x80, 0xfa, 0xf2, 0x63, 0x97, 0xd3, 0x1, 0x9e, 0xc8, 0xcf, 0x4a, 0x4e, 0xc5, 0x97, 0xb6, 0xb7, 0xf2}}
+	return a, nil
+}
+
+var __000019_board_snapshotsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\xd0\x41\x4b\xc3\x30\x14\x07\xf0\x73\xf3\x29\xde\x71\x85\xb2\x8b\x22\xc2\x4e\x59\x8d\x33\x18\x53\xc9\xa2\x6c\xa7\x90\x36\x29\x06\xb7\xb6\x36\x1d\x6e\x84\x7c\x77\x99\x96\xb1\x1d\xe6\x2d\xe1\xff\xde\xff\xc1\x2f\x17\x04\x4b\x02\x12\xcf\x19\x01\xfa\x08\xbc\x90\x40\x56\x74\x29\x97\x10\xc2\xb4\xeb\x6d\xed\xf6\x31\x96\xad\xee\x8d\xf2\x8d\xee\xfc\x47\x3b\x78\x98\xa0\xc4\x19\x78\xc7\x22\x7f\xc2\x62\x72\x73\x97\x66\x28\xf9\x6e\xfb\x4f\xdf\xe9\xca\xaa\xcb\xe8\xb7\x92\xbf\x31\x96\xa1\xe4\xaf\xe7\x7a\x5e\xf5\x56\x0f\xd6\xa8\xf2\x70\x3e\x71\x0a\x94\x1e\x60\x4e\x17\x94\xcb\x0c\x25\x46\x0f\x1a\x42\x70\x35\x4c\xb7\x07\xff\xb5\x89\x91\x15\x7c\x21\xc9\x4a\x86\x60\x37\xde\xc6\x38\xbe\x1b\x13\x63\x86\x92\x57\x41\x5f\xb0\x58\xc3\x33\x59\xc3\xc4\x99\x14\xa5\x17\xcb\xc7\x63\x38\x97\x44\xc0\x92\x48\xd8\x0d\xf5\xfd\xb6\xbc\x85\xbc\x60\xec\xe8\x33\xfe\xd5\xae\x71\x55\x6b\xac\xaa\xdc\x58\x3c\x43\x68\x24\xa4\xfc\x81\xac\xc0\x99\xbd\xba\x0e\xa7\x4e\x00\x05\xff\xd7\xf7\x1c\x33\x83\xb2\xd5\xbd\x51\xce\xa4\x33\xf4\x33\x00\x68\x26\x5c\x14\xb0\x01\x00\x00")
+
+func _000019_board_snapshotsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000019_board_snapshotsUpSql,
+		"000019_board_snapshots.up.sql",
+	)
+}
+
+func _000019_board_snapshotsUpSql() (*asset, error) {
+	bytes, err := _000019_board_snapshotsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000019_board_snapshots.up.sql", size: 432, mode: os.FileMode(0644), modTime: time.Unix(1791972315, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x77, 0x92, 0x34, 0xfd, 0xae, 0xb8, 0x2b, 0xf9, 0x15, 0xe9, 0xf3, 0x1f, 0x85, 0x62, 0xaa, 0x77, 0xf6, 0x6b, 0x3c, 0x68, 0xcb, 0x8b, 0x1, 0x8a, 0x7a, 0x73, 0x43, 0xc7, 0x39, 0x60, 0xb2, 0x4b}}
+	return a, nil
+}
+
+var __000020_block_migration_flagsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x37\x00\xc8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x5f\x6d\x69\x67\x72\x61\x74\x69\x6f\x6e\x5f\x66\x6c\x61\x67\x73\x3b\x0a\x03\x00\xc6\x22\xbf\xf5\x37\x00\x00\x00")
+
+func _000020_block_migration_flagsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000020_block_migration_flagsDownSql,
+		"000020_block_migration_flags.down.sql",
+	)
+}
+
+func _000020_block_migration_flagsDownSql() (*asset, error) {
+	bytes, err := _000020_block_migration_flagsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000020_block_migration_flags.down.sql", size: 55, mode: os.FileMode(0644), modTime: time.Unix(1791972548, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x78, 0x8b, 0xbe, 0x6e, 0xcd, 0xd9, 0x17, 0x5f, 0xd8, 0x9e, 0xd2, 0x2b, 0x9f, 0x9f, 0x91, 0x42, 0x56, 0xa6, 0x6c, 0x61, 0x5e, 0xf9, 0x89, 0x98, 0x40, 0xb0, 0x34, 0x68, 0x5d, 0xa2, 0x62, 0x7c}}
+	return a, nil
+}
+
+var __000020_block_migration_flagsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcf\xcd\x4a\xc4\x30\x10\xc0\xf1\xf3\xe6\x29\xe6\xd8\xc2\xb2\xac\x28\x22\x78\xca\x86\xa8\xc1\xd8\x95\x34\x8a\x7b\x0a\xd9\x34\x29\xc1\x7e\x68\x9b\x82\x12\xf2\xee\x12\xbf\xd0\xe3\xf0\x1b\xfe\xc3\x10\x41\xb1\xa4\x20\xf1\x8e\x53\x60\x57\x50\xed\x25\xd0\x27\x56\xcb\x1a\x62\xdc\xbc\x4c\xd6\xf9\xb7\x94\x8e\xdd\x68\x9e\x55\xef\xdb\x49\x07\x3f\x0e\xca\x75\xba\x9d\xa1\x40\xab\x2f\xf0\x0d\x3c\x62\x41\x6e\xb0\x28\x4e\xcf\xcb\xcf\x46\xf5\xc0\xf9\x1a\xad\xf2\xe2\xaf\x9d\x6c\xb7\xff\xd0\x4c\x56\x07\xab\x74\x80\x1d\xbb\x66\x95\xfc\x6b\xf7\x82\xdd\x61\x71\x80\x5b\x7a\x80\x22\x57\xd6\xf0\x73\xab\x44\x65\x8c\xde\xc1\xa6\x7f\x9f\x5f\xbb\x94\x72\x1b\x13\x49\x05\xd4\x54\xc2\x12\xdc\x45\x7f\x3c\x03\xb2\xe7\x3c\x7f\xf6\x3d\xab\x65\xf0\x66\x6c\xac\x32\x3e\x46\x3b\x34\x29\x5d\xa2\x8f\x01\x00\x91\x4c\xe5\xa7\xfc\x00\x00\x00")
+
+func _000020_block_migration_flagsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000020_block_migration_flagsUpSql,
+		"000020_block_migration_flags.up.sql",
+	)
+}
+
+func _000020_block_migration_flagsUpSql() (*asset, error) {
+	bytes, err := _000020_block_migration_flagsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000020_block_migration_flags.up.sql", size: 252, mode: os.FileMode(0644), modTime: time.Unix(1791972548, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa9, 0x94, 0x26, 0x6a, 0xf7, 0x68, 0x6e, 0x53, 0xb6, 0x25, 0xb4, 0xd9, 0x7a, 0xc6, 0xed, 0xc2, 0xa2, 0x61, 0x82, 0x27, 0x9c, 0xb6, 0x28, 0xf7, 0xb8, 0x74, 0x9e, 0x90, 0xbb, 0x66, 0xfd, 0xa2}}
+	return a, nil
+}
+
+var __000021_board_locksDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2d\x00\xd2\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6f\x61\x72\x64\x5f\x6c\x6f\x63\x6b\x73\x3b\x0a\x03\x00\xae\xbc\xeb\xb8\x2d\x00\x00\x00")
+
+func _000021_board_locksDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000021_board_locksDownSql,
+		"000021_board_locks.down.sql",
+	)
+}
+
+func _000021_board_locksDownSql() (*asset, error) {
+	bytes, err := _000021_board_locksDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000021_board_locks.down.sql", size: 45, mode: os.FileMode(0644), modTime: time.Unix(1791973813, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x36, 0x6c, 0x33, 0x53, 0xc4, 0xab, 0x3, 0xc9, 0x4d, 0xe, 0x3f, 0x36, 0xa1, 0x55, 0xd5, 0x97, 0x19, 0x96, 0x50, 0xcd, 0xa7, 0xa4, 0x6b, 0x1f, 0xe1, 0x6f, 0x3e, 0x96, 0xd9, 0x88, 0x70, 0x70}}
+	return a, nil
+}
+
+var __000021_board_locksUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcd\x4a\xc4\x30\x14\x85\xd7\x93\xa7\xb8\xcb\x16\x64\x18\x51\x44\x70\x95\x09\x51\x83\xb1\x23\x69\x14\x67\x15\x3a\x49\x8a\x61\x3a\x4d\x4d\x5b\xac\x84\xbc\xbb\xd4\x3f\x74\x33\xcb\x8f\xef\xde\xc3\x39\x44\x50\x2c\x29\x48\xbc\xe6\x14\xd8\x35\x14\x1b\x09\xf4\x99\x95\xb2\x84\x18\x97\x5d\xb0\xb5\x9b\x52\xda\xf9\x2a\x18\xd5\x78\xbd\xef\x21\x43\x8b\x2f\x74\x06\x9e\xb0\x20\xb7\x58\x64\x67\x17\xf9\xe7\x67\xf1\xc8\xf9\x09\x5a\xbc\xf9\xb0\xef\xbb\x4a\xdb\x23\x37\x2f\xbe\x31\x36\xfc\xda\xd3\xd5\xea\x9f\xb6\x53\xe7\x82\x55\xd5\x00\x6b\x76\xc3\x0a\xf9\xd7\x3d\x08\x76\x8f\xc5\x16\xee\xe8\x16\xb2\x9f\x2e\x39\xca\x63\x74\x35\x2c\x0f\xef\xfd\x6b\x93\xd2\x9c\x8a\x89\xa4\x02\x4a\x2a\x61\x1c\xea\xcb\xc3\xee\x1c\xc8\x86\xf3\x79\xef\x37\xab\xb1\x75\xda\x1b\xab\xb4\x8b\xd1\xb6\x26\xa5\x2b\xf4\x31\x00\x59\x44\xe2\xac\x12\x01\x00\x00")
+
+func _000021_board_locksUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000021_board_locksUpSql,
+		"000021_board_locks.up.sql",
+	)
+}
+
+func _000021_board_locksUpSql() (*asset, error) {
+	bytes, err := _000021_board_locksUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000021_board_locks.up.sql", size: 274, mode: os.FileMode(0644), modTime: time.Unix(1791973813, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x55, 0xe0, 0x9a, 0x0, 0xdc, 0xcd, 0x8c, 0xec, 0xef, 0x1c, 0xf7, 0x57, 0xd9, 0xb8, 0x3a, 0xec, 0x1b, 0x91, 0xcb, 0xdb, 0x9a, 0xb6, 0x40, 0x1d, 0x64, 0x3e, 0x1d, 0x5a, 0x11, 0xc1, 0x84, 0x25}}
+	return a, nil
+}
+
+var __000022_history_deletions_millisDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x0a\x0d\x70\x71\x0c\x71\x55\xa8\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\x4d\xca\xc9\x4f\xce\x2e\x8e\xcf\xc8\x2c\x2e\xc9\x2f\xaa\x54\x08\x76\x0d\x51\x28\x2d\x48\x49\x2c\x49\x8d\x4f\x2c\x51\xb0\x45\x62\x57\x57\x67\xa6\x29\xe8\xe5\x56\x16\x17\xe6\xd4\xd6\xba\x78\x86\x55\x57\xa7\xe6\x14\xa7\xd6\xd6\xea\x57\x57\xa7\xe6\xa5\xd4\xd6\x2a\x18\x1a\x18\x18\x28\x84\x7b\xb8\x06\xb9\x2a\xa4\xa4\xe6\xa4\x42\x4c\xb0\xb3\x05\x8b\xc3\xa0\x82\xa3\x9f\x0b\x92\x99\x68\xb2\xd6\x5c\x44\xb9\x0f\x61\xba\x2d\x92\x4d\x54\x71\x9f\x35\x17\x60\x00\x3d\x9f\x38\xc6\x22\x01\x00\x00")
+
+func _000022_history_deletions_millisDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000022_history_deletions_millisDownSql,
+		"000022_history_deletions_millis.down.sql",
+	)
+}
+
+func _000022_history_deletions_millisDownSql() (*asset, error) {
+	bytes, err := _000022_history_deletions_millisDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000022_history_deletions_millis.down.sql", size: 290, mode: os.FileMode(0644), modTime: time.Unix(1791978871, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3e, 0x17, 0x17, 0x87, 0xf4, 0x24, 0x6a, 0x58, 0xc9, 0x60, 0x12, 0x40, 0x52, 0x4a, 0xec, 0x75, 0xda, 0xe, 0x8a, 0x1a, 0xaa, 0xb, 0x8f, 0x67, 0xdc, 0x3d, 0xcf, 0xb0, 0xd4, 0x66, 0xe9, 0xcc}}
+	return a, nil
+}
+
+var __000022_history_deletions_millisUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x0a\x0d\x70\x71\x0c\x71\x55\xa8\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\x4d\xca\xc9\x4f\xce\x2e\x8e\xcf\xc8\x2c\x2e\xc9\x2f\xaa\x54\x08\x76\x0d\x51\x28\x2d\x48\x49\x2c\x49\x8d\x4f\x2c\x51\xb0\x45\x62\x6b\x29\x18\x1a\x18\x18\x28\x84\x7b\xb8\x06\xb9\x2a\xa4\xa4\xe6\xa4\x42\x94\xd8\x29\x18\x28\x38\xfa\xb9\x20\x29\xc4\x14\xb1\x01\x6b\x85\x41\x6b\x2e\xa2\x5c\x81\xb0\xc2\x16\xc9\x3a\x02\xae\x40\x88\xa0\xdb\x09\x18\x00\x16\x3a\x1f\xc9\xfa\x00\x00\x00")
+
+func _000022_history_deletions_millisUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000022_history_deletions_millisUpSql,
+		"000022_history_deletions_millis.up.sql",
+	)
+}
+
+func _000022_history_deletions_millisUpSql() (*asset, error) {
+	bytes, err := _000022_history_deletions_millisUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000022_history_deletions_millis.up.sql", size: 250, mode: os.FileMode(0644), modTime: time.Unix(1791978871, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xed, 0x8a, 0x12, 0x73, 0x37, 0x9f, 0xe4, 0xad, 0x6b, 0xc0, 0x40, 0xaa, 0x7d, 0x11, 0xbb, 0xa8, 0x65, 0xc1, 0x16, 0xbf, 0x3e, 0x33, 0x8c, 0xca, 0x91, 0xde, 0x41, 0xe1, 0x41, 0x17, 0x47, 0x17}}
+	return a, nil
+}
+
+var __000023_blocks_history_root_id_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x63\x00\x9c\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x68\x69\x73\x74\x6f\x72\x79\x5f\x72\x6f\x6f\x74\x5f\x69\x64\x7b\x7b\x69\x66\x20\x2e\x6d\x79\x73\x71\x6c\x7d\x7d\x20\x4f\x4e\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x68\x69\x73\x74\x6f\x72\x79\x7b\x7b\x65\x6e\x64\x7d\x7d\x3b\x0a\x03\x00\x3a\xab\x90\x9e\x63\x00\x00\x00")
+
+func _000023_blocks_history_root_id_indexDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000023_blocks_history_root_id_indexDownSql,
+		"000023_blocks_history_root_id_index.down.sql",
+	)
+}
+
+func _000023_blocks_history_root_id_indexDownSql() (*asset, error) {
+	bytes, err := _000023_blocks_history_root_id_indexDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000023_blocks_history_root_id_index.down.sql", size: 99, mode: os.FileMode(0644), modTime: time.Unix(1791981169, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xed, 0x3a, 0x95, 0xa8, 0x40, 0xb6, 0xca, 0xc3, 0x49, 0xc6, 0xff, 0xa5, 0x8b, 0x46, 0xe2, 0xe4, 0xe7, 0x53, 0x93, 0x34, 0xc4, 0x28, 0x85, 0x4c, 0x51, 0xf3, 0x4c, 0x96, 0x73, 0x8a, 0xb3, 0xc}}
+	return a, nil
+}
+
+var __000023_blocks_history_root_id_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5b\x00\xa4\xff\x43\x52\x45\x41\x54\x45\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x68\x69\x73\x74\x6f\x72\x79\x5f\x72\x6f\x6f\x74\x5f\x69\x64\x20\x4f\x4e\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x62\x6c\x6f\x63\x6b\x73\x5f\x68\x69\x73\x74\x6f\x72\x79\x20\x28\x72\x6f\x6f\x74\x5f\x69\x64\x29\x3b\x0a\x03\x00\xb3\x58\xaa\xed\x5b\x00\x00\x00")
+
+func _000023_blocks_history_root_id_indexUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000023_blocks_history_root_id_indexUpSql,
+		"000023_blocks_history_root_id_index.up.sql",
+	)
+}
+
+func _000023_blocks_history_root_id_indexUpSql() (*asset, error) {
+	bytes, err := _000023_blocks_history_root_id_indexUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000023_blocks_history_root_id_index.up.sql", size: 91, mode: os.FileMode(0644), modTime: time.Unix(1791981169, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x89, 0xac, 0x5, 0x26, 0xa6, 0x54, 0xa5, 0x87, 0xcf, 0xe, 0x4f, 0xf6, 0xa2, 0x53, 0xb1, 0xa7, 0xb, 0xfd, 0x49, 0x3d, 0x94, 0x11, 0x24, 0x91, 0x2b, 0xeb, 0x6b, 0x59, 0xb8, 0x76, 0x24, 0x3}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -537,24 +1125,52 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"000001_init.down.sql":                  _000001_initDownSql,
-	"000001_init.up.sql":                    _000001_initUpSql,
-	"000002_system_settings_table.down.sql": _000002_system_settings_tableDownSql,
-	"000002_system_settings_table.up.sql":   _000002_system_settings_tableUpSql,
-	"000003_blocks_rootid.down.sql":         _000003_blocks_rootidDownSql,
-	"000003_blocks_rootid.up.sql":           _000003_blocks_rootidUpSql,
-	"000004_auth_table.down.sql":            _000004_auth_tableDownSql,
-	"000004_auth_table.up.sql":              _000004_auth_tableUpSql,
-	"000005_blocks_modifiedby.down.sql":     _000005_blocks_modifiedbyDownSql,
-	"000005_blocks_modifiedby.up.sql":       _000005_blocks_modifiedbyUpSql,
-	"000006_sharing_table.down.sql":         _000006_sharing_tableDownSql,
-	"000006_sharing_table.up.sql":           _000006_sharing_tableUpSql,
-	"000007_workspaces_table.down.sql":      _000007_workspaces_tableDownSql,
-	"000007_workspaces_table.up.sql":        _000007_workspaces_tableUpSql,
-	"000008_teams.down.sql":                 _000008_teamsDownSql,
-	"000008_teams.up.sql":                   _000008_teamsUpSql,
-	"000009_blocks_history.down.sql":        _000009_blocks_historyDownSql,
-	"000009_blocks_history.up.sql":          _000009_blocks_historyUpSql,
+	"000001_init.down.sql":                         _000001_initDownSql,
+	"000001_init.up.sql":                           _000001_initUpSql,
+	"000002_system_settings_table.down.sql":        _000002_system_settings_tableDownSql,
+	"000002_system_settings_table.up.sql":          _000002_system_settings_tableUpSql,
+	"000003_blocks_rootid.down.sql":                _000003_blocks_rootidDownSql,
+	"000003_blocks_rootid.up.sql":                  _000003_blocks_rootidUpSql,
+	"000004_auth_table.down.sql":                   _000004_auth_tableDownSql,
+	"000004_auth_table.up.sql":                     _000004_auth_tableUpSql,
+	"000005_blocks_modifiedby.down.sql":            _000005_blocks_modifiedbyDownSql,
+	"000005_blocks_modifiedby.up.sql":              _000005_blocks_modifiedbyUpSql,
+	"000006_sharing_table.down.sql":                _000006_sharing_tableDownSql,
+	"000006_sharing_table.up.sql":                  _000006_sharing_tableUpSql,
+	"000007_workspaces_table.down.sql":             _000007_workspaces_tableDownSql,
+	"000007_workspaces_table.up.sql":               _000007_workspaces_tableUpSql,
+	"000008_teams.down.sql":                        _000008_teamsDownSql,
+	"000008_teams.up.sql":                          _000008_teamsUpSql,
+	"000009_blocks_history.down.sql":               _000009_blocks_historyDownSql,
+	"000009_blocks_history.up.sql":                 _000009_blocks_historyUpSql,
+	"000010_blocks_archived.down.sql":              _000010_blocks_archivedDownSql,
+	"000010_blocks_archived.up.sql":                _000010_blocks_archivedUpSql,
+	"000011_sessions_device_fingerprint.down.sql":  _000011_sessions_device_fingerprintDownSql,
+	"000011_sessions_device_fingerprint.up.sql":    _000011_sessions_device_fingerprintUpSql,
+	"000012_blocks_workspace_type_index.down.sql":  _000012_blocks_workspace_type_indexDownSql,
+	"000012_blocks_workspace_type_index.up.sql":    _000012_blocks_workspace_type_indexUpSql,
+	"000013_sharing_expire_at.down.sql":            _000013_sharing_expire_atDownSql,
+	"000013_sharing_expire_at.up.sql":              _000013_sharing_expire_atUpSql,
+	"000014_workspace_webhooks.down.sql":           _000014_workspace_webhooksDownSql,
+	"000014_workspace_webhooks.up.sql":             _000014_workspace_webhooksUpSql,
+	"000015_file_blobs.down.sql":                   _000015_file_blobsDownSql,
+	"000015_file_blobs.up.sql":                     _000015_file_blobsUpSql,
+	"000016_upload_sessions.down.sql":              _000016_upload_sessionsDownSql,
+	"000016_upload_sessions.up.sql":                _000016_upload_sessionsUpSql,
+	"000017_blocks_modified_by_index.down.sql":     _000017_blocks_modified_by_indexDownSql,
+	"000017_blocks_modified_by_index.up.sql":       _000017_blocks_modified_by_indexUpSql,
+	"000018_audit_logs.down.sql":                   _000018_audit_logsDownSql,
+	"000018_audit_logs.up.sql":                     _000018_audit_logsUpSql,
+	"000019_board_snapshots.down.sql":              _000019_board_snapshotsDownSql,
+	"000019_board_snapshots.up.sql":                _000019_board_snapshotsUpSql,
+	"000020_block_migration_flags.down.sql":        _000020_block_migration_flagsDownSql,
+	"000020_block_migration_flags.up.sql":          _000020_block_migration_flagsUpSql,
+	"000021_board_locks.down.sql":                  _000021_board_locksDownSql,
+	"000021_board_locks.up.sql":                    _000021_board_locksUpSql,
+	"000022_history_deletions_millis.down.sql":     _000022_history_deletions_millisDownSql,
+	"000022_history_deletions_millis.up.sql":       _000022_history_deletions_millisUpSql,
+	"000023_blocks_history_root_id_index.down.sql": _000023_blocks_history_root_id_indexDownSql,
+	"000023_blocks_history_root_id_index.up.sql":   _000023_blocks_history_root_id_indexUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -619,6 +1235,34 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"000008_teams.up.sql": {_000008_teamsUpSql, map[string]*bintree{}},
 	"000009_blocks_history.down.sql": {_000009_blocks_historyDownSql, map[string]*bintree{}},
 	"000009_blocks_history.up.sql": {_000009_blocks_historyUpSql, map[string]*bintree{}},
+	"000010_blocks_archived.down.sql": {_000010_blocks_archivedDownSql, map[string]*bintree{}},
+	"000010_blocks_archived.up.sql": {_000010_blocks_archivedUpSql, map[string]*bintree{}},
+	"000011_sessions_device_fingerprint.down.sql": {_000011_sessions_device_fingerprintDownSql, map[string]*bintree{}},
+	"000011_sessions_device_fingerprint.up.sql": {_000011_sessions_device_fingerprintUpSql, map[string]*bintree{}},
+	"000012_blocks_workspace_type_index.down.sql": {_000012_blocks_workspace_type_indexDownSql, map[string]*bintree{}},
+	"000012_blocks_workspace_type_index.up.sql": {_000012_blocks_workspace_type_indexUpSql, map[string]*bintree{}},
+	"000013_sharing_expire_at.down.sql": {_000013_sharing_expire_atDownSql, map[string]*bintree{}},
+	"000013_sharing_expire_at.up.sql": {_000013_sharing_expire_atUpSql, map[string]*bintree{}},
+	"000014_workspace_webhooks.down.sql": {_000014_workspace_webhooksDownSql, map[string]*bintree{}},
+	"000014_workspace_webhooks.up.sql": {_000014_workspace_webhooksUpSql, map[string]*bintree{}},
+	"000015_file_blobs.down.sql": {_000015_file_blobsDownSql, map[string]*bintree{}},
+	"000015_file_blobs.up.sql": {_000015_file_blobsUpSql, map[string]*bintree{}},
+	"000016_upload_sessions.down.sql": {_000016_upload_sessionsDownSql, map[string]*bintree{}},
+	"000016_upload_sessions.up.sql": {_000016_upload_sessionsUpSql, map[string]*bintree{}},
+	"000017_blocks_modified_by_index.down.sql": {_000017_blocks_modified_by_indexDownSql, map[string]*bintree{}},
+	"000017_blocks_modified_by_index.up.sql": {_000017_blocks_modified_by_indexUpSql, map[string]*bintree{}},
+	"000018_audit_logs.down.sql": {_000018_audit_logsDownSql, map[string]*bintree{}},
+	"000018_audit_logs.up.sql": {_000018_audit_logsUpSql, map[string]*bintree{}},
+	"000019_board_snapshots.down.sql": {_000019_board_snapshotsDownSql, map[string]*bintree{}},
+	"000019_board_snapshots.up.sql": {_000019_board_snapshotsUpSql, map[string]*bintree{}},
+	"000020_block_migration_flags.down.sql": {_000020_block_migration_flagsDownSql, map[string]*bintree{}},
+	"000020_block_migration_flags.up.sql": {_000020_block_migration_flagsUpSql, map[string]*bintree{}},
+	"000021_board_locks.down.sql": {_000021_board_locksDownSql, map[string]*bintree{}},
+	"000021_board_locks.up.sql": {_000021_board_locksUpSql, map[string]*bintree{}},
+	"000022_history_deletions_millis.down.sql": {_000022_history_deletions_millisDownSql, map[string]*bintree{}},
+	"000022_history_deletions_millis.up.sql": {_000022_history_deletions_millisUpSql, map[string]*bintree{}},
+	"000023_blocks_history_root_id_index.down.sql": {_000023_blocks_history_root_id_indexDownSql, map[string]*bintree{}},
+	"000023_blocks_history_root_id_index.up.sql": {_000023_blocks_history_root_id_indexUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}blocks
+DROP COLUMN archived;
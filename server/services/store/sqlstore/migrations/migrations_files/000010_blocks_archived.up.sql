@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}blocks
+ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}sessions
+DROP COLUMN device_fingerprint;
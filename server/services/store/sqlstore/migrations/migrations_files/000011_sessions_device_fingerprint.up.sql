@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}sessions
+ADD COLUMN device_fingerprint VARCHAR(64);
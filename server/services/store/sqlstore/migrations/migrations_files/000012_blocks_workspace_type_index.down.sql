@@ -0,0 +1 @@
+DROP INDEX idx_{{.prefix}}blocks_workspace_id_type{{if .mysql}} ON {{.prefix}}blocks{{end}};
@@ -0,0 +1 @@
+CREATE INDEX idx_{{.prefix}}blocks_workspace_id_type ON {{.prefix}}blocks (workspace_id, {{if .mysql}}type(32){{else}}type{{end}});
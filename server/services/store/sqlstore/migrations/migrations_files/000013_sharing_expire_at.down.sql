@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}sharing
+DROP COLUMN expire_at;
@@ -0,0 +1,2 @@
+ALTER TABLE {{.prefix}}sharing
+ADD COLUMN expire_at BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}workspace_webhooks (
+	id VARCHAR(36),
+	workspace_id VARCHAR(36) NOT NULL,
+	url TEXT NOT NULL,
+	created_by VARCHAR(36),
+	create_at BIGINT,
+	PRIMARY KEY (id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE INDEX idx_{{.prefix}}workspace_webhooks_workspace_id ON {{.prefix}}workspace_webhooks (workspace_id);
@@ -0,0 +1,2 @@
+DROP TABLE {{.prefix}}file_refs;
+DROP TABLE {{.prefix}}file_blobs;
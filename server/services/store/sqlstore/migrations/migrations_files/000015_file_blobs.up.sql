@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}file_blobs (
+	hash VARCHAR(64),
+	size BIGINT NOT NULL,
+	ref_count BIGINT NOT NULL,
+	create_at BIGINT,
+	PRIMARY KEY (hash)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE TABLE IF NOT EXISTS {{.prefix}}file_refs (
+	id VARCHAR(100),
+	workspace_id VARCHAR(36) NOT NULL,
+	root_id VARCHAR(36) NOT NULL,
+	hash VARCHAR(64) NOT NULL,
+	create_at BIGINT,
+	PRIMARY KEY (id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE INDEX idx_{{.prefix}}file_refs_hash ON {{.prefix}}file_refs (hash);
@@ -0,0 +1 @@
+DROP TABLE {{.prefix}}upload_sessions;
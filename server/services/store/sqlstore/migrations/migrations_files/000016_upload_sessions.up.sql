@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}upload_sessions (
+	id VARCHAR(100),
+	workspace_id VARCHAR(36) NOT NULL,
+	root_id VARCHAR(36) NOT NULL,
+	path TEXT NOT NULL,
+	create_at BIGINT,
+	update_at BIGINT,
+	PRIMARY KEY (id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE INDEX idx_{{.prefix}}upload_sessions_update_at ON {{.prefix}}upload_sessions (update_at);
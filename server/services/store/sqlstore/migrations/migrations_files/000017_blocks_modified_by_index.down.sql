@@ -0,0 +1 @@
+DROP INDEX idx_{{.prefix}}blocks_modified_by_update_at{{if .mysql}} ON {{.prefix}}blocks{{end}};
@@ -0,0 +1 @@
+CREATE INDEX idx_{{.prefix}}blocks_modified_by_update_at ON {{.prefix}}blocks (modified_by, update_at);
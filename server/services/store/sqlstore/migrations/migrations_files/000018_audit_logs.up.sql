@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}audit_logs (
+	id VARCHAR(36),
+	create_at BIGINT NOT NULL,
+	actor VARCHAR(36) NOT NULL,
+	action VARCHAR(100) NOT NULL,
+	target VARCHAR(100),
+	details TEXT,
+	PRIMARY KEY (id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE INDEX idx_{{.prefix}}audit_logs_create_at ON {{.prefix}}audit_logs (create_at);
+CREATE INDEX idx_{{.prefix}}audit_logs_actor ON {{.prefix}}audit_logs (actor);
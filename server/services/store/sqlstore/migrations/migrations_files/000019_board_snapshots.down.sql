@@ -0,0 +1 @@
+DROP TABLE IF EXISTS {{.prefix}}board_snapshots;
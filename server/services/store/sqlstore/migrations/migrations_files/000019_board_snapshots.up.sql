@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}board_snapshots (
+	id VARCHAR(36),
+	workspace_id VARCHAR(36) NOT NULL,
+	board_id VARCHAR(36) NOT NULL,
+	created_by VARCHAR(36),
+	create_at BIGINT,
+	data {{if .mysql}}LONGTEXT{{else}}TEXT{{end}},
+	PRIMARY KEY (id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
+
+CREATE INDEX idx_{{.prefix}}board_snapshots_board_id ON {{.prefix}}board_snapshots (workspace_id, board_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS {{.prefix}}block_migration_flags;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}block_migration_flags (
+	block_id VARCHAR(36) NOT NULL,
+	flag VARCHAR(100) NOT NULL,
+	create_at BIGINT NOT NULL,
+	PRIMARY KEY (flag, block_id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
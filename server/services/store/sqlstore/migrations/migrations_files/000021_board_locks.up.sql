@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}board_locks (
+	board_id VARCHAR(36) NOT NULL,
+	workspace_id VARCHAR(36) NOT NULL,
+	holder VARCHAR(100) NOT NULL,
+	expire_at BIGINT NOT NULL,
+	PRIMARY KEY (board_id)
+){{if .mysql}}CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci{{end}};
@@ -0,0 +1,2 @@
+UPDATE {{.prefix}}blocks_history SET update_at = update_at {{if .mysql}}DIV{{else}}/{{end}} 1000 WHERE delete_at >= 100000000000 AND update_at >= 100000000000;
+UPDATE {{.prefix}}blocks_history SET delete_at = delete_at {{if .mysql}}DIV{{else}}/{{end}} 1000 WHERE delete_at >= 100000000000;
@@ -0,0 +1,2 @@
+UPDATE {{.prefix}}blocks_history SET update_at = update_at * 1000 WHERE delete_at > 0 AND update_at > 0 AND update_at < 100000000000;
+UPDATE {{.prefix}}blocks_history SET delete_at = delete_at * 1000 WHERE delete_at > 0 AND delete_at < 100000000000;
@@ -0,0 +1 @@
+DROP INDEX idx_{{.prefix}}blocks_history_root_id{{if .mysql}} ON {{.prefix}}blocks_history{{end}};
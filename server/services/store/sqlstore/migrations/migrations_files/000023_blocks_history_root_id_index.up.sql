@@ -0,0 +1 @@
+CREATE INDEX idx_{{.prefix}}blocks_history_root_id ON {{.prefix}}blocks_history (root_id);
@@ -0,0 +1,152 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// RenameBoardProperty changes the name of a property in the board schema.
+// Cards reference their properties by ID, so they are left untouched.
+func (s *SQLStore) RenameBoardProperty(c store.Container, boardID, propertyID, newName, modifiedBy string) error {
+	return s.updateBoardProperty(c, boardID, propertyID, modifiedBy, func(property map[string]interface{}, cards []model.Block) ([]*model.Block, error) {
+		property["name"] = newName
+		return nil, nil
+	})
+}
+
+// RenamePropertyOption changes the value of one of the options of a property
+// in the board schema, and rewrites the cards that hold the old value itself
+// instead of the option ID, like the ones imported from other tools.
+func (s *SQLStore) RenamePropertyOption(c store.Container, boardID, propertyID, oldOption, newOption, modifiedBy string) error {
+	return s.updateBoardProperty(c, boardID, propertyID, modifiedBy, func(property map[string]interface{}, cards []model.Block) ([]*model.Block, error) {
+		options, _ := property["options"].([]interface{})
+		found := false
+		for _, o := range options {
+			option, ok := o.(map[string]interface{})
+			if ok && option["value"] == oldOption {
+				option["value"] = newOption
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("option %q not found in property %s", oldOption, propertyID)
+		}
+
+		changed := []*model.Block{}
+		for i := range cards {
+			properties, _ := cards[i].Fields["properties"].(map[string]interface{})
+			switch value := properties[propertyID].(type) {
+			case string:
+				if value == oldOption {
+					properties[propertyID] = newOption
+					changed = append(changed, &cards[i])
+				}
+			case []interface{}:
+				// Multi select properties hold a list of options
+				renamed := false
+				for j := range value {
+					if value[j] == oldOption {
+						value[j] = newOption
+						renamed = true
+					}
+				}
+				if renamed {
+					changed = append(changed, &cards[i])
+				}
+			}
+		}
+
+		return changed, nil
+	})
+}
+
+// propertyUpdate changes the property definition, taken from the board
+// schema, and returns the cards it modified.
+type propertyUpdate func(property map[string]interface{}, cards []model.Block) ([]*model.Block, error)
+
+// updateBoardProperty applies the update to a property of the board and to
+// its cards, writing the board and the modified cards in a single
+// transaction.
+func (s *SQLStore) updateBoardProperty(c store.Container, boardID, propertyID, modifiedBy string, update propertyUpdate) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := sq.QueryContextWith(ctx, tx, s.getBlocksWithRootIDQuery(c, boardID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	blocks, err := blocksFromRows(rows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var board *model.Block
+	cards := []model.Block{}
+	for i := range blocks {
+		switch {
+		case blocks[i].ID == boardID && blocks[i].Type == "board":
+			board = &blocks[i]
+		case blocks[i].Type == "card":
+			cards = append(cards, blocks[i])
+		}
+	}
+	if board == nil {
+		tx.Rollback()
+		return &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	property := boardProperty(*board, propertyID)
+	if property == nil {
+		tx.Rollback()
+		return fmt.Errorf("property %s not found in board %s", propertyID, boardID)
+	}
+
+	changed, err := update(property, cards)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := utils.GetMillis()
+	for _, block := range append([]*model.Block{board}, changed...) {
+		block.ModifiedBy = modifiedBy
+		block.UpdateAt = now
+
+		err = s.insertBlock(ctx, tx, c, *block)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// boardProperty returns the definition of a property from the board schema,
+// or nil if the board doesn't have it
+func boardProperty(board model.Block, propertyID string) map[string]interface{} {
+	cardProperties, _ := board.Fields["cardProperties"].([]interface{})
+	for _, p := range cardProperties {
+		if property, ok := p.(map[string]interface{}); ok && property["id"] == propertyID {
+			return property
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package sqlstore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/services/store/storetests"
+	"github.com/stretchr/testify/require"
+)
+
+func setupShards(t *testing.T, names ...string) (store.Store, map[string]store.Store) {
+	connect := func() store.Store {
+		s, err := New(sqliteDBType, ":memory:", "test_", 0, 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Shutdown() })
+		return s
+	}
+
+	shards := map[string]store.Store{}
+	for _, name := range names {
+		shards[name] = connect()
+	}
+
+	return connect(), shards
+}
+
+func setupShardedTests(t *testing.T) (store.Store, func()) {
+	primary, shards := setupShards(t, "shard-a", "shard-b")
+	router, err := store.NewRouter(primary, shards, map[string]string{"workspace-1": "shard-a", "workspace-2": "shard-b"})
+	require.NoError(t, err)
+
+	return router, func() {}
+}
+
+func TestRouter(t *testing.T) {
+	primary, shards := setupShards(t, "shard-a", "shard-b")
+	router, err := store.NewRouter(primary, shards, map[string]string{
+		"workspace-1": "shard-a",
+		"workspace-2": "shard-b",
+	})
+	require.NoError(t, err)
+
+	workspace1 := store.Container{WorkspaceID: "workspace-1"}
+	workspace2 := store.Container{WorkspaceID: "workspace-2"}
+
+	t.Run("workspaces on different shards are isolated", func(t *testing.T) {
+		require.NoError(t, router.InsertBlock(workspace1, model.Block{ID: "board-1", RootID: "board-1", Type: "board", ModifiedBy: "user-1"}))
+		require.NoError(t, router.InsertBlock(workspace2, model.Block{ID: "board-2", RootID: "board-2", Type: "board", ModifiedBy: "user-1"}))
+
+		exists, err := shards["shard-a"].BoardExists(workspace1, "board-1")
+		require.NoError(t, err)
+		require.True(t, exists)
+		exists, err = shards["shard-b"].BoardExists(workspace1, "board-1")
+		require.NoError(t, err)
+		require.False(t, exists)
+		exists, err = primary.BoardExists(workspace1, "board-1")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		blocks, err := router.GetBlocksByIDs(workspace2, []string{"board-1", "board-2"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, "board-2", blocks[0].ID)
+	})
+
+	t.Run("system settings use the primary", func(t *testing.T) {
+		require.NoError(t, router.SetSystemSetting("ShardedSetting", "on"))
+
+		settings, err := primary.GetSystemSettings()
+		require.NoError(t, err)
+		require.Equal(t, "on", settings["ShardedSetting"])
+
+		for _, shard := range shards {
+			settings, err := shard.GetSystemSettings()
+			require.NoError(t, err)
+			require.NotContains(t, settings, "ShardedSetting")
+		}
+	})
+
+	t.Run("the unmapped workspaces are spread by hash", func(t *testing.T) {
+		shard := router.ShardFor("workspace-3")
+		require.Contains(t, []store.Store{shards["shard-a"], shards["shard-b"]}, shard)
+		require.True(t, shard == router.ShardFor("workspace-3"))
+
+		// The root workspace stays in the primary
+		require.True(t, router.ShardFor("0") == primary)
+	})
+
+	t.Run("adding a shard only moves workspaces to it", func(t *testing.T) {
+		grown := map[string]store.Store{"shard-c": primary}
+		for name, shard := range shards {
+			grown[name] = shard
+		}
+		grownRouter, err := store.NewRouter(primary, grown, nil)
+		require.NoError(t, err)
+
+		moved := 0
+		for i := 0; i < 100; i++ {
+			workspaceID := fmt.Sprintf("workspace-%d", i+10)
+			shard := grownRouter.ShardFor(workspaceID)
+			if shard != router.ShardFor(workspaceID) {
+				require.True(t, shard == primary, "a workspace moved between the existing shards")
+				moved++
+			}
+		}
+		require.NotZero(t, moved)
+		require.Less(t, moved, 100)
+	})
+
+	t.Run("maintenance runs on every database", func(t *testing.T) {
+		reassigned, err := router.ReassignBoards("user-1", "user-2")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, reassigned)
+	})
+
+	t.Run("the workspaces by activity of every database", func(t *testing.T) {
+		activities, err := router.GetWorkspacesByActivity("user-2", 0)
+		require.NoError(t, err)
+		require.Len(t, activities, 2)
+
+		activities, err = router.GetWorkspacesByActivity("user-2", 1)
+		require.NoError(t, err)
+		require.Len(t, activities, 1)
+	})
+
+	t.Run("an unknown user isn't deleted from the shards", func(t *testing.T) {
+		_, err := router.DeleteUserReassigningBoards("user-2", "user-3")
+		require.Error(t, err)
+
+		activities, err := router.GetWorkspacesByActivity("user-2", 0)
+		require.NoError(t, err)
+		require.Len(t, activities, 2)
+
+		require.NoError(t, router.CreateUser(&model.User{ID: "user-2", Username: "user-2", Email: "user-2@example.com"}))
+		reassigned, err := router.DeleteUserReassigningBoards("user-2", "user-3")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, reassigned)
+	})
+
+	t.Run("unknown shard", func(t *testing.T) {
+		_, err := store.NewRouter(primary, shards, map[string]string{"workspace-1": "shard-c"})
+		require.Error(t, err)
+	})
+}
+
+func TestShardedStore(t *testing.T) {
+	t.Run("BlocksStore", func(t *testing.T) { storetests.StoreTestBlocksStore(t, setupShardedTests) })
+	t.Run("BoardsStore", func(t *testing.T) { storetests.StoreTestBoardsStore(t, setupShardedTests) })
+	t.Run("SharingStore", func(t *testing.T) { storetests.StoreTestSharingStore(t, setupShardedTests) })
+	t.Run("FilesStore", func(t *testing.T) { storetests.StoreTestFilesStore(t, setupShardedTests) })
+}
@@ -0,0 +1,145 @@
+package sqlstore
+
+import (
+	"log"
+	"strings"
+	"unicode"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// searchFoldings maps accented latin letters, in both cases, to the lower
+// case letter they are compared as when searching
+var searchFoldings = []struct {
+	base     rune
+	variants string
+}{
+	{'a', "àáâãäåāăąÀÁÂÃÄÅĀĂĄ"},
+	{'c', "çćĉċčÇĆĈĊČ"},
+	{'d', "ďđĎĐ"},
+	{'e', "èéêëēĕėęěÈÉÊËĒĔĖĘĚ"},
+	{'g', "ĝğġģĜĞĠĢ"},
+	{'i', "ìíîïĩīĭįÌÍÎÏĨĪĬĮ"},
+	{'l', "ĺļľłĹĻĽŁ"},
+	{'n', "ñńņňÑŃŅŇ"},
+	{'o', "òóôõöøōŏőÒÓÔÕÖØŌŎŐ"},
+	{'r', "ŕŗřŔŖŘ"},
+	{'s', "śŝşšŚŜŞŠ"},
+	{'t', "ţťŢŤ"},
+	{'u', "ùúûüũūŭůűųÙÚÛÜŨŪŬŮŰŲ"},
+	{'y', "ýÿŷÝŸŶ"},
+	{'z', "źżžŹŻŽ"},
+}
+
+var (
+	searchFoldFrom string
+	searchFoldTo   string
+	searchFolder   *strings.Replacer
+
+	// searchVariants are the letters compared as each base letter
+	searchVariants map[rune]string
+)
+
+func init() {
+	var from, to strings.Builder
+	var pairs []string
+	searchVariants = map[rune]string{}
+	for _, folding := range searchFoldings {
+		for _, variant := range folding.variants {
+			from.WriteRune(variant)
+			to.WriteRune(folding.base)
+			pairs = append(pairs, string(variant), string(folding.base))
+		}
+		searchVariants[folding.base] = folding.variants
+	}
+
+	searchFoldFrom = from.String()
+	searchFoldTo = to.String()
+	searchFolder = strings.NewReplacer(pairs...)
+}
+
+// foldSearchText lower-cases the text and strips the accents of the letters
+// in searchFoldings, the same way the search query does in the database
+func foldSearchText(text string) string {
+	return searchFolder.Replace(strings.ToLower(text))
+}
+
+// searchGlobPattern returns the GLOB pattern matching the texts that contain
+// the term regardless of case and accents. SQLite has neither translate()
+// nor unicode aware lower(), and nesting a replace() per accented letter
+// overflows its parser, so instead of folding the titles each letter of the
+// term matches a class of all the letters folded like it.
+func searchGlobPattern(term string) string {
+	var pattern strings.Builder
+	pattern.WriteString("*")
+	for _, r := range foldSearchText(term) {
+		upper := unicode.ToUpper(r)
+		switch {
+		case searchVariants[r] != "" || upper != r:
+			pattern.WriteString("[" + string(r))
+			if upper != r {
+				pattern.WriteRune(upper)
+			}
+			pattern.WriteString(searchVariants[r] + "]")
+		case r == '*' || r == '?' || r == '[':
+			pattern.WriteString("[" + string(r) + "]")
+		default:
+			pattern.WriteRune(r)
+		}
+	}
+	pattern.WriteString("*")
+
+	return pattern.String()
+}
+
+// searchTitleCondition returns the condition matching the titles that
+// contain the term regardless of case and accents. The default collations
+// don't agree on this: Postgres is case sensitive and SQLite only folds
+// ASCII letters.
+func (s *SQLStore) searchTitleCondition(term string) sq.Sqlizer {
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	pattern := "%" + escaper.Replace(foldSearchText(term)) + "%"
+
+	switch s.dbType {
+	case postgresDBType:
+		return sq.Expr("translate(title, ?, ?) ILIKE ?", searchFoldFrom, searchFoldTo, pattern)
+	case mysqlDBType:
+		return sq.Expr("title COLLATE utf8mb4_general_ci LIKE ?", pattern)
+	default:
+		return sq.Expr("title GLOB ?", searchGlobPattern(term))
+	}
+}
+
+// SearchBlocks returns the blocks of the workspace whose title contains the
+// term, ignoring case and accents, so "cafe" matches "Café" and "CAFÉ"
+func (s *SQLStore) SearchBlocks(c store.Container, term string) ([]model.Block, error) {
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"parent_id",
+			"root_id",
+			"modified_by",
+			s.escapeField("schema"),
+			"type",
+			"title",
+			"COALESCE(fields, '{}')",
+			"create_at",
+			"update_at",
+			"delete_at",
+		).
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"archived": false}).
+		Where(s.searchTitleCondition(term))
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`searchBlocks ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
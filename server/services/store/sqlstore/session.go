@@ -28,7 +28,7 @@ func (s *SQLStore) GetActiveUserCount(updatedSecondsAgo int64) (int, error) {
 
 func (s *SQLStore) GetSession(token string, expireTime int64) (*model.Session, error) {
 	query := s.getQueryBuilder().
-		Select("id", "token", "user_id", "auth_service", "props").
+		Select("id", "token", "user_id", "auth_service", "props", "create_at", "update_at", "COALESCE(device_fingerprint, '')").
 		From(s.tablePrefix + "sessions").
 		Where(sq.Eq{"token": token}).
 		Where(sq.Gt{"update_at": time.Now().Unix() - expireTime})
@@ -37,7 +37,7 @@ func (s *SQLStore) GetSession(token string, expireTime int64) (*model.Session, e
 	session := model.Session{}
 
 	var propsBytes []byte
-	err := row.Scan(&session.ID, &session.Token, &session.UserID, &session.AuthService, &propsBytes)
+	err := row.Scan(&session.ID, &session.Token, &session.UserID, &session.AuthService, &propsBytes, &session.CreateAt, &session.UpdateAt, &session.DeviceFingerprint)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +50,43 @@ func (s *SQLStore) GetSession(token string, expireTime int64) (*model.Session, e
 	return &session, nil
 }
 
+// GetRecentSessions returns the sessions used within N seconds ago, the most
+// recently used first, up to the limit
+func (s *SQLStore) GetRecentSessions(updatedSecondsAgo int64, limit int) ([]*model.Session, error) {
+	query := s.getQueryBuilder().
+		Select("id", "token", "user_id", "auth_service", "props", "create_at", "update_at", "COALESCE(device_fingerprint, '')").
+		From(s.tablePrefix + "sessions").
+		Where(sq.Gt{"update_at": time.Now().Unix() - updatedSecondsAgo}).
+		OrderBy("update_at DESC").
+		Limit(uint64(limit))
+
+	rows, err := query.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*model.Session{}
+	for rows.Next() {
+		session := model.Session{}
+
+		var propsBytes []byte
+		err := rows.Scan(&session.ID, &session.Token, &session.UserID, &session.AuthService, &propsBytes, &session.CreateAt, &session.UpdateAt, &session.DeviceFingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(propsBytes, &session.Props)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
 func (s *SQLStore) CreateSession(session *model.Session) error {
 	now := time.Now().Unix()
 
@@ -59,8 +96,8 @@ func (s *SQLStore) CreateSession(session *model.Session) error {
 	}
 
 	query := s.getQueryBuilder().Insert(s.tablePrefix+"sessions").
-		Columns("id", "token", "user_id", "auth_service", "props", "create_at", "update_at").
-		Values(session.ID, session.Token, session.UserID, session.AuthService, propsBytes, now, now)
+		Columns("id", "token", "user_id", "auth_service", "props", "create_at", "update_at", "device_fingerprint").
+		Values(session.ID, session.Token, session.UserID, session.AuthService, propsBytes, now, now, session.DeviceFingerprint)
 
 	_, err = query.Exec()
 	return err
@@ -95,17 +132,89 @@ func (s *SQLStore) UpdateSession(session *model.Session) error {
 }
 
 func (s *SQLStore) DeleteSession(sessionId string) error {
-	query := s.getQueryBuilder().Delete(s.tablePrefix+"sessions").
-		Where("id", sessionId)
+	query := s.getQueryBuilder().Delete(s.tablePrefix + "sessions").
+		Where(sq.Eq{"id": sessionId})
 
 	_, err := query.Exec()
 	return err
 }
 
-func (s *SQLStore) CleanUpSessions(expireTime int64) error {
+// GetExpiredSessionIDs returns the IDs of the sessions not updated within
+// expireTime seconds, the ones CleanUpSessions would remove
+func (s *SQLStore) GetExpiredSessionIDs(expireTime int64) ([]string, error) {
+	query := s.getQueryBuilder().
+		Select("id").
+		From(s.tablePrefix + "sessions").
+		Where(sq.Lt{"update_at": time.Now().Unix() - expireTime})
+
+	rows, err := query.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetUserSessionIDs returns the IDs of the sessions of a user
+func (s *SQLStore) GetUserSessionIDs(userID string) ([]string, error) {
+	query := s.getQueryBuilder().
+		Select("id").
+		From(s.tablePrefix + "sessions").
+		Where(sq.Eq{"user_id": userID})
+
+	rows, err := query.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// CleanUpSessions deletes the sessions not updated within expireTime
+// seconds and returns how many were removed
+func (s *SQLStore) CleanUpSessions(expireTime int64) (int64, error) {
 	query := s.getQueryBuilder().Delete(s.tablePrefix + "sessions").
 		Where(sq.Lt{"update_at": time.Now().Unix() - expireTime})
 
-	_, err := query.Exec()
-	return err
+	result, err := query.Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteSessionsForDeletedUsers deletes the sessions of the users that are
+// deleted or don't exist anymore, which would otherwise last until they
+// expire, and returns how many were removed
+func (s *SQLStore) DeleteSessionsForDeletedUsers() (int64, error) {
+	query := s.getQueryBuilder().Delete(s.tablePrefix + "sessions").
+		Where("NOT EXISTS (SELECT 1 FROM " + s.tablePrefix + "users u WHERE u.id = " + s.tablePrefix + "sessions.user_id AND u.delete_at = 0)")
+
+	result, err := query.Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
 }
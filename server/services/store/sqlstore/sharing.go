@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"log"
 	"time"
 
 	"github.com/mattermost/focalboard/server/model"
@@ -16,22 +17,26 @@ func (s *SQLStore) UpsertSharing(c store.Container, sharing model.Sharing) error
 		Insert(s.tablePrefix+"sharing").
 		Columns(
 			"id",
+			"workspace_id",
 			"enabled",
 			"token",
 			"modified_by",
 			"update_at",
+			"expire_at",
 		).
 		Values(
 			sharing.ID,
+			c.WorkspaceID,
 			sharing.Enabled,
 			sharing.Token,
 			sharing.ModifiedBy,
 			now,
+			sharing.ExpireAt,
 		)
 	if s.dbType == mysqlDBType {
-		query = query.Suffix("ON DUPLICATE KEY UPDATE enabled = ?, token = ?, modified_by = ?, update_at = ?", sharing.Enabled, sharing.Token, sharing.ModifiedBy, now)
+		query = query.Suffix("ON DUPLICATE KEY UPDATE workspace_id = ?, enabled = ?, token = ?, modified_by = ?, update_at = ?, expire_at = ?", c.WorkspaceID, sharing.Enabled, sharing.Token, sharing.ModifiedBy, now, sharing.ExpireAt)
 	} else {
-		query = query.Suffix("ON CONFLICT (id) DO UPDATE SET enabled = EXCLUDED.enabled, token = EXCLUDED.token, modified_by = EXCLUDED.modified_by, update_at = EXCLUDED.update_at")
+		query = query.Suffix("ON CONFLICT (id) DO UPDATE SET workspace_id = EXCLUDED.workspace_id, enabled = EXCLUDED.enabled, token = EXCLUDED.token, modified_by = EXCLUDED.modified_by, update_at = EXCLUDED.update_at, expire_at = EXCLUDED.expire_at")
 	}
 
 	_, err := query.Exec()
@@ -39,15 +44,7 @@ func (s *SQLStore) UpsertSharing(c store.Container, sharing model.Sharing) error
 }
 
 func (s *SQLStore) GetSharing(c store.Container, rootID string) (*model.Sharing, error) {
-	query := s.getQueryBuilder().
-		Select(
-			"id",
-			"enabled",
-			"token",
-			"modified_by",
-			"update_at",
-		).
-		From(s.tablePrefix + "sharing").
+	query := s.getSharingQuery().
 		Where(sq.Eq{"id": rootID})
 	row := query.QueryRow()
 	sharing := model.Sharing{}
@@ -58,6 +55,7 @@ func (s *SQLStore) GetSharing(c store.Container, rootID string) (*model.Sharing,
 		&sharing.Token,
 		&sharing.ModifiedBy,
 		&sharing.UpdateAt,
+		&sharing.ExpireAt,
 	)
 	if err != nil {
 		return nil, err
@@ -65,3 +63,82 @@ func (s *SQLStore) GetSharing(c store.Container, rootID string) (*model.Sharing,
 
 	return &sharing, nil
 }
+
+// GetExpiredSharingTokens returns the sharing tokens of all the workspaces
+// that expired at the time now, in seconds
+func (s *SQLStore) GetExpiredSharingTokens(now int64) ([]model.Sharing, error) {
+	query := s.getSharingQuery().
+		Where(sq.Gt{"expire_at": 0}).
+		Where(sq.LtOrEq{"expire_at": now})
+
+	return s.getSharings(query)
+}
+
+// DeleteExpiredSharingTokens removes the sharing tokens that expired at the
+// time now, in seconds, and returns how many were removed
+func (s *SQLStore) DeleteExpiredSharingTokens(now int64) (int64, error) {
+	query := s.getQueryBuilder().
+		Delete(s.tablePrefix + "sharing").
+		Where(sq.Gt{"expire_at": 0}).
+		Where(sq.LtOrEq{"expire_at": now})
+
+	result, err := query.Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetActiveSharingTokens returns the enabled sharing tokens of the workspace
+// that haven't expired at the time now, in seconds
+func (s *SQLStore) GetActiveSharingTokens(c store.Container, now int64) ([]model.Sharing, error) {
+	query := s.getSharingQuery().
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID}).
+		Where(sq.Eq{"enabled": true}).
+		Where(sq.Or{sq.Eq{"expire_at": 0}, sq.Gt{"expire_at": now}}).
+		OrderBy("id")
+
+	return s.getSharings(query)
+}
+
+func (s *SQLStore) getSharingQuery() sq.SelectBuilder {
+	return s.getQueryBuilder().
+		Select(
+			"id",
+			"enabled",
+			"token",
+			"modified_by",
+			"update_at",
+			"expire_at",
+		).
+		From(s.tablePrefix + "sharing")
+}
+
+func (s *SQLStore) getSharings(query sq.SelectBuilder) ([]model.Sharing, error) {
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getSharings ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sharings := []model.Sharing{}
+	for rows.Next() {
+		var sharing model.Sharing
+		err := rows.Scan(
+			&sharing.ID,
+			&sharing.Enabled,
+			&sharing.Token,
+			&sharing.ModifiedBy,
+			&sharing.UpdateAt,
+			&sharing.ExpireAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sharings = append(sharings, sharing)
+	}
+
+	return sharings, rows.Err()
+}
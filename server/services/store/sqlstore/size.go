@@ -0,0 +1,73 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// GetDatabaseSize returns the space used by the database and by each of the
+// tables of the store. SQLite only reports the tables if it's built with the
+// dbstat virtual table.
+func (s *SQLStore) GetDatabaseSize() (store.DBSize, error) {
+	size := store.DBSize{Tables: map[string]int64{}}
+
+	var rows *sql.Rows
+	var err error
+	switch s.dbType {
+	case sqliteDBType:
+		err = s.db.QueryRow("SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&size.Total)
+		if err != nil {
+			return size, err
+		}
+
+		rows, err = s.db.Query("SELECT name, SUM(pgsize) FROM dbstat GROUP BY name")
+		if err != nil {
+			// Without dbstat only the total is available
+			return size, nil
+		}
+	case postgresDBType:
+		err = s.db.QueryRow("SELECT pg_database_size(current_database())").Scan(&size.Total)
+		if err != nil {
+			return size, err
+		}
+
+		rows, err = s.db.Query("SELECT relname, pg_total_relation_size(relid) FROM pg_catalog.pg_statio_user_tables")
+	case mysqlDBType:
+		rows, err = s.db.Query("SELECT table_name, data_length + index_length FROM information_schema.tables WHERE table_schema = DATABASE()")
+	default:
+		return size, fmt.Errorf("size of %s databases: %w", s.dbType, store.ErrNotSupported)
+	}
+	if err != nil {
+		log.Printf(`getDatabaseSize ERROR: %v`, err)
+		return size, err
+	}
+	defer rows.Close()
+
+	var tablesTotal int64
+	for rows.Next() {
+		var name string
+		var tableSize sql.NullInt64
+		if err := rows.Scan(&name, &tableSize); err != nil {
+			return size, err
+		}
+
+		tablesTotal += tableSize.Int64
+		if strings.HasPrefix(name, s.tablePrefix) {
+			size.Tables[strings.TrimPrefix(name, s.tablePrefix)] = tableSize.Int64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return size, err
+	}
+
+	// MySQL has no size for the whole database
+	if s.dbType == mysqlDBType {
+		size.Total = tablesTotal
+	}
+
+	return size, nil
+}
@@ -0,0 +1,39 @@
+package sqlstore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDatabaseSize(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	before, err := s.GetDatabaseSize()
+	require.NoError(t, err)
+	require.Greater(t, before.Total, int64(0))
+	for _, tableSize := range before.Tables {
+		require.LessOrEqual(t, tableSize, before.Total)
+	}
+
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+	for i := 0; i < 50; i++ {
+		err := s.InsertBlock(container, model.Block{
+			ID:     "size-block-" + strings.Repeat("x", i),
+			RootID: "size-board",
+			Type:   "card",
+			Title:  strings.Repeat("filler ", 200),
+		})
+		require.NoError(t, err)
+	}
+
+	after, err := s.GetDatabaseSize()
+	require.NoError(t, err)
+	require.Greater(t, after.Total, before.Total)
+}
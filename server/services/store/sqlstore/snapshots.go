@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// snapshotArchiveVersion is the version of the export archive format the
+// snapshots are stored in
+const snapshotArchiveVersion = 1
+
+// CreateBoardSnapshot stores a copy of the blocks of a board, read in a
+// single query, and returns the ID of the snapshot
+func (s *SQLStore) CreateBoardSnapshot(c store.Container, boardID, createdBy string) (string, error) {
+	blocks, err := s.GetBlocksWithRootID(c, boardID)
+	if err != nil {
+		return "", err
+	}
+
+	found := false
+	for _, block := range blocks {
+		if block.ID == boardID && block.Type == "board" {
+			found = true
+		}
+	}
+	if !found {
+		return "", &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	now := utils.GetMillis()
+	data, err := json.Marshal(model.Archive{
+		Version: snapshotArchiveVersion,
+		Date:    now,
+		Blocks:  blocks,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	snapshotID := utils.CreateGUID()
+	query := s.getQueryBuilder().
+		Insert(s.tablePrefix+"board_snapshots").
+		Columns(
+			"id",
+			"workspace_id",
+			"board_id",
+			"created_by",
+			"create_at",
+			"data",
+		).
+		Values(
+			snapshotID,
+			c.WorkspaceID,
+			boardID,
+			createdBy,
+			now,
+			data,
+		)
+
+	if _, err := query.Exec(); err != nil {
+		log.Printf(`createBoardSnapshot ERROR: %v`, err)
+		return "", err
+	}
+
+	return snapshotID, nil
+}
+
+// GetBoardSnapshot returns a snapshot of the workspace with its blocks, or
+// sql.ErrNoRows if there's no such snapshot
+func (s *SQLStore) GetBoardSnapshot(c store.Container, snapshotID string) (*model.BoardSnapshot, error) {
+	query := s.getQueryBuilder().
+		Select("id", "board_id", "COALESCE(created_by, '')", "create_at", "data").
+		From(s.tablePrefix + "board_snapshots").
+		Where(sq.Eq{"id": snapshotID}).
+		Where(sq.Eq{"workspace_id": c.WorkspaceID})
+
+	var snapshot model.BoardSnapshot
+	var data []byte
+	err := query.QueryRow().Scan(&snapshot.ID, &snapshot.BoardID, &snapshot.CreatedBy, &snapshot.CreateAt, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot.Archive); err != nil {
+		log.Printf("getBoardSnapshot ERROR unmarshalling data: %v", err)
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetBoardSnapshots returns the snapshots of a board, newest first, without
+// their blocks
+func (s *SQLStore) GetBoardSnapshots(c store.Container, boardID string) ([]model.BoardSnapshot, error) {
+	query := s.getQueryBuilder().
+		Select("id", "board_id", "COALESCE(created_by, '')", "create_at").
+		From(s.tablePrefix+"board_snapshots").
+		Where(sq.Eq{"workspace_id": c.WorkspaceID}).
+		Where(sq.Eq{"board_id": boardID}).
+		OrderBy("create_at DESC", "id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBoardSnapshots ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []model.BoardSnapshot{}
+	for rows.Next() {
+		var snapshot model.BoardSnapshot
+		if err := rows.Scan(&snapshot.ID, &snapshot.BoardID, &snapshot.CreatedBy, &snapshot.CreateAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
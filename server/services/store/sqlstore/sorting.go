@@ -0,0 +1,162 @@
+package sqlstore
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+)
+
+// numberPattern matches the values of number properties that can be
+// compared numerically, the rest of them sort as if they were empty
+const numberPattern = `^[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?$`
+
+var numberRegexp = regexp.MustCompile(numberPattern)
+
+// GetBlocksWithParentAndTypeSorted returns the same blocks as
+// GetBlocksWithParentAndType ordered by a property, using the block ID to
+// break ties. If the parent is a board and the property is a number in its
+// schema the values are compared as numbers, otherwise as text.
+func (s *SQLStore) GetBlocksWithParentAndTypeSorted(c store.Container, parentID string, blockType string, blockSort store.BlockSort) ([]model.Block, error) {
+	if err := checkPropertyID(blockSort.PropertyID); err != nil {
+		return nil, err
+	}
+
+	numeric, err := s.isNumberProperty(c, parentID, blockSort.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.getBlocksWithParentAndTypeQuery(c, parentID, blockType)
+
+	if !s.jsonSupported {
+		rows, err := query.Query()
+		if err != nil {
+			log.Printf(`getBlocksWithParentAndTypeSorted ERROR: %v`, err)
+
+			return nil, err
+		}
+
+		blocks, err := blocksFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sortBlocksByProperty(blocks, blockSort, numeric)
+
+		return blocks, nil
+	}
+
+	value, args := s.propertySortValue(blockSort.PropertyID, numeric)
+	direction := "ASC"
+	if blockSort.Descending {
+		direction = "DESC"
+	}
+
+	query = query.
+		OrderByClause(fmt.Sprintf("CASE WHEN %s IS NULL THEN 1 ELSE 0 END", value), args...).
+		OrderByClause(fmt.Sprintf("%s %s", value, direction), args...).
+		OrderBy("id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getBlocksWithParentAndTypeSorted ERROR: %v`, err)
+
+		return nil, err
+	}
+
+	return blocksFromRows(rows)
+}
+
+// isNumberProperty checks if the parent block is a board that defines the
+// property as a number
+func (s *SQLStore) isNumberProperty(c store.Container, parentID, propertyID string) (bool, error) {
+	rows, err := s.getBlocksByIDsQuery(c, []string{parentID}).Query()
+	if err != nil {
+		log.Printf(`isNumberProperty ERROR: %v`, err)
+
+		return false, err
+	}
+
+	parents, err := blocksFromRows(rows)
+	if err != nil {
+		return false, err
+	}
+
+	if len(parents) == 0 || parents[0].Type != "board" {
+		return false, nil
+	}
+
+	property := boardProperty(parents[0], propertyID)
+
+	return property != nil && property["type"] == "number", nil
+}
+
+// propertySortValue returns the expression to order the blocks by, which
+// is NULL for the blocks with no value for the property
+func (s *SQLStore) propertySortValue(propertyID string, numeric bool) (string, []interface{}) {
+	jsonPath := fmt.Sprintf(`$.properties."%s"`, propertyID)
+
+	switch {
+	case s.dbType == postgresDBType && numeric:
+		return "CASE WHEN (fields->'properties'->>?) ~ ? THEN CAST(fields->'properties'->>? AS DOUBLE PRECISION) END",
+			[]interface{}{propertyID, numberPattern, propertyID}
+	case s.dbType == postgresDBType:
+		return "NULLIF(fields->'properties'->>?, '')", []interface{}{propertyID}
+	case s.dbType == mysqlDBType && numeric:
+		return "CASE WHEN JSON_UNQUOTE(JSON_EXTRACT(fields, ?)) REGEXP ? THEN CAST(JSON_UNQUOTE(JSON_EXTRACT(fields, ?)) AS DECIMAL(65, 10)) END",
+			[]interface{}{jsonPath, numberPattern, jsonPath}
+	case s.dbType == mysqlDBType:
+		return "NULLIF(JSON_UNQUOTE(JSON_EXTRACT(fields, ?)), '')", []interface{}{jsonPath}
+	case numeric:
+		// SQLite has no regular expressions by default, so only discard the
+		// values with characters that can't be part of a number
+		return "CASE WHEN json_extract(fields, ?) <> '' AND json_extract(fields, ?) NOT GLOB '*[^0-9.eE+-]*' THEN CAST(json_extract(fields, ?) AS REAL) END",
+			[]interface{}{jsonPath, jsonPath, jsonPath}
+	default:
+		return "NULLIF(json_extract(fields, ?), '')", []interface{}{jsonPath}
+	}
+}
+
+// sortBlocksByProperty orders the blocks in memory the same way the query
+// does, for the databases that can't extract the property
+func sortBlocksByProperty(blocks []model.Block, blockSort store.BlockSort, numeric bool) {
+	type sortKey struct {
+		text   string
+		number float64
+		empty  bool
+	}
+
+	keys := make(map[string]sortKey, len(blocks))
+	for _, block := range blocks {
+		properties, _ := block.Fields["properties"].(map[string]interface{})
+		value, _ := properties[blockSort.PropertyID].(string)
+
+		key := sortKey{text: value, empty: value == ""}
+		if numeric {
+			number, err := strconv.ParseFloat(value, 64)
+			key.number = number
+			key.empty = err != nil || !numberRegexp.MatchString(value)
+		}
+		keys[block.ID] = key
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := keys[blocks[i].ID], keys[blocks[j].ID]
+		switch {
+		case a.empty != b.empty:
+			return b.empty
+		case a.empty:
+		case numeric && a.number != b.number:
+			return (a.number < b.number) != blockSort.Descending
+		case !numeric && a.text != b.text:
+			return (a.text < b.text) != blockSort.Descending
+		}
+
+		return blocks[i].ID < blocks[j].ID
+	})
+}
@@ -3,8 +3,11 @@ package sqlstore
 import (
 	"database/sql"
 	"log"
+	"sync"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
 )
 
 const (
@@ -13,52 +16,122 @@ const (
 	postgresDBType = "postgres"
 )
 
+// maxConnectRetryInterval caps the wait between the connection attempts
+const maxConnectRetryInterval = time.Minute
+
+// sqlOpen opens the database, it's replaced by the tests
+var sqlOpen = sql.Open
+
 // SQLStore is a SQL database.
 type SQLStore struct {
 	db          *sql.DB
 	dbType      string
 	tablePrefix string
+	// SQLite may be built without the JSON1 extension
+	jsonSupported bool
+
+	// The insert_at of the last history entry written to SQLite
+	historyMu       sync.Mutex
+	lastHistoryTime time.Time
+
+	// filesBackend is the files storage scanned for the orphaned files, and
+	// read by the workspace exports
+	filesBackend filesstore.FileBackend
 }
 
-// New creates a new SQL implementation of the store.
-func New(dbType, connectionString string, tablePrefix string) (*SQLStore, error) {
-	log.Println("connectDatabase", dbType, connectionString)
-	var err error
+// New creates a new SQL implementation of the store. If the database can't
+// be reached, the connection is retried up to connectRetries times, waiting
+// connectRetryInterval after the first attempt and twice as long after each
+// of the next ones, so the server can start before the database is ready.
+func New(dbType, connectionString string, tablePrefix string, connectRetries int, connectRetryInterval time.Duration) (*SQLStore, error) {
+	store, err := connectWithRetries(dbType, connectionString, tablePrefix, connectRetries, connectRetryInterval)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open(dbType, connectionString)
+	err = store.Migrate()
 	if err != nil {
-		log.Print("connectDatabase: ", err)
+		log.Printf(`Table creation / migration failed: %v`, err)
 
 		return nil, err
 	}
 
-	err = db.Ping()
+	err = store.InitializeTemplates()
 	if err != nil {
-		log.Printf(`Database Ping failed: %v`, err)
+		log.Printf(`InitializeTemplates failed: %v`, err)
 
 		return nil, err
 	}
 
-	store := &SQLStore{
-		db:          db,
-		dbType:      dbType,
-		tablePrefix: tablePrefix,
+	return store, nil
+}
+
+// DryRunMigrations logs the migrations that New would apply to the database,
+// and returns them without applying any
+func DryRunMigrations(dbType, connectionString string, tablePrefix string) ([]string, error) {
+	store, err := connect(dbType, connectionString, tablePrefix)
+	if err != nil {
+		return nil, err
 	}
+	defer store.Shutdown()
 
-	err = store.Migrate()
+	version, err := store.GetSchemaVersion()
 	if err != nil {
-		log.Printf(`Table creation / migration failed: %v`, err)
+		return nil, err
+	}
+
+	log.Printf("Migrate dry run, schema version: %d, dirty: %t, pending migrations: %d", version.Version, version.Dirty, len(version.Pending))
+	for _, name := range version.Pending {
+		log.Printf("Pending migration: %s", name)
+	}
+
+	return version.Pending, nil
+}
+
+func connectWithRetries(dbType, connectionString string, tablePrefix string, retries int, interval time.Duration) (*SQLStore, error) {
+	for attempt := 1; ; attempt++ {
+		store, err := connect(dbType, connectionString, tablePrefix)
+		if err == nil || attempt > retries {
+			return store, err
+		}
+
+		log.Printf("Database connection attempt %d of %d failed, retrying in %s: %v", attempt, retries+1, interval, err)
+		time.Sleep(interval)
+
+		interval *= 2
+		if interval > maxConnectRetryInterval {
+			interval = maxConnectRetryInterval
+		}
+	}
+}
+
+func connect(dbType, connectionString string, tablePrefix string) (*SQLStore, error) {
+	log.Println("connectDatabase", dbType, connectionString)
+	var err error
+
+	db, err := sqlOpen(dbType, connectionString)
+	if err != nil {
+		log.Print("connectDatabase: ", err)
 
 		return nil, err
 	}
 
-	err = store.InitializeTemplates()
+	err = db.Ping()
 	if err != nil {
-		log.Printf(`InitializeTemplates failed: %v`, err)
+		log.Printf(`Database Ping failed: %v`, err)
+		db.Close()
 
 		return nil, err
 	}
 
+	store := &SQLStore{
+		db:          db,
+		dbType:      dbType,
+		tablePrefix: tablePrefix,
+	}
+
+	store.jsonSupported = store.checkJSONSupport()
+
 	return store, nil
 }
 
@@ -67,6 +140,27 @@ func (s *SQLStore) Shutdown() error {
 	return s.db.Close()
 }
 
+// DBStats returns the statistics of the database connection pool
+func (s *SQLStore) DBStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// checkJSONSupport returns true if the database provides the JSON functions
+func (s *SQLStore) checkJSONSupport() bool {
+	if s.dbType != sqliteDBType {
+		return true
+	}
+
+	var value string
+	err := s.db.QueryRow(`SELECT json_extract('{"a":"b"}', '$.a')`).Scan(&value)
+	if err != nil {
+		log.Printf("SQLite JSON functions not available, falling back to pattern matching: %v", err)
+		return false
+	}
+
+	return true
+}
+
 func (s *SQLStore) getQueryBuilder() sq.StatementBuilderType {
 	builder := sq.StatementBuilder
 	if s.dbType == postgresDBType || s.dbType == sqliteDBType {
@@ -20,7 +20,7 @@ func SetupTests(t *testing.T) (store.Store, func()) {
 		connectionString = ":memory:"
 	}
 
-	store, err := New(dbType, connectionString, "test_")
+	store, err := New(dbType, connectionString, "test_", 0, 0)
 	require.Nil(t, err)
 
 	tearDown := func() {
@@ -33,5 +33,11 @@ func SetupTests(t *testing.T) (store.Store, func()) {
 
 func TestBlocksStore(t *testing.T) {
 	t.Run("BlocksStore", func(t *testing.T) { storetests.StoreTestBlocksStore(t, SetupTests) })
+	t.Run("BoardsStore", func(t *testing.T) { storetests.StoreTestBoardsStore(t, SetupTests) })
 	t.Run("SharingStore", func(t *testing.T) { storetests.StoreTestSharingStore(t, SetupTests) })
+	t.Run("WorkspaceWebhooksStore", func(t *testing.T) { storetests.StoreTestWorkspaceWebhooksStore(t, SetupTests) })
+	t.Run("UsersStore", func(t *testing.T) { storetests.StoreTestUsersStore(t, SetupTests) })
+	t.Run("FilesStore", func(t *testing.T) { storetests.StoreTestFilesStore(t, SetupTests) })
+	t.Run("AuditStore", func(t *testing.T) { storetests.StoreTestAuditStore(t, SetupTests) })
+	t.Run("WorkspacesStore", func(t *testing.T) { storetests.StoreTestWorkspacesStore(t, SetupTests) })
 }
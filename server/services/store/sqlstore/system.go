@@ -8,6 +8,8 @@
  */
 package sqlstore
 
+import "github.com/mattermost/focalboard/server/services/store"
+
 //获取系统设置路径
 func (s *SQLStore) GetSystemSettings() (map[string]string, error) {
 	query := s.getQueryBuilder().Select("*").From(s.tablePrefix + "system_settings") //sql查询
@@ -35,8 +37,25 @@ func (s *SQLStore) GetSystemSettings() (map[string]string, error) {
 	return results, nil
 }
 
+// GetFeatureFlags returns the values of the system settings that are feature
+// flags, by the name of the flag
+func (s *SQLStore) GetFeatureFlags() (map[string]string, error) {
+	settings, err := s.GetSystemSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.FeatureFlags(settings), nil
+}
+
+// SetSystemSetting stores the setting, replacing its value if it's already set
 func (s *SQLStore) SetSystemSetting(id, value string) error {
 	query := s.getQueryBuilder().Insert(s.tablePrefix+"system_settings").Columns("id", "value").Values(id, value)
+	if s.dbType == mysqlDBType {
+		query = query.Suffix("ON DUPLICATE KEY UPDATE value = ?", value)
+	} else {
+		query = query.Suffix("ON CONFLICT (id) DO UPDATE SET value = EXCLUDED.value")
+	}
 
 	_, err := query.Exec()
 	if err != nil {
@@ -45,3 +64,17 @@ func (s *SQLStore) SetSystemSetting(id, value string) error {
 
 	return nil
 }
+
+// CreateSystemSettingIfNotExists stores the setting unless it's already set,
+// so when several servers race to initialize it the first value is kept
+func (s *SQLStore) CreateSystemSettingIfNotExists(id, value string) error {
+	query := s.getQueryBuilder().Insert(s.tablePrefix+"system_settings").Columns("id", "value").Values(id, value)
+	if s.dbType == mysqlDBType {
+		query = query.Options("IGNORE")
+	} else {
+		query = query.Suffix("ON CONFLICT (id) DO NOTHING")
+	}
+
+	_, err := query.Exec()
+	return err
+}
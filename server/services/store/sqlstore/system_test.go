@@ -0,0 +1,31 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSystemSettingIfNotExists(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	require.NoError(t, s.CreateSystemSettingIfNotExists("TelemetryID", "first-id"))
+	require.NoError(t, s.CreateSystemSettingIfNotExists("TelemetryID", "second-id"))
+
+	settings, err := s.GetSystemSettings()
+	require.NoError(t, err)
+	require.Equal(t, "first-id", settings["TelemetryID"])
+}
+
+func TestSetSystemSetting(t *testing.T) {
+	s, tearDown := SetupTests(t)
+	defer tearDown()
+
+	require.NoError(t, s.SetSystemSetting("Setting", "first-value"))
+	require.NoError(t, s.SetSystemSetting("Setting", "second-value"))
+
+	settings, err := s.GetSystemSettings()
+	require.NoError(t, err)
+	require.Equal(t, "second-value", settings["Setting"])
+}
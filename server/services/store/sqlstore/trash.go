@@ -0,0 +1,333 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+)
+
+// trashBatchSize is the number of blocks looked up or removed by each query,
+// to stay under the limit of query parameters of the databases
+const trashBatchSize = 500
+
+// latestVersion matches the latest version of a block in the history, by
+// the alias of the history table. The entries of the deletions only have the
+// ID of the block, so they're skipped.
+func (s *SQLStore) latestVersion(alias string) string {
+	return alias + ".insert_at = (SELECT MAX(v.insert_at) FROM " + s.tablePrefix + "blocks_history v" +
+		" WHERE v.id = " + alias + ".id AND v.type IS NOT NULL)"
+}
+
+// deletedBlocksQuery selects the blocks in the trash: the ones removed from
+// the blocks table whose last history entry is their deletion, aliased d
+func (s *SQLStore) deletedBlocksQuery(columns ...string) sq.SelectBuilder {
+	history := s.tablePrefix + "blocks_history"
+
+	return s.getQueryBuilder().
+		Select(columns...).
+		From(history + " d").
+		Where(sq.Gt{"d.delete_at": 0}).
+		Where("d.insert_at = (SELECT MAX(p.insert_at) FROM " + history + " p WHERE p.id = d.id)").
+		Where("NOT EXISTS (SELECT 1 FROM " + s.tablePrefix + "blocks b WHERE b.id = d.id)")
+}
+
+func (s *SQLStore) deletedBoardsQuery() sq.SelectBuilder {
+	return s.deletedBlocksQuery("d.id", "COALESCE(d.workspace_id, '0')", "COALESCE(d.modified_by, '')", "d.delete_at").
+		Where("EXISTS (SELECT 1 FROM "+s.tablePrefix+"blocks_history p WHERE p.id = d.id AND p.type = 'board')").
+		OrderBy("d.delete_at DESC", "d.id")
+}
+
+func deletedBoardsFromRows(rows *sql.Rows) ([]model.DeletedBoard, error) {
+	defer rows.Close()
+
+	boards := []model.DeletedBoard{}
+	for rows.Next() {
+		var board model.DeletedBoard
+		if err := rows.Scan(&board.BoardID, &board.WorkspaceID, &board.ModifiedBy, &board.DeleteAt); err != nil {
+			return nil, err
+		}
+		boards = append(boards, board)
+	}
+
+	return boards, rows.Err()
+}
+
+func idsFromRows(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// latestBlockVersions returns the latest version of the blocks in the
+// history, by their ID
+func (s *SQLStore) latestBlockVersions(ctx context.Context, db sq.QueryerContext, blockIDs []string) (map[string]model.Block, error) {
+	blocks := make(map[string]model.Block, len(blockIDs))
+	for start := 0; start < len(blockIDs); start += trashBatchSize {
+		end := start + trashBatchSize
+		if end > len(blockIDs) {
+			end = len(blockIDs)
+		}
+
+		query := s.getQueryBuilder().
+			Select(
+				"h.id",
+				"h.parent_id",
+				"h.root_id",
+				"h.modified_by",
+				"h."+s.escapeField("schema"),
+				"h.type",
+				"h.title",
+				"COALESCE(h.fields, '{}')",
+				"h.create_at",
+				"h.update_at",
+				"COALESCE(h.delete_at, 0)",
+			).
+			From(s.tablePrefix + "blocks_history h").
+			Where(sq.Eq{"h.id": blockIDs[start:end]}).
+			Where(s.latestVersion("h"))
+
+		rows, err := sq.QueryContextWith(ctx, db, query)
+		if err != nil {
+			return nil, err
+		}
+
+		found, err := blocksFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range found {
+			blocks[block.ID] = block
+		}
+	}
+
+	return blocks, nil
+}
+
+// GetDeletedBoards returns the boards in the trash of a workspace, most
+// recently deleted first. Each board is its latest version before the
+// deletion, with the time of the deletion and the user who deleted it.
+func (s *SQLStore) GetDeletedBoards(workspaceID string) ([]model.Block, error) {
+	ctx := context.Background()
+
+	rows, err := sq.QueryContextWith(ctx, s.db, s.deletedBoardsQuery().
+		Where(sq.Eq{"coalesce(d.workspace_id, '0')": workspaceID}))
+	if err != nil {
+		log.Printf(`getDeletedBoards ERROR: %v`, err)
+		return nil, err
+	}
+
+	deleted, err := deletedBoardsFromRows(rows)
+	if err != nil {
+		log.Printf(`getDeletedBoards ERROR: %v`, err)
+		return nil, err
+	}
+
+	boardIDs := make([]string, 0, len(deleted))
+	for _, board := range deleted {
+		boardIDs = append(boardIDs, board.BoardID)
+	}
+
+	versions, err := s.latestBlockVersions(ctx, s.db, boardIDs)
+	if err != nil {
+		log.Printf(`getDeletedBoards ERROR: %v`, err)
+		return nil, err
+	}
+
+	boards := make([]model.Block, 0, len(deleted))
+	for _, board := range deleted {
+		block := versions[board.BoardID]
+		block.ModifiedBy = board.ModifiedBy
+		block.DeleteAt = board.DeleteAt
+		boards = append(boards, block)
+	}
+
+	return boards, nil
+}
+
+// GetDeletedBoardsBefore returns the boards of all the workspaces that were
+// moved to the trash before a time, in milliseconds
+func (s *SQLStore) GetDeletedBoardsBefore(deletedBefore int64) ([]model.DeletedBoard, error) {
+	rows, err := s.deletedBoardsQuery().
+		Where(sq.Lt{"d.delete_at": deletedBefore}).
+		Query()
+	if err != nil {
+		log.Printf(`getDeletedBoardsBefore ERROR: %v`, err)
+		return nil, err
+	}
+
+	return deletedBoardsFromRows(rows)
+}
+
+// getDeletedBoard returns the board from the trash of the workspace, or an
+// *store.ErrBlocksNotFound if it isn't there
+func (s *SQLStore) getDeletedBoard(ctx context.Context, tx *sql.Tx, c store.Container, boardID string) (*model.DeletedBoard, error) {
+	rows, err := sq.QueryContextWith(ctx, tx, s.deletedBoardsQuery().
+		Where(sq.Eq{"d.id": boardID}).
+		Where(sq.Eq{"coalesce(d.workspace_id, '0')": c.WorkspaceID}))
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := deletedBoardsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deleted) == 0 {
+		return nil, &store.ErrBlocksNotFound{BlockIDs: []string{boardID}}
+	}
+
+	return &deleted[0], nil
+}
+
+// RestoreBoard moves a board out of the trash in a single transaction, with
+// the blocks of the board deleted since it was, and returns the restored
+// blocks. The blocks deleted before the board stay deleted, by the order of
+// their history entries, as the deletions in the same millisecond share
+// their delete_at. Each block gets back its latest version, as a new version
+// by the user restoring it.
+func (s *SQLStore) RestoreBoard(c store.Container, boardID, modifiedBy string) ([]model.Block, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getDeletedBoard(ctx, tx, c, boardID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	query := s.deletedBlocksQuery("d.id").
+		Where(sq.Eq{"coalesce(d.workspace_id, '0')": c.WorkspaceID}).
+		Where("d.insert_at >= (SELECT MAX(p.insert_at) FROM "+s.tablePrefix+"blocks_history p WHERE p.id = ?)", boardID).
+		Where("d.id IN (SELECT r.id FROM "+s.tablePrefix+"blocks_history r WHERE r.root_id = ? AND "+s.latestVersion("r")+")", boardID)
+
+	rows, err := sq.QueryContextWith(ctx, tx, query)
+	if err != nil {
+		log.Printf(`restoreBoard ERROR: %v`, err)
+		tx.Rollback()
+		return nil, err
+	}
+
+	blockIDs, err := idsFromRows(rows)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	versions, err := s.latestBlockVersions(ctx, tx, blockIDs)
+	if err != nil {
+		log.Printf(`restoreBoard ERROR: %v`, err)
+		tx.Rollback()
+		return nil, err
+	}
+
+	now := utils.GetMillis()
+	restored := make([]model.Block, 0, len(blockIDs))
+	for _, blockID := range blockIDs {
+		block := versions[blockID]
+		block.ModifiedBy = modifiedBy
+		block.UpdateAt = now
+		block.DeleteAt = 0
+
+		if err := s.insertBlock(ctx, tx, c, block); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		restored = append(restored, block)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// PurgeBoard removes a board from the trash for good, with all its blocks
+// and their history, in a single transaction. The files of the board are
+// left to the caller.
+func (s *SQLStore) PurgeBoard(c store.Container, boardID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.getDeletedBoard(ctx, tx, c, boardID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The blocks still in the blocks table and the deleted ones, which are
+	// only in the history
+	blocksQuery := s.getQueryBuilder().
+		Select("id").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"root_id": boardID}).
+		Where(sq.Eq{"coalesce(workspace_id, '0')": c.WorkspaceID})
+	historyQuery := s.getQueryBuilder().
+		Select("h.id").
+		From(s.tablePrefix + "blocks_history h").
+		Where(sq.Eq{"h.root_id": boardID}).
+		Where(sq.Eq{"coalesce(h.workspace_id, '0')": c.WorkspaceID}).
+		Where(s.latestVersion("h"))
+
+	blockIDs := []string{boardID}
+	seen := map[string]bool{boardID: true}
+	for _, query := range []sq.SelectBuilder{blocksQuery, historyQuery} {
+		rows, err := sq.QueryContextWith(ctx, tx, query)
+		if err != nil {
+			log.Printf(`purgeBoard ERROR: %v`, err)
+			tx.Rollback()
+			return err
+		}
+
+		ids, err := idsFromRows(rows)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				blockIDs = append(blockIDs, id)
+			}
+		}
+	}
+
+	for start := 0; start < len(blockIDs); start += trashBatchSize {
+		end := start + trashBatchSize
+		if end > len(blockIDs) {
+			end = len(blockIDs)
+		}
+
+		for _, table := range []string{"blocks", "blocks_history"} {
+			deleteQuery := s.getQueryBuilder().
+				Delete(s.tablePrefix + table).
+				Where(sq.Eq{"id": blockIDs[start:end]})
+
+			if _, err := sq.ExecContextWith(ctx, tx, deleteQuery); err != nil {
+				log.Printf(`purgeBoard ERROR: %v`, err)
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,11 +1,15 @@
 package sqlstore
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -61,20 +65,240 @@ func (s *SQLStore) GetUserByUsername(username string) (*model.User, error) {
 	return s.getUserByCondition(sq.Eq{"username": username})
 }
 
+// usersByIDsBatchSize is the number of users looked up by each query, to
+// stay under the limit of query parameters of the databases
+const usersByIDsBatchSize = 500
+
+// GetUsersByIDs returns the display information of the users, in no
+// particular order. The IDs without a user, or of deleted users, are left
+// out.
+func (s *SQLStore) GetUsersByIDs(ids []string) ([]model.UserDisplay, error) {
+	users := []model.UserDisplay{}
+	for start := 0; start < len(ids); start += usersByIDsBatchSize {
+		end := start + usersByIDsBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		query := s.getQueryBuilder().
+			Select("id", "username", "props").
+			From(s.tablePrefix + "users").
+			Where(sq.Eq{"delete_at": 0}).
+			Where(sq.Eq{"id": ids[start:end]})
+
+		rows, err := query.Query()
+		if err != nil {
+			log.Printf(`getUsersByIDs ERROR: %v`, err)
+			return nil, err
+		}
+
+		for rows.Next() {
+			var user model.UserDisplay
+			var propsBytes []byte
+			if err := rows.Scan(&user.ID, &user.Username, &propsBytes); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			var props map[string]interface{}
+			if err := json.Unmarshal(propsBytes, &props); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			user.AvatarURL, _ = props["avatarUrl"].(string)
+
+			users = append(users, user)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
 func (s *SQLStore) CreateUser(user *model.User) error {
+	query, err := s.createUserQuery(user, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	_, err = query.Exec()
+	return err
+}
+
+// CreateUsers creates all the users in a single transaction, or none of them
+// if any username or email is repeated or already taken
+func (s *SQLStore) CreateUsers(users []model.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	usernames := make([]string, 0, len(users))
+	emails := make([]string, 0, len(users))
+	seen := map[string]bool{}
+	for _, user := range users {
+		if seen["username:"+user.Username] {
+			return &store.ErrUserConflict{Field: "username", Value: user.Username}
+		}
+		if user.Email != "" && seen["email:"+user.Email] {
+			return &store.ErrUserConflict{Field: "email", Value: user.Email}
+		}
+		seen["username:"+user.Username] = true
+		seen["email:"+user.Email] = true
+		usernames = append(usernames, user.Username)
+		if user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	conflictsQuery := s.getQueryBuilder().
+		Select("username", "email").
+		From(s.tablePrefix + "users").
+		Where(sq.Eq{"delete_at": 0}).
+		Where(sq.Or{sq.Eq{"username": usernames}, sq.Eq{"email": emails}})
+
+	rows, err := sq.QueryContextWith(ctx, tx, conflictsQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var conflict *store.ErrUserConflict
+	for rows.Next() && conflict == nil {
+		var username, email string
+		if err := rows.Scan(&username, &email); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+		if seen["username:"+username] {
+			conflict = &store.ErrUserConflict{Field: "username", Value: username}
+		} else {
+			conflict = &store.ErrUserConflict{Field: "email", Value: email}
+		}
+	}
+	rows.Close()
+	if conflict != nil {
+		tx.Rollback()
+		return conflict
+	}
+
 	now := time.Now().Unix()
+	for i := range users {
+		query, err := s.createUserQuery(&users[i], now)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = sq.ExecContextWith(ctx, tx, query)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
-	propsBytes, err := json.Marshal(user.Props)
+	return tx.Commit()
+}
+
+// DeleteUser marks the user as deleted and removes its sessions, in a single
+// transaction. It returns sql.ErrNoRows if there's no such active user.
+func (s *SQLStore) DeleteUser(userID string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
+	if err := s.deleteUser(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteUserReassigningBoards transfers the boards of the user to the new
+// owner and deletes the user, in a single transaction, so a failure leaves
+// both as they were. It returns the number of boards reassigned, and
+// sql.ErrNoRows if there's no such active user.
+func (s *SQLStore) DeleteUserReassigningBoards(userID, boardsOwnerID string) (int64, error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned, err := s.reassignBoards(ctx, tx, userID, boardsOwnerID)
+	if err != nil {
+		tx.Rollback()
+		log.Printf(`deleteUserReassigningBoards ERROR: %v`, err)
+		return 0, err
+	}
+
+	if err := s.deleteUser(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return reassigned, nil
+}
+
+func (s *SQLStore) deleteUser(ctx context.Context, tx *sql.Tx, userID string) error {
+	now := time.Now().Unix()
+	query := s.getQueryBuilder().Update(s.tablePrefix+"users").
+		Set("update_at", now).
+		Set("delete_at", now).
+		Where(sq.Eq{"id": userID}).
+		Where(sq.Eq{"delete_at": 0})
+
+	result, err := sq.ExecContextWith(ctx, tx, query)
+	if err != nil {
+		return err
+	}
+
+	rowCount, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowCount < 1 {
+		return sql.ErrNoRows
+	}
+
+	deleteSessions := s.getQueryBuilder().
+		Delete(s.tablePrefix + "sessions").
+		Where(sq.Eq{"user_id": userID})
+	if _, err := sq.ExecContextWith(ctx, tx, deleteSessions); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLStore) createUserQuery(user *model.User, now int64) (sq.InsertBuilder, error) {
+	propsBytes, err := json.Marshal(user.Props)
+	if err != nil {
+		return sq.InsertBuilder{}, err
+	}
+
 	query := s.getQueryBuilder().Insert(s.tablePrefix+"users").
 		Columns("id", "username", "email", "password", "mfa_secret", "auth_service", "auth_data", "props", "create_at", "update_at", "delete_at").
 		Values(user.ID, user.Username, user.Email, user.Password, user.MfaSecret, user.AuthService, user.AuthData, propsBytes, now, now, 0)
 
-	_, err = query.Exec()
-	return err
+	return query, nil
 }
 
 func (s *SQLStore) UpdateUser(user *model.User) error {
@@ -0,0 +1,94 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"log"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/focalboard/server/model"
+)
+
+func (s *SQLStore) GetWorkspaceWebhooks(workspaceID string) ([]model.WorkspaceWebhook, error) {
+	query := s.getQueryBuilder().
+		Select(
+			"id",
+			"workspace_id",
+			"url",
+			"created_by",
+			"create_at",
+		).
+		From(s.tablePrefix+"workspace_webhooks").
+		Where(sq.Eq{"workspace_id": workspaceID}).
+		OrderBy("create_at", "id")
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getWorkspaceWebhooks ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []model.WorkspaceWebhook{}
+	for rows.Next() {
+		var webhook model.WorkspaceWebhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.WorkspaceID,
+			&webhook.URL,
+			&webhook.CreatedBy,
+			&webhook.CreateAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *SQLStore) CreateWorkspaceWebhook(webhook model.WorkspaceWebhook) error {
+	query := s.getQueryBuilder().
+		Insert(s.tablePrefix+"workspace_webhooks").
+		Columns(
+			"id",
+			"workspace_id",
+			"url",
+			"created_by",
+			"create_at",
+		).
+		Values(
+			webhook.ID,
+			webhook.WorkspaceID,
+			webhook.URL,
+			webhook.CreatedBy,
+			webhook.CreateAt,
+		)
+
+	_, err := query.Exec()
+	return err
+}
+
+// DeleteWorkspaceWebhook removes a webhook of the workspace, returning
+// sql.ErrNoRows if the workspace doesn't have it
+func (s *SQLStore) DeleteWorkspaceWebhook(workspaceID, webhookID string) error {
+	query := s.getQueryBuilder().
+		Delete(s.tablePrefix + "workspace_webhooks").
+		Where(sq.Eq{"id": webhookID}).
+		Where(sq.Eq{"workspace_id": workspaceID})
+
+	result, err := query.Exec()
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
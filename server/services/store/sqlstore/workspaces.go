@@ -2,10 +2,13 @@ package sqlstore
 
 import (
 	"encoding/json"
+	"io"
 	"log"
 	"time"
 
 	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -45,16 +48,20 @@ func (s *SQLStore) UpsertWorkspaceSettings(workspace model.Workspace) error {
 		return err
 	}
 
+	// The signup token is only set if the workspace is new, as the databases
+	// check it isn't null before they find the existing one
 	query := s.getQueryBuilder().
 		Insert(s.tablePrefix+"workspaces").
 		Columns(
 			"id",
+			"signup_token",
 			"settings",
 			"modified_by",
 			"update_at",
 		).
 		Values(
 			workspace.ID,
+			utils.CreateGUID(),
 			settingsJSON,
 			workspace.ModifiedBy,
 			now,
@@ -104,3 +111,56 @@ func (s *SQLStore) GetWorkspace(ID string) (*model.Workspace, error) {
 
 	return &workspace, nil
 }
+
+// GetWorkspacesByActivity returns the workspaces the user has changed blocks
+// in, most recently active first. Archived boards don't count as activity, so
+// the workspaces with only archived boards are left out. A limit of 0 returns
+// all of them.
+func (s *SQLStore) GetWorkspacesByActivity(userID string, limit int) ([]model.WorkspaceActivity, error) {
+	// Built without the placeholder format of the database, as the outer
+	// query numbers the placeholders of both
+	userWorkspaces := sq.
+		Select("DISTINCT workspace_id").
+		From(s.tablePrefix + "blocks").
+		Where(sq.Eq{"modified_by": userID})
+
+	userWorkspacesSQL, args, err := userWorkspaces.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.getQueryBuilder().
+		Select("workspace_id", "MAX(update_at) AS last_activity_at").
+		From(s.tablePrefix+"blocks").
+		Where(sq.Eq{"archived": false}).
+		Where("workspace_id IN ("+userWorkspacesSQL+")", args...).
+		GroupBy("workspace_id").
+		OrderBy("last_activity_at DESC", "workspace_id")
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		log.Printf(`getWorkspacesByActivity ERROR: %v`, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []model.WorkspaceActivity{}
+	for rows.Next() {
+		var activity model.WorkspaceActivity
+		if err := rows.Scan(&activity.WorkspaceID, &activity.LastActivityAt); err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+
+	return activities, rows.Err()
+}
+
+// ExportWorkspace writes the bundle of a workspace, with the files read from
+// the files storage
+func (s *SQLStore) ExportWorkspace(workspaceID string, w io.Writer) error {
+	return store.WriteWorkspaceBundle(s, s.filesBackend, workspaceID, w)
+}
@@ -1,7 +1,68 @@
 //go:generate mockgen -destination=mockstore/mockstore.go -package mockstore . Store
 package store
 
-import "github.com/mattermost/focalboard/server/model"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+)
+
+// ErrNotSupported is returned when the database backend can't perform an operation
+var ErrNotSupported = errors.New("not supported by the database backend")
+
+// ErrBoardArchived is returned when archiving a board that's already archived
+var ErrBoardArchived = errors.New("the board is already archived")
+
+// DeletedUserID replaces the ID of the anonymized users as the author of
+// their blocks and records
+const DeletedUserID = "deleted-user"
+
+// DBSize is the space used by the database, in bytes
+type DBSize struct {
+	Total int64 `json:"total"`
+
+	// Tables has the size of each table of the store, including its
+	// indexes, if the backend can report it
+	Tables map[string]int64 `json:"tables"`
+}
+
+// SchemaVersion is the version of the database schema, the last migration
+// applied
+type SchemaVersion struct {
+	Version uint `json:"version"`
+
+	// Dirty is set if the last migration failed halfway
+	Dirty bool `json:"dirty"`
+
+	// Pending are the names of the migrations not applied yet
+	Pending []string `json:"pending"`
+}
+
+// ErrBlocksNotFound is returned when an operation references blocks that don't exist
+type ErrBlocksNotFound struct {
+	BlockIDs []string
+}
+
+func (e *ErrBlocksNotFound) Error() string {
+	return fmt.Sprintf("blocks not found: %s", strings.Join(e.BlockIDs, ", "))
+}
+
+// ErrUserConflict is returned when creating a user with a username or an
+// email that's already taken
+type ErrUserConflict struct {
+	Field string
+	Value string
+}
+
+func (e *ErrUserConflict) Error() string {
+	return fmt.Sprintf("the %s %q is already taken", e.Field, e.Value)
+}
 
 // Conainer represents a container in a store
 // Using a struct to make extending this easier in the future
@@ -9,45 +70,151 @@ type Container struct {
 	WorkspaceID string
 }
 
+// BlockSort orders a list of blocks by the value of one of their properties.
+// Blocks without a value for the property always go last.
+type BlockSort struct {
+	PropertyID string
+	Descending bool
+}
+
+// MergeStrategy decides which card keeps its value when both cards of a
+// merge have a value for the same property
+type MergeStrategy string
+
+const (
+	MergePreferTarget MergeStrategy = "prefer-target"
+	MergePreferSource MergeStrategy = "prefer-source"
+)
+
+// AuditFilter selects the audit logs of an actor, action or target, within
+// a time range in milliseconds. The empty fields don't filter.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  int64
+	Until  int64
+}
+
 // Store represents the abstraction of the data storage.
 type Store interface {
 	GetBlocksWithParentAndType(c Container, parentID string, blockType string) ([]model.Block, error)
+	GetComments(c Container, cardID string, limit int, before int64, beforeID string) ([]model.Block, error)
+	GetBlocksWithParentAndTypeSorted(c Container, parentID string, blockType string, sort BlockSort) ([]model.Block, error)
 	GetBlocksWithParent(c Container, parentID string) ([]model.Block, error)
 	GetBlocksWithType(c Container, blockType string) ([]model.Block, error)
+	GetBlocksWithRootID(c Container, rootID string) ([]model.Block, error)
+	GetBlocksByProperty(c Container, boardID, propertyID, value string) ([]model.Block, error)
+	SearchBlocks(c Container, term string) ([]model.Block, error)
+	GetBoardAggregates(c Container, boardID, groupByPropertyID string) (*model.BoardAggregates, error)
+	GroupCardsByDate(c Container, boardID, datePropertyID, bucket string) ([]model.DateBucket, error)
+	CountBlocksByBoard(c Container) (map[string]int, error)
+	FindDuplicateBoards(c Container) ([]model.DuplicateBoards, error)
+	CreateBoardSnapshot(c Container, boardID, createdBy string) (string, error)
+	GetBoardSnapshot(c Container, snapshotID string) (*model.BoardSnapshot, error)
+	GetBoardSnapshots(c Container, boardID string) ([]model.BoardSnapshot, error)
+	GetBoardActivity(c Container, boardID string, limit int, before int64, beforeID string) ([]model.BlockActivity, error)
+	GetBoardChecksum(c Container, boardID string) (string, error)
+	GetBoardsMetadata(c Container, boardIDs []string) ([]model.BoardMetadata, error)
+	GetCardsAssignedTo(c Container, userID string) ([]model.Block, error)
+	GetBlocksModifiedBy(c Container, userID string, since int64, limit int) ([]model.Block, error)
+	CountBoards(c Container) (int, error)
+	RenameBoardProperty(c Container, boardID, propertyID, newName, modifiedBy string) error
+	RenamePropertyOption(c Container, boardID, propertyID, oldOption, newOption, modifiedBy string) error
 	GetSubTree2(c Container, blockID string) ([]model.Block, error)
 	GetSubTree3(c Container, blockID string) ([]model.Block, error)
 	GetAllBlocks(c Container) ([]model.Block, error)
 	GetRootID(c Container, blockID string) (string, error)
 	GetParentID(c Container, blockID string) (string, error)
+	GetBlocksByIDs(c Container, blockIDs []string) ([]model.Block, error)
+	BlockExists(c Container, blockID string) (bool, error)
+	BoardExists(c Container, boardID string) (bool, error)
+	GetExistingBoards(c Container, boardIDs []string) ([]string, error)
 	InsertBlock(c Container, block model.Block) error
+	PatchBlocks(c Container, patches []model.BlockPatch, modifiedBy string) error
 	DeleteBlock(c Container, blockID string, modifiedBy string) error
+	MergeBlocks(c Container, targetID, sourceID string, strategy MergeStrategy, modifiedBy string) error
+	CopyCard(c Container, card model.Block, content []model.Block) error
+	CreateBoardWithDefaults(c Container, board model.Block, views []model.Block) error
+	ArchiveBoard(c Container, boardID string, archive func(blocks []model.Block) error) error
+	UnarchiveBoard(c Container, boardID string, blocks []model.Block) error
+	GetDeletedBoards(workspaceID string) ([]model.Block, error)
+	GetDeletedBoardsBefore(deletedBefore int64) ([]model.DeletedBoard, error)
+	RestoreBoard(c Container, boardID, modifiedBy string) ([]model.Block, error)
+	PurgeBoard(c Container, boardID string) error
+	AcquireBoardLock(c Container, boardID, holder string, ttl time.Duration) (bool, error)
+	ReleaseBoardLock(c Container, boardID, holder string) error
+	GetLockedBoards(c Container, boardIDs []string) ([]string, error)
 
 	Shutdown() error
+	BackupDatabase(filename string) error
+	DBStats() sql.DBStats
+	GetDatabaseSize() (DBSize, error)
+	GetSchemaVersion() (SchemaVersion, error)
 
 	GetSystemSettings() (map[string]string, error)
+	GetFeatureFlags() (map[string]string, error)
 	SetSystemSetting(key, value string) error
+	UpgradeBlockData() (int, error)
+	SetBlockMigrationFlag(blockIDs []string, flag string) error
+	GetBlocksByMigrationFlag(flag string) ([]model.BlockMigrationFlag, error)
+	CreateSystemSettingIfNotExists(key, value string) error
 
 	GetRegisteredUserCount() (int, error)
 	GetUserById(userID string) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserByUsername(username string) (*model.User, error)
+	GetUsersByIDs(ids []string) ([]model.UserDisplay, error)
 	CreateUser(user *model.User) error
+	CreateUsers(users []model.User) error
 	UpdateUser(user *model.User) error
 	UpdateUserPassword(username, password string) error
 	UpdateUserPasswordByID(userID, password string) error
+	AnonymizeUser(userID string) error
+	DeleteUser(userID string) error
+	ReassignBoards(fromUserID, toUserID string) (int64, error)
+	DeleteUserReassigningBoards(userID, boardsOwnerID string) (int64, error)
 
 	GetActiveUserCount(updatedSecondsAgo int64) (int, error)
 	GetSession(token string, expireTime int64) (*model.Session, error)
+	GetRecentSessions(updatedSecondsAgo int64, limit int) ([]*model.Session, error)
 	CreateSession(session *model.Session) error
 	RefreshSession(session *model.Session) error
 	UpdateSession(session *model.Session) error
 	DeleteSession(sessionId string) error
-	CleanUpSessions(expireTime int64) error
+	GetExpiredSessionIDs(expireTime int64) ([]string, error)
+	GetUserSessionIDs(userID string) ([]string, error)
+	CleanUpSessions(expireTime int64) (int64, error)
+	DeleteSessionsForDeletedUsers() (int64, error)
 
 	UpsertSharing(c Container, sharing model.Sharing) error
 	GetSharing(c Container, rootID string) (*model.Sharing, error)
+	GetActiveSharingTokens(c Container, now int64) ([]model.Sharing, error)
+	GetExpiredSharingTokens(now int64) ([]model.Sharing, error)
+	DeleteExpiredSharingTokens(now int64) (int64, error)
 
 	UpsertWorkspaceSignupToken(workspace model.Workspace) error
 	UpsertWorkspaceSettings(workspace model.Workspace) error
 	GetWorkspace(ID string) (*model.Workspace, error)
+	GetWorkspacesByActivity(userID string, limit int) ([]model.WorkspaceActivity, error)
+
+	CreateFileRef(ref model.FileRef, size int64) (bool, error)
+	GetFileRef(id string) (*model.FileRef, error)
+	GetWorkspaceFileRefs(workspaceID string) ([]model.FileRef, error)
+	GetFileBlob(hash string) (*model.FileBlob, error)
+	DeleteFileRef(id string, removeBlob func(hash string) error) error
+	GetWorkspaceStorageUsage(workspaceID string) (int64, error)
+	SetFilesBackend(backend filesstore.FileBackend)
+	FindOrphanedFiles() ([]model.FileInfo, error)
+	ExportWorkspace(workspaceID string, w io.Writer) error
+	CreateUploadSession(session model.UploadSession) error
+	GetAbandonedUploadSessions(olderThan int64) ([]model.UploadSession, error)
+	DeleteUploadSession(id string) error
+
+	GetWorkspaceWebhooks(workspaceID string) ([]model.WorkspaceWebhook, error)
+	CreateWorkspaceWebhook(webhook model.WorkspaceWebhook) error
+	DeleteWorkspaceWebhook(workspaceID, webhookID string) error
+
+	CreateAuditLog(entry model.AuditLog) error
+	GetAuditLogs(filter AuditFilter, limit, offset int) ([]model.AuditLog, error)
 }
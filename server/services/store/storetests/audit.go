@@ -0,0 +1,60 @@
+package storetests
+
+import (
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestAuditStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("GetAuditLogs", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetAuditLogs(t, store)
+	})
+}
+
+func testGetAuditLogs(t *testing.T, s store.Store) {
+	entries := []model.AuditLog{
+		{ID: "audit-1", CreateAt: 100, Actor: "user-1", Action: "deleteUser", Target: "user-3"},
+		{ID: "audit-2", CreateAt: 200, Actor: "user-2", Action: "setPassword", Target: "user-3", Details: "by an admin"},
+		{ID: "audit-3", CreateAt: 300, Actor: "user-1", Action: "setPassword", Target: "user-4"},
+		{ID: "audit-4", CreateAt: 400, Actor: "user-1", Action: "backup"},
+	}
+	for _, entry := range entries {
+		require.NoError(t, s.CreateAuditLog(entry))
+	}
+
+	ids := func(filter store.AuditFilter, limit, offset int) []string {
+		found, err := s.GetAuditLogs(filter, limit, offset)
+		require.NoError(t, err)
+		ids := []string{}
+		for _, entry := range found {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	t.Run("all the entries, oldest first", func(t *testing.T) {
+		found, err := s.GetAuditLogs(store.AuditFilter{}, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, entries, found)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		require.Equal(t, []string{"audit-1", "audit-3", "audit-4"}, ids(store.AuditFilter{Actor: "user-1"}, 10, 0))
+		require.Equal(t, []string{"audit-2", "audit-3"}, ids(store.AuditFilter{Action: "setPassword"}, 10, 0))
+		require.Equal(t, []string{"audit-1", "audit-2"}, ids(store.AuditFilter{Target: "user-3"}, 10, 0))
+		require.Equal(t, []string{"audit-2", "audit-3"}, ids(store.AuditFilter{Since: 200, Until: 300}, 10, 0))
+		require.Equal(t, []string{"audit-3"}, ids(store.AuditFilter{Actor: "user-1", Action: "setPassword", Since: 150}, 10, 0))
+		require.Empty(t, ids(store.AuditFilter{Actor: "user-5"}, 10, 0))
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		require.Equal(t, []string{"audit-1", "audit-2"}, ids(store.AuditFilter{}, 2, 0))
+		require.Equal(t, []string{"audit-3", "audit-4"}, ids(store.AuditFilter{}, 2, 2))
+		require.Empty(t, ids(store.AuditFilter{}, 2, 4))
+	})
+}
@@ -1,6 +1,7 @@
 package storetests
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -19,84 +20,1096 @@ func StoreTestBlocksStore(t *testing.T, setup func(t *testing.T) (store.Store, f
 		defer tearDown()
 		testInsertBlock(t, store, container)
 	})
+	t.Run("PatchBlocks", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testPatchBlocks(t, store, container)
+	})
 	t.Run("DeleteBlock", func(t *testing.T) {
 		store, tearDown := setup(t)
 		defer tearDown()
 		testDeleteBlock(t, store, container)
 	})
-	t.Run("GetSubTree2", func(t *testing.T) {
-		store, tearDown := setup(t)
-		defer tearDown()
-		testGetSubTree2(t, store, container)
+	t.Run("MergeBlocks", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testMergeBlocks(t, store, container)
+	})
+	t.Run("CopyCard", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testCopyCard(t, store, container)
+	})
+	t.Run("ArchiveBoard", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testArchiveBoard(t, store, container)
+	})
+	t.Run("GetBlocksByProperty", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBlocksByProperty(t, store, container)
+	})
+	t.Run("GetBoardAggregates", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardAggregates(t, store, container)
+	})
+	t.Run("GroupCardsByDate", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGroupCardsByDate(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("CountBlocksByBoard", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testCountBlocksByBoard(t, store, container)
+	})
+	t.Run("CountBoards", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testCountBoards(t, store, container)
+	})
+	t.Run("RenameBoardProperty", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testRenameBoardProperty(t, store, container)
+	})
+	t.Run("RenamePropertyOption", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testRenamePropertyOption(t, store, container)
+	})
+	t.Run("GetBlocksWithParentAndTypeSorted", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBlocksWithParentAndTypeSorted(t, store, container)
+	})
+	t.Run("SearchBlocks", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testSearchBlocks(t, store, container)
+	})
+	t.Run("GetSubTree2", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetSubTree2(t, store, container)
+	})
+	t.Run("GetSubTree3", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetSubTree3(t, store, container)
+	})
+	t.Run("GetParentID", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetParentID(t, store, container)
+	})
+	t.Run("GetRootID", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetRootID(t, store, container)
+	})
+	t.Run("GetComments", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetComments(t, store, container)
+	})
+	t.Run("GetCardsAssignedTo", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetCardsAssignedTo(t, store, container)
+	})
+	t.Run("GetBlocksModifiedBy", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBlocksModifiedBy(t, store, container)
+	})
+	t.Run("BlockExists", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testBlockExists(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("BlockMigrationFlags", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testBlockMigrationFlags(t, store)
+	})
+}
+
+func testInsertBlock(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocks, err := store.GetAllBlocks(container)
+	require.NoError(t, err)
+	initialCount := len(blocks)
+
+	t.Run("valid block", func(t *testing.T) {
+		block := model.Block{
+			ID:         "id-test",
+			RootID:     "id-test",
+			ModifiedBy: userID,
+		}
+
+		err := store.InsertBlock(container, block)
+		require.NoError(t, err)
+
+		blocks, err := store.GetAllBlocks(container)
+		require.NoError(t, err)
+		require.Len(t, blocks, initialCount+1)
+	})
+
+	t.Run("invalid rootid", func(t *testing.T) {
+		block := model.Block{
+			ID:         "id-test",
+			RootID:     "",
+			ModifiedBy: userID,
+		}
+
+		err := store.InsertBlock(container, block)
+		require.Error(t, err)
+
+		blocks, err := store.GetAllBlocks(container)
+		require.NoError(t, err)
+		require.Len(t, blocks, initialCount+1)
+	})
+
+	t.Run("invalid fields data", func(t *testing.T) {
+		block := model.Block{
+			ID:         "id-test",
+			RootID:     "id-test",
+			ModifiedBy: userID,
+			Fields:     map[string]interface{}{"no-serialiable-value": t.Run},
+		}
+
+		err := store.InsertBlock(container, block)
+		require.Error(t, err)
+
+		blocks, err := store.GetAllBlocks(container)
+		require.NoError(t, err)
+		require.Len(t, blocks, initialCount+1)
+	})
+}
+
+func testPatchBlocks(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "card1",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Title:      "Card 1",
+			Fields:     map[string]interface{}{"status": "todo", "priority": "high"},
+		},
+		{
+			ID:         "card2",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Title:      "Card 2",
+			Fields:     map[string]interface{}{"status": "todo"},
+		},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	t.Run("patch multiple blocks", func(t *testing.T) {
+		title := "Renamed card"
+		patches := []model.BlockPatch{
+			{
+				ID:            "card1",
+				Title:         &title,
+				UpdatedFields: map[string]interface{}{"status": "done"},
+				DeletedFields: []string{"priority"},
+			},
+			{
+				ID:            "card2",
+				UpdatedFields: map[string]interface{}{"status": "done"},
+			},
+		}
+
+		err := store.PatchBlocks(container, patches, "patcher")
+		require.NoError(t, err)
+
+		blocks, err := store.GetBlocksByIDs(container, []string{"card1", "card2"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+
+		for _, block := range blocks {
+			require.Equal(t, "done", block.Fields["status"])
+			require.Equal(t, "patcher", block.ModifiedBy)
+			require.Equal(t, "board", block.ParentID)
+			switch block.ID {
+			case "card1":
+				require.Equal(t, "Renamed card", block.Title)
+				require.NotContains(t, block.Fields, "priority")
+			case "card2":
+				require.Equal(t, "Card 2", block.Title)
+			}
+		}
+	})
+
+	t.Run("unknown id fails the whole batch", func(t *testing.T) {
+		patches := []model.BlockPatch{
+			{
+				ID:            "card1",
+				UpdatedFields: map[string]interface{}{"status": "archived"},
+			},
+			{
+				ID:            "not-exists",
+				UpdatedFields: map[string]interface{}{"status": "archived"},
+			},
+		}
+
+		err := store.PatchBlocks(container, patches, "patcher")
+		require.EqualError(t, err, "blocks not found: not-exists")
+
+		blocks, err := store.GetBlocksByIDs(container, []string{"card1"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, "done", blocks[0].Fields["status"])
+	})
+}
+
+func testMergeBlocks(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	card := func(id string, properties map[string]interface{}, contentOrder ...interface{}) model.Block {
+		return model.Block{
+			ID:         id,
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties":   properties,
+				"contentOrder": contentOrder,
+			},
+		}
+	}
+	child := func(id, parentID, blockType string) model.Block {
+		return model.Block{ID: id, RootID: "board", ParentID: parentID, ModifiedBy: userID, Type: blockType}
+	}
+
+	blocksToInsert := []model.Block{
+		{ID: "board", RootID: "board", ModifiedBy: userID, Type: "board"},
+		card("target-1", map[string]interface{}{"status": "todo", "priority": ""}, "text-1"),
+		child("text-1", "target-1", "text"),
+		card("source-1", map[string]interface{}{"status": "done", "priority": "high", "estimate": "3"}, "text-2"),
+		child("text-2", "source-1", "text"),
+		child("comment-1", "source-1", "comment"),
+		card("target-2", map[string]interface{}{"status": "todo", "priority": "low"}),
+		card("source-2", map[string]interface{}{"status": "done", "priority": ""}),
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	t.Run("prefer target", func(t *testing.T) {
+		err := store.MergeBlocks(container, "target-1", "source-1", "prefer-target", "user-id-2")
+		require.NoError(t, err)
+
+		target := getBlock(t, store, container, "target-1")
+		require.Equal(t, map[string]interface{}{"status": "todo", "priority": "high", "estimate": "3"}, target.Fields["properties"])
+		require.Equal(t, []interface{}{"text-1", "text-2"}, target.Fields["contentOrder"])
+		require.Equal(t, "user-id-2", target.ModifiedBy)
+
+		children, err := store.GetBlocksWithParent(container, "target-1")
+		require.NoError(t, err)
+		require.Len(t, children, 3)
+		require.True(t, ContainsBlockWithID(children, "text-2"))
+		require.True(t, ContainsBlockWithID(children, "comment-1"))
+
+		blocks, err := store.GetBlocksByIDs(container, []string{"source-1"})
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("prefer source", func(t *testing.T) {
+		err := store.MergeBlocks(container, "target-2", "source-2", "prefer-source", "user-id-2")
+		require.NoError(t, err)
+
+		target := getBlock(t, store, container, "target-2")
+		require.Equal(t, map[string]interface{}{"status": "done", "priority": "low"}, target.Fields["properties"])
+
+		blocks, err := store.GetBlocksByIDs(container, []string{"source-2"})
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		err := store.MergeBlocks(container, "target-1", "not-exists", "prefer-target", "user-id-2")
+		require.EqualError(t, err, "blocks not found: not-exists")
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		err := store.MergeBlocks(container, "target-1", "target-2", "prefer-neither", "user-id-2")
+		require.Error(t, err)
+
+		blocks, err := store.GetBlocksByIDs(container, []string{"target-2"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+	})
+}
+
+func testCopyCard(t *testing.T, store store.Store, container store.Container) {
+	t.Run("inserts the card and its content", func(t *testing.T) {
+		card := model.Block{ID: "card-copy", ParentID: "board-1", RootID: "board-1", Type: "card"}
+		content := []model.Block{
+			{ID: "text-copy", ParentID: "card-copy", RootID: "board-1", Type: "text"},
+			{ID: "image-copy", ParentID: "card-copy", RootID: "board-1", Type: "image", Fields: map[string]interface{}{"fileId": "file.png"}},
+		}
+
+		err := store.CopyCard(container, card, content)
+		require.NoError(t, err)
+
+		blocks, err := store.GetBlocksWithParent(container, "card-copy")
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+		require.True(t, ContainsBlockWithID(blocks, "text-copy"))
+		require.True(t, ContainsBlockWithID(blocks, "image-copy"))
+	})
+
+	t.Run("a failed insert rolls back the card", func(t *testing.T) {
+		card := model.Block{ID: "card-failed", ParentID: "board-1", RootID: "board-1", Type: "card"}
+		content := []model.Block{
+			// The fields can't be serialized, so the insert fails
+			{ID: "text-failed", ParentID: "card-failed", RootID: "board-1", Type: "text", Fields: map[string]interface{}{"invalid": make(chan int)}},
+		}
+
+		err := store.CopyCard(container, card, content)
+		require.Error(t, err)
+
+		exists, err := store.BlockExists(container, "card-failed")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("content of another board", func(t *testing.T) {
+		card := model.Block{ID: "card-other", ParentID: "board-1", RootID: "board-1", Type: "card"}
+		content := []model.Block{
+			{ID: "text-other", ParentID: "card-other", RootID: "board-2", Type: "text"},
+		}
+
+		require.Error(t, store.CopyCard(container, card, content))
+
+		exists, err := store.BlockExists(container, "card-other")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
+
+func testArchiveBoard(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
+			ModifiedBy: userID,
+			Type:       "board",
+			Title:      "Finished board",
+		},
+		{
+			ID:         "card",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields:     map[string]interface{}{"status": "done"},
+		},
+		{
+			ID:         "text",
+			RootID:     "board",
+			ParentID:   "card",
+			ModifiedBy: userID,
+			Type:       "text",
+			Title:      "Some notes",
+		},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+
+	archived, err := store.GetBlocksWithRootID(container, "board")
+	require.NoError(t, err)
+	require.Len(t, archived, 3)
+
+	t.Run("a failed archive changes nothing", func(t *testing.T) {
+		err := store.ArchiveBoard(container, "board", func([]model.Block) error {
+			return errors.New("unable to store the board archive")
+		})
+		require.EqualError(t, err, "unable to store the board archive")
+
+		blocks, err := store.GetBlocksWithRootID(container, "board")
+		require.NoError(t, err)
+		require.ElementsMatch(t, archived, blocks)
+	})
+
+	t.Run("archive the board", func(t *testing.T) {
+		var written []model.Block
+		err := store.ArchiveBoard(container, "board", func(blocks []model.Block) error {
+			written = blocks
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, archived, written)
+
+		blocks, err := store.GetBlocksWithRootID(container, "board")
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, "board", blocks[0].ID)
+
+		boards, err := store.GetBlocksWithType(container, "board")
+		require.NoError(t, err)
+		require.False(t, ContainsBlockWithID(boards, "board"))
+
+		rootBlocks, err := store.GetBlocksWithParent(container, "")
+		require.NoError(t, err)
+		require.False(t, ContainsBlockWithID(rootBlocks, "board"))
+	})
+
+	t.Run("archive the board again", func(t *testing.T) {
+		err := store.ArchiveBoard(container, "board", func([]model.Block) error {
+			require.Fail(t, "the archived board is archived again")
+			return nil
+		})
+		require.EqualError(t, err, "the board is already archived")
+	})
+
+	t.Run("archive a non existing board", func(t *testing.T) {
+		err := store.ArchiveBoard(container, "not-exists", func([]model.Block) error { return nil })
+		require.EqualError(t, err, "blocks not found: not-exists")
+	})
+
+	t.Run("unarchive the board", func(t *testing.T) {
+		err := store.UnarchiveBoard(container, "board", archived)
+		require.NoError(t, err)
+
+		blocks, err := store.GetBlocksWithRootID(container, "board")
+		require.NoError(t, err)
+		require.ElementsMatch(t, archived, blocks)
+
+		boards, err := store.GetBlocksWithType(container, "board")
+		require.NoError(t, err)
+		require.True(t, ContainsBlockWithID(boards, "board"))
+	})
+
+	t.Run("unarchive blocks from another board", func(t *testing.T) {
+		err := store.UnarchiveBoard(container, "other-board", archived)
+		require.Error(t, err)
+	})
+}
+
+func testGetBlocksByProperty(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
+			ModifiedBy: userID,
+			Type:       "board",
+		},
+		{
+			ID:         "card-high",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"priority": "high", "status": "todo"},
+			},
+		},
+		{
+			ID:         "card-low",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"priority": "low", "status": "high"},
+			},
+		},
+		{
+			ID:         "card-other-board",
+			RootID:     "other-board",
+			ParentID:   "other-board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"priority": "high"},
+			},
+		},
+		{
+			ID:         "card-special-chars",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"priority": "100% \"urgent\""},
+			},
+		},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	t.Run("matching value", func(t *testing.T) {
+		blocks, err := store.GetBlocksByProperty(container, "board", "priority", "high")
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, "card-high", blocks[0].ID)
+	})
+
+	t.Run("value with special characters", func(t *testing.T) {
+		blocks, err := store.GetBlocksByProperty(container, "board", "priority", "100% \"urgent\"")
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, "card-special-chars", blocks[0].ID)
+
+		blocks, err = store.GetBlocksByProperty(container, "board", "priority", "100%")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("no matching value", func(t *testing.T) {
+		blocks, err := store.GetBlocksByProperty(container, "board", "priority", "medium")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("unknown property", func(t *testing.T) {
+		blocks, err := store.GetBlocksByProperty(container, "board", "not-exists", "high")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("invalid property id", func(t *testing.T) {
+		_, err := store.GetBlocksByProperty(container, "board", `bad"id`, "high")
+		require.Error(t, err)
+	})
+}
+
+func testGetBoardAggregates(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	card := func(id, rootID string, properties map[string]interface{}) model.Block {
+		return model.Block{
+			ID:         id,
+			RootID:     rootID,
+			ParentID:   rootID,
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields:     map[string]interface{}{"properties": properties},
+		}
+	}
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
+			ModifiedBy: userID,
+			Type:       "board",
+		},
+		card("card-1", "board", map[string]interface{}{"status": "todo"}),
+		card("card-2", "board", map[string]interface{}{"status": "todo", "priority": "high"}),
+		card("card-3", "board", map[string]interface{}{"status": "done"}),
+		card("card-4", "board", map[string]interface{}{}),
+		card("card-other-board", "other-board", map[string]interface{}{"status": "todo"}),
+		{
+			ID:         "view",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "view",
+		},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	t.Run("grouped by property", func(t *testing.T) {
+		aggregates, err := store.GetBoardAggregates(container, "board", "status")
+		require.NoError(t, err)
+		require.EqualValues(t, 4, aggregates.Total)
+		require.Equal(t, map[string]int64{"todo": 2, "done": 1, "": 1}, aggregates.Groups)
+	})
+
+	t.Run("without grouping", func(t *testing.T) {
+		aggregates, err := store.GetBoardAggregates(container, "board", "")
+		require.NoError(t, err)
+		require.EqualValues(t, 4, aggregates.Total)
+		require.Empty(t, aggregates.Groups)
+	})
+
+	t.Run("empty board", func(t *testing.T) {
+		aggregates, err := store.GetBoardAggregates(container, "not-exists", "status")
+		require.NoError(t, err)
+		require.Zero(t, aggregates.Total)
+		require.Empty(t, aggregates.Groups)
+	})
+
+	t.Run("invalid property id", func(t *testing.T) {
+		_, err := store.GetBoardAggregates(container, "board", `bad"id`)
+		require.Error(t, err)
+	})
+}
+
+// DatedCardsBlocks are a board with cards dated in June and July 2021 by
+// their "due" property, and some without a valid date
+func DatedCardsBlocks() []model.Block {
+	card := func(id, rootID, due string) model.Block {
+		properties := map[string]interface{}{}
+		if due != "" {
+			properties["due"] = due
+		}
+		return model.Block{
+			ID:       id,
+			RootID:   rootID,
+			ParentID: rootID,
+			Type:     "card",
+			Fields:   map[string]interface{}{"properties": properties},
+		}
+	}
+
+	return []model.Block{
+		{ID: "board", RootID: "board", Type: "board"},
+		// Tuesday
+		card("card-1", "board", `{"from":1622541600000}`),
+		card("card-2", "board", `{"from":1622588400000,"to":1622980800000}`),
+		// Sunday, the end of the same week
+		card("card-3", "board", `{"from":1622980800000}`),
+		// Monday, the start of the next one
+		card("card-4", "board", `{"from":1623052800000}`),
+		card("card-5", "board", `{"from":1626307200000}`),
+		card("card-no-date", "board", ""),
+		card("card-not-json", "board", "tomorrow"),
+		card("card-no-from", "board", `{"to":1622541600000}`),
+		card("card-text-from", "board", `{"from":"tomorrow"}`),
+		card("card-other-board", "other-board", `{"from":1622541600000}`),
+	}
+}
+
+func testGroupCardsByDate(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, DatedCardsBlocks())
+
+	t.Run("by day", func(t *testing.T) {
+		buckets, err := store.GroupCardsByDate(container, "board", "due", model.DateBucketDay)
+		require.NoError(t, err)
+		require.Equal(t, []model.DateBucket{
+			{Start: "2021-06-01", Count: 2},
+			{Start: "2021-06-06", Count: 1},
+			{Start: "2021-06-07", Count: 1},
+			{Start: "2021-07-15", Count: 1},
+		}, buckets)
+	})
+
+	t.Run("by week", func(t *testing.T) {
+		buckets, err := store.GroupCardsByDate(container, "board", "due", model.DateBucketWeek)
+		require.NoError(t, err)
+		require.Equal(t, []model.DateBucket{
+			{Start: "2021-05-31", Count: 3},
+			{Start: "2021-06-07", Count: 1},
+			{Start: "2021-07-12", Count: 1},
+		}, buckets)
+	})
+
+	t.Run("by month", func(t *testing.T) {
+		buckets, err := store.GroupCardsByDate(container, "board", "due", model.DateBucketMonth)
+		require.NoError(t, err)
+		require.Equal(t, []model.DateBucket{
+			{Start: "2021-06-01", Count: 4},
+			{Start: "2021-07-01", Count: 1},
+		}, buckets)
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		buckets, err := store.GroupCardsByDate(workspaceContainer("other-workspace"), "board", "due", model.DateBucketDay)
+		require.NoError(t, err)
+		require.Empty(t, buckets)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := store.GroupCardsByDate(container, "board", `bad"id`, model.DateBucketDay)
+		require.Error(t, err)
+
+		_, err = store.GroupCardsByDate(container, "board", "due", "year")
+		require.Error(t, err)
+	})
+}
+
+func testGetBlocksWithParentAndTypeSorted(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	card := func(id string, properties map[string]interface{}) model.Block {
+		return model.Block{
+			ID:         id,
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields:     map[string]interface{}{"properties": properties},
+		}
+	}
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
+			ModifiedBy: userID,
+			Type:       "board",
+			Fields: map[string]interface{}{
+				"cardProperties": []interface{}{
+					map[string]interface{}{"id": "name", "name": "Name", "type": "text"},
+					map[string]interface{}{"id": "estimate", "name": "Estimate", "type": "number"},
+				},
+			},
+		},
+		card("card-1", map[string]interface{}{"name": "banana", "estimate": "10"}),
+		card("card-2", map[string]interface{}{"name": "apple", "estimate": "9"}),
+		card("card-3", map[string]interface{}{"name": "cherry", "estimate": "-2.5"}),
+		card("card-4", map[string]interface{}{"name": "apple", "estimate": "not a number"}),
+		card("card-5", map[string]interface{}{}),
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	blockIDs := func(blocks []model.Block) []string {
+		ids := []string{}
+		for _, block := range blocks {
+			ids = append(ids, block.ID)
+		}
+		return ids
+	}
+
+	t.Run("text property", func(t *testing.T) {
+		blocks, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort("name", false))
+		require.NoError(t, err)
+		require.Equal(t, []string{"card-2", "card-4", "card-1", "card-3", "card-5"}, blockIDs(blocks))
+	})
+
+	t.Run("text property descending", func(t *testing.T) {
+		blocks, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort("name", true))
+		require.NoError(t, err)
+		require.Equal(t, []string{"card-3", "card-1", "card-2", "card-4", "card-5"}, blockIDs(blocks))
 	})
-	t.Run("GetSubTree3", func(t *testing.T) {
-		store, tearDown := setup(t)
-		defer tearDown()
-		testGetSubTree3(t, store, container)
+
+	t.Run("number property", func(t *testing.T) {
+		blocks, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort("estimate", false))
+		require.NoError(t, err)
+		require.Equal(t, []string{"card-3", "card-2", "card-1", "card-4", "card-5"}, blockIDs(blocks))
 	})
-	t.Run("GetParentID", func(t *testing.T) {
-		store, tearDown := setup(t)
-		defer tearDown()
-		testGetParentID(t, store, container)
+
+	t.Run("number property descending", func(t *testing.T) {
+		blocks, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort("estimate", true))
+		require.NoError(t, err)
+		require.Equal(t, []string{"card-1", "card-2", "card-3", "card-4", "card-5"}, blockIDs(blocks))
 	})
-	t.Run("GetRootID", func(t *testing.T) {
-		store, tearDown := setup(t)
-		defer tearDown()
-		testGetRootID(t, store, container)
+
+	t.Run("unknown property", func(t *testing.T) {
+		blocks, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort("unknown", false))
+		require.NoError(t, err)
+		require.Equal(t, []string{"card-1", "card-2", "card-3", "card-4", "card-5"}, blockIDs(blocks))
+	})
+
+	t.Run("invalid property id", func(t *testing.T) {
+		_, err := store.GetBlocksWithParentAndTypeSorted(container, "board", "card", blockSort(`bad"id`, false))
+		require.Error(t, err)
 	})
 }
 
-func testInsertBlock(t *testing.T, store store.Store, container store.Container) {
+func testCountBoards(t *testing.T, store store.Store, container store.Container) {
 	userID := "user-id"
 
-	blocks, err := store.GetAllBlocks(container)
+	// The workspace also has the initial templates, which aren't counted
+	initialCount, err := store.CountBoards(container)
 	require.NoError(t, err)
-	initialCount := len(blocks)
+	require.Zero(t, initialCount)
 
-	t.Run("valid block", func(t *testing.T) {
-		block := model.Block{
-			ID:         "id-test",
-			RootID:     "id-test",
+	blocksToInsert := []model.Block{
+		{ID: "board-1", RootID: "board-1", ModifiedBy: userID, Type: "board"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", ModifiedBy: userID, Type: "card"},
+		{ID: "board-2", RootID: "board-2", ModifiedBy: userID, Type: "board", Fields: map[string]interface{}{"isTemplate": false}},
+		{ID: "template-1", RootID: "template-1", ModifiedBy: userID, Type: "board", Fields: map[string]interface{}{"isTemplate": true}},
+		{ID: "board-4", RootID: "board-4", ModifiedBy: userID, Type: "board", Fields: map[string]interface{}{
+			"cardProperties": []interface{}{map[string]interface{}{"id": "flags", "isTemplate": true}},
+		}},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	otherContainer := container
+	otherContainer.WorkspaceID = "other-workspace"
+	otherBlocks := []model.Block{
+		{ID: "board-3", RootID: "board-3", ModifiedBy: userID, Type: "board"},
+	}
+	InsertBlocks(t, store, otherContainer, otherBlocks)
+	defer DeleteBlocks(t, store, otherContainer, otherBlocks, "test")
+
+	count, err := store.CountBoards(container)
+	require.NoError(t, err)
+	require.Equal(t, initialCount+3, count)
+
+	count, err = store.CountBoards(otherContainer)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+// blockSort builds the sort options where the store package is shadowed
+func blockSort(propertyID string, descending bool) store.BlockSort {
+	return store.BlockSort{PropertyID: propertyID, Descending: descending}
+}
+
+func testCountBlocksByBoard(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocksToInsert := []model.Block{
+		{ID: "board-1", RootID: "board-1", ModifiedBy: userID, Type: "board"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", ModifiedBy: userID, Type: "card"},
+		{ID: "card-2", RootID: "board-1", ParentID: "board-1", ModifiedBy: userID, Type: "card"},
+		{ID: "text-1", RootID: "board-1", ParentID: "card-1", ModifiedBy: userID, Type: "text"},
+		{ID: "board-2", RootID: "board-2", ModifiedBy: userID, Type: "board"},
+		{ID: "card-3", RootID: "board-2", ParentID: "board-2", ModifiedBy: userID, Type: "card"},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	otherContainer := container
+	otherContainer.WorkspaceID = "other-workspace"
+	otherBlocks := []model.Block{
+		{ID: "board-3", RootID: "board-3", ModifiedBy: userID, Type: "board"},
+	}
+	InsertBlocks(t, store, otherContainer, otherBlocks)
+	defer DeleteBlocks(t, store, otherContainer, otherBlocks, "test")
+
+	// The workspace also has the initial templates
+	counts, err := store.CountBlocksByBoard(container)
+	require.NoError(t, err)
+	require.Equal(t, 4, counts["board-1"])
+	require.Equal(t, 2, counts["board-2"])
+	require.NotContains(t, counts, "board-3")
+
+	counts, err = store.CountBlocksByBoard(otherContainer)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"board-3": 1}, counts)
+}
+
+func propertiesBoard(userID string) []model.Block {
+	return []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
 			ModifiedBy: userID,
-		}
+			Type:       "board",
+			Fields: map[string]interface{}{
+				"cardProperties": []interface{}{
+					map[string]interface{}{
+						"id":   "status",
+						"name": "Status",
+						"type": "select",
+						"options": []interface{}{
+							map[string]interface{}{"id": "option-todo", "value": "To do"},
+							map[string]interface{}{"id": "option-done", "value": "Done"},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID:         "card-by-id",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "option-todo"},
+			},
+		},
+		{
+			ID:         "card-by-value",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": "To do"},
+			},
+		},
+		{
+			ID:         "card-multi",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Fields: map[string]interface{}{
+				"properties": map[string]interface{}{"status": []interface{}{"Done", "To do"}},
+			},
+		},
+	}
+}
 
-		err := store.InsertBlock(container, block)
-		require.NoError(t, err)
+func getBlock(t *testing.T, store store.Store, container store.Container, blockID string) model.Block {
+	blocks, err := store.GetBlocksByIDs(container, []string{blockID})
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	return blocks[0]
+}
 
-		blocks, err := store.GetAllBlocks(container)
+func boardProperty(t *testing.T, board model.Block) map[string]interface{} {
+	cardProperties, ok := board.Fields["cardProperties"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, cardProperties, 1)
+	return cardProperties[0].(map[string]interface{})
+}
+
+func testRenameBoardProperty(t *testing.T, store store.Store, container store.Container) {
+	blocksToInsert := propertiesBoard("user-id")
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	t.Run("rename property", func(t *testing.T) {
+		err := store.RenameBoardProperty(container, "board", "status", "State", "user-id-2")
 		require.NoError(t, err)
-		require.Len(t, blocks, initialCount+1)
+
+		board := getBlock(t, store, container, "board")
+		require.Equal(t, "State", boardProperty(t, board)["name"])
+		require.Equal(t, "user-id-2", board.ModifiedBy)
+
+		card := getBlock(t, store, container, "card-by-id")
+		require.Equal(t, "option-todo", card.Fields["properties"].(map[string]interface{})["status"])
 	})
 
-	t.Run("invalid rootid", func(t *testing.T) {
-		block := model.Block{
-			ID:         "id-test",
-			RootID:     "",
-			ModifiedBy: userID,
-		}
+	t.Run("unknown property", func(t *testing.T) {
+		err := store.RenameBoardProperty(container, "board", "not-exists", "State", "user-id-2")
+		require.Error(t, err)
+	})
 
-		err := store.InsertBlock(container, block)
+	t.Run("unknown board", func(t *testing.T) {
+		err := store.RenameBoardProperty(container, "not-exists", "status", "State", "user-id-2")
+		require.EqualError(t, err, "blocks not found: not-exists")
+	})
+}
+
+func testRenamePropertyOption(t *testing.T, store store.Store, container store.Container) {
+	blocksToInsert := propertiesBoard("user-id")
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	cardStatus := func(cardID string) interface{} {
+		card := getBlock(t, store, container, cardID)
+		return card.Fields["properties"].(map[string]interface{})["status"]
+	}
+
+	t.Run("unknown option changes nothing", func(t *testing.T) {
+		err := store.RenamePropertyOption(container, "board", "status", "Backlog", "Later", "user-id-2")
 		require.Error(t, err)
 
-		blocks, err := store.GetAllBlocks(container)
+		board := getBlock(t, store, container, "board")
+		require.Equal(t, "user-id", board.ModifiedBy)
+		require.Equal(t, "To do", cardStatus("card-by-value"))
+	})
+
+	t.Run("rename option", func(t *testing.T) {
+		err := store.RenamePropertyOption(container, "board", "status", "To do", "Backlog", "user-id-2")
 		require.NoError(t, err)
-		require.Len(t, blocks, initialCount+1)
+
+		board := getBlock(t, store, container, "board")
+		options := boardProperty(t, board)["options"].([]interface{})
+		require.Equal(t, "Backlog", options[0].(map[string]interface{})["value"])
+		require.Equal(t, "option-todo", options[0].(map[string]interface{})["id"])
+		require.Equal(t, "Done", options[1].(map[string]interface{})["value"])
+
+		require.Equal(t, "option-todo", cardStatus("card-by-id"))
+		require.Equal(t, "Backlog", cardStatus("card-by-value"))
+		require.Equal(t, []interface{}{"Done", "Backlog"}, cardStatus("card-multi"))
+
+		require.Equal(t, "user-id", getBlock(t, store, container, "card-by-id").ModifiedBy)
+		require.Equal(t, "user-id-2", getBlock(t, store, container, "card-by-value").ModifiedBy)
 	})
+}
 
-	t.Run("invalid fields data", func(t *testing.T) {
-		block := model.Block{
-			ID:         "id-test",
-			RootID:     "id-test",
+func testSearchBlocks(t *testing.T, store store.Store, container store.Container) {
+	userID := "user-id"
+
+	blocksToInsert := []model.Block{
+		{
+			ID:         "board",
+			RootID:     "board",
 			ModifiedBy: userID,
-			Fields:     map[string]interface{}{"no-serialiable-value": t.Run},
+			Type:       "board",
+			Title:      "Café opening",
+		},
+		{
+			ID:         "card-upper",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Title:      "CAFE MENU",
+		},
+		{
+			ID:         "card-other",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Title:      "Bakery",
+		},
+		{
+			ID:         "card-wildcard",
+			RootID:     "board",
+			ParentID:   "board",
+			ModifiedBy: userID,
+			Type:       "card",
+			Title:      "100% done",
+		},
+	}
+	InsertBlocks(t, store, container, blocksToInsert)
+	defer DeleteBlocks(t, store, container, blocksToInsert, "test")
+
+	blockIDs := func(blocks []model.Block) []string {
+		ids := []string{}
+		for _, block := range blocks {
+			ids = append(ids, block.ID)
 		}
+		return ids
+	}
 
-		err := store.InsertBlock(container, block)
-		require.Error(t, err)
+	for _, term := range []string{"cafe", "CAFE", "café", "CAFÉ", "Cafè"} {
+		t.Run("case and accent insensitive "+term, func(t *testing.T) {
+			blocks, err := store.SearchBlocks(container, term)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"board", "card-upper"}, blockIDs(blocks))
+		})
+	}
 
-		blocks, err := store.GetAllBlocks(container)
+	t.Run("partial match", func(t *testing.T) {
+		blocks, err := store.SearchBlocks(container, "aker")
 		require.NoError(t, err)
-		require.Len(t, blocks, initialCount+1)
+		require.ElementsMatch(t, []string{"card-other"}, blockIDs(blocks))
+	})
+
+	t.Run("wildcards are matched literally", func(t *testing.T) {
+		blocks, err := store.SearchBlocks(container, "100%")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"card-wildcard"}, blockIDs(blocks))
+
+		blocks, err = store.SearchBlocks(container, "_")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+
+		for _, term := range []string{"100?", "10*done", "[1]00"} {
+			blocks, err = store.SearchBlocks(container, term)
+			require.NoError(t, err)
+			require.Empty(t, blocks)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		blocks, err := store.SearchBlocks(container, "coffee")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
 	})
 }
 
@@ -451,3 +1464,232 @@ func testDeleteBlock(t *testing.T, store store.Store, container store.Container)
 		require.NoError(t, err)
 	})
 }
+
+func testGetComments(t *testing.T, store store.Store, container store.Container) {
+	blocks := []model.Block{
+		{ID: "card", ParentID: "board", RootID: "board", Type: "card", CreateAt: 1},
+		{ID: "comment-1", ParentID: "card", RootID: "board", Type: "comment", Title: "First", CreateAt: 10},
+		{ID: "comment-2", ParentID: "card", RootID: "board", Type: "comment", Title: "Second", CreateAt: 20},
+		{ID: "comment-3", ParentID: "card", RootID: "board", Type: "comment", Title: "Third", CreateAt: 30},
+		{ID: "comment-4", ParentID: "card", RootID: "board", Type: "comment", Title: "Fourth", CreateAt: 30},
+		{ID: "text", ParentID: "card", RootID: "board", Type: "text", Title: "Description", CreateAt: 40},
+		{ID: "other-comment", ParentID: "other-card", RootID: "board", Type: "comment", CreateAt: 50},
+	}
+	InsertBlocks(t, store, container, blocks)
+
+	ids := func(blocks []model.Block) []string {
+		result := []string{}
+		for _, block := range blocks {
+			result = append(result, block.ID)
+		}
+		return result
+	}
+
+	t.Run("only the comments of the card, newest first", func(t *testing.T) {
+		comments, err := store.GetComments(container, "card", 10, 0, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-4", "comment-3", "comment-2", "comment-1"}, ids(comments))
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		comments, err := store.GetComments(container, "card", 2, 0, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-4", "comment-3"}, ids(comments))
+
+		comments, err = store.GetComments(container, "card", 2, comments[1].CreateAt, comments[1].ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-2", "comment-1"}, ids(comments))
+	})
+
+	t.Run("a page ending within comments created at the same time", func(t *testing.T) {
+		comments, err := store.GetComments(container, "card", 1, 0, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-4"}, ids(comments))
+
+		comments, err = store.GetComments(container, "card", 1, comments[0].CreateAt, comments[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-3"}, ids(comments))
+	})
+
+	t.Run("before without an ID", func(t *testing.T) {
+		comments, err := store.GetComments(container, "card", 10, 30, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"comment-2", "comment-1"}, ids(comments))
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		comments, err := store.GetComments(workspaceContainer("other-workspace"), "card", 10, 0, "")
+		require.NoError(t, err)
+		require.Empty(t, comments)
+	})
+}
+
+// AssignedCardsBlocks are the blocks of two boards whose cards are assigned
+// to "user-1" in different ways, only in the cards with "assigned" in their ID
+func AssignedCardsBlocks() []model.Block {
+	schema := []interface{}{
+		map[string]interface{}{"id": "owner", "type": "person"},
+		map[string]interface{}{"id": "reviewers", "type": "multiPerson"},
+		map[string]interface{}{"id": "notes", "type": "text"},
+	}
+
+	return []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Fields: map[string]interface{}{"cardProperties": schema}},
+		{ID: "board-2", RootID: "board-2", Type: "board"},
+		{ID: "card-assigned-owner", ParentID: "board-1", RootID: "board-1", Type: "card", UpdateAt: 3, Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"owner": "user-1"},
+		}},
+		{ID: "card-assigned-reviewer", ParentID: "board-1", RootID: "board-1", Type: "card", UpdateAt: 2, Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"reviewers": []interface{}{"user-2", "user-1"}},
+		}},
+		{ID: "card-other-user", ParentID: "board-1", RootID: "board-1", Type: "card", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"owner": "user-2"},
+		}},
+		// The user ID as the value of a property that isn't a person
+		{ID: "card-text", ParentID: "board-1", RootID: "board-1", Type: "card", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"notes": "user-1"},
+		}},
+		// The board has no schema
+		{ID: "card-no-schema", ParentID: "board-2", RootID: "board-2", Type: "card", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"owner": "user-1"},
+		}},
+		{ID: "comment", ParentID: "card-other-user", RootID: "board-1", Type: "comment", Fields: map[string]interface{}{
+			"properties": map[string]interface{}{"owner": "user-1"},
+		}},
+	}
+}
+
+func testGetCardsAssignedTo(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, AssignedCardsBlocks())
+
+	cards, err := store.GetCardsAssignedTo(container, "user-1")
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	require.Equal(t, "card-assigned-owner", cards[0].ID)
+	require.Equal(t, "card-assigned-reviewer", cards[1].ID)
+
+	cards, err = store.GetCardsAssignedTo(container, "user-3")
+	require.NoError(t, err)
+	require.Empty(t, cards)
+
+	cards, err = store.GetCardsAssignedTo(workspaceContainer("other-workspace"), "user-1")
+	require.NoError(t, err)
+	require.Empty(t, cards)
+}
+
+func testGetBlocksModifiedBy(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "block-old", RootID: "board", Type: "card", ModifiedBy: "user-1", UpdateAt: 100},
+		{ID: "block-1", RootID: "board", Type: "card", ModifiedBy: "user-1", UpdateAt: 200},
+		{ID: "block-2", RootID: "board", Type: "card", ModifiedBy: "user-1", UpdateAt: 300},
+		{ID: "block-other-user", RootID: "board", Type: "card", ModifiedBy: "user-2", UpdateAt: 250},
+	})
+	InsertBlocks(t, store, workspaceContainer("other-workspace"), []model.Block{
+		{ID: "block-other-workspace", RootID: "board", Type: "card", ModifiedBy: "user-1", UpdateAt: 250},
+	})
+
+	blocks, err := store.GetBlocksModifiedBy(container, "user-1", 200, 10)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	require.Equal(t, "block-2", blocks[0].ID)
+	require.Equal(t, "block-1", blocks[1].ID)
+
+	blocks, err = store.GetBlocksModifiedBy(container, "user-1", 0, 2)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	require.Equal(t, "block-2", blocks[0].ID)
+
+	blocks, err = store.GetBlocksModifiedBy(container, "user-3", 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+}
+
+func testBlockMigrationFlags(t *testing.T, store store.Store) {
+	blockIDs := func(flags []model.BlockMigrationFlag) []string {
+		ids := []string{}
+		for _, f := range flags {
+			ids = append(ids, f.BlockID)
+		}
+		return ids
+	}
+
+	t.Run("no blocks tagged", func(t *testing.T) {
+		flags, err := store.GetBlocksByMigrationFlag("split-tags")
+		require.NoError(t, err)
+		require.Empty(t, flags)
+	})
+
+	t.Run("tag a set of blocks", func(t *testing.T) {
+		require.NoError(t, store.SetBlockMigrationFlag([]string{"block-1", "block-2", "block-3"}, "split-tags"))
+		require.NoError(t, store.SetBlockMigrationFlag([]string{"block-2"}, "rename-status"))
+
+		flags, err := store.GetBlocksByMigrationFlag("split-tags")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"block-1", "block-2", "block-3"}, blockIDs(flags))
+		for _, f := range flags {
+			require.Equal(t, "split-tags", f.Flag)
+			require.NotZero(t, f.CreateAt)
+		}
+
+		flags, err = store.GetBlocksByMigrationFlag("rename-status")
+		require.NoError(t, err)
+		require.Equal(t, []string{"block-2"}, blockIDs(flags))
+	})
+
+	t.Run("tagging again keeps the first time", func(t *testing.T) {
+		before, err := store.GetBlocksByMigrationFlag("rename-status")
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, store.SetBlockMigrationFlag([]string{"block-2", "block-4"}, "rename-status"))
+
+		flags, err := store.GetBlocksByMigrationFlag("rename-status")
+		require.NoError(t, err)
+		require.Equal(t, []string{"block-2", "block-4"}, blockIDs(flags))
+		require.Equal(t, before[0].CreateAt, flags[0].CreateAt)
+	})
+
+	t.Run("a flag is required", func(t *testing.T) {
+		require.Error(t, store.SetBlockMigrationFlag([]string{"block-1"}, ""))
+	})
+}
+
+func testBlockExists(t *testing.T, s store.Store, container store.Container) {
+	InsertBlocks(t, s, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board"},
+		{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card"},
+		{ID: "card-2", ParentID: "board-1", RootID: "board-1", Type: "card"},
+	})
+	require.NoError(t, s.DeleteBlock(container, "card-2", "user-id"))
+
+	exists := func(check func(store.Container, string) (bool, error), c store.Container, id string) bool {
+		found, err := check(c, id)
+		require.NoError(t, err)
+		return found
+	}
+
+	t.Run("blocks", func(t *testing.T) {
+		require.True(t, exists(s.BlockExists, container, "board-1"))
+		require.True(t, exists(s.BlockExists, container, "card-1"))
+		require.False(t, exists(s.BlockExists, container, "card-2"))
+		require.False(t, exists(s.BlockExists, container, "unknown"))
+		require.False(t, exists(s.BlockExists, workspaceContainer("workspace-2"), "card-1"))
+	})
+
+	t.Run("boards", func(t *testing.T) {
+		require.True(t, exists(s.BoardExists, container, "board-1"))
+		require.False(t, exists(s.BoardExists, container, "card-1"))
+		require.False(t, exists(s.BoardExists, container, "unknown"))
+		require.False(t, exists(s.BoardExists, workspaceContainer("workspace-2"), "board-1"))
+	})
+
+	t.Run("boards in a batch", func(t *testing.T) {
+		existing, err := s.GetExistingBoards(container, []string{"board-1", "card-1", "unknown"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"board-1"}, existing)
+
+		existing, err = s.GetExistingBoards(workspaceContainer("workspace-2"), []string{"board-1"})
+		require.NoError(t, err)
+		require.Empty(t, existing)
+	})
+}
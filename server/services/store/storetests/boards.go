@@ -0,0 +1,580 @@
+package storetests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestBoardsStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	container := store.Container{
+		WorkspaceID: "0",
+	}
+
+	t.Run("CreateBoardWithDefaults", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testCreateBoardWithDefaults(t, store, container)
+	})
+	t.Run("GetBoardsMetadata", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardsMetadata(t, store, container)
+	})
+	t.Run("FindDuplicateBoards", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testFindDuplicateBoards(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("BoardSnapshots", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testBoardSnapshots(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("GetBoardActivity", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardActivity(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("GetBoardChecksum", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetBoardChecksum(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("Trash", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testTrash(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("BoardLocks", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testBoardLocks(t, store, workspaceContainer("workspace-1"))
+	})
+}
+
+func testCreateBoardWithDefaults(t *testing.T, store store.Store, container store.Container) {
+	t.Run("creates the board and its views", func(t *testing.T) {
+		board := model.Block{
+			ID:     "board-1",
+			RootID: "board-1",
+			Type:   "board",
+			Fields: map[string]interface{}{
+				"cardProperties": []interface{}{map[string]interface{}{"id": "status", "type": "select"}},
+			},
+		}
+		views := []model.Block{
+			{ID: "view-1", ParentID: "board-1", RootID: "board-1", Type: "view", Fields: map[string]interface{}{"viewType": "board"}},
+			{ID: "view-2", ParentID: "board-1", RootID: "board-1", Type: "view", Fields: map[string]interface{}{"viewType": "table"}},
+		}
+
+		err := store.CreateBoardWithDefaults(container, board, views)
+		require.NoError(t, err)
+
+		blocks, err := store.GetBlocksWithRootID(container, "board-1")
+		require.NoError(t, err)
+		require.Len(t, blocks, 3)
+		require.True(t, ContainsBlockWithID(blocks, "board-1"))
+		require.True(t, ContainsBlockWithID(blocks, "view-1"))
+		require.True(t, ContainsBlockWithID(blocks, "view-2"))
+	})
+
+	t.Run("a failed view insert rolls back the board", func(t *testing.T) {
+		board := model.Block{ID: "board-2", RootID: "board-2", Type: "board"}
+		views := []model.Block{
+			{ID: "view-3", ParentID: "board-2", RootID: "board-2", Type: "view"},
+			// The fields can't be serialized, so the insert fails
+			{ID: "view-4", ParentID: "board-2", RootID: "board-2", Type: "view", Fields: map[string]interface{}{"invalid": make(chan int)}},
+		}
+
+		err := store.CreateBoardWithDefaults(container, board, views)
+		require.Error(t, err)
+
+		blocks, err := store.GetBlocksWithRootID(container, "board-2")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+
+	t.Run("views of another board", func(t *testing.T) {
+		board := model.Block{ID: "board-3", RootID: "board-3", Type: "board"}
+		views := []model.Block{
+			{ID: "view-5", ParentID: "board-1", RootID: "board-1", Type: "view"},
+		}
+
+		err := store.CreateBoardWithDefaults(container, board, views)
+		require.Error(t, err)
+
+		blocks, err := store.GetBlocksWithRootID(container, "board-3")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+	})
+}
+
+func testGetBoardsMetadata(t *testing.T, store store.Store, container store.Container) {
+	schema := []interface{}{map[string]interface{}{"id": "status", "type": "select"}}
+	blocks := []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Title: "Roadmap", UpdateAt: 10, Fields: map[string]interface{}{"icon": "🚀", "cardProperties": schema}},
+		{ID: "board-2", RootID: "board-2", Type: "board", Title: "Bugs", UpdateAt: 20},
+		{ID: "board-3", RootID: "board-3", Type: "board", Title: "Not requested"},
+		{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Card 1"},
+		{ID: "card-2", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Card 2"},
+		{ID: "view-1", ParentID: "board-1", RootID: "board-1", Type: "view", Title: "View"},
+		{ID: "card-3", ParentID: "board-3", RootID: "board-3", Type: "card", Title: "Card 3"},
+	}
+	InsertBlocks(t, store, container, blocks)
+
+	t.Run("returns the requested boards", func(t *testing.T) {
+		metadata, err := store.GetBoardsMetadata(container, []string{"board-1", "board-2", "card-1", "not-exists"})
+		require.NoError(t, err)
+		require.Equal(t, []model.BoardMetadata{
+			{ID: "board-2", Title: "Bugs", CardCount: 0, UpdateAt: 20},
+			{ID: "board-1", Title: "Roadmap", Icon: "🚀", CardCount: 2, UpdateAt: 10},
+		}, metadata)
+	})
+
+	t.Run("other workspaces' boards are ignored", func(t *testing.T) {
+		metadata, err := store.GetBoardsMetadata(workspaceContainer("other-workspace"), []string{"board-1"})
+		require.NoError(t, err)
+		require.Empty(t, metadata)
+	})
+
+	t.Run("no IDs", func(t *testing.T) {
+		metadata, err := store.GetBoardsMetadata(container, nil)
+		require.NoError(t, err)
+		require.Empty(t, metadata)
+	})
+}
+
+func testFindDuplicateBoards(t *testing.T, store store.Store, container store.Container) {
+	board := func(id, title string, cards ...string) []model.Block {
+		blocks := []model.Block{
+			{ID: id, RootID: id, Type: "board", Title: title, Schema: 1},
+			{ID: id + "-view", ParentID: id, RootID: id, Type: "view", Title: "Board view"},
+		}
+		for i, card := range cards {
+			blocks = append(blocks, model.Block{ID: fmt.Sprintf("%s-card-%d", id, i), ParentID: id, RootID: id, Type: "card", Title: card})
+		}
+		return blocks
+	}
+
+	t.Run("no duplicates", func(t *testing.T) {
+		duplicates, err := store.FindDuplicateBoards(container)
+		require.NoError(t, err)
+		require.Empty(t, duplicates)
+	})
+
+	// withStatus gives the board a status property with the options
+	withStatus := func(blocks []model.Block, id, propertyType string, values ...string) []model.Block {
+		options := []interface{}{}
+		for i, value := range values {
+			options = append(options, map[string]interface{}{"id": fmt.Sprintf("%s-option-%d", id, i), "value": value})
+		}
+		blocks[0].Fields = map[string]interface{}{"cardProperties": []interface{}{
+			map[string]interface{}{"id": id, "name": "Status", "type": propertyType, "options": options},
+		}}
+		return blocks
+	}
+
+	blocks := withStatus(board("board-1", "Roadmap", "Design", "Build"), "status", "select", "Done", "To do")
+	// The same content, with the cards and options in another order and
+	// other property IDs
+	blocks = append(blocks, withStatus(board("board-2", "Roadmap", "Build", "Design"), "state", "select", "To do", "Done")...)
+	blocks = append(blocks, withStatus(board("board-3", "Roadmap", "Design", "Build"), "status", "select", "Done", "To do")...)
+	// The same blocks, but other card properties
+	blocks = append(blocks, withStatus(board("board-9", "Roadmap", "Design", "Build"), "status", "select", "Done")...)
+	blocks = append(blocks, withStatus(board("board-10", "Roadmap", "Design", "Build"), "status", "text")...)
+	// Same title and block count, but other cards
+	blocks = append(blocks, board("board-4", "Roadmap", "Design", "Ship")...)
+	blocks = append(blocks, board("board-5", "Tasks")...)
+	blocks = append(blocks, board("board-6", "Tasks")...)
+	// Another board with one more card
+	blocks = append(blocks, board("board-7", "Tasks", "Triage")...)
+	InsertBlocks(t, store, container, blocks)
+	// The boards of other workspaces aren't compared
+	InsertBlocks(t, store, workspaceContainer("workspace-2"), board("board-8", "Tasks"))
+
+	duplicates, err := store.FindDuplicateBoards(container)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 2)
+
+	require.Equal(t, []string{"board-1", "board-2", "board-3"}, duplicates[0].BoardIDs)
+	require.Equal(t, "Roadmap", duplicates[0].Title)
+	require.Equal(t, 4, duplicates[0].BlockCount)
+	require.Equal(t, []string{"board-5", "board-6"}, duplicates[1].BoardIDs)
+	require.Equal(t, 2, duplicates[1].BlockCount)
+	require.NotEqual(t, duplicates[0].Hash, duplicates[1].Hash)
+}
+
+func testBoardSnapshots(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Title: "Roadmap"},
+		{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Design"},
+		{ID: "card-2", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Build"},
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		_, err := store.CreateBoardSnapshot(container, "board-2", "user-1")
+		require.Error(t, err)
+
+		// Nor a card
+		_, err = store.CreateBoardSnapshot(container, "card-1", "user-1")
+		require.Error(t, err)
+	})
+
+	snapshotID, err := store.CreateBoardSnapshot(container, "board-1", "user-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, snapshotID)
+
+	t.Run("the snapshot isn't affected by the changes to the board", func(t *testing.T) {
+		InsertBlocks(t, store, container, []model.Block{
+			{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Design v2"},
+			{ID: "card-3", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Ship"},
+		})
+		require.NoError(t, store.DeleteBlock(container, "card-2", "user-1"))
+
+		snapshot, err := store.GetBoardSnapshot(container, snapshotID)
+		require.NoError(t, err)
+		require.Equal(t, snapshotID, snapshot.ID)
+		require.Equal(t, "board-1", snapshot.BoardID)
+		require.Equal(t, "user-1", snapshot.CreatedBy)
+		require.NotZero(t, snapshot.CreateAt)
+		require.NotNil(t, snapshot.Archive)
+		require.Len(t, snapshot.Archive.Blocks, 3)
+
+		titles := map[string]string{}
+		for _, block := range snapshot.Archive.Blocks {
+			titles[block.ID] = block.Title
+		}
+		require.Equal(t, map[string]string{"board-1": "Roadmap", "card-1": "Design", "card-2": "Build"}, titles)
+	})
+
+	t.Run("list the snapshots of a board", func(t *testing.T) {
+		secondID, err := store.CreateBoardSnapshot(container, "board-1", "user-2")
+		require.NoError(t, err)
+
+		snapshots, err := store.GetBoardSnapshots(container, "board-1")
+		require.NoError(t, err)
+		require.Len(t, snapshots, 2)
+		ids := []string{snapshots[0].ID, snapshots[1].ID}
+		require.ElementsMatch(t, []string{snapshotID, secondID}, ids)
+		for _, snapshot := range snapshots {
+			require.Nil(t, snapshot.Archive)
+		}
+
+		second, err := store.GetBoardSnapshot(container, secondID)
+		require.NoError(t, err)
+		require.Len(t, second.Archive.Blocks, 3)
+
+		snapshots, err = store.GetBoardSnapshots(container, "board-2")
+		require.NoError(t, err)
+		require.Empty(t, snapshots)
+	})
+
+	t.Run("the snapshots of other workspaces aren't found", func(t *testing.T) {
+		_, err := store.GetBoardSnapshot(workspaceContainer("workspace-2"), snapshotID)
+		require.Error(t, err)
+
+		snapshots, err := store.GetBoardSnapshots(workspaceContainer("workspace-2"), "board-1")
+		require.NoError(t, err)
+		require.Empty(t, snapshots)
+	})
+}
+
+func testGetBoardActivity(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Title: "Roadmap", ModifiedBy: "user-1", UpdateAt: 10},
+		{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Design", ModifiedBy: "user-1", UpdateAt: 20},
+		{ID: "card-1", ParentID: "board-1", RootID: "board-1", Type: "card", Title: "Design v2", ModifiedBy: "user-2", UpdateAt: 30},
+		{ID: "text-1", ParentID: "card-1", RootID: "board-1", Type: "text", Title: "Notes", ModifiedBy: "user-2", UpdateAt: 40},
+		{ID: "card-2", ParentID: "board-2", RootID: "board-2", Type: "card", Title: "Other", ModifiedBy: "user-1", UpdateAt: 50},
+	})
+	require.NoError(t, store.DeleteBlock(container, "text-1", "user-3"))
+
+	type entry struct {
+		blockID    string
+		title      string
+		action     string
+		modifiedBy string
+	}
+	entries := func(activity []model.BlockActivity) []entry {
+		result := []entry{}
+		for _, a := range activity {
+			result = append(result, entry{a.BlockID, a.Title, a.Action, a.ModifiedBy})
+		}
+		return result
+	}
+
+	t.Run("the changes to the board, newest first", func(t *testing.T) {
+		activity, err := store.GetBoardActivity(container, "board-1", 10, 0, "")
+		require.NoError(t, err)
+		require.Equal(t, []entry{
+			{"text-1", "Notes", model.BlockActivityDeleted, "user-3"},
+			{"text-1", "Notes", model.BlockActivityCreated, "user-2"},
+			{"card-1", "Design v2", model.BlockActivityUpdated, "user-2"},
+			{"card-1", "Design", model.BlockActivityCreated, "user-1"},
+			{"board-1", "Roadmap", model.BlockActivityCreated, "user-1"},
+		}, entries(activity))
+		require.Equal(t, "text", activity[0].BlockType)
+		require.EqualValues(t, 40, activity[1].UpdateAt)
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		activity, err := store.GetBoardActivity(container, "board-1", 2, 0, "")
+		require.NoError(t, err)
+		require.Len(t, activity, 2)
+
+		activity, err = store.GetBoardActivity(container, "board-1", 2, activity[1].UpdateAt, activity[1].BlockID)
+		require.NoError(t, err)
+		require.Equal(t, []entry{
+			{"card-1", "Design v2", model.BlockActivityUpdated, "user-2"},
+			{"card-1", "Design", model.BlockActivityCreated, "user-1"},
+		}, entries(activity))
+	})
+
+	t.Run("the changes made at the same time aren't skipped", func(t *testing.T) {
+		InsertBlocks(t, store, container, []model.Block{
+			{ID: "card-3", ParentID: "board-3", RootID: "board-3", Type: "card", Title: "First", ModifiedBy: "user-1", UpdateAt: 60},
+			{ID: "card-4", ParentID: "board-3", RootID: "board-3", Type: "card", Title: "Second", ModifiedBy: "user-1", UpdateAt: 60},
+			{ID: "card-5", ParentID: "board-3", RootID: "board-3", Type: "card", Title: "Third", ModifiedBy: "user-1", UpdateAt: 60},
+		})
+
+		blockIDs := []string{}
+		var before int64
+		beforeID := ""
+		for {
+			activity, err := store.GetBoardActivity(container, "board-3", 1, before, beforeID)
+			require.NoError(t, err)
+			if len(activity) == 0 {
+				break
+			}
+			blockIDs = append(blockIDs, activity[0].BlockID)
+			before, beforeID = activity[0].UpdateAt, activity[0].BlockID
+		}
+		require.Equal(t, []string{"card-5", "card-4", "card-3"}, blockIDs)
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		activity, err := store.GetBoardActivity(workspaceContainer("workspace-2"), "board-1", 10, 0, "")
+		require.NoError(t, err)
+		require.Empty(t, activity)
+	})
+}
+
+func testBoardLocks(t *testing.T, store store.Store, container store.Container) {
+	t.Run("contention", func(t *testing.T) {
+		acquired, err := store.AcquireBoardLock(container, "board-1", "import-1", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, err = store.AcquireBoardLock(container, "board-1", "import-2", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		// The holder can extend it, also within the same millisecond, when
+		// the expiry doesn't change
+		for i := 0; i < 3; i++ {
+			acquired, err = store.AcquireBoardLock(container, "board-1", "import-1", time.Minute)
+			require.NoError(t, err)
+			require.True(t, acquired)
+		}
+
+		// Other boards aren't locked
+		acquired, err = store.AcquireBoardLock(container, "board-2", "import-2", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		// Only by the holder
+		require.NoError(t, store.ReleaseBoardLock(container, "board-1", "import-2"))
+		acquired, err := store.AcquireBoardLock(container, "board-1", "import-2", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		require.NoError(t, store.ReleaseBoardLock(container, "board-1", "import-1"))
+		acquired, err = store.AcquireBoardLock(container, "board-1", "import-2", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("the lock expires", func(t *testing.T) {
+		acquired, err := store.AcquireBoardLock(container, "board-3", "import-1", 10*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		time.Sleep(20 * time.Millisecond)
+		acquired, err = store.AcquireBoardLock(container, "board-3", "import-2", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("locked boards", func(t *testing.T) {
+		acquired, err := store.AcquireBoardLock(container, "board-4", "import-1", 10*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		time.Sleep(20 * time.Millisecond)
+
+		// Without the expired lock, and the boards nobody locked
+		locked, err := store.GetLockedBoards(container, []string{"board-1", "board-4", "board-5"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"board-1"}, locked)
+	})
+}
+
+func testGetBoardChecksum(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", UpdateAt: 10},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 1", UpdateAt: 20},
+		{ID: "card-2", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 2", UpdateAt: 30},
+		{ID: "board-2", RootID: "board-2", Type: "board", UpdateAt: 10},
+	})
+
+	checksum, err := store.GetBoardChecksum(container, "board-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, checksum)
+
+	t.Run("stable without changes", func(t *testing.T) {
+		again, err := store.GetBoardChecksum(container, "board-1")
+		require.NoError(t, err)
+		require.Equal(t, checksum, again)
+
+		// Nor with changes to other boards
+		InsertBlocks(t, store, container, []model.Block{{ID: "card-3", RootID: "board-2", ParentID: "board-2", Type: "card", UpdateAt: 40}})
+		again, err = store.GetBoardChecksum(container, "board-1")
+		require.NoError(t, err)
+		require.Equal(t, checksum, again)
+	})
+
+	t.Run("changes after an edit", func(t *testing.T) {
+		InsertBlocks(t, store, container, []model.Block{{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 1 edited", UpdateAt: 50}})
+
+		edited, err := store.GetBoardChecksum(container, "board-1")
+		require.NoError(t, err)
+		require.NotEqual(t, checksum, edited)
+		checksum = edited
+	})
+
+	t.Run("changes when blocks swap their update times", func(t *testing.T) {
+		InsertBlocks(t, store, container, []model.Block{
+			{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 1 edited", UpdateAt: 30},
+			{ID: "card-2", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 2", UpdateAt: 50},
+		})
+
+		swapped, err := store.GetBoardChecksum(container, "board-1")
+		require.NoError(t, err)
+		require.NotEqual(t, checksum, swapped)
+		checksum = swapped
+	})
+
+	t.Run("changes after a deletion", func(t *testing.T) {
+		require.NoError(t, store.DeleteBlock(container, "card-2", "user-1"))
+
+		deleted, err := store.GetBoardChecksum(container, "board-1")
+		require.NoError(t, err)
+		require.NotEqual(t, checksum, deleted)
+	})
+
+	t.Run("empty board", func(t *testing.T) {
+		empty, err := store.GetBoardChecksum(container, "unknown-board")
+		require.NoError(t, err)
+		require.NotEmpty(t, empty)
+		require.NotEqual(t, checksum, empty)
+	})
+}
+
+func testTrash(t *testing.T, s store.Store, container store.Container) {
+	InsertBlocks(t, s, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Title: "Board 1", ModifiedBy: "user-1"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 1"},
+		{ID: "card-2", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Card 2"},
+		{ID: "board-2", RootID: "board-2", Type: "board", Title: "Board 2"},
+	})
+
+	// Card 1 is deleted before the board and card 2 while it's in the trash
+	DeleteBlocks(t, s, container, []model.Block{{ID: "card-1"}, {ID: "board-1"}, {ID: "card-2"}}, "user-1")
+
+	t.Run("list the trash", func(t *testing.T) {
+		boards, err := s.GetDeletedBoards(container.WorkspaceID)
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+		require.Equal(t, "board-1", boards[0].ID)
+		require.Equal(t, "Board 1", boards[0].Title)
+		require.Equal(t, "user-1", boards[0].ModifiedBy)
+		require.NotZero(t, boards[0].DeleteAt)
+
+		boards, err = s.GetDeletedBoards("other-workspace")
+		require.NoError(t, err)
+		require.Empty(t, boards)
+	})
+
+	t.Run("restore a board", func(t *testing.T) {
+		restored, err := s.RestoreBoard(container, "board-1", "user-2")
+		require.NoError(t, err)
+		require.Len(t, restored, 2)
+		require.True(t, ContainsBlockWithID(restored, "board-1"))
+		require.True(t, ContainsBlockWithID(restored, "card-2"))
+
+		blocks, err := s.GetBlocksWithRootID(container, "board-1")
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+		require.False(t, ContainsBlockWithID(blocks, "card-1"))
+		for _, block := range blocks {
+			require.Equal(t, "user-2", block.ModifiedBy)
+			require.Zero(t, block.DeleteAt)
+		}
+
+		boards, err := s.GetDeletedBoards(container.WorkspaceID)
+		require.NoError(t, err)
+		require.Empty(t, boards)
+
+		_, err = s.RestoreBoard(container, "board-1", "user-2")
+		var notFoundErr *store.ErrBlocksNotFound
+		require.True(t, errors.As(err, &notFoundErr))
+	})
+
+	t.Run("boards deleted before a time", func(t *testing.T) {
+		DeleteBlocks(t, s, container, []model.Block{{ID: "board-2"}}, "user-1")
+
+		boards, err := s.GetDeletedBoardsBefore(utils.GetMillis() + 1)
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+		require.Equal(t, "board-2", boards[0].BoardID)
+		require.Equal(t, container.WorkspaceID, boards[0].WorkspaceID)
+
+		boards, err = s.GetDeletedBoardsBefore(boards[0].DeleteAt)
+		require.NoError(t, err)
+		require.Empty(t, boards)
+	})
+
+	t.Run("delete a board permanently", func(t *testing.T) {
+		// Only the boards in the trash
+		err := s.PurgeBoard(container, "board-1")
+		var notFoundErr *store.ErrBlocksNotFound
+		require.True(t, errors.As(err, &notFoundErr))
+
+		DeleteBlocks(t, s, container, []model.Block{{ID: "board-1"}}, "user-1")
+		require.NoError(t, s.PurgeBoard(container, "board-1"))
+
+		blocks, err := s.GetBlocksWithRootID(container, "board-1")
+		require.NoError(t, err)
+		require.Empty(t, blocks)
+
+		activity, err := s.GetBoardActivity(container, "board-1", 10, 0, "")
+		require.NoError(t, err)
+		require.Empty(t, activity)
+
+		boards, err := s.GetDeletedBoards(container.WorkspaceID)
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+		require.Equal(t, "board-2", boards[0].ID)
+
+		_, err = s.RestoreBoard(container, "board-1", "user-2")
+		require.True(t, errors.As(err, &notFoundErr))
+	})
+}
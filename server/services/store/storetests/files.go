@@ -0,0 +1,368 @@
+package storetests
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/mattermost/mattermost-server/v5/services/filesstore"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestFilesStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("FileRefs", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testFileRefs(t, store)
+	})
+	t.Run("GetWorkspaceStorageUsage", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetWorkspaceStorageUsage(t, store)
+	})
+	t.Run("GetWorkspaceFileRefs", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetWorkspaceFileRefs(t, store)
+	})
+	t.Run("FindOrphanedFiles", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testFindOrphanedFiles(t, store)
+	})
+	t.Run("ExportWorkspace", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testExportWorkspace(t, store)
+	})
+	t.Run("UploadSessions", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testUploadSessions(t, store)
+	})
+}
+
+func testFileRefs(t *testing.T, store store.Store) {
+	hash := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	refs := []model.FileRef{
+		{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: hash, CreateAt: 1},
+		{ID: "file-2.png", WorkspaceID: "workspace-1", RootID: "board-2", Hash: hash, CreateAt: 2},
+	}
+
+	t.Run("identical uploads share one blob", func(t *testing.T) {
+		created, err := store.CreateFileRef(refs[0], 3)
+		require.NoError(t, err)
+		require.True(t, created)
+
+		created, err = store.CreateFileRef(refs[1], 3)
+		require.NoError(t, err)
+		require.False(t, created)
+
+		blob, err := store.GetFileBlob(hash)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, blob.RefCount)
+		require.EqualValues(t, 3, blob.Size)
+
+		for _, ref := range refs {
+			result, err := store.GetFileRef(ref.ID)
+			require.NoError(t, err)
+			require.Equal(t, ref, *result)
+		}
+	})
+
+	removed := []string{}
+	removeBlob := func(hash string) error {
+		removed = append(removed, hash)
+		return nil
+	}
+
+	t.Run("the blob is kept until its last file is deleted", func(t *testing.T) {
+		require.NoError(t, store.DeleteFileRef(refs[0].ID, removeBlob))
+		require.Empty(t, removed)
+
+		blob, err := store.GetFileBlob(hash)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, blob.RefCount)
+
+		_, err = store.GetFileRef(refs[0].ID)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		// Nothing is deleted if the content can't be removed
+		err = store.DeleteFileRef(refs[1].ID, func(string) error { return errors.New("storage unavailable") })
+		require.EqualError(t, err, "storage unavailable")
+		blob, err = store.GetFileBlob(hash)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, blob.RefCount)
+		_, err = store.GetFileRef(refs[1].ID)
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteFileRef(refs[1].ID, removeBlob))
+		require.Equal(t, []string{hash}, removed)
+
+		_, err = store.GetFileBlob(hash)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("delete a missing file", func(t *testing.T) {
+		err := store.DeleteFileRef("missing.png", removeBlob)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func testGetWorkspaceStorageUsage(t *testing.T, store store.Store) {
+	usage, err := store.GetWorkspaceStorageUsage("workspace-1")
+	require.NoError(t, err)
+	require.Zero(t, usage)
+
+	for _, file := range []struct {
+		ref  model.FileRef
+		size int64
+	}{
+		{model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "hash-1", CreateAt: 1}, 100},
+		// The same content counts for each file
+		{model.FileRef{ID: "file-2.png", WorkspaceID: "workspace-1", RootID: "board-2", Hash: "hash-1", CreateAt: 2}, 100},
+		{model.FileRef{ID: "file-3.pdf", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "hash-2", CreateAt: 3}, 2500},
+		{model.FileRef{ID: "file-4.png", WorkspaceID: "workspace-2", RootID: "board-3", Hash: "hash-3", CreateAt: 4}, 700},
+	} {
+		_, err := store.CreateFileRef(file.ref, file.size)
+		require.NoError(t, err)
+	}
+
+	usage, err = store.GetWorkspaceStorageUsage("workspace-1")
+	require.NoError(t, err)
+	require.EqualValues(t, 2700, usage)
+
+	usage, err = store.GetWorkspaceStorageUsage("workspace-2")
+	require.NoError(t, err)
+	require.EqualValues(t, 700, usage)
+
+	require.NoError(t, store.DeleteFileRef("file-3.pdf", func(string) error { return nil }))
+	usage, err = store.GetWorkspaceStorageUsage("workspace-1")
+	require.NoError(t, err)
+	require.EqualValues(t, 200, usage)
+}
+
+func testGetWorkspaceFileRefs(t *testing.T, store store.Store) {
+	refs, err := store.GetWorkspaceFileRefs("workspace-1")
+	require.NoError(t, err)
+	require.Empty(t, refs)
+
+	created := []model.FileRef{
+		{ID: "file-2.png", WorkspaceID: "workspace-1", RootID: "board-2", Hash: "hash-1", CreateAt: 2},
+		{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "hash-1", CreateAt: 1},
+		{ID: "file-3.png", WorkspaceID: "workspace-2", RootID: "board-3", Hash: "hash-2", CreateAt: 3},
+	}
+	for _, ref := range created {
+		_, err := store.CreateFileRef(ref, 100)
+		require.NoError(t, err)
+	}
+
+	refs, err = store.GetWorkspaceFileRefs("workspace-1")
+	require.NoError(t, err)
+	require.Equal(t, []model.FileRef{created[1], created[0]}, refs)
+}
+
+func testUploadSessions(t *testing.T, store store.Store) {
+	stale := model.UploadSession{ID: "stale.png", WorkspaceID: "workspace-1", RootID: "board-1", Path: "uploads/stale.png", CreateAt: 100, UpdateAt: 100}
+	active := model.UploadSession{ID: "active.png", WorkspaceID: "workspace-1", RootID: "board-1", Path: "uploads/active.png", CreateAt: 100, UpdateAt: 300}
+	require.NoError(t, store.CreateUploadSession(stale))
+	require.NoError(t, store.CreateUploadSession(active))
+
+	sessions, err := store.GetAbandonedUploadSessions(200)
+	require.NoError(t, err)
+	require.Equal(t, []model.UploadSession{stale}, sessions)
+
+	require.NoError(t, store.DeleteUploadSession(stale.ID))
+
+	sessions, err = store.GetAbandonedUploadSessions(200)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	sessions, err = store.GetAbandonedUploadSessions(400)
+	require.NoError(t, err)
+	require.Equal(t, []model.UploadSession{active}, sessions)
+}
+
+func testFindOrphanedFiles(t *testing.T, store store.Store) {
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+	store.SetFilesBackend(filesBackend)
+
+	writeFiles := func(paths ...string) {
+		for _, path := range paths {
+			_, appErr := filesBackend.WriteFile(strings.NewReader("content of "+path), path)
+			require.Nil(t, appErr)
+		}
+	}
+
+	t.Run("empty storage", func(t *testing.T) {
+		orphans, err := store.FindOrphanedFiles()
+		require.NoError(t, err)
+		require.Empty(t, orphans)
+	})
+
+	_, err = store.CreateFileRef(model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "aa01", CreateAt: 1}, 3)
+	require.NoError(t, err)
+	_, err = store.CreateFileRef(model.FileRef{ID: "file-2.png", WorkspaceID: "workspace-2", RootID: "board-2", Hash: "bb01", CreateAt: 2}, 3)
+	require.NoError(t, err)
+	_, err = store.CreateFileRef(model.FileRef{ID: "file-3.png", WorkspaceID: "workspace-2", RootID: "board-2", Hash: "aa02", CreateAt: 3}, 3)
+	require.NoError(t, err)
+	// No file references the blob once its last file is deleted
+	require.NoError(t, store.DeleteFileRef("file-3.png", func(string) error { return nil }))
+
+	InsertBlocks(t, store, workspaceContainer("workspace-1"), []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board"},
+		{ID: "image-1", ParentID: "board-1", RootID: "board-1", Type: "image", Fields: map[string]interface{}{"fileId": "legacy.png"}},
+	})
+
+	writeFiles(
+		"blobs/aa/aa01",
+		"blobs/aa/aa02",
+		"blobs/bb/bb01",
+		"blobs/cc/cc01",
+		// The files uploaded before the blobs, referenced by a block of
+		// their board or not
+		"workspace-1/board-1/legacy.png",
+		"workspace-1/board-1/unused.png",
+		"workspace-2/board-2/legacy.png",
+		// Neither the uploads in progress nor the archives are files
+		"uploads/upload.png",
+		"archive/workspace-1/board-1.json",
+	)
+
+	t.Run("only the files without references", func(t *testing.T) {
+		orphans, err := store.FindOrphanedFiles()
+		require.NoError(t, err)
+
+		paths := []string{}
+		for _, info := range orphans {
+			paths = append(paths, info.Path)
+		}
+		require.Equal(t, []string{
+			"blobs/aa/aa02",
+			"blobs/cc/cc01",
+			"workspace-1/board-1/unused.png",
+			"workspace-2/board-2/legacy.png",
+		}, paths)
+
+		require.Equal(t, "aa02", orphans[0].Hash)
+		require.EqualValues(t, len("content of blobs/aa/aa02"), orphans[0].Size)
+		require.NotZero(t, orphans[0].UpdateAt)
+		require.Empty(t, orphans[2].Hash)
+
+		// Nothing is removed
+		for _, path := range paths {
+			require.FileExists(t, filepath.Join(filesPath, path))
+		}
+	})
+
+	t.Run("more blobs in a directory than a batch", func(t *testing.T) {
+		for i := 0; i < 1200; i++ {
+			writeFiles(fmt.Sprintf("blobs/dd/dd%04d", i))
+		}
+		_, err := store.CreateFileRef(model.FileRef{ID: "file-4.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "dd1100", CreateAt: 4}, 3)
+		require.NoError(t, err)
+
+		orphans, err := store.FindOrphanedFiles()
+		require.NoError(t, err)
+		require.Len(t, orphans, 4+1199)
+		for _, info := range orphans {
+			require.NotEqual(t, "dd1100", info.Hash)
+		}
+	})
+}
+
+func testExportWorkspace(t *testing.T, s store.Store) {
+	filesPath, err := ioutil.TempDir("", "focalboard-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(filesPath)
+
+	filesBackend, appErr := filesstore.NewFileBackend(filesstore.FileBackendSettings{DriverName: "local", Directory: filesPath})
+	require.Nil(t, appErr)
+	s.SetFilesBackend(filesBackend)
+
+	require.NoError(t, s.UpsertWorkspaceSettings(model.Workspace{ID: "workspace-1", Settings: map[string]interface{}{"theme": "dark"}}))
+	InsertBlocks(t, s, workspaceContainer("workspace-1"), []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", Title: "Roadmap"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", Title: "Launch"},
+		{ID: "image-1", RootID: "board-1", ParentID: "card-1", Type: "image", Fields: map[string]interface{}{"fileId": "file-1.png"}},
+		{ID: "board-2", RootID: "board-2", Type: "board", Title: "Bugs"},
+	})
+	InsertBlocks(t, s, workspaceContainer("workspace-2"), []model.Block{
+		{ID: "board-3", RootID: "board-3", Type: "board", Title: "Elsewhere"},
+	})
+	_, err = s.CreateFileRef(model.FileRef{ID: "file-1.png", WorkspaceID: "workspace-1", RootID: "board-1", Hash: "aa01", CreateAt: 1}, 5)
+	require.NoError(t, err)
+	_, err = s.CreateFileRef(model.FileRef{ID: "file-2.png", WorkspaceID: "workspace-2", RootID: "board-3", Hash: "bb01", CreateAt: 2}, 5)
+	require.NoError(t, err)
+	_, appErr = filesBackend.WriteFile(strings.NewReader("hello"), "blobs/aa/aa01")
+	require.Nil(t, appErr)
+
+	var bundle bytes.Buffer
+	require.NoError(t, s.ExportWorkspace("workspace-1", &bundle))
+
+	reader, err := zip.NewReader(bytes.NewReader(bundle.Bytes()), int64(bundle.Len()))
+	require.NoError(t, err)
+	entries := map[string][]byte{}
+	for _, file := range reader.File {
+		f, err := file.Open()
+		require.NoError(t, err)
+		entries[file.Name], err = ioutil.ReadAll(f)
+		require.NoError(t, err)
+		f.Close()
+	}
+
+	t.Run("the bundle has all the boards and files of the workspace", func(t *testing.T) {
+		names := []string{}
+		for name := range entries {
+			names = append(names, name)
+		}
+		require.ElementsMatch(t, []string{
+			store.BundleWorkspaceEntry,
+			store.BundleBoardsPrefix + "board-1.json",
+			store.BundleBoardsPrefix + "board-2.json",
+			store.BundleFilesEntry,
+			store.BundleFilesPrefix + "file-1.png",
+		}, names)
+
+		var workspace model.Workspace
+		require.NoError(t, json.Unmarshal(entries[store.BundleWorkspaceEntry], &workspace))
+		require.Equal(t, "workspace-1", workspace.ID)
+		require.Equal(t, map[string]interface{}{"theme": "dark"}, workspace.Settings)
+
+		var archive model.Archive
+		require.NoError(t, json.Unmarshal(entries[store.BundleBoardsPrefix+"board-1.json"], &archive))
+		require.EqualValues(t, model.ArchiveVersion, archive.Version)
+		require.Len(t, archive.Blocks, 3)
+
+		var refs []model.FileRef
+		require.NoError(t, json.Unmarshal(entries[store.BundleFilesEntry], &refs))
+		require.Len(t, refs, 1)
+		require.Equal(t, "file-1.png", refs[0].ID)
+		require.Equal(t, "hello", string(entries[store.BundleFilesPrefix+"file-1.png"]))
+	})
+
+	t.Run("a workspace without settings", func(t *testing.T) {
+		var empty bytes.Buffer
+		require.NoError(t, s.ExportWorkspace("workspace-3", &empty))
+
+		reader, err := zip.NewReader(bytes.NewReader(empty.Bytes()), int64(empty.Len()))
+		require.NoError(t, err)
+		require.Len(t, reader.File, 2)
+		require.Equal(t, store.BundleWorkspaceEntry, reader.File[0].Name)
+	})
+}
@@ -1,7 +1,9 @@
 package storetests
 
 import (
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/store"
@@ -18,6 +20,12 @@ func StoreTestSharingStore(t *testing.T, setup func(t *testing.T) (store.Store,
 		defer tearDown()
 		testUpsertSharingAndGetSharing(t, store, container)
 	})
+
+	t.Run("ExpiredSharingTokens", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testExpiredSharingTokens(t, store, container)
+	})
 }
 
 func testUpsertSharingAndGetSharing(t *testing.T, store store.Store, container store.Container) {
@@ -61,3 +69,66 @@ func testUpsertSharingAndGetSharing(t *testing.T, store store.Store, container s
 		require.Error(t, err)
 	})
 }
+
+func testExpiredSharingTokens(t *testing.T, store store.Store, container store.Container) {
+	now := time.Now().Unix()
+
+	otherContainer := container
+	otherContainer.WorkspaceID = "other-workspace"
+
+	sharings := []model.Sharing{
+		{ID: "never-expires", Enabled: true, Token: "token-1"},
+		{ID: "expires-later", Enabled: true, Token: "token-2", ExpireAt: now + 3600},
+		{ID: "expired", Enabled: true, Token: "token-3", ExpireAt: now - 3600},
+		{ID: "disabled", Enabled: false, Token: "token-4"},
+	}
+	for _, sharing := range sharings {
+		require.NoError(t, store.UpsertSharing(container, sharing))
+	}
+
+	otherSharings := []model.Sharing{
+		{ID: "other-workspace", Enabled: true, Token: "token-5"},
+		{ID: "other-workspace-expired", Enabled: true, Token: "token-6", ExpireAt: now - 60},
+	}
+	for _, sharing := range otherSharings {
+		require.NoError(t, store.UpsertSharing(otherContainer, sharing))
+	}
+
+	sharingIDs := func(sharings []model.Sharing) []string {
+		ids := []string{}
+		for _, sharing := range sharings {
+			ids = append(ids, sharing.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	t.Run("list active tokens", func(t *testing.T) {
+		active, err := store.GetActiveSharingTokens(container, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"expires-later", "never-expires"}, sharingIDs(active))
+	})
+
+	t.Run("list expired tokens", func(t *testing.T) {
+		expired, err := store.GetExpiredSharingTokens(now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"expired", "other-workspace-expired"}, sharingIDs(expired))
+	})
+
+	t.Run("purge expired tokens", func(t *testing.T) {
+		removed, err := store.DeleteExpiredSharingTokens(now)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, removed)
+
+		_, err = store.GetSharing(container, "expired")
+		require.Error(t, err)
+
+		sharing, err := store.GetSharing(container, "expires-later")
+		require.NoError(t, err)
+		require.Equal(t, now+3600, sharing.ExpireAt)
+
+		expired, err := store.GetExpiredSharingTokens(now)
+		require.NoError(t, err)
+		require.Empty(t, expired)
+	})
+}
@@ -0,0 +1,389 @@
+package storetests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestUsersStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("CreateUsers", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testCreateUsers(t, store)
+	})
+	t.Run("AnonymizeUser", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testAnonymizeUser(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("ReassignBoards", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testReassignBoards(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("DeleteUser", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testDeleteUser(t, store)
+	})
+	t.Run("DeleteUserReassigningBoards", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testDeleteUserReassigningBoards(t, store, workspaceContainer("workspace-1"))
+	})
+	t.Run("GetUsersByIDs", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetUsersByIDs(t, store)
+	})
+	t.Run("DeleteSession", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testDeleteSession(t, store)
+	})
+	t.Run("DeleteSessionsForDeletedUsers", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testDeleteSessionsForDeletedUsers(t, store)
+	})
+	t.Run("GetRecentSessions", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetRecentSessions(t, store)
+	})
+	t.Run("GetUserSessionIDs", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetUserSessionIDs(t, store)
+	})
+}
+
+func testCreateUsers(t *testing.T, store store.Store) {
+	t.Run("create a batch of users", func(t *testing.T) {
+		users := []model.User{
+			{ID: "user-1", Username: "jane", Email: "jane@example.com", Props: map[string]interface{}{}},
+			{ID: "user-2", Username: "john", Email: "john@example.com", Props: map[string]interface{}{}},
+		}
+		require.NoError(t, store.CreateUsers(users))
+
+		for _, user := range users {
+			created, err := store.GetUserByUsername(user.Username)
+			require.NoError(t, err)
+			require.Equal(t, user.ID, created.ID)
+			require.Equal(t, user.Email, created.Email)
+		}
+	})
+
+	t.Run("a conflict with an existing user aborts the batch", func(t *testing.T) {
+		users := []model.User{
+			{ID: "user-3", Username: "alice", Email: "alice@example.com", Props: map[string]interface{}{}},
+			{ID: "user-4", Username: "jane", Email: "jane2@example.com", Props: map[string]interface{}{}},
+		}
+		err := store.CreateUsers(users)
+		require.EqualError(t, err, `the username "jane" is already taken`)
+
+		_, err = store.GetUserByUsername("alice")
+		require.Error(t, err)
+	})
+
+	t.Run("an email conflict within the batch aborts it", func(t *testing.T) {
+		users := []model.User{
+			{ID: "user-5", Username: "bob", Email: "bob@example.com", Props: map[string]interface{}{}},
+			{ID: "user-6", Username: "robert", Email: "bob@example.com", Props: map[string]interface{}{}},
+		}
+		err := store.CreateUsers(users)
+		require.EqualError(t, err, `the email "bob@example.com" is already taken`)
+
+		_, err = store.GetUserByUsername("bob")
+		require.Error(t, err)
+	})
+}
+
+func testAnonymizeUser(t *testing.T, store store.Store, container store.Container) {
+	blocks := AssignedCardsBlocks()
+	for i := range blocks {
+		blocks[i].ModifiedBy = "user-2"
+		if blocks[i].Type == "comment" {
+			blocks[i].ModifiedBy = "user-1"
+			blocks[i].Title = "a comment"
+		}
+	}
+	InsertBlocks(t, store, container, blocks)
+
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	require.NoError(t, store.AnonymizeUser("user-1"))
+
+	_, err := store.GetSession("token-1", 60)
+	require.Error(t, err)
+	_, err = store.GetSession("token-2", 60)
+	require.NoError(t, err)
+
+	cards, err := store.GetCardsAssignedTo(container, "user-1")
+	require.NoError(t, err)
+	require.Empty(t, cards)
+
+	anonymized := map[string]model.Block{}
+	for _, rootID := range []string{"board-1", "board-2"} {
+		boardBlocks, err := store.GetBlocksWithRootID(container, rootID)
+		require.NoError(t, err)
+		for _, block := range boardBlocks {
+			require.NotEqual(t, "user-1", block.ModifiedBy)
+			anonymized[block.ID] = block
+		}
+	}
+	require.Len(t, anonymized, len(blocks))
+
+	comment := anonymized["comment"]
+	require.Equal(t, "deleted-user", comment.ModifiedBy)
+	require.Equal(t, "a comment", comment.Title)
+
+	properties := func(id string) map[string]interface{} {
+		properties, _ := anonymized[id].Fields["properties"].(map[string]interface{})
+		return properties
+	}
+	require.NotContains(t, properties("card-assigned-owner"), "owner")
+	require.Equal(t, []interface{}{"user-2"}, properties("card-assigned-reviewer")["reviewers"])
+	require.Equal(t, "user-2", properties("card-other-user")["owner"])
+	require.Equal(t, "user-2", anonymized["card-assigned-owner"].ModifiedBy)
+
+	// Only the values of person properties are identities
+	require.Equal(t, "user-1", properties("card-text")["notes"])
+	require.Equal(t, "user-1", properties("card-no-schema")["owner"])
+}
+
+func testReassignBoards(t *testing.T, store store.Store, container store.Container) {
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", ModifiedBy: "user-1", Type: "board"},
+		{ID: "board-2", RootID: "board-2", ModifiedBy: "user-1", Type: "board"},
+		{ID: "board-3", RootID: "board-3", ModifiedBy: "user-2", Type: "board"},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", ModifiedBy: "user-1", Type: "card"},
+	})
+
+	reassigned, err := store.ReassignBoards("user-1", "admin")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, reassigned)
+
+	owners := map[string]string{}
+	for _, rootID := range []string{"board-1", "board-2", "board-3"} {
+		blocks, err := store.GetBlocksWithRootID(container, rootID)
+		require.NoError(t, err)
+		for _, block := range blocks {
+			owners[block.ID] = block.ModifiedBy
+		}
+	}
+	require.Equal(t, map[string]string{
+		"board-1": "admin",
+		"board-2": "admin",
+		"board-3": "user-2",
+		"card-1":  "user-1",
+	}, owners)
+
+	// The reassignment is a new version of the boards
+	activity, err := store.GetBoardActivity(container, "board-1", 10, 0, "")
+	require.NoError(t, err)
+	require.Equal(t, "board-1", activity[0].BlockID)
+	require.Equal(t, "updated", activity[0].Action)
+	require.Equal(t, "admin", activity[0].ModifiedBy)
+
+	reassigned, err = store.ReassignBoards("user-1", "admin")
+	require.NoError(t, err)
+	require.Zero(t, reassigned)
+}
+
+func testDeleteUserReassigningBoards(t *testing.T, store store.Store, container store.Container) {
+	require.NoError(t, store.CreateUsers([]model.User{
+		{ID: "user-1", Username: "jane", Email: "jane@example.com", Props: map[string]interface{}{}},
+		{ID: "admin", Username: "admin", Email: "admin@example.com", Props: map[string]interface{}{}},
+	}))
+	InsertBlocks(t, store, container, []model.Block{
+		{ID: "board-1", RootID: "board-1", ModifiedBy: "user-1", Type: "board"},
+		{ID: "board-2", RootID: "board-2", ModifiedBy: "user-2", Type: "board"},
+	})
+
+	owner := func(boardID string) string {
+		blocks, err := store.GetBlocksByIDs(container, []string{boardID})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		return blocks[0].ModifiedBy
+	}
+
+	t.Run("nothing is reassigned for a missing user", func(t *testing.T) {
+		InsertBlocks(t, store, container, []model.Block{
+			{ID: "board-3", RootID: "board-3", ModifiedBy: "missing-user", Type: "board"},
+		})
+
+		_, err := store.DeleteUserReassigningBoards("missing-user", "admin")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.Equal(t, "missing-user", owner("board-3"))
+	})
+
+	t.Run("the boards are reassigned and the user deleted", func(t *testing.T) {
+		reassigned, err := store.DeleteUserReassigningBoards("user-1", "admin")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, reassigned)
+
+		require.Equal(t, "admin", owner("board-1"))
+		require.Equal(t, "user-2", owner("board-2"))
+		_, err = store.GetUserById("user-1")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func testDeleteUser(t *testing.T, store store.Store) {
+	require.NoError(t, store.CreateUsers([]model.User{
+		{ID: "user-1", Username: "jane", Email: "jane@example.com", Props: map[string]interface{}{}},
+		{ID: "user-2", Username: "john", Email: "john@example.com", Props: map[string]interface{}{}},
+	}))
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	require.NoError(t, store.DeleteUser("user-1"))
+
+	_, err := store.GetUserById("user-1")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetSession("token-1", 60)
+	require.Error(t, err)
+
+	_, err = store.GetUserById("user-2")
+	require.NoError(t, err)
+	_, err = store.GetSession("token-2", 60)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, store.DeleteUser("user-1"), sql.ErrNoRows)
+}
+
+func testDeleteSession(t *testing.T, store store.Store) {
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-1", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	// Only the session with the ID is deleted
+	require.NoError(t, store.DeleteSession("session-1"))
+	_, err := store.GetSession("token-1", 60)
+	require.Error(t, err)
+	_, err = store.GetSession("token-2", 60)
+	require.NoError(t, err)
+}
+
+func testDeleteSessionsForDeletedUsers(t *testing.T, store store.Store) {
+	require.NoError(t, store.CreateUsers([]model.User{
+		{ID: "user-1", Username: "jane", Email: "jane@example.com", Props: map[string]interface{}{}},
+		{ID: "user-2", Username: "john", Email: "john@example.com", Props: map[string]interface{}{}},
+	}))
+	require.NoError(t, store.DeleteUser("user-1"))
+
+	// Sessions left behind for a deleted user and one that doesn't exist
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{}},
+		{ID: "session-3", Token: "token-3", UserID: "unknown-user", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	removed, err := store.DeleteSessionsForDeletedUsers()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, removed)
+
+	_, err = store.GetSession("token-1", 60)
+	require.Error(t, err)
+	_, err = store.GetSession("token-3", 60)
+	require.Error(t, err)
+	_, err = store.GetSession("token-2", 60)
+	require.NoError(t, err)
+
+	removed, err = store.DeleteSessionsForDeletedUsers()
+	require.NoError(t, err)
+	require.Zero(t, removed)
+}
+
+func testGetUsersByIDs(t *testing.T, store store.Store) {
+	require.NoError(t, store.CreateUsers([]model.User{
+		{ID: "user-1", Username: "jane", Email: "jane@example.com", Props: map[string]interface{}{"avatarUrl": "https://example.com/jane.png"}},
+		{ID: "user-2", Username: "john", Email: "john@example.com", Props: map[string]interface{}{}},
+		{ID: "user-3", Username: "alice", Email: "alice@example.com", Props: map[string]interface{}{}},
+	}))
+	require.NoError(t, store.DeleteUser("user-3"))
+
+	users, err := store.GetUsersByIDs([]string{"user-1", "missing-user", "user-2", "user-3"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []model.UserDisplay{
+		{ID: "user-1", Username: "jane", AvatarURL: "https://example.com/jane.png"},
+		{ID: "user-2", Username: "john"},
+	}, users)
+
+	users, err = store.GetUsersByIDs([]string{})
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func testGetRecentSessions(t *testing.T, store store.Store) {
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{"key": "value"}},
+		{ID: "session-3", Token: "token-3", UserID: "user-3", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	sessions, err := store.GetRecentSessions(60, 10)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	for _, session := range sessions {
+		if session.ID == "session-2" {
+			require.Equal(t, "token-2", session.Token)
+			require.Equal(t, "user-2", session.UserID)
+			require.Equal(t, map[string]interface{}{"key": "value"}, session.Props)
+		}
+	}
+
+	sessions, err = store.GetRecentSessions(60, 2)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	// A negative window starts after now
+	sessions, err = store.GetRecentSessions(-60, 10)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func testGetUserSessionIDs(t *testing.T, store store.Store) {
+	for _, session := range []model.Session{
+		{ID: "session-1", Token: "token-1", UserID: "user-1", Props: map[string]interface{}{}},
+		{ID: "session-2", Token: "token-2", UserID: "user-2", Props: map[string]interface{}{}},
+		{ID: "session-3", Token: "token-3", UserID: "user-1", Props: map[string]interface{}{}},
+	} {
+		session := session
+		require.NoError(t, store.CreateSession(&session))
+	}
+
+	ids, err := store.GetUserSessionIDs("user-1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"session-1", "session-3"}, ids)
+
+	ids, err = store.GetUserSessionIDs("user-4")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
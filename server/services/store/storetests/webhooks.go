@@ -0,0 +1,57 @@
+package storetests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestWorkspaceWebhooksStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("WorkspaceWebhooks", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testWorkspaceWebhooks(t, store)
+	})
+}
+
+func testWorkspaceWebhooks(t *testing.T, store store.Store) {
+	webhooks := []model.WorkspaceWebhook{
+		{ID: "webhook-1", WorkspaceID: "workspace-1", URL: "https://example.com/1", CreatedBy: "user-id", CreateAt: 1},
+		{ID: "webhook-2", WorkspaceID: "workspace-1", URL: "https://example.com/2", CreatedBy: "user-id", CreateAt: 2},
+		{ID: "webhook-3", WorkspaceID: "workspace-2", URL: "https://example.com/3", CreatedBy: "user-id", CreateAt: 3},
+	}
+	for _, webhook := range webhooks {
+		require.NoError(t, store.CreateWorkspaceWebhook(webhook))
+	}
+
+	t.Run("get the webhooks of a workspace", func(t *testing.T) {
+		result, err := store.GetWorkspaceWebhooks("workspace-1")
+		require.NoError(t, err)
+		require.Equal(t, webhooks[:2], result)
+
+		result, err = store.GetWorkspaceWebhooks("workspace-3")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("delete a webhook of another workspace", func(t *testing.T) {
+		err := store.DeleteWorkspaceWebhook("workspace-1", "webhook-3")
+		require.Equal(t, sql.ErrNoRows, err)
+
+		result, err := store.GetWorkspaceWebhooks("workspace-2")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+	})
+
+	t.Run("delete a webhook", func(t *testing.T) {
+		err := store.DeleteWorkspaceWebhook("workspace-1", "webhook-1")
+		require.NoError(t, err)
+
+		result, err := store.GetWorkspaceWebhooks("workspace-1")
+		require.NoError(t, err)
+		require.Equal(t, webhooks[1:2], result)
+	})
+}
@@ -0,0 +1,73 @@
+package storetests
+
+import (
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/store"
+	"github.com/stretchr/testify/require"
+)
+
+func StoreTestWorkspacesStore(t *testing.T, setup func(t *testing.T) (store.Store, func())) {
+	t.Run("GetWorkspacesByActivity", func(t *testing.T) {
+		store, tearDown := setup(t)
+		defer tearDown()
+		testGetWorkspacesByActivity(t, store)
+	})
+}
+
+func workspaceContainer(workspaceID string) store.Container {
+	return store.Container{WorkspaceID: workspaceID}
+}
+
+func testGetWorkspacesByActivity(t *testing.T, store store.Store) {
+	InsertBlocks(t, store, workspaceContainer("workspace-1"), []model.Block{
+		{ID: "board-1", RootID: "board-1", Type: "board", ModifiedBy: "user-1", UpdateAt: 100},
+		{ID: "card-1", RootID: "board-1", ParentID: "board-1", Type: "card", ModifiedBy: "user-2", UpdateAt: 400},
+	})
+	InsertBlocks(t, store, workspaceContainer("workspace-2"), []model.Block{
+		{ID: "board-2", RootID: "board-2", Type: "board", ModifiedBy: "user-1", UpdateAt: 300},
+	})
+	InsertBlocks(t, store, workspaceContainer("workspace-3"), []model.Block{
+		{ID: "board-3", RootID: "board-3", Type: "board", ModifiedBy: "user-1", UpdateAt: 200},
+	})
+	InsertBlocks(t, store, workspaceContainer("workspace-4"), []model.Block{
+		{ID: "board-4", RootID: "board-4", Type: "board", ModifiedBy: "user-2", UpdateAt: 500},
+	})
+	InsertBlocks(t, store, workspaceContainer("workspace-5"), []model.Block{
+		{ID: "board-5", RootID: "board-5", Type: "board", ModifiedBy: "user-1", UpdateAt: 600},
+	})
+	require.NoError(t, store.ArchiveBoard(workspaceContainer("workspace-5"), "board-5", func([]model.Block) error { return nil }))
+
+	t.Run("ordered by the latest change of anyone", func(t *testing.T) {
+		activities, err := store.GetWorkspacesByActivity("user-1", 10)
+		require.NoError(t, err)
+		require.Equal(t, []model.WorkspaceActivity{
+			{WorkspaceID: "workspace-1", LastActivityAt: 400},
+			{WorkspaceID: "workspace-2", LastActivityAt: 300},
+			{WorkspaceID: "workspace-3", LastActivityAt: 200},
+		}, activities)
+	})
+
+	t.Run("respects the limit", func(t *testing.T) {
+		activities, err := store.GetWorkspacesByActivity("user-1", 2)
+		require.NoError(t, err)
+		require.Len(t, activities, 2)
+		require.Equal(t, "workspace-1", activities[0].WorkspaceID)
+		require.Equal(t, "workspace-2", activities[1].WorkspaceID)
+	})
+
+	t.Run("without a limit", func(t *testing.T) {
+		all, err := store.GetWorkspacesByActivity("user-1", 10)
+		require.NoError(t, err)
+		activities, err := store.GetWorkspacesByActivity("user-1", 0)
+		require.NoError(t, err)
+		require.Equal(t, all, activities)
+	})
+
+	t.Run("user without activity", func(t *testing.T) {
+		activities, err := store.GetWorkspacesByActivity("user-3", 10)
+		require.NoError(t, err)
+		require.Empty(t, activities)
+	})
+}
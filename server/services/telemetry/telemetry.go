@@ -4,9 +4,13 @@
 package telemetry
 
 import (
+	"context"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/mattermost/focalboard/server/services/scheduler"
@@ -27,6 +31,18 @@ type Service struct {
 	rudderClient               rudder.Client
 	telemetryID                string
 	timestampLastTelemetrySent time.Time
+	task                       *scheduler.ScheduledTask
+
+	// Timeout bounds each request to the telemetry endpoint. The client
+	// never waits more than 10 seconds anyway.
+	Timeout time.Duration
+
+	// pending is the number of events enqueued and not sent or discarded
+	// yet, a new report is skipped until they're done
+	pending int32
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type RudderConfig struct {
@@ -35,10 +51,13 @@ type RudderConfig struct {
 }
 
 func New(telemetryID string, log *log.Logger) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
 	service := &Service{
 		log:         log,
 		telemetryID: telemetryID,
 		trackers:    map[string]Tracker{},
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 
 	return service
@@ -72,7 +91,7 @@ func (ts *Service) sendDailyTelemetry(override bool) {
 func (ts *Service) sendTelemetry(event string, properties map[string]interface{}) {
 	if ts.rudderClient != nil {
 		var context *rudder.Context
-		ts.rudderClient.Enqueue(rudder.Track{
+		ts.enqueue(rudder.Track{
 			Event:      event,
 			UserId:     ts.telemetryID,
 			Properties: properties,
@@ -81,11 +100,24 @@ func (ts *Service) sendTelemetry(event string, properties map[string]interface{}
 	}
 }
 
+func (ts *Service) enqueue(message rudder.Message) {
+	atomic.AddInt32(&ts.pending, 1)
+	if err := ts.rudderClient.Enqueue(message); err != nil {
+		atomic.AddInt32(&ts.pending, -1)
+	}
+}
+
 func (ts *Service) initRudder(endpoint, rudderKey string) {
 	if ts.rudderClient == nil {
 		config := rudder.Config{}
 		config.Logger = rudder.StdLogger(ts.log)
 		config.Endpoint = endpoint
+		config.Callback = reportCallback{ts}
+		config.Transport = &cancelableTransport{
+			ctx:     ts.ctx,
+			timeout: ts.Timeout,
+			base:    http.DefaultTransport,
+		}
 		// For testing
 		if endpoint != rudderDataplaneURL {
 			config.Verbose = true
@@ -97,11 +129,11 @@ func (ts *Service) initRudder(endpoint, rudderKey string) {
 
 			return
 		}
-		client.Enqueue(rudder.Identify{
+		ts.rudderClient = client
+
+		ts.enqueue(rudder.Identify{
 			UserId: ts.telemetryID,
 		})
-
-		ts.rudderClient = client
 	}
 }
 
@@ -122,21 +154,93 @@ func (ts *Service) doTelemetryIfNeeded(firstRun time.Time) {
 func (ts *Service) RunTelemetryJob(firstRun int64) {
 	// Send on boot
 	ts.doTelemetry()
-	scheduler.CreateRecurringTask("Telemetry", func() {
+	ts.task = scheduler.CreateRecurringTask("Telemetry", func() {
 		ts.doTelemetryIfNeeded(time.Unix(0, firstRun*int64(time.Millisecond)))
 	}, timeBetweenTelemetryChecks)
 }
 
+// PendingEvents returns the number of telemetry events enqueued and not
+// sent or discarded yet
+func (ts *Service) PendingEvents() int {
+	return int(atomic.LoadInt32(&ts.pending))
+}
+
 func (ts *Service) doTelemetry() {
+	if pending := atomic.LoadInt32(&ts.pending); pending > 0 {
+		ts.log.Printf("Skipping the telemetry report, %d events of the previous one are still being sent", pending)
+
+		return
+	}
+
 	ts.timestampLastTelemetrySent = time.Now()
 	ts.sendDailyTelemetry(false)
 }
 
-// Shutdown closes the telemetry client.
+// Shutdown stops the telemetry job and closes the telemetry client. The
+// requests in flight are cancelled, so it doesn't wait for a slow endpoint.
 func (ts *Service) Shutdown() error {
+	if ts.task != nil {
+		ts.task.Cancel()
+	}
+	ts.cancel()
+
 	if ts.rudderClient != nil {
 		return ts.rudderClient.Close()
 	}
 
 	return nil
 }
+
+// reportCallback keeps the count of the events still being sent
+type reportCallback struct {
+	ts *Service
+}
+
+func (rc reportCallback) Success(rudder.Message) {
+	atomic.AddInt32(&rc.ts.pending, -1)
+}
+
+func (rc reportCallback) Failure(message rudder.Message, err error) {
+	atomic.AddInt32(&rc.ts.pending, -1)
+}
+
+// cancelableTransport bounds each request by the timeout, and cancels all of
+// them once the context is done
+type cancelableTransport struct {
+	ctx     context.Context
+	timeout time.Duration
+	base    http.RoundTripper
+}
+
+func (t *cancelableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(t.ctx, t.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(t.ctx)
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a request once its response is read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+
+	return err
+}
@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHangingEndpoint(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	release := make(chan struct{})
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read
+		ioutil.ReadAll(r.Body)
+
+		select {
+		case requests <- r:
+		default:
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer endpoint.Close()
+	defer close(release)
+
+	ts := New("telemetry-id", log.New(ioutil.Discard, "", 0))
+	ts.Timeout = 50 * time.Millisecond
+	ts.RegisterTracker("server", func() map[string]interface{} {
+		return map[string]interface{}{"version": "test"}
+	})
+
+	ts.initRudder(endpoint.URL, "rudder-key")
+	ts.sendTelemetry("server", map[string]interface{}{})
+
+	var request *http.Request
+	select {
+	case request = <-requests:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the report wasn't sent")
+	}
+
+	t.Run("the report times out", func(t *testing.T) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(time.Second):
+			require.Fail(t, "the request didn't time out")
+		}
+	})
+
+	t.Run("a new report is skipped while the previous one is in flight", func(t *testing.T) {
+		require.Greater(t, atomic.LoadInt32(&ts.pending), int32(0))
+
+		lastSent := ts.timestampLastTelemetrySent
+		ts.doTelemetry()
+		require.Equal(t, lastSent, ts.timestampLastTelemetrySent)
+	})
+
+	t.Run("shutdown isn't blocked", func(t *testing.T) {
+		done := make(chan error)
+		go func() {
+			done <- ts.Shutdown()
+		}()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "the shutdown was blocked by the endpoint")
+		}
+	})
+}
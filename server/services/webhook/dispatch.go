@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+)
+
+const (
+	defaultWebhookWorkers   = 8
+	defaultWebhookQueueSize = 1000
+	defaultWebhookTimeout   = 10 * time.Second
+
+	// shutdownCancelWait is how long the shutdown waits for the deliveries it
+	// cancelled at the timeout to return
+	shutdownCancelWait = time.Second
+)
+
+// WorkerStats are the state of the workers delivering the webhooks
+type WorkerStats struct {
+	ActiveWorkers int
+	QueuedEvents  int
+	DroppedEvents int64
+}
+
+type queuedEvent struct {
+	workspaceID string
+	block       model.Block
+	requestID   string
+}
+
+// Dispatch queues the notification of a change to be delivered in the
+// background. The events are delivered by a bounded number of workers,
+// started when needed, and the events that don't fit in the queue are
+// dropped, so a flood of changes can't start unbounded goroutines.
+func (wh *Client) Dispatch(workspaceID string, block model.Block, requestID string) {
+	if len(wh.config.WebhookUpdate) == 0 && wh.store == nil {
+		return
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	if wh.closed {
+		wh.dropped++
+		log.Printf("webhook.Dispatch: shutting down, dropped the event of block %s, requestID: %s", block.ID, requestID)
+		return
+	}
+
+	if len(wh.queue) >= wh.maxQueued {
+		wh.dropped++
+		log.Printf("webhook.Dispatch: the queue is full, dropped the event of block %s, requestID: %s, %d dropped so far", block.ID, requestID, wh.dropped)
+		return
+	}
+
+	wh.queue = append(wh.queue, queuedEvent{workspaceID: workspaceID, block: block, requestID: requestID})
+	if wh.workers < wh.maxWorkers {
+		wh.workers++
+		wh.working.Add(1)
+		go wh.work()
+	}
+}
+
+// work delivers the queued events until there are none left
+func (wh *Client) work() {
+	for {
+		wh.mu.Lock()
+		if len(wh.queue) == 0 {
+			wh.workers--
+			wh.mu.Unlock()
+			wh.working.Done()
+			return
+		}
+		event := wh.queue[0]
+		wh.queue[0] = queuedEvent{}
+		wh.queue = wh.queue[1:]
+		wh.mu.Unlock()
+
+		wh.NotifyUpdate(event.workspaceID, event.block, event.requestID)
+	}
+}
+
+// Stats returns the number of workers delivering the webhooks and of the
+// events waiting for one
+func (wh *Client) Stats() WorkerStats {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	return WorkerStats{
+		ActiveWorkers: wh.workers,
+		QueuedEvents:  len(wh.queue),
+		DroppedEvents: wh.dropped,
+	}
+}
+
+// Shutdown stops queueing events and waits for the workers to deliver the
+// queued ones. Once the timeout passes the events still queued are dropped
+// and the deliveries in progress are cancelled, so a webhook that never
+// replies doesn't hold up the shutdown.
+func (wh *Client) Shutdown(timeout time.Duration) error {
+	wh.mu.Lock()
+	wh.closed = true
+	wh.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		wh.working.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	wh.mu.Lock()
+	pending := len(wh.queue) + wh.workers
+	wh.dropped += int64(pending)
+	wh.queue = nil
+	wh.mu.Unlock()
+	wh.cancel()
+
+	select {
+	case <-done:
+	case <-time.After(shutdownCancelWait):
+		log.Printf("webhook.Shutdown: the cancelled deliveries didn't return, not waiting for them")
+	}
+
+	if pending == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d webhook events not delivered before the shutdown timeout", pending)
+}
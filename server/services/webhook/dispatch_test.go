@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatch(t *testing.T) {
+	release := make(chan struct{})
+	var delivered int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer ts.Close()
+
+	client := NewClient(&config.Configuration{
+		WebhookUpdate:              []string{ts.URL},
+		WebhookAllowPrivateTargets: true,
+		WebhookWorkers:             2,
+		WebhookQueueSize:           5,
+	}, nil)
+
+	const events = 50
+	for i := 0; i < events; i++ {
+		client.Dispatch("0", model.Block{ID: "card-id"}, "")
+		require.LessOrEqual(t, client.Stats().ActiveWorkers, 2)
+	}
+
+	stats := client.Stats()
+	require.Equal(t, 2, stats.ActiveWorkers)
+	require.LessOrEqual(t, stats.QueuedEvents, 5)
+	// At most the two events taken by the workers don't count against the
+	// queue
+	require.GreaterOrEqual(t, stats.DroppedEvents, int64(events-5-2))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return client.Stats().ActiveWorkers == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats = client.Stats()
+	require.Zero(t, stats.QueuedEvents)
+	require.EqualValues(t, events, int64(atomic.LoadInt32(&delivered))+stats.DroppedEvents)
+}
+
+func TestDispatchWithoutWebhooks(t *testing.T) {
+	client := NewClient(&config.Configuration{}, nil)
+	client.Dispatch("0", model.Block{ID: "card-id"}, "")
+
+	require.Equal(t, WorkerStats{}, client.Stats())
+}
+
+func TestShutdown(t *testing.T) {
+	newClient := func(url string) *Client {
+		return NewClient(&config.Configuration{
+			WebhookUpdate:              []string{url},
+			WebhookAllowPrivateTargets: true,
+			WebhookWorkers:             1,
+			WebhookQueueSize:           5,
+		}, nil)
+	}
+
+	t.Run("the queued events are delivered", func(t *testing.T) {
+		var delivered int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&delivered, 1)
+		}))
+		defer ts.Close()
+
+		client := newClient(ts.URL)
+		for i := 0; i < 3; i++ {
+			client.Dispatch("0", model.Block{ID: "card-id"}, "")
+		}
+
+		require.NoError(t, client.Shutdown(5*time.Second))
+		require.EqualValues(t, 3, atomic.LoadInt32(&delivered))
+		require.Zero(t, client.Stats().ActiveWorkers)
+
+		// The events after the shutdown are dropped
+		client.Dispatch("0", model.Block{ID: "card-id"}, "")
+		require.Equal(t, WorkerStats{DroppedEvents: 1}, client.Stats())
+	})
+
+	t.Run("the events still queued at the timeout are dropped", func(t *testing.T) {
+		release := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer ts.Close()
+
+		client := newClient(ts.URL)
+		for i := 0; i < 3; i++ {
+			client.Dispatch("0", model.Block{ID: "card-id"}, "")
+		}
+		require.Eventually(t, func() bool {
+			return client.Stats().QueuedEvents == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		defer close(release)
+		// And the delivery in progress is cancelled
+		require.EqualError(t, client.Shutdown(10*time.Millisecond), "3 webhook events not delivered before the shutdown timeout")
+
+		stats := client.Stats()
+		require.Zero(t, stats.ActiveWorkers)
+		require.Zero(t, stats.QueuedEvents)
+		require.EqualValues(t, 3, stats.DroppedEvents)
+	})
+
+	t.Run("a webhook that never replies doesn't hold up the shutdown", func(t *testing.T) {
+		release := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer ts.Close()
+		defer close(release)
+
+		client := newClient(ts.URL)
+		client.Dispatch("0", model.Block{ID: "card-id"}, "")
+		require.Eventually(t, func() bool {
+			return client.Stats().QueuedEvents == 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		start := time.Now()
+		require.Error(t, client.Shutdown(10*time.Millisecond))
+		require.Less(t, int64(time.Since(start)), int64(shutdownCancelWait+time.Second))
+	})
+}
+
+func TestDeliveryTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := NewClient(&config.Configuration{
+		WebhookUpdate:              []string{ts.URL},
+		WebhookAllowPrivateTargets: true,
+	}, nil)
+	client.httpClient.Timeout = 50 * time.Millisecond
+
+	// The worker gives up on the webhook rather than waiting for it forever
+	client.Dispatch("0", model.Block{ID: "card-id"}, "")
+	require.Eventually(t, func() bool {
+		return client.Stats().ActiveWorkers == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
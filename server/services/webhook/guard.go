@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrPrivateTarget is returned when registering a webhook whose host only
+// resolves to blocked addresses
+var ErrPrivateTarget = errors.New("the webhook target resolves to a private address")
+
+// blockedNetworks are the private, loopback, link-local, multicast and
+// reserved ranges the webhooks can't be delivered to unless
+// WebhookAllowPrivateTargets is set. The NAT64 and 6to4 ranges are blocked
+// whole, as they reach the IPv4 addresses embedded in them. The IPv4
+// addresses mapped to IPv6 are matched by the IPv4 ranges.
+var blockedNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// guardedDialer connects to the webhooks only at the addresses that aren't
+// blocked. The host is resolved once and the connection is made to the
+// address that was checked, so the name can't be rebound to a blocked
+// address in between.
+type guardedDialer struct {
+	allowPrivate bool
+	lookupIPAddr func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dial         func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+func (d *guardedDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := d.lookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		if d.allowPrivate || !isBlockedIP(addr.IP) {
+			return d.dial(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		}
+	}
+
+	return nil, fmt.Errorf("webhook target %s resolves to a private address", host)
+}
+
+// CheckTarget checks the host of a webhook URL resolves to an address the
+// webhooks can be delivered to, so the webhooks that would always be
+// refused aren't registered. The deliveries are still checked, as the name
+// can be rebound later.
+func (wh *Client) CheckTarget(ctx context.Context, webhookURL string) error {
+	if wh.config.WebhookAllowPrivateTargets {
+		return nil
+	}
+
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := wh.lookupIPAddr(ctx, parsed.Hostname())
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if !isBlockedIP(addr.IP) {
+			return nil
+		}
+	}
+
+	return ErrPrivateTarget
+}
+
+// newHTTPClient creates the client the webhooks are delivered with, which
+// gives up on a delivery after the timeout. It doesn't use the proxy of the
+// environment, as the guard would check the address of the proxy instead of
+// the one of the webhook.
+func newHTTPClient(allowPrivate bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	guard := &guardedDialer{
+		allowPrivate: allowPrivate,
+		lookupIPAddr: net.DefaultResolver.LookupIPAddr,
+		dial:         dialer.DialContext,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           guard.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: timeout,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
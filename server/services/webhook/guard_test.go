@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/stretchr/testify/require"
+)
+
+var errDialed = errors.New("dialed")
+
+func TestGuardedDialer(t *testing.T) {
+	hosts := map[string][]string{
+		"public.example":   {"93.184.216.34"},
+		"metadata.example": {"169.254.169.254"},
+		"internal.example": {"10.0.0.5"},
+		"localhost":        {"127.0.0.1", "::1"},
+		"mixed.example":    {"192.168.1.10", "93.184.216.34"},
+	}
+
+	var dialed []string
+	newDialer := func(allowPrivate bool) *guardedDialer {
+		return &guardedDialer{
+			allowPrivate: allowPrivate,
+			lookupIPAddr: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+				if ip := net.ParseIP(host); ip != nil {
+					return []net.IPAddr{{IP: ip}}, nil
+				}
+				addrs := []net.IPAddr{}
+				for _, ip := range hosts[host] {
+					addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+				}
+				return addrs, nil
+			},
+			dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialed = append(dialed, address)
+				return nil, errDialed
+			},
+		}
+	}
+
+	t.Run("public targets are allowed", func(t *testing.T) {
+		dialed = nil
+		_, err := newDialer(false).DialContext(context.Background(), "tcp", "public.example:443")
+		require.Equal(t, errDialed, err)
+
+		// The checked address is the one connected to
+		require.Equal(t, []string{"93.184.216.34:443"}, dialed)
+	})
+
+	t.Run("private targets are blocked", func(t *testing.T) {
+		dialed = nil
+		for _, address := range []string{
+			"metadata.example:80",
+			"169.254.169.254:80",
+			"internal.example:80",
+			"10.1.2.3:80",
+			"localhost:8000",
+			"[::1]:8000",
+			"192.0.0.8:80",
+			"198.18.0.1:80",
+			"224.0.0.1:80",
+			"255.255.255.255:80",
+			"[ff02::1]:80",
+			// 127.0.0.1 and 169.254.169.254 through NAT64, 6to4 and as mapped
+			// IPv4 addresses
+			"[64:ff9b::7f00:1]:80",
+			"[64:ff9b::a9fe:a9fe]:80",
+			"[2002:7f00:1::1]:80",
+			"[::ffff:127.0.0.1]:80",
+			"[::ffff:169.254.169.254]:80",
+		} {
+			_, err := newDialer(false).DialContext(context.Background(), "tcp", address)
+			require.Error(t, err, address)
+			require.NotEqual(t, errDialed, err, address)
+		}
+		require.Empty(t, dialed)
+	})
+
+	t.Run("only the public addresses of a host are used", func(t *testing.T) {
+		dialed = nil
+		_, err := newDialer(false).DialContext(context.Background(), "tcp", "mixed.example:80")
+		require.Equal(t, errDialed, err)
+		require.Equal(t, []string{"93.184.216.34:80"}, dialed)
+	})
+
+	t.Run("private targets allowed by the configuration", func(t *testing.T) {
+		dialed = nil
+		_, err := newDialer(true).DialContext(context.Background(), "tcp", "metadata.example:80")
+		require.Equal(t, errDialed, err)
+		require.Equal(t, []string{"169.254.169.254:80"}, dialed)
+	})
+}
+
+func TestNotifyUpdatePrivateTarget(t *testing.T) {
+	calls := make(chan string, 1)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- r.URL.Path
+	}))
+	defer sink.Close()
+
+	client := NewClient(&config.Configuration{WebhookUpdate: []string{sink.URL}}, nil)
+	client.NotifyUpdate("workspace-id", model.Block{ID: "card-id"}, "")
+	close(calls)
+
+	_, called := <-calls
+	require.False(t, called)
+}
+
+func TestCheckTarget(t *testing.T) {
+	hosts := map[string][]string{
+		"public.example":   {"93.184.216.34"},
+		"internal.example": {"10.0.0.5"},
+		"mixed.example":    {"192.168.1.10", "93.184.216.34"},
+	}
+
+	newClient := func(allowPrivate bool) *Client {
+		client := NewClient(&config.Configuration{WebhookAllowPrivateTargets: allowPrivate}, nil)
+		client.lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			ips, ok := hosts[host]
+			if !ok {
+				return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+			addrs := []net.IPAddr{}
+			for _, ip := range ips {
+				addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+			}
+			return addrs, nil
+		}
+		return client
+	}
+
+	ctx := context.Background()
+	for _, url := range []string{"https://public.example/hook", "http://mixed.example:8080/hook"} {
+		require.NoError(t, newClient(false).CheckTarget(ctx, url), url)
+	}
+
+	require.Equal(t, ErrPrivateTarget, newClient(false).CheckTarget(ctx, "https://internal.example/hook"))
+	require.NoError(t, newClient(true).CheckTarget(ctx, "https://internal.example/hook"))
+
+	var dnsErr *net.DNSError
+	require.True(t, errors.As(newClient(false).CheckTarget(ctx, "https://missing.example/hook"), &dnsErr))
+}
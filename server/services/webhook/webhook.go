@@ -2,38 +2,185 @@ package webhook
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"log"
+	"net"
 	"net/http"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/mattermost/focalboard/server/model"
 	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/utils"
 )
 
-// NotifyUpdate calls webhooks
-func (wh *Client) NotifyUpdate(block model.Block) {
-	if len(wh.config.WebhookUpdate) < 1 {
+// Event is the data the payload templates are rendered with
+type Event struct {
+	Type      string
+	Block     model.Block
+	RequestID string
+}
+
+// WorkspaceWebhookStore provides the webhooks registered by the workspaces
+type WorkspaceWebhookStore interface {
+	GetWorkspaceWebhooks(workspaceID string) ([]model.WorkspaceWebhook, error)
+}
+
+// NotifyUpdate calls the webhooks of the configuration and the ones
+// registered by the workspace of the block. The ID of the request that
+// changed the block, if any, is sent in the X-Focalboard-Request-ID header.
+func (wh *Client) NotifyUpdate(workspaceID string, block model.Block, requestID string) {
+	urls := wh.webhookURLs(workspaceID)
+	if len(urls) < 1 {
 		return
 	}
 
-	json, err := json.Marshal(block)
+	event := Event{Type: "update", Block: block, RequestID: requestID}
+	for _, url := range urls {
+		payload, err := wh.render(url, event)
+		if err != nil {
+			log.Printf("webhook.NotifyUpdate: unable to render the payload for %s, requestID: %s: %v", url, requestID, err)
+			continue
+		}
+
+		request, err := http.NewRequestWithContext(wh.ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+		if err != nil {
+			log.Printf("webhook.NotifyUpdate: invalid URL %s, requestID: %s: %v", url, requestID, err)
+			continue
+		}
+		request.Header.Set("Content-Type", "application/json")
+		if wh.config.WebhookRequestID && requestID != "" {
+			request.Header.Set(utils.RequestIDHeader, requestID)
+		}
+
+		response, err := wh.httpClient.Do(request)
+		if err != nil {
+			log.Printf("webhook.NotifyUpdate: unable to deliver to %s, requestID: %s: %v", url, requestID, err)
+			continue
+		}
+		response.Body.Close()
+		log.Printf("webhook.NotifyUpdate: %s, requestID: %s", url, requestID)
+	}
+}
+
+// webhookURLs returns the URLs to notify of the changes in the workspace,
+// calling each one once even if it's also in the configuration
+func (wh *Client) webhookURLs(workspaceID string) []string {
+	urls := append([]string{}, wh.config.WebhookUpdate...)
+	if wh.store == nil {
+		return urls
+	}
+
+	webhooks, err := wh.store.GetWorkspaceWebhooks(workspaceID)
 	if err != nil {
-		log.Fatal("NotifyUpdate: json.Marshal", err)
+		log.Printf("webhook.NotifyUpdate: unable to get the webhooks of workspace %s: %v", workspaceID, err)
+		return urls
+	}
+
+	seen := map[string]bool{}
+	for _, url := range urls {
+		seen[url] = true
 	}
-	for _, url := range wh.config.WebhookUpdate {
-		http.Post(url, "application/json", bytes.NewBuffer(json))
-		log.Printf("webhook.NotifyUpdate: %s", url)
+	for _, webhook := range webhooks {
+		if !seen[webhook.URL] {
+			seen[webhook.URL] = true
+			urls = append(urls, webhook.URL)
+		}
 	}
+
+	return urls
 }
 
+// render builds the payload of the event for the webhook URL, with its
+// template if it has one
+func (wh *Client) render(url string, event Event) ([]byte, error) {
+	tmpl, ok := wh.templates[url]
+	if !ok {
+		tmpl = wh.defaultTemplate
+	}
+
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, event); err != nil {
+		return nil, err
+	}
+
+	return payload.Bytes(), nil
+}
+
+// defaultWebhookTemplate renders the payloads of the webhooks without a
+// template of their own
+var defaultWebhookTemplate = config.WebhookTemplate{Template: config.DefaultWebhookTemplate}
+
 // Client is a webhook client
 type Client struct {
-	config *config.Configuration
+	config          *config.Configuration
+	store           WorkspaceWebhookStore
+	templates       map[string]*template.Template
+	defaultTemplate *template.Template
+	httpClient      *http.Client
+	lookupIPAddr    func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	// ctx is cancelled when the shutdown times out, to stop the deliveries
+	// in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	maxWorkers int
+	maxQueued  int
+	mu         sync.Mutex
+	queue      []queuedEvent
+	workers    int
+	working    sync.WaitGroup
+	closed     bool
+	dropped    int64
 }
 
-// NewClient creates a new Client
-func NewClient(config *config.Configuration) *Client {
+// NewClient creates a new Client. Without a store only the webhooks of the
+// configuration are called. The webhooks at private, loopback and link-local
+// addresses are refused unless WebhookAllowPrivateTargets is set.
+func NewClient(config *config.Configuration, store WorkspaceWebhookStore) *Client {
+	defaultTemplate := template.Must(defaultWebhookTemplate.Parse())
+
+	templates := map[string]*template.Template{}
+	for _, wt := range config.WebhookTemplates {
+		if wt.Template == "" {
+			continue
+		}
+
+		// The templates are validated when the configuration is loaded
+		tmpl, err := wt.Parse()
+		if err != nil {
+			log.Printf("webhook.NewClient: invalid template for %s: %v", wt.URL, err)
+			continue
+		}
+		templates[wt.URL] = tmpl
+	}
+
+	maxWorkers := config.WebhookWorkers
+	if maxWorkers <= 0 {
+		maxWorkers = defaultWebhookWorkers
+	}
+	maxQueued := config.WebhookQueueSize
+	if maxQueued <= 0 {
+		maxQueued = defaultWebhookQueueSize
+	}
+	timeout := time.Duration(config.WebhookTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Client{
-		config: config,
+		config:          config,
+		store:           store,
+		templates:       templates,
+		defaultTemplate: defaultTemplate,
+		httpClient:      newHTTPClient(config.WebhookAllowPrivateTargets, timeout),
+		lookupIPAddr:    net.DefaultResolver.LookupIPAddr,
+		ctx:             ctx,
+		cancel:          cancel,
+		maxWorkers:      maxWorkers,
+		maxQueued:       maxQueued,
 	}
 }
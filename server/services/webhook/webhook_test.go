@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyUpdate(t *testing.T) {
+	payloads := make(chan string, 2)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		payloads <- r.URL.Path + " " + string(body)
+	}))
+	defer sink.Close()
+
+	cfg := &config.Configuration{
+		WebhookUpdate:              []string{sink.URL + "/slack", sink.URL + "/raw"},
+		WebhookAllowPrivateTargets: true,
+		WebhookTemplates: []config.WebhookTemplate{
+			{URL: sink.URL + "/slack", Template: `{"text": {{json (printf "%s was updated" .Block.Title)}}}`},
+		},
+	}
+	client := NewClient(cfg, nil)
+
+	client.NotifyUpdate("workspace-id", model.Block{ID: "card-id", Type: "card", Title: `My "card"`}, "")
+
+	require.Equal(t, `/slack {"text": "My \"card\" was updated"}`, <-payloads)
+
+	raw := <-payloads
+	require.Contains(t, raw, `/raw {"id":"card-id"`)
+	require.Contains(t, raw, `"title":"My \"card\""`)
+}
+
+type fakeWorkspaceWebhookStore map[string][]model.WorkspaceWebhook
+
+func (s fakeWorkspaceWebhookStore) GetWorkspaceWebhooks(workspaceID string) ([]model.WorkspaceWebhook, error) {
+	return s[workspaceID], nil
+}
+
+func TestNotifyUpdateWorkspaceWebhooks(t *testing.T) {
+	calls := make(chan string, 10)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- r.URL.Path
+	}))
+	defer sink.Close()
+
+	cfg := &config.Configuration{
+		WebhookUpdate:              []string{sink.URL + "/global"},
+		WebhookAllowPrivateTargets: true,
+	}
+	store := fakeWorkspaceWebhookStore{
+		"workspace-1": {
+			{ID: "webhook-1", WorkspaceID: "workspace-1", URL: sink.URL + "/workspace-1"},
+			{ID: "webhook-2", WorkspaceID: "workspace-1", URL: sink.URL + "/global"},
+		},
+		"workspace-2": {
+			{ID: "webhook-3", WorkspaceID: "workspace-2", URL: sink.URL + "/workspace-2"},
+		},
+	}
+	client := NewClient(cfg, store)
+
+	client.NotifyUpdate("workspace-1", model.Block{ID: "card-id"}, "")
+	close(calls)
+
+	paths := []string{}
+	for path := range calls {
+		paths = append(paths, path)
+	}
+	require.Equal(t, []string{"/global", "/workspace-1"}, paths)
+}
+
+func TestNotifyUpdateRequestID(t *testing.T) {
+	headers := make(chan string, 1)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get(utils.RequestIDHeader)
+	}))
+	defer sink.Close()
+
+	t.Run("enabled", func(t *testing.T) {
+		client := NewClient(&config.Configuration{WebhookUpdate: []string{sink.URL}, WebhookRequestID: true, WebhookAllowPrivateTargets: true}, nil)
+		client.NotifyUpdate("workspace-id", model.Block{ID: "card-id"}, "request-id")
+		require.Equal(t, "request-id", <-headers)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient(&config.Configuration{WebhookUpdate: []string{sink.URL}, WebhookAllowPrivateTargets: true}, nil)
+		client.NotifyUpdate("workspace-id", model.Block{ID: "card-id"}, "request-id")
+		require.Empty(t, <-headers)
+	})
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "context"
+
+// RequestIDHeader is the header with the ID of a request, sent in its
+// response and in the webhook deliveries it triggers
+const RequestIDHeader = "X-Focalboard-Request-ID"
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of the context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID of the context, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"time"
 )
 
 // CreateGUID returns a random GUID.
@@ -17,3 +18,8 @@ func CreateGUID() string {
 
 	return uuid
 }
+
+// GetMillis returns the current time in milliseconds since the epoch.
+func GetMillis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
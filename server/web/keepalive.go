@@ -0,0 +1,44 @@
+package web
+
+import (
+	"log"
+	"net"
+	"time"
+)
+
+// keepAliveConn is implemented by the TCP connections, the only ones that
+// support keepalive probes.
+type keepAliveConn interface {
+	SetKeepAlive(keepalive bool) error
+	SetKeepAlivePeriod(d time.Duration) error
+}
+
+// keepAliveListener enables TCP keepalive on the accepted connections, so
+// the sockets of peers that silently went away are eventually closed.
+type keepAliveListener struct {
+	net.Listener
+	period time.Duration
+}
+
+func newKeepAliveListener(l net.Listener, period time.Duration) net.Listener {
+	return &keepAliveListener{Listener: l, period: period}
+}
+
+func (l *keepAliveListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if tcpConn, ok := conn.(keepAliveConn); ok {
+		// A failure here only means the connection won't be probed, which
+		// isn't a reason to drop it
+		if err := tcpConn.SetKeepAlive(true); err != nil {
+			log.Printf("Unable to enable TCP keepalive: %v\n", err)
+		} else if err := tcpConn.SetKeepAlivePeriod(l.period); err != nil {
+			log.Printf("Unable to set the TCP keepalive period: %v\n", err)
+		}
+	}
+
+	return conn, nil
+}
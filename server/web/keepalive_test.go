@@ -0,0 +1,67 @@
+package web
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingConn struct {
+	net.Conn
+	keepAlive       bool
+	keepAlivePeriod time.Duration
+}
+
+func (c *recordingConn) SetKeepAlive(keepalive bool) error {
+	c.keepAlive = keepalive
+	return nil
+}
+
+func (c *recordingConn) SetKeepAlivePeriod(d time.Duration) error {
+	c.keepAlivePeriod = d
+	return nil
+}
+
+type recordingListener struct {
+	net.Listener
+	conns chan net.Conn
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+func TestKeepAliveListener(t *testing.T) {
+	t.Run("keepalive is set on accepted connections", func(t *testing.T) {
+		conn := &recordingConn{}
+		inner := &recordingListener{conns: make(chan net.Conn, 1)}
+		inner.conns <- conn
+
+		listener := newKeepAliveListener(inner, 30*time.Second)
+		accepted, err := listener.Accept()
+		require.NoError(t, err)
+		require.Equal(t, conn, accepted)
+		require.True(t, conn.keepAlive)
+		require.Equal(t, 30*time.Second, conn.keepAlivePeriod)
+	})
+
+	t.Run("tcp connections support keepalive", func(t *testing.T) {
+		inner, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listener := newKeepAliveListener(inner, time.Minute)
+		defer listener.Close()
+
+		client, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer client.Close()
+
+		accepted, err := listener.Accept()
+		require.NoError(t, err)
+		defer accepted.Close()
+
+		_, ok := accepted.(keepAliveConn)
+		require.True(t, ok)
+	})
+}
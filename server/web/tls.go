@@ -0,0 +1,55 @@
+package web
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// DefaultTLSMinVersion is the minimum TLS version when none is configured
+const DefaultTLSMinVersion = "1.2"
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig returns the TLS configuration of the web server, with the
+// minimum version, such as "1.2", and the names of the cipher suites, such
+// as "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Only the suites considered
+// secure by Go are accepted, and no suites means Go's defaults. The suites
+// don't apply to TLS 1.3, whose suites aren't configurable.
+func NewTLSConfig(minVersion string, cipherSuites []string) (*tls.Config, error) {
+	if minVersion == "" {
+		minVersion = DefaultTLSMinVersion
+	}
+
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid TLS version %q", minVersion)
+	}
+
+	config := &tls.Config{
+		MinVersion: version,
+	}
+
+	if len(cipherSuites) == 0 {
+		return config, nil
+	}
+
+	secure := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+
+	for _, name := range cipherSuites {
+		id, ok := secure[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure TLS cipher suite %q", name)
+		}
+		config.CipherSuites = append(config.CipherSuites, id)
+	}
+
+	return config, nil
+}
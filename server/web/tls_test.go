@@ -0,0 +1,39 @@
+package web
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("defaults to TLS 1.2", func(t *testing.T) {
+		config, err := NewTLSConfig("", nil)
+		require.NoError(t, err)
+		require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+		require.Nil(t, config.CipherSuites)
+	})
+
+	t.Run("configured version and suites", func(t *testing.T) {
+		config, err := NewTLSConfig("1.3", []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+		require.NoError(t, err)
+		require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+		require.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, config.CipherSuites)
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		_, err := NewTLSConfig("1.4", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown suite", func(t *testing.T) {
+		_, err := NewTLSConfig("1.2", []string{"TLS_NOT_A_SUITE"})
+		require.Error(t, err)
+	})
+
+	t.Run("insecure suite", func(t *testing.T) {
+		_, err := NewTLSConfig("1.2", []string{"TLS_RSA_WITH_RC4_128_SHA"})
+		require.Error(t, err)
+	})
+}
@@ -1,14 +1,17 @@
 package web
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"text/template"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -24,15 +27,24 @@ type RoutedService interface {
 type Server struct {
 	http.Server
 
+	router    *mux.Router
+	listening bool
 	baseURL   string
 	rootPath  string
 	port      int
 	ssl       bool
 	localOnly bool
+
+	tcpKeepAlive time.Duration
+
+	// ShutdownTimeout is how long the shutdown waits for the requests in
+	// progress before closing their connections, 0 closes them right away
+	ShutdownTimeout time.Duration
 }
 
-// NewServer creates a new instance of the webserver.
-func NewServer(rootPath string, serverRoot string, port int, ssl, localOnly bool) *Server {
+// NewServer creates a new instance of the webserver. A positive tcpKeepAlive
+// enables TCP keepalive, with that period, on the accepted connections.
+func NewServer(rootPath string, serverRoot string, port int, ssl, localOnly bool, tcpKeepAlive time.Duration) *Server {
 	r := mux.NewRouter()
 
 	var addr string
@@ -54,17 +66,22 @@ func NewServer(rootPath string, serverRoot string, port int, ssl, localOnly bool
 			Addr:    addr,
 			Handler: r,
 		},
+		router:   r,
 		baseURL:  baseURL,
 		rootPath: rootPath,
 		port:     port,
 		ssl:      ssl,
+
+		tcpKeepAlive: tcpKeepAlive,
 	}
 
 	return ws
 }
 
+// Router returns the router of the server, which serves the requests unless
+// the Handler of the server is replaced, e.g. with a handler wrapping it
 func (ws *Server) Router() *mux.Router {
-	return ws.Server.Handler.(*mux.Router)
+	return ws.router
 }
 
 // AddRoutes allows services to register themself in the webserver router and provide new endpoints.
@@ -91,33 +108,74 @@ func (ws *Server) registerRoutes() {
 	})
 }
 
-// Start runs the web server and start listening for charsetnnections.
+// Start runs the web server and start listening for charsetnnections, unless
+// Listen was called before.
 func (ws *Server) Start() {
 	ws.registerRoutes()
 
+	if ws.listening {
+		return
+	}
+
+	if err := ws.Listen(); err != nil {
+		log.Fatalf("Listen: %v", err)
+	}
+}
+
+// Listen binds the port and starts serving the connections, before the
+// routes of the web app are registered by Start, so the Handler of the
+// server must not route the requests to the router until then.
+func (ws *Server) Listen() error {
+	listener, err := net.Listen("tcp", ws.Addr)
+	if err != nil {
+		return err
+	}
+	ws.listening = true
+
+	if ws.tcpKeepAlive > 0 {
+		listener = newKeepAliveListener(listener, ws.tcpKeepAlive)
+	}
+
 	isSSL := ws.ssl && fileExists("./cert/cert.pem") && fileExists("./cert/key.pem")
 	if isSSL {
 		log.Printf("https server started on :%d\n", ws.port)
 		go func() {
-			if err := ws.ListenAndServeTLS("./cert/cert.pem", "./cert/key.pem"); err != nil {
-				log.Fatalf("ListenAndServeTLS: %v", err)
+			if err := ws.ServeTLS(listener, "./cert/cert.pem", "./cert/key.pem"); err != nil {
+				log.Fatalf("ServeTLS: %v", err)
 			}
 		}()
 
-		return
+		return nil
 	}
 
 	log.Printf("http server started on :%d\n", ws.port)
 	go func() {
-		if err := ws.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("ListenAndServe: %v", err)
+		if err := ws.Serve(listener); err != http.ErrServerClosed {
+			log.Fatalf("Serve: %v", err)
 		}
 		log.Println("http server stopped")
 	}()
+
+	return nil
 }
 
+// Shutdown stops accepting connections and waits for the requests in
+// progress to finish within the shutdown timeout, then closes the
+// connections left
 func (ws *Server) Shutdown() error {
-	return ws.Close()
+	if ws.ShutdownTimeout <= 0 {
+		return ws.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ws.ShutdownTimeout)
+	defer cancel()
+
+	if err := ws.Server.Shutdown(ctx); err != nil {
+		log.Printf("http server shutdown timed out: %v", err)
+		return ws.Close()
+	}
+
+	return nil
 }
 
 // fileExists returns true if a file exists at the path.
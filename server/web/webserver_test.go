@@ -0,0 +1,65 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	// serve starts a server whose requests wait for the release, and returns
+	// the result of a request once it's in progress
+	serve := func(t *testing.T, timeout time.Duration, release chan struct{}) (*Server, chan error) {
+		ws := NewServer("", "", 0, false, true, 0)
+		ws.ShutdownTimeout = timeout
+
+		started := make(chan struct{})
+		ws.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusOK)
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go ws.Serve(listener)
+
+		result := make(chan error, 1)
+		go func() {
+			response, err := http.Get("http://" + listener.Addr().String())
+			if err == nil {
+				response.Body.Close()
+			}
+			result <- err
+		}()
+		<-started
+
+		return ws, result
+	}
+
+	t.Run("the requests in progress finish", func(t *testing.T) {
+		release := make(chan struct{})
+		ws, result := serve(t, 5*time.Second, release)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+		}()
+		require.NoError(t, ws.Shutdown())
+		require.NoError(t, <-result)
+	})
+
+	t.Run("the connections are closed once the timeout passes", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		ws, result := serve(t, 50*time.Millisecond, release)
+
+		start := time.Now()
+		require.NoError(t, ws.Shutdown())
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+		require.Error(t, <-result)
+	})
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/go-multierror"
+)
+
+// CloseUnauthorized is the close code sent to the clients that fail to
+// authenticate.
+const CloseUnauthorized = 4002
+
+// closeCause is a reason for the server to close a connection
+type closeCause struct {
+	code    int
+	name    string
+	message string
+}
+
+var (
+	closeAuthTimeout    = closeCause{code: CloseAuthTimeout, name: "authTimeout", message: "auth timeout"}
+	closeSessionExpired = closeCause{code: CloseSessionExpired, name: "sessionExpired", message: "session expired"}
+	closeUnauthorized   = closeCause{code: CloseUnauthorized, name: "unauthorized", message: "unauthorized"}
+	closeShutdown       = closeCause{code: websocket.CloseServiceRestart, name: "shutdown", message: "server shutting down"}
+	closeOverload       = closeCause{code: websocket.CloseTryAgainLater, name: "overload", message: "server overloaded"}
+)
+
+// defaultReconnectDelays are the delays suggested to the clients before they
+// reconnect, per close cause: short for a transient failure, longer when the
+// server goes down, so the clients don't all reconnect at once.
+var defaultReconnectDelays = map[string]time.Duration{
+	closeAuthTimeout.name:    time.Second,
+	closeSessionExpired.name: 0,
+	closeUnauthorized.name:   5 * time.Second,
+	closeShutdown.name:       30 * time.Second,
+	closeOverload.name:       10 * time.Second,
+}
+
+// ClosePayload is the reason of the close frames sent by the server, as JSON
+type ClosePayload struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+
+	// ReconnectDelay is the suggested wait in milliseconds before
+	// reconnecting
+	ReconnectDelay int64 `json:"reconnectDelay"`
+}
+
+// SetReconnectDelays overrides the reconnect delays, in milliseconds,
+// suggested for the close causes: authTimeout, sessionExpired, unauthorized,
+// shutdown and overload.
+func (ws *Server) SetReconnectDelays(delays map[string]int) error {
+	for name, delay := range delays {
+		if _, ok := defaultReconnectDelays[name]; !ok {
+			return fmt.Errorf("unknown websocket close cause %q", name)
+		}
+		if delay < 0 {
+			return fmt.Errorf("invalid reconnect delay for %s: %d", name, delay)
+		}
+	}
+
+	for name, delay := range delays {
+		ws.reconnectDelays[name] = time.Duration(delay) * time.Millisecond
+	}
+
+	return nil
+}
+
+// sendClose sends the close frame of a cause to a client, with the suggested
+// reconnect delay. The connection is left for the caller to close.
+func (ws *Server) sendClose(client *websocket.Conn, cause closeCause) error {
+	payload, err := json.Marshal(ClosePayload{
+		Reason:         cause.name,
+		Message:        cause.message,
+		ReconnectDelay: ws.reconnectDelays[cause.name].Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	closeMessage := websocket.FormatCloseMessage(cause.code, string(payload))
+	return client.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+}
+
+// closeListeners closes the connections of all the listeners with the close
+// frame of a cause. They aren't closed with the web server, as the upgrade
+// hijacks them.
+func (ws *Server) closeListeners(cause closeCause) error {
+	ws.mu.Lock()
+	var clients []*websocket.Conn
+	found := map[*websocket.Conn]bool{}
+	for _, listeners := range ws.listeners {
+		for _, client := range listeners {
+			if !found[client] {
+				found[client] = true
+				clients = append(clients, client)
+			}
+		}
+	}
+	ws.listeners = make(map[string][]*websocket.Conn)
+	ws.sessions = make(map[string][]*websocket.Conn)
+	ws.mu.Unlock()
+
+	// The close frames are sent without the lock, as a slow client can
+	// hold each of them for a while
+	var result *multierror.Error
+	for _, client := range clients {
+		ws.sendClose(client, cause)
+		if err := client.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func readClosePayload(t *testing.T, client *websocket.Conn, code int) ClosePayload {
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := client.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		closeErr, ok := err.(*websocket.CloseError)
+		require.True(t, ok, err)
+		require.Equal(t, code, closeErr.Code)
+
+		var payload ClosePayload
+		require.NoError(t, json.Unmarshal([]byte(closeErr.Text), &payload))
+		return payload
+	}
+}
+
+func TestCloseFrames(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+	ws.AuthTimeout = 50 * time.Millisecond
+	require.NoError(t, ws.SetReconnectDelays(map[string]int{"unauthorized": 2000}))
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	dial := func() *websocket.Conn {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	t.Run("auth timeout", func(t *testing.T) {
+		payload := readClosePayload(t, dial(), CloseAuthTimeout)
+		require.Equal(t, ClosePayload{Reason: "authTimeout", Message: "auth timeout", ReconnectDelay: 1000}, payload)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		client := dial()
+		require.NoError(t, client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "wrong-token"}))
+
+		payload := readClosePayload(t, client, CloseUnauthorized)
+		require.Equal(t, ClosePayload{Reason: "unauthorized", Message: "unauthorized", ReconnectDelay: 2000}, payload)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		client := dial()
+		require.NoError(t, client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "single-user-token"}))
+		require.NoError(t, client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: []string{"block-id"}}))
+		require.Eventually(t, func() bool {
+			return len(ws.getListeners("0", "block-id")) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, ws.Shutdown())
+
+		payload := readClosePayload(t, client, websocket.CloseServiceRestart)
+		require.Equal(t, ClosePayload{Reason: "shutdown", Message: "server shutting down", ReconnectDelay: 30000}, payload)
+	})
+}
+
+func TestCloseOverload(t *testing.T) {
+	ws := NewServer(nil, "")
+	ws.MaxClients = 1
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer first.Close()
+	require.Eventually(t, func() bool { return ws.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	second, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer second.Close()
+
+	payload := readClosePayload(t, second, websocket.CloseTryAgainLater)
+	require.Equal(t, ClosePayload{Reason: "overload", Message: "server overloaded", ReconnectDelay: 10000}, payload)
+
+	// The rejected client isn't counted, and the first one stays connected
+	require.Eventually(t, func() bool { return ws.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	first.Close()
+	require.Eventually(t, func() bool { return ws.ClientCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	third, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer third.Close()
+	require.Eventually(t, func() bool { return ws.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestSetReconnectDelays(t *testing.T) {
+	ws := NewServer(nil, "")
+
+	require.EqualError(t, ws.SetReconnectDelays(map[string]int{"shutdown": 1000, "restart": 10}), `unknown websocket close cause "restart"`)
+	require.EqualError(t, ws.SetReconnectDelays(map[string]int{"shutdown": -1}), "invalid reconnect delay for shutdown: -1")
+	// The invalid settings aren't applied at all
+	require.Equal(t, 30*time.Second, ws.reconnectDelays["shutdown"])
+
+	require.NoError(t, ws.SetReconnectDelays(map[string]int{"shutdown": 60000}))
+	require.Equal(t, time.Minute, ws.reconnectDelays["shutdown"])
+	require.Equal(t, time.Second, ws.reconnectDelays["authTimeout"])
+	require.Equal(t, 30*time.Second, defaultReconnectDelays["shutdown"])
+}
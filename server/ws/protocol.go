@@ -0,0 +1,103 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// protocolPrefix is the prefix of the websocket subprotocols, which end in
+// the version of the messages, e.g. focalboard.v1
+const protocolPrefix = "focalboard.v"
+
+// legacyProtocolVersion is used with the clients that don't ask for a
+// subprotocol, which predate the negotiation
+const legacyProtocolVersion = 1
+
+// SupportedProtocolVersions are the versions of the websocket messages the
+// server implements
+var SupportedProtocolVersions = []int{1}
+
+// ProtocolName returns the subprotocol of a version of the messages
+func ProtocolName(version int) string {
+	return protocolPrefix + strconv.Itoa(version)
+}
+
+// SetProtocolVersions restricts the protocol versions the clients can use to
+// the given ones, which must be supported
+func (ws *Server) SetProtocolVersions(versions []int) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("at least one protocol version is required")
+	}
+
+	for _, version := range versions {
+		if !containsVersion(SupportedProtocolVersions, version) {
+			return fmt.Errorf("unsupported websocket protocol version %d", version)
+		}
+	}
+
+	ws.protocolVersions = append([]int{}, versions...)
+	sort.Ints(ws.protocolVersions)
+
+	return nil
+}
+
+// negotiateProtocol selects the highest protocol version offered by the
+// client that the server has enabled. The clients that offer none are
+// treated as legacy clients. It returns the subprotocol to confirm to the
+// client, empty for legacy clients, or an error if there's no overlap.
+func (ws *Server) negotiateProtocol(r *http.Request) (int, string, error) {
+	offered := websocket.Subprotocols(r)
+	if len(offered) == 0 {
+		if !containsVersion(ws.protocolVersions, legacyProtocolVersion) {
+			return 0, "", fmt.Errorf("a websocket protocol is required, supported: %s", ws.protocolNames())
+		}
+
+		return legacyProtocolVersion, "", nil
+	}
+
+	selected := 0
+	for _, protocol := range offered {
+		if !strings.HasPrefix(protocol, protocolPrefix) {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.TrimPrefix(protocol, protocolPrefix))
+		if err != nil {
+			continue
+		}
+
+		if version > selected && containsVersion(ws.protocolVersions, version) {
+			selected = version
+		}
+	}
+
+	if selected == 0 {
+		return 0, "", fmt.Errorf("no supported websocket protocol offered (%s), supported: %s", strings.Join(offered, ", "), ws.protocolNames())
+	}
+
+	return selected, ProtocolName(selected), nil
+}
+
+func (ws *Server) protocolNames() string {
+	names := make([]string, len(ws.protocolVersions))
+	for i, version := range ws.protocolVersions {
+		names[i] = ProtocolName(version)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
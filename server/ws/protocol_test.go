@@ -0,0 +1,59 @@
+package ws
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolNegotiation(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+	ws.protocolVersions = []int{1, 2}
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	dial := func(protocols ...string) (*websocket.Conn, *http.Response, error) {
+		dialer := *websocket.DefaultDialer
+		dialer.Subprotocols = protocols
+		return dialer.Dial(url, nil)
+	}
+
+	t.Run("the highest common version is selected", func(t *testing.T) {
+		client, _, err := dial("focalboard.v1", "focalboard.v2", "focalboard.v3")
+		require.NoError(t, err)
+		defer client.Close()
+		require.Equal(t, "focalboard.v2", client.Subprotocol())
+	})
+
+	t.Run("clients without a protocol use the legacy one", func(t *testing.T) {
+		client, _, err := dial()
+		require.NoError(t, err)
+		defer client.Close()
+		require.Empty(t, client.Subprotocol())
+	})
+
+	t.Run("no overlap is rejected", func(t *testing.T) {
+		_, response, err := dial("focalboard.v3", "other")
+		require.Error(t, err)
+		require.NotNil(t, response)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		body, err := ioutil.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "supported: focalboard.v1, focalboard.v2")
+	})
+
+	t.Run("only supported versions can be enabled", func(t *testing.T) {
+		require.Error(t, ws.SetProtocolVersions([]int{1, 99}))
+		require.Error(t, ws.SetProtocolVersions(nil))
+		require.NoError(t, ws.SetProtocolVersions([]int{1}))
+
+		_, response, err := dial("focalboard.v2")
+		require.Error(t, err)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
@@ -4,32 +4,100 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/mattermost/focalboard/server/auth"
 	"github.com/mattermost/focalboard/server/model"
+	serviceAuth "github.com/mattermost/focalboard/server/services/auth"
 	"github.com/mattermost/focalboard/server/services/store"
 )
 
+// CloseAuthTimeout is the close code sent to the clients that don't
+// authenticate within the AuthTimeout.
+const CloseAuthTimeout = 4000
+
+// CloseSessionExpired is the close code sent to the clients whose session
+// expired or was revoked, after the SESSION_EXPIRED message.
+const CloseSessionExpired = 4001
+
 type WorkspaceAuthenticator interface {
 	DoesUserHaveWorkspaceAccess(session *model.Session, workspaceID string) bool
 }
 
+// HeaderAuthenticator authenticates the connections through the header set
+// by a trusted reverse proxy
+type HeaderAuthenticator interface {
+	// HeaderSession returns the session of the user set by the proxy, or nil
+	// if the request has no authentication header
+	HeaderSession(r *http.Request) (*model.Session, error)
+}
+
 // IsValidSessionToken authenticates session tokens
 type IsValidSessionToken func(token string) bool
 
 // Server is a WebSocket server.
 type Server struct {
+	// clients and broadcastFailures are first so they're 64-bit aligned for
+	// the atomic operations
+	clients           int64
+	broadcastFailures int64
+
 	upgrader               websocket.Upgrader
 	listeners              map[string][]*websocket.Conn
+	sessions               map[string][]*websocket.Conn
 	mu                     sync.RWMutex
 	auth                   *auth.Auth
 	singleUserToken        string
 	WorkspaceAuthenticator WorkspaceAuthenticator
+
+	// HeaderAuthenticator, if set, authenticates the connections by the
+	// header of the upgrade request instead of the token of the AUTH command
+	HeaderAuthenticator HeaderAuthenticator
+
+	// MaxClients is how many clients can be connected at once, the ones
+	// past it are closed as soon as they connect. Zero doesn't limit them.
+	MaxClients int
+
+	// AuthTimeout is how long a client has, once connected, to authenticate
+	// or subscribe with a read token. Zero disables the timeout.
+	AuthTimeout time.Duration
+
+	// protocolVersions are the versions of the messages the clients can
+	// negotiate, in ascending order
+	protocolVersions []int
+
+	// reconnectDelays are the delays suggested to the clients in the close
+	// frames, per close cause
+	reconnectDelays map[string]time.Duration
+
+	// CoalesceWindow is how long the changes to the blocks of a board are
+	// held to be broadcast together, so a client updating a board rapidly
+	// doesn't flood the rest of them. Zero broadcasts every change at once.
+	CoalesceWindow time.Duration
+
+	// WriteTimeout is how long a broadcast to a client can take before the
+	// client is dropped, so a slow client doesn't hold up the rest of them.
+	// Zero waits for the writes to finish.
+	WriteTimeout time.Duration
+
+	pendingMu sync.Mutex
+	pending   map[string]*pendingBroadcast
+}
+
+// pendingBroadcast has the changes to the blocks of a board waiting for the
+// coalesce window to end, with the latest state of each block
+type pendingBroadcast struct {
+	workspaceID string
+	blocks      []model.Block
+	indexes     map[string]int
+	timer       *time.Timer
 }
 
 // UpdateMsg is sent on block updates
@@ -38,6 +106,18 @@ type UpdateMsg struct {
 	Block  model.Block `json:"block"`
 }
 
+// UpdateBlocksMsg is sent on batched block updates
+type UpdateBlocksMsg struct {
+	Action string        `json:"action"`
+	Blocks []model.Block `json:"blocks"`
+}
+
+// SessionExpiredMsg is sent to the connections of an expired session, so the
+// client logs out, right before closing them
+type SessionExpiredMsg struct {
+	Action string `json:"action"`
+}
+
 // ErrorMsg is sent on errors
 type ErrorMsg struct {
 	Error string `json:"error"`
@@ -53,61 +133,210 @@ type WebsocketCommand struct {
 }
 
 type websocketSession struct {
-	client          *websocket.Conn
-	isAuthenticated bool
-	workspaceID     string
+	client            *websocket.Conn
+	isAuthenticated   bool
+	hasReadAccess     bool
+	workspaceID       string
+	sessionID         string
+	deviceFingerprint string
+
+	// headerSession is the session authenticated by the header of the
+	// upgrade request, used by the AUTH command in the header auth mode
+	headerSession *model.Session
+
+	// protocolVersion is the version of the messages negotiated with the
+	// client, for the handlers to pick the shape of the messages
+	protocolVersion int
 }
 
 // NewServer creates a new Server.
 func NewServer(auth *auth.Auth, singleUserToken string) *Server {
 	return &Server{
 		listeners: make(map[string][]*websocket.Conn),
+		sessions:  make(map[string][]*websocket.Conn),
+		pending:   make(map[string]*pendingBroadcast),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
-		auth:            auth,
-		singleUserToken: singleUserToken,
+		auth:             auth,
+		singleUserToken:  singleUserToken,
+		protocolVersions: append([]int{}, SupportedProtocolVersions...),
+		reconnectDelays:  copyReconnectDelays(defaultReconnectDelays),
 	}
 }
 
+func copyReconnectDelays(delays map[string]time.Duration) map[string]time.Duration {
+	copied := make(map[string]time.Duration, len(delays))
+	for name, delay := range delays {
+		copied[name] = delay
+	}
+
+	return copied
+}
+
+// Shutdown drops the pending broadcasts and closes the connections of all
+// the listeners, telling the clients when to reconnect.
+func (ws *Server) Shutdown() error {
+	ws.pendingMu.Lock()
+	for _, pending := range ws.pending {
+		pending.timer.Stop()
+	}
+	ws.pending = make(map[string]*pendingBroadcast)
+	ws.pendingMu.Unlock()
+
+	return ws.closeListeners(closeShutdown)
+}
+
+// ClientCount returns the number of connected clients, authenticated or not.
+func (ws *Server) ClientCount() int {
+	return int(atomic.LoadInt64(&ws.clients))
+}
+
+// SubscriptionCounts returns the number of clients listening to the changes
+// of each block, usually boards, keyed by block ID. The blocks of different
+// workspaces with the same ID are counted together.
+func (ws *Server) SubscriptionCounts() map[string]int {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	clients := map[string]map[*websocket.Conn]bool{}
+	for itemID, listeners := range ws.listeners {
+		if len(listeners) == 0 {
+			continue
+		}
+
+		// Workspace IDs have no dashes, unlike some block IDs
+		blockID := itemID
+		if parts := strings.SplitN(itemID, "-", 2); len(parts) == 2 {
+			blockID = parts[1]
+		}
+
+		if clients[blockID] == nil {
+			clients[blockID] = map[*websocket.Conn]bool{}
+		}
+		for _, client := range listeners {
+			clients[blockID][client] = true
+		}
+	}
+
+	counts := make(map[string]int, len(clients))
+	for blockID, blockClients := range clients {
+		counts[blockID] = len(blockClients)
+	}
+
+	return counts
+}
+
 // RegisterRoutes registers routes.
 func (ws *Server) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/ws/onchange", ws.handleWebSocketOnChange)
 }
 
+// ExpireSessions sends the SESSION_EXPIRED message to the connections
+// authenticated with any of the sessions and closes them.
+func (ws *Server) ExpireSessions(sessionIDs []string) {
+	ws.mu.Lock()
+	var clients []*websocket.Conn
+	for _, sessionID := range sessionIDs {
+		clients = append(clients, ws.sessions[sessionID]...)
+		delete(ws.sessions, sessionID)
+	}
+	ws.mu.Unlock()
+
+	for _, client := range clients {
+		log.Printf("Session expired, closing client: %s", client.RemoteAddr())
+
+		if err := client.WriteJSON(SessionExpiredMsg{Action: "SESSION_EXPIRED"}); err != nil {
+			log.Printf("session expired error: %v", err)
+		}
+		ws.sendClose(client, closeSessionExpired)
+		client.Close()
+	}
+}
+
 func (ws *Server) handleWebSocketOnChange(w http.ResponseWriter, r *http.Request) {
+	protocolVersion, protocol, err := ws.negotiateProtocol(r)
+	if err != nil {
+		log.Printf("ERROR negotiating the websocket protocol: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The header is checked before upgrading, like the API rejects the
+	// requests from untrusted sources
+	var headerSession *model.Session
+	if ws.isHeaderAuth() {
+		headerSession, err = ws.HeaderAuthenticator.HeaderSession(r)
+		if err != nil {
+			log.Printf("ERROR authenticating the websocket header: %v", err)
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var responseHeader http.Header
+	if protocol != "" {
+		responseHeader = http.Header{"Sec-Websocket-Protocol": {protocol}}
+	}
+
 	// Upgrade initial GET request to a websocket
-	client, err := ws.upgrader.Upgrade(w, r, nil)
+	client, err := ws.upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		log.Printf("ERROR upgrading to websocket: %v", err)
 		return
 	}
 
-	// TODO: Auth
+	if clients := atomic.AddInt64(&ws.clients, 1); ws.MaxClients > 0 && clients > int64(ws.MaxClients) {
+		log.Printf("WebSocket onChange, client: %s rejected, %d clients connected", client.RemoteAddr(), clients-1)
+		atomic.AddInt64(&ws.clients, -1)
+		ws.sendClose(client, closeOverload)
+		client.Close()
+		return
+	}
 
-	log.Printf("CONNECT WebSocket onChange, client: %s", client.RemoteAddr())
+	log.Printf("CONNECT WebSocket onChange, client: %s, protocol version: %d", client.RemoteAddr(), protocolVersion)
+
+	wsSession := websocketSession{
+		client:            client,
+		isAuthenticated:   false,
+		deviceFingerprint: serviceAuth.ParseDeviceFingerprintFromRequest(r),
+		protocolVersion:   protocolVersion,
+		headerSession:     headerSession,
+	}
 
 	// Make sure we close the connection when the function returns
 	defer func() {
 		log.Printf("DISCONNECT WebSocket onChange, client: %s", client.RemoteAddr())
+		atomic.AddInt64(&ws.clients, -1)
 
 		// Remove client from listeners
 		ws.removeListener(client)
+		ws.removeSessionClient(wsSession.sessionID, client)
 
 		client.Close()
 	}()
 
-	wsSession := websocketSession{
-		client:          client,
-		isAuthenticated: false,
+	awaitingAuth := ws.AuthTimeout > 0
+	if awaitingAuth {
+		client.SetReadDeadline(time.Now().Add(ws.AuthTimeout))
 	}
 
 	// Simple message handling loop
 	for {
+		if awaitingAuth && (wsSession.isAuthenticated || wsSession.hasReadAccess) {
+			awaitingAuth = false
+			client.SetReadDeadline(time.Time{})
+		}
+
 		_, p, err := client.ReadMessage()
 		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && awaitingAuth {
+				log.Printf("WebSocket onChange, client: %s didn't authenticate in time", client.RemoteAddr())
+				ws.sendClose(client, closeAuthTimeout)
+			}
+
 			log.Printf("ERROR WebSocket onChange, client: %s, err: %v", client.RemoteAddr(), err)
 			ws.removeListener(client)
 
@@ -143,24 +372,38 @@ func (ws *Server) handleWebSocketOnChange(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (ws *Server) isValidSessionToken(token, workspaceID string) bool {
+// isHeaderAuth is whether the connections authenticate by the header of the
+// upgrade request, which the single-user token takes precedence over
+func (ws *Server) isHeaderAuth() bool {
+	return ws.HeaderAuthenticator != nil && len(ws.singleUserToken) == 0
+}
+
+// isValidSessionToken validates the token and returns the ID of its
+// session, which is empty for the single-user token
+func (ws *Server) isValidSessionToken(token, workspaceID, deviceFingerprint string) (string, bool) {
 	if len(ws.singleUserToken) > 0 {
-		return token == ws.singleUserToken
+		return "", token == ws.singleUserToken
 	}
 
-	session, err := ws.auth.GetSession(token)
+	session, err := ws.auth.GetSession(token, deviceFingerprint)
 	if session == nil || err != nil {
-		return false
+		return "", false
 	}
 
+	return ws.isValidSession(session, workspaceID)
+}
+
+// isValidSession checks the session has access to the workspace and returns
+// its ID
+func (ws *Server) isValidSession(session *model.Session, workspaceID string) (string, bool) {
 	// Check workspace permission
 	if ws.WorkspaceAuthenticator != nil {
 		if !ws.WorkspaceAuthenticator.DoesUserHaveWorkspaceAccess(session, workspaceID) {
-			return false
+			return "", false
 		}
 	}
 
-	return true
+	return session.ID, true
 }
 
 func (ws *Server) authenticateListener(wsSession *websocketSession, workspaceID, token string) {
@@ -170,16 +413,33 @@ func (ws *Server) authenticateListener(wsSession *websocketSession, workspaceID,
 		return
 	}
 
-	// Authenticate session
-	isValidSession := ws.isValidSessionToken(token, workspaceID)
+	// Authenticate session, in the header auth mode by the header of the
+	// upgrade request only, like the API does
+	var sessionID string
+	var isValidSession bool
+	if ws.isHeaderAuth() {
+		if wsSession.headerSession != nil {
+			sessionID, isValidSession = ws.isValidSession(wsSession.headerSession, workspaceID)
+		}
+	} else {
+		sessionID, isValidSession = ws.isValidSessionToken(token, workspaceID, wsSession.deviceFingerprint)
+	}
 	if !isValidSession {
+		ws.sendClose(wsSession.client, closeUnauthorized)
 		wsSession.client.Close()
 		return
 	}
 
+	if sessionID != "" {
+		ws.mu.Lock()
+		ws.sessions[sessionID] = append(ws.sessions[sessionID], wsSession.client)
+		ws.mu.Unlock()
+	}
+
 	// Authenticated
 
 	wsSession.workspaceID = workspaceID
+	wsSession.sessionID = sessionID
 	wsSession.isAuthenticated = true
 	log.Printf("authenticateListener: Authenticated, workspaceID: %s", workspaceID)
 }
@@ -227,6 +487,9 @@ func (ws *Server) addListener(wsSession *websocketSession, command *WebsocketCom
 		sendError(wsSession.client, "not authenticated")
 		return
 	}
+	if !wsSession.isAuthenticated {
+		wsSession.hasReadAccess = true
+	}
 
 	ws.mu.Lock()
 	for _, blockID := range command.BlockIDs {
@@ -257,6 +520,27 @@ func (ws *Server) removeListener(client *websocket.Conn) {
 	ws.mu.Unlock()
 }
 
+// removeSessionClient stops tracking a closed client of a session.
+func (ws *Server) removeSessionClient(sessionID string, client *websocket.Conn) {
+	if sessionID == "" {
+		return
+	}
+
+	ws.mu.Lock()
+	clients := []*websocket.Conn{}
+	for _, existingClient := range ws.sessions[sessionID] {
+		if client != existingClient {
+			clients = append(clients, existingClient)
+		}
+	}
+	if len(clients) == 0 {
+		delete(ws.sessions, sessionID)
+	} else {
+		ws.sessions[sessionID] = clients
+	}
+	ws.mu.Unlock()
+}
+
 // removeListenerFromBlocks removes a webSocket listener from a set of block.
 func (ws *Server) removeListenerFromBlocks(wsSession *websocketSession, command *WebsocketCommand) {
 	workspaceID, err := ws.getAuthenticatedWorkspaceID(wsSession, command)
@@ -311,12 +595,14 @@ func (ws *Server) getListeners(workspaceID string, blockID string) []*websocket.
 	return listeners
 }
 
-// BroadcastBlockDelete broadcasts delete messages to clients
-func (ws *Server) BroadcastBlockDelete(workspaceID, blockID, parentID string) {
+// BroadcastBlockDelete broadcasts delete messages to clients. The root ID
+// puts the delete in the coalesced changes of its board.
+func (ws *Server) BroadcastBlockDelete(workspaceID, blockID, parentID, rootID string) {
 	now := time.Now().Unix()
 	block := model.Block{}
 	block.ID = blockID
 	block.ParentID = parentID
+	block.RootID = rootID
 	block.UpdateAt = now
 	block.DeleteAt = now
 
@@ -325,6 +611,15 @@ func (ws *Server) BroadcastBlockDelete(workspaceID, blockID, parentID string) {
 
 // BroadcastBlockChange broadcasts update messages to clients
 func (ws *Server) BroadcastBlockChange(workspaceID string, block model.Block) {
+	if ws.CoalesceWindow > 0 {
+		ws.coalesce(workspaceID, []model.Block{block})
+		return
+	}
+
+	ws.broadcastBlockChange(workspaceID, block)
+}
+
+func (ws *Server) broadcastBlockChange(workspaceID string, block model.Block) {
 	blockIDsToNotify := []string{block.ID, block.ParentID}
 
 	for _, blockID := range blockIDsToNotify {
@@ -340,12 +635,129 @@ func (ws *Server) BroadcastBlockChange(workspaceID string, block model.Block) {
 			for _, listener := range listeners {
 				log.Printf("Broadcast change, workspaceID: %s, blockID: %s, remoteAddr: %s", workspaceID, blockID, listener.RemoteAddr())
 
-				err := listener.WriteJSON(message)
-				if err != nil {
-					log.Printf("broadcast error: %v", err)
-					listener.Close()
+				ws.writeBroadcast(listener, message)
+			}
+		}
+	}
+}
+
+// BroadcastBlockChanges broadcasts a single batched update message to each
+// client listening to any of the blocks or their parents
+func (ws *Server) BroadcastBlockChanges(workspaceID string, blocks []model.Block) {
+	if ws.CoalesceWindow > 0 {
+		ws.coalesce(workspaceID, blocks)
+		return
+	}
+
+	ws.broadcastBlockChanges(workspaceID, blocks)
+}
+
+// coalesce holds the changes until the coalesce window of their board ends,
+// replacing the earlier changes to the same blocks
+func (ws *Server) coalesce(workspaceID string, blocks []model.Block) {
+	ws.pendingMu.Lock()
+	defer ws.pendingMu.Unlock()
+
+	for _, block := range blocks {
+		boardID := block.RootID
+		if boardID == "" {
+			boardID = block.ID
+		}
+
+		key := makeItemID(workspaceID, boardID)
+		pending, ok := ws.pending[key]
+		if !ok {
+			pending = &pendingBroadcast{workspaceID: workspaceID, indexes: map[string]int{}}
+			pending.timer = time.AfterFunc(ws.CoalesceWindow, func() { ws.flush(key) })
+			ws.pending[key] = pending
+		}
+
+		if i, ok := pending.indexes[block.ID]; ok {
+			pending.blocks[i] = block
+			continue
+		}
+		pending.indexes[block.ID] = len(pending.blocks)
+		pending.blocks = append(pending.blocks, block)
+	}
+}
+
+// flush broadcasts the changes to a board held during its coalesce window
+func (ws *Server) flush(key string) {
+	ws.pendingMu.Lock()
+	pending, ok := ws.pending[key]
+	delete(ws.pending, key)
+	ws.pendingMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// A single change keeps the message clients got before coalescing
+	if len(pending.blocks) == 1 {
+		ws.broadcastBlockChange(pending.workspaceID, pending.blocks[0])
+		return
+	}
+
+	ws.broadcastBlockChanges(pending.workspaceID, pending.blocks)
+}
+
+func (ws *Server) broadcastBlockChanges(workspaceID string, blocks []model.Block) {
+	var listeners []*websocket.Conn
+	blocksByListener := make(map[*websocket.Conn][]model.Block)
+
+	for _, block := range blocks {
+		notified := make(map[*websocket.Conn]bool)
+
+		for _, blockID := range []string{block.ID, block.ParentID} {
+			for _, listener := range ws.getListeners(workspaceID, blockID) {
+				if notified[listener] {
+					continue
 				}
+				notified[listener] = true
+
+				if _, ok := blocksByListener[listener]; !ok {
+					listeners = append(listeners, listener)
+				}
+				blocksByListener[listener] = append(blocksByListener[listener], block)
 			}
 		}
 	}
+
+	for _, listener := range listeners {
+		message := UpdateBlocksMsg{
+			Action: "UPDATE_BLOCKS",
+			Blocks: blocksByListener[listener],
+		}
+
+		log.Printf("Broadcast %d change(s), workspaceID: %s, remoteAddr: %s", len(message.Blocks), workspaceID, listener.RemoteAddr())
+
+		ws.writeBroadcast(listener, message)
+	}
+}
+
+// writeBroadcast sends a broadcast message to a listener. The listeners the
+// message can't be written to are closed and removed from the blocks right
+// away, so the broadcast goes on with the rest of them and the next ones
+// don't wait for the connection to be torn down.
+func (ws *Server) writeBroadcast(listener *websocket.Conn, message interface{}) {
+	if ws.WriteTimeout > 0 {
+		listener.SetWriteDeadline(time.Now().Add(ws.WriteTimeout))
+		defer listener.SetWriteDeadline(time.Time{})
+	}
+
+	err := listener.WriteJSON(message)
+	if err == nil {
+		return
+	}
+
+	atomic.AddInt64(&ws.broadcastFailures, 1)
+	log.Printf("broadcast error, remoteAddr: %s, err: %v", listener.RemoteAddr(), err)
+	ws.removeListener(listener)
+	listener.Close()
+}
+
+// BroadcastFailures returns the number of broadcast messages that couldn't be
+// written to a client since the server started
+func (ws *Server) BroadcastFailures() int64 {
+	return atomic.LoadInt64(&ws.broadcastFailures)
 }
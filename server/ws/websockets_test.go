@@ -0,0 +1,380 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/mattermost/focalboard/server/auth"
+	"github.com/mattermost/focalboard/server/model"
+	"github.com/mattermost/focalboard/server/services/config"
+	"github.com/mattermost/focalboard/server/services/store/mockstore"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestServer(t *testing.T, ws *Server) (string, func()) {
+	r := mux.NewRouter()
+	ws.RegisterRoutes(r)
+	httpServer := httptest.NewServer(r)
+
+	return "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws/onchange", httpServer.Close
+}
+
+func TestAuthTimeout(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+	ws.AuthTimeout = 50 * time.Millisecond
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	t.Run("unauthenticated connection is closed", func(t *testing.T) {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, err = client.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, CloseAuthTimeout), err)
+		require.Contains(t, err.Error(), "auth timeout")
+	})
+
+	t.Run("authenticated connection stays open", func(t *testing.T) {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "single-user-token"})
+		require.NoError(t, err)
+		err = client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: []string{"block-id"}})
+		require.NoError(t, err)
+
+		time.Sleep(2 * ws.AuthTimeout)
+		ws.BroadcastBlockChange("0", model.Block{ID: "block-id"})
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		var message UpdateMsg
+		err = client.ReadJSON(&message)
+		require.NoError(t, err)
+		require.Equal(t, "block-id", message.Block.ID)
+	})
+}
+
+func TestExpireSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	ws := NewServer(auth.New(&config.Configuration{SessionExpireTime: 60, SessionRefreshTime: 60}, store), "")
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	store.EXPECT().GetSession("session-token", int64(60)).Return(&model.Session{ID: "session-id", UpdateAt: time.Now().Unix()}, nil)
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "session-token"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		ws.mu.RLock()
+		defer ws.mu.RUnlock()
+		return len(ws.sessions["session-id"]) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	ws.ExpireSessions([]string{"other-session-id", "session-id"})
+
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	var message SessionExpiredMsg
+	err = client.ReadJSON(&message)
+	require.NoError(t, err)
+	require.Equal(t, "SESSION_EXPIRED", message.Action)
+
+	_, _, err = client.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, CloseSessionExpired), err)
+
+	var payload ClosePayload
+	require.NoError(t, json.Unmarshal([]byte(err.(*websocket.CloseError).Text), &payload))
+	require.Equal(t, ClosePayload{Reason: "sessionExpired", Message: "session expired", ReconnectDelay: 0}, payload)
+}
+
+type testHeaderAuthenticator struct{}
+
+func (testHeaderAuthenticator) HeaderSession(r *http.Request) (*model.Session, error) {
+	switch username := r.Header.Get("X-Auth-User"); username {
+	case "":
+		return nil, nil
+	case "untrusted":
+		return nil, errors.New("authentication header received from an untrusted source")
+	default:
+		return &model.Session{ID: "header-" + username, UserID: username}, nil
+	}
+}
+
+func TestHeaderAuth(t *testing.T) {
+	ws := NewServer(nil, "")
+	ws.HeaderAuthenticator = testHeaderAuthenticator{}
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	t.Run("authenticated by the header", func(t *testing.T) {
+		client, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Auth-User": {"jane"}})
+		require.NoError(t, err)
+		defer client.Close()
+
+		err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0"})
+		require.NoError(t, err)
+		err = client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: []string{"block-id"}})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(ws.getListeners("0", "block-id")) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		ws.BroadcastBlockChange("0", model.Block{ID: "block-id"})
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		var message UpdateMsg
+		err = client.ReadJSON(&message)
+		require.NoError(t, err)
+		require.Equal(t, "block-id", message.Block.ID)
+	})
+
+	t.Run("untrusted source", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Auth-User": {"untrusted"}})
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("a token doesn't replace the header", func(t *testing.T) {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "session-token"})
+		require.NoError(t, err)
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, err = client.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, CloseUnauthorized), err)
+	})
+}
+
+func TestRefreshDeniedExpiresSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockstore.NewMockStore(ctrl)
+	auth := auth.New(&config.Configuration{SessionExpireTime: 60 * 60, SessionRefreshTime: 60, SessionDeviceBinding: true}, store)
+	ws := NewServer(auth, "")
+	auth.SessionDenied = ws.ExpireSessions
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	session := model.Session{ID: "session-id", UpdateAt: time.Now().Unix(), DeviceFingerprint: "device-fingerprint"}
+	store.EXPECT().GetSession("session-token", int64(60*60)).Return(&session, nil)
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "session-token"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		ws.mu.RLock()
+		defer ws.mu.RUnlock()
+		return len(ws.sessions["session-id"]) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The session is due for a refresh, from a device other than its own
+	stale := session
+	stale.UpdateAt -= 2 * 60
+	store.EXPECT().GetSession("session-token", int64(60*60)).Return(&stale, nil)
+
+	other, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer other.Close()
+
+	err = other.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "session-token"})
+	require.NoError(t, err)
+
+	other.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = other.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, CloseUnauthorized), err)
+
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	var message SessionExpiredMsg
+	err = client.ReadJSON(&message)
+	require.NoError(t, err)
+	require.Equal(t, "SESSION_EXPIRED", message.Action)
+
+	_, _, err = client.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, CloseSessionExpired), err)
+}
+
+func TestCoalesceBroadcasts(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+	ws.CoalesceWindow = 100 * time.Millisecond
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "single-user-token"})
+	require.NoError(t, err)
+	err = client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: []string{"board-id"}})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(ws.getListeners("0", "board-id")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	for i := 1; i <= 5; i++ {
+		ws.BroadcastBlockChange("0", model.Block{ID: "card-1", ParentID: "board-id", RootID: "board-id", UpdateAt: int64(i)})
+	}
+	ws.BroadcastBlockChanges("0", []model.Block{
+		{ID: "card-2", ParentID: "board-id", RootID: "board-id", UpdateAt: 6},
+		{ID: "card-1", ParentID: "board-id", RootID: "board-id", UpdateAt: 7},
+	})
+	ws.BroadcastBlockDelete("0", "card-3", "board-id", "board-id")
+
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	var message UpdateBlocksMsg
+	err = client.ReadJSON(&message)
+	require.NoError(t, err)
+	require.Equal(t, "UPDATE_BLOCKS", message.Action)
+	require.Len(t, message.Blocks, 3)
+	require.Equal(t, "card-1", message.Blocks[0].ID)
+	require.EqualValues(t, 7, message.Blocks[0].UpdateAt)
+	require.Equal(t, "card-2", message.Blocks[1].ID)
+
+	// The delete is in the batch of its board
+	require.Equal(t, "card-3", message.Blocks[2].ID)
+	require.NotZero(t, message.Blocks[2].DeleteAt)
+
+	// Nothing else was sent for the same changes
+	client.SetReadDeadline(time.Now().Add(2 * ws.CoalesceWindow))
+	_, _, err = client.ReadMessage()
+	require.Error(t, err)
+}
+
+func TestSubscriptionCounts(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	subscribe := func(blockIDs ...string) *websocket.Conn {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+
+		err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "single-user-token"})
+		require.NoError(t, err)
+		err = client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: blockIDs})
+		require.NoError(t, err)
+
+		return client
+	}
+
+	first := subscribe("board-1", "board-2")
+	defer first.Close()
+	second := subscribe("board-1")
+	defer second.Close()
+	third := subscribe("board-1", "board-1")
+
+	require.Eventually(t, func() bool {
+		counts := ws.SubscriptionCounts()
+		return counts["board-1"] == 3 && counts["board-2"] == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, ws.SubscriptionCounts(), 2)
+
+	third.Close()
+	require.Eventually(t, func() bool {
+		return ws.SubscriptionCounts()["board-1"] == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBroadcastWriteFailure(t *testing.T) {
+	ws := NewServer(nil, "single-user-token")
+
+	url, tearDown := setupTestServer(t, ws)
+	defer tearDown()
+
+	subscribe := func() *websocket.Conn {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+
+		err = client.WriteJSON(WebsocketCommand{Action: "AUTH", WorkspaceID: "0", Token: "single-user-token"})
+		require.NoError(t, err)
+		err = client.WriteJSON(WebsocketCommand{Action: "ADD", BlockIDs: []string{"board-1"}})
+		require.NoError(t, err)
+
+		return client
+	}
+
+	clients := []*websocket.Conn{subscribe(), subscribe(), subscribe()}
+	for _, client := range clients {
+		defer client.Close()
+	}
+	require.Eventually(t, func() bool {
+		return ws.SubscriptionCounts()["board-1"] == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// Writing to the server side of the second client times out, without
+	// breaking its reads
+	failed := clients[1]
+	for _, listener := range ws.getListeners("0", "board-1") {
+		if listener.RemoteAddr().String() == failed.LocalAddr().String() {
+			listener.SetWriteDeadline(time.Now().Add(-time.Second))
+		}
+	}
+
+	receive := func(client *websocket.Conn, message interface{}) error {
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		return client.ReadJSON(message)
+	}
+
+	t.Run("single change", func(t *testing.T) {
+		ws.BroadcastBlockChange("0", model.Block{ID: "card-1", ParentID: "board-1"})
+
+		for _, client := range []*websocket.Conn{clients[0], clients[2]} {
+			var message UpdateMsg
+			require.NoError(t, receive(client, &message))
+			require.Equal(t, "card-1", message.Block.ID)
+		}
+		require.EqualValues(t, 1, ws.BroadcastFailures())
+		require.Equal(t, 2, ws.SubscriptionCounts()["board-1"])
+
+		var message UpdateMsg
+		require.Error(t, receive(failed, &message))
+	})
+
+	t.Run("the failed client isn't written to again", func(t *testing.T) {
+		ws.BroadcastBlockChanges("0", []model.Block{
+			{ID: "card-1", ParentID: "board-1"},
+			{ID: "card-2", ParentID: "board-1"},
+		})
+
+		for _, client := range []*websocket.Conn{clients[0], clients[2]} {
+			var message UpdateBlocksMsg
+			require.NoError(t, receive(client, &message))
+			require.Len(t, message.Blocks, 2)
+		}
+		require.EqualValues(t, 1, ws.BroadcastFailures())
+	})
+}